@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"net"
 	"reflect"
-	"time"
+	"strings"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -12,13 +12,23 @@ import (
 	"github.com/oschwald/geoip2-golang"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
 )
 
 const (
-	ErrEmptyGeoIPStageConfig       = "geoip stage config cannot be empty"
-	ErrEmptyDBPathGeoIPStageConfig = "db path cannot be empty"
-	ErrEmptySourceGeoIPStageConfig = "source cannot be empty"
-	ErrEmptyDBTypeGeoIPStageConfig = "db type should be either city or asn"
+	ErrEmptyGeoIPStageConfig         = "geoip stage config cannot be empty"
+	ErrEmptyDBPathGeoIPStageConfig   = "db path cannot be empty"
+	ErrEmptySourceGeoIPStageConfig   = "source cannot be empty"
+	ErrEmptyDBTypeGeoIPStageConfig   = "db type should be either city or asn"
+	ErrInvalidTargetGeoIPStageConfig = "target should be either labels or structured_metadata"
+	ErrInvalidFieldGeoIPStageConfig  = "field %s is invalid for the %s db type, fields must be some of %v"
+)
+
+// The targets the geoip data can be written to.
+const (
+	GeoIPTargetLabels             = "labels"
+	GeoIPTargetStructuredMetadata = "structured_metadata"
 )
 
 type GeoIPFields int
@@ -33,6 +43,9 @@ const (
 	TIMEZONE
 	SUBDIVISIONNAME
 	SUBDIVISIONCODE
+	COUNTRYCODE
+	AUTONOMOUSSYSTEMNUMBER
+	AUTONOMOUSSYSTEMORGANIZATION
 )
 
 var fields = map[GeoIPFields]string{
@@ -45,6 +58,24 @@ var fields = map[GeoIPFields]string{
 	TIMEZONE:        "geoip_timezone",
 	SUBDIVISIONNAME: "geoip_subdivision_name",
 	SUBDIVISIONCODE: "geoip_subdivision_code",
+	COUNTRYCODE:     "geoip_country_code",
+
+	AUTONOMOUSSYSTEMNUMBER:       "geoip_autonomous_system_number",
+	AUTONOMOUSSYSTEMORGANIZATION: "geoip_autonomous_system_organization",
+}
+
+// The fields of each db type, in the order they are written. The country
+// code is only written when selected, so that the labels written by default
+// are unchanged.
+var (
+	cityFields        = []GeoIPFields{CITYNAME, COUNTRYNAME, COUNTRYCODE, CONTINENTNAME, CONTINENTCODE, LOCATION, POSTALCODE, TIMEZONE, SUBDIVISIONNAME, SUBDIVISIONCODE}
+	defaultCityFields = []GeoIPFields{CITYNAME, COUNTRYNAME, CONTINENTNAME, CONTINENTCODE, LOCATION, POSTALCODE, TIMEZONE, SUBDIVISIONNAME, SUBDIVISIONCODE}
+	asnFields         = []GeoIPFields{AUTONOMOUSSYSTEMNUMBER, AUTONOMOUSSYSTEMORGANIZATION}
+)
+
+// fieldName returns the name the field is selected by in the config.
+func fieldName(field GeoIPFields) string {
+	return strings.TrimPrefix(fields[field], "geoip_")
 }
 
 // GeoIPConfig represents GeoIP stage config
@@ -52,6 +83,12 @@ type GeoIPConfig struct {
 	DB     string  `mapstructure:"db"`
 	Source *string `mapstructure:"source"`
 	DBType string  `mapstructure:"db_type"`
+	// Target is where the geoip data is written, labels by default.
+	Target string `mapstructure:"target"`
+	// Fields selects the fields written, by their name without the geoip_
+	// prefix.
+	Fields   []string `mapstructure:"fields"`
+	selected []GeoIPFields
 }
 
 func validateGeoIPConfig(c *GeoIPConfig) error {
@@ -67,10 +104,42 @@ func validateGeoIPConfig(c *GeoIPConfig) error {
 		return errors.New(ErrEmptySourceGeoIPStageConfig)
 	}
 
-	if c.DBType == "" {
+	var available, defaults []GeoIPFields
+	switch c.DBType {
+	case "city":
+		available, defaults = cityFields, defaultCityFields
+	case "asn":
+		available, defaults = asnFields, asnFields
+	default:
 		return errors.New(ErrEmptyDBTypeGeoIPStageConfig)
 	}
 
+	switch c.Target {
+	case "":
+		c.Target = GeoIPTargetLabels
+	case GeoIPTargetLabels, GeoIPTargetStructuredMetadata:
+	default:
+		return errors.New(ErrInvalidTargetGeoIPStageConfig)
+	}
+
+	c.selected = defaults
+	if len(c.Fields) > 0 {
+		names := make([]string, 0, len(available))
+		byName := make(map[string]GeoIPFields, len(available))
+		for _, field := range available {
+			names = append(names, fieldName(field))
+			byName[fieldName(field)] = field
+		}
+		c.selected = make([]GeoIPFields, 0, len(c.Fields))
+		for _, name := range c.Fields {
+			field, ok := byName[name]
+			if !ok {
+				return errors.Errorf(ErrInvalidFieldGeoIPStageConfig, name, c.DBType, names)
+			}
+			c.selected = append(c.selected, field)
+		}
+	}
+
 	return nil
 }
 
@@ -111,7 +180,7 @@ func (g *geoIPStage) Run(in chan Entry) chan Entry {
 		defer close(out)
 		defer g.close()
 		for e := range in {
-			g.process(e.Labels, e.Extracted, &e.Timestamp, &e.Entry.Line)
+			g.process(&e)
 			out <- e
 		}
 	}()
@@ -128,7 +197,8 @@ func (*geoIPStage) Cleanup() {
 	// no-op
 }
 
-func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interface{}, _ *time.Time, _ *string) {
+func (g *geoIPStage) process(e *Entry) {
+	extracted := e.Extracted
 	var ip net.IP
 	if g.cfgs.Source != nil {
 		if _, ok := extracted[*g.cfgs.Source]; !ok {
@@ -154,14 +224,14 @@ func (g *geoIPStage) process(labels model.LabelSet, extracted map[string]interfa
 			level.Error(g.logger).Log("msg", "unable to get City record for the ip", "err", err, "ip", ip)
 			return
 		}
-		g.populateLabelsWithCityData(labels, record)
+		g.write(e, g.cityData(record))
 	case "asn":
 		record, err := g.db.ASN(ip)
 		if err != nil {
 			level.Error(g.logger).Log("msg", "unable to get ASN record for the ip", "err", err, "ip", ip)
 			return
 		}
-		g.populateLabelsWithASNData(labels, record)
+		g.write(e, g.asnData(record))
 	default:
 		level.Error(g.logger).Log("msg", "unknown database type")
 	}
@@ -173,74 +243,82 @@ func (g *geoIPStage) close() {
 	}
 }
 
-func (g *geoIPStage) populateLabelsWithCityData(labels model.LabelSet, record *geoip2.City) {
-	for field, label := range fields {
+// write writes the geoip data to the target.
+func (g *geoIPStage) write(e *Entry, data []logproto.LabelAdapter) {
+	for _, d := range data {
+		switch g.cfgs.Target {
+		case GeoIPTargetStructuredMetadata:
+			e.StructuredMetadata = append(e.StructuredMetadata, d)
+		default:
+			e.Labels[model.LabelName(d.Name)] = model.LabelValue(d.Value)
+		}
+	}
+}
+
+// cityData returns the non-empty values of the selected fields of the record.
+func (g *geoIPStage) cityData(record *geoip2.City) []logproto.LabelAdapter {
+	data := make([]logproto.LabelAdapter, 0, len(g.cfgs.selected)+1)
+	add := func(name, value string) {
+		if value != "" {
+			data = append(data, logproto.LabelAdapter{Name: name, Value: value})
+		}
+	}
+	for _, field := range g.cfgs.selected {
+		label := fields[field]
 		switch field {
 		case CITYNAME:
-			cityName := record.City.Names["en"]
-			if cityName != "" {
-				labels[model.LabelName(label)] = model.LabelValue(cityName)
-			}
+			add(label, record.City.Names["en"])
 		case COUNTRYNAME:
-			contryName := record.Country.Names["en"]
-			if contryName != "" {
-				labels[model.LabelName(label)] = model.LabelValue(contryName)
-			}
+			add(label, record.Country.Names["en"])
+		case COUNTRYCODE:
+			add(label, record.Country.IsoCode)
 		case CONTINENTNAME:
-			continentName := record.Continent.Names["en"]
-			if continentName != "" {
-				labels[model.LabelName(label)] = model.LabelValue(continentName)
-			}
+			add(label, record.Continent.Names["en"])
 		case CONTINENTCODE:
-			continentCode := record.Continent.Code
-			if continentCode != "" {
-				labels[model.LabelName(label)] = model.LabelValue(continentCode)
-			}
+			add(label, record.Continent.Code)
 		case POSTALCODE:
-			postalCode := record.Postal.Code
-			if postalCode != "" {
-				labels[model.LabelName(label)] = model.LabelValue(postalCode)
-			}
+			add(label, record.Postal.Code)
 		case TIMEZONE:
-			timezone := record.Location.TimeZone
-			if timezone != "" {
-				labels[model.LabelName(label)] = model.LabelValue(timezone)
-			}
+			add(label, record.Location.TimeZone)
 		case LOCATION:
 			latitude := record.Location.Latitude
 			longitude := record.Location.Longitude
 			if latitude != 0 || longitude != 0 {
-				labels[model.LabelName(fmt.Sprintf("%s_latitude", label))] = model.LabelValue(fmt.Sprint(latitude))
-				labels[model.LabelName(fmt.Sprintf("%s_longitude", label))] = model.LabelValue(fmt.Sprint(longitude))
+				add(fmt.Sprintf("%s_latitude", label), fmt.Sprint(latitude))
+				add(fmt.Sprintf("%s_longitude", label), fmt.Sprint(longitude))
 			}
 		case SUBDIVISIONNAME:
 			if len(record.Subdivisions) > 0 {
 				// we get most specific subdivision https://dev.maxmind.com/release-note/most-specific-subdivision-attribute-added/
-				subdivisionName := record.Subdivisions[len(record.Subdivisions)-1].Names["en"]
-				if subdivisionName != "" {
-					labels[model.LabelName(label)] = model.LabelValue(subdivisionName)
-				}
+				add(label, record.Subdivisions[len(record.Subdivisions)-1].Names["en"])
 			}
 		case SUBDIVISIONCODE:
 			if len(record.Subdivisions) > 0 {
-				subdivisionCode := record.Subdivisions[len(record.Subdivisions)-1].IsoCode
-				if subdivisionCode != "" {
-					labels[model.LabelName(label)] = model.LabelValue(subdivisionCode)
-				}
+				add(label, record.Subdivisions[len(record.Subdivisions)-1].IsoCode)
 			}
 		default:
 			level.Error(g.logger).Log("msg", "unknown geoip field")
 		}
 	}
+	return data
 }
 
-func (g *geoIPStage) populateLabelsWithASNData(labels model.LabelSet, record *geoip2.ASN) {
-	autonomousSystemNumber := record.AutonomousSystemNumber
-	autonomousSystemOrganization := record.AutonomousSystemOrganization
-	if autonomousSystemNumber != 0 {
-		labels[model.LabelName("geoip_autonomous_system_number")] = model.LabelValue(fmt.Sprint(autonomousSystemNumber))
-	}
-	if autonomousSystemOrganization != "" {
-		labels[model.LabelName("geoip_autonomous_system_organization")] = model.LabelValue(autonomousSystemOrganization)
+// asnData returns the non-empty values of the selected fields of the record.
+func (g *geoIPStage) asnData(record *geoip2.ASN) []logproto.LabelAdapter {
+	data := make([]logproto.LabelAdapter, 0, len(g.cfgs.selected))
+	for _, field := range g.cfgs.selected {
+		switch field {
+		case AUTONOMOUSSYSTEMNUMBER:
+			if record.AutonomousSystemNumber != 0 {
+				data = append(data, logproto.LabelAdapter{Name: fields[field], Value: fmt.Sprint(record.AutonomousSystemNumber)})
+			}
+		case AUTONOMOUSSYSTEMORGANIZATION:
+			if record.AutonomousSystemOrganization != "" {
+				data = append(data, logproto.LabelAdapter{Name: fields[field], Value: record.AutonomousSystemOrganization})
+			}
+		default:
+			level.Error(g.logger).Log("msg", "unknown geoip field")
+		}
 	}
+	return data
 }
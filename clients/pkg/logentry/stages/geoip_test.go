@@ -2,9 +2,15 @@ package stages
 
 import (
 	"testing"
+	"time"
 
+	"github.com/oschwald/geoip2-golang"
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
 )
 
 func Test_ValidateConfigs(t *testing.T) {
@@ -42,6 +48,24 @@ func Test_ValidateConfigs(t *testing.T) {
 			},
 			errors.New(ErrEmptyDBTypeGeoIPStageConfig),
 		},
+		{
+			GeoIPConfig{
+				DB:     "test",
+				Source: &source,
+				DBType: "city",
+				Target: "extracted",
+			},
+			errors.New(ErrInvalidTargetGeoIPStageConfig),
+		},
+		{
+			GeoIPConfig{
+				DB:     "test",
+				Source: &source,
+				DBType: "asn",
+				Fields: []string{"country_code"},
+			},
+			errors.New("field country_code is invalid for the asn db type, fields must be some of [autonomous_system_number autonomous_system_organization]"),
+		},
 	}
 	for _, tt := range tests {
 		err := validateGeoIPConfig(&tt.config)
@@ -53,3 +77,68 @@ func Test_ValidateConfigs(t *testing.T) {
 		}
 	}
 }
+
+func Test_GeoIPCityData(t *testing.T) {
+	var record geoip2.City
+	record.City.Names = map[string]string{"en": "Paris"}
+	record.Country.Names = map[string]string{"en": "France"}
+	record.Country.IsoCode = "FR"
+	record.Continent.Code = "EU"
+	record.Location.Latitude = 48.8566
+	record.Location.Longitude = 2.3522
+
+	source := "ip"
+	for _, tt := range []struct {
+		name                       string
+		config                     GeoIPConfig
+		labels                     model.LabelSet
+		expectedStructuredMetadata []logproto.LabelAdapter
+	}{
+		{
+			name:   "all fields to labels by default",
+			config: GeoIPConfig{DB: "test", Source: &source, DBType: "city"},
+			labels: model.LabelSet{
+				"job":                      "nginx",
+				"geoip_city_name":          "Paris",
+				"geoip_country_name":       "France",
+				"geoip_continent_code":     "EU",
+				"geoip_location_latitude":  "48.8566",
+				"geoip_location_longitude": "2.3522",
+			},
+		},
+		{
+			name:   "selected fields to structured metadata",
+			config: GeoIPConfig{DB: "test", Source: &source, DBType: "city", Target: GeoIPTargetStructuredMetadata, Fields: []string{"country_code", "city_name", "postal_code"}},
+			labels: model.LabelSet{"job": "nginx"},
+			expectedStructuredMetadata: []logproto.LabelAdapter{
+				{Name: "geoip_country_code", Value: "FR"},
+				{Name: "geoip_city_name", Value: "Paris"},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, validateGeoIPConfig(&tt.config))
+			g := &geoIPStage{logger: util_log.Logger, cfgs: &tt.config}
+
+			e := newEntry(nil, model.LabelSet{"job": "nginx"}, "", time.Now())
+			g.write(&e, g.cityData(&record))
+			require.Equal(t, tt.labels, e.Labels)
+			require.Equal(t, tt.expectedStructuredMetadata, []logproto.LabelAdapter(e.StructuredMetadata))
+		})
+	}
+}
+
+func Test_GeoIPASNData(t *testing.T) {
+	source := "ip"
+	cfg := GeoIPConfig{DB: "test", Source: &source, DBType: "asn", Target: GeoIPTargetStructuredMetadata}
+	require.NoError(t, validateGeoIPConfig(&cfg))
+	g := &geoIPStage{logger: util_log.Logger, cfgs: &cfg}
+
+	e := newEntry(nil, model.LabelSet{}, "", time.Now())
+	g.write(&e, g.asnData(&geoip2.ASN{AutonomousSystemNumber: 15169, AutonomousSystemOrganization: "GOOGLE"}))
+	require.Equal(t, model.LabelSet{}, e.Labels)
+	require.Equal(t, []logproto.LabelAdapter{
+		{Name: "geoip_autonomous_system_number", Value: "15169"},
+		{Name: "geoip_autonomous_system_organization", Value: "GOOGLE"},
+	}, []logproto.LabelAdapter(e.StructuredMetadata))
+}
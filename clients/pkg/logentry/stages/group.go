@@ -0,0 +1,298 @@
+package stages
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	ErrGroupStageEmptySource        = "group stage config must define the `source` to group the lines by"
+	ErrGroupStageInvalidRegex       = "group stage first line regex compilation error: %v"
+	ErrGroupStageInvalidMaxWaitTime = "group stage `max_wait_time` parse error: %v"
+	ErrGroupStageInvalidLimit       = "group stage `max_lines` and `max_groups` must be greater than 0"
+)
+
+const (
+	maxGroupsDefault = 1000
+
+	groupFlushFirstLine = "firstline"
+	groupFlushMaxLines  = "max_lines"
+	groupFlushTimeout   = "timeout"
+	groupFlushMaxGroups = "max_groups"
+	groupFlushShutdown  = "shutdown"
+)
+
+// GroupConfig contains the configuration for a groupStage
+type GroupConfig struct {
+	Source      *string `mapstructure:"source"`
+	Expression  *string `mapstructure:"firstline"`
+	regex       *regexp.Regexp
+	Separator   *string `mapstructure:"separator"`
+	MaxLines    *uint64 `mapstructure:"max_lines"`
+	MaxGroups   *int    `mapstructure:"max_groups"`
+	MaxWaitTime *string `mapstructure:"max_wait_time"`
+	maxWait     time.Duration
+}
+
+func validateGroupConfig(cfg *GroupConfig) error {
+	if cfg == nil || cfg.Source == nil || *cfg.Source == "" {
+		return errors.New(ErrGroupStageEmptySource)
+	}
+
+	if cfg.Expression != nil {
+		expr, err := regexp.Compile(*cfg.Expression)
+		if err != nil {
+			return errors.Errorf(ErrGroupStageInvalidRegex, err)
+		}
+		cfg.regex = expr
+	}
+
+	if cfg.MaxWaitTime != nil {
+		maxWait, err := time.ParseDuration(*cfg.MaxWaitTime)
+		if err != nil {
+			return errors.Errorf(ErrGroupStageInvalidMaxWaitTime, err)
+		}
+		cfg.maxWait = maxWait
+	} else {
+		cfg.maxWait = maxWaitDefault
+	}
+
+	if cfg.Separator == nil {
+		cfg.Separator = new(string)
+		*cfg.Separator = "\n"
+	}
+	if cfg.MaxLines == nil {
+		cfg.MaxLines = new(uint64)
+		*cfg.MaxLines = maxLineDefault
+	}
+	if cfg.MaxGroups == nil {
+		cfg.MaxGroups = new(int)
+		*cfg.MaxGroups = maxGroupsDefault
+	}
+	if *cfg.MaxLines == 0 || *cfg.MaxGroups <= 0 {
+		return errors.New(ErrGroupStageInvalidLimit)
+	}
+
+	return nil
+}
+
+// groupKey identifies a group by its stream and the value of the source.
+type groupKey struct {
+	stream model.Fingerprint
+	value  string
+}
+
+// group holds the lines of an event being grouped.
+type group struct {
+	key        groupKey
+	firstEntry Entry // The entry of the first line, used to set the timestamp and labels on flush.
+	lines      []string
+	lastUpdate time.Time
+}
+
+// groupStage groups the lines of each stream by the value of an extracted
+// key, so that the lines of events written by concurrent threads can be
+// joined even when they are interleaved.
+type groupStage struct {
+	logger  log.Logger
+	cfg     *GroupConfig
+	metrics *groupMetrics
+}
+
+// groupState captures the internal state of a running group stage.
+type groupState struct {
+	groups map[groupKey]*list.Element
+	// lru orders the groups from the least recently updated.
+	lru *list.List
+}
+
+// newGroupStage creates a groupStage from config
+func newGroupStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &GroupConfig{}
+	err := mapstructure.WeakDecode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = validateGroupConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &groupStage{
+		logger:  log.With(logger, "component", "stage", "type", "group"),
+		cfg:     cfg,
+		metrics: getGroupMetrics(registerer),
+	}, nil
+}
+
+func (g *groupStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+
+		state := &groupState{
+			groups: make(map[groupKey]*list.Element),
+			lru:    list.New(),
+		}
+
+		// Look for the groups whose max wait time has been reached 10 times
+		// per max wait time, with a cap of 10ms.
+		checkFrequency := g.cfg.maxWait / 10
+		if checkFrequency < 10*time.Millisecond {
+			checkFrequency = 10 * time.Millisecond
+		}
+		ticker := time.NewTicker(checkFrequency)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case e, ok := <-in:
+				if !ok {
+					for state.lru.Len() > 0 {
+						g.flush(out, state, state.lru.Front(), groupFlushShutdown)
+					}
+					return
+				}
+				g.process(out, state, e)
+			case now := <-ticker.C:
+				for el := state.lru.Front(); el != nil && now.Sub(el.Value.(*group).lastUpdate) >= g.cfg.maxWait; el = state.lru.Front() {
+					g.flush(out, state, el, groupFlushTimeout)
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (g *groupStage) process(out chan Entry, state *groupState, e Entry) {
+	value, ok := e.Extracted[*g.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "pass through entry without the source", "source", *g.cfg.Source)
+		}
+		out <- e
+		return
+	}
+	s, err := getString(value)
+	if err != nil {
+		if Debug {
+			level.Debug(g.logger).Log("msg", "failed to convert source value to string", "source", *g.cfg.Source, "err", err)
+		}
+		out <- e
+		return
+	}
+
+	key := groupKey{stream: e.Labels.FastFingerprint(), value: s}
+	el, ok := state.groups[key]
+	if ok && g.cfg.regex != nil && g.cfg.regex.MatchString(e.Line) {
+		g.flush(out, state, el, groupFlushFirstLine)
+		ok = false
+	}
+	if !ok {
+		if state.lru.Len() >= *g.cfg.MaxGroups {
+			if Debug {
+				level.Debug(g.logger).Log("msg", "flush least recently updated group because the max number of groups is reached", "max_groups", *g.cfg.MaxGroups)
+			}
+			g.flush(out, state, state.lru.Front(), groupFlushMaxGroups)
+		}
+		el = state.lru.PushBack(&group{key: key, firstEntry: e})
+		state.groups[key] = el
+		g.metrics.activeGroups.Inc()
+	}
+
+	gr := el.Value.(*group)
+	gr.lines = append(gr.lines, e.Line)
+	gr.lastUpdate = time.Now()
+	state.lru.MoveToBack(el)
+
+	if uint64(len(gr.lines)) >= *g.cfg.MaxLines {
+		g.flush(out, state, el, groupFlushMaxLines)
+	}
+}
+
+func (g *groupStage) flush(out chan Entry, state *groupState, el *list.Element, reason string) {
+	gr := state.lru.Remove(el).(*group)
+	delete(state.groups, gr.key)
+	g.metrics.activeGroups.Dec()
+	g.metrics.flushedGroups.WithLabelValues(reason).Inc()
+	if Debug {
+		level.Debug(g.logger).Log("msg", "flush group", "reason", reason, "key", gr.key.value, "lines", len(gr.lines))
+	}
+
+	// copy extracted data.
+	extracted := make(map[string]interface{}, len(gr.firstEntry.Extracted))
+	for k, v := range gr.firstEntry.Extracted {
+		extracted[k] = v
+	}
+	out <- Entry{
+		Extracted: extracted,
+		Entry: api.Entry{
+			Labels: gr.firstEntry.Labels.Clone(),
+			Entry: logproto.Entry{
+				Timestamp:          gr.firstEntry.Timestamp,
+				Line:               strings.Join(gr.lines, *g.cfg.Separator),
+				StructuredMetadata: gr.firstEntry.StructuredMetadata,
+			},
+		},
+	}
+}
+
+// Name implements Stage
+func (g *groupStage) Name() string {
+	return StageTypeGroup
+}
+
+// Cleanup implements Stage.
+func (*groupStage) Cleanup() {
+	// no-op
+}
+
+type groupMetrics struct {
+	activeGroups  prometheus.Gauge
+	flushedGroups *prometheus.CounterVec
+}
+
+func getGroupMetrics(registerer prometheus.Registerer) *groupMetrics {
+	m := &groupMetrics{
+		activeGroups: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "logentry",
+			Name:      "group_active_groups",
+			Help:      "Number of groups of lines buffered by the group stages.",
+		}),
+		flushedGroups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "logentry",
+			Name:      "group_flushed_groups_total",
+			Help:      "A count of all groups of lines flushed by the group stages, by reason.",
+		}, []string{"reason"}),
+	}
+	if err := registerer.Register(m.activeGroups); err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.activeGroups = existing.ExistingCollector.(prometheus.Gauge)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	if err := registerer.Register(m.flushedGroups); err != nil {
+		if existing, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			m.flushedGroups = existing.ExistingCollector.(*prometheus.CounterVec)
+		} else {
+			// Same behavior as MustRegister if the error is not for AlreadyRegistered
+			panic(err)
+		}
+	}
+	return m
+}
@@ -0,0 +1,127 @@
+package stages
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testGroupPipeline = `
+pipeline_stages:
+- regex:
+    expression: '^\[(?P<thread>[^\]]+)\]'
+- group:
+    source: thread
+    firstline: '^\[[^\]]+\] \d{2}:\d{2}:\d{2}'
+    max_lines: 3
+`
+
+func threadEntry(line string) Entry {
+	return newEntry(nil, model.LabelSet{"job": "java"}, line, time.Now())
+}
+
+func TestGroupStage_InterleavedThreads(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testGroupPipeline), nil, reg)
+	require.NoError(t, err)
+
+	out := processEntries(pl,
+		threadEntry("[main] 10:00:00 ERROR request failed"),
+		threadEntry("[worker-1] 10:00:00 ERROR job failed"),
+		threadEntry("[main] java.lang.IllegalStateException: closed"),
+		threadEntry("[worker-1] java.io.IOException: broken pipe"),
+		threadEntry("[main] 10:00:01 INFO retrying"),
+		threadEntry("line without thread"),
+		threadEntry("[worker-1]   at Job.run(Job.java:42)"),
+		threadEntry("[worker-1]   at Thread.run(Thread.java:833)"),
+	)
+
+	lines := make([]string, 0, len(out))
+	for _, e := range out {
+		lines = append(lines, e.Line)
+	}
+	require.Equal(t, []string{
+		"[main] 10:00:00 ERROR request failed\n[main] java.lang.IllegalStateException: closed",
+		"line without thread",
+		"[worker-1] 10:00:00 ERROR job failed\n[worker-1] java.io.IOException: broken pipe\n[worker-1]   at Job.run(Job.java:42)",
+		// The remaining groups are flushed on shutdown from the least recently updated.
+		"[main] 10:00:01 INFO retrying",
+		"[worker-1]   at Thread.run(Thread.java:833)",
+	}, lines)
+	require.Equal(t, "main", out[0].Extracted["thread"])
+	require.Equal(t, model.LabelSet{"job": "java"}, out[0].Labels)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP logentry_group_active_groups Number of groups of lines buffered by the group stages.
+# TYPE logentry_group_active_groups gauge
+logentry_group_active_groups 0
+# HELP logentry_group_flushed_groups_total A count of all groups of lines flushed by the group stages, by reason.
+# TYPE logentry_group_flushed_groups_total counter
+logentry_group_flushed_groups_total{reason="firstline"} 1
+logentry_group_flushed_groups_total{reason="max_lines"} 1
+logentry_group_flushed_groups_total{reason="shutdown"} 2
+`), "logentry_group_active_groups", "logentry_group_flushed_groups_total"))
+}
+
+func TestGroupStage_Limits(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	stage, err := newGroupStage(util_log.Logger, map[string]interface{}{
+		"source":        "thread",
+		"separator":     " | ",
+		"max_groups":    1,
+		"max_wait_time": "50ms",
+	}, reg)
+	require.NoError(t, err)
+
+	in := make(chan Entry)
+	out := stage.Run(in)
+	entry := func(thread, line string) Entry {
+		return newEntry(map[string]interface{}{"thread": thread}, model.LabelSet{"job": "java"}, line, time.Now())
+	}
+	next := func() string {
+		select {
+		case e := <-out:
+			return e.Line
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a group")
+			return ""
+		}
+	}
+
+	in <- entry("a", "a1")
+	in <- entry("a", "a2")
+	// The group of thread a is flushed to buffer the group of thread b.
+	in <- entry("b", "b1")
+	require.Equal(t, "a1 | a2", next())
+
+	// The group of thread b is flushed once not updated for max_wait_time.
+	require.Equal(t, "b1", next())
+	close(in)
+	_, ok := <-out
+	require.False(t, ok)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP logentry_group_flushed_groups_total A count of all groups of lines flushed by the group stages, by reason.
+# TYPE logentry_group_flushed_groups_total counter
+logentry_group_flushed_groups_total{reason="max_groups"} 1
+logentry_group_flushed_groups_total{reason="timeout"} 1
+`), "logentry_group_flushed_groups_total"))
+}
+
+func TestGroupStage_Config(t *testing.T) {
+	_, err := newGroupStage(util_log.Logger, map[string]interface{}{}, prometheus.NewRegistry())
+	require.EqualError(t, err, ErrGroupStageEmptySource)
+
+	_, err = newGroupStage(util_log.Logger, map[string]interface{}{"source": "thread", "max_groups": 0}, prometheus.NewRegistry())
+	require.EqualError(t, err, ErrGroupStageInvalidLimit)
+
+	_, err = newGroupStage(util_log.Logger, map[string]interface{}{"source": "thread", "firstline": "("}, prometheus.NewRegistry())
+	require.ErrorContains(t, err, "group stage first line regex compilation error")
+}
@@ -0,0 +1,206 @@
+package stages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/prometheus/common/model"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+
+	"github.com/grafana/loki/pkg/push"
+
+	lokipush "github.com/grafana/loki/v3/pkg/loghttp/push"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	// OTelLevelKey is the default name of the extracted value holding the
+	// severity text of the log records.
+	OTelLevelKey = "level"
+)
+
+// OTelConfig is the configuration of the otel stage.
+type OTelConfig struct {
+	// ResourceAttributesAsLabels are the resource attributes promoted to
+	// labels, the others are stored as structured metadata. Defaults to the
+	// resource attributes stored as index labels by the distributor OTLP
+	// endpoint.
+	ResourceAttributesAsLabels *[]string `mapstructure:"resource_attributes_as_labels"`
+
+	// SeverityTextKey is the name of the extracted value the severity text is
+	// written to, for later stages to use it. It is not a label unless
+	// promoted by a labels stage. Defaults to OTelLevelKey.
+	SeverityTextKey string `mapstructure:"severity_text_key"`
+}
+
+type otelStage struct {
+	logger             log.Logger
+	resourceAttributes map[string]struct{}
+	severityTextKey    string
+	unmarshaler        plog.JSONUnmarshaler
+}
+
+// newOTelStage creates a stage parsing log lines written in the OTLP JSON
+// format, e.g. by the OpenTelemetry file exporter.
+func newOTelStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &OTelConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	attributes := lokipush.DefaultOTLPResourceAttributesAsIndexLabels
+	if cfg.ResourceAttributesAsLabels != nil {
+		attributes = *cfg.ResourceAttributesAsLabels
+	}
+	s := &otelStage{
+		logger:             log.With(logger, "component", "stage", "type", "otel"),
+		resourceAttributes: make(map[string]struct{}, len(attributes)),
+		severityTextKey:    cfg.SeverityTextKey,
+	}
+	if s.severityTextKey == "" {
+		s.severityTextKey = OTelLevelKey
+	}
+	for _, attribute := range attributes {
+		s.resourceAttributes[attribute] = struct{}{}
+	}
+	return s, nil
+}
+
+func (s *otelStage) Name() string {
+	return StageTypeOTel
+}
+
+// Cleanup implements Stage.
+func (*otelStage) Cleanup() {
+	// no-op
+}
+
+// Run implements Stage. Each log record of the line is sent as an entry, the
+// lines that are not OTLP JSON are sent unchanged.
+func (s *otelStage) Run(in chan Entry) chan Entry {
+	return RunWithSkipOrSendMany(in, func(e Entry) ([]Entry, bool) {
+		logs, err := s.parse(e.Line)
+		if err != nil {
+			if Debug {
+				level.Debug(s.logger).Log("msg", "failed to parse the line as OTLP JSON", "err", err)
+			}
+			return []Entry{e}, false
+		}
+
+		var entries []Entry
+		rls := logs.ResourceLogs()
+		for i := 0; i < rls.Len(); i++ {
+			labels, resourceMetadata := s.resourceAttributesToLabels(rls.At(i).Resource().Attributes())
+			sls := rls.At(i).ScopeLogs()
+			for j := 0; j < sls.Len(); j++ {
+				scopeMetadata := scopeToStructuredMetadata(sls.At(j).Scope())
+				records := sls.At(j).LogRecords()
+				for k := 0; k < records.Len(); k++ {
+					entries = append(entries, s.newEntry(e, records.At(k), labels, resourceMetadata, scopeMetadata))
+				}
+			}
+		}
+		return entries, false
+	})
+}
+
+// parse parses the line either as an export request holding several log
+// records, or as a single log record.
+func (s *otelStage) parse(line string) (plog.Logs, error) {
+	if !strings.HasPrefix(strings.TrimSpace(line), "{") {
+		return plog.Logs{}, fmt.Errorf("not a JSON object")
+	}
+	logs, err := s.unmarshaler.UnmarshalLogs([]byte(line))
+	if err != nil {
+		return plog.Logs{}, err
+	}
+	if logs.LogRecordCount() > 0 {
+		return logs, nil
+	}
+
+	logs, err = s.unmarshaler.UnmarshalLogs([]byte(`{"resourceLogs":[{"scopeLogs":[{"logRecords":[` + line + `]}]}]}`))
+	if err != nil {
+		return plog.Logs{}, err
+	}
+	record := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+	if record.Body().Type() == pcommon.ValueTypeEmpty && record.Timestamp() == 0 && record.ObservedTimestamp() == 0 {
+		return plog.Logs{}, fmt.Errorf("not an OTLP log record")
+	}
+	return logs, nil
+}
+
+// resourceAttributesToLabels splits the resource attributes between the
+// labels allowed and the structured metadata.
+func (s *otelStage) resourceAttributesToLabels(attrs pcommon.Map) (model.LabelSet, push.LabelsAdapter) {
+	labels := model.LabelSet{}
+	metadata := make(push.LabelsAdapter, 0, attrs.Len())
+	attrs.Range(func(k string, v pcommon.Value) bool {
+		attributeAsLabels := lokipush.AttributeToLabels(k, v, "")
+		if _, ok := s.resourceAttributes[k]; ok {
+			for _, lbl := range attributeAsLabels {
+				labels[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+			}
+		} else {
+			metadata = append(metadata, attributeAsLabels...)
+		}
+		return true
+	})
+	return labels, metadata
+}
+
+func scopeToStructuredMetadata(scope pcommon.InstrumentationScope) push.LabelsAdapter {
+	metadata := lokipush.AttributesToLabels(scope.Attributes(), "")
+	if name := scope.Name(); name != "" {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_name", Value: name})
+	}
+	if version := scope.Version(); version != "" {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_version", Value: version})
+	}
+	if count := scope.DroppedAttributesCount(); count != 0 {
+		metadata = append(metadata, push.LabelAdapter{Name: "scope_dropped_attributes_count", Value: fmt.Sprintf("%d", count)})
+	}
+	return metadata
+}
+
+// newEntry creates the entry of the log record with the conversion of the
+// distributor OTLP endpoint, the attributes and fields of the record being
+// stored as structured metadata.
+func (s *otelStage) newEntry(e Entry, record plog.LogRecord, labels model.LabelSet, resourceMetadata, scopeMetadata push.LabelsAdapter) Entry {
+	extracted := make(map[string]interface{}, len(e.Extracted)+1)
+	for k, v := range e.Extracted {
+		extracted[k] = v
+	}
+	if severityText := record.SeverityText(); severityText != "" {
+		extracted[s.severityTextKey] = severityText
+	}
+
+	entry := lokipush.OTLPLogToPushEntry(record, lokipush.OTLPConfig{}, lokipush.OTLPSeverityText)
+	metadata := make(push.LabelsAdapter, 0, len(e.StructuredMetadata)+len(entry.StructuredMetadata)+len(resourceMetadata)+len(scopeMetadata))
+	metadata = append(metadata, e.StructuredMetadata...)
+	metadata = append(metadata, entry.StructuredMetadata...)
+	metadata = append(metadata, resourceMetadata...)
+	metadata = append(metadata, scopeMetadata...)
+
+	// The records without timestamps keep the timestamp of the line.
+	timestamp := entry.Timestamp
+	if record.Timestamp() == 0 && record.ObservedTimestamp() == 0 {
+		timestamp = e.Timestamp
+	}
+
+	return Entry{
+		Extracted: extracted,
+		Entry: api.Entry{
+			Labels: e.Labels.Clone().Merge(labels),
+			Entry: logproto.Entry{
+				Timestamp:          timestamp,
+				Line:               entry.Line,
+				StructuredMetadata: metadata,
+			},
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/push"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testOTelPipeline = `
+pipeline_stages:
+- otel:
+- labels:
+    level:
+`
+
+var testOTelPipelineCustomLabels = `
+pipeline_stages:
+- otel:
+    resource_attributes_as_labels:
+      - host.name
+`
+
+var testOTelPipelineSeverityTextKey = `
+pipeline_stages:
+- otel:
+    severity_text_key: severity
+- labels:
+    severity:
+`
+
+const testOTelExportRequest = `{"resourceLogs":[{"resource":{"attributes":[
+	{"key":"service.name","value":{"stringValue":"checkout"}},
+	{"key":"host.name","value":{"stringValue":"node-1"}}
+]},"scopeLogs":[{"scope":{"name":"checkout.logger","version":"1.2.0"},"logRecords":[
+	{"timeUnixNano":"1700000000000000001","observedTimeUnixNano":"1700000000000000002","severityNumber":17,"severityText":"ERROR",
+	 "body":{"stringValue":"payment failed"},
+	 "attributes":[{"key":"http.route","value":{"stringValue":"/pay"}},{"key":"error","value":{"kvlistValue":{"values":[{"key":"type","value":{"stringValue":"timeout"}}]}}}],
+	 "traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174"},
+	{"timeUnixNano":"1700000000000000003","severityText":"INFO","body":{"kvlistValue":{"values":[{"key":"msg","value":{"stringValue":"retrying"}}]}}}
+]}]}]}`
+
+func TestOTelStage_ExportRequest(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testOTelPipeline), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, model.LabelSet{"filename": "/var/log/otel.json"}, testOTelExportRequest, time.Now()))
+	require.Len(t, out, 2)
+
+	require.Equal(t, model.LabelSet{"filename": "/var/log/otel.json", "service_name": "checkout", "level": "ERROR"}, out[0].Labels)
+	require.Equal(t, time.Unix(0, 1700000000000000001), out[0].Timestamp)
+	require.Equal(t, "payment failed", out[0].Line)
+	require.Equal(t, push.LabelsAdapter{
+		{Name: "http_route", Value: "/pay"},
+		{Name: "error_type", Value: "timeout"},
+		{Name: "observed_timestamp", Value: "1700000000000000002"},
+		{Name: "severity_number", Value: "17"},
+		{Name: "severity_text", Value: "ERROR"},
+		{Name: "trace_id", Value: "5b8efff798038103d269b633813fc60c"},
+		{Name: "span_id", Value: "eee19b7ec3c1b174"},
+		{Name: "host_name", Value: "node-1"},
+		{Name: "scope_name", Value: "checkout.logger"},
+		{Name: "scope_version", Value: "1.2.0"},
+	}, out[0].StructuredMetadata)
+
+	require.Equal(t, model.LabelSet{"filename": "/var/log/otel.json", "service_name": "checkout", "level": "INFO"}, out[1].Labels)
+	require.Equal(t, time.Unix(0, 1700000000000000003), out[1].Timestamp)
+	require.Equal(t, `{"msg":"retrying"}`, out[1].Line)
+}
+
+func TestOTelStage_LogRecord(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testOTelPipelineCustomLabels), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	ts := time.Now()
+	out := processEntries(pl,
+		newEntry(nil, nil, `{"observedTimeUnixNano":"1700000000000000002","severityText":"WARN","body":{"stringValue":"disk almost full"}}`, ts),
+		newEntry(nil, nil, `plain text line`, ts),
+		newEntry(nil, nil, `{"msg":"not an OTel record"}`, ts),
+	)
+	require.Len(t, out, 3)
+
+	require.Equal(t, time.Unix(0, 1700000000000000002), out[0].Timestamp)
+	require.Equal(t, "disk almost full", out[0].Line)
+	require.Equal(t, "WARN", out[0].Extracted[OTelLevelKey])
+	require.Equal(t, push.LabelsAdapter{{Name: "severity_text", Value: "WARN"}}, out[0].StructuredMetadata)
+
+	// The lines which are not OTLP JSON are sent unchanged.
+	require.Equal(t, "plain text line", out[1].Line)
+	require.Equal(t, ts, out[1].Timestamp)
+	require.Equal(t, `{"msg":"not an OTel record"}`, out[2].Line)
+}
+
+func TestOTelStage_ResourceAttributesAsLabels(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testOTelPipelineCustomLabels), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, testOTelExportRequest, time.Now()))
+	require.Len(t, out, 2)
+	require.Equal(t, model.LabelSet{"host_name": "node-1"}, out[0].Labels)
+	require.Contains(t, out[0].StructuredMetadata, push.LabelAdapter{Name: "service_name", Value: "checkout"})
+}
+
+func TestOTelStage_SeverityTextKey(t *testing.T) {
+	pl, err := NewPipeline(util_log.Logger, loadConfig(testOTelPipelineSeverityTextKey), nil, prometheus.DefaultRegisterer)
+	require.NoError(t, err)
+
+	out := processEntries(pl, newEntry(nil, nil, testOTelExportRequest, time.Now()))
+	require.Len(t, out, 2)
+	require.Equal(t, "ERROR", out[0].Extracted["severity"])
+	require.NotContains(t, out[0].Extracted, OTelLevelKey)
+	require.Equal(t, model.LabelValue("ERROR"), out[0].Labels["severity"])
+}
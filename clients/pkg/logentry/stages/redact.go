@@ -0,0 +1,303 @@
+package stages
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/metric"
+)
+
+// Config Errors
+const (
+	ErrEmptyRedactStageConfig     = "redact stage config must contain at least one detector"
+	ErrRedactDetectorNameRequired = "redact stage detector name is required"
+	ErrRedactDetectorDuplicate    = "redact stage detector %s is defined more than once"
+	ErrRedactUnknownDetector      = "redact stage detector %s is not a built-in detector, an expression is required"
+	ErrRedactInvalidAction        = "redact stage detector %s has an invalid action %s, action must be one of `mask`, `drop` or `hash`"
+	ErrRedactHashKeyRequired      = "redact stage hash_key is required when a detector uses the `hash` action"
+	ErrRedactCouldNotCompileRegex = "redact stage detector %s: could not compile regular expression"
+	ErrRedactInvalidDetectorName  = "redact stage detector name %s is not a valid label value"
+)
+
+// Redact actions.
+const (
+	RedactActionMask = "mask"
+	RedactActionDrop = "drop"
+	RedactActionHash = "hash"
+
+	defaultRedactMask   = "<redacted>"
+	redactDropReason    = "redact"
+	redactHashLength    = 16
+	redactDetectorLabel = "detector"
+)
+
+// RedactDetectorConfig configures a detector of the redact stage.
+type RedactDetectorConfig struct {
+	// Name is either the name of a built-in detector, or the name of a custom
+	// detector defined by its expression.
+	Name       string  `mapstructure:"name"`
+	Expression *string `mapstructure:"expression"`
+	Action     string  `mapstructure:"action"`
+	Mask       *string `mapstructure:"mask"`
+}
+
+// RedactConfig contains the configuration of a redactStage.
+type RedactConfig struct {
+	Detectors []RedactDetectorConfig `mapstructure:"detectors"`
+	HashKey   string                 `mapstructure:"hash_key"`
+}
+
+// redactDetector finds the values to redact. If the expression has capture
+// groups, only the first group of each match is redacted.
+type redactDetector struct {
+	name       string
+	expression *regexp.Regexp
+	validate   func(string) bool
+	action     string
+	mask       string
+}
+
+// builtinRedactDetectors are the detectors which can be used by their name only.
+var builtinRedactDetectors = map[string]redactDetector{
+	"credit_card": {
+		expression: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		validate:   luhnValid,
+	},
+	"jwt": {
+		expression: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	},
+	"aws_access_key": {
+		expression: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`),
+	},
+	"aws_secret_key": {
+		expression: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+=]{40})\b`),
+	},
+	"email": {
+		expression: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	"ipv4": {
+		expression: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+	},
+	"ipv6": {
+		expression: regexp.MustCompile(`(?i)(?:[0-9a-f]{1,4}|:)(?::[0-9a-f]{0,4}){2,7}(?:%[0-9a-z]+)?`),
+		validate: func(s string) bool {
+			addr, err := netip.ParseAddr(s)
+			return err == nil && addr.Is6()
+		},
+	},
+}
+
+// validateRedactConfig validates the config and returns the detectors.
+func validateRedactConfig(cfg *RedactConfig) ([]redactDetector, error) {
+	if cfg == nil || len(cfg.Detectors) == 0 {
+		return nil, errors.New(ErrEmptyRedactStageConfig)
+	}
+
+	detectors := make([]redactDetector, 0, len(cfg.Detectors))
+	names := map[string]struct{}{}
+	for _, dc := range cfg.Detectors {
+		if dc.Name == "" {
+			return nil, errors.New(ErrRedactDetectorNameRequired)
+		}
+		if !model.LabelValue(dc.Name).IsValid() {
+			return nil, errors.Errorf(ErrRedactInvalidDetectorName, dc.Name)
+		}
+		if _, ok := names[dc.Name]; ok {
+			return nil, errors.Errorf(ErrRedactDetectorDuplicate, dc.Name)
+		}
+		names[dc.Name] = struct{}{}
+
+		var d redactDetector
+		if dc.Expression != nil {
+			expr, err := regexp.Compile(*dc.Expression)
+			if err != nil {
+				return nil, errors.Wrapf(err, ErrRedactCouldNotCompileRegex, dc.Name)
+			}
+			d.expression = expr
+		} else {
+			builtin, ok := builtinRedactDetectors[dc.Name]
+			if !ok {
+				return nil, errors.Errorf(ErrRedactUnknownDetector, dc.Name)
+			}
+			d = builtin
+		}
+		d.name = dc.Name
+
+		d.action = strings.ToLower(dc.Action)
+		switch d.action {
+		case "":
+			d.action = RedactActionMask
+		case RedactActionMask, RedactActionDrop:
+		case RedactActionHash:
+			if cfg.HashKey == "" {
+				return nil, errors.New(ErrRedactHashKeyRequired)
+			}
+		default:
+			return nil, errors.Errorf(ErrRedactInvalidAction, dc.Name, dc.Action)
+		}
+
+		d.mask = defaultRedactMask
+		if dc.Mask != nil {
+			d.mask = *dc.Mask
+		}
+		detectors = append(detectors, d)
+	}
+	return detectors, nil
+}
+
+// redactStage redacts the secrets and personal data found in the log lines.
+type redactStage struct {
+	logger    log.Logger
+	detectors []redactDetector
+	hashKey   []byte
+	hits      *metric.Counters
+	dropCount *prometheus.CounterVec
+}
+
+// newRedactStage creates a redactStage from config
+func newRedactStage(logger log.Logger, config interface{}, registerer prometheus.Registerer) (Stage, error) {
+	cfg := &RedactConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	detectors, err := validateRedactConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The hits are counted per stream the same way as the counters of the
+	// metrics stage.
+	hits, err := metric.NewCounters("promtail_redact_hits_total", "Number of values redacted by the redact stage, per detector.",
+		map[string]interface{}{"action": metric.CounterAdd}, int64(5*time.Minute.Seconds()))
+	if err != nil {
+		return nil, err
+	}
+	registerer.MustRegister(hits)
+
+	return &redactStage{
+		logger:    log.With(logger, "component", "stage", "type", "redact"),
+		detectors: detectors,
+		hashKey:   []byte(cfg.HashKey),
+		hits:      hits,
+		dropCount: getDropCountMetric(registerer),
+	}, nil
+}
+
+func (r *redactStage) Run(in chan Entry) chan Entry {
+	return RunWithSkip(in, func(e Entry) (Entry, bool) {
+		for _, d := range r.detectors {
+			line, hits := r.redact(d, e.Line)
+			if hits == 0 {
+				continue
+			}
+			r.hits.With(e.Labels.Clone().Merge(model.LabelSet{redactDetectorLabel: model.LabelValue(d.name)})).Add(float64(hits))
+			if d.action == RedactActionDrop {
+				if Debug {
+					level.Debug(r.logger).Log("msg", "dropping line matching redact detector", "detector", d.name)
+				}
+				r.dropCount.WithLabelValues(redactDropReason).Inc()
+				return e, true
+			}
+			e.Line = line
+		}
+		return e, false
+	})
+}
+
+// redact replaces the values found by the detector, returning the number of
+// values found.
+func (r *redactStage) redact(d redactDetector, line string) (string, int) {
+	matches := d.expression.FindAllStringSubmatchIndex(line, -1)
+	if len(matches) == 0 {
+		return line, 0
+	}
+
+	var (
+		sb   strings.Builder
+		last int
+		hits int
+	)
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) > 2 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		value := line[start:end]
+		if d.validate != nil && !d.validate(value) {
+			continue
+		}
+		hits++
+		if d.action == RedactActionDrop {
+			return line, hits
+		}
+		sb.WriteString(line[last:start])
+		sb.WriteString(r.replacement(d, value))
+		last = end
+	}
+	if hits == 0 {
+		return line, 0
+	}
+	sb.WriteString(line[last:])
+	return sb.String(), hits
+}
+
+func (r *redactStage) replacement(d redactDetector, value string) string {
+	if d.action != RedactActionHash {
+		return d.mask
+	}
+	mac := hmac.New(sha256.New, r.hashKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))[:redactHashLength]
+}
+
+// Name implements Stage
+func (r *redactStage) Name() string {
+	return StageTypeRedact
+}
+
+// Cleanup implements Stage.
+func (r *redactStage) Cleanup() {
+	r.hits.DeleteAll()
+}
+
+// luhnValid checks the number with the Luhn algorithm, ignoring spaces and
+// dashes.
+func luhnValid(s string) bool {
+	var (
+		sum    int
+		digits int
+		double bool
+	)
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		n := int(c - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		digits++
+		double = !double
+	}
+	return digits >= 13 && digits <= 19 && sum%10 == 0
+}
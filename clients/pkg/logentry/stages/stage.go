@@ -41,6 +41,10 @@ const (
 	StageTypeDecolorize      = "decolorize"
 	StageTypeEventLogMessage = "eventlogmessage"
 	StageTypeGeoIP           = "geoip"
+	StageTypeOTel            = "otel"
+	StageTypeRedact          = "redact"
+	StageTypeGroup           = "group"
+	StageTypeUserAgent       = "useragent"
 	// Deprecated. Renamed to `structured_metadata`. Will be removed after the migration.
 	StageTypeNonIndexedLabels   = "non_indexed_labels"
 	StageTypeStructuredMetadata = "structured_metadata"
@@ -208,6 +212,18 @@ func initCreators() {
 		StageTypeGeoIP: func(params StageCreationParams) (Stage, error) {
 			return newGeoIPStage(params.logger, params.config)
 		},
+		StageTypeOTel: func(params StageCreationParams) (Stage, error) {
+			return newOTelStage(params.logger, params.config)
+		},
+		StageTypeRedact: func(params StageCreationParams) (Stage, error) {
+			return newRedactStage(params.logger, params.config, params.registerer)
+		},
+		StageTypeGroup: func(params StageCreationParams) (Stage, error) {
+			return newGroupStage(params.logger, params.config, params.registerer)
+		},
+		StageTypeUserAgent: func(params StageCreationParams) (Stage, error) {
+			return newUserAgentStage(params.logger, params.config)
+		},
 		StageTypeNonIndexedLabels:   newStructuredMetadataStage,
 		StageTypeStructuredMetadata: newStructuredMetadataStage,
 	}
@@ -0,0 +1,160 @@
+package stages
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/mssola/useragent"
+	"github.com/pkg/errors"
+)
+
+// Config Errors
+const (
+	ErrEmptyUserAgentStageConfig       = "useragent stage config cannot be empty"
+	ErrEmptySourceUserAgentStageConfig = "useragent stage source cannot be empty"
+	ErrInvalidUserAgentStageField      = "useragent stage field %s is invalid, fields must be some of %v"
+)
+
+// The fields extracted by the useragent stage.
+const (
+	UserAgentBrowserName    = "browser_name"
+	UserAgentBrowserVersion = "browser_version"
+	UserAgentOSName         = "os_name"
+	UserAgentOSVersion      = "os_version"
+	UserAgentDeviceType     = "device_type"
+	UserAgentDeviceModel    = "device_model"
+
+	userAgentPrefix = "useragent_"
+
+	userAgentDeviceBot     = "bot"
+	userAgentDeviceMobile  = "mobile"
+	userAgentDeviceDesktop = "desktop"
+	userAgentDeviceOther   = "other"
+)
+
+var userAgentFields = []string{
+	UserAgentBrowserName,
+	UserAgentBrowserVersion,
+	UserAgentOSName,
+	UserAgentOSVersion,
+	UserAgentDeviceType,
+	UserAgentDeviceModel,
+}
+
+// UserAgentConfig represents the useragent stage config
+type UserAgentConfig struct {
+	Source *string `mapstructure:"source"`
+	// Fields selects the fields extracted, all by default.
+	Fields []string `mapstructure:"fields"`
+}
+
+func validateUserAgentConfig(c *UserAgentConfig) error {
+	if c == nil {
+		return errors.New(ErrEmptyUserAgentStageConfig)
+	}
+	if c.Source == nil || *c.Source == "" {
+		return errors.New(ErrEmptySourceUserAgentStageConfig)
+	}
+	for _, f := range c.Fields {
+		if !slices.Contains(userAgentFields, f) {
+			return errors.Errorf(ErrInvalidUserAgentStageField, f, userAgentFields)
+		}
+	}
+	if len(c.Fields) == 0 {
+		c.Fields = userAgentFields
+	}
+	return nil
+}
+
+func newUserAgentStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg := &UserAgentConfig{}
+	if err := mapstructure.Decode(config, cfg); err != nil {
+		return nil, err
+	}
+	if err := validateUserAgentConfig(cfg); err != nil {
+		return nil, err
+	}
+	return &userAgentStage{
+		logger: log.With(logger, "component", "stage", "type", "useragent"),
+		cfg:    cfg,
+	}, nil
+}
+
+// userAgentStage extracts the browser, operating system and device from a
+// user agent.
+type userAgentStage struct {
+	logger log.Logger
+	cfg    *UserAgentConfig
+}
+
+// Run implements Stage
+func (u *userAgentStage) Run(in chan Entry) chan Entry {
+	return RunWith(in, func(e Entry) Entry {
+		u.process(e.Extracted)
+		return e
+	})
+}
+
+func (u *userAgentStage) process(extracted map[string]interface{}) {
+	v, ok := extracted[*u.cfg.Source]
+	if !ok {
+		if Debug {
+			level.Debug(u.logger).Log("msg", "source does not exist in the set of extracted values", "source", *u.cfg.Source)
+		}
+		return
+	}
+	s, err := getString(v)
+	if err != nil {
+		if Debug {
+			level.Debug(u.logger).Log("msg", "failed to convert source value to string", "source", *u.cfg.Source, "err", err, "type", reflect.TypeOf(v))
+		}
+		return
+	}
+	if s == "" {
+		return
+	}
+
+	ua := useragent.New(s)
+	for _, f := range u.cfg.Fields {
+		var value string
+		switch f {
+		case UserAgentBrowserName:
+			value, _ = ua.Browser()
+		case UserAgentBrowserVersion:
+			_, value = ua.Browser()
+		case UserAgentOSName:
+			value = ua.OSInfo().Name
+		case UserAgentOSVersion:
+			value = ua.OSInfo().Version
+		case UserAgentDeviceType:
+			switch {
+			case ua.Bot():
+				value = userAgentDeviceBot
+			case ua.Mobile():
+				value = userAgentDeviceMobile
+			case ua.OSInfo().Name == "":
+				value = userAgentDeviceOther
+			default:
+				value = userAgentDeviceDesktop
+			}
+		case UserAgentDeviceModel:
+			value = ua.Model()
+		}
+		if value != "" {
+			extracted[userAgentPrefix+f] = value
+		}
+	}
+}
+
+// Name implements Stage
+func (u *userAgentStage) Name() string {
+	return StageTypeUserAgent
+}
+
+// Cleanup implements Stage.
+func (*userAgentStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,111 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var testUserAgentPipeline = `
+pipeline_stages:
+- regex:
+    expression: '"(?P<user_agent>[^"]*)"$'
+- useragent:
+    source: user_agent
+`
+
+func TestPipeline_UserAgent(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		entry    string
+		expected map[string]interface{}
+	}{
+		"desktop browser": {
+			`GET /index.html 200 "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"`,
+			map[string]interface{}{
+				"useragent_browser_name":    "Chrome",
+				"useragent_browser_version": "120.0.0.0",
+				"useragent_os_name":         "Mac OS X",
+				"useragent_os_version":      "10.15.7",
+				"useragent_device_type":     "desktop",
+			},
+		},
+		"mobile browser": {
+			`GET /index.html 200 "Mozilla/5.0 (Linux; Android 13; Pixel 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36"`,
+			map[string]interface{}{
+				"useragent_browser_name":    "Chrome",
+				"useragent_browser_version": "120.0.6099.144",
+				"useragent_os_name":         "Android",
+				"useragent_os_version":      "13",
+				"useragent_device_type":     "mobile",
+				"useragent_device_model":    "Pixel 7",
+			},
+		},
+		"bot": {
+			`GET /robots.txt 200 "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"`,
+			map[string]interface{}{
+				"useragent_browser_name":    "Googlebot",
+				"useragent_browser_version": "2.1",
+				"useragent_device_type":     "bot",
+			},
+		},
+		"command line client": {
+			`GET /healthz 200 "curl/8.4.0"`,
+			map[string]interface{}{
+				"useragent_browser_name":    "curl",
+				"useragent_browser_version": "8.4.0",
+				"useragent_device_type":     "other",
+			},
+		},
+		"empty user agent": {
+			`GET /healthz 200 ""`,
+			map[string]interface{}{},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(util_log.Logger, loadConfig(testUserAgentPipeline), nil, prometheus.DefaultRegisterer)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))
+			require.Len(t, out, 1)
+
+			extracted := out[0].Extracted
+			delete(extracted, "user_agent")
+			require.Equal(t, testData.expected, extracted)
+		})
+	}
+}
+
+func TestUserAgentStage_Fields(t *testing.T) {
+	stage, err := newUserAgentStage(util_log.Logger, map[string]interface{}{
+		"source": "ua",
+		"fields": []string{"browser_name", "device_type"},
+	})
+	require.NoError(t, err)
+
+	out := processEntries(stage, newEntry(map[string]interface{}{"ua": "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"}, nil, "", time.Now()))
+	require.Len(t, out, 1)
+	require.Equal(t, map[string]interface{}{
+		"ua":                     "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		"useragent_browser_name": "Firefox",
+		"useragent_device_type":  "desktop",
+	}, out[0].Extracted)
+}
+
+func TestUserAgentStage_Config(t *testing.T) {
+	_, err := newUserAgentStage(util_log.Logger, map[string]interface{}{})
+	require.EqualError(t, err, ErrEmptySourceUserAgentStageConfig)
+
+	_, err = newUserAgentStage(util_log.Logger, map[string]interface{}{"source": "ua", "fields": []string{"browser"}})
+	require.EqualError(t, err, "useragent stage field browser is invalid, fields must be some of [browser_name browser_version os_name os_version device_type device_model]")
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -21,6 +22,8 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/queue"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
 
 	lokiutil "github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/build"
@@ -44,6 +47,7 @@ const (
 	ReasonRateLimited   = "rate_limited"
 	ReasonStreamLimited = "stream_limited"
 	ReasonLineTooLong   = "line_too_long"
+	ReasonQueueEvicted  = "queue_evicted"
 )
 
 var Reasons = []string{ReasonGeneric, ReasonRateLimited, ReasonStreamLimited, ReasonLineTooLong}
@@ -63,6 +67,7 @@ type Metrics struct {
 	countersWithHost             []*prometheus.CounterVec
 	countersWithHostTenant       []*prometheus.CounterVec
 	countersWithHostTenantReason []*prometheus.CounterVec
+	queueMetrics                 *queue.Metrics
 }
 
 func NewMetrics(reg prometheus.Registerer) *Metrics {
@@ -127,30 +132,21 @@ func NewMetrics(reg prometheus.Registerer) *Metrics {
 	}
 
 	if reg != nil {
-		m.encodedBytes = mustRegisterOrGet(reg, m.encodedBytes).(*prometheus.CounterVec)
-		m.sentBytes = mustRegisterOrGet(reg, m.sentBytes).(*prometheus.CounterVec)
-		m.droppedBytes = mustRegisterOrGet(reg, m.droppedBytes).(*prometheus.CounterVec)
-		m.sentEntries = mustRegisterOrGet(reg, m.sentEntries).(*prometheus.CounterVec)
-		m.droppedEntries = mustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
-		m.mutatedEntries = mustRegisterOrGet(reg, m.mutatedEntries).(*prometheus.CounterVec)
-		m.mutatedBytes = mustRegisterOrGet(reg, m.mutatedBytes).(*prometheus.CounterVec)
-		m.requestDuration = mustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
-		m.batchRetries = mustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
+		m.encodedBytes = utils.MustRegisterOrGet(reg, m.encodedBytes).(*prometheus.CounterVec)
+		m.sentBytes = utils.MustRegisterOrGet(reg, m.sentBytes).(*prometheus.CounterVec)
+		m.droppedBytes = utils.MustRegisterOrGet(reg, m.droppedBytes).(*prometheus.CounterVec)
+		m.sentEntries = utils.MustRegisterOrGet(reg, m.sentEntries).(*prometheus.CounterVec)
+		m.droppedEntries = utils.MustRegisterOrGet(reg, m.droppedEntries).(*prometheus.CounterVec)
+		m.mutatedEntries = utils.MustRegisterOrGet(reg, m.mutatedEntries).(*prometheus.CounterVec)
+		m.mutatedBytes = utils.MustRegisterOrGet(reg, m.mutatedBytes).(*prometheus.CounterVec)
+		m.requestDuration = utils.MustRegisterOrGet(reg, m.requestDuration).(*prometheus.HistogramVec)
+		m.batchRetries = utils.MustRegisterOrGet(reg, m.batchRetries).(*prometheus.CounterVec)
 	}
+	m.queueMetrics = queue.NewMetrics(reg)
 
 	return &m
 }
 
-func mustRegisterOrGet(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
-	if err := reg.Register(c); err != nil {
-		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
-			return are.ExistingCollector
-		}
-		panic(err)
-	}
-	return c
-}
-
 // Client pushes entries to Loki and can be stopped
 type Client interface {
 	api.EntryHandler
@@ -171,6 +167,13 @@ type client struct {
 	once sync.Once
 	wg   sync.WaitGroup
 
+	// queue holds the batches to send when the on-disk queue is enabled, they
+	// are sent by the replay goroutine.
+	queue        *queue.Queue
+	replayWg     sync.WaitGroup
+	replayCtx    context.Context
+	replayCancel context.CancelFunc
+
 	externalLabels model.LabelSet
 
 	// ctx is used in any upstream calls from the `client`.
@@ -236,6 +239,16 @@ func newClient(metrics *Metrics, cfg Config, maxStreams, maxLineSize int, maxLin
 		counter.WithLabelValues(c.cfg.URL.Host).Add(0)
 	}
 
+	if cfg.Queue.Enabled {
+		c.queue, err = queue.Open(queueDir(cfg), c.name, int64(cfg.Queue.MaxSize.Val()), int64(cfg.Queue.SegmentSize.Val()), metrics.queueMetrics, c.logger, c.onEvict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the queue of client %s: %w", c.name, err)
+		}
+		c.replayCtx, c.replayCancel = context.WithCancel(context.Background())
+		c.replayWg.Add(1)
+		go c.replay()
+	}
+
 	c.wg.Add(1)
 	go c.run()
 	return c, nil
@@ -262,6 +275,9 @@ func (c *client) initBatchMetrics(tenantID string) {
 		for _, reason := range Reasons {
 			counter.WithLabelValues(c.cfg.URL.Host, tenantID, reason).Add(0)
 		}
+		if c.queue != nil {
+			counter.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonQueueEvicted).Add(0)
+		}
 	}
 
 	for _, counter := range c.metrics.countersWithHostTenant {
@@ -374,6 +390,17 @@ func asSha256(o interface{}) string {
 	return temp[:6]
 }
 
+// queueDir returns the directory of the queue of a client, named after the
+// client when it has a name and after its URL and tenant otherwise, so that
+// changing the other settings of the client doesn't orphan its queued batches.
+func queueDir(cfg Config) string {
+	name := cfg.Name
+	if name == "" {
+		name = asSha256(cfg.URL.String() + "/" + cfg.TenantID)
+	}
+	return filepath.Join(cfg.Queue.Dir, name)
+}
+
 func batchIsRateLimited(status int) bool {
 	return status == 429
 }
@@ -387,12 +414,23 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	bufBytes := float64(len(buf))
 	c.metrics.encodedBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
 
+	// The batch is sent by the replay goroutine once written to the queue.
+	if c.queue != nil {
+		err := c.queue.Append(queue.Record{TenantID: tenantID, Entries: entriesCount, Buf: buf})
+		if err != nil {
+			level.Error(c.logger).Log("msg", "error writing batch to the queue", "tenant", tenantID, "error", err)
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonGeneric).Add(bufBytes)
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonGeneric).Add(float64(entriesCount))
+		}
+		return
+	}
+
 	backoff := backoff.New(c.ctx, c.cfg.BackoffConfig)
 	var status int
 	for {
 		start := time.Now()
 		// send uses `timeout` internally, so `context.Background` is good enough.
-		status, err = c.send(context.Background(), tenantID, buf)
+		status, _, err = c.send(context.Background(), tenantID, buf)
 
 		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
 
@@ -439,12 +477,14 @@ func (c *client) sendBatch(tenantID string, batch *batch) {
 	}
 }
 
-func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, error) {
+// send pushes the batch to Loki, returning the status code and the delay
+// requested by its Retry-After header if any.
+func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, time.Duration, error) {
 	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
 	defer cancel()
 	req, err := http.NewRequestWithContext(ctx, "POST", c.cfg.URL.String(), bytes.NewReader(buf))
 	if err != nil {
-		return -1, err
+		return -1, 0, err
 	}
 	req.Header.Set("Content-Type", contentType)
 	req.Header.Set("User-Agent", UserAgent)
@@ -468,7 +508,7 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return -1, err
+		return -1, 0, err
 	}
 	defer lokiutil.LogError("closing response body", resp.Body.Close)
 
@@ -480,7 +520,108 @@ func (c *client) send(ctx context.Context, tenantID string, buf []byte) (int, er
 		}
 		err = fmt.Errorf("server returned HTTP status %s (%d): %s", resp.Status, resp.StatusCode, line)
 	}
-	return resp.StatusCode, err
+	return resp.StatusCode, parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()), err
+}
+
+// parseRetryAfter parses the value of a Retry-After header, either a number
+// of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// onEvict counts the batches evicted from the queue before being sent as
+// dropped.
+func (c *client) onEvict(tenantID string, entries, bytes int) {
+	level.Warn(c.logger).Log("msg", "dropping batch evicted from the full queue", "tenant", tenantID)
+	c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonQueueEvicted).Add(float64(bytes))
+	c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonQueueEvicted).Add(float64(entries))
+}
+
+// replay sends the batches of the queue in order, until the client is
+// stopped.
+func (c *client) replay() {
+	defer c.replayWg.Done()
+	for {
+		item, err := c.queue.Next(c.replayCtx)
+		if err != nil {
+			return
+		}
+		if !c.sendQueued(item) {
+			// The client is stopped, the batch is sent again on restart.
+			return
+		}
+		c.queue.Commit(item)
+	}
+}
+
+// sendQueued sends a batch read from the queue. Unlike sendBatch, retriable
+// errors are retried until the client is stopped since the batches are
+// evicted once the queue is full, and the delay requested by Loki with the
+// Retry-After header is honored. It returns false if the client has been
+// stopped before the batch is either sent or dropped.
+func (c *client) sendQueued(item queue.Item) bool {
+	tenantID := item.TenantID
+	bufBytes := float64(len(item.Buf))
+	c.initBatchMetrics(tenantID)
+
+	cfg := c.cfg.BackoffConfig
+	cfg.MaxRetries = 0
+	backoff := backoff.New(c.replayCtx, cfg)
+	for {
+		start := time.Now()
+		// send uses `timeout` internally, so `context.Background` is good enough.
+		status, retryAfter, err := c.send(context.Background(), tenantID, item.Buf)
+
+		c.metrics.requestDuration.WithLabelValues(strconv.Itoa(status), c.cfg.URL.Host).Observe(time.Since(start).Seconds())
+
+		// Immediately drop rate limited batches to avoid HOL blocking for other tenants not experiencing throttling
+		if c.cfg.DropRateLimitedBatches && batchIsRateLimited(status) {
+			level.Warn(c.logger).Log("msg", "dropping batch due to rate limiting applied at ingester")
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(bufBytes)
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonRateLimited).Add(float64(item.Entries))
+			return true
+		}
+
+		if err == nil {
+			c.metrics.sentBytes.WithLabelValues(c.cfg.URL.Host).Add(bufBytes)
+			c.metrics.sentEntries.WithLabelValues(c.cfg.URL.Host).Add(float64(item.Entries))
+			return true
+		}
+
+		// Only retry 429s, 500s and connection-level errors.
+		if status > 0 && !batchIsRateLimited(status) && status/100 != 5 {
+			level.Error(c.logger).Log("msg", "final error sending batch", "status", status, "tenant", tenantID, "error", err)
+			c.metrics.droppedBytes.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonGeneric).Add(bufBytes)
+			c.metrics.droppedEntries.WithLabelValues(c.cfg.URL.Host, tenantID, ReasonGeneric).Add(float64(item.Entries))
+			return true
+		}
+
+		level.Warn(c.logger).Log("msg", "error sending batch, will retry", "status", status, "tenant", tenantID, "error", err, "retry_after", retryAfter)
+		c.metrics.batchRetries.WithLabelValues(c.cfg.URL.Host, tenantID).Inc()
+		if retryAfter > 0 {
+			select {
+			case <-time.After(retryAfter):
+			case <-c.replayCtx.Done():
+			}
+		} else {
+			backoff.Wait()
+		}
+		if c.replayCtx.Err() != nil {
+			return false
+		}
+	}
 }
 
 func (c *client) getTenantID(labels model.LabelSet) string {
@@ -503,6 +644,15 @@ func (c *client) getTenantID(labels model.LabelSet) string {
 func (c *client) Stop() {
 	c.once.Do(func() { close(c.entries) })
 	c.wg.Wait()
+
+	// The batches not sent yet stay in the queue, to be sent on restart.
+	if c.queue != nil {
+		c.replayCancel()
+		c.replayWg.Wait()
+		if err := c.queue.Close(); err != nil {
+			level.Error(c.logger).Log("msg", "error closing the queue", "error", err)
+		}
+	}
 }
 
 // StopNow stops the client without retries
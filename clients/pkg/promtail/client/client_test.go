@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -20,6 +22,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/queue"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
 
 	"github.com/grafana/loki/pkg/push"
@@ -746,3 +749,91 @@ func Test_Tripperware(t *testing.T) {
 	c.Stop()
 	require.True(t, called)
 }
+
+func newQueueTestClient(t *testing.T, reg prometheus.Registerer, serverURL, dir string) Client {
+	t.Helper()
+	var u flagext.URLValue
+	require.NoError(t, u.Set(serverURL))
+	c, err := New(NewMetrics(reg), Config{
+		Name:          "queued",
+		URL:           u,
+		BatchWait:     10 * time.Millisecond,
+		BatchSize:     5,
+		BackoffConfig: backoff.Config{MinBackoff: 1 * time.Millisecond, MaxBackoff: 2 * time.Millisecond, MaxRetries: 1},
+		Timeout:       1 * time.Second,
+		Queue:         queue.Config{Enabled: true, Dir: dir, MaxSize: 1 << 20, SegmentSize: 1 << 10},
+	}, 0, 0, false, log.NewNopLogger())
+	require.NoError(t, err)
+	return c
+}
+
+func TestClient_QueueRetryAfter(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		received []time.Time
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		received = append(received, time.Now())
+		if len(received) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer server.Close()
+
+	c := newQueueTestClient(t, prometheus.NewRegistry(), server.URL, t.TempDir())
+	c.Chan() <- logEntries[0]
+	require.Eventually(t, func() bool {
+		mtx.Lock()
+		defer mtx.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	c.Stop()
+
+	// The rate limited batch is retried after the delay requested by the
+	// server instead of the configured backoff, and beyond its max retries.
+	require.GreaterOrEqual(t, received[1].Sub(received[0]), time.Second)
+}
+
+func TestClient_QueueReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	down := utils.NewRemoteWriteServer(make(chan utils.RemoteWriteRequest, 100), http.StatusServiceUnavailable)
+	defer down.Close()
+
+	c := newQueueTestClient(t, prometheus.NewRegistry(), down.URL, dir)
+	c.Chan() <- logEntries[0]
+	c.Chan() <- logEntries[1]
+	c.Stop()
+
+	// The batches not sent are replayed in order once restarted.
+	receivedReqsChan := make(chan utils.RemoteWriteRequest, 10)
+	up := utils.NewRemoteWriteServer(receivedReqsChan, http.StatusOK)
+	defer up.Close()
+	reg := prometheus.NewRegistry()
+	c = newQueueTestClient(t, reg, up.URL, dir)
+	defer c.Stop()
+
+	for _, e := range logEntries[:2] {
+		select {
+		case req := <-receivedReqsChan:
+			require.Equal(t, []logproto.Entry{e.Entry}, req.Request.Streams[0].Entries)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the replayed batch")
+		}
+	}
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(c.(*client).metrics.sentEntries.WithLabelValues(strings.TrimPrefix(up.URL, "http://"))) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_ParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, time.Duration(0), parseRetryAfter("", now))
+	require.Equal(t, 2*time.Second, parseRetryAfter("2", now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+	require.Equal(t, 30*time.Second, parseRetryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now))
+	require.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now))
+	require.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+}
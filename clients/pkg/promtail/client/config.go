@@ -8,6 +8,8 @@ import (
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/config"
 
+	"github.com/grafana/loki/v3/clients/pkg/promtail/queue"
+
 	lokiflag "github.com/grafana/loki/v3/pkg/util/flagext"
 )
 
@@ -48,6 +50,11 @@ type Config struct {
 	// 429 'Too Many Requests' response from the distributor. Helps
 	// prevent HOL blocking in multitenant deployments.
 	DropRateLimitedBatches bool `yaml:"drop_rate_limited_batches"`
+
+	// Queue configures the on-disk queue the batches are written to before
+	// being sent, so they survive restarts and outages of this endpoint
+	// without blocking the other clients.
+	Queue queue.Config `yaml:"queue"`
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
@@ -104,6 +111,9 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := cfg.Client.Validate(); err != nil {
 		return err
 	}
+	if err := cfg.Queue.Validate(); err != nil {
+		return err
+	}
 
 	*c = Config(cfg)
 	return nil
@@ -65,9 +65,19 @@ func NewManager(metrics *Metrics, logger log.Logger, limits limit.Config, reg pr
 	}
 
 	clientsCheck := make(map[string]struct{})
+	queueDirs := make(map[string]struct{})
 	clients := make([]Client, 0, len(clientCfgs))
 	watchers := make([]Stoppable, 0, len(clientCfgs))
 	for _, cfg := range clientCfgs {
+		if cfg.Queue.Enabled {
+			// Clients sharing a queue directory would read each other's batches.
+			dir := queueDir(cfg)
+			if _, ok := queueDirs[dir]; ok {
+				return nil, fmt.Errorf("clients with the same URL and tenant share the queue directory %s, set a distinct name for each of them", dir)
+			}
+			queueDirs[dir] = fake
+		}
+
 		client, err := New(metrics, cfg, limits.MaxStreams, limits.MaxLineSize.Val(), limits.MaxLineSizeTruncate, logger)
 		if err != nil {
 			return nil, err
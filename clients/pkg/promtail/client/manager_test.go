@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/limit"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/queue"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/wal"
 
@@ -74,6 +75,29 @@ func TestManager_ErrorCreatingWhenRepeatedConfigs(t *testing.T) {
 	}
 }
 
+func TestManager_ErrorCreatingWhenQueuesShareDir(t *testing.T) {
+	host, _ := url.Parse("http://localhost:3100")
+	queueCfg := queue.Config{Enabled: true, Dir: t.TempDir(), MaxSize: 1 << 20, SegmentSize: 1 << 10}
+	config1 := Config{
+		BatchSize: 20,
+		BatchWait: 1 * time.Second,
+		URL:       flagext.URLValue{URL: host},
+		Queue:     queueCfg,
+	}
+	// The clients differ, but not their queue directory.
+	config2 := config1
+	config2.BatchSize = 40
+	require.NotEqual(t, asSha256(config1), asSha256(config2))
+	require.Equal(t, queueDir(config1), queueDir(config2))
+
+	_, err := NewManager(nilMetrics, log.NewNopLogger(), testLimitsConfig, prometheus.NewRegistry(), wal.Config{}, NilNotifier, config1, config2)
+	require.ErrorContains(t, err, "share the queue directory")
+
+	// Named clients have their own queue directory.
+	config1.Name, config2.Name = "a", "b"
+	require.NotEqual(t, queueDir(config1), queueDir(config2))
+}
+
 type closer interface {
 	Close()
 }
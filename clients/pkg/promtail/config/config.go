@@ -11,6 +11,7 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/limit"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/remoteconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/server"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/file"
@@ -38,6 +39,9 @@ type Config struct {
 	Options         Options               `yaml:"options,omitempty"`
 	Tracing         tracing.Config        `yaml:"tracing"`
 	WAL             wal.Config            `yaml:"wal"`
+	// RemoteConfig replaces the scrape configs with the ones served by a
+	// remote endpoint.
+	RemoteConfig remoteconfig.Config `yaml:"remote_config,omitempty"`
 }
 
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
@@ -59,7 +63,7 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		}
 		jobNames[j.JobName] = struct{}{}
 	}
-	return nil
+	return c.RemoteConfig.Validate()
 }
 
 // RegisterFlags with prefix registers flags where every name is prefixed by
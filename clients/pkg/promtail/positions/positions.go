@@ -74,6 +74,8 @@ type Positions interface {
 	Put(path string, pos int64)
 	// Remove removes the position tracking for a filepath
 	Remove(path string)
+	// Keys returns the paths tracked with the prefix.
+	Keys(prefix string) []string
 	// SyncPeriod returns how often the positions file gets resynced
 	SyncPeriod() time.Duration
 	// Stop the Position tracker.
@@ -140,6 +142,18 @@ func (p *positions) remove(path string) {
 	delete(p.positions, path)
 }
 
+func (p *positions) Keys(prefix string) []string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	var keys []string
+	for k := range p.positions {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
 func (p *positions) SyncPeriod() time.Duration {
 	return p.cfg.SyncPeriod
 }
@@ -2,6 +2,7 @@ package positions
 
 import (
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -180,3 +181,25 @@ func Test_ReadOnly(t *testing.T) {
 	}, out)
 
 }
+
+func Test_Keys(t *testing.T) {
+	temp := tempFilename(t)
+	defer func() {
+		_ = os.Remove(temp)
+	}()
+	p, err := New(util_log.Logger, Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: temp,
+	})
+	require.NoError(t, err)
+	defer p.Stop()
+
+	p.PutString(CursorKey("s3://logs/a.log"), "abc:1")
+	p.PutString(CursorKey("s3://logs/b.log"), "abc:done:0")
+	p.Put("/var/log/c.log", 10)
+
+	keys := p.Keys(CursorKey("s3://logs/"))
+	sort.Strings(keys)
+	require.Equal(t, []string{CursorKey("s3://logs/a.log"), CursorKey("s3://logs/b.log")}, keys)
+	require.Empty(t, p.Keys(CursorKey("s3://other/")))
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/config"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/remoteconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/server"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
@@ -79,6 +80,14 @@ type Promtail struct {
 	newConfig    func() (*config.Config, error)
 	metrics      *client.Metrics
 	dryRun       bool
+
+	remoteConfig *remoteconfig.Manager
+	// remoteMtx serializes the reloads of the local and remote configs.
+	remoteMtx sync.Mutex
+	// localConfig is the last config loaded from the file and flags, and
+	// remote the last remote config applied over it, if any.
+	localConfig config.Config
+	remote      *remoteconfig.Remote
 }
 
 // New makes a new Promtail.
@@ -107,6 +116,13 @@ func New(cfg config.Config, newConfig func() (*config.Config, error), metrics *c
 	if err != nil {
 		return nil, fmt.Errorf("error register prometheus collector reloadFailTotal :%w", err)
 	}
+	if cfg.RemoteConfig.Enabled() {
+		promtail.remoteConfig, err = remoteconfig.New(cfg.RemoteConfig, promtail, promtail.logger, promtail.reg)
+		if err != nil {
+			return nil, fmt.Errorf("error creating remote config manager: %w", err)
+		}
+	}
+	promtail.localConfig = cfg
 	err = promtail.reloadConfig(&cfg)
 	if err != nil {
 		return nil, err
@@ -131,11 +147,12 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 	}
 	newConf := cfg.String()
 	level.Info(p.logger).Log("msg", "Reloading configuration file", "md5sum", fmt.Sprintf("%x", md5.Sum([]byte(newConf))))
-	if p.targetManagers != nil {
+	// Stop the previous config if it is running. It must be possible to load
+	// it again if loading the new one fails.
+	if p.configLoaded != "" {
 		p.targetManagers.Stop()
-	}
-	if p.client != nil {
 		p.client.Stop()
+		p.configLoaded = ""
 	}
 
 	cfg.Setup(p.logger)
@@ -186,6 +203,7 @@ func (p *Promtail) reloadConfig(cfg *config.Config) error {
 
 	tms, err := targets.NewTargetManagers(p, p.reg, p.logger, cfg.PositionsConfig, p.entriesFanout, cfg.ScrapeConfig, &cfg.TargetConfig, cfg.Global.FileWatch, &cfg.LimitsConfig)
 	if err != nil {
+		p.client.Stop()
 		return err
 	}
 	p.targetManagers = tms
@@ -212,6 +230,9 @@ func (p *Promtail) Run() error {
 	}
 	p.mtx.Unlock() // unlock before blocking
 	go p.watchConfig()
+	if p.remoteConfig != nil {
+		p.remoteConfig.Start()
+	}
 	return p.server.Run()
 }
 
@@ -222,6 +243,10 @@ func (p *Promtail) Client() client.Client {
 
 // Shutdown the promtail.
 func (p *Promtail) Shutdown() {
+	// The remote config is stopped first as applying it requires the lock.
+	if p.remoteConfig != nil {
+		p.remoteConfig.Stop()
+	}
 	p.mtx.Lock()
 	defer p.mtx.Unlock()
 	if p.stopped {
@@ -231,7 +256,7 @@ func (p *Promtail) Shutdown() {
 	if p.server != nil {
 		p.server.Shutdown()
 	}
-	if p.targetManagers != nil {
+	if p.configLoaded != "" {
 		p.targetManagers.Stop()
 	}
 	if p.entriesFanout != nil {
@@ -287,12 +312,65 @@ func (p *Promtail) reload() error {
 		reloadFailTotal.Inc()
 		return fmt.Errorf("Error new Config: %w", err)
 	}
-	err = p.reloadConfig(cfg)
+	p.remoteMtx.Lock()
+	defer p.remoteMtx.Unlock()
+	local := *cfg
+	err = p.reloadConfig(withRemoteConfig(cfg, p.remote))
 	if err != nil {
 		reloadFailTotal.Inc()
 		level.Error(p.logger).Log("msg", "Error reloading config", "err", err)
 		return err
 	}
+	p.localConfig = local
 	reloadSuccessTotal.Inc()
 	return nil
 }
+
+// ApplyRemoteConfig implements remoteconfig.Applier, it reloads the local
+// config with the scrape configs of the remote config. If it fails, the
+// previous config is reloaded.
+func (p *Promtail) ApplyRemoteConfig(r *remoteconfig.Remote) error {
+	p.remoteMtx.Lock()
+	defer p.remoteMtx.Unlock()
+	local := p.localConfig
+	err := p.reloadConfig(withRemoteConfig(&local, r))
+	switch {
+	case errors.Is(err, errConfigNotChange):
+	case err != nil:
+		reloadFailTotal.Inc()
+		level.Error(p.logger).Log("msg", "Error reloading remote config, rolling back", "version", r.Version, "err", err)
+		if rollbackErr := p.reloadConfig(withRemoteConfig(&local, p.remote)); rollbackErr != nil {
+			return fmt.Errorf("%w, and rolling back failed: %v", err, rollbackErr)
+		}
+		return err
+	default:
+		reloadSuccessTotal.Inc()
+	}
+	p.remote = r
+	return nil
+}
+
+// EffectiveConfig implements remoteconfig.Applier.
+func (p *Promtail) EffectiveConfig() string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.configLoaded
+}
+
+// Ready implements remoteconfig.Applier.
+func (p *Promtail) Ready() bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	return p.configLoaded != "" && p.targetManagers.Ready()
+}
+
+// withRemoteConfig returns the local config with the scrape configs of the
+// remote config, if any.
+func withRemoteConfig(local *config.Config, remote *remoteconfig.Remote) *config.Config {
+	if remote == nil {
+		return local
+	}
+	cfg := *local
+	cfg.ScrapeConfig = remote.ScrapeConfigs
+	return &cfg
+}
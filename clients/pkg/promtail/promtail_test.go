@@ -34,6 +34,7 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/client"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/config"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/remoteconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/server"
 	pserver "github.com/grafana/loki/v3/clients/pkg/promtail/server"
@@ -836,6 +837,58 @@ func Test_ReloadFail_NotPanic(t *testing.T) {
 	require.Equal(t, fmt.Sprintf("failed to reload config: %s\n", errConfigNotChange), result)
 }
 
+func Test_ApplyRemoteConfigRollback(t *testing.T) {
+	f, err := os.CreateTemp("", "Test_ApplyRemoteConfigRollback")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+
+	cfg := config.Config{
+		ServerConfig: server.Config{
+			Config: localhostConfig,
+		},
+		ClientConfig: client.Config{URL: flagext.URLValue{URL: &url.URL{Host: "string"}}},
+		PositionsConfig: positions.Config{
+			PositionsFile: f.Name(),
+			SyncPeriod:    time.Second,
+		},
+	}
+
+	prometheus.DefaultRegisterer = prometheus.NewRegistry() // reset registry, otherwise you can't create 2 weavework server.
+	p, err := New(cfg, nil, clientMetrics, true, nil)
+	require.NoError(t, err)
+	defer p.Shutdown()
+
+	good, err := remoteconfig.Parse([]byte(`
+version: v1
+scrape_configs:
+- job_name: remote
+  static_configs:
+  - labels:
+      __path__: /var/log/remote/*.log
+`), "")
+	require.NoError(t, err)
+	require.NoError(t, p.ApplyRemoteConfig(good))
+	require.Contains(t, p.EffectiveConfig(), "job_name: remote")
+
+	bad, err := remoteconfig.Parse([]byte(`
+version: v2
+scrape_configs:
+- job_name: broken
+  pipeline_stages:
+  - regex:
+      expression: "("
+  static_configs:
+  - labels:
+      __path__: /var/log/broken/*.log
+`), "")
+	require.NoError(t, err)
+	require.Error(t, p.ApplyRemoteConfig(bad))
+	// The previous remote config is running again.
+	require.Contains(t, p.EffectiveConfig(), "job_name: remote")
+	require.NotContains(t, p.EffectiveConfig(), "job_name: broken")
+	require.Same(t, good, p.remote)
+}
+
 func reload(t *testing.T, httpListenAddr net.Addr) (string, error) {
 	resp, err := http.Get(fmt.Sprintf("http://%s/reload", httpListenAddr))
 	if err != nil {
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"errors"
+
+	"github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+const (
+	defaultMaxSize     = 1 << 30 // 1GiB
+	defaultSegmentSize = 8 << 20 // 8MiB
+)
+
+// Config contains the settings of the on-disk queue of a client.
+type Config struct {
+	// Whether the batches are written to the queue before being sent.
+	Enabled bool `yaml:"enabled"`
+
+	// Dir is the directory the queues are written to, each client having its
+	// own sub-directory named after its name, or its URL and tenant.
+	Dir string `yaml:"dir"`
+
+	// MaxSize is the maximum size of the queue on disk, the oldest batches
+	// are evicted once reached. Default: 1GiB.
+	MaxSize flagext.ByteSize `yaml:"max_size"`
+
+	// SegmentSize is the size of the files the queue is split into, the
+	// batches are evicted a segment at a time. Default: 8MiB.
+	SegmentSize flagext.ByteSize `yaml:"segment_size"`
+}
+
+// UnmarshalYAML implement YAML Unmarshaler
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Apply defaults
+	c.MaxSize = defaultMaxSize
+	c.SegmentSize = defaultSegmentSize
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Dir == "" {
+		return errors.New("the queue directory is required")
+	}
+	if c.SegmentSize == 0 || c.SegmentSize > c.MaxSize/2 {
+		return errors.New("the queue segment size must be positive and at most half the max size")
+	}
+	return nil
+}
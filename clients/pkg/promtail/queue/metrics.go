@@ -0,0 +1,70 @@
+package queue
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/utils"
+)
+
+// Metrics holds the metrics of the on-disk queues.
+type Metrics struct {
+	sizeBytes         *prometheus.GaugeVec
+	appendedBytes     *prometheus.CounterVec
+	evictedSegments   *prometheus.CounterVec
+	evictedBytes      *prometheus.CounterVec
+	evictedBatches    *prometheus.CounterVec
+	corruptedSegments *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics of the on-disk queues. If reg is non-nil,
+// the metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		sizeBytes: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "size_bytes",
+			Help:      "Size on disk of the queue of a client, including the batches already sent of its oldest segment.",
+		}, []string{"client"}),
+		appendedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "appended_bytes_total",
+			Help:      "Total number of bytes of batches written to the queue of a client.",
+		}, []string{"client"}),
+		evictedSegments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "evicted_segments_total",
+			Help:      "Total number of segments evicted because the queue of a client reached its max size.",
+		}, []string{"client"}),
+		evictedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "evicted_bytes_total",
+			Help:      "Total number of bytes of batches evicted before being sent because the queue of a client reached its max size.",
+		}, []string{"client"}),
+		evictedBatches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "evicted_batches_total",
+			Help:      "Total number of batches evicted before being sent because the queue of a client reached its max size.",
+		}, []string{"client"}),
+		corruptedSegments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "queue",
+			Name:      "corrupted_segments_total",
+			Help:      "Total number of segments of the queue of a client found corrupted, their remaining batches are skipped.",
+		}, []string{"client"}),
+	}
+
+	if reg != nil {
+		m.sizeBytes = utils.MustRegisterOrGet(reg, m.sizeBytes).(*prometheus.GaugeVec)
+		m.appendedBytes = utils.MustRegisterOrGet(reg, m.appendedBytes).(*prometheus.CounterVec)
+		m.evictedSegments = utils.MustRegisterOrGet(reg, m.evictedSegments).(*prometheus.CounterVec)
+		m.evictedBytes = utils.MustRegisterOrGet(reg, m.evictedBytes).(*prometheus.CounterVec)
+		m.evictedBatches = utils.MustRegisterOrGet(reg, m.evictedBatches).(*prometheus.CounterVec)
+		m.corruptedSegments = utils.MustRegisterOrGet(reg, m.corruptedSegments).(*prometheus.CounterVec)
+	}
+	return m
+}
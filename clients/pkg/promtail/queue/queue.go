@@ -0,0 +1,494 @@
+package queue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	segmentSuffix = ".segment"
+	cursorFile    = "cursor"
+
+	// recordHeaderSize is the size of the header of the records, holding the
+	// length and the checksum of the payload.
+	recordHeaderSize = 8
+)
+
+var (
+	castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+	// ErrClosed is returned when reading a closed queue.
+	ErrClosed = errors.New("queue closed")
+
+	errCorrupted = errors.New("corrupted record")
+)
+
+// Record is a batch encoded and ready to be sent.
+type Record struct {
+	TenantID string
+	Entries  int
+	Buf      []byte
+}
+
+// Position is the position of a record in the queue.
+type Position struct {
+	Segment int
+	Offset  int64
+}
+
+// Item is a record read from the queue, to commit once sent.
+type Item struct {
+	Record
+	start, end Position
+}
+
+// EvictFunc is called for the records evicted before being read.
+type EvictFunc func(tenantID string, entries, bytes int)
+
+type segment struct {
+	seq  int
+	size int64
+}
+
+// Queue is a size-bounded queue of records written to disk.
+//
+// The records are appended to segment files, and read in order from a cursor
+// saved on disk. Once the queue reaches its max size, the oldest segments are
+// evicted even if they haven't been read yet.
+type Queue struct {
+	dir         string
+	name        string
+	maxSize     int64
+	segmentSize int64
+	metrics     *Metrics
+	logger      log.Logger
+	onEvict     EvictFunc
+
+	mtx      sync.Mutex
+	segments []segment // ordered from the oldest to the head
+	head     *os.File
+	cursor   Position
+	size     int64
+	closed   bool
+
+	// notify is signaled when records are appended.
+	notify chan struct{}
+	done   chan struct{}
+}
+
+// Open opens the queue written in dir, creating it if needed. The records
+// are read from the cursor saved by the previous instance.
+func Open(dir, name string, maxSize, segmentSize int64, metrics *Metrics, logger log.Logger, onEvict EvictFunc) (*Queue, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create the queue directory: %w", err)
+	}
+	q := &Queue{
+		dir:         dir,
+		name:        name,
+		maxSize:     maxSize,
+		segmentSize: segmentSize,
+		metrics:     metrics,
+		logger:      log.With(logger, "component", "queue", "client", name),
+		onEvict:     onEvict,
+		notify:      make(chan struct{}, 1),
+		done:        make(chan struct{}),
+	}
+
+	if err := q.loadSegments(); err != nil {
+		return nil, err
+	}
+	if err := q.loadCursor(); err != nil {
+		return nil, err
+	}
+	if err := q.openHead(); err != nil {
+		return nil, err
+	}
+	q.metrics.sizeBytes.WithLabelValues(q.name).Set(float64(q.size))
+	q.metrics.appendedBytes.WithLabelValues(q.name).Add(0)
+	q.metrics.evictedSegments.WithLabelValues(q.name).Add(0)
+	q.metrics.evictedBytes.WithLabelValues(q.name).Add(0)
+	q.metrics.evictedBatches.WithLabelValues(q.name).Add(0)
+	q.metrics.corruptedSegments.WithLabelValues(q.name).Add(0)
+	return q, nil
+}
+
+func (q *Queue) segmentPath(seq int) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+}
+
+func (q *Queue) loadSegments() error {
+	files, err := os.ReadDir(q.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list the queue segments: %w", err)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), segmentSuffix) {
+			continue
+		}
+		seq, err := strconv.Atoi(strings.TrimSuffix(f.Name(), segmentSuffix))
+		if err != nil {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		q.segments = append(q.segments, segment{seq: seq, size: info.Size()})
+		q.size += info.Size()
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].seq < q.segments[j].seq })
+	return nil
+}
+
+func (q *Queue) loadCursor() error {
+	content, err := os.ReadFile(filepath.Join(q.dir, cursorFile))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read the queue cursor: %w", err)
+	}
+	if len(content) > 0 {
+		if _, err := fmt.Sscanf(string(content), "%d %d", &q.cursor.Segment, &q.cursor.Offset); err != nil {
+			level.Warn(q.logger).Log("msg", "invalid queue cursor, reading from the oldest segment", "err", err)
+			q.cursor = Position{}
+		}
+	}
+	// The segment of the cursor may have been evicted, or fully read and
+	// removed.
+	if len(q.segments) > 0 && q.cursor.Segment < q.segments[0].seq {
+		q.cursor = Position{Segment: q.segments[0].seq}
+	}
+	return nil
+}
+
+// openHead opens the newest segment to append to it, after truncating the
+// record partially written before a crash.
+func (q *Queue) openHead() error {
+	// The segments older than the cursor have been read already.
+	for len(q.segments) > 0 && q.segments[0].seq < q.cursor.Segment {
+		q.removeOldest()
+	}
+	if len(q.segments) == 0 {
+		return q.newHead(q.cursor.Segment)
+	}
+	last := &q.segments[len(q.segments)-1]
+	f, err := os.OpenFile(q.segmentPath(last.seq), os.O_RDWR, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to open the queue head segment: %w", err)
+	}
+	valid, err := validSize(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if valid != last.size {
+		level.Warn(q.logger).Log("msg", "truncating the partially written records of the queue head segment", "segment", last.seq, "size", last.size, "valid", valid)
+		if err := f.Truncate(valid); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to truncate the queue head segment: %w", err)
+		}
+		q.size -= last.size - valid
+		last.size = valid
+	}
+	if q.cursor.Segment == last.seq && q.cursor.Offset > valid {
+		q.cursor.Offset = valid
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		_ = f.Close()
+		return err
+	}
+	q.head = f
+	return nil
+}
+
+func (q *Queue) newHead(seq int) error {
+	f, err := os.OpenFile(q.segmentPath(seq), os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create the queue segment: %w", err)
+	}
+	q.head = f
+	q.segments = append(q.segments, segment{seq: seq})
+	return nil
+}
+
+// validSize returns the size of the valid records at the start of the file.
+func validSize(f *os.File) (int64, error) {
+	var offset int64
+	for {
+		_, n, err := readRecord(f, offset)
+		if err == io.EOF || errors.Is(err, errCorrupted) || err == io.ErrUnexpectedEOF {
+			return offset, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		offset += n
+	}
+}
+
+// Append writes the record to the queue, evicting the oldest segments if
+// the queue exceeds its max size.
+func (q *Queue) Append(r Record) error {
+	payload := encodeRecord(r)
+	buf := make([]byte, recordHeaderSize, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:8], crc32.Checksum(payload, castagnoliTable))
+	buf = append(buf, payload...)
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.closed {
+		return ErrClosed
+	}
+
+	head := &q.segments[len(q.segments)-1]
+	if head.size > 0 && head.size+int64(len(buf)) > q.segmentSize {
+		if err := q.rotate(); err != nil {
+			return err
+		}
+		head = &q.segments[len(q.segments)-1]
+	}
+	if _, err := q.head.Write(buf); err != nil {
+		return fmt.Errorf("failed to write to the queue: %w", err)
+	}
+	if err := q.head.Sync(); err != nil {
+		return fmt.Errorf("failed to sync the queue: %w", err)
+	}
+	head.size += int64(len(buf))
+	q.size += int64(len(buf))
+	q.metrics.appendedBytes.WithLabelValues(q.name).Add(float64(len(buf)))
+
+	q.evict()
+	q.metrics.sizeBytes.WithLabelValues(q.name).Set(float64(q.size))
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (q *Queue) rotate() error {
+	if err := q.head.Close(); err != nil {
+		return fmt.Errorf("failed to close the queue segment: %w", err)
+	}
+	return q.newHead(q.segments[len(q.segments)-1].seq + 1)
+}
+
+// evict removes the oldest segments while the queue exceeds its max size,
+// the head segment is never evicted.
+func (q *Queue) evict() {
+	for q.size > q.maxSize && len(q.segments) > 1 {
+		oldest := q.segments[0]
+		if oldest.seq == q.cursor.Segment {
+			q.reportEvicted(oldest.seq, q.cursor.Offset)
+			q.cursor = Position{Segment: q.segments[1].seq}
+			q.saveCursor()
+		}
+		q.removeOldest()
+		q.metrics.evictedSegments.WithLabelValues(q.name).Inc()
+	}
+}
+
+// reportEvicted reports the records of the segment not read yet.
+func (q *Queue) reportEvicted(seq int, offset int64) {
+	f, err := os.Open(q.segmentPath(seq))
+	if err != nil {
+		level.Warn(q.logger).Log("msg", "failed to open the evicted queue segment", "segment", seq, "err", err)
+		return
+	}
+	defer f.Close()
+	for {
+		r, n, err := readRecord(f, offset)
+		if err != nil {
+			return
+		}
+		offset += n
+		q.metrics.evictedBatches.WithLabelValues(q.name).Inc()
+		q.metrics.evictedBytes.WithLabelValues(q.name).Add(float64(len(r.Buf)))
+		if q.onEvict != nil {
+			q.onEvict(r.TenantID, r.Entries, len(r.Buf))
+		}
+	}
+}
+
+func (q *Queue) removeOldest() {
+	oldest := q.segments[0]
+	if err := os.Remove(q.segmentPath(oldest.seq)); err != nil {
+		level.Warn(q.logger).Log("msg", "failed to remove the queue segment", "segment", oldest.seq, "err", err)
+	}
+	q.size -= oldest.size
+	q.segments = q.segments[1:]
+}
+
+// Next returns the record at the cursor, waiting for one to be appended if
+// all the records have been read. It returns the context error if the
+// context is done first, and ErrClosed if the queue is closed.
+func (q *Queue) Next(ctx context.Context) (Item, error) {
+	for {
+		item, ok, err := q.next()
+		if err != nil || ok {
+			return item, err
+		}
+		select {
+		case <-ctx.Done():
+			return Item{}, ctx.Err()
+		case <-q.done:
+			return Item{}, ErrClosed
+		case <-q.notify:
+		}
+	}
+}
+
+func (q *Queue) next() (Item, bool, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.closed {
+		return Item{}, false, ErrClosed
+	}
+
+	for {
+		head := q.segments[len(q.segments)-1]
+		current := q.segments[0]
+		if q.cursor.Offset >= current.size {
+			if current.seq == head.seq {
+				return Item{}, false, nil
+			}
+			// The oldest segment has been entirely read.
+			q.cursor = Position{Segment: q.segments[1].seq}
+			q.saveCursor()
+			q.removeOldest()
+			q.metrics.sizeBytes.WithLabelValues(q.name).Set(float64(q.size))
+			continue
+		}
+
+		f, err := os.Open(q.segmentPath(current.seq))
+		if err != nil {
+			return Item{}, false, fmt.Errorf("failed to open the queue segment: %w", err)
+		}
+		r, n, err := readRecord(f, q.cursor.Offset)
+		_ = f.Close()
+		if err != nil {
+			// Skip the rest of the corrupted segment.
+			level.Warn(q.logger).Log("msg", "skipping the corrupted end of the queue segment", "segment", current.seq, "offset", q.cursor.Offset, "err", err)
+			q.metrics.corruptedSegments.WithLabelValues(q.name).Inc()
+			q.cursor.Offset = current.size
+			if current.seq == head.seq {
+				// Start a new head segment, to not append after the corrupted records.
+				if err := q.rotate(); err != nil {
+					return Item{}, false, err
+				}
+			}
+			continue
+		}
+		return Item{
+			Record: r,
+			start:  q.cursor,
+			end:    Position{Segment: q.cursor.Segment, Offset: q.cursor.Offset + n},
+		}, true, nil
+	}
+}
+
+// Commit moves the cursor after the item, unless it has been evicted in
+// the meantime.
+func (q *Queue) Commit(item Item) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.closed || q.cursor != item.start {
+		return
+	}
+	q.cursor = item.end
+	q.saveCursor()
+}
+
+// saveCursor writes the cursor to disk, atomically.
+func (q *Queue) saveCursor() {
+	path := filepath.Join(q.dir, cursorFile)
+	tmp := path + ".tmp"
+	err := os.WriteFile(tmp, []byte(fmt.Sprintf("%d %d\n", q.cursor.Segment, q.cursor.Offset)), 0o640)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		level.Warn(q.logger).Log("msg", "failed to save the queue cursor", "err", err)
+	}
+}
+
+// Close closes the queue, the records not read yet are read by the next
+// instance.
+func (q *Queue) Close() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+	close(q.done)
+	return q.head.Close()
+}
+
+func encodeRecord(r Record) []byte {
+	buf := make([]byte, 0, 2*binary.MaxVarintLen64+len(r.TenantID)+len(r.Buf))
+	buf = binary.AppendUvarint(buf, uint64(len(r.TenantID)))
+	buf = append(buf, r.TenantID...)
+	buf = binary.AppendUvarint(buf, uint64(r.Entries))
+	return append(buf, r.Buf...)
+}
+
+// readRecord reads the record at the offset, returning its size on disk.
+func readRecord(f *os.File, offset int64) (Record, int64, error) {
+	var header [recordHeaderSize]byte
+	if _, err := f.ReadAt(header[:], offset); err != nil {
+		if err == io.EOF {
+			return Record{}, 0, io.EOF
+		}
+		return Record{}, 0, err
+	}
+	length := binary.BigEndian.Uint32(header[0:4])
+	// The length can't be trusted before the checksum is verified, a record
+	// going past the end of the file is a partially written one.
+	info, err := f.Stat()
+	if err != nil {
+		return Record{}, 0, err
+	}
+	if offset+recordHeaderSize+int64(length) > info.Size() {
+		return Record{}, 0, io.ErrUnexpectedEOF
+	}
+	payload := make([]byte, length)
+	if _, err := f.ReadAt(payload, offset+recordHeaderSize); err != nil {
+		if err == io.EOF {
+			return Record{}, 0, io.ErrUnexpectedEOF
+		}
+		return Record{}, 0, err
+	}
+	if crc32.Checksum(payload, castagnoliTable) != binary.BigEndian.Uint32(header[4:8]) {
+		return Record{}, 0, errCorrupted
+	}
+
+	var r Record
+	tenantLen, n := binary.Uvarint(payload)
+	if n <= 0 || uint64(len(payload)-n) < tenantLen {
+		return Record{}, 0, errCorrupted
+	}
+	payload = payload[n:]
+	r.TenantID = string(payload[:tenantLen])
+	payload = payload[tenantLen:]
+	entries, n := binary.Uvarint(payload)
+	if n <= 0 {
+		return Record{}, 0, errCorrupted
+	}
+	r.Entries = int(entries)
+	r.Buf = payload[n:]
+	return r, recordHeaderSize + int64(length), nil
+}
@@ -0,0 +1,180 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestQueue(t *testing.T, dir string, maxSize, segmentSize int64, onEvict EvictFunc) (*Queue, *Metrics) {
+	t.Helper()
+	metrics := NewMetrics(prometheus.NewRegistry())
+	q, err := Open(dir, "test", maxSize, segmentSize, metrics, log.NewNopLogger(), onEvict)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = q.Close() })
+	return q, metrics
+}
+
+func record(i int) Record {
+	return Record{TenantID: fmt.Sprintf("tenant-%d", i%2), Entries: i, Buf: []byte(fmt.Sprintf("batch-%03d", i))}
+}
+
+func next(t *testing.T, q *Queue) Item {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	item, err := q.Next(ctx)
+	require.NoError(t, err)
+	return item
+}
+
+func Test_QueueAppendNextCommit(t *testing.T) {
+	q, _ := openTestQueue(t, t.TempDir(), 1<<20, 64, nil)
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, q.Append(record(i)))
+	}
+	for i := 0; i < 5; i++ {
+		item := next(t, q)
+		require.Equal(t, record(i), item.Record)
+		// The item is read again until committed.
+		require.Equal(t, record(i), next(t, q).Record)
+		q.Commit(item)
+	}
+
+	// The segments entirely read are removed.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := q.Next(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	files, err := filepath.Glob(filepath.Join(q.dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// Next waits for records to be appended.
+	done := make(chan Item)
+	go func() { done <- next(t, q) }()
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, q.Append(record(5)))
+	select {
+	case item := <-done:
+		require.Equal(t, record(5), item.Record)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the record")
+	}
+}
+
+func Test_QueueReplayAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := openTestQueue(t, dir, 1<<20, 64, nil)
+	for i := 0; i < 6; i++ {
+		require.NoError(t, q.Append(record(i)))
+	}
+	for i := 0; i < 3; i++ {
+		q.Commit(next(t, q))
+	}
+	require.NoError(t, q.Close())
+
+	// Simulate a record partially written before a crash.
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	f, err := os.OpenFile(files[len(files)-1], os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0, 0, 1})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	q, _ = openTestQueue(t, dir, 1<<20, 64, nil)
+	require.NoError(t, q.Append(record(6)))
+	for i := 3; i < 7; i++ {
+		item := next(t, q)
+		require.Equal(t, record(i), item.Record)
+		q.Commit(item)
+	}
+}
+
+func Test_QueueEviction(t *testing.T) {
+	var (
+		evictedEntries int
+		evictedBatches int
+	)
+	// Each record is 27 bytes, so a segment holds two records and the queue
+	// at most three segments.
+	q, metrics := openTestQueue(t, t.TempDir(), 3*54, 54, func(_ string, entries, _ int) {
+		evictedBatches++
+		evictedEntries += entries
+	})
+
+	item := next(t, appendAll(t, q, 0, 1))
+	q.Commit(item)
+	appendAll(t, q, 2, 9)
+
+	// Records 1 to 3 are evicted with the two oldest segments, record 0 was
+	// already sent.
+	require.Equal(t, 3, evictedBatches)
+	require.Equal(t, 1+2+3, evictedEntries)
+	require.Equal(t, float64(2), testutil.ToFloat64(metrics.evictedSegments.WithLabelValues("test")))
+	require.Equal(t, float64(3), testutil.ToFloat64(metrics.evictedBatches.WithLabelValues("test")))
+	require.Equal(t, float64(3*54), testutil.ToFloat64(metrics.sizeBytes.WithLabelValues("test")))
+
+	for i := 4; i < 10; i++ {
+		item := next(t, q)
+		require.Equal(t, record(i), item.Record)
+		q.Commit(item)
+	}
+}
+
+func Test_QueueCommitAfterEviction(t *testing.T) {
+	q, _ := openTestQueue(t, t.TempDir(), 3*54, 54, nil)
+	appendAll(t, q, 0, 1)
+	item := next(t, q)
+	appendAll(t, q, 2, 7)
+
+	// The item being sent has been evicted, committing it must not move the
+	// cursor past the records not read yet.
+	q.Commit(item)
+	require.Equal(t, record(2), next(t, q).Record)
+}
+
+func appendAll(t *testing.T, q *Queue, from, to int) *Queue {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		require.NoError(t, q.Append(record(i)))
+	}
+	return q
+}
+
+func Test_QueueRecordLengthPastEndOfFile(t *testing.T) {
+	dir := t.TempDir()
+	q, _ := openTestQueue(t, dir, 1<<20, 1<<10, nil)
+	appendAll(t, q, 0, 1)
+	require.NoError(t, q.Close())
+
+	// A garbage header claiming a 4GiB record must not be allocated.
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	f, err := os.OpenFile(files[0], os.O_APPEND|os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	require.NoError(t, err)
+	_, _, err = readRecord(f, 2*27)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	require.NoError(t, f.Close())
+
+	q, _ = openTestQueue(t, dir, 1<<20, 1<<10, nil)
+	appendAll(t, q, 2, 2)
+	for i := 0; i < 3; i++ {
+		item := next(t, q)
+		require.Equal(t, record(i), item.Record)
+		q.Commit(item)
+	}
+}
@@ -0,0 +1,63 @@
+package remoteconfig
+
+import (
+	"errors"
+	"time"
+
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/config"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultTimeout      = 10 * time.Second
+)
+
+// Config contains the settings of the remote configuration of promtail.
+type Config struct {
+	// URL of the endpoint serving the scrape configs. Remote configuration is
+	// disabled when empty.
+	URL flagext.URLValue `yaml:"url"`
+
+	// Labels identify this promtail to the endpoint, they are sent as query
+	// parameters so the endpoint can select the configuration of the agent.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// PollInterval is how often the configuration is fetched. Default: 1m.
+	PollInterval time.Duration `yaml:"poll_interval"`
+
+	// Timeout of the requests to the endpoint. Default: 10s.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// DisableReporting disables the status reported to the endpoint after
+	// each poll.
+	DisableReporting bool `yaml:"disable_reporting"`
+
+	Client config.HTTPClientConfig `yaml:",inline"`
+}
+
+// UnmarshalYAML implement YAML Unmarshaler
+func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	// Apply defaults
+	c.PollInterval = defaultPollInterval
+	c.Timeout = defaultTimeout
+	c.Client = config.DefaultHTTPClientConfig
+	type plain Config
+	return unmarshal((*plain)(c))
+}
+
+// Enabled returns whether the remote configuration is enabled.
+func (c *Config) Enabled() bool {
+	return c.URL.URL != nil
+}
+
+// Validate validates the config.
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.PollInterval <= 0 {
+		return errors.New("the remote config poll interval must be positive")
+	}
+	return c.Client.Validate()
+}
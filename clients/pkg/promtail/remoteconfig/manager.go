@@ -0,0 +1,316 @@
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/config"
+
+	"github.com/grafana/loki/v3/pkg/util/build"
+)
+
+const (
+	// maxConfigSize is the maximum size of the configurations accepted from
+	// the endpoint.
+	maxConfigSize = 16 << 20 // 16MiB
+	maxErrMsgLen  = 1024
+)
+
+var userAgent = fmt.Sprintf("promtail/%s", build.Version)
+
+// Applier applies the remote configurations.
+type Applier interface {
+	// ApplyRemoteConfig runs promtail with the scrape configs of the remote
+	// configuration. If it fails, the configuration promtail was running
+	// with must be restored before returning the error.
+	ApplyRemoteConfig(r *Remote) error
+	// EffectiveConfig returns the configuration promtail is running with.
+	EffectiveConfig() string
+	// Ready returns whether promtail is ready.
+	Ready() bool
+}
+
+// Status is reported to the endpoint after each poll.
+type Status struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	// Version of the remote configuration promtail is running with, empty
+	// until one is applied.
+	Version string `json:"version"`
+	// RejectedVersion is the last version which could not be applied, and
+	// Error the reason why. They are cleared once a new version is applied.
+	RejectedVersion string `json:"rejected_version,omitempty"`
+	Error           string `json:"error,omitempty"`
+	Ready           bool   `json:"ready"`
+	// Config is the effective configuration, secrets masked.
+	Config string `json:"config"`
+}
+
+type metrics struct {
+	fetchFailures  prometheus.Counter
+	applied        prometheus.Counter
+	rejected       prometheus.Counter
+	reportFailures prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := &metrics{
+		fetchFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "remote_config",
+			Name:      "fetch_failures_total",
+			Help:      "Number of failed fetches of the remote configuration.",
+		}),
+		applied: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "remote_config",
+			Name:      "applied_total",
+			Help:      "Number of versions of the remote configuration applied.",
+		}),
+		rejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "remote_config",
+			Name:      "rejected_total",
+			Help:      "Number of versions of the remote configuration which were invalid or rolled back.",
+		}),
+		reportFailures: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "promtail",
+			Subsystem: "remote_config",
+			Name:      "report_failures_total",
+			Help:      "Number of failed status reports to the remote configuration endpoint.",
+		}),
+	}
+	for _, c := range []prometheus.Collector{m.fetchFailures, m.applied, m.rejected, m.reportFailures} {
+		if err := reg.Register(c); err != nil {
+			return nil, fmt.Errorf("error register prometheus collector: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// Manager polls the remote configuration, applies its new versions and
+// reports the status of promtail back to the endpoint.
+type Manager struct {
+	cfg     Config
+	url     string
+	applier Applier
+	client  *http.Client
+	logger  log.Logger
+	metrics *metrics
+
+	// Only accessed by the polling goroutine.
+	etag     string
+	version  string
+	rejected string
+	applyErr error
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a Manager, which must be started to poll the endpoint.
+func New(cfg Config, applier Applier, logger log.Logger, reg prometheus.Registerer) (*Manager, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+
+	client, err := config.NewClientFromConfig(cfg.Client, "promtail_remote_config", config.WithHTTP2Disabled())
+	if err != nil {
+		return nil, err
+	}
+	m, err := newMetrics(reg)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *cfg.URL.URL
+	q := u.Query()
+	for name, value := range cfg.Labels {
+		q.Set(name, value)
+	}
+	u.RawQuery = q.Encode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		cfg:     cfg,
+		url:     u.String(),
+		applier: applier,
+		client:  client,
+		logger:  log.With(logger, "component", "remote_config", "url", cfg.URL.String()),
+		metrics: m,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// Start polls the endpoint in the background, starting immediately.
+func (m *Manager) Start() {
+	m.wg.Add(1)
+	go m.run()
+}
+
+// Stop stops polling the endpoint.
+func (m *Manager) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+func (m *Manager) run() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		m.poll()
+		select {
+		case <-m.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll fetches the configuration, applies it if it is a new version and
+// reports the status.
+func (m *Manager) poll() {
+	b, etag, err := m.fetch()
+	switch {
+	case err != nil:
+		m.metrics.fetchFailures.Inc()
+		level.Warn(m.logger).Log("msg", "failed to fetch remote config", "err", err)
+	case b != nil:
+		m.etag = etag
+		m.update(b, etag)
+	}
+
+	if m.cfg.DisableReporting || m.ctx.Err() != nil {
+		return
+	}
+	if err := m.report(); err != nil {
+		m.metrics.reportFailures.Inc()
+		level.Warn(m.logger).Log("msg", "failed to report status to remote config endpoint", "err", err)
+	}
+}
+
+func (m *Manager) update(b []byte, etag string) {
+	r, err := Parse(b, etag)
+	if err != nil {
+		m.reject(implicitVersion(b, etag), fmt.Errorf("invalid remote config: %w", err))
+		return
+	}
+	if r.Version == m.version || r.Version == m.rejected {
+		return
+	}
+
+	level.Info(m.logger).Log("msg", "applying remote config", "version", r.Version, "previous_version", m.version)
+	if err := m.applier.ApplyRemoteConfig(r); err != nil {
+		m.reject(r.Version, fmt.Errorf("remote config rolled back: %w", err))
+		return
+	}
+	m.metrics.applied.Inc()
+	m.version = r.Version
+	m.rejected = ""
+	m.applyErr = nil
+}
+
+func (m *Manager) reject(version string, err error) {
+	if version == m.rejected {
+		return
+	}
+	m.metrics.rejected.Inc()
+	level.Error(m.logger).Log("msg", "rejected remote config", "version", version, "current_version", m.version, "err", err)
+	m.rejected = version
+	m.applyErr = err
+}
+
+// fetch returns the configuration and its ETag, or a nil configuration if
+// it has not been modified since the last fetch.
+func (m *Manager) fetch() ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	if m.etag != "" {
+		req.Header.Set("If-None-Match", m.etag)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, "", nil
+	case resp.StatusCode/100 != 2:
+		return nil, "", responseError(resp)
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(b) > maxConfigSize {
+		return nil, "", fmt.Errorf("remote config is larger than %d bytes", maxConfigSize)
+	}
+	return b, resp.Header.Get("ETag"), nil
+}
+
+// report sends the status to the endpoint.
+func (m *Manager) report() error {
+	status := Status{
+		Labels:          m.cfg.Labels,
+		Version:         m.version,
+		RejectedVersion: m.rejected,
+		Ready:           m.applier.Ready(),
+		Config:          m.applier.EffectiveConfig(),
+	}
+	if m.applyErr != nil {
+		status.Error = m.applyErr.Error()
+	}
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, m.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrMsgLen))
+	return fmt.Errorf("server returned HTTP status %s: %s", resp.Status, bytes.TrimSpace(b))
+}
@@ -0,0 +1,193 @@
+package remoteconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+type fakeApplier struct {
+	mtx     sync.Mutex
+	applied []string
+	fail    map[string]bool
+}
+
+func (a *fakeApplier) ApplyRemoteConfig(r *Remote) error {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if a.fail[r.Version] {
+		return errors.New("invalid pipeline")
+	}
+	a.applied = append(a.applied, r.Version)
+	return nil
+}
+
+func (a *fakeApplier) EffectiveConfig() string {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	if len(a.applied) == 0 {
+		return "local"
+	}
+	return a.applied[len(a.applied)-1]
+}
+
+func (a *fakeApplier) Ready() bool {
+	return true
+}
+
+type fakeConfig struct {
+	body string
+	etag string
+}
+
+// fakeEndpoint serves the configs in order, one per fetch, and records the
+// status reports.
+type fakeEndpoint struct {
+	mtx     sync.Mutex
+	configs []fakeConfig
+	query   url.Values
+	reports []Status
+}
+
+func (e *fakeEndpoint) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	e.query = req.URL.Query()
+	switch req.Method {
+	case http.MethodGet:
+		cfg := e.configs[0]
+		if len(e.configs) > 1 {
+			e.configs = e.configs[1:]
+		}
+		if cfg.etag != "" {
+			if req.Header.Get("If-None-Match") == cfg.etag {
+				rw.WriteHeader(http.StatusNotModified)
+				return
+			}
+			rw.Header().Set("ETag", cfg.etag)
+		}
+		_, _ = rw.Write([]byte(cfg.body))
+	case http.MethodPost:
+		var s Status
+		if err := json.NewDecoder(req.Body).Decode(&s); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		e.reports = append(e.reports, s)
+	}
+}
+
+func newTestManager(t *testing.T, endpoint http.Handler, applier Applier, reg prometheus.Registerer) *Manager {
+	srv := httptest.NewServer(endpoint)
+	t.Cleanup(srv.Close)
+
+	var cfg Config
+	require.NoError(t, yaml.Unmarshal([]byte(`
+url: `+srv.URL+`/config?tenant=team-a
+labels:
+  cluster: eu-west
+`), &cfg))
+	m, err := New(cfg, applier, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	return m
+}
+
+func TestManager_Poll(t *testing.T) {
+	endpoint := &fakeEndpoint{configs: []fakeConfig{
+		{body: "version: v1\nscrape_configs:\n- job_name: system\n"},
+		{body: "version: v1\nscrape_configs:\n- job_name: system\n"},
+		// Versions which can't be parsed or applied are rejected.
+		{body: "scrape_configs:\n- job_name: system\n- job_name: system\n", etag: `"e3"`},
+		{body: "scrape_configs:\n- job_name: system\n- job_name: system\n", etag: `"e3"`},
+		{body: "version: v2\nscrape_configs:\n- job_name: broken\n"},
+		{body: "version: v2\nscrape_configs:\n- job_name: broken\n"},
+		// The ETag is the version when the config is not versioned.
+		{body: "scrape_configs: []\n", etag: `"e7"`},
+	}}
+	applier := &fakeApplier{fail: map[string]bool{"v2": true}}
+	reg := prometheus.NewRegistry()
+	m := newTestManager(t, endpoint, applier, reg)
+
+	for i := 0; i < 7; i++ {
+		m.poll()
+	}
+
+	require.Equal(t, []string{"v1", `"e7"`}, applier.applied)
+	require.Equal(t, url.Values{"tenant": {"team-a"}, "cluster": {"eu-west"}}, endpoint.query)
+
+	require.Len(t, endpoint.reports, 7)
+	require.Equal(t, Status{Labels: map[string]string{"cluster": "eu-west"}, Version: "v1", Ready: true, Config: "v1"}, endpoint.reports[1])
+	require.Equal(t, "v1", endpoint.reports[2].Version)
+	require.Contains(t, endpoint.reports[2].Error, `invalid remote config: found multiple scrape configs with job name "system"`)
+	require.Equal(t, `"e3"`, endpoint.reports[3].RejectedVersion)
+	require.Equal(t, "v1", endpoint.reports[5].Version)
+	require.Equal(t, "v2", endpoint.reports[5].RejectedVersion)
+	require.Equal(t, "remote config rolled back: invalid pipeline", endpoint.reports[5].Error)
+	require.Equal(t, `"e7"`, endpoint.reports[6].Version)
+	require.Empty(t, endpoint.reports[6].RejectedVersion)
+	require.Empty(t, endpoint.reports[6].Error)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP promtail_remote_config_applied_total Number of versions of the remote configuration applied.
+# TYPE promtail_remote_config_applied_total counter
+promtail_remote_config_applied_total 2
+# HELP promtail_remote_config_rejected_total Number of versions of the remote configuration which were invalid or rolled back.
+# TYPE promtail_remote_config_rejected_total counter
+promtail_remote_config_rejected_total 2
+`), "promtail_remote_config_applied_total", "promtail_remote_config_rejected_total"))
+}
+
+func TestManager_StartStop(t *testing.T) {
+	endpoint := &fakeEndpoint{configs: []fakeConfig{{body: "version: v1\nscrape_configs: []\n"}}}
+	applier := &fakeApplier{}
+	m := newTestManager(t, endpoint, applier, prometheus.NewRegistry())
+
+	m.Start()
+	require.Eventually(t, func() bool {
+		endpoint.mtx.Lock()
+		defer endpoint.mtx.Unlock()
+		return len(endpoint.reports) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	m.Stop()
+	require.Equal(t, "v1", applier.EffectiveConfig())
+}
+
+func TestManager_FetchFailure(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	applier := &fakeApplier{}
+	m := newTestManager(t, http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		http.Error(rw, "unavailable", http.StatusServiceUnavailable)
+	}), applier, reg)
+
+	m.poll()
+	require.Empty(t, applier.applied)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP promtail_remote_config_fetch_failures_total Number of failed fetches of the remote configuration.
+# TYPE promtail_remote_config_fetch_failures_total counter
+promtail_remote_config_fetch_failures_total 1
+# HELP promtail_remote_config_report_failures_total Number of failed status reports to the remote configuration endpoint.
+# TYPE promtail_remote_config_report_failures_total counter
+promtail_remote_config_report_failures_total 1
+`), "promtail_remote_config_fetch_failures_total", "promtail_remote_config_report_failures_total"))
+}
+
+func TestConfig_Validate(t *testing.T) {
+	var cfg Config
+	require.NoError(t, cfg.Validate())
+
+	cfg.URL = flagext.URLValue{URL: &url.URL{Scheme: "http", Host: "localhost"}}
+	require.EqualError(t, cfg.Validate(), "the remote config poll interval must be positive")
+}
@@ -0,0 +1,50 @@
+package remoteconfig
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+// Remote is a version of the configuration served by the endpoint.
+type Remote struct {
+	// Version identifies the configuration. When not set by the endpoint,
+	// the ETag of the response or the checksum of the configuration is used.
+	Version       string                `yaml:"version"`
+	ScrapeConfigs []scrapeconfig.Config `yaml:"scrape_configs"`
+}
+
+// Parse parses a remote configuration, etag being the ETag of the response
+// if any.
+func Parse(b []byte, etag string) (*Remote, error) {
+	r := &Remote{}
+	if err := yaml.UnmarshalStrict(b, r); err != nil {
+		return nil, err
+	}
+
+	// Validate unique names.
+	jobNames := map[string]struct{}{}
+	for _, j := range r.ScrapeConfigs {
+		if _, ok := jobNames[j.JobName]; ok {
+			return nil, fmt.Errorf("found multiple scrape configs with job name %q", j.JobName)
+		}
+		jobNames[j.JobName] = struct{}{}
+	}
+
+	if r.Version == "" {
+		r.Version = implicitVersion(b, etag)
+	}
+	return r, nil
+}
+
+// implicitVersion returns the version of a configuration without explicit
+// version.
+func implicitVersion(b []byte, etag string) string {
+	if etag != "" {
+		return etag
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b))[:16]
+}
@@ -29,23 +29,29 @@ import (
 
 	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/discovery/consulagent"
+	lokiflag "github.com/grafana/loki/v3/pkg/util/flagext"
 )
 
 // Config describes a job to scrape.
 type Config struct {
-	JobName              string                      `mapstructure:"job_name,omitempty" yaml:"job_name,omitempty"`
-	PipelineStages       stages.PipelineStages       `mapstructure:"pipeline_stages,omitempty" yaml:"pipeline_stages,omitempty"`
-	JournalConfig        *JournalTargetConfig        `mapstructure:"journal,omitempty" yaml:"journal,omitempty"`
-	SyslogConfig         *SyslogTargetConfig         `mapstructure:"syslog,omitempty" yaml:"syslog,omitempty"`
-	GcplogConfig         *GcplogTargetConfig         `mapstructure:"gcplog,omitempty" yaml:"gcplog,omitempty"`
-	PushConfig           *PushTargetConfig           `mapstructure:"loki_push_api,omitempty" yaml:"loki_push_api,omitempty"`
-	WindowsConfig        *WindowsEventsTargetConfig  `mapstructure:"windows_events,omitempty" yaml:"windows_events,omitempty"`
-	KafkaConfig          *KafkaTargetConfig          `mapstructure:"kafka,omitempty" yaml:"kafka,omitempty"`
-	AzureEventHubsConfig *AzureEventHubsTargetConfig `mapstructure:"azure_event_hubs,omitempty" yaml:"azure_event_hubs,omitempty"`
-	GelfConfig           *GelfTargetConfig           `mapstructure:"gelf,omitempty" yaml:"gelf,omitempty"`
-	CloudflareConfig     *CloudflareConfig           `mapstructure:"cloudflare,omitempty" yaml:"cloudflare,omitempty"`
-	HerokuDrainConfig    *HerokuDrainTargetConfig    `mapstructure:"heroku_drain,omitempty" yaml:"heroku_drain,omitempty"`
-	RelabelConfigs       []*relabel.Config           `mapstructure:"relabel_configs,omitempty" yaml:"relabel_configs,omitempty"`
+	JobName                string                        `mapstructure:"job_name,omitempty" yaml:"job_name,omitempty"`
+	PipelineStages         stages.PipelineStages         `mapstructure:"pipeline_stages,omitempty" yaml:"pipeline_stages,omitempty"`
+	JournalConfig          *JournalTargetConfig          `mapstructure:"journal,omitempty" yaml:"journal,omitempty"`
+	SyslogConfig           *SyslogTargetConfig           `mapstructure:"syslog,omitempty" yaml:"syslog,omitempty"`
+	GcplogConfig           *GcplogTargetConfig           `mapstructure:"gcplog,omitempty" yaml:"gcplog,omitempty"`
+	PushConfig             *PushTargetConfig             `mapstructure:"loki_push_api,omitempty" yaml:"loki_push_api,omitempty"`
+	WindowsConfig          *WindowsEventsTargetConfig    `mapstructure:"windows_events,omitempty" yaml:"windows_events,omitempty"`
+	KafkaConfig            *KafkaTargetConfig            `mapstructure:"kafka,omitempty" yaml:"kafka,omitempty"`
+	AzureEventHubsConfig   *AzureEventHubsTargetConfig   `mapstructure:"azure_event_hubs,omitempty" yaml:"azure_event_hubs,omitempty"`
+	GelfConfig             *GelfTargetConfig             `mapstructure:"gelf,omitempty" yaml:"gelf,omitempty"`
+	FluentForwardConfig    *FluentForwardTargetConfig    `mapstructure:"fluent_forward,omitempty" yaml:"fluent_forward,omitempty"`
+	S3Config               *S3TargetConfig               `mapstructure:"s3,omitempty" yaml:"s3,omitempty"`
+	MQTTConfig             *MQTTTargetConfig             `mapstructure:"mqtt,omitempty" yaml:"mqtt,omitempty"`
+	NATSConfig             *NATSTargetConfig             `mapstructure:"nats,omitempty" yaml:"nats,omitempty"`
+	KubernetesEventsConfig *KubernetesEventsTargetConfig `mapstructure:"kubernetes_events,omitempty" yaml:"kubernetes_events,omitempty"`
+	CloudflareConfig       *CloudflareConfig             `mapstructure:"cloudflare,omitempty" yaml:"cloudflare,omitempty"`
+	HerokuDrainConfig      *HerokuDrainTargetConfig      `mapstructure:"heroku_drain,omitempty" yaml:"heroku_drain,omitempty"`
+	RelabelConfigs         []*relabel.Config             `mapstructure:"relabel_configs,omitempty" yaml:"relabel_configs,omitempty"`
 	// List of Docker service discovery configurations.
 	DockerSDConfigs        []*moby.DockerSDConfig `mapstructure:"docker_sd_configs,omitempty" yaml:"docker_sd_configs,omitempty"`
 	ServiceDiscoveryConfig ServiceDiscoveryConfig `mapstructure:",squash" yaml:",inline"`
@@ -365,6 +371,105 @@ type KafkaSASLConfig struct {
 	TLSConfig promconfig.TLSConfig `yaml:",inline"`
 }
 
+// MQTTTargetConfig describes a scrape config that subscribes to MQTT topics.
+type MQTTTargetConfig struct {
+	// Labels optionally holds labels to associate with each log line.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// The list of brokers to connect to, e.g. tcp://localhost:1883 (Required).
+	Brokers []string `yaml:"brokers"`
+
+	// Topic filters to subscribe to, wildcards are supported (Required).
+	Topics []string `yaml:"topics"`
+
+	// ClientID identifies the persistent session of the client on the brokers. (Default to promtail)
+	ClientID string `yaml:"client_id"`
+
+	// SharedGroup subscribes to the topics through the `$share/<group>/` shared
+	// subscriptions, the messages are then balanced between the clients of the group.
+	SharedGroup string `yaml:"shared_group"`
+
+	// QoS is the quality of service of the subscriptions, 0, 1 or 2. (Default to 1)
+	QoS *byte `yaml:"qos"`
+
+	// CleanSession discards the session, and the messages not acknowledged yet,
+	// when connecting to the brokers.
+	CleanSession bool `yaml:"clean_session"`
+
+	// Username and Password to authenticate with the brokers.
+	Username string         `yaml:"username"`
+	Password flagext.Secret `yaml:"password"`
+
+	// TLSConfig is used for the TLS connections to the brokers.
+	TLSConfig promconfig.TLSConfig `yaml:"tls_config,omitempty"`
+}
+
+// NATSTargetConfig describes a scrape config that consumes the messages of a
+// NATS JetStream stream.
+type NATSTargetConfig struct {
+	// Labels optionally holds labels to associate with each log line.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the time the messages were
+	// stored in the stream.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+
+	// The list of servers to connect to, e.g. nats://localhost:4222 (Required).
+	URLs []string `yaml:"urls"`
+
+	// Stream to consume (Required).
+	Stream string `yaml:"stream"`
+
+	// Consumer is the name of the durable consumer shared by the promtail
+	// instances. (Default to promtail)
+	Consumer string `yaml:"consumer"`
+
+	// Subjects optionally restricts the messages consumed to these subjects,
+	// wildcards are supported.
+	Subjects []string `yaml:"subjects"`
+
+	// DeliverPolicy sets where the consumer starts when created, one of all,
+	// new or last. (Default to all)
+	DeliverPolicy string `yaml:"deliver_policy"`
+
+	// AckWait is the time after which a message not acknowledged is delivered again. (Default to 30s)
+	AckWait time.Duration `yaml:"ack_wait"`
+
+	// Username and Password, Token or CredentialsFile to authenticate with the servers.
+	Username        string         `yaml:"username"`
+	Password        flagext.Secret `yaml:"password"`
+	Token           flagext.Secret `yaml:"token"`
+	CredentialsFile string         `yaml:"credentials_file"`
+
+	// TLSConfig is used for the TLS connections to the servers.
+	TLSConfig promconfig.TLSConfig `yaml:"tls_config,omitempty"`
+}
+
+// KubernetesEventsTargetConfig describes a scrape config that watches the
+// events of a Kubernetes cluster.
+type KubernetesEventsTargetConfig struct {
+	// APIServer is the address of the API server, the in-cluster configuration
+	// is used when neither it or KubeConfig are set.
+	APIServer promconfig.URL `yaml:"api_server,omitempty"`
+
+	// KubeConfig is the path of a kubeconfig file to use instead of APIServer.
+	KubeConfig string `yaml:"kubeconfig_file"`
+
+	// HTTPClientConfig is used to connect to the APIServer.
+	HTTPClientConfig promconfig.HTTPClientConfig `yaml:",inline"`
+
+	// Namespaces restricts the events watched to these namespaces, the events
+	// of all the namespaces are watched when empty.
+	Namespaces []string `yaml:"namespaces"`
+
+	// Labels optionally holds labels to associate with each event.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the time the event was last
+	// observed instead of the time it was received.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+}
+
 // GelfTargetConfig describes a scrape config that read GELF messages on UDP.
 type GelfTargetConfig struct {
 	// ListenAddress is the address to listen on UDP for gelf messages. (Default to `:12201`)
@@ -378,6 +483,79 @@ type GelfTargetConfig struct {
 	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
 }
 
+// FluentForwardTargetConfig describes a scrape config that receives messages
+// from Fluentd and Fluent Bit using the forward protocol over TCP.
+type FluentForwardTargetConfig struct {
+	// ListenAddress is the address to listen on TCP for forward messages. (Default to `:24224`)
+	ListenAddress string `yaml:"listen_address"`
+
+	// IdleTimeout is the idle timeout for tcp connections.
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+
+	// Labels optionally holds labels to associate with each record read from forward messages.
+	Labels model.LabelSet `yaml:"labels"`
+
+	// UseIncomingTimestamp sets the timestamp to the incoming forward messages
+	// timestamp if it's set.
+	UseIncomingTimestamp bool `yaml:"use_incoming_timestamp"`
+
+	// SharedKey enables the shared key authentication of the clients when set.
+	SharedKey flagext.Secret `yaml:"shared_key"`
+
+	// SelfHostname is the hostname sent to the clients during the authentication.
+	// (Default to the hostname of the machine)
+	SelfHostname string `yaml:"self_hostname"`
+
+	// MaxMessageSize is the maximum size of a message, and of the entries of a
+	// compressed message once decompressed. (Default to `32MiB`)
+	MaxMessageSize lokiflag.ByteSize `yaml:"max_message_size"`
+
+	// StructuredMetadata lists the record fields sent as structured metadata
+	// of the entries, while the record fields are otherwise only available as
+	// labels to the relabeling.
+	StructuredMetadata []string `yaml:"structured_metadata"`
+}
+
+// S3TargetConfig describes a scrape config that reads the objects written to
+// an S3 compatible bucket.
+type S3TargetConfig struct {
+	// Bucket is the name of the bucket to read the objects from.
+	Bucket string `yaml:"bucket"`
+
+	// Prefix optionally restricts the objects read to the ones with this key prefix.
+	Prefix string `yaml:"prefix"`
+
+	// Endpoint is the S3 compatible endpoint to use instead of the AWS one.
+	Endpoint string `yaml:"endpoint"`
+
+	// Region is the region of the bucket.
+	Region string `yaml:"region"`
+
+	// AccessKeyID and SecretAccessKey are the static credentials to use, the
+	// default AWS credentials chain is used when they are not set.
+	AccessKeyID     string         `yaml:"access_key_id"`
+	SecretAccessKey flagext.Secret `yaml:"secret_access_key"`
+
+	// S3ForcePathStyle forces the path style requests, required by most S3
+	// compatible servers.
+	S3ForcePathStyle bool `yaml:"s3_force_path_style"`
+
+	// SQSQueueURL is the URL of the SQS queue receiving the bucket
+	// notifications. The bucket is polled when it's not set.
+	SQSQueueURL string `yaml:"sqs_queue_url"`
+
+	// PollInterval is the interval at which the bucket is listed for new
+	// objects, listed after the key of the last object read. (Default to 1m)
+	PollInterval time.Duration `yaml:"poll_interval"`
+
+	// Format is the compression format of the objects, one of `gz`, `z`, `bz2`
+	// or `none`. (Default to the format matching the extension of the object key)
+	Format string `yaml:"format"`
+
+	// Labels optionally holds labels to associate with each line read from the objects.
+	Labels model.LabelSet `yaml:"labels"`
+}
+
 type CloudflareConfig struct {
 	// APIToken is the API key for the Cloudflare account.
 	APIToken string `yaml:"api_token"`
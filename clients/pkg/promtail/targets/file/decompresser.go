@@ -103,23 +103,25 @@ func newDecompressor(metrics *Metrics, logger log.Logger, handler api.EntryHandl
 	return decompressor, nil
 }
 
-// mountReader instantiate a reader ready to be used by the decompressor.
+// MountReader instantiate a reader ready to be used to decompress the content
+// of r, name being the name of the file or object being read.
 //
-// The selected reader implementation is based on the extension of the given file name.
-// It'll error if the extension isn't supported.
-func mountReader(f *os.File, logger log.Logger, format string) (reader io.Reader, err error) {
+// The selected reader implementation is based on the given format, one of the
+// supported compressed formats without its leading dot.
+// It'll error if the format isn't supported.
+func MountReader(r io.Reader, name string, logger log.Logger, format string) (reader io.Reader, err error) {
 	var decompressLib string
 
 	switch format {
 	case "gz":
 		decompressLib = "compress/gzip"
-		reader, err = gzip.NewReader(f)
+		reader, err = gzip.NewReader(r)
 	case "z":
 		decompressLib = "compress/zlib"
-		reader, err = zlib.NewReader(f)
+		reader, err = zlib.NewReader(r)
 	case "bz2":
 		decompressLib = "bzip2"
-		reader = bzip2.NewReader(f)
+		reader = bzip2.NewReader(r)
 	}
 
 	if err != nil && err != io.EOF {
@@ -131,10 +133,10 @@ func mountReader(f *os.File, logger log.Logger, format string) (reader io.Reader
 		for format := range supportedCompressedFormats() {
 			supportedFormatsList.WriteString(format)
 		}
-		return nil, fmt.Errorf("file %q has unsupported format, it has to be one of %q", f.Name(), supportedFormatsList.String())
+		return nil, fmt.Errorf("file %q has unsupported format, it has to be one of %q", name, supportedFormatsList.String())
 	}
 
-	level.Debug(logger).Log("msg", fmt.Sprintf("using %q to decompress file %q", decompressLib, f.Name()))
+	level.Debug(logger).Log("msg", fmt.Sprintf("using %q to decompress file %q", decompressLib, name))
 	return reader, nil
 }
 
@@ -188,7 +190,7 @@ func (t *decompressor) readLines() {
 	}
 	defer f.Close()
 
-	r, err := mountReader(f, t.logger, t.cfg.Format)
+	r, err := MountReader(f, f.Name(), t.logger, t.cfg.Format)
 	if err != nil {
 		level.Error(t.logger).Log("msg", "error mounting new reader", "err", err)
 		return
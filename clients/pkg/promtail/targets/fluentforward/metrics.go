@@ -0,0 +1,52 @@
+package fluentforward
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of fluent forward metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	entries     prometheus.Counter
+	errors      prometheus.Counter
+	authErrors  prometheus.Counter
+	connections prometheus.Gauge
+}
+
+// NewMetrics creates a new set of fluent forward metrics. If reg is non-nil,
+// the metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.entries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "fluent_forward_target_entries_total",
+		Help:      "Total number of successful entries sent to the fluent forward target",
+	})
+	m.errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "fluent_forward_target_parsing_errors_total",
+		Help:      "Total number of parsing errors while receiving fluent forward messages",
+	})
+	m.authErrors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "fluent_forward_target_authentication_failures_total",
+		Help:      "Total number of fluent forward connections that failed the shared key authentication",
+	})
+	m.connections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "promtail",
+		Name:      "fluent_forward_target_connections",
+		Help:      "Number of open fluent forward connections",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entries,
+			m.errors,
+			m.authErrors,
+			m.connections,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,338 @@
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/ugorji/go/codec"
+)
+
+// The forward protocol is described at
+// https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1
+
+const (
+	eventTimeExtType = 0
+
+	compressionGzip = "gzip"
+)
+
+// EventTime is the extended msgpack type the forward protocol uses to send
+// timestamps with a nanosecond precision.
+type EventTime struct {
+	time.Time
+}
+
+type eventTimeExt struct{}
+
+// WriteExt implements codec.BytesExt.
+func (eventTimeExt) WriteExt(v interface{}) []byte {
+	var t EventTime
+	switch v := v.(type) {
+	case EventTime:
+		t = v
+	case *EventTime:
+		t = *v
+	default:
+		panic(fmt.Sprintf("unsupported event time type %T", v))
+	}
+
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, uint32(t.Unix()))
+	binary.BigEndian.PutUint32(b[4:], uint32(t.Nanosecond()))
+	return b
+}
+
+// ReadExt implements codec.BytesExt.
+func (eventTimeExt) ReadExt(dst interface{}, src []byte) {
+	if len(src) != 8 {
+		panic(fmt.Sprintf("invalid event time length %d", len(src)))
+	}
+	*dst.(*EventTime) = EventTime{time.Unix(
+		int64(binary.BigEndian.Uint32(src)),
+		int64(binary.BigEndian.Uint32(src[4:])),
+	)}
+}
+
+// NewHandle returns the msgpack handle used to encode and decode forward
+// protocol messages.
+func NewHandle() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.RawToString = true
+	h.WriteExt = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	// Don't trust the lengths announced by the clients to allocate memory.
+	h.MaxInitLen = 1024
+	h.ReaderBufferSize = 32 * 1024
+	if err := h.SetBytesExt(reflect.TypeOf(EventTime{}), eventTimeExtType, eventTimeExt{}); err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// errMessageTooLarge is returned when a message, or the entries of a compressed
+// message once decompressed, are larger than the maximum message size.
+var errMessageTooLarge = errors.New("message larger than the maximum message size")
+
+// limitedReader fails the reads with errMessageTooLarge once more than n bytes
+// are read, where io.LimitReader would silently truncate the data.
+type limitedReader struct {
+	r io.Reader
+	n int
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// Only fail if there is data beyond the limit.
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		return 0, errMessageTooLarge
+	}
+	if len(p) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= n
+	return n, err
+}
+
+// event is a single record of a forward message.
+type event struct {
+	timestamp time.Time
+	record    map[string]interface{}
+}
+
+// message is a decoded forward message, in any of its modes.
+type message struct {
+	tag    string
+	events []event
+	// chunk is set when the client expects an acknowledgment of the message.
+	chunk string
+}
+
+// decodeMessage decodes a message received in the Message, Forward,
+// PackedForward or CompressedPackedForward mode. The entries of a compressed
+// message can't be larger than maxSize once decompressed.
+func decodeMessage(h *codec.MsgpackHandle, raw []interface{}, maxSize int) (message, error) {
+	if len(raw) < 2 {
+		return message{}, fmt.Errorf("invalid message with %d elements", len(raw))
+	}
+	tag, ok := raw[0].(string)
+	if !ok {
+		return message{}, fmt.Errorf("invalid message tag of type %T", raw[0])
+	}
+	msg := message{tag: tag}
+
+	var (
+		opts map[string]interface{}
+		err  error
+	)
+	switch entries := raw[1].(type) {
+	case []interface{}:
+		// Forward mode: [tag, [[time, record], ...], option]
+		if len(raw) > 2 {
+			if opts, err = decodeOptions(raw[2]); err != nil {
+				return message{}, err
+			}
+		}
+		msg.events = make([]event, 0, len(entries))
+		for _, entry := range entries {
+			ev, err := decodeEntry(entry)
+			if err != nil {
+				return message{}, err
+			}
+			msg.events = append(msg.events, ev)
+		}
+
+	case string, []byte:
+		// (Compressed)PackedForward mode: [tag, msgpack stream of [time, record], option]
+		if len(raw) > 2 {
+			if opts, err = decodeOptions(raw[2]); err != nil {
+				return message{}, err
+			}
+		}
+		stream := toBytes(entries)
+		var r io.Reader = bytes.NewReader(stream)
+		if compressed, _ := opts["compressed"].(string); compressed != "" {
+			if compressed != compressionGzip {
+				return message{}, fmt.Errorf("unsupported compression %q", compressed)
+			}
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return message{}, fmt.Errorf("invalid compressed entries: %w", err)
+			}
+			defer gz.Close()
+			r = &limitedReader{r: gz, n: maxSize}
+		}
+		if msg.events, err = decodeEventStream(h, r); err != nil {
+			return message{}, err
+		}
+
+	default:
+		// Message mode: [tag, time, record, option]
+		if len(raw) < 3 {
+			return message{}, fmt.Errorf("invalid message with %d elements", len(raw))
+		}
+		if len(raw) > 3 {
+			if opts, err = decodeOptions(raw[3]); err != nil {
+				return message{}, err
+			}
+		}
+		ev, err := decodeEntry(raw[1:3])
+		if err != nil {
+			return message{}, err
+		}
+		msg.events = []event{ev}
+	}
+
+	if chunk, ok := opts["chunk"]; ok {
+		msg.chunk = string(toBytes(chunk))
+	}
+	return msg, nil
+}
+
+func decodeEventStream(h *codec.MsgpackHandle, r io.Reader) ([]event, error) {
+	var events []event
+	dec := codec.NewDecoder(r, h)
+	for {
+		var entry []interface{}
+		if err := dec.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			return nil, fmt.Errorf("invalid packed entries: %w", err)
+		}
+		ev, err := decodeEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+}
+
+func decodeOptions(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	opts, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid message option of type %T", v)
+	}
+	return opts, nil
+}
+
+// decodeEntry decodes an [time, record] entry.
+func decodeEntry(v interface{}) (event, error) {
+	entry, ok := v.([]interface{})
+	if !ok || len(entry) != 2 {
+		return event{}, fmt.Errorf("invalid entry %v", v)
+	}
+	ts, err := decodeTime(entry[0])
+	if err != nil {
+		return event{}, err
+	}
+	record, ok := entry[1].(map[string]interface{})
+	if !ok {
+		return event{}, fmt.Errorf("invalid record of type %T", entry[1])
+	}
+	return event{timestamp: ts, record: record}, nil
+}
+
+func decodeTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case EventTime:
+		return t.Time, nil
+	case uint64:
+		return time.Unix(int64(t), 0), nil
+	case int64:
+		return time.Unix(t, 0), nil
+	case float64:
+		sec, frac := math.Modf(t)
+		return time.Unix(int64(sec), int64(frac*float64(time.Second))), nil
+	case []interface{}:
+		// Fluent Bit 2.1+ sends [time, metadata] instead of the time.
+		if len(t) > 0 {
+			return decodeTime(t[0])
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid event time of type %T", v)
+}
+
+func toBytes(v interface{}) []byte {
+	switch v := v.(type) {
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	}
+	return []byte(fmt.Sprint(v))
+}
+
+// ackResponse is sent back to the clients requesting an acknowledgment.
+type ackResponse struct {
+	Ack string `codec:"ack"`
+}
+
+// handshake holds the state of the shared key authentication of a connection.
+type handshake struct {
+	sharedKey    string
+	selfHostname string
+	nonce        []byte
+}
+
+func newHandshake(sharedKey, selfHostname string) (*handshake, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &handshake{sharedKey: sharedKey, selfHostname: selfHostname, nonce: nonce}, nil
+}
+
+// helo is the first message sent by the server to the clients.
+func (h *handshake) helo() []interface{} {
+	return []interface{}{"HELO", map[string]interface{}{
+		"nonce":     h.nonce,
+		"auth":      "",
+		"keepalive": true,
+	}}
+}
+
+// verify checks the PING message of a client and returns the PONG message to
+// send back, along with an error if the client isn't authenticated.
+func (h *handshake) verify(ping []interface{}) ([]interface{}, error) {
+	if len(ping) != 6 {
+		return nil, fmt.Errorf("invalid PING message with %d elements", len(ping))
+	}
+	if kind, _ := ping[0].(string); kind != "PING" {
+		return nil, fmt.Errorf("unexpected message %v, expected PING", ping[0])
+	}
+	hostname := string(toBytes(ping[1]))
+	salt := toBytes(ping[2])
+	digest := string(toBytes(ping[3]))
+
+	if subtle.ConstantTimeCompare([]byte(digest), []byte(h.digest(salt, hostname))) != 1 {
+		return []interface{}{"PONG", false, "shared key mismatch", "", ""}, errors.New("shared key mismatch")
+	}
+	return []interface{}{"PONG", true, "", h.selfHostname, h.digest(salt, h.selfHostname)}, nil
+}
+
+func (h *handshake) digest(salt []byte, hostname string) string {
+	d := sha512.New()
+	d.Write(salt)
+	d.Write([]byte(hostname))
+	d.Write(h.nonce)
+	d.Write([]byte(h.sharedKey))
+	return hex.EncodeToString(d.Sum(nil))
+}
@@ -0,0 +1,399 @@
+package fluentforward
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/mwitkow/go-conntrack"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/util/strutil"
+	"github.com/ugorji/go/codec"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	defaultListenAddress  = ":24224"
+	defaultIdleTimeout    = 120 * time.Second
+	defaultMaxMessageSize = 32 << 20 // 32MiB
+
+	recordLabelPrefix = "__fluent_forward_record_"
+)
+
+// Target listens to fluent forward messages on tcp.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	config        *scrapeconfig.FluentForwardTargetConfig
+	relabelConfig []*relabel.Config
+	handle        *codec.MsgpackHandle
+	listener      net.Listener
+
+	openConnections sync.WaitGroup
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+}
+
+// NewTarget configures a new fluent forward Target.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	relabel []*relabel.Config,
+	config *scrapeconfig.FluentForwardTargetConfig,
+) (*Target, error) {
+	if config.ListenAddress == "" {
+		config.ListenAddress = defaultListenAddress
+	}
+	if config.IdleTimeout == 0 {
+		config.IdleTimeout = defaultIdleTimeout
+	}
+	if config.MaxMessageSize <= 0 {
+		config.MaxMessageSize = defaultMaxMessageSize
+	}
+	if config.SelfHostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get the hostname for the fluent forward target: %w", err)
+		}
+		config.SelfHostname = hostname
+	}
+
+	l, err := net.Listen("tcp", config.ListenAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up fluent forward target: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		config:        config,
+		relabelConfig: relabel,
+		handle:        NewHandle(),
+		listener:      conntrack.NewListener(l, conntrack.TrackWithName("fluent_forward_target/"+config.ListenAddress)),
+
+		ctx:       ctx,
+		ctxCancel: cancel,
+	}
+
+	level.Info(logger).Log("msg", "listening for fluent forward messages", "listen_address", t.Addr().String(), "auth", config.SharedKey.String() != "")
+	t.openConnections.Add(1)
+	go t.acceptConnections()
+
+	return t, nil
+}
+
+// Addr returns the address the target listens on.
+func (t *Target) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *Target) acceptConnections() {
+	defer t.openConnections.Done()
+
+	l := log.With(t.logger, "listen_address", t.config.ListenAddress)
+
+	backoff := backoff.New(t.ctx, backoff.Config{
+		MinBackoff: 5 * time.Millisecond,
+		MaxBackoff: 1 * time.Second,
+	})
+
+	for {
+		c, err := t.listener.Accept()
+		if err != nil {
+			if t.ctx.Err() != nil {
+				level.Info(l).Log("msg", "fluent forward listener shutdown")
+				return
+			}
+
+			if _, ok := err.(net.Error); ok {
+				level.Warn(l).Log("msg", "failed to accept fluent forward connection", "err", err, "num_retries", backoff.NumRetries())
+				backoff.Wait()
+				continue
+			}
+
+			level.Error(l).Log("msg", "failed to accept fluent forward connection. quiting", "err", err)
+			return
+		}
+		backoff.Reset()
+
+		t.openConnections.Add(1)
+		go t.handleConnection(c)
+	}
+}
+
+func (t *Target) handleConnection(cn net.Conn) {
+	defer t.openConnections.Done()
+
+	c := &idleTimeoutConn{cn, t.config.IdleTimeout}
+
+	handlerCtx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+	go func() {
+		<-handlerCtx.Done()
+		_ = c.Close()
+	}()
+
+	t.metrics.connections.Inc()
+	defer t.metrics.connections.Dec()
+
+	ip := ipFromConn(c)
+	l := log.With(t.logger, "remote_address", c.RemoteAddr().String())
+	// The limit is reset before reading each message. The decoder reads ahead
+	// up to the size of its buffer, which may be accounted to the previous message.
+	maxMessageSize := t.config.MaxMessageSize.Val()
+	r := &limitedReader{r: c, n: maxMessageSize}
+	dec := codec.NewDecoder(r, t.handle)
+	enc := codec.NewEncoder(c, t.handle)
+
+	if t.config.SharedKey.String() != "" {
+		if err := t.authenticate(dec, enc); err != nil {
+			if handlerCtx.Err() == nil {
+				level.Warn(l).Log("msg", "fluent forward authentication failed", "err", err)
+				t.metrics.authErrors.Inc()
+			}
+			return
+		}
+	}
+
+	for {
+		var raw []interface{}
+		r.n = maxMessageSize
+		if err := dec.Decode(&raw); err != nil {
+			// The rest of a message too large can't be skipped, the connection is closed.
+			if !errors.Is(err, io.EOF) && handlerCtx.Err() == nil {
+				level.Warn(l).Log("msg", "error while reading fluent forward message", "err", err, "max_message_size", t.config.MaxMessageSize)
+				t.metrics.errors.Inc()
+			}
+			return
+		}
+
+		msg, err := decodeMessage(t.handle, raw, maxMessageSize)
+		if err != nil {
+			// The message has been entirely read, the next ones can still be decoded.
+			level.Warn(l).Log("msg", "invalid fluent forward message", "err", err)
+			t.metrics.errors.Inc()
+			continue
+		}
+
+		for _, ev := range msg.events {
+			t.handleEvent(ip, msg.tag, ev)
+		}
+
+		if msg.chunk != "" {
+			if err := enc.Encode(ackResponse{Ack: msg.chunk}); err != nil {
+				level.Warn(l).Log("msg", "error while acknowledging fluent forward message", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// authenticate runs the shared key handshake of the forward protocol.
+func (t *Target) authenticate(dec *codec.Decoder, enc *codec.Encoder) error {
+	hs, err := newHandshake(t.config.SharedKey.String(), t.config.SelfHostname)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(hs.helo()); err != nil {
+		return err
+	}
+
+	var ping []interface{}
+	if err := dec.Decode(&ping); err != nil {
+		return err
+	}
+	pong, authErr := hs.verify(ping)
+	if pong != nil {
+		if err := enc.Encode(pong); err != nil {
+			return err
+		}
+	}
+	return authErr
+}
+
+func (t *Target) handleEvent(ip net.IP, tag string, ev event) {
+	lb := labels.NewBuilder(nil)
+
+	// Add all labels from the config.
+	for k, v := range t.config.Labels {
+		lb.Set(string(k), string(v))
+	}
+	lb.Set("__fluent_forward_tag", tag)
+	if ip != nil {
+		lb.Set("__fluent_forward_connection_ip_address", ip.String())
+	}
+	var structuredMetadata []logproto.LabelAdapter
+	for k, v := range ev.record {
+		value, ok := scalarValue(v)
+		if !ok {
+			continue
+		}
+		name := strutil.SanitizeLabelName(k)
+		lb.Set(recordLabelPrefix+name, value)
+		if slices.Contains(t.config.StructuredMetadata, k) {
+			structuredMetadata = append(structuredMetadata, logproto.LabelAdapter{Name: name, Value: value})
+		}
+	}
+
+	processed, keep := relabel.Process(lb.Labels(), t.relabelConfig...)
+	if !keep {
+		return
+	}
+
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+
+	line, err := json.Marshal(normalizeValue(ev.record))
+	if err != nil {
+		level.Error(t.logger).Log("msg", "error while marshalling fluent forward record", "tag", tag, "err", err)
+		t.metrics.errors.Inc()
+		return
+	}
+
+	timestamp := time.Now()
+	if t.config.UseIncomingTimestamp && !ev.timestamp.IsZero() {
+		timestamp = ev.timestamp
+	}
+
+	t.metrics.entries.Inc()
+	t.handler.Chan() <- api.Entry{
+		Labels: filtered,
+		Entry: logproto.Entry{
+			Timestamp:          timestamp,
+			Line:               string(line),
+			StructuredMetadata: structuredMetadata,
+		},
+	}
+}
+
+// scalarValue returns the label value of the scalar fields of a record.
+func scalarValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	}
+	return "", false
+}
+
+// normalizeValue converts the binary values of a record to strings so that
+// they are not base64 encoded in the JSON line.
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []byte:
+		return string(v)
+	case map[string]interface{}:
+		for k, val := range v {
+			v[k] = normalizeValue(val)
+		}
+	case []interface{}:
+		for i, val := range v {
+			v[i] = normalizeValue(val)
+		}
+	case EventTime:
+		return v.Time
+	}
+	return v
+}
+
+// Type returns FluentForwardTargetType.
+func (t *Target) Type() target.TargetType {
+	return target.FluentForwardTargetType
+}
+
+// Ready indicates whether or not the fluent forward target is ready to be read from.
+func (t *Target) Ready() bool {
+	return t.ctx.Err() == nil
+}
+
+// DiscoveredLabels returns the set of labels discovered by the fluent forward
+// target, which is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the Target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{}
+}
+
+// Stop shuts down the Target.
+func (t *Target) Stop() {
+	level.Info(t.logger).Log("msg", "Shutting down fluent forward listener", "listen_address", t.config.ListenAddress)
+	t.ctxCancel()
+	if err := t.listener.Close(); err != nil {
+		level.Error(t.logger).Log("msg", "error while closing fluent forward listener", "err", err)
+	}
+	t.openConnections.Wait()
+	t.handler.Stop()
+}
+
+type idleTimeoutConn struct {
+	net.Conn
+	idleTimeout time.Duration
+}
+
+func (c *idleTimeoutConn) Write(p []byte) (int, error) {
+	c.setDeadline()
+	return c.Conn.Write(p)
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	c.setDeadline()
+	return c.Conn.Read(b)
+}
+
+func (c *idleTimeoutConn) setDeadline() {
+	_ = c.Conn.SetDeadline(time.Now().Add(c.idleTimeout))
+}
+
+func ipFromConn(c net.Conn) net.IP {
+	if addr, ok := c.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+	return nil
+}
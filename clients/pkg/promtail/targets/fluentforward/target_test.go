@@ -0,0 +1,278 @@
+package fluentforward
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+
+	"github.com/grafana/loki/pkg/push"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+type testClient struct {
+	conn net.Conn
+	enc  *codec.Encoder
+	dec  *codec.Decoder
+}
+
+func dial(t *testing.T, target *Target) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", target.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	h := NewHandle()
+	return &testClient{conn: conn, enc: codec.NewEncoder(conn, h), dec: codec.NewDecoder(conn, h)}
+}
+
+func newTestTargetManager(t *testing.T, client *fake.Client, cfg *scrapeconfig.FluentForwardTargetConfig) *Target {
+	t.Helper()
+	cfg.ListenAddress = "127.0.0.1:0"
+	tm, err := NewTargetManager(NewMetrics(nil), log.NewNopLogger(), client, []scrapeconfig.Config{
+		{
+			JobName:             "fluent",
+			FluentForwardConfig: cfg,
+			RelabelConfigs: []*relabel.Config{
+				{
+					SourceLabels: model.LabelNames{"__fluent_forward_tag"},
+					TargetLabel:  "tag",
+					Replacement:  "$1",
+					Action:       relabel.Replace,
+					Regex:        relabel.MustNewRegexp("(.*)"),
+				},
+				{
+					SourceLabels: model.LabelNames{"__fluent_forward_record_level"},
+					TargetLabel:  "level",
+					Replacement:  "$1",
+					Action:       relabel.Replace,
+					Regex:        relabel.MustNewRegexp("(.+)"),
+				},
+				{
+					SourceLabels: model.LabelNames{"__fluent_forward_tag"},
+					Action:       relabel.Drop,
+					Regex:        relabel.MustNewRegexp("dropped"),
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(tm.Stop)
+	target := tm.targets["fluent"]
+	require.NotNil(t, target)
+	return target
+}
+
+func Test_FluentForward(t *testing.T) {
+	client := fake.New(func() {})
+	target := newTestTargetManager(t, client, &scrapeconfig.FluentForwardTargetConfig{
+		UseIncomingTimestamp: true,
+		Labels:               model.LabelSet{"cfg": "true"},
+		StructuredMetadata:   []string{"msg"},
+	})
+	c := dial(t, target)
+
+	ts := time.Unix(10, 250)
+	record := map[string]interface{}{"level": "error", "msg": "oops", "data": []byte("raw")}
+
+	// Message mode.
+	require.NoError(t, c.enc.Encode([]interface{}{"app.message", 10, record}))
+	// Forward mode.
+	require.NoError(t, c.enc.Encode([]interface{}{"app.forward", []interface{}{
+		[]interface{}{EventTime{ts}, record},
+		[]interface{}{EventTime{ts}, map[string]interface{}{"msg": "no level"}},
+	}}))
+	// Dropped by the relabeling.
+	require.NoError(t, c.enc.Encode([]interface{}{"dropped", 10, record}))
+
+	// PackedForward mode, with the Fluent Bit 2.1+ metadata.
+	var packed bytes.Buffer
+	packedEnc := codec.NewEncoder(&packed, NewHandle())
+	for i := 0; i < 2; i++ {
+		require.NoError(t, packedEnc.Encode([]interface{}{[]interface{}{EventTime{ts}, map[string]interface{}{}}, record}))
+	}
+	require.NoError(t, c.enc.Encode([]interface{}{"app.packed", packed.Bytes()}))
+
+	// CompressedPackedForward mode, with an acknowledgment.
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write(packed.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.NoError(t, c.enc.Encode([]interface{}{"app.compressed", compressed.Bytes(), map[string]interface{}{
+		"compressed": "gzip",
+		"chunk":      "Y2h1bms=",
+		"size":       2,
+	}}))
+
+	var ack map[string]interface{}
+	require.NoError(t, c.dec.Decode(&ack))
+	require.Equal(t, map[string]interface{}{"ack": "Y2h1bms="}, ack)
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 7
+	}, 5*time.Second, 20*time.Millisecond)
+
+	received := client.Received()
+	require.Equal(t, model.LabelSet{"cfg": "true", "tag": "app.message", "level": "error"}, received[0].Labels)
+	require.Equal(t, time.Unix(10, 0), received[0].Timestamp)
+	require.Equal(t, `{"data":"raw","level":"error","msg":"oops"}`, received[0].Line)
+	require.Equal(t, push.LabelsAdapter{{Name: "msg", Value: "oops"}}, received[0].StructuredMetadata)
+
+	require.Equal(t, model.LabelSet{"cfg": "true", "tag": "app.forward", "level": "error"}, received[1].Labels)
+	require.Equal(t, ts, received[1].Timestamp)
+	require.Equal(t, model.LabelSet{"cfg": "true", "tag": "app.forward"}, received[2].Labels)
+	require.Equal(t, `{"msg":"no level"}`, received[2].Line)
+
+	for _, entry := range received[3:5] {
+		require.Equal(t, model.LabelValue("app.packed"), entry.Labels["tag"])
+		require.Equal(t, ts, entry.Timestamp)
+		require.Equal(t, `{"data":"raw","level":"error","msg":"oops"}`, entry.Line)
+	}
+	for _, entry := range received[5:] {
+		require.Equal(t, model.LabelValue("app.compressed"), entry.Labels["tag"])
+		require.Equal(t, ts, entry.Timestamp)
+	}
+}
+
+func Test_FluentForwardInvalidMessage(t *testing.T) {
+	client := fake.New(func() {})
+	target := newTestTargetManager(t, client, &scrapeconfig.FluentForwardTargetConfig{})
+	c := dial(t, target)
+
+	// Invalid messages are skipped, the connection stays usable.
+	require.NoError(t, c.enc.Encode([]interface{}{"app", "not msgpack", map[string]interface{}{"compressed": "zstd"}}))
+	require.NoError(t, c.enc.Encode([]interface{}{"app", 10, "not a record"}))
+	require.NoError(t, c.enc.Encode([]interface{}{"app", 10, map[string]interface{}{"msg": "ok"}, map[string]interface{}{"chunk": "abc"}}))
+
+	var ack map[string]interface{}
+	require.NoError(t, c.dec.Decode(&ack))
+	require.Equal(t, "abc", ack["ack"])
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, `{"msg":"ok"}`, client.Received()[0].Line)
+}
+
+func Test_FluentForwardMaxMessageSize(t *testing.T) {
+	client := fake.New(func() {})
+	target := newTestTargetManager(t, client, &scrapeconfig.FluentForwardTargetConfig{
+		MaxMessageSize: 1024,
+	})
+	c := dial(t, target)
+
+	// The compressed entries larger than the limit once decompressed are
+	// rejected, the connection stays usable.
+	var packed bytes.Buffer
+	packedEnc := codec.NewEncoder(&packed, NewHandle())
+	for i := 0; i < 100; i++ {
+		require.NoError(t, packedEnc.Encode([]interface{}{10, map[string]interface{}{"msg": "a compressible message"}}))
+	}
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, err := gz.Write(packed.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	require.Less(t, compressed.Len(), 1024)
+	require.NoError(t, c.enc.Encode([]interface{}{"app", compressed.Bytes(), map[string]interface{}{"compressed": "gzip"}}))
+	require.NoError(t, c.enc.Encode([]interface{}{"app", 10, map[string]interface{}{"msg": "ok"}, map[string]interface{}{"chunk": "abc"}}))
+
+	var ack map[string]interface{}
+	require.NoError(t, c.dec.Decode(&ack))
+	require.Equal(t, "abc", ack["ack"])
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, `{"msg":"ok"}`, client.Received()[0].Line)
+
+	// The connection is closed by the server when a message is too large.
+	require.NoError(t, c.enc.Encode([]interface{}{"app", 10, map[string]interface{}{"msg": strings.Repeat("a", 64*1024)}, map[string]interface{}{"chunk": "def"}}))
+	require.Error(t, c.dec.Decode(&ack))
+	require.Len(t, client.Received(), 1)
+}
+
+func Test_FluentForwardAuthentication(t *testing.T) {
+	client := fake.New(func() {})
+	target := newTestTargetManager(t, client, &scrapeconfig.FluentForwardTargetConfig{
+		SharedKey:    flagext.SecretWithValue("secret"),
+		SelfHostname: "promtail",
+	})
+
+	handshake := func(c *testClient, key string) []interface{} {
+		var helo []interface{}
+		require.NoError(t, c.dec.Decode(&helo))
+		require.Equal(t, "HELO", helo[0])
+		nonce := toBytes(helo[1].(map[string]interface{})["nonce"])
+		require.Len(t, nonce, 16)
+
+		salt := []byte("salt")
+		require.NoError(t, c.enc.Encode([]interface{}{"PING", "client", salt, digest(salt, "client", nonce, key), "", ""}))
+
+		var pong []interface{}
+		require.NoError(t, c.dec.Decode(&pong))
+		require.Equal(t, "PONG", pong[0])
+		if pong[1] == true {
+			require.Equal(t, "promtail", pong[3])
+			require.Equal(t, digest(salt, "promtail", nonce, key), pong[4])
+		}
+		return pong
+	}
+
+	c := dial(t, target)
+	pong := handshake(c, "secret")
+	require.Equal(t, true, pong[1])
+	require.NoError(t, c.enc.Encode([]interface{}{"app", 10, map[string]interface{}{"msg": "ok"}, map[string]interface{}{"chunk": "abc"}}))
+	var ack map[string]interface{}
+	require.NoError(t, c.dec.Decode(&ack))
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+
+	c = dial(t, target)
+	pong = handshake(c, "wrong")
+	require.Equal(t, false, pong[1])
+	require.Equal(t, "shared key mismatch", pong[2])
+	// The connection is closed by the server.
+	require.Error(t, c.dec.Decode(&ack))
+}
+
+func digest(salt []byte, hostname string, nonce []byte, key string) string {
+	d := sha512.New()
+	d.Write(salt)
+	d.Write([]byte(hostname))
+	d.Write(nonce)
+	d.Write([]byte(key))
+	return hex.EncodeToString(d.Sum(nil))
+}
+
+func TestDecodeTime(t *testing.T) {
+	for _, tc := range []struct {
+		in       interface{}
+		expected time.Time
+	}{
+		{in: uint64(10), expected: time.Unix(10, 0)},
+		{in: int64(10), expected: time.Unix(10, 0)},
+		{in: 10.25, expected: time.Unix(10, 250000000)},
+		{in: EventTime{time.Unix(10, 5)}, expected: time.Unix(10, 5)},
+		{in: []interface{}{EventTime{time.Unix(10, 5)}, map[string]interface{}{}}, expected: time.Unix(10, 5)},
+	} {
+		actual, err := decodeTime(tc.in)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, actual)
+	}
+
+	_, err := decodeTime("10")
+	require.Error(t, err)
+}
@@ -0,0 +1,45 @@
+package kubernetesevents
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of Kubernetes events target metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	entries *prometheus.CounterVec
+	errors  *prometheus.CounterVec
+	relists *prometheus.CounterVec
+}
+
+// NewMetrics creates a new set of Kubernetes events target metrics. If reg is
+// non-nil, the metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.entries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_events_target_entries_total",
+		Help:      "Total number of Kubernetes events sent by the Kubernetes events target.",
+	}, []string{"job"})
+	m.errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_events_target_errors_total",
+		Help:      "Total number of errors listing or watching the Kubernetes events.",
+	}, []string{"job"})
+	m.relists = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "kubernetes_events_target_relists_total",
+		Help:      "Total number of times the Kubernetes events were listed again because the watched resource version expired.",
+	}, []string{"job"})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entries,
+			m.errors,
+			m.relists,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,453 @@
+package kubernetesevents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.uber.org/atomic"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util"
+)
+
+const (
+	labelKeyNamespace               = "__meta_kubernetes_event_namespace"
+	labelKeyType                    = "__meta_kubernetes_event_type"
+	labelKeyReason                  = "__meta_kubernetes_event_reason"
+	labelKeySourceComponent         = "__meta_kubernetes_event_source_component"
+	labelKeySourceHost              = "__meta_kubernetes_event_source_host"
+	labelKeyInvolvedObjectKind      = "__meta_kubernetes_event_involved_object_kind"
+	labelKeyInvolvedObjectName      = "__meta_kubernetes_event_involved_object_name"
+	labelKeyInvolvedObjectNamespace = "__meta_kubernetes_event_involved_object_namespace"
+
+	// listPageSize is the number of events fetched by each list request.
+	listPageSize = 500
+)
+
+var defaultBackoff = backoff.Config{
+	MinBackoff: 1 * time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// Target watches the events of a Kubernetes cluster and sends each of them as
+// a log line.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	positions     positions.Positions
+	client        kubernetes.Interface
+	jobName       string
+	config        *scrapeconfig.KubernetesEventsTargetConfig
+	relabelConfig []*relabel.Config
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running *atomic.Bool
+}
+
+// NewTarget creates a Target watching the events of the configured namespaces,
+// starting from the resource versions saved in the positions.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	positions positions.Positions,
+	client kubernetes.Interface,
+	jobName string,
+	config *scrapeconfig.KubernetesEventsTargetConfig,
+	relabelConfig []*relabel.Config,
+) *Target {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		positions:     positions,
+		client:        client,
+		jobName:       jobName,
+		config:        config,
+		relabelConfig: relabelConfig,
+		ctx:           ctx,
+		cancel:        cancel,
+		running:       atomic.NewBool(true),
+	}
+
+	namespaces := config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, namespace := range namespaces {
+		t.wg.Add(1)
+		go t.run(namespace)
+	}
+	return t
+}
+
+// positionKey is the key of the last resource version of the events of the
+// namespace in the positions file.
+func (t *Target) positionKey(namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		namespace = "*"
+	}
+	return positions.CursorKey(fmt.Sprintf("kubernetes_events-%s-%s", t.jobName, namespace))
+}
+
+// run lists then watches the events of the namespace until the target is
+// stopped. The events are listed again whenever the watched resource version
+// expired.
+func (t *Target) run(namespace string) {
+	defer t.wg.Done()
+
+	w := &namespaceWatcher{
+		Target:          t,
+		namespace:       namespace,
+		key:             t.positionKey(namespace),
+		seen:            map[types.UID]string{},
+		resourceVersion: t.positions.GetString(t.positionKey(namespace)),
+	}
+	// The events are watched from the resource version saved before a restart,
+	// they are listed first otherwise.
+	relist := w.resourceVersion == ""
+	bo := backoff.New(t.ctx, defaultBackoff)
+	for t.ctx.Err() == nil {
+		if relist {
+			if err := w.list(); err != nil {
+				t.metrics.errors.WithLabelValues(t.jobName).Inc()
+				level.Warn(t.logger).Log("msg", "failed to list the events", "namespace", namespace, "err", err)
+				bo.Wait()
+				continue
+			}
+			relist = false
+		}
+
+		expired, err := w.watch()
+		if err != nil {
+			t.metrics.errors.WithLabelValues(t.jobName).Inc()
+			level.Warn(t.logger).Log("msg", "failed to watch the events", "namespace", namespace, "err", err)
+			bo.Wait()
+			continue
+		}
+		if expired {
+			t.metrics.relists.WithLabelValues(t.jobName).Inc()
+			level.Info(t.logger).Log("msg", "resource version expired, listing the events again", "namespace", namespace, "resource_version", w.resourceVersion)
+			relist = true
+		}
+		bo.Reset()
+	}
+}
+
+// namespaceWatcher holds the state of the events of a namespace.
+type namespaceWatcher struct {
+	*Target
+	namespace string
+	key       string
+
+	// seen holds the resource version of the events sent, by UID, to not send
+	// again the events received more than once.
+	seen            map[types.UID]string
+	resourceVersion string
+}
+
+// list sends the events not sent yet and resets the resource version to the
+// one of the list.
+func (w *namespaceWatcher) list() error {
+	seen := make(map[types.UID]string, len(w.seen))
+	opts := metav1.ListOptions{Limit: listPageSize}
+	var resourceVersion string
+	for {
+		list, err := w.client.CoreV1().Events(w.namespace).List(w.ctx, opts)
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			event := &list.Items[i]
+			if !isNewer(event.ResourceVersion, w.resourceVersion) {
+				seen[event.UID] = event.ResourceVersion
+				continue
+			}
+			w.send(event)
+			seen[event.UID] = event.ResourceVersion
+		}
+		resourceVersion = list.ResourceVersion
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	w.seen = seen
+	w.setResourceVersion(resourceVersion)
+	return nil
+}
+
+// watch watches the events from the current resource version. It returns true
+// if the resource version expired and the events must be listed again.
+func (w *namespaceWatcher) watch() (bool, error) {
+	watcher, err := w.client.CoreV1().Events(w.namespace).Watch(w.ctx, metav1.ListOptions{
+		ResourceVersion:     w.resourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		if isExpired(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return false, nil
+		case ev, ok := <-watcher.ResultChan():
+			if !ok {
+				// The API server closes the watches after a while, watch again
+				// from the current resource version.
+				return false, nil
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				event, ok := ev.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				if w.seen[event.UID] != event.ResourceVersion {
+					w.send(event)
+					w.seen[event.UID] = event.ResourceVersion
+				}
+				w.setResourceVersion(event.ResourceVersion)
+			case watch.Deleted:
+				if event, ok := ev.Object.(*corev1.Event); ok {
+					delete(w.seen, event.UID)
+					w.setResourceVersion(event.ResourceVersion)
+				}
+			case watch.Bookmark:
+				if accessor, err := meta.Accessor(ev.Object); err == nil {
+					w.setResourceVersion(accessor.GetResourceVersion())
+				}
+			case watch.Error:
+				err := apierrors.FromObject(ev.Object)
+				if isExpired(err) {
+					return true, nil
+				}
+				return false, err
+			}
+		}
+	}
+}
+
+func (w *namespaceWatcher) setResourceVersion(resourceVersion string) {
+	if resourceVersion == "" {
+		return
+	}
+	w.resourceVersion = resourceVersion
+	w.positions.PutString(w.key, resourceVersion)
+}
+
+// send sends the event to the pipeline, unless it's dropped by the relabeling.
+func (t *Target) send(event *corev1.Event) {
+	lbs := labels.NewBuilder(labels.EmptyLabels())
+	for k, v := range t.config.Labels {
+		lbs.Set(string(k), string(v))
+	}
+	lbs.Set(labelKeyNamespace, event.Namespace)
+	lbs.Set(labelKeyType, event.Type)
+	lbs.Set(labelKeyReason, event.Reason)
+	lbs.Set(labelKeySourceComponent, event.Source.Component)
+	lbs.Set(labelKeySourceHost, event.Source.Host)
+	lbs.Set(labelKeyInvolvedObjectKind, event.InvolvedObject.Kind)
+	lbs.Set(labelKeyInvolvedObjectName, event.InvolvedObject.Name)
+	lbs.Set(labelKeyInvolvedObjectNamespace, event.InvolvedObject.Namespace)
+
+	processed, keep := relabel.Process(lbs.Labels(), t.relabelConfig...)
+	if !keep {
+		return
+	}
+	out := model.LabelSet(util.LabelsToMetric(processed))
+	for k := range out {
+		if strings.HasPrefix(string(k), "__") {
+			delete(out, k)
+		}
+	}
+
+	line, err := json.Marshal(newEventLine(event))
+	if err != nil {
+		level.Warn(t.logger).Log("msg", "failed to marshal event", "uid", event.UID, "err", err)
+		return
+	}
+
+	timestamp := time.Now()
+	if t.config.UseIncomingTimestamp {
+		if ts := eventTimestamp(event); !ts.IsZero() {
+			timestamp = ts
+		}
+	}
+
+	select {
+	case t.handler.Chan() <- api.Entry{
+		Labels: out,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      string(line),
+		},
+	}:
+		t.metrics.entries.WithLabelValues(t.jobName).Inc()
+	case <-t.ctx.Done():
+	}
+}
+
+// eventLine is the log line of an event.
+type eventLine struct {
+	Type           string         `json:"type,omitempty"`
+	Reason         string         `json:"reason,omitempty"`
+	Message        string         `json:"message,omitempty"`
+	Count          int32          `json:"count,omitempty"`
+	InvolvedObject involvedObject `json:"involved_object"`
+	Source         eventSource    `json:"source"`
+	FirstTimestamp *time.Time     `json:"first_timestamp,omitempty"`
+	LastTimestamp  *time.Time     `json:"last_timestamp,omitempty"`
+}
+
+type involvedObject struct {
+	Kind      string `json:"kind,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	FieldPath string `json:"field_path,omitempty"`
+}
+
+type eventSource struct {
+	Component string `json:"component,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+func newEventLine(event *corev1.Event) eventLine {
+	line := eventLine{
+		Type:    event.Type,
+		Reason:  event.Reason,
+		Message: event.Message,
+		Count:   event.Count,
+		InvolvedObject: involvedObject{
+			Kind:      event.InvolvedObject.Kind,
+			Namespace: event.InvolvedObject.Namespace,
+			Name:      event.InvolvedObject.Name,
+			FieldPath: event.InvolvedObject.FieldPath,
+		},
+		Source: eventSource{
+			Component: event.Source.Component,
+			Host:      event.Source.Host,
+		},
+	}
+	// Events created through the events.k8s.io API only have the reporting
+	// controller and the series.
+	if line.Source.Component == "" {
+		line.Source.Component = event.ReportingController
+	}
+	if line.Count == 0 && event.Series != nil {
+		line.Count = event.Series.Count
+	}
+	if !event.FirstTimestamp.IsZero() {
+		line.FirstTimestamp = &event.FirstTimestamp.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		line.LastTimestamp = &event.LastTimestamp.Time
+	}
+	return line
+}
+
+// eventTimestamp returns the time the event was last observed.
+func eventTimestamp(event *corev1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// isNewer returns whether the resource version is newer than the last one.
+// Resource versions are opaque, but are integers for the etcd backed API
+// servers, the event is considered newer when they can't be compared.
+func isNewer(resourceVersion, last string) bool {
+	if last == "" {
+		return true
+	}
+	rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return true
+	}
+	lastRV, err := strconv.ParseUint(last, 10, 64)
+	if err != nil {
+		return true
+	}
+	return rv > lastRV
+}
+
+func isExpired(err error) bool {
+	return apierrors.IsResourceExpired(err) || apierrors.IsGone(err)
+}
+
+func (t *Target) Type() target.TargetType {
+	return target.KubernetesEventsTargetType
+}
+
+func (t *Target) Ready() bool {
+	return t.running.Load()
+}
+
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	details := map[string]string{}
+	namespaces := t.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	for _, namespace := range namespaces {
+		details[t.positionKey(namespace)] = t.positions.GetString(t.positionKey(namespace))
+	}
+	return details
+}
+
+func (t *Target) Stop() {
+	t.cancel()
+	t.wg.Wait()
+	t.running.Store(false)
+	t.handler.Stop()
+}
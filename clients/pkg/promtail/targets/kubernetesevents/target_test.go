@@ -0,0 +1,239 @@
+package kubernetesevents
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	clientfake "github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+// fakeAPIServer serves the events lists and records the watches made.
+type fakeAPIServer struct {
+	*fake.Clientset
+
+	mtx      sync.Mutex
+	events   []corev1.Event
+	listRV   string
+	lists    int
+	watchRVs []string
+	watchers chan *watch.RaceFreeFakeWatcher
+}
+
+func newFakeAPIServer() *fakeAPIServer {
+	s := &fakeAPIServer{
+		Clientset: fake.NewSimpleClientset(),
+		watchers:  make(chan *watch.RaceFreeFakeWatcher, 10),
+	}
+	s.PrependReactor("list", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		s.lists++
+		return true, &corev1.EventList{
+			ListMeta: metav1.ListMeta{ResourceVersion: s.listRV},
+			Items:    append([]corev1.Event(nil), s.events...),
+		}, nil
+	})
+	s.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		s.mtx.Lock()
+		defer s.mtx.Unlock()
+		s.watchRVs = append(s.watchRVs, action.(k8stesting.WatchActionImpl).WatchRestrictions.ResourceVersion)
+		w := watch.NewRaceFreeFake()
+		s.watchers <- w
+		return true, w, nil
+	})
+	return s
+}
+
+func (s *fakeAPIServer) setList(resourceVersion string, events ...corev1.Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.listRV = resourceVersion
+	s.events = events
+}
+
+func (s *fakeAPIServer) nextWatcher(t *testing.T) *watch.RaceFreeFakeWatcher {
+	t.Helper()
+	select {
+	case w := <-s.watchers:
+		return w
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch")
+		return nil
+	}
+}
+
+func newEvent(uid, resourceVersion, reason string, count int32) corev1.Event {
+	return corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "api.17a" + uid,
+			Namespace:       "default",
+			UID:             types.UID(uid),
+			ResourceVersion: resourceVersion,
+		},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "api"},
+		Reason:         reason,
+		Message:        "Back-off restarting failed container",
+		Type:           corev1.EventTypeWarning,
+		Count:          count,
+		Source:         corev1.EventSource{Component: "kubelet", Host: "node-1"},
+		LastTimestamp:  metav1.NewTime(time.Unix(100, 0)),
+	}
+}
+
+func newTestPositions(t *testing.T) positions.Positions {
+	t.Helper()
+	pos, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: filepath.Join(t.TempDir(), "positions.yaml"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(pos.Stop)
+	return pos
+}
+
+func newTestTarget(t *testing.T, client *clientfake.Client, pos positions.Positions, server *fakeAPIServer) *Target {
+	t.Helper()
+	target := NewTarget(NewMetrics(prometheus.NewRegistry()), log.NewNopLogger(), client, pos, server, "events",
+		&scrapeconfig.KubernetesEventsTargetConfig{
+			Namespaces:           []string{"default"},
+			Labels:               model.LabelSet{"job": "events"},
+			UseIncomingTimestamp: true,
+		},
+		[]*relabel.Config{
+			{
+				SourceLabels: model.LabelNames{"__meta_kubernetes_event_involved_object_kind"},
+				TargetLabel:  "kind",
+				Replacement:  "$1",
+				Action:       relabel.Replace,
+				Regex:        relabel.MustNewRegexp("(.*)"),
+			},
+			{
+				SourceLabels: model.LabelNames{"__meta_kubernetes_event_reason"},
+				Action:       relabel.Drop,
+				Regex:        relabel.MustNewRegexp("Dropped"),
+			},
+		},
+	)
+	t.Cleanup(target.Stop)
+	return target
+}
+
+func waitReceived(t *testing.T, client *clientfake.Client, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == n
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_KubernetesEventsTarget(t *testing.T) {
+	client := clientfake.New(func() {})
+	pos := newTestPositions(t)
+	server := newFakeAPIServer()
+	server.setList("20",
+		newEvent("a", "10", "BackOff", 1),
+		newEvent("b", "11", "Pulled", 1),
+		newEvent("c", "12", "Dropped", 1),
+	)
+	target := newTestTarget(t, client, pos, server)
+	key := target.positionKey("default")
+
+	// The existing events are listed first.
+	w := server.nextWatcher(t)
+	waitReceived(t, client, 2)
+	received := client.Received()
+	require.Equal(t, model.LabelSet{"job": "events", "kind": "Pod"}, received[0].Labels)
+	require.Equal(t, time.Unix(100, 0), received[0].Timestamp)
+	require.JSONEq(t, `{
+		"type": "Warning",
+		"reason": "BackOff",
+		"message": "Back-off restarting failed container",
+		"count": 1,
+		"involved_object": {"kind": "Pod", "namespace": "default", "name": "api"},
+		"source": {"component": "kubelet", "host": "node-1"},
+		"last_timestamp": "1970-01-01T00:01:40Z"
+	}`, received[0].Line)
+	require.Equal(t, "20", pos.GetString(key))
+
+	// Updates with the same resource version are sent once.
+	updated := newEvent("a", "21", "BackOff", 2)
+	w.Modify(updated.DeepCopy())
+	w.Modify(updated.DeepCopy())
+	w.Action(watch.Bookmark, &corev1.Event{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "25"}})
+	waitReceived(t, client, 3)
+	require.Contains(t, client.Received()[2].Line, `"count":2`)
+	require.Eventually(t, func() bool {
+		return pos.GetString(key) == "25"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Once the resource version expired, only the events newer than the last
+	// resource version are sent.
+	server.setList("40", updated, newEvent("d", "30", "Killing", 1))
+	w.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired})
+	server.nextWatcher(t)
+	waitReceived(t, client, 4)
+	require.Contains(t, client.Received()[3].Line, `"reason":"Killing"`)
+	require.Equal(t, "40", pos.GetString(key))
+
+	time.Sleep(50 * time.Millisecond)
+	require.Len(t, client.Received(), 4)
+	server.mtx.Lock()
+	require.Equal(t, []string{"20", "40"}, server.watchRVs)
+	server.mtx.Unlock()
+}
+
+func Test_KubernetesEventsTargetRestart(t *testing.T) {
+	pos := newTestPositions(t)
+	server := newFakeAPIServer()
+	server.setList("20", newEvent("a", "10", "BackOff", 1))
+
+	client := clientfake.New(func() {})
+	target := newTestTarget(t, client, pos, server)
+	server.nextWatcher(t)
+	waitReceived(t, client, 1)
+	target.Stop()
+
+	// Restarting watches from the saved resource version, without listing
+	// the events again.
+	key := target.positionKey("default")
+	pos.PutString(key, "15")
+	client = clientfake.New(func() {})
+	target = newTestTarget(t, client, pos, server)
+	w := server.nextWatcher(t)
+	added := newEvent("b", "16", "Pulled", 1)
+	w.Add(&added)
+	waitReceived(t, client, 1)
+	require.Eventually(t, func() bool {
+		return pos.GetString(key) == "16"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]string{key: "16"}, target.Details())
+
+	server.mtx.Lock()
+	defer server.mtx.Unlock()
+	require.Equal(t, 1, server.lists)
+	require.Equal(t, []string{"20", "15"}, server.watchRVs)
+}
+
+func Test_IsNewer(t *testing.T) {
+	require.True(t, isNewer("10", ""))
+	require.True(t, isNewer("11", "10"))
+	require.False(t, isNewer("10", "10"))
+	require.False(t, isNewer("9", "10"))
+	require.True(t, isNewer("opaque", "10"))
+}
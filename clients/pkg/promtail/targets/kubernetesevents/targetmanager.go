@@ -0,0 +1,138 @@
+package kubernetesevents
+
+import (
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	promconfig "github.com/prometheus/common/config"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/util/build"
+)
+
+var userAgent = fmt.Sprintf("promtail/%s", build.Version)
+
+// TargetManager manages a series of Kubernetes events targets.
+type TargetManager struct {
+	logger  log.Logger
+	targets map[string]*Target
+}
+
+// NewTargetManager creates a new Kubernetes events target manager.
+func NewTargetManager(
+	metrics *Metrics,
+	logger log.Logger,
+	positions positions.Positions,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	tm := &TargetManager{
+		logger:  logger,
+		targets: make(map[string]*Target),
+	}
+	for _, cfg := range scrapeConfigs {
+		if cfg.KubernetesEventsConfig == nil {
+			continue
+		}
+		client, err := newClient(logger, cfg.KubernetesEventsConfig)
+		if err != nil {
+			tm.Stop()
+			return nil, fmt.Errorf("failed to create the Kubernetes client: %w", err)
+		}
+		pipeline, err := stages.NewPipeline(log.With(logger, "component", "kubernetes_events_pipeline"), cfg.PipelineStages, &cfg.JobName, metrics.reg)
+		if err != nil {
+			tm.Stop()
+			return nil, err
+		}
+		tm.targets[cfg.JobName] = NewTarget(
+			metrics,
+			log.With(logger, "target", "kubernetes_events", "job", cfg.JobName),
+			pipeline.Wrap(pushClient),
+			positions,
+			client,
+			cfg.JobName,
+			cfg.KubernetesEventsConfig,
+			cfg.RelabelConfigs,
+		)
+	}
+
+	return tm, nil
+}
+
+// newClient creates the Kubernetes client the same way as the Prometheus
+// Kubernetes service discovery.
+func newClient(logger log.Logger, cfg *scrapeconfig.KubernetesEventsTargetConfig) (kubernetes.Interface, error) {
+	var (
+		kcfg *rest.Config
+		err  error
+	)
+	switch {
+	case cfg.KubeConfig != "":
+		kcfg, err = clientcmd.BuildConfigFromFlags("", cfg.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.APIServer.URL == nil:
+		kcfg, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, err
+		}
+		level.Info(logger).Log("msg", "Using pod service account via in-cluster config")
+	default:
+		rt, err := promconfig.NewRoundTripperFromConfig(cfg.HTTPClientConfig, "kubernetes_events")
+		if err != nil {
+			return nil, err
+		}
+		kcfg = &rest.Config{
+			Host:      cfg.APIServer.String(),
+			Transport: rt,
+		}
+	}
+
+	kcfg.UserAgent = userAgent
+	kcfg.ContentType = "application/vnd.kubernetes.protobuf"
+	return kubernetes.NewForConfig(kcfg)
+}
+
+// Ready returns true if at least one Kubernetes events target is active.
+func (tm *TargetManager) Ready() bool {
+	for _, t := range tm.targets {
+		if t.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TargetManager) Stop() {
+	for _, t := range tm.targets {
+		t.Stop()
+	}
+}
+
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.targets))
+	for k, v := range tm.targets {
+		if v.Ready() {
+			result[k] = []target.Target{v}
+		}
+	}
+	return result
+}
+
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.targets))
+	for k, v := range tm.targets {
+		result[k] = []target.Target{v}
+	}
+	return result
+}
@@ -16,12 +16,17 @@ import (
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/cloudflare"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/docker"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/file"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/fluentforward"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/gcplog"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/gelf"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/heroku"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/journal"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/kafka"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/kubernetesevents"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/lokipush"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/mqtt"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/nats"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/s3"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/stdin"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/syslog"
 	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
@@ -37,6 +42,11 @@ const (
 	WindowsEventsConfigs        = "windowsEventsConfigs"
 	KafkaConfigs                = "kafkaConfigs"
 	GelfConfigs                 = "gelfConfigs"
+	FluentForwardConfigs        = "fluentForwardConfigs"
+	S3Configs                   = "s3Configs"
+	MQTTConfigs                 = "mqttConfigs"
+	NATSConfigs                 = "natsConfigs"
+	KubernetesEventsConfigs     = "kubernetesEventsConfigs"
 	CloudflareConfigs           = "cloudflareConfigs"
 	DockerSDConfigs             = "dockerSDConfigs"
 	HerokuDrainConfigs          = "herokuDrainConfigs"
@@ -48,6 +58,9 @@ var (
 	syslogMetrics      *syslog.Metrics
 	gcplogMetrics      *gcplog.Metrics
 	gelfMetrics        *gelf.Metrics
+	fluentMetrics      *fluentforward.Metrics
+	s3Metrics          *s3.Metrics
+	kubeEventsMetrics  *kubernetesevents.Metrics
 	cloudflareMetrics  *cloudflare.Metrics
 	dockerMetrics      *docker.Metrics
 	journalMetrics     *journal.Metrics
@@ -111,6 +124,16 @@ func NewTargetManagers(
 			targetScrapeConfigs[AzureEventHubsScrapeConfigs] = append(targetScrapeConfigs[AzureEventHubsScrapeConfigs], cfg)
 		case cfg.GelfConfig != nil:
 			targetScrapeConfigs[GelfConfigs] = append(targetScrapeConfigs[GelfConfigs], cfg)
+		case cfg.FluentForwardConfig != nil:
+			targetScrapeConfigs[FluentForwardConfigs] = append(targetScrapeConfigs[FluentForwardConfigs], cfg)
+		case cfg.S3Config != nil:
+			targetScrapeConfigs[S3Configs] = append(targetScrapeConfigs[S3Configs], cfg)
+		case cfg.MQTTConfig != nil:
+			targetScrapeConfigs[MQTTConfigs] = append(targetScrapeConfigs[MQTTConfigs], cfg)
+		case cfg.NATSConfig != nil:
+			targetScrapeConfigs[NATSConfigs] = append(targetScrapeConfigs[NATSConfigs], cfg)
+		case cfg.KubernetesEventsConfig != nil:
+			targetScrapeConfigs[KubernetesEventsConfigs] = append(targetScrapeConfigs[KubernetesEventsConfigs], cfg)
 		case cfg.CloudflareConfig != nil:
 			targetScrapeConfigs[CloudflareConfigs] = append(targetScrapeConfigs[CloudflareConfigs], cfg)
 		case cfg.DockerSDConfigs != nil:
@@ -148,6 +171,15 @@ func NewTargetManagers(
 	if len(targetScrapeConfigs[GelfConfigs]) > 0 && gelfMetrics == nil {
 		gelfMetrics = gelf.NewMetrics(reg)
 	}
+	if len(targetScrapeConfigs[FluentForwardConfigs]) > 0 && fluentMetrics == nil {
+		fluentMetrics = fluentforward.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[S3Configs]) > 0 && s3Metrics == nil {
+		s3Metrics = s3.NewMetrics(reg)
+	}
+	if len(targetScrapeConfigs[KubernetesEventsConfigs]) > 0 && kubeEventsMetrics == nil {
+		kubeEventsMetrics = kubernetesevents.NewMetrics(reg)
+	}
 	if len(targetScrapeConfigs[CloudflareConfigs]) > 0 && cloudflareMetrics == nil {
 		cloudflareMetrics = cloudflare.NewMetrics(reg)
 	}
@@ -260,6 +292,44 @@ func NewTargetManagers(
 				return nil, errors.Wrap(err, "failed to make gelf target manager")
 			}
 			targetManagers = append(targetManagers, gelfTargetManager)
+		case FluentForwardConfigs:
+			fluentTargetManager, err := fluentforward.NewTargetManager(fluentMetrics, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make fluent forward target manager")
+			}
+			targetManagers = append(targetManagers, fluentTargetManager)
+		case S3Configs:
+			pos, err := getPositionFile()
+			if err != nil {
+				return nil, err
+			}
+			s3TargetManager, err := s3.NewTargetManager(s3Metrics, logger, pos, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make S3 target manager")
+			}
+			targetManagers = append(targetManagers, s3TargetManager)
+		case MQTTConfigs:
+			mqttTargetManager, err := mqtt.NewTargetManager(reg, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make MQTT target manager")
+			}
+			targetManagers = append(targetManagers, mqttTargetManager)
+		case NATSConfigs:
+			natsTargetManager, err := nats.NewTargetManager(reg, logger, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make NATS target manager")
+			}
+			targetManagers = append(targetManagers, natsTargetManager)
+		case KubernetesEventsConfigs:
+			pos, err := getPositionFile()
+			if err != nil {
+				return nil, err
+			}
+			kubeEventsTargetManager, err := kubernetesevents.NewTargetManager(kubeEventsMetrics, logger, pos, client, scrapeConfigs)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to make Kubernetes events target manager")
+			}
+			targetManagers = append(targetManagers, kubeEventsTargetManager)
 		case CloudflareConfigs:
 			pos, err := getPositionFile()
 			if err != nil {
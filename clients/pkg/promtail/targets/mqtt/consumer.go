@@ -0,0 +1,181 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+const (
+	defaultClientID = "promtail"
+	defaultQoS      = byte(1)
+
+	// disconnectQuiesce is the time in milliseconds given to the in-flight
+	// work to complete when disconnecting.
+	disconnectQuiesce = 250
+)
+
+// consumer holds the connection to the brokers of a scrape config and the
+// targets of its subscriptions.
+type consumer struct {
+	logger  log.Logger
+	client  mqtt.Client
+	handler api.EntryHandler
+
+	activeTargets  []*Target
+	droppedTargets []target.Target
+
+	stopOnce sync.Once
+}
+
+func newConsumer(
+	reg prometheus.Registerer,
+	logger log.Logger,
+	cfg scrapeconfig.Config,
+	pushClient api.EntryHandler,
+	newClient func(*mqtt.ClientOptions) mqtt.Client,
+) (*consumer, error) {
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
+	logger = log.With(logger, "target", "mqtt", "job", cfg.JobName)
+
+	pipeline, err := stages.NewPipeline(log.With(logger, "component", "mqtt_pipeline"), cfg.PipelineStages, &cfg.JobName, reg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline: %w", err)
+	}
+
+	mqttCfg := cfg.MQTTConfig
+	c := &consumer{
+		logger:  logger,
+		handler: pipeline.Wrap(pushClient),
+	}
+
+	for _, topic := range mqttCfg.Topics {
+		filter := topic
+		if mqttCfg.SharedGroup != "" {
+			filter = fmt.Sprintf("$share/%s/%s", mqttCfg.SharedGroup, topic)
+		}
+		details := SubscriptionDetails{
+			ClientID:    mqttCfg.ClientID,
+			Topic:       filter,
+			SharedGroup: mqttCfg.SharedGroup,
+			QoS:         *mqttCfg.QoS,
+		}
+		discoveredLabels := model.LabelSet{
+			"__meta_mqtt_subscription": model.LabelValue(filter),
+			"__meta_mqtt_client_id":    model.LabelValue(mqttCfg.ClientID),
+			"__meta_mqtt_shared_group": model.LabelValue(mqttCfg.SharedGroup),
+		}
+		labelMap := make(map[string]string)
+		for k, v := range discoveredLabels.Clone().Merge(mqttCfg.Labels) {
+			labelMap[string(k)] = string(v)
+		}
+		labelOut, keep := target.Relabel(labels.FromMap(labelMap), cfg.RelabelConfigs)
+		if !keep || len(labelOut) == 0 {
+			level.Warn(logger).Log("msg", "dropping target", "reason", "no labels", "details", details, "discovered_labels", discoveredLabels.String())
+			c.droppedTargets = append(c.droppedTargets, target.NewDroppedTarget("dropping target, no labels", discoveredLabels))
+			continue
+		}
+		c.activeTargets = append(c.activeTargets, NewTarget(logger, details, discoveredLabels, labelOut, cfg.RelabelConfigs, c.handler))
+	}
+
+	tlsConfig, err := promconfig.NewTLSConfig(&mqttCfg.TLSConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create TLS config: %w", err)
+	}
+
+	opts := mqtt.NewClientOptions().
+		SetClientID(mqttCfg.ClientID).
+		SetUsername(mqttCfg.Username).
+		SetPassword(mqttCfg.Password.String()).
+		SetTLSConfig(tlsConfig).
+		SetCleanSession(mqttCfg.CleanSession).
+		// Messages are acknowledged once handed to the pipeline, so that
+		// the brokers deliver them again if promtail stops before.
+		SetAutoAckDisabled(true).
+		SetOrderMatters(false).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(c.subscribe).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			level.Warn(logger).Log("msg", "connection to the broker lost", "err", err)
+		})
+	for _, broker := range mqttCfg.Brokers {
+		opts.AddBroker(broker)
+	}
+
+	c.client = newClient(opts)
+	// With the connect retry enabled the token only completes once connected,
+	// the subscriptions are then made by the on connect handler.
+	c.client.Connect()
+	return c, nil
+}
+
+// subscribe subscribes to the topics of the active targets, it's called each
+// time the client (re)connects to a broker.
+func (c *consumer) subscribe(client mqtt.Client) {
+	level.Info(c.logger).Log("msg", "connected to the broker, subscribing to the topics")
+	for _, t := range c.activeTargets {
+		token := client.Subscribe(t.details.Topic, t.details.QoS, t.handle)
+		token.Wait()
+		if err := token.Error(); err != nil {
+			level.Error(c.logger).Log("msg", "failed to subscribe", "topic", t.details.Topic, "err", err)
+		}
+	}
+}
+
+func (c *consumer) ActiveTargets() []target.Target {
+	result := make([]target.Target, 0, len(c.activeTargets))
+	for _, t := range c.activeTargets {
+		result = append(result, t)
+	}
+	return result
+}
+
+func (c *consumer) DroppedTargets() []target.Target {
+	return c.droppedTargets
+}
+
+func (c *consumer) Stop() {
+	c.stopOnce.Do(func() {
+		c.client.Disconnect(disconnectQuiesce)
+		c.handler.Stop()
+	})
+}
+
+func validateConfig(cfg *scrapeconfig.Config) error {
+	if cfg.MQTTConfig == nil {
+		return errors.New("MQTT configuration is empty")
+	}
+	if len(cfg.MQTTConfig.Brokers) == 0 {
+		return errors.New("no MQTT brokers defined")
+	}
+	if len(cfg.MQTTConfig.Topics) == 0 {
+		return errors.New("no topics given to be subscribed to")
+	}
+	if cfg.MQTTConfig.ClientID == "" {
+		cfg.MQTTConfig.ClientID = defaultClientID
+	}
+	if cfg.MQTTConfig.QoS == nil {
+		qos := defaultQoS
+		cfg.MQTTConfig.QoS = &qos
+	}
+	if *cfg.MQTTConfig.QoS > 2 {
+		return fmt.Errorf("invalid QoS %d, it must be 0, 1 or 2", *cfg.MQTTConfig.QoS)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package mqtt
+
+import (
+	"strconv"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	labelKeyMQTTTopic    = "__meta_mqtt_topic"
+	labelKeyMQTTQoS      = "__meta_mqtt_qos"
+	labelKeyMQTTRetained = "__meta_mqtt_retained"
+)
+
+// Target receives the messages of a subscription.
+type Target struct {
+	logger           log.Logger
+	discoveredLabels model.LabelSet
+	lbs              model.LabelSet
+	details          SubscriptionDetails
+	client           api.EntryHandler
+	relabelConfig    []*relabel.Config
+}
+
+// SubscriptionDetails are the details of the subscription of a Target.
+type SubscriptionDetails struct {
+	ClientID    string
+	Topic       string
+	SharedGroup string
+	QoS         byte
+}
+
+func NewTarget(
+	logger log.Logger,
+	details SubscriptionDetails,
+	discoveredLabels, lbs model.LabelSet,
+	relabelConfig []*relabel.Config,
+	client api.EntryHandler,
+) *Target {
+	return &Target{
+		logger:           logger,
+		discoveredLabels: discoveredLabels,
+		lbs:              lbs,
+		details:          details,
+		client:           client,
+		relabelConfig:    relabelConfig,
+	}
+}
+
+// handle sends the message to the pipeline and acknowledges it once sent.
+func (t *Target) handle(_ mqtt.Client, message mqtt.Message) {
+	lbs, keep := target.Relabel(labels.FromStrings(
+		labelKeyMQTTTopic, message.Topic(),
+		labelKeyMQTTQoS, strconv.Itoa(int(message.Qos())),
+		labelKeyMQTTRetained, strconv.FormatBool(message.Retained()),
+	), t.relabelConfig)
+	if !keep {
+		// Dropped by the relabel configs, there is nothing to send.
+		message.Ack()
+		return
+	}
+
+	out := t.lbs.Clone()
+	if len(lbs) > 0 {
+		out = out.Merge(lbs)
+	}
+
+	t.client.Chan() <- api.Entry{
+		Labels: out,
+		Entry: logproto.Entry{
+			Timestamp: time.Now(),
+			Line:      string(message.Payload()),
+		},
+	}
+	message.Ack()
+}
+
+func (t *Target) Type() target.TargetType {
+	return target.MQTTTargetType
+}
+
+func (t *Target) Ready() bool {
+	return true
+}
+
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return t.discoveredLabels
+}
+
+func (t *Target) Labels() model.LabelSet {
+	return t.lbs
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return t.details
+}
@@ -0,0 +1,164 @@
+package mqtt
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+type doneToken struct{ err error }
+
+func (t doneToken) Wait() bool                     { return true }
+func (t doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t doneToken) Error() error                   { return t.err }
+func (t doneToken) Done() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+type fakeClient struct {
+	mqtt.Client
+	opts *mqtt.ClientOptions
+
+	mtx           sync.Mutex
+	subscriptions map[string]mqtt.MessageHandler
+	qos           map[string]byte
+	disconnected  bool
+}
+
+func (c *fakeClient) Connect() mqtt.Token {
+	c.opts.OnConnect(c)
+	return doneToken{}
+}
+
+func (c *fakeClient) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.subscriptions[topic] = callback
+	c.qos[topic] = qos
+	return doneToken{}
+}
+
+func (c *fakeClient) Disconnect(uint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.disconnected = true
+}
+
+func (c *fakeClient) publish(subscription string, msg *fakeMessage) {
+	c.mtx.Lock()
+	handler := c.subscriptions[subscription]
+	c.mtx.Unlock()
+	handler(c, msg)
+}
+
+type fakeMessage struct {
+	mqtt.Message
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+	acked    atomic.Bool
+}
+
+func (m *fakeMessage) Topic() string   { return m.topic }
+func (m *fakeMessage) Qos() byte       { return m.qos }
+func (m *fakeMessage) Retained() bool  { return m.retained }
+func (m *fakeMessage) Payload() []byte { return []byte(m.payload) }
+func (m *fakeMessage) Ack()            { m.acked.Store(true) }
+
+func Test_MQTTTarget(t *testing.T) {
+	client := fake.New(func() {})
+	var fc *fakeClient
+	tm, err := newTargetManager(prometheus.NewRegistry(), log.NewNopLogger(), client, []scrapeconfig.Config{
+		{
+			JobName: "mqtt",
+			MQTTConfig: &scrapeconfig.MQTTTargetConfig{
+				Brokers:     []string{"tcp://localhost:1883"},
+				Topics:      []string{"sensors/#", "dropped"},
+				SharedGroup: "loki",
+				Labels:      model.LabelSet{"job": "mqtt"},
+			},
+			RelabelConfigs: []*relabel.Config{
+				{
+					SourceLabels: model.LabelNames{"__meta_mqtt_topic"},
+					TargetLabel:  "topic",
+					Replacement:  "$1",
+					Action:       relabel.Replace,
+					Regex:        relabel.MustNewRegexp("(.*)"),
+				},
+				{
+					SourceLabels: model.LabelNames{"__meta_mqtt_subscription"},
+					Action:       relabel.Drop,
+					Regex:        relabel.MustNewRegexp(".*/dropped"),
+				},
+				{
+					SourceLabels: model.LabelNames{"__meta_mqtt_retained"},
+					Action:       relabel.Drop,
+					Regex:        relabel.MustNewRegexp("true"),
+				},
+			},
+		},
+	}, func(opts *mqtt.ClientOptions) mqtt.Client {
+		require.Equal(t, "promtail", opts.ClientID)
+		require.True(t, opts.AutoAckDisabled)
+		require.Equal(t, "localhost:1883", opts.Servers[0].Host)
+		fc = &fakeClient{opts: opts, subscriptions: map[string]mqtt.MessageHandler{}, qos: map[string]byte{}}
+		return fc
+	})
+	require.NoError(t, err)
+
+	require.True(t, tm.Ready())
+	require.Len(t, tm.ActiveTargets()["mqtt"], 1)
+	require.Len(t, tm.AllTargets()["mqtt"], 2)
+	require.Equal(t, model.LabelSet{"job": "mqtt"}, tm.ActiveTargets()["mqtt"][0].Labels())
+
+	// Only the active target is subscribed to, with the default QoS.
+	require.Len(t, fc.subscriptions, 1)
+	require.Equal(t, byte(1), fc.qos["$share/loki/sensors/#"])
+
+	msg := &fakeMessage{topic: "sensors/kitchen", qos: 1, payload: "21.5"}
+	fc.publish("$share/loki/sensors/#", msg)
+	require.True(t, msg.acked.Load())
+	// The messages dropped by the relabel configs are acknowledged but not sent.
+	retained := &fakeMessage{topic: "sensors/garage", qos: 1, retained: true, payload: "18.0"}
+	fc.publish("$share/loki/sensors/#", retained)
+	require.True(t, retained.acked.Load())
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Never(t, func() bool {
+		return len(client.Received()) > 1
+	}, 100*time.Millisecond, 20*time.Millisecond)
+	received := client.Received()[0]
+	require.Equal(t, "21.5", received.Line)
+	require.Equal(t, model.LabelSet{"job": "mqtt", "topic": "sensors/kitchen"}, received.Labels)
+
+	tm.Stop()
+	require.True(t, fc.disconnected)
+}
+
+func Test_ValidateConfig(t *testing.T) {
+	for name, cfg := range map[string]*scrapeconfig.MQTTTargetConfig{
+		"no brokers":  {Topics: []string{"a"}},
+		"no topics":   {Brokers: []string{"tcp://localhost:1883"}},
+		"invalid qos": {Brokers: []string{"tcp://localhost:1883"}, Topics: []string{"a"}, QoS: func() *byte { q := byte(3); return &q }()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, validateConfig(&scrapeconfig.Config{MQTTConfig: cfg}))
+		})
+	}
+}
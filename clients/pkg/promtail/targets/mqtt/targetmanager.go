@@ -0,0 +1,82 @@
+package mqtt
+
+import (
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+// TargetManager manages a series of MQTT targets.
+type TargetManager struct {
+	logger    log.Logger
+	consumers map[string]*consumer
+}
+
+// NewTargetManager creates a new MQTT target manager.
+func NewTargetManager(
+	reg prometheus.Registerer,
+	logger log.Logger,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	return newTargetManager(reg, logger, pushClient, scrapeConfigs, mqtt.NewClient)
+}
+
+func newTargetManager(
+	reg prometheus.Registerer,
+	logger log.Logger,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+	newClient func(*mqtt.ClientOptions) mqtt.Client,
+) (*TargetManager, error) {
+	tm := &TargetManager{
+		logger:    logger,
+		consumers: make(map[string]*consumer),
+	}
+	for _, cfg := range scrapeConfigs {
+		c, err := newConsumer(reg, logger, cfg, pushClient, newClient)
+		if err != nil {
+			tm.Stop()
+			return nil, err
+		}
+		tm.consumers[cfg.JobName] = c
+	}
+
+	return tm, nil
+}
+
+// Ready returns true if at least one MQTT target is active.
+func (tm *TargetManager) Ready() bool {
+	for _, c := range tm.consumers {
+		if len(c.ActiveTargets()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TargetManager) Stop() {
+	for _, c := range tm.consumers {
+		c.Stop()
+	}
+}
+
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.consumers))
+	for k, v := range tm.consumers {
+		result[k] = v.ActiveTargets()
+	}
+	return result
+}
+
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.consumers))
+	for k, v := range tm.consumers {
+		result[k] = append(v.ActiveTargets(), v.DroppedTargets()...)
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package nats
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/util/strutil"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	labelKeyNATSSubject      = "__meta_nats_subject"
+	labelPrefixNATSHeader    = "__meta_nats_header_"
+	labelKeyNATSStream       = "__meta_nats_stream"
+	labelKeyNATSConsumerName = "__meta_nats_consumer"
+)
+
+// Target consumes the messages of a JetStream consumer.
+type Target struct {
+	logger               log.Logger
+	discoveredLabels     model.LabelSet
+	lbs                  model.LabelSet
+	details              ConsumerDetails
+	handler              api.EntryHandler
+	relabelConfig        []*relabel.Config
+	useIncomingTimestamp bool
+
+	consumeCtx jetstream.ConsumeContext
+}
+
+// ConsumerDetails are the details of the JetStream consumer of a Target.
+type ConsumerDetails struct {
+	Stream   string
+	Consumer string
+	Subjects []string
+}
+
+// NewTarget creates a Target consuming the messages of the given consumer.
+func NewTarget(
+	logger log.Logger,
+	consumer jetstream.Consumer,
+	details ConsumerDetails,
+	discoveredLabels, lbs model.LabelSet,
+	relabelConfig []*relabel.Config,
+	handler api.EntryHandler,
+	useIncomingTimestamp bool,
+) (*Target, error) {
+	t := &Target{
+		logger:               logger,
+		discoveredLabels:     discoveredLabels,
+		lbs:                  lbs,
+		details:              details,
+		handler:              handler,
+		relabelConfig:        relabelConfig,
+		useIncomingTimestamp: useIncomingTimestamp,
+	}
+	consumeCtx, err := consumer.Consume(t.handle, jetstream.ConsumeErrHandler(func(_ jetstream.ConsumeContext, err error) {
+		level.Warn(logger).Log("msg", "error consuming messages", "err", err)
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume messages: %w", err)
+	}
+	t.consumeCtx = consumeCtx
+	return t, nil
+}
+
+// handle sends the message to the pipeline and acknowledges it once sent.
+func (t *Target) handle(msg jetstream.Msg) {
+	lbs := make(labels.Labels, 0, 1+len(msg.Headers()))
+	lbs = append(lbs, labels.Label{Name: labelKeyNATSSubject, Value: msg.Subject()})
+	for name, values := range msg.Headers() {
+		if len(values) == 0 {
+			continue
+		}
+		lbs = append(lbs, labels.Label{Name: labelPrefixNATSHeader + strutil.SanitizeLabelName(name), Value: values[0]})
+	}
+	processed, keep := target.Relabel(labels.New(lbs...), t.relabelConfig)
+	if !keep {
+		// Dropped by the relabel configs, there is nothing to send.
+		if err := msg.Ack(); err != nil {
+			level.Warn(t.logger).Log("msg", "failed to acknowledge message", "subject", msg.Subject(), "err", err)
+		}
+		return
+	}
+
+	out := t.lbs.Clone()
+	if len(processed) > 0 {
+		out = out.Merge(processed)
+	}
+
+	timestamp := time.Now()
+	if t.useIncomingTimestamp {
+		if metadata, err := msg.Metadata(); err == nil {
+			timestamp = metadata.Timestamp
+		} else {
+			level.Debug(t.logger).Log("msg", "failed to read the message metadata, using the current time", "err", err)
+		}
+	}
+
+	t.handler.Chan() <- api.Entry{
+		Labels: out,
+		Entry: logproto.Entry{
+			Timestamp: timestamp,
+			Line:      string(msg.Data()),
+		},
+	}
+	if err := msg.Ack(); err != nil {
+		level.Warn(t.logger).Log("msg", "failed to acknowledge message", "subject", msg.Subject(), "err", err)
+	}
+}
+
+// Stop stops consuming messages, the messages already received are sent
+// before it returns.
+func (t *Target) Stop() {
+	t.consumeCtx.Drain()
+	<-t.consumeCtx.Closed()
+}
+
+func (t *Target) Type() target.TargetType {
+	return target.NATSTargetType
+}
+
+func (t *Target) Ready() bool {
+	return true
+}
+
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return t.discoveredLabels
+}
+
+func (t *Target) Labels() model.LabelSet {
+	return t.lbs
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return t.details
+}
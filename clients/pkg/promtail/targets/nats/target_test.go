@@ -0,0 +1,131 @@
+package nats
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+type fakeConsumer struct {
+	jetstream.Consumer
+	handler jetstream.MessageHandler
+	closed  chan struct{}
+}
+
+func (c *fakeConsumer) Consume(handler jetstream.MessageHandler, _ ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	c.handler = handler
+	c.closed = make(chan struct{})
+	return c, nil
+}
+
+func (c *fakeConsumer) Stop()                   { close(c.closed) }
+func (c *fakeConsumer) Drain()                  { close(c.closed) }
+func (c *fakeConsumer) Closed() <-chan struct{} { return c.closed }
+
+type fakeMsg struct {
+	jetstream.Msg
+	subject   string
+	data      string
+	headers   natsgo.Header
+	timestamp time.Time
+	acked     atomic.Bool
+}
+
+func (m *fakeMsg) Subject() string        { return m.subject }
+func (m *fakeMsg) Data() []byte           { return []byte(m.data) }
+func (m *fakeMsg) Headers() natsgo.Header { return m.headers }
+func (m *fakeMsg) Ack() error {
+	m.acked.Store(true)
+	return nil
+}
+
+func (m *fakeMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	if m.timestamp.IsZero() {
+		return nil, errors.New("no metadata")
+	}
+	return &jetstream.MsgMetadata{Timestamp: m.timestamp}, nil
+}
+
+func Test_NATSTarget(t *testing.T) {
+	client := fake.New(func() {})
+	consumer := &fakeConsumer{}
+	relabelConfigs := []*relabel.Config{
+		{
+			SourceLabels: model.LabelNames{"__meta_nats_subject"},
+			TargetLabel:  "subject",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.*)"),
+		},
+		{
+			SourceLabels: model.LabelNames{"__meta_nats_header_X_Level"},
+			TargetLabel:  "level",
+			Replacement:  "$1",
+			Action:       relabel.Replace,
+			Regex:        relabel.MustNewRegexp("(.+)"),
+		},
+		{
+			SourceLabels: model.LabelNames{"__meta_nats_subject"},
+			Action:       relabel.Drop,
+			Regex:        relabel.MustNewRegexp("logs\\.debug"),
+		},
+	}
+	target, err := NewTarget(log.NewNopLogger(), consumer, ConsumerDetails{Stream: "logs", Consumer: "promtail"},
+		model.LabelSet{"__meta_nats_stream": "logs"}, model.LabelSet{"job": "nats"}, relabelConfigs, client, true)
+	require.NoError(t, err)
+
+	ts := time.Unix(10, 0)
+	first := &fakeMsg{subject: "logs.app", data: "hello", headers: natsgo.Header{"X-Level": []string{"error"}}, timestamp: ts}
+	consumer.handler(first)
+	require.True(t, first.acked.Load())
+	// The messages dropped by the relabel configs are acknowledged but not sent.
+	dropped := &fakeMsg{subject: "logs.debug", data: "dropped"}
+	consumer.handler(dropped)
+	require.True(t, dropped.acked.Load())
+	// Without metadata the current time is used.
+	second := &fakeMsg{subject: "logs.db", data: "world"}
+	consumer.handler(second)
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+	received := client.Received()
+	require.Len(t, received, 2)
+	require.Equal(t, model.LabelSet{"job": "nats", "subject": "logs.app", "level": "error"}, received[0].Labels)
+	require.Equal(t, "hello", received[0].Line)
+	require.Equal(t, ts, received[0].Timestamp)
+	require.Equal(t, model.LabelSet{"job": "nats", "subject": "logs.db"}, received[1].Labels)
+	require.NotEqual(t, ts, received[1].Timestamp)
+
+	require.Equal(t, model.LabelSet{"job": "nats"}, target.Labels())
+	target.Stop()
+}
+
+func Test_ValidateConfig(t *testing.T) {
+	cfg := &scrapeconfig.Config{NATSConfig: &scrapeconfig.NATSTargetConfig{URLs: []string{"nats://localhost:4222"}, Stream: "logs"}}
+	require.NoError(t, validateConfig(cfg))
+	require.Equal(t, "promtail", cfg.NATSConfig.Consumer)
+	require.Equal(t, "all", cfg.NATSConfig.DeliverPolicy)
+	require.Equal(t, 30*time.Second, cfg.NATSConfig.AckWait)
+
+	for name, cfg := range map[string]*scrapeconfig.NATSTargetConfig{
+		"no urls":        {Stream: "logs"},
+		"no stream":      {URLs: []string{"nats://localhost:4222"}},
+		"invalid policy": {URLs: []string{"nats://localhost:4222"}, Stream: "logs", DeliverPolicy: "first"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Error(t, validateConfig(&scrapeconfig.Config{NATSConfig: cfg}))
+		})
+	}
+}
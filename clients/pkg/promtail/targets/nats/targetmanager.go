@@ -0,0 +1,249 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/clients/pkg/logentry/stages"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+)
+
+const (
+	defaultConsumer = "promtail"
+	defaultAckWait  = 30 * time.Second
+
+	// setupTimeout is the maximum time given to create or update the consumer.
+	setupTimeout = 30 * time.Second
+)
+
+var deliverPolicies = map[string]jetstream.DeliverPolicy{
+	"all":  jetstream.DeliverAllPolicy,
+	"new":  jetstream.DeliverNewPolicy,
+	"last": jetstream.DeliverLastPolicy,
+}
+
+// TargetManager manages a series of NATS JetStream targets.
+type TargetManager struct {
+	logger log.Logger
+	jobs   map[string]*job
+}
+
+// job holds the connection and the target of a scrape config.
+type job struct {
+	conn    *natsgo.Conn
+	handler api.EntryHandler
+	active  *Target
+	dropped target.Target
+}
+
+// NewTargetManager creates a new NATS JetStream target manager.
+func NewTargetManager(
+	reg prometheus.Registerer,
+	logger log.Logger,
+	pushClient api.EntryHandler,
+	scrapeConfigs []scrapeconfig.Config,
+) (*TargetManager, error) {
+	tm := &TargetManager{
+		logger: logger,
+		jobs:   make(map[string]*job),
+	}
+	for _, cfg := range scrapeConfigs {
+		j, err := newJob(reg, logger, cfg, pushClient)
+		if err != nil {
+			tm.Stop()
+			return nil, err
+		}
+		tm.jobs[cfg.JobName] = j
+	}
+
+	return tm, nil
+}
+
+func newJob(reg prometheus.Registerer, logger log.Logger, cfg scrapeconfig.Config, pushClient api.EntryHandler) (*job, error) {
+	if err := validateConfig(&cfg); err != nil {
+		return nil, err
+	}
+	natsCfg := cfg.NATSConfig
+	logger = log.With(logger, "target", "nats", "job", cfg.JobName)
+
+	details := ConsumerDetails{
+		Stream:   natsCfg.Stream,
+		Consumer: natsCfg.Consumer,
+		Subjects: natsCfg.Subjects,
+	}
+	discoveredLabels := model.LabelSet{
+		labelKeyNATSStream:       model.LabelValue(natsCfg.Stream),
+		labelKeyNATSConsumerName: model.LabelValue(natsCfg.Consumer),
+	}
+	labelMap := make(map[string]string)
+	for k, v := range discoveredLabels.Clone().Merge(natsCfg.Labels) {
+		labelMap[string(k)] = string(v)
+	}
+	labelOut, keep := target.Relabel(labels.FromMap(labelMap), cfg.RelabelConfigs)
+	if !keep || len(labelOut) == 0 {
+		level.Warn(logger).Log("msg", "dropping target", "reason", "no labels", "details", details, "discovered_labels", discoveredLabels.String())
+		return &job{dropped: target.NewDroppedTarget("dropping target, no labels", discoveredLabels)}, nil
+	}
+
+	pipeline, err := stages.NewPipeline(log.With(logger, "component", "nats_pipeline"), cfg.PipelineStages, &cfg.JobName, reg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pipeline: %w", err)
+	}
+
+	conn, err := connect(logger, natsCfg)
+	if err != nil {
+		return nil, err
+	}
+	consumer, err := createConsumer(conn, natsCfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	handler := pipeline.Wrap(pushClient)
+	t, err := NewTarget(logger, consumer, details, discoveredLabels, labelOut, cfg.RelabelConfigs, handler, natsCfg.UseIncomingTimestamp)
+	if err != nil {
+		handler.Stop()
+		conn.Close()
+		return nil, err
+	}
+	return &job{conn: conn, handler: handler, active: t}, nil
+}
+
+func connect(logger log.Logger, cfg *scrapeconfig.NATSTargetConfig) (*natsgo.Conn, error) {
+	opts := []natsgo.Option{
+		natsgo.Name("promtail"),
+		natsgo.MaxReconnects(-1),
+		natsgo.DisconnectErrHandler(func(_ *natsgo.Conn, err error) {
+			if err != nil {
+				level.Warn(logger).Log("msg", "disconnected from the server", "err", err)
+			}
+		}),
+	}
+	switch {
+	case cfg.CredentialsFile != "":
+		opts = append(opts, natsgo.UserCredentials(cfg.CredentialsFile))
+	case cfg.Token.String() != "":
+		opts = append(opts, natsgo.Token(cfg.Token.String()))
+	case cfg.Username != "":
+		opts = append(opts, natsgo.UserInfo(cfg.Username, cfg.Password.String()))
+	}
+	if cfg.TLSConfig != (promconfig.TLSConfig{}) {
+		tlsConfig, err := promconfig.NewTLSConfig(&cfg.TLSConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create TLS config: %w", err)
+		}
+		opts = append(opts, natsgo.Secure(tlsConfig))
+	}
+
+	conn, err := natsgo.Connect(strings.Join(cfg.URLs, ","), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return conn, nil
+}
+
+// createConsumer creates the durable consumer shared by the promtail
+// instances, or updates it when its configuration changed.
+func createConsumer(conn *natsgo.Conn, cfg *scrapeconfig.NATSTargetConfig) (jetstream.Consumer, error) {
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), setupTimeout)
+	defer cancel()
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, cfg.Stream, jetstream.ConsumerConfig{
+		Durable:        cfg.Consumer,
+		FilterSubjects: cfg.Subjects,
+		DeliverPolicy:  deliverPolicies[cfg.DeliverPolicy],
+		AckPolicy:      jetstream.AckExplicitPolicy,
+		AckWait:        cfg.AckWait,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create consumer %q of stream %q: %w", cfg.Consumer, cfg.Stream, err)
+	}
+	return consumer, nil
+}
+
+// Ready returns true if at least one NATS target is active.
+func (tm *TargetManager) Ready() bool {
+	for _, j := range tm.jobs {
+		if j.active != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (tm *TargetManager) Stop() {
+	for _, j := range tm.jobs {
+		if j.active == nil {
+			continue
+		}
+		j.active.Stop()
+		j.handler.Stop()
+		j.conn.Close()
+	}
+}
+
+func (tm *TargetManager) ActiveTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.jobs))
+	for k, j := range tm.jobs {
+		if j.active != nil {
+			result[k] = []target.Target{j.active}
+		}
+	}
+	return result
+}
+
+func (tm *TargetManager) AllTargets() map[string][]target.Target {
+	result := make(map[string][]target.Target, len(tm.jobs))
+	for k, j := range tm.jobs {
+		if j.active != nil {
+			result[k] = []target.Target{j.active}
+		} else {
+			result[k] = []target.Target{j.dropped}
+		}
+	}
+	return result
+}
+
+func validateConfig(cfg *scrapeconfig.Config) error {
+	if cfg.NATSConfig == nil {
+		return errors.New("NATS configuration is empty")
+	}
+	if len(cfg.NATSConfig.URLs) == 0 {
+		return errors.New("no NATS servers defined")
+	}
+	if cfg.NATSConfig.Stream == "" {
+		return errors.New("no stream given to be consumed")
+	}
+	if cfg.NATSConfig.Consumer == "" {
+		cfg.NATSConfig.Consumer = defaultConsumer
+	}
+	if cfg.NATSConfig.DeliverPolicy == "" {
+		cfg.NATSConfig.DeliverPolicy = "all"
+	}
+	if _, ok := deliverPolicies[cfg.NATSConfig.DeliverPolicy]; !ok {
+		return fmt.Errorf("invalid deliver policy %q, it must be one of all, new or last", cfg.NATSConfig.DeliverPolicy)
+	}
+	if cfg.NATSConfig.AckWait == 0 {
+		cfg.NATSConfig.AckWait = defaultAckWait
+	}
+	return nil
+}
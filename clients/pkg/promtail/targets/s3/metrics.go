@@ -0,0 +1,45 @@
+package s3
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Metrics holds a set of S3 target metrics.
+type Metrics struct {
+	reg prometheus.Registerer
+
+	entries prometheus.Counter
+	objects prometheus.Counter
+	errors  prometheus.Counter
+}
+
+// NewMetrics creates a new set of S3 target metrics. If reg is non-nil, the
+// metrics will be registered.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	var m Metrics
+	m.reg = reg
+
+	m.entries = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "s3_target_entries_total",
+		Help:      "Total number of successful entries sent via the S3 target",
+	})
+	m.objects = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "s3_target_objects_total",
+		Help:      "Total number of objects entirely read by the S3 target",
+	})
+	m.errors = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "promtail",
+		Name:      "s3_target_errors_total",
+		Help:      "Total number of errors while listing, reading objects or receiving bucket notifications",
+	})
+
+	if reg != nil {
+		reg.MustRegister(
+			m.entries,
+			m.objects,
+			m.errors,
+		)
+	}
+
+	return &m
+}
@@ -0,0 +1,580 @@
+package s3
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	awss3 "github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/api"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/file"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/targets/target"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	defaultPollInterval = time.Minute
+	defaultRegion       = "us-east-1"
+
+	formatNone = "none"
+
+	maxLineSize = 2000000 // 2 MB
+
+	// maxQueueRetention is the maximum retention of a SQS queue, used when
+	// the retention of the queue can't be retrieved.
+	maxQueueRetention = 14 * 24 * time.Hour
+	// expireDoneInterval is the interval at which the positions of the
+	// objects no longer notified are removed.
+	expireDoneInterval = time.Hour
+)
+
+var notificationBackoff = backoff.Config{
+	MinBackoff: 1 * time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// Target reads the objects written to a S3 compatible bucket, either by
+// polling the bucket or by consuming its notifications from a SQS queue.
+type Target struct {
+	metrics       *Metrics
+	logger        log.Logger
+	handler       api.EntryHandler
+	positions     positions.Positions
+	config        *scrapeconfig.S3TargetConfig
+	relabelConfig []*relabel.Config
+
+	s3  s3iface.S3API
+	sqs sqsiface.SQSAPI
+
+	// retention is the retention of the SQS queue, the positions of the
+	// objects read are kept as long as their notification can be received again.
+	retention time.Duration
+	now       func() time.Time
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	running *atomic.Bool
+}
+
+// NewTarget creates a new S3 Target.
+func NewTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	positions positions.Positions,
+	relabel []*relabel.Config,
+	config *scrapeconfig.S3TargetConfig,
+) (*Target, error) {
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	s3Client, sqsClient, err := newClients(config)
+	if err != nil {
+		return nil, err
+	}
+	return newTarget(metrics, logger, handler, positions, relabel, config, s3Client, sqsClient), nil
+}
+
+func newTarget(
+	metrics *Metrics,
+	logger log.Logger,
+	handler api.EntryHandler,
+	positions positions.Positions,
+	relabel []*relabel.Config,
+	config *scrapeconfig.S3TargetConfig,
+	s3Client s3iface.S3API,
+	sqsClient sqsiface.SQSAPI,
+) *Target {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &Target{
+		metrics:       metrics,
+		logger:        logger,
+		handler:       handler,
+		positions:     positions,
+		config:        config,
+		relabelConfig: relabel,
+		s3:            s3Client,
+		sqs:           sqsClient,
+		retention:     maxQueueRetention,
+		now:           time.Now,
+
+		ctx:     ctx,
+		cancel:  cancel,
+		running: atomic.NewBool(false),
+	}
+	t.start()
+	return t
+}
+
+func validateConfig(cfg *scrapeconfig.S3TargetConfig) error {
+	if cfg.Bucket == "" {
+		return errors.New("bucket is required")
+	}
+	switch cfg.Format {
+	case "", formatNone, "gz", "z", "bz2":
+	default:
+		return fmt.Errorf("unsupported format %q, it has to be one of gz, z, bz2 or none", cfg.Format)
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	return nil
+}
+
+func newClients(cfg *scrapeconfig.S3TargetConfig) (s3iface.S3API, sqsiface.SQSAPI, error) {
+	awsConfig := aws.NewConfig()
+	if cfg.Region != "" {
+		awsConfig = awsConfig.WithRegion(cfg.Region)
+	} else if cfg.Endpoint != "" {
+		awsConfig = awsConfig.WithRegion(defaultRegion)
+	}
+	if cfg.AccessKeyID != "" {
+		awsConfig = awsConfig.WithCredentials(credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey.String(), ""))
+	}
+	sess, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the AWS session: %w", err)
+	}
+
+	s3Config := aws.NewConfig().WithS3ForcePathStyle(cfg.S3ForcePathStyle)
+	if cfg.Endpoint != "" {
+		s3Config = s3Config.WithEndpoint(cfg.Endpoint)
+	}
+	s3Client := awss3.New(sess, s3Config)
+
+	var sqsClient sqsiface.SQSAPI
+	if cfg.SQSQueueURL != "" {
+		sqsClient = sqs.New(sess)
+	}
+	return s3Client, sqsClient, nil
+}
+
+func (t *Target) start() {
+	t.wg.Add(1)
+	t.running.Store(true)
+	go func() {
+		defer func() {
+			t.wg.Done()
+			t.running.Store(false)
+		}()
+		if t.sqs != nil {
+			level.Info(t.logger).Log("msg", "consuming bucket notifications", "bucket", t.config.Bucket, "queue_url", t.config.SQSQueueURL)
+			t.consumeNotifications()
+			return
+		}
+
+		level.Info(t.logger).Log("msg", "polling bucket", "bucket", t.config.Bucket, "prefix", t.config.Prefix, "interval", t.config.PollInterval)
+		ticker := time.NewTicker(t.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			if err := t.poll(); err != nil && t.ctx.Err() == nil {
+				level.Error(t.logger).Log("msg", "failed to poll bucket", "bucket", t.config.Bucket, "err", err)
+				t.metrics.errors.Inc()
+			}
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// poll lists the objects of the bucket after the last one entirely read and
+// reads them. Objects are expected to be written in the lexical order of their
+// keys, the ones written before the last object read are not listed anymore.
+func (t *Target) poll() error {
+	listKey := t.listPositionKey()
+	startAfter := t.positions.GetString(listKey)
+	input := &awss3.ListObjectsV2Input{
+		Bucket: aws.String(t.config.Bucket),
+		Prefix: aws.String(t.config.Prefix),
+	}
+	if startAfter != "" {
+		input.StartAfter = aws.String(startAfter)
+	}
+	var objects []*awss3.Object
+	err := t.s3.ListObjectsV2PagesWithContext(t.ctx, input, func(page *awss3.ListObjectsV2Output, _ bool) bool {
+		objects = append(objects, page.Contents...)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	// The listing resumes after the last object read in order, the positions
+	// of the objects before it aren't needed anymore.
+	advance := true
+	for _, obj := range objects {
+		key := aws.StringValue(obj.Key)
+		if !strings.HasSuffix(key, "/") {
+			if err := t.readObject(key, aws.StringValue(obj.ETag)); err != nil {
+				if t.ctx.Err() != nil {
+					return nil
+				}
+				level.Error(t.logger).Log("msg", "failed to read object", "bucket", t.config.Bucket, "key", key, "err", err)
+				t.metrics.errors.Inc()
+				advance = false
+				continue
+			}
+		}
+		if advance {
+			t.positions.PutString(listKey, key)
+			t.positions.Remove(t.positionKey(key))
+		}
+	}
+	return nil
+}
+
+// bucketNotification is the S3 event notification, optionally wrapped in a
+// SNS notification when the bucket notifies a SNS topic.
+type bucketNotification struct {
+	Message string `json:"Message"`
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key  string `json:"key"`
+				ETag string `json:"eTag"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+}
+
+func (t *Target) consumeNotifications() {
+	t.retention = t.queueRetention()
+	lastExpiry := time.Time{}
+	backoff := backoff.New(t.ctx, notificationBackoff)
+	for t.ctx.Err() == nil {
+		if now := t.now(); now.Sub(lastExpiry) >= expireDoneInterval {
+			t.expireDone(now)
+			lastExpiry = now
+		}
+
+		out, err := t.sqs.ReceiveMessageWithContext(t.ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(t.config.SQSQueueURL),
+			MaxNumberOfMessages: aws.Int64(10),
+			WaitTimeSeconds:     aws.Int64(20),
+		})
+		if err != nil {
+			if t.ctx.Err() != nil {
+				return
+			}
+			level.Error(t.logger).Log("msg", "failed to receive bucket notifications", "queue_url", t.config.SQSQueueURL, "err", err, "num_retries", backoff.NumRetries())
+			t.metrics.errors.Inc()
+			backoff.Wait()
+			continue
+		}
+		backoff.Reset()
+
+		for _, msg := range out.Messages {
+			err := t.handleNotification(aws.StringValue(msg.Body))
+			if err != nil {
+				if t.ctx.Err() != nil {
+					return
+				}
+				// The message is received again once its visibility timeout expires.
+				level.Error(t.logger).Log("msg", "failed to handle bucket notification", "message_id", aws.StringValue(msg.MessageId), "err", err)
+				t.metrics.errors.Inc()
+				continue
+			}
+
+			// The positions of the objects are kept, the notification can
+			// still be delivered again even once deleted.
+			if _, err := t.sqs.DeleteMessageWithContext(t.ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(t.config.SQSQueueURL),
+				ReceiptHandle: msg.ReceiptHandle,
+			}); err != nil {
+				level.Error(t.logger).Log("msg", "failed to delete bucket notification", "message_id", aws.StringValue(msg.MessageId), "err", err)
+				t.metrics.errors.Inc()
+			}
+		}
+	}
+}
+
+// queueRetention returns the message retention period of the SQS queue.
+func (t *Target) queueRetention() time.Duration {
+	out, err := t.sqs.GetQueueAttributesWithContext(t.ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(t.config.SQSQueueURL),
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameMessageRetentionPeriod)},
+	})
+	if err == nil {
+		var seconds int64
+		seconds, err = strconv.ParseInt(aws.StringValue(out.Attributes[sqs.QueueAttributeNameMessageRetentionPeriod]), 10, 64)
+		if err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	level.Warn(t.logger).Log("msg", "failed to get the retention of the queue, keeping the positions of the objects read for the maximum retention", "queue_url", t.config.SQSQueueURL, "retention", maxQueueRetention, "err", err)
+	return maxQueueRetention
+}
+
+// expireDone removes the positions of the objects entirely read for longer
+// than the retention of the queue, their notifications can't be received
+// anymore.
+func (t *Target) expireDone(now time.Time) {
+	for _, key := range t.positions.Keys(t.positionKey("")) {
+		pos := parsePosition(t.positions.GetString(key))
+		if pos.done && now.Sub(pos.doneAt) > t.retention {
+			t.positions.Remove(key)
+		}
+	}
+}
+
+// handleNotification reads the objects created according to the
+// notification.
+func (t *Target) handleNotification(body string) error {
+	var notification bucketNotification
+	if err := json.Unmarshal([]byte(body), &notification); err != nil {
+		return fmt.Errorf("invalid bucket notification: %w", err)
+	}
+	if len(notification.Records) == 0 && notification.Message != "" {
+		return t.handleNotification(notification.Message)
+	}
+
+	for _, record := range notification.Records {
+		if !strings.HasPrefix(record.EventName, "ObjectCreated:") || record.S3.Bucket.Name != t.config.Bucket {
+			continue
+		}
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return fmt.Errorf("invalid object key %q: %w", record.S3.Object.Key, err)
+		}
+		if !strings.HasPrefix(key, t.config.Prefix) || strings.HasSuffix(key, "/") {
+			continue
+		}
+		if err := t.readObject(key, record.S3.Object.ETag); err != nil {
+			return fmt.Errorf("failed to read object %q: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// readObject sends the lines of an object not sent yet and records the
+// position of the last line sent.
+func (t *Target) readObject(key, etag string) error {
+	etag = strings.Trim(etag, `"`)
+	posKey := t.positionKey(key)
+	pos := parsePosition(t.positions.GetString(posKey))
+	if pos.etag == etag && pos.done {
+		return nil
+	}
+	if pos.etag != etag {
+		// The object is new or has been overwritten.
+		pos = objectPosition{etag: etag}
+	}
+
+	lbs, keep := t.objectLabels(key)
+	if !keep {
+		pos.done, pos.doneAt = true, t.now()
+		t.positions.PutString(posKey, pos.String())
+		return nil
+	}
+
+	input := &awss3.GetObjectInput{
+		Bucket: aws.String(t.config.Bucket),
+		Key:    aws.String(key),
+	}
+	if etag != "" {
+		// Fails if the object is overwritten in the meantime, its new version
+		// will be read later on.
+		input.IfMatch = aws.String(`"` + etag + `"`)
+	}
+	out, err := t.s3.GetObjectWithContext(t.ctx, input)
+	if err != nil {
+		return err
+	}
+	defer out.Body.Close()
+
+	r, err := t.objectReader(key, out.Body)
+	if err != nil {
+		return err
+	}
+
+	level.Debug(t.logger).Log("msg", "reading object", "bucket", t.config.Bucket, "key", key, "position", pos.lines)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLineSize)
+	for line := int64(1); scanner.Scan(); line++ {
+		if line <= pos.lines {
+			// skip already sent lines.
+			continue
+		}
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+
+		t.handler.Chan() <- api.Entry{
+			Labels: lbs.Clone(),
+			Entry: logproto.Entry{
+				Timestamp: time.Now(),
+				Line:      scanner.Text(),
+			},
+		}
+		t.metrics.entries.Inc()
+		pos.lines = line
+		t.positions.PutString(posKey, pos.String())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	pos.done, pos.doneAt = true, t.now()
+	t.positions.PutString(posKey, pos.String())
+	t.metrics.objects.Inc()
+	return nil
+}
+
+func (t *Target) objectReader(key string, body io.Reader) (io.Reader, error) {
+	format := t.config.Format
+	if format == "" {
+		format = formatFromKey(key)
+	}
+	if format == formatNone {
+		return body, nil
+	}
+	return file.MountReader(body, key, t.logger, format)
+}
+
+func formatFromKey(key string) string {
+	key = strings.ToLower(key)
+	for _, format := range []string{"gz", "z", "bz2"} {
+		if strings.HasSuffix(key, "."+format) {
+			return format
+		}
+	}
+	return formatNone
+}
+
+func (t *Target) objectLabels(key string) (model.LabelSet, bool) {
+	lb := labels.NewBuilder(nil)
+	for k, v := range t.config.Labels {
+		lb.Set(string(k), string(v))
+	}
+	lb.Set("__s3_bucket", t.config.Bucket)
+	lb.Set("__s3_object_key", key)
+
+	processed, keep := relabel.Process(lb.Labels(), t.relabelConfig...)
+	if !keep {
+		return nil, false
+	}
+
+	filtered := make(model.LabelSet)
+	for _, lbl := range processed {
+		if strings.HasPrefix(lbl.Name, "__") {
+			continue
+		}
+		filtered[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
+	}
+	return filtered, true
+}
+
+func (t *Target) positionKey(key string) string {
+	return positions.CursorKey("s3://" + t.config.Bucket + "/" + key)
+}
+
+// listPositionKey is the key of the last object read in order when polling
+// the bucket.
+func (t *Target) listPositionKey() string {
+	return positions.CursorKey("s3-list://" + t.config.Bucket + "/" + t.config.Prefix)
+}
+
+// objectPosition is the position of an object, recorded as `<etag>:<lines>`
+// while the object is read and `<etag>:done:<unix time>` once entirely read.
+type objectPosition struct {
+	etag   string
+	lines  int64
+	done   bool
+	doneAt time.Time
+}
+
+func parsePosition(s string) objectPosition {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 {
+		return objectPosition{}
+	}
+	pos := objectPosition{etag: parts[0]}
+	if parts[1] == "done" {
+		pos.done = true
+		if len(parts) == 3 {
+			sec, _ := strconv.ParseInt(parts[2], 10, 64)
+			pos.doneAt = time.Unix(sec, 0)
+		}
+		return pos
+	}
+	pos.lines, _ = strconv.ParseInt(parts[1], 10, 64)
+	return pos
+}
+
+func (p objectPosition) String() string {
+	if p.done {
+		return p.etag + ":done:" + strconv.FormatInt(p.doneAt.Unix(), 10)
+	}
+	return p.etag + ":" + strconv.FormatInt(p.lines, 10)
+}
+
+// Type returns S3TargetType.
+func (t *Target) Type() target.TargetType {
+	return target.S3TargetType
+}
+
+// Ready indicates whether or not the S3 target is running.
+func (t *Target) Ready() bool {
+	return t.running.Load()
+}
+
+// DiscoveredLabels returns the set of labels discovered by the S3 target, which
+// is always nil. Implements Target.
+func (t *Target) DiscoveredLabels() model.LabelSet {
+	return nil
+}
+
+// Labels returns the set of labels that statically apply to all log entries
+// produced by the S3 target.
+func (t *Target) Labels() model.LabelSet {
+	return t.config.Labels
+}
+
+// Details returns target-specific details.
+func (t *Target) Details() interface{} {
+	return map[string]string{
+		"bucket":        t.config.Bucket,
+		"prefix":        t.config.Prefix,
+		"sqs_queue_url": t.config.SQSQueueURL,
+	}
+}
+
+// Stop shuts down the S3 target.
+func (t *Target) Stop() {
+	t.cancel()
+	t.wg.Wait()
+	t.handler.Stop()
+}
@@ -0,0 +1,380 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/clients/pkg/promtail/client/fake"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/positions"
+	"github.com/grafana/loki/v3/clients/pkg/promtail/scrapeconfig"
+)
+
+// fsBucketServer is a S3 compatible server serving the objects of a bucket
+// from a directory, it only supports ListObjectsV2 and GetObject.
+type fsBucketServer struct {
+	dir    string
+	bucket string
+}
+
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Name        string   `xml:"Name"`
+	Prefix      string   `xml:"Prefix"`
+	KeyCount    int      `xml:"KeyCount"`
+	IsTruncated bool     `xml:"IsTruncated"`
+	Contents    []struct {
+		Key  string `xml:"Key"`
+		ETag string `xml:"ETag"`
+		Size int    `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+func (s *fsBucketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/"+s.bucket)
+	if r.Method != http.MethodGet || (path != "" && !strings.HasPrefix(path, "/")) {
+		http.Error(w, "unsupported request", http.StatusBadRequest)
+		return
+	}
+
+	if key := strings.TrimPrefix(path, "/"); key != "" {
+		content, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(key)))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		etag := etagOf(content)
+		if match := r.Header.Get("If-Match"); match != "" && match != etag {
+			http.Error(w, "precondition failed", http.StatusPreconditionFailed)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write(content)
+		return
+	}
+
+	result := listBucketResult{Name: s.bucket, Prefix: r.URL.Query().Get("prefix")}
+	err := filepath.Walk(s.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		key, _ := filepath.Rel(s.dir, path)
+		key = filepath.ToSlash(key)
+		if !strings.HasPrefix(key, result.Prefix) || key <= r.URL.Query().Get("start-after") {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		result.Contents = append(result.Contents, struct {
+			Key  string `xml:"Key"`
+			ETag string `xml:"ETag"`
+			Size int    `xml:"Size"`
+		}{Key: key, ETag: etagOf(content), Size: len(content)})
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(result)
+}
+
+func etagOf(content []byte) string {
+	sum := md5.Sum(content)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeObject(t *testing.T, dir, key string, lines []string, compress bool) {
+	t.Helper()
+	content := []byte(strings.Join(lines, "\n") + "\n")
+	if compress {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		_, err := gz.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		content = buf.Bytes()
+	}
+	path := filepath.Join(dir, filepath.FromSlash(key))
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, content, 0o644))
+}
+
+func newTestPositions(t *testing.T) positions.Positions {
+	t.Helper()
+	pos, err := positions.New(log.NewNopLogger(), positions.Config{
+		SyncPeriod:    10 * time.Second,
+		PositionsFile: filepath.Join(t.TempDir(), "positions.yaml"),
+	})
+	require.NoError(t, err)
+	t.Cleanup(pos.Stop)
+	return pos
+}
+
+func newTestConfig(endpoint string) *scrapeconfig.S3TargetConfig {
+	return &scrapeconfig.S3TargetConfig{
+		Bucket:           "logs",
+		Prefix:           "lb/",
+		Endpoint:         endpoint,
+		AccessKeyID:      "access",
+		SecretAccessKey:  flagext.SecretWithValue("secret"),
+		S3ForcePathStyle: true,
+		PollInterval:     50 * time.Millisecond,
+		Labels:           model.LabelSet{"job": "s3"},
+	}
+}
+
+var testRelabelConfigs = []*relabel.Config{
+	{
+		SourceLabels: model.LabelNames{"__s3_object_key"},
+		TargetLabel:  "key",
+		Replacement:  "$1",
+		Action:       relabel.Replace,
+		Regex:        relabel.MustNewRegexp("(.*)"),
+	},
+	{
+		SourceLabels: model.LabelNames{"__s3_object_key"},
+		Action:       relabel.Drop,
+		Regex:        relabel.MustNewRegexp(".*\\.skip"),
+	},
+}
+
+func receivedLines(client *fake.Client) map[string][]string {
+	lines := map[string][]string{}
+	for _, entry := range client.Received() {
+		key := string(entry.Labels["key"])
+		lines[key] = append(lines[key], entry.Line)
+	}
+	return lines
+}
+
+func TestTargetPolling(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(&fsBucketServer{dir: dir, bucket: "logs"})
+	defer server.Close()
+
+	writeObject(t, dir, "lb/2024/01/a.log.gz", []string{"a1", "a2", "a3"}, true)
+	writeObject(t, dir, "lb/2024/01/b.log", []string{"b1", "b2"}, false)
+	writeObject(t, dir, "lb/2024/01/c.skip", []string{"c1"}, false)
+	writeObject(t, dir, "cdn/d.log", []string{"d1"}, false)
+
+	pos := newTestPositions(t)
+	client := fake.New(func() {})
+	tm, err := NewTargetManager(NewMetrics(nil), log.NewNopLogger(), pos, client, []scrapeconfig.Config{
+		{
+			JobName:        "s3",
+			S3Config:       newTestConfig(server.URL),
+			RelabelConfigs: testRelabelConfigs,
+		},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 5
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, map[string][]string{
+		"lb/2024/01/a.log.gz": {"a1", "a2", "a3"},
+		"lb/2024/01/b.log":    {"b1", "b2"},
+	}, receivedLines(client))
+	require.Equal(t, model.LabelValue("s3"), client.Received()[0].Labels["job"])
+	require.True(t, tm.Ready())
+	tm.Stop()
+
+	// The listing resumes after the last object read, the positions of the
+	// objects before it are removed.
+	listKey := positions.CursorKey("s3-list://logs/lb/")
+	aKey := positions.CursorKey("s3://logs/lb/2024/01/a.log.gz")
+	require.Equal(t, "lb/2024/01/c.skip", pos.GetString(listKey))
+	require.Equal(t, "", pos.GetString(aKey))
+
+	// A new target resumes from the positions: it reads the remaining lines
+	// of the partially read objects and the objects after them.
+	content, err := os.ReadFile(filepath.Join(dir, "lb", "2024", "01", "a.log.gz"))
+	require.NoError(t, err)
+	pos.PutString(listKey, "")
+	pos.PutString(aKey, strings.Trim(etagOf(content), `"`)+":1")
+	writeObject(t, dir, "lb/2024/01/b.log", []string{"b1", "b2", "b3"}, false)
+	writeObject(t, dir, "lb/2024/02/e.log", []string{"e1"}, false)
+
+	client = fake.New(func() {})
+	tm, err = NewTargetManager(NewMetrics(nil), log.NewNopLogger(), pos, client, []scrapeconfig.Config{
+		{
+			JobName:        "s3",
+			S3Config:       newTestConfig(server.URL),
+			RelabelConfigs: testRelabelConfigs,
+		},
+	})
+	require.NoError(t, err)
+	defer tm.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(client.Received()) == 6
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, map[string][]string{
+		"lb/2024/01/a.log.gz": {"a2", "a3"},
+		"lb/2024/01/b.log":    {"b1", "b2", "b3"},
+		"lb/2024/02/e.log":    {"e1"},
+	}, receivedLines(client))
+
+	// Only the objects after the last one read are listed.
+	writeObject(t, dir, "lb/2024/01/f.log", []string{"f1"}, false)
+	writeObject(t, dir, "lb/2024/03/g.log", []string{"g1"}, false)
+	require.Eventually(t, func() bool {
+		return pos.GetString(listKey) == "lb/2024/03/g.log"
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, []string{"g1"}, receivedLines(client)["lb/2024/03/g.log"])
+	require.Len(t, client.Received(), 7)
+	require.Empty(t, pos.Keys(positions.CursorKey("s3://logs/")))
+}
+
+type fakeSQS struct {
+	sqsiface.SQSAPI
+
+	mtx      sync.Mutex
+	messages []*sqs.Message
+	deleted  []string
+}
+
+func (f *fakeSQS) ReceiveMessageWithContext(ctx context.Context, _ *sqs.ReceiveMessageInput, _ ...request.Option) (*sqs.ReceiveMessageOutput, error) {
+	f.mtx.Lock()
+	messages := f.messages
+	f.messages = nil
+	f.mtx.Unlock()
+	if len(messages) == 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return &sqs.ReceiveMessageOutput{Messages: messages}, nil
+}
+
+func (f *fakeSQS) GetQueueAttributesWithContext(_ context.Context, _ *sqs.GetQueueAttributesInput, _ ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{Attributes: map[string]*string{
+		sqs.QueueAttributeNameMessageRetentionPeriod: aws.String("3600"),
+	}}, nil
+}
+
+func (f *fakeSQS) DeleteMessageWithContext(_ context.Context, input *sqs.DeleteMessageInput, _ ...request.Option) (*sqs.DeleteMessageOutput, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.deleted = append(f.deleted, aws.StringValue(input.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (f *fakeSQS) Deleted() []string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]string(nil), f.deleted...)
+}
+
+func notification(bucket, key, etag string) string {
+	return fmt.Sprintf(`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":%q},"object":{"key":%q,"eTag":%q}}}]}`, bucket, key, etag)
+}
+
+func TestTargetNotifications(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(&fsBucketServer{dir: dir, bucket: "logs"})
+	defer server.Close()
+
+	writeObject(t, dir, "lb/my file.log", []string{"a1", "a2"}, false)
+	writeObject(t, dir, "lb/b.log", []string{"b1"}, false)
+	writeObject(t, dir, "lb/missing.log", []string{"m1"}, false)
+	content, err := os.ReadFile(filepath.Join(dir, "lb", "my file.log"))
+	require.NoError(t, err)
+
+	queue := &fakeSQS{messages: []*sqs.Message{
+		{ReceiptHandle: aws.String("test"), Body: aws.String(`{"Service":"Amazon S3","Event":"s3:TestEvent"}`)},
+		{ReceiptHandle: aws.String("a"), Body: aws.String(notification("logs", "lb/my+file.log", strings.Trim(etagOf(content), `"`)))},
+		// Wrapped in a SNS notification.
+		{ReceiptHandle: aws.String("b"), Body: aws.String(fmt.Sprintf(`{"Type":"Notification","Message":%q}`, notification("logs", "lb/b.log", "")))},
+		{ReceiptHandle: aws.String("other"), Body: aws.String(notification("other", "lb/b.log", ""))},
+		// The object has been overwritten since the notification.
+		{ReceiptHandle: aws.String("missing"), Body: aws.String(notification("logs", "lb/missing.log", "0123"))},
+	}}
+
+	cfg := newTestConfig(server.URL)
+	require.NoError(t, validateConfig(cfg))
+	s3Client, _, err := newClients(cfg)
+	require.NoError(t, err)
+
+	pos := newTestPositions(t)
+	client := fake.New(func() {})
+	target := newTarget(NewMetrics(nil), log.NewNopLogger(), client, pos, testRelabelConfigs, cfg, s3Client, queue)
+	defer target.Stop()
+
+	require.Eventually(t, func() bool {
+		return len(queue.Deleted()) == 4
+	}, 5*time.Second, 20*time.Millisecond)
+	require.Equal(t, []string{"test", "a", "b", "other"}, queue.Deleted())
+	require.Equal(t, map[string][]string{
+		"lb/my file.log": {"a1", "a2"},
+		"lb/b.log":       {"b1"},
+	}, receivedLines(client))
+
+	// The positions of the objects are kept until their notifications can't
+	// be received again.
+	bKey := positions.CursorKey("s3://logs/lb/b.log")
+	require.Equal(t, time.Hour, target.retention)
+	require.True(t, parsePosition(pos.GetString(bKey)).done)
+	target.expireDone(time.Now().Add(30 * time.Minute))
+	require.True(t, parsePosition(pos.GetString(bKey)).done)
+	target.expireDone(time.Now().Add(2 * time.Hour))
+	require.Equal(t, "", pos.GetString(bKey))
+}
+
+func TestPosition(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected objectPosition
+	}{
+		{in: "", expected: objectPosition{}},
+		{in: "abc:12", expected: objectPosition{etag: "abc", lines: 12}},
+		{in: "abc-2:done:1700000000", expected: objectPosition{etag: "abc-2", done: true, doneAt: time.Unix(1700000000, 0)}},
+		{in: ":0", expected: objectPosition{}},
+	} {
+		pos := parsePosition(tc.in)
+		require.Equal(t, tc.expected, pos)
+		if tc.in != "" {
+			require.Equal(t, tc.in, pos.String())
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	require.Error(t, validateConfig(&scrapeconfig.S3TargetConfig{}))
+	require.Error(t, validateConfig(&scrapeconfig.S3TargetConfig{Bucket: "logs", Format: "zip"}))
+
+	cfg := &scrapeconfig.S3TargetConfig{Bucket: "logs"}
+	require.NoError(t, validateConfig(cfg))
+	require.Equal(t, defaultPollInterval, cfg.PollInterval)
+}
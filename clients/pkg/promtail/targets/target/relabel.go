@@ -0,0 +1,31 @@
+package target
+
+import (
+	"strings"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/relabel"
+
+	"github.com/grafana/loki/v3/pkg/util"
+)
+
+// Relabel applies the relabel configs to the labels and removes the internal
+// labels, prefixed with `__`. It returns false when the labels are dropped by
+// the relabel configs.
+func Relabel(lbs labels.Labels, cfg []*relabel.Config) (model.LabelSet, bool) {
+	if len(lbs) == 0 {
+		return nil, true
+	}
+	processed, keep := relabel.Process(lbs, cfg...)
+	if !keep {
+		return nil, false
+	}
+	labelOut := model.LabelSet(util.LabelsToMetric(processed))
+	for k := range labelOut {
+		if strings.HasPrefix(string(k), "__") {
+			delete(labelOut, k)
+		}
+	}
+	return labelOut, true
+}
@@ -36,6 +36,21 @@ const (
 	// GelfTargetType is a gelf target
 	GelfTargetType = TargetType("gelf")
 
+	// FluentForwardTargetType is a Fluent Forward protocol target
+	FluentForwardTargetType = TargetType("FluentForward")
+
+	// S3TargetType is a S3 bucket target
+	S3TargetType = TargetType("S3")
+
+	// MQTTTargetType is a MQTT target
+	MQTTTargetType = TargetType("MQTT")
+
+	// NATSTargetType is a NATS JetStream target
+	NATSTargetType = TargetType("NATS")
+
+	// KubernetesEventsTargetType is a Kubernetes events target
+	KubernetesEventsTargetType = TargetType("KubernetesEvents")
+
 	// CloudflareTargetType is a Cloudflare target
 	CloudflareTargetType = TargetType("Cloudflare")
 
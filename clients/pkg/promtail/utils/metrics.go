@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MustRegisterOrGet registers the collector, returning the collector already
+// registered in its place if any.
+func MustRegisterOrGet(reg prometheus.Registerer, c prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(c); err != nil {
+		if are, ok := err.(prometheus.AlreadyRegisteredError); ok {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return c
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logcli/detected"
 	"github.com/grafana/loki/v3/pkg/logcli/index"
 	"github.com/grafana/loki/v3/pkg/logcli/labelquery"
+	"github.com/grafana/loki/v3/pkg/logcli/lint"
 	"github.com/grafana/loki/v3/pkg/logcli/output"
 	"github.com/grafana/loki/v3/pkg/logcli/query"
 	"github.com/grafana/loki/v3/pkg/logcli/seriesquery"
@@ -185,6 +186,23 @@ This is helpful to find high cardinality labels.
 
 	fmtCmd = app.Command("fmt", "Formats a LogQL query.")
 
+	lintCmd = app.Command("lint", `Lint a LogQL query.
+
+The "lint" command analyzes the provided query and reports anti-patterns
+such as regular expressions that match a literal string, line filters
+applied after a parser, high cardinality grouping labels and stream
+selectors that don't narrow the selected streams.
+
+When the Loki instance serving the request runs a querier, the output
+also contains an estimate of the data the query would scan, computed
+from the index over the requested time range. By default we look over
+the last hour of data; use --since to modify or provide specific start
+and end times with --from and --to respectively.
+
+Use --output=raw to get the findings as JSON.
+`)
+	lintQuery = newLintQuery(lintCmd)
+
 	statsCmd = app.Command("stats", `Run a stats query.
 
 The "stats" command will take the provided query and return statistics
@@ -398,6 +416,8 @@ func main() {
 		if err := formatLogQL(os.Stdin, os.Stdout); err != nil {
 			log.Fatalf("unable to format logql: %s", err)
 		}
+	case lintCmd.FullCommand():
+		lintQuery.Do(queryClient, *outputMode, os.Stdout)
 	case statsCmd.FullCommand():
 		statsQuery.DoStats(queryClient)
 	case volumeCmd.FullCommand(), volumeRangeCmd.FullCommand():
@@ -476,6 +496,7 @@ func newQueryClient(app *kingpin.Application) client.Client {
 	app.Flag("org-id", "adds X-Scope-OrgID to API requests for representing tenant ID. Useful for requesting tenant data when bypassing an auth gateway. Can also be set using LOKI_ORG_ID env var.").Default("").Envar("LOKI_ORG_ID").StringVar(&client.OrgID)
 	app.Flag("query-tags", "adds X-Query-Tags http header to API requests. This header value will be part of `metrics.go` statistics. Useful for tracking the query. Can also be set using LOKI_QUERY_TAGS env var.").Default("").Envar("LOKI_QUERY_TAGS").StringVar(&client.QueryTags)
 	app.Flag("nocache", "adds Cache-Control: no-cache http header to API requests. Can also be set using LOKI_NO_CACHE env var.").Default("false").Envar("LOKI_NO_CACHE").BoolVar(&client.NoCache)
+	app.Flag("explain-analyze", "requests the distributed execution plan of queries and prints it to stderr. Can also be set using LOKI_EXPLAIN_ANALYZE env var.").Default("false").Envar("LOKI_EXPLAIN_ANALYZE").BoolVar(&client.ExplainAnalyze)
 	app.Flag("bearer-token", "adds the Authorization header to API requests for authentication purposes. Can also be set using LOKI_BEARER_TOKEN env var.").Default("").Envar("LOKI_BEARER_TOKEN").StringVar(&client.BearerToken)
 	app.Flag("bearer-token-file", "adds the Authorization header to API requests for authentication purposes. Can also be set using LOKI_BEARER_TOKEN_FILE env var.").Default("").Envar("LOKI_BEARER_TOKEN_FILE").StringVar(&client.BearerTokenFile)
 	app.Flag("retries", "How many times to retry each query when getting an error response from Loki. Can also be set using LOKI_CLIENT_RETRIES env var.").Default("0").Envar("LOKI_CLIENT_RETRIES").IntVar(&client.Retries)
@@ -654,6 +675,34 @@ func newStatsQuery(cmd *kingpin.CmdClause) *index.StatsQuery {
 	return q
 }
 
+func newLintQuery(cmd *kingpin.CmdClause) *lint.Query {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &lint.Query{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+
+		q.Quiet = *quiet
+
+		return nil
+	})
+
+	cmd.Arg("query", "eg '{foo=\"bar\",baz=~\".*blip\"} |~ \".*error.*\"'").Required().StringVar(&q.QueryString)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+
+	return q
+}
+
 func newVolumeQuery(rangeQuery bool, cmd *kingpin.CmdClause) *volume.Query {
 	// calculate query range from cli params
 	var from, to string
@@ -14,12 +14,14 @@ import (
 var (
 	ruleCommand  commands.RuleCommand
 	auditCommand commands.AuditCommand
+	macroCommand commands.MacroCommand
 )
 
 func main() {
 	app := kingpin.New("lokitool", "A command-line tool to manage Loki.")
 	ruleCommand.Register(app)
 	auditCommand.Register(app)
+	macroCommand.Register(app)
 
 	app.Command("version", "Get the version of the lokitool CLI").Action(func(_ *kingpin.ParseContext) error {
 		fmt.Println(version.Print("loki"))
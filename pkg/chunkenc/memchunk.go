@@ -1291,26 +1291,32 @@ func (hb *headBlock) SampleIterator(ctx context.Context, mint, maxt int64, extra
 			continue
 		}
 		stats.AddPostFilterLines(1)
-		var (
-			found bool
-			s     *logproto.Series
-		)
 
-		lbs := parsedLabels.String()
-		if s, found = series[lbs]; !found {
-			s = &logproto.Series{
-				Labels:     lbs,
-				Samples:    SamplesPool.Get(len(hb.entries)).([]logproto.Sample)[:0],
-				StreamHash: baseHash,
+		// the line is extracted into multiple samples when the pipeline explodes it.
+		hash := xxhash.Sum64(unsafeGetBytes(e.s))
+		for ; ok; value, parsedLabels, ok = extractor.NextSample() {
+			var (
+				found bool
+				s     *logproto.Series
+			)
+
+			lbs := parsedLabels.String()
+			if s, found = series[lbs]; !found {
+				s = &logproto.Series{
+					Labels:     lbs,
+					Samples:    SamplesPool.Get(len(hb.entries)).([]logproto.Sample)[:0],
+					StreamHash: baseHash,
+				}
+				series[lbs] = s
 			}
-			series[lbs] = s
-		}
 
-		s.Samples = append(s.Samples, logproto.Sample{
-			Timestamp: e.t,
-			Value:     value,
-			Hash:      xxhash.Sum64(unsafeGetBytes(e.s)),
-		})
+			s.Samples = append(s.Samples, logproto.Sample{
+				Timestamp: e.t,
+				Value:     value,
+				Hash:      hash,
+			})
+			hash++
+		}
 	}
 
 	if extractor.ReferencedStructuredMetadata() {
@@ -1688,9 +1694,26 @@ type sampleBufferedIterator struct {
 
 	cur        logproto.Sample
 	currLabels log.LabelsResult
+
+	// exploded holds the remaining samples of the current line when the pipeline explodes it.
+	// They are read right away since the extractor is shared with the other iterators of the stream.
+	exploded []explodedSample
+}
+
+type explodedSample struct {
+	value  float64
+	labels log.LabelsResult
 }
 
 func (e *sampleBufferedIterator) Next() bool {
+	if len(e.exploded) > 0 {
+		e.currLabels = e.exploded[0].labels
+		e.cur.Value = e.exploded[0].value
+		e.cur.Hash++
+		e.exploded = e.exploded[1:]
+		return true
+	}
+
 	for e.bufferedIterator.Next() {
 		val, labels, ok := e.extractor.Process(e.currTs, e.currLine, e.currStructuredMetadata...)
 		if !ok {
@@ -1701,6 +1724,9 @@ func (e *sampleBufferedIterator) Next() bool {
 		e.cur.Value = val
 		e.cur.Hash = xxhash.Sum64(e.currLine)
 		e.cur.Timestamp = e.currTs
+		for val, labels, ok = e.extractor.NextSample(); ok; val, labels, ok = e.extractor.NextSample() {
+			e.exploded = append(e.exploded, explodedSample{value: val, labels: labels})
+		}
 		return true
 	}
 	return false
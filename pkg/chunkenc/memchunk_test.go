@@ -1164,6 +1164,49 @@ func TestMemchunkLongLine(t *testing.T) {
 	}
 }
 
+func TestMemChunk_SampleIteratorExplode(t *testing.T) {
+	explode, err := log.NewExplodeStage("items")
+	require.NoError(t, err)
+	ex, err := log.LabelExtractorWithStages("price", log.ConvertFloat, []string{"sku"}, false, false, []log.Stage{explode}, log.NoopStage)
+	require.NoError(t, err)
+
+	for _, f := range allPossibleFormats {
+		t.Run(fmt.Sprintf("%v-%v", f.headBlockFmt, f.chunkFormat), func(t *testing.T) {
+			c := NewMemChunk(f.chunkFormat, compression.EncNone, f.headBlockFmt, testBlockSize, testTargetSize)
+			for i, line := range []string{
+				`{"items":[{"sku":"a","price":1},{"sku":"b","price":2},{"sku":"a","price":3}]}`,
+				`{"items":[]}`,
+				`{"items":[{"sku":"b","price":4}]}`,
+			} {
+				dup, err := c.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: line})
+				require.False(t, dup)
+				require.NoError(t, err)
+			}
+
+			assertSamples := func() {
+				t.Helper()
+				it := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), ex.ForStream(labels.Labels{}))
+				sums := map[string]float64{}
+				hashes := map[uint64]struct{}{}
+				for it.Next() {
+					sums[it.Labels()] += it.At().Value
+					hashes[it.At().Hash] = struct{}{}
+				}
+				require.NoError(t, it.Close())
+				require.Equal(t, map[string]float64{`{sku="a"}`: 4, `{sku="b"}`: 6}, sums)
+				// each sample of an exploded line must be kept by the replicas deduplication.
+				require.Len(t, hashes, 4)
+			}
+
+			// head block
+			assertSamples()
+			require.NoError(t, c.cut())
+			// compressed block
+			assertSamples()
+		})
+	}
+}
+
 // Ensure passing a reusable []byte doesn't affect output
 func TestBytesWith(t *testing.T) {
 	t.Parallel()
@@ -327,25 +327,31 @@ func (hb *unorderedHeadBlock) SampleIterator(
 				return nil
 			}
 			statsCtx.AddPostFilterLines(1)
-			var (
-				found bool
-				s     *logproto.Series
-			)
-			lbs := parsedLabels.String()
-			s, found = series[lbs]
-			if !found {
-				s = &logproto.Series{
-					Labels:     lbs,
-					Samples:    SamplesPool.Get(hb.lines).([]logproto.Sample)[:0],
-					StreamHash: baseHash,
+
+			// the line is extracted into multiple samples when the pipeline explodes it.
+			hash := xxhash.Sum64(unsafeGetBytes(line))
+			for ; ok; value, parsedLabels, ok = extractor.NextSample() {
+				var (
+					found bool
+					s     *logproto.Series
+				)
+				lbs := parsedLabels.String()
+				s, found = series[lbs]
+				if !found {
+					s = &logproto.Series{
+						Labels:     lbs,
+						Samples:    SamplesPool.Get(hb.lines).([]logproto.Sample)[:0],
+						StreamHash: baseHash,
+					}
+					series[lbs] = s
 				}
-				series[lbs] = s
+				s.Samples = append(s.Samples, logproto.Sample{
+					Timestamp: ts,
+					Value:     value,
+					Hash:      hash,
+				})
+				hash++
 			}
-			s.Samples = append(s.Samples, logproto.Sample{
-				Timestamp: ts,
-				Value:     value,
-				Hash:      xxhash.Sum64(unsafeGetBytes(line)),
-			})
 			return nil
 		},
 	)
@@ -0,0 +1,123 @@
+package compactor
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+// coldStorageMarkersPrefix is where the tables whose chunks were all moved to cold storage are recorded in the cold storage.
+const coldStorageMarkersPrefix = "cold_storage_moved_tables/"
+
+// coldStorage moves the chunks of the tables which are old enough to the cold storage of their schema period.
+// Chunks ending after the table are moved along with the table they end in.
+type coldStorage struct {
+	cfg          config.ColdStorageConfig
+	chunkClient  *client.TieredClient
+	markerClient client.ObjectClient
+	workerCount  int
+
+	movedChunksTotal prometheus.Counter
+
+	// movedTables caches the tables known to be moved to not look up their marker on every compaction.
+	movedTablesMtx sync.Mutex
+	movedTables    map[string]struct{}
+
+	now func() model.Time
+}
+
+func newColdStorage(cfg config.ColdStorageConfig, chunkClient *client.TieredClient, markerClient client.ObjectClient, workerCount int, r prometheus.Registerer) *coldStorage {
+	return &coldStorage{
+		cfg:          cfg,
+		chunkClient:  chunkClient,
+		markerClient: markerClient,
+		workerCount:  workerCount,
+		movedChunksTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "cold_storage_moved_chunks_total",
+			Help:      "Total number of chunks moved to cold storage",
+		}),
+		movedTables: map[string]struct{}{},
+		now:         model.Now,
+	}
+}
+
+// tableNeedsMove returns true if the table is old enough for cold storage and its chunks were not moved yet.
+func (s *coldStorage) tableNeedsMove(ctx context.Context, tableName string) (bool, error) {
+	interval := retention.ExtractIntervalFromTableName(tableName)
+	if !s.cfg.IsCold(interval.End, s.now()) {
+		return false, nil
+	}
+
+	s.movedTablesMtx.Lock()
+	defer s.movedTablesMtx.Unlock()
+
+	if _, ok := s.movedTables[tableName]; ok {
+		return false, nil
+	}
+
+	moved, err := s.markerClient.ObjectExists(ctx, coldStorageMarkersPrefix+tableName)
+	if err != nil && !s.markerClient.IsObjectNotFoundErr(err) {
+		return false, err
+	}
+	if moved {
+		s.movedTables[tableName] = struct{}{}
+	}
+	return !moved, nil
+}
+
+// moveChunks moves the chunks of the index which are old enough to the cold storage.
+func (s *coldStorage) moveChunks(ctx context.Context, iterator retention.ChunkIterator, logger log.Logger) error {
+	type chunkID struct {
+		userID, chunkID string
+	}
+
+	var chunks []chunkID
+	now := s.now()
+	err := iterator.ForEachChunk(ctx, func(c retention.ChunkEntry) (bool, error) {
+		if s.cfg.IsCold(c.Through, now) {
+			chunks = append(chunks, chunkID{userID: string(c.UserID), chunkID: string(c.ChunkID)})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = concurrency.ForEachJob(ctx, len(chunks), s.workerCount, func(ctx context.Context, idx int) error {
+		moved, err := s.chunkClient.MoveToCold(ctx, chunks[idx].userID, chunks[idx].chunkID)
+		if moved {
+			s.movedChunksTotal.Inc()
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "moved chunks to cold storage", "chunks", len(chunks))
+	return nil
+}
+
+// markTableMoved records that all the chunks of the table were moved to cold storage.
+func (s *coldStorage) markTableMoved(ctx context.Context, tableName string) error {
+	if err := s.markerClient.PutObject(ctx, coldStorageMarkersPrefix+tableName, strings.NewReader("")); err != nil {
+		return err
+	}
+
+	s.movedTablesMtx.Lock()
+	defer s.movedTablesMtx.Unlock()
+	s.movedTables[tableName] = struct{}{}
+	return nil
+}
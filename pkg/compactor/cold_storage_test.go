@@ -0,0 +1,122 @@
+package compactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+type chunkEntries []retention.ChunkEntry
+
+func (c chunkEntries) ForEachChunk(_ context.Context, callback retention.ChunkEntryCallback) error {
+	for _, entry := range c {
+		if _, err := callback(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestColdStorage(t *testing.T) {
+	now := model.Now()
+	periodConfig := config.PeriodConfig{
+		From:   config.DayTime{Time: now.Add(-100 * 24 * time.Hour)},
+		Schema: "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: indexTablePrefix, Period: config.ObjectStorageIndexRequiredPeriod},
+		},
+		ColdStorage: config.ColdStorageConfig{ObjectType: "cold", After: model.Duration(30 * 24 * time.Hour)},
+	}
+	schemaConfig := config.SchemaConfig{Configs: []config.PeriodConfig{periodConfig}}
+
+	hotObjectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	coldObjectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	hot := newChunkClient(hotObjectClient, schemaConfig)
+	tieredClient := client.NewTieredClient(hot, newChunkClient(coldObjectClient, schemaConfig), periodConfig.ColdStorage, nil)
+	s := newColdStorage(periodConfig.ColdStorage, tieredClient, coldObjectClient, 2, prometheus.NewRegistry())
+	s.now = func() model.Time { return now }
+
+	lbs := labels.FromStrings("app", "foo")
+	newChunk := func(through model.Time) chunk.Chunk {
+		chunkEnc := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 0)
+		_, err := chunkEnc.Append(&logproto.Entry{Timestamp: through.Time(), Line: "foo"})
+		require.NoError(t, err)
+		require.NoError(t, chunkEnc.Close())
+
+		c := chunk.NewChunk("user", model.Fingerprint(labels.StableHash(lbs)), lbs, chunkenc.NewFacade(chunkEnc, 0, 0), through.Add(-time.Hour), through)
+		require.NoError(t, c.Encode())
+		require.NoError(t, hot.PutChunks(context.Background(), []chunk.Chunk{c}))
+		return c
+	}
+
+	oldTable := periodConfig.IndexTables.TableFor(now.Add(-40 * 24 * time.Hour))
+	oldTableEnd := retention.ExtractIntervalFromTableName(oldTable).End
+	oldChunk := newChunk(oldTableEnd)
+	// a chunk of the same table which ends in a later table not old enough yet.
+	spanningChunk := newChunk(now.Add(-time.Hour))
+
+	var entries chunkEntries
+	for _, c := range []chunk.Chunk{oldChunk, spanningChunk} {
+		entries = append(entries, retention.ChunkEntry{ChunkRef: retention.ChunkRef{
+			UserID:  []byte(c.UserID),
+			ChunkID: []byte(schemaConfig.ExternalKey(c.ChunkRef)),
+			From:    c.From,
+			Through: c.Through,
+		}})
+	}
+
+	ctx := context.Background()
+	needsMove, err := s.tableNeedsMove(ctx, periodConfig.IndexTables.TableFor(now))
+	require.NoError(t, err)
+	require.False(t, needsMove)
+
+	needsMove, err = s.tableNeedsMove(ctx, oldTable)
+	require.NoError(t, err)
+	require.True(t, needsMove)
+
+	require.NoError(t, s.moveChunks(ctx, entries, util_log.Logger))
+	require.NoError(t, s.markTableMoved(ctx, oldTable))
+
+	objectExists := func(objectClient client.ObjectClient, c chunk.Chunk) bool {
+		exists, err := objectClient.ObjectExists(ctx, client.FSEncoder(schemaConfig, c))
+		if objectClient.IsObjectNotFoundErr(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return exists
+	}
+	require.False(t, objectExists(hotObjectClient, oldChunk))
+	require.True(t, objectExists(coldObjectClient, oldChunk))
+	require.True(t, objectExists(hotObjectClient, spanningChunk))
+	require.False(t, objectExists(coldObjectClient, spanningChunk))
+
+	// both chunks remain readable through the tiered client.
+	chks, err := tieredClient.GetChunks(ctx, []chunk.Chunk{{ChunkRef: oldChunk.ChunkRef}, {ChunkRef: spanningChunk.ChunkRef}})
+	require.NoError(t, err)
+	require.Len(t, chks, 2)
+
+	// the moved table is remembered across restarts.
+	s = newColdStorage(periodConfig.ColdStorage, tieredClient, coldObjectClient, 2, prometheus.NewRegistry())
+	s.now = func() model.Time { return now }
+	needsMove, err = s.tableNeedsMove(ctx, oldTable)
+	require.NoError(t, err)
+	require.False(t, needsMove)
+}
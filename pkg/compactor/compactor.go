@@ -15,6 +15,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/kv"
@@ -78,6 +79,7 @@ type Config struct {
 	RetentionEnabled            bool                `yaml:"retention_enabled"`
 	RetentionDeleteDelay        time.Duration       `yaml:"retention_delete_delay"`
 	RetentionDeleteWorkCount    int                 `yaml:"retention_delete_worker_count"`
+	ColdStorageMoveWorkCount    int                 `yaml:"cold_storage_move_worker_count"`
 	RetentionTableTimeout       time.Duration       `yaml:"retention_table_timeout"`
 	RetentionBackoffConfig      backoff.Config      `yaml:"retention_backoff_config"`
 	DeleteRequestStore          string              `yaml:"delete_request_store"`
@@ -101,6 +103,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.RetentionDeleteDelay, "compactor.retention-delete-delay", 2*time.Hour, "Delay after which chunks will be fully deleted during retention.")
 	f.BoolVar(&cfg.RetentionEnabled, "compactor.retention-enabled", false, "Activate custom (per-stream,per-tenant) retention.")
 	f.IntVar(&cfg.RetentionDeleteWorkCount, "compactor.retention-delete-worker-count", 150, "The total amount of worker to use to delete chunks.")
+	f.IntVar(&cfg.ColdStorageMoveWorkCount, "compactor.cold-storage-move-worker-count", 50, "The amount of workers per table to use to move chunks to the cold storage of their schema period.")
 	f.StringVar(&cfg.DeleteRequestStore, "compactor.delete-request-store", "", "Store used for managing delete requests.")
 	f.StringVar(&cfg.DeleteRequestStoreKeyPrefix, "compactor.delete-request-store.key-prefix", "index/", "Path prefix for storing delete requests.")
 	f.IntVar(&cfg.DeleteBatchSize, "compactor.delete-batch-size", 70, "The max number of delete requests to run per compaction cycle.")
@@ -171,6 +174,8 @@ type Compactor struct {
 	DeleteRequestsHandler     *deletion.DeleteRequestHandler
 	DeleteRequestsGRPCHandler *deletion.GRPCRequestHandler
 	deleteRequestsManager     *deletion.DeleteRequestsManager
+	legalHoldsStore           deletion.LegalHoldsStore
+	LegalHoldHandler          *deletion.LegalHoldHandler
 	expirationChecker         retention.ExpirationChecker
 	metrics                   *metrics
 	running                   bool
@@ -196,6 +201,7 @@ type storeContainer struct {
 	tableMarker        retention.TableMarker
 	sweeper            *retention.Sweeper
 	indexStorageClient storage.Client
+	coldStorage        *coldStorage
 }
 
 type Limits interface {
@@ -204,7 +210,7 @@ type Limits interface {
 	DefaultLimits() *validation.Limits
 }
 
-func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer, metricsNamespace string) (*Compactor, error) {
+func NewCompactor(cfg Config, objectStoreClients, coldStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer, metricsNamespace string) (*Compactor, error) {
 	retentionEnabledStats.Set("false")
 	if cfg.RetentionEnabled {
 		retentionEnabledStats.Set("true")
@@ -260,7 +266,7 @@ func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.Objec
 	compactor.subservicesWatcher = services.NewFailureWatcher()
 	compactor.subservicesWatcher.WatchManager(compactor.subservices)
 
-	if err := compactor.init(objectStoreClients, deleteStoreClient, schemaConfig, limits, r); err != nil {
+	if err := compactor.init(objectStoreClients, coldStoreClients, deleteStoreClient, schemaConfig, limits, r); err != nil {
 		return nil, fmt.Errorf("init compactor: %w", err)
 	}
 
@@ -268,7 +274,7 @@ func NewCompactor(cfg Config, objectStoreClients map[config.DayTime]client.Objec
 	return compactor, nil
 }
 
-func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer) error {
+func (c *Compactor) init(objectStoreClients, coldStoreClients map[config.DayTime]client.ObjectClient, deleteStoreClient client.ObjectClient, schemaConfig config.SchemaConfig, limits Limits, r prometheus.Registerer) error {
 	err := chunk_util.EnsureDirectory(c.cfg.WorkingDirectory)
 	if err != nil {
 		return err
@@ -295,10 +301,17 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 		var sc storeContainer
 		sc.indexStorageClient = storage.NewIndexStorageClient(objectClient, period.IndexTables.PathPrefix)
 
+		chunkClient := newChunkClient(objectClient, schemaConfig)
+		if coldClient, ok := coldStoreClients[from]; ok {
+			tieredClient := client.NewTieredClient(chunkClient, newChunkClient(coldClient, schemaConfig), period.ColdStorage, nil)
+			r := prometheus.WrapRegistererWith(prometheus.Labels{"from": fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())}, r)
+			sc.coldStorage = newColdStorage(period.ColdStorage, tieredClient, coldClient, c.cfg.ColdStorageMoveWorkCount, r)
+			// retention must find the chunks in both storages.
+			chunkClient = tieredClient
+		}
+
 		if c.cfg.RetentionEnabled {
 			var (
-				raw              client.ObjectClient
-				encoder          client.KeyEncoder
 				name             = fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())
 				retentionWorkDir = filepath.Join(c.cfg.WorkingDirectory, "retention", name)
 				r                = prometheus.WrapRegistererWith(prometheus.Labels{"from": name}, r)
@@ -317,16 +330,6 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 			// remove markers from the store dir after copying them to period specific dirs.
 			legacyMarkerDirs[period.ObjectType] = struct{}{}
 
-			if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
-				raw = casted.GetDownstream()
-			} else {
-				raw = objectClient
-			}
-			if _, ok := raw.(*local.FSObjectClient); ok {
-				encoder = client.FSEncoder
-			}
-			chunkClient := client.NewClient(objectClient, encoder, schemaConfig)
-
 			sc.sweeper, err = retention.NewSweeper(retentionWorkDir, chunkClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, c.cfg.RetentionBackoffConfig, r)
 			if err != nil {
 				return fmt.Errorf("failed to init sweeper: %w", err)
@@ -358,14 +361,37 @@ func (c *Compactor) init(objectStoreClients map[config.DayTime]client.ObjectClie
 	return nil
 }
 
+func newChunkClient(objectClient client.ObjectClient, schemaConfig config.SchemaConfig) client.Client {
+	var (
+		raw     client.ObjectClient
+		encoder client.KeyEncoder
+	)
+	if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
+		raw = casted.GetDownstream()
+	} else {
+		raw = objectClient
+	}
+	if _, ok := raw.(*local.FSObjectClient); ok {
+		encoder = client.FSEncoder
+	}
+	return client.NewClient(objectClient, encoder, schemaConfig)
+}
+
 func (c *Compactor) initDeletes(objectClient client.ObjectClient, r prometheus.Registerer, limits Limits) error {
 	deletionWorkDir := filepath.Join(c.cfg.WorkingDirectory, "deletion")
-	store, err := deletion.NewDeleteStore(deletionWorkDir, storage.NewIndexStorageClient(objectClient, c.cfg.DeleteRequestStoreKeyPrefix))
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, c.cfg.DeleteRequestStoreKeyPrefix)
+	store, err := deletion.NewDeleteStore(deletionWorkDir, indexStorageClient)
 	if err != nil {
 		return err
 	}
 	c.deleteRequestsStore = store
 
+	c.legalHoldsStore, err = deletion.NewLegalHoldsStore(deletionWorkDir, indexStorageClient)
+	if err != nil {
+		return err
+	}
+	c.LegalHoldHandler = deletion.NewLegalHoldHandler(c.legalHoldsStore)
+
 	c.DeleteRequestsHandler = deletion.NewDeleteRequestHandler(
 		c.deleteRequestsStore,
 		c.cfg.DeleteMaxInterval,
@@ -382,7 +408,7 @@ func (c *Compactor) initDeletes(objectClient client.ObjectClient, r prometheus.R
 		r,
 	)
 
-	c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits), c.deleteRequestsManager)
+	c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits), c.deleteRequestsManager, deletion.NewLegalHoldsManager(c.legalHoldsStore, r))
 	return nil
 }
 
@@ -457,6 +483,9 @@ func (c *Compactor) loop(ctx context.Context) error {
 		if c.deleteRequestsManager != nil {
 			defer c.deleteRequestsManager.Stop()
 		}
+		if c.legalHoldsStore != nil {
+			defer c.legalHoldsStore.Stop()
+		}
 	}
 
 	syncTicker := time.NewTicker(c.ringPollPeriod)
@@ -645,7 +674,7 @@ func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRet
 	defer c.tableLocker.unlockTable(tableName)
 
 	table, err := newTable(ctx, filepath.Join(c.cfg.WorkingDirectory, tableName), sc.indexStorageClient, indexCompactor,
-		schemaCfg, sc.tableMarker, c.expirationChecker, c.cfg.UploadParallelism)
+		schemaCfg, sc.tableMarker, c.expirationChecker, sc.coldStorage, c.cfg.UploadParallelism)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "failed to initialize table for compaction", "table", tableName, "err", err)
 		return err
@@ -781,8 +810,8 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err
 
 	go func() {
 		for _, tableName := range tables {
-			if tableName == deletion.DeleteRequestsTableName {
-				// we do not want to compact or apply retention on delete requests table
+			if tableName == deletion.DeleteRequestsTableName || tableName == deletion.LegalHoldsTableName {
+				// we do not want to compact or apply retention on delete requests and legal holds tables
 				continue
 			}
 
@@ -815,21 +844,46 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err
 type expirationChecker struct {
 	retentionExpiryChecker retention.ExpirationChecker
 	deletionExpiryChecker  retention.ExpirationChecker
+	legalHolds             *deletion.LegalHoldsManager
 }
 
-func newExpirationChecker(retentionExpiryChecker, deletionExpiryChecker retention.ExpirationChecker) retention.ExpirationChecker {
-	return &expirationChecker{retentionExpiryChecker, deletionExpiryChecker}
+func newExpirationChecker(retentionExpiryChecker, deletionExpiryChecker retention.ExpirationChecker, legalHolds *deletion.LegalHoldsManager) retention.ExpirationChecker {
+	return &expirationChecker{retentionExpiryChecker, deletionExpiryChecker, legalHolds}
 }
 
 func (e *expirationChecker) Expired(ref retention.ChunkEntry, now model.Time) (bool, filter.Func) {
-	if expired, nonDeletedIntervals := e.retentionExpiryChecker.Expired(ref, now); expired {
-		return expired, nonDeletedIntervals
+	expired, filterFunc := e.expired(ref, now)
+	// chunks under a legal hold are preserved from both retention and deletion.
+	if expired && e.legalHolds != nil && e.legalHolds.Holds(ref) {
+		return false, nil
+	}
+	return expired, filterFunc
+}
+
+func (e *expirationChecker) expired(ref retention.ChunkEntry, now model.Time) (bool, filter.Func) {
+	expired, retentionFilter := e.retentionExpiryChecker.Expired(ref, now)
+	if expired && retentionFilter == nil {
+		return true, nil
+	}
+
+	// retention only deletes some lines of the chunk, so the delete requests still have to be applied to it.
+	deleted, deletionFilter := e.deletionExpiryChecker.Expired(ref, now)
+	switch {
+	case !deleted:
+		return expired, retentionFilter
+	case !expired || deletionFilter == nil:
+		return deleted, deletionFilter
 	}
 
-	return e.deletionExpiryChecker.Expired(ref, now)
+	return true, func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		return retentionFilter(ts, s, structuredMetadata...) || deletionFilter(ts, s, structuredMetadata...)
+	}
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
+	if e.legalHolds != nil {
+		e.legalHolds.LoadLegalHolds(context.Background())
+	}
 	e.retentionExpiryChecker.MarkPhaseStarted()
 	e.deletionExpiryChecker.MarkPhaseStarted()
 }
@@ -854,7 +908,10 @@ func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval
 }
 
 func (e *expirationChecker) DropFromIndex(ref retention.ChunkEntry, tableEndTime model.Time, now model.Time) bool {
-	return e.retentionExpiryChecker.DropFromIndex(ref, tableEndTime, now) || e.deletionExpiryChecker.DropFromIndex(ref, tableEndTime, now)
+	if !e.retentionExpiryChecker.DropFromIndex(ref, tableEndTime, now) && !e.deletionExpiryChecker.DropFromIndex(ref, tableEndTime, now) {
+		return false
+	}
+	return e.legalHolds == nil || !e.legalHolds.Holds(ref)
 }
 
 func (c *Compactor) OnRingInstanceRegister(_ *ring.BasicLifecycler, ringDesc ring.Desc, instanceExists bool, _ string, instanceDesc ring.InstanceDesc) (ring.InstanceState, ring.Tokens) {
@@ -13,12 +13,17 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
 	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/util/filter"
 	loki_net "github.com/grafana/loki/v3/pkg/util/net"
 	"github.com/grafana/loki/v3/pkg/validation"
 )
@@ -61,7 +66,7 @@ func setupTestCompactor(t *testing.T, objectClients map[config.DayTime]client.Ob
 	overrides, err := validation.NewOverrides(defaultLimits, nil)
 	require.NoError(t, err)
 
-	c, err := NewCompactor(cfg, objectClients, objectClients[periodConfigs[len(periodConfigs)-1].From], config.SchemaConfig{
+	c, err := NewCompactor(cfg, objectClients, nil, objectClients[periodConfigs[len(periodConfigs)-1].From], config.SchemaConfig{
 		Configs: periodConfigs,
 	}, overrides, prometheus.NewPedanticRegistry(), constants.Loki)
 	require.NoError(t, err)
@@ -304,6 +309,123 @@ func Test_tableSort(t *testing.T) {
 	require.Equal(t, []string{"index_19195", "index_19192", "index_19191"}, intervals)
 }
 
+type fakeExpirationChecker struct {
+	retention.ExpirationChecker
+	expired    bool
+	filterFunc filter.Func
+}
+
+func (f fakeExpirationChecker) Expired(_ retention.ChunkEntry, _ model.Time) (bool, filter.Func) {
+	return f.expired, f.filterFunc
+}
+
+func (f fakeExpirationChecker) MarkPhaseStarted() {}
+
+func Test_expirationChecker_Expired(t *testing.T) {
+	containsFilter := func(s string) filter.Func {
+		return func(_ time.Time, line string, _ ...labels.Label) bool {
+			return strings.Contains(line, s)
+		}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		retention       fakeExpirationChecker
+		deletion        fakeExpirationChecker
+		expected        bool
+		expectedDeleted []string
+	}{
+		{
+			name: "nothing expired",
+		},
+		{
+			name:            "fully expired by retention",
+			retention:       fakeExpirationChecker{expired: true},
+			deletion:        fakeExpirationChecker{expired: true, filterFunc: containsFilter("a")},
+			expected:        true,
+			expectedDeleted: nil,
+		},
+		{
+			name:            "lines expired by retention",
+			retention:       fakeExpirationChecker{expired: true, filterFunc: containsFilter("a")},
+			expected:        true,
+			expectedDeleted: []string{"a", "ab"},
+		},
+		{
+			name:            "lines deleted by request",
+			deletion:        fakeExpirationChecker{expired: true, filterFunc: containsFilter("b")},
+			expected:        true,
+			expectedDeleted: []string{"ab", "b"},
+		},
+		{
+			name:            "lines expired by retention and deleted by request",
+			retention:       fakeExpirationChecker{expired: true, filterFunc: containsFilter("a")},
+			deletion:        fakeExpirationChecker{expired: true, filterFunc: containsFilter("b")},
+			expected:        true,
+			expectedDeleted: []string{"a", "ab", "b"},
+		},
+		{
+			name:            "lines expired by retention and chunk deleted by request",
+			retention:       fakeExpirationChecker{expired: true, filterFunc: containsFilter("a")},
+			deletion:        fakeExpirationChecker{expired: true},
+			expected:        true,
+			expectedDeleted: nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expired, filterFunc := newExpirationChecker(tc.retention, tc.deletion, nil).Expired(retention.ChunkEntry{}, model.Now())
+			require.Equal(t, tc.expected, expired)
+			if tc.expectedDeleted == nil {
+				require.Nil(t, filterFunc)
+				return
+			}
+
+			var deleted []string
+			for _, line := range []string{"a", "ab", "b", "c"} {
+				if filterFunc(time.Now(), line) {
+					deleted = append(deleted, line)
+				}
+			}
+			require.Equal(t, tc.expectedDeleted, deleted)
+		})
+	}
+}
+
+func Test_expirationChecker_LegalHold(t *testing.T) {
+	tempDir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: filepath.Join(tempDir, "object-store")})
+	require.NoError(t, err)
+	store, err := deletion.NewLegalHoldsStore(filepath.Join(tempDir, "working-dir"), storage.NewIndexStorageClient(objectClient, ""))
+	require.NoError(t, err)
+	defer store.Stop()
+
+	now := model.Now()
+	_, err = store.AddLegalHold(context.Background(), deletion.LegalHold{
+		UserID:    "1",
+		Query:     `{foo="bar"}`,
+		StartTime: now.Add(-48 * time.Hour),
+		EndTime:   now.Add(-24 * time.Hour),
+	})
+	require.NoError(t, err)
+
+	held := retention.ChunkEntry{
+		ChunkRef: retention.ChunkRef{UserID: []byte("1"), From: now.Add(-30 * time.Hour), Through: now.Add(-29 * time.Hour)},
+		Labels:   labels.FromStrings("foo", "bar"),
+	}
+	notHeld := retention.ChunkEntry{
+		ChunkRef: retention.ChunkRef{UserID: []byte("1"), From: now.Add(-30 * time.Hour), Through: now.Add(-29 * time.Hour)},
+		Labels:   labels.FromStrings("foo", "baz"),
+	}
+
+	e := newExpirationChecker(fakeExpirationChecker{expired: true}, fakeExpirationChecker{}, deletion.NewLegalHoldsManager(store, nil))
+	e.MarkPhaseStarted()
+
+	expired, _ := e.Expired(held, now)
+	require.False(t, expired)
+	expired, _ = e.Expired(notHeld, now)
+	require.True(t, expired)
+}
+
 func TestCompactor_TableLocking(t *testing.T) {
 	commonDBsConfig := IndexesConfig{NumUnCompactedFiles: 5}
 	perUserDBsConfig := PerUserIndexesConfig{}
@@ -23,6 +23,7 @@ import (
 
 type deleteRequestsTable struct {
 	indexStorageClient storage.Client
+	name               string
 	dbPath             string
 
 	boltdbIndexClient *local.BoltIndexClient
@@ -31,10 +32,14 @@ type deleteRequestsTable struct {
 	wg                sync.WaitGroup
 }
 
-const deleteRequestsIndexFileName = DeleteRequestsTableName + ".gz"
-
 func newDeleteRequestsTable(workingDirectory string, indexStorageClient storage.Client) (index.Client, error) {
-	dbPath := filepath.Join(workingDirectory, DeleteRequestsTableName, DeleteRequestsTableName)
+	return newBoltDBTable(workingDirectory, DeleteRequestsTableName, indexStorageClient)
+}
+
+// newBoltDBTable returns an index.Client storing the entries in a local boltdb file
+// which is periodically uploaded to the index storage as the only file of the table.
+func newBoltDBTable(workingDirectory, name string, indexStorageClient storage.Client) (index.Client, error) {
+	dbPath := filepath.Join(workingDirectory, name, name)
 	boltdbIndexClient, err := local.NewBoltDBIndexClient(local.BoltDBConfig{Directory: filepath.Dir(dbPath)})
 	if err != nil {
 		return nil, err
@@ -42,6 +47,7 @@ func newDeleteRequestsTable(workingDirectory string, indexStorageClient storage.
 
 	table := &deleteRequestsTable{
 		indexStorageClient: indexStorageClient,
+		name:               name,
 		dbPath:             dbPath,
 		boltdbIndexClient:  boltdbIndexClient,
 		done:               make(chan struct{}),
@@ -67,8 +73,8 @@ func (t *deleteRequestsTable) init() error {
 	_, err := os.Stat(t.dbPath)
 	if err != nil {
 		err = storage.DownloadFileFromStorage(t.dbPath, true,
-			true, storage.LoggerWithFilename(util_log.Logger, deleteRequestsIndexFileName), func() (io.ReadCloser, error) {
-				return t.indexStorageClient.GetFile(context.Background(), DeleteRequestsTableName, deleteRequestsIndexFileName)
+			true, storage.LoggerWithFilename(util_log.Logger, t.fileName()), func() (io.ReadCloser, error) {
+				return t.indexStorageClient.GetFile(context.Background(), t.name, t.fileName())
 			})
 		if err != nil && !t.indexStorageClient.IsFileNotFoundErr(err) {
 			return err
@@ -89,7 +95,7 @@ func (t *deleteRequestsTable) loop() {
 		select {
 		case <-uploadTicker.C:
 			if err := t.uploadFile(); err != nil {
-				level.Error(util_log.Logger).Log("msg", "failed to upload delete requests file", "err", err)
+				level.Error(util_log.Logger).Log("msg", "failed to upload db file", "table", t.name, "err", err)
 			}
 		case <-t.done:
 			return
@@ -98,7 +104,7 @@ func (t *deleteRequestsTable) loop() {
 }
 
 func (t *deleteRequestsTable) uploadFile() error {
-	level.Debug(util_log.Logger).Log("msg", "uploading delete requests db")
+	level.Debug(util_log.Logger).Log("msg", "uploading db", "table", t.name)
 
 	tempFilePath := fmt.Sprintf("%s.%s", t.dbPath, tempFileSuffix)
 	f, err := os.Create(tempFilePath)
@@ -144,7 +150,11 @@ func (t *deleteRequestsTable) uploadFile() error {
 		return err
 	}
 
-	return t.indexStorageClient.PutFile(context.Background(), DeleteRequestsTableName, deleteRequestsIndexFileName, f)
+	return t.indexStorageClient.PutFile(context.Background(), t.name, t.fileName(), f)
+}
+
+func (t *deleteRequestsTable) fileName() string {
+	return t.name + ".gz"
 }
 
 func (t *deleteRequestsTable) Stop() {
@@ -152,11 +162,11 @@ func (t *deleteRequestsTable) Stop() {
 	t.wg.Wait()
 
 	if err := t.uploadFile(); err != nil {
-		level.Error(util_log.Logger).Log("msg", "failed to upload delete requests file during shutdown", "err", err)
+		level.Error(util_log.Logger).Log("msg", "failed to upload db file during shutdown", "table", t.name, "err", err)
 	}
 
 	if err := t.db.Close(); err != nil {
-		level.Error(util_log.Logger).Log("msg", "failed to close delete requests db", "err", err)
+		level.Error(util_log.Logger).Log("msg", "failed to close db", "table", t.name, "err", err)
 	}
 
 	t.boltdbIndexClient.Stop()
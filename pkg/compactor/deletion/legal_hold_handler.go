@@ -0,0 +1,190 @@
+package deletion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// LegalHoldHandler provides handlers for legal holds
+type LegalHoldHandler struct {
+	legalHoldsStore LegalHoldsStore
+}
+
+// NewLegalHoldHandler creates a LegalHoldHandler
+func NewLegalHoldHandler(store LegalHoldsStore) *LegalHoldHandler {
+	return &LegalHoldHandler{
+		legalHoldsStore: store,
+	}
+}
+
+// AddLegalHoldHandler handles creation of a new legal hold
+func (h *LegalHoldHandler) AddLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	hold := LegalHold{
+		UserID: userID,
+		Reason: params.Get("reason"),
+	}
+
+	if params.Get("query") == "" {
+		http.Error(w, "query not set", http.StatusBadRequest)
+		return
+	}
+	if err := hold.SetQuery(params.Get("query")); err != nil {
+		http.Error(w, fmt.Sprintf("invalid query: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	hold.StartTime, err = startTime(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// a legal hold without end time preserves all the data after its start time, including the data to come.
+	hold.EndTime = model.Time(math.MaxInt64)
+	if endParam := params.Get("end"); endParam != "" {
+		end, err := parseTime(endParam)
+		if err != nil {
+			http.Error(w, "invalid end time: require unix seconds or RFC3339 format", http.StatusBadRequest)
+			return
+		}
+		if int64(hold.StartTime) > end {
+			http.Error(w, "start time can't be greater than end time", http.StatusBadRequest)
+			return
+		}
+		hold.EndTime = model.Time(end)
+	}
+
+	hold, err = h.legalHoldsStore.AddLegalHold(ctx, hold)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error adding legal hold to the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "legal hold for user added",
+		"legal_hold_id", hold.HoldID,
+		"user", userID,
+		"query", hold.Query,
+		"reason", hold.Reason,
+	)
+
+	writeJSON(w, hold)
+}
+
+// GetAllLegalHoldsHandler handles listing the legal holds of a user
+func (h *LegalHoldHandler) GetAllLegalHoldsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	holds, err := h.legalHoldsStore.GetAllLegalHoldsForUser(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting legal holds from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if holds == nil {
+		holds = []LegalHold{} // return [] rather than null
+	}
+	writeJSON(w, holds)
+}
+
+// ReleaseLegalHoldHandler handles the release of a legal hold
+func (h *LegalHoldHandler) ReleaseLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	hold, err := h.legalHoldsStore.GetLegalHold(ctx, userID, params.Get("hold_id"))
+	if err != nil {
+		if errors.Is(err, ErrLegalHoldNotFound) {
+			http.Error(w, "could not find legal hold with given id", http.StatusNotFound)
+			return
+		}
+
+		level.Error(util_log.Logger).Log("msg", "error getting legal hold from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if hold.Status != LegalHoldStatusActive {
+		http.Error(w, "legal hold is already released", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.legalHoldsStore.ReleaseLegalHold(ctx, hold, params.Get("reason")); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error releasing the legal hold", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "legal hold for user released",
+		"legal_hold_id", hold.HoldID,
+		"user", userID,
+		"reason", params.Get("reason"),
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLegalHoldEventsHandler handles querying the audit trail of the legal holds of a user,
+// optionally restricted to a single legal hold.
+func (h *LegalHoldHandler) GetLegalHoldEventsHandler(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.legalHoldsStore.GetLegalHoldEvents(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting legal hold events from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	holdID := r.URL.Query().Get("hold_id")
+	filtered := []LegalHoldEvent{} // return [] rather than null
+	for _, event := range events {
+		if holdID == "" || event.HoldID == holdID {
+			filtered = append(filtered, event)
+		}
+	}
+	writeJSON(w, filtered)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
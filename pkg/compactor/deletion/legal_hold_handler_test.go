@@ -0,0 +1,102 @@
+package deletion
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func legalHoldRequest(t *testing.T, method, userID string, params url.Values) *http.Request {
+	t.Helper()
+
+	req, err := http.NewRequest(method, "http://localhost:3100/loki/api/v1/legal_hold?"+params.Encode(), nil)
+	require.NoError(t, err)
+	return req.WithContext(user.InjectOrgID(req.Context(), userID))
+}
+
+func TestLegalHoldHandler(t *testing.T) {
+	tempDir := t.TempDir()
+	ts := model.Time(1000)
+	store := newTestLegalHoldsStore(t, filepath.Join(tempDir, "working-dir"), filepath.Join(tempDir, "object-store"), &ts)
+	defer store.Stop()
+	h := NewLegalHoldHandler(store)
+
+	for _, tc := range []struct {
+		name   string
+		params url.Values
+	}{
+		{"missing query", url.Values{"start": {"0000000000"}}},
+		{"line filter", url.Values{"query": {`{foo="bar"} |= "baz"`}, "start": {"0000000000"}}},
+		{"missing start", url.Values{"query": {`{foo="bar"}`}}},
+		{"start after end", url.Values{"query": {`{foo="bar"}`}, "start": {"0000000002"}, "end": {"0000000001"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.AddLegalHoldHandler(w, legalHoldRequest(t, http.MethodPost, user1, tc.params))
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+
+	w := httptest.NewRecorder()
+	h.AddLegalHoldHandler(w, legalHoldRequest(t, http.MethodPost, user1, url.Values{
+		"query":  {`{foo="bar"}`},
+		"start":  {"0000000000"},
+		"end":    {"0000000001"},
+		"reason": {"case 1"},
+	}))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var hold LegalHold
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &hold))
+	require.NotEmpty(t, hold.HoldID)
+	require.Equal(t, `{foo="bar"}`, hold.Query)
+	require.Equal(t, model.Time(0), hold.StartTime)
+	require.Equal(t, model.Time(1000), hold.EndTime)
+	require.Equal(t, "case 1", hold.Reason)
+	require.Equal(t, LegalHoldStatusActive, hold.Status)
+
+	// the legal holds are only visible to their tenant.
+	w = httptest.NewRecorder()
+	h.GetAllLegalHoldsHandler(w, legalHoldRequest(t, http.MethodGet, user2, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[]`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	h.ReleaseLegalHoldHandler(w, legalHoldRequest(t, http.MethodDelete, user2, url.Values{"hold_id": {hold.HoldID}}))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	ts = 2000
+	w = httptest.NewRecorder()
+	h.ReleaseLegalHoldHandler(w, legalHoldRequest(t, http.MethodDelete, user1, url.Values{"hold_id": {hold.HoldID}, "reason": {"case closed"}}))
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ReleaseLegalHoldHandler(w, legalHoldRequest(t, http.MethodDelete, user1, url.Values{"hold_id": {hold.HoldID}}))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	h.GetAllLegalHoldsHandler(w, legalHoldRequest(t, http.MethodGet, user1, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var holds []LegalHold
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &holds))
+	require.Len(t, holds, 1)
+	require.Equal(t, LegalHoldStatusReleased, holds[0].Status)
+	require.Equal(t, model.Time(2000), holds[0].ReleasedAt)
+
+	w = httptest.NewRecorder()
+	h.GetLegalHoldEventsHandler(w, legalHoldRequest(t, http.MethodGet, user1, url.Values{"hold_id": {hold.HoldID}}))
+	require.Equal(t, http.StatusOK, w.Code)
+	var events []LegalHoldEvent
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &events))
+	require.Equal(t, []LegalHoldEvent{
+		{HoldID: hold.HoldID, Action: LegalHoldActionCreated, Time: 1000, Query: `{foo="bar"}`, Reason: "case 1"},
+		{HoldID: hold.HoldID, Action: LegalHoldActionReleased, Time: 2000, Query: `{foo="bar"}`, Reason: "case closed"},
+	}, events)
+}
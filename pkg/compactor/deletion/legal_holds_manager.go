@@ -0,0 +1,75 @@
+package deletion
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// LegalHoldsManager tells retention and deletion which chunks are preserved by a legal hold.
+// The active legal holds are loaded at the start of every retention phase.
+type LegalHoldsManager struct {
+	store   LegalHoldsStore
+	metrics *legalHoldsManagerMetrics
+
+	mtx   sync.RWMutex
+	holds map[string][]LegalHold
+	// holdAll is set when the legal holds could not be loaded, in which case
+	// no chunk must be deleted since any of them could be held.
+	holdAll bool
+}
+
+func NewLegalHoldsManager(store LegalHoldsStore, registerer prometheus.Registerer) *LegalHoldsManager {
+	return &LegalHoldsManager{
+		store:   store,
+		metrics: newLegalHoldsManagerMetrics(registerer),
+		holds:   map[string][]LegalHold{},
+	}
+}
+
+// LoadLegalHolds refreshes the active legal holds from the store.
+func (m *LegalHoldsManager) LoadLegalHolds(ctx context.Context) {
+	holds, err := m.store.GetActiveLegalHolds(ctx)
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to load legal holds, all chunks are held during this retention phase", "err", err)
+		m.metrics.loadLegalHoldsAttemptsTotal.WithLabelValues(statusFail).Inc()
+		m.holdAll = true
+		return
+	}
+	m.metrics.loadLegalHoldsAttemptsTotal.WithLabelValues(statusSuccess).Inc()
+	m.metrics.activeLegalHolds.Set(float64(len(holds)))
+
+	m.holdAll = false
+	m.holds = make(map[string][]LegalHold, len(m.holds))
+	for _, hold := range holds {
+		m.holds[hold.UserID] = append(m.holds[hold.UserID], hold)
+	}
+}
+
+// Holds returns true if the chunk is preserved by a legal hold and must neither be expired nor deleted.
+func (m *LegalHoldsManager) Holds(ref retention.ChunkEntry) bool {
+	userID := string(ref.UserID)
+
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	if m.holdAll {
+		return true
+	}
+	for i := range m.holds[userID] {
+		if m.holds[userID][i].Holds(userID, ref) {
+			m.metrics.heldChunksTotal.WithLabelValues(userID).Inc()
+			return true
+		}
+	}
+	return false
+}
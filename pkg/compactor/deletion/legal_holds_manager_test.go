@@ -0,0 +1,62 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+)
+
+type mockLegalHoldsStore struct {
+	LegalHoldsStore
+	holds []LegalHold
+	err   error
+}
+
+func (m *mockLegalHoldsStore) GetActiveLegalHolds(_ context.Context) ([]LegalHold, error) {
+	return m.holds, m.err
+}
+
+func TestLegalHoldsManager(t *testing.T) {
+	hold := LegalHold{
+		UserID:    user1,
+		Status:    LegalHoldStatusActive,
+		StartTime: now.Add(-24 * time.Hour),
+		EndTime:   now.Add(-12 * time.Hour),
+	}
+	require.NoError(t, hold.SetQuery(`{foo="bar"}`))
+
+	store := &mockLegalHoldsStore{holds: []LegalHold{hold}}
+	m := NewLegalHoldsManager(store, nil)
+	m.LoadLegalHolds(context.Background())
+
+	for _, tc := range []struct {
+		name     string
+		ref      retention.ChunkEntry
+		expected bool
+	}{
+		{"held", retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user1), From: now.Add(-13 * time.Hour), Through: now.Add(-11 * time.Hour)}, Labels: mustParseLabel(`{foo="bar", app="a"}`)}, true},
+		{"other user", retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user2), From: now.Add(-13 * time.Hour), Through: now.Add(-11 * time.Hour)}, Labels: mustParseLabel(`{foo="bar"}`)}, false},
+		{"other stream", retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user1), From: now.Add(-13 * time.Hour), Through: now.Add(-11 * time.Hour)}, Labels: mustParseLabel(`{foo="baz"}`)}, false},
+		{"out of range", retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user1), From: now.Add(-11 * time.Hour), Through: now.Add(-10 * time.Hour)}, Labels: mustParseLabel(`{foo="bar"}`)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, m.Holds(tc.ref))
+		})
+	}
+
+	// all the chunks are held when the legal holds can not be loaded.
+	store.err = errors.New("fail")
+	m.LoadLegalHolds(context.Background())
+	require.True(t, m.Holds(retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user2), From: 0, Through: model.Time(1)}, Labels: mustParseLabel(`{foo="baz"}`)}))
+
+	store.err = nil
+	store.holds = nil
+	m.LoadLegalHolds(context.Background())
+	require.False(t, m.Holds(retention.ChunkEntry{ChunkRef: retention.ChunkRef{UserID: []byte(user1), From: now.Add(-13 * time.Hour), Through: now.Add(-11 * time.Hour)}, Labels: mustParseLabel(`{foo="bar"}`)}))
+}
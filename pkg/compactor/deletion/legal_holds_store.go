@@ -0,0 +1,385 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/storage/stores/series/index"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+type (
+	LegalHoldStatus string
+	LegalHoldAction string
+)
+
+const (
+	LegalHoldStatusActive   LegalHoldStatus = "active"
+	LegalHoldStatusReleased LegalHoldStatus = "released"
+
+	LegalHoldActionCreated  LegalHoldAction = "created"
+	LegalHoldActionReleased LegalHoldAction = "released"
+
+	legalHoldID      indexType = "1"
+	legalHoldDetails indexType = "2"
+	legalHoldEvents  indexType = "3"
+
+	LegalHoldsTableName = "legal_holds"
+)
+
+var ErrLegalHoldNotFound = errors.New("could not find matching legal hold")
+
+// LegalHold preserves the chunks of the streams matching its selector which overlap its time range
+// from retention and deletion until it is released.
+type LegalHold struct {
+	HoldID     string          `json:"hold_id"`
+	StartTime  model.Time      `json:"start_time"`
+	EndTime    model.Time      `json:"end_time"`
+	Query      string          `json:"query"`
+	Reason     string          `json:"reason,omitempty"`
+	Status     LegalHoldStatus `json:"status"`
+	CreatedAt  model.Time      `json:"created_at"`
+	ReleasedAt model.Time      `json:"released_at,omitempty"`
+
+	UserID   string            `json:"-"`
+	matchers []*labels.Matcher `json:"-"`
+}
+
+func (h *LegalHold) SetQuery(query string) error {
+	matchers, err := syntax.ParseMatchers(query, true)
+	if err != nil {
+		return err
+	}
+	h.Query = query
+	h.matchers = matchers
+	return nil
+}
+
+// Holds returns true if the given chunk is preserved by the legal hold.
+func (h *LegalHold) Holds(userID string, entry retention.ChunkEntry) bool {
+	if h.Status != LegalHoldStatusActive || h.UserID != userID {
+		return false
+	}
+	if !intervalsOverlap(model.Interval{Start: entry.From, End: entry.Through}, model.Interval{Start: h.StartTime, End: h.EndTime}) {
+		return false
+	}
+	return allMatch(h.matchers, entry.Labels)
+}
+
+// LegalHoldEvent is an entry of the audit trail of a legal hold.
+type LegalHoldEvent struct {
+	HoldID string          `json:"hold_id"`
+	Action LegalHoldAction `json:"action"`
+	Time   model.Time      `json:"time"`
+	Query  string          `json:"query,omitempty"`
+	Reason string          `json:"reason,omitempty"`
+}
+
+// legalHoldDetailsValue is stored in the value of the details entry of a legal hold.
+type legalHoldDetailsValue struct {
+	Query  string `json:"query"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type LegalHoldsStore interface {
+	AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error)
+	GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error)
+	GetAllLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error)
+	GetActiveLegalHolds(ctx context.Context) ([]LegalHold, error)
+	ReleaseLegalHold(ctx context.Context, hold LegalHold, reason string) error
+	GetLegalHoldEvents(ctx context.Context, userID string) ([]LegalHoldEvent, error)
+	Stop()
+}
+
+// legalHoldsStore keeps the legal holds and their audit trail in a table managed like the delete requests one.
+// Released legal holds are kept to preserve their history.
+type legalHoldsStore struct {
+	indexClient index.Client
+	now         func() model.Time
+}
+
+// NewLegalHoldsStore creates a store for managing legal holds.
+func NewLegalHoldsStore(workingDirectory string, indexStorageClient storage.Client) (LegalHoldsStore, error) {
+	indexClient, err := newBoltDBTable(workingDirectory, LegalHoldsTableName, indexStorageClient)
+	if err != nil {
+		return nil, err
+	}
+
+	return &legalHoldsStore{
+		indexClient: indexClient,
+		now:         model.Now,
+	}, nil
+}
+
+func (s *legalHoldsStore) Stop() {
+	s.indexClient.Stop()
+}
+
+// AddLegalHold creates a new active legal hold and records its creation in the audit trail.
+func (s *legalHoldsStore) AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	if err := hold.SetQuery(hold.Query); err != nil {
+		return LegalHold{}, err
+	}
+
+	holdID, err := s.generateID(ctx, hold)
+	if err != nil {
+		return LegalHold{}, err
+	}
+	hold.HoldID = string(holdID)
+	hold.Status = LegalHoldStatusActive
+	hold.CreatedAt = s.now()
+	hold.ReleasedAt = 0
+
+	userIDAndHoldID := fmt.Sprintf("%s:%s", hold.UserID, hold.HoldID)
+	writeBatch := s.indexClient.NewWriteBatch()
+	writeBatch.Add(LegalHoldsTableName, string(legalHoldID), []byte(userIDAndHoldID), []byte(hold.Status))
+
+	details, err := json.Marshal(legalHoldDetailsValue{Query: hold.Query, Reason: hold.Reason})
+	if err != nil {
+		return LegalHold{}, err
+	}
+	rangeValue := fmt.Sprintf("%x:%x:%x", int64(hold.CreatedAt), int64(hold.StartTime), int64(hold.EndTime))
+	writeBatch.Add(LegalHoldsTableName, fmt.Sprintf("%s:%s", legalHoldDetails, userIDAndHoldID), []byte(rangeValue), details)
+
+	if err := s.addEvent(writeBatch, hold.UserID, LegalHoldEvent{
+		HoldID: hold.HoldID,
+		Action: LegalHoldActionCreated,
+		Time:   hold.CreatedAt,
+		Query:  hold.Query,
+		Reason: hold.Reason,
+	}); err != nil {
+		return LegalHold{}, err
+	}
+
+	if err := s.indexClient.BatchWrite(ctx, writeBatch); err != nil {
+		return LegalHold{}, err
+	}
+	return hold, nil
+}
+
+func (s *legalHoldsStore) generateID(ctx context.Context, hold LegalHold) ([]byte, error) {
+	holdID := generateUniqueID(hold.UserID, hold.Query)
+
+	for {
+		if _, err := s.GetLegalHold(ctx, hold.UserID, string(holdID)); err != nil {
+			if err == ErrLegalHoldNotFound {
+				return holdID, nil
+			}
+			return nil, err
+		}
+
+		// we have a collision here, lets recreate a new holdID and check for collision
+		time.Sleep(time.Millisecond)
+		holdID = generateUniqueID(hold.UserID, hold.Query)
+	}
+}
+
+// ReleaseLegalHold marks a legal hold as released and records it in the audit trail.
+func (s *legalHoldsStore) ReleaseLegalHold(ctx context.Context, hold LegalHold, reason string) error {
+	releasedAt := s.now()
+	userIDAndHoldID := fmt.Sprintf("%s:%s", hold.UserID, hold.HoldID)
+
+	writeBatch := s.indexClient.NewWriteBatch()
+	writeBatch.Add(LegalHoldsTableName, string(legalHoldID), []byte(userIDAndHoldID), []byte(fmt.Sprintf("%s:%x", LegalHoldStatusReleased, int64(releasedAt))))
+	if err := s.addEvent(writeBatch, hold.UserID, LegalHoldEvent{
+		HoldID: hold.HoldID,
+		Action: LegalHoldActionReleased,
+		Time:   releasedAt,
+		Query:  hold.Query,
+		Reason: reason,
+	}); err != nil {
+		return err
+	}
+
+	return s.indexClient.BatchWrite(ctx, writeBatch)
+}
+
+func (s *legalHoldsStore) addEvent(writeBatch index.WriteBatch, userID string, event LegalHoldEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	// the time comes first and is zero padded to list the events in order.
+	rangeValue := fmt.Sprintf("%016x:%s:%s", int64(event.Time), event.HoldID, event.Action)
+	writeBatch.Add(LegalHoldsTableName, fmt.Sprintf("%s:%s", legalHoldEvents, userID), []byte(rangeValue), value)
+	return nil
+}
+
+// GetLegalHold returns the legal hold with the given id.
+func (s *legalHoldsStore) GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error) {
+	holds, err := s.queryLegalHolds(ctx, index.Query{
+		TableName:        LegalHoldsTableName,
+		HashValue:        string(legalHoldID),
+		RangeValuePrefix: []byte(fmt.Sprintf("%s:%s", userID, holdID)),
+	})
+	if err != nil {
+		return LegalHold{}, err
+	}
+
+	for _, hold := range holds {
+		if hold.HoldID == holdID {
+			return hold, nil
+		}
+	}
+	return LegalHold{}, ErrLegalHoldNotFound
+}
+
+// GetAllLegalHoldsForUser returns all the legal holds of a user, including the released ones.
+func (s *legalHoldsStore) GetAllLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error) {
+	holds, err := s.queryLegalHolds(ctx, index.Query{
+		TableName:        LegalHoldsTableName,
+		HashValue:        string(legalHoldID),
+		RangeValuePrefix: []byte(userID + ":"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].CreatedAt < holds[j].CreatedAt
+	})
+	return holds, nil
+}
+
+// GetActiveLegalHolds returns the legal holds of all the users which are not released.
+func (s *legalHoldsStore) GetActiveLegalHolds(ctx context.Context) ([]LegalHold, error) {
+	return s.queryLegalHolds(ctx, index.Query{
+		TableName:  LegalHoldsTableName,
+		HashValue:  string(legalHoldID),
+		ValueEqual: []byte(LegalHoldStatusActive),
+	})
+}
+
+// GetLegalHoldEvents returns the audit trail of the legal holds of a user, oldest first.
+func (s *legalHoldsStore) GetLegalHoldEvents(ctx context.Context, userID string) ([]LegalHoldEvent, error) {
+	var (
+		events       []LegalHoldEvent
+		unmarshalErr error
+	)
+	err := s.indexClient.QueryPages(ctx, []index.Query{{
+		TableName: LegalHoldsTableName,
+		HashValue: fmt.Sprintf("%s:%s", legalHoldEvents, userID),
+	}}, func(_ index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		itr := batch.Iterator()
+		for itr.Next() {
+			var event LegalHoldEvent
+			if unmarshalErr = json.Unmarshal(itr.Value(), &event); unmarshalErr != nil {
+				return false
+			}
+			events = append(events, event)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return events, nil
+}
+
+func (s *legalHoldsStore) queryLegalHolds(ctx context.Context, query index.Query) ([]LegalHold, error) {
+	var (
+		holds    []LegalHold
+		parseErr error
+	)
+	err := s.indexClient.QueryPages(ctx, []index.Query{query}, func(_ index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		// No need to lock inside the callback since we run a single index query.
+		itr := batch.Iterator()
+		for itr.Next() {
+			userID, holdID, ok := strings.Cut(string(itr.RangeValue()), ":")
+			if !ok {
+				parseErr = errors.New("invalid key in parsing legal hold lookup response")
+				return false
+			}
+
+			hold := LegalHold{UserID: userID, HoldID: holdID}
+			status, releasedAt, released := strings.Cut(string(itr.Value()), ":")
+			hold.Status = LegalHoldStatus(status)
+			if released {
+				var ts int64
+				if ts, parseErr = strconv.ParseInt(releasedAt, 16, 64); parseErr != nil {
+					return false
+				}
+				hold.ReleasedAt = model.Time(ts)
+			}
+			holds = append(holds, hold)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	for i := range holds {
+		if err := s.queryLegalHoldDetails(ctx, &holds[i]); err != nil {
+			return nil, err
+		}
+	}
+	return holds, nil
+}
+
+func (s *legalHoldsStore) queryLegalHoldDetails(ctx context.Context, hold *LegalHold) error {
+	var parseErr error
+	err := s.indexClient.QueryPages(ctx, []index.Query{{
+		TableName: LegalHoldsTableName,
+		HashValue: fmt.Sprintf("%s:%s:%s", legalHoldDetails, hold.UserID, hold.HoldID),
+	}}, func(_ index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		itr := batch.Iterator()
+		if !itr.Next() {
+			return false
+		}
+
+		if parseErr = parseLegalHoldTimestamps(itr.RangeValue(), hold); parseErr != nil {
+			return false
+		}
+
+		var details legalHoldDetailsValue
+		if parseErr = json.Unmarshal(itr.Value(), &details); parseErr != nil {
+			return false
+		}
+		hold.Reason = details.Reason
+		parseErr = hold.SetQuery(details.Query)
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	return parseErr
+}
+
+func parseLegalHoldTimestamps(rangeValue []byte, hold *LegalHold) error {
+	hexParts := strings.Split(string(rangeValue), ":")
+	if len(hexParts) != 3 {
+		return errors.New("invalid key in parsing legal hold details")
+	}
+
+	var timestamps [3]int64
+	for i, part := range hexParts {
+		ts, err := strconv.ParseInt(part, 16, 64)
+		if err != nil {
+			return err
+		}
+		timestamps[i] = ts
+	}
+
+	hold.CreatedAt = model.Time(timestamps[0])
+	hold.StartTime = model.Time(timestamps[1])
+	hold.EndTime = model.Time(timestamps[2])
+	return nil
+}
@@ -0,0 +1,106 @@
+package deletion
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+func newTestLegalHoldsStore(t *testing.T, workingDir, objectStorePath string, ts *model.Time) *legalHoldsStore {
+	t.Helper()
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{
+		Directory: objectStorePath,
+	})
+	require.NoError(t, err)
+	s, err := NewLegalHoldsStore(workingDir, storage.NewIndexStorageClient(objectClient, ""))
+	require.NoError(t, err)
+
+	store := s.(*legalHoldsStore)
+	store.now = func() model.Time { return *ts }
+	return store
+}
+
+func TestLegalHoldsStore(t *testing.T) {
+	tempDir := t.TempDir()
+	workingDir := filepath.Join(tempDir, "working-dir")
+	objectStorePath := filepath.Join(tempDir, "object-store")
+	ts := model.Time(1000)
+	store := newTestLegalHoldsStore(t, workingDir, objectStorePath, &ts)
+
+	ctx := context.Background()
+	hold1, err := store.AddLegalHold(ctx, LegalHold{
+		UserID:    user1,
+		Query:     `{app="foo"}`,
+		StartTime: now.Add(-24 * time.Hour),
+		EndTime:   now,
+		Reason:    "case 1",
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, hold1.HoldID)
+	require.Equal(t, LegalHoldStatusActive, hold1.Status)
+	require.Equal(t, ts, hold1.CreatedAt)
+
+	ts = 2000
+	hold2, err := store.AddLegalHold(ctx, LegalHold{
+		UserID:    user2,
+		Query:     `{app="bar"}`,
+		StartTime: now.Add(-48 * time.Hour),
+		EndTime:   now,
+	})
+	require.NoError(t, err)
+
+	_, err = store.AddLegalHold(ctx, LegalHold{UserID: user1, Query: `{app="foo"} |= "bar"`})
+	require.Error(t, err)
+
+	active, err := store.GetActiveLegalHolds(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []LegalHold{hold1, hold2}, active)
+
+	userHolds, err := store.GetAllLegalHoldsForUser(ctx, user1)
+	require.NoError(t, err)
+	require.Equal(t, []LegalHold{hold1}, userHolds)
+
+	_, err = store.GetLegalHold(ctx, user2, hold1.HoldID)
+	require.ErrorIs(t, err, ErrLegalHoldNotFound)
+
+	ts = 3000
+	require.NoError(t, store.ReleaseLegalHold(ctx, hold1, "case closed"))
+
+	released, err := store.GetLegalHold(ctx, user1, hold1.HoldID)
+	require.NoError(t, err)
+	require.Equal(t, LegalHoldStatusReleased, released.Status)
+	require.Equal(t, model.Time(3000), released.ReleasedAt)
+	require.Equal(t, "case 1", released.Reason)
+
+	active, err = store.GetActiveLegalHolds(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []LegalHold{hold2}, active)
+
+	events, err := store.GetLegalHoldEvents(ctx, user1)
+	require.NoError(t, err)
+	require.Equal(t, []LegalHoldEvent{
+		{HoldID: hold1.HoldID, Action: LegalHoldActionCreated, Time: 1000, Query: `{app="foo"}`, Reason: "case 1"},
+		{HoldID: hold1.HoldID, Action: LegalHoldActionReleased, Time: 3000, Query: `{app="foo"}`, Reason: "case closed"},
+	}, events)
+
+	// the legal holds and their history survive a restart from the object store.
+	store.Stop()
+	store = newTestLegalHoldsStore(t, filepath.Join(tempDir, "other-working-dir"), objectStorePath, &ts)
+	defer store.Stop()
+
+	userHolds, err = store.GetAllLegalHoldsForUser(ctx, user1)
+	require.NoError(t, err)
+	require.Equal(t, []LegalHold{released}, userHolds)
+
+	events, err = store.GetLegalHoldEvents(ctx, user1)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+}
@@ -102,3 +102,31 @@ func newDeleteRequestsManagerMetrics(r prometheus.Registerer) *deleteRequestsMan
 
 	return &m
 }
+
+type legalHoldsManagerMetrics struct {
+	loadLegalHoldsAttemptsTotal *prometheus.CounterVec
+	activeLegalHolds            prometheus.Gauge
+	heldChunksTotal             *prometheus.CounterVec
+}
+
+func newLegalHoldsManagerMetrics(r prometheus.Registerer) *legalHoldsManagerMetrics {
+	m := legalHoldsManagerMetrics{}
+
+	m.loadLegalHoldsAttemptsTotal = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_load_legal_holds_attempts_total",
+		Help:      "Number of attempts that were made to load the active legal holds with status",
+	}, []string{"status"})
+	m.activeLegalHolds = promauto.With(r).NewGauge(prometheus.GaugeOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_active_legal_holds",
+		Help:      "Count of legal holds which are not released",
+	})
+	m.heldChunksTotal = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_legal_hold_held_chunks_total",
+		Help:      "Number of chunks which were kept by retention or deletion because of a legal hold per user",
+	}, []string{"user"})
+
+	return &m
+}
@@ -33,6 +33,7 @@ type ExpirationChecker interface {
 
 type expirationChecker struct {
 	tenantsRetention         *TenantsRetention
+	lineRetention            *lineRetention
 	latestRetentionStartTime latestRetentionStartTime
 }
 
@@ -46,18 +47,29 @@ type Limits interface {
 func NewExpirationChecker(limits Limits) ExpirationChecker {
 	return &expirationChecker{
 		tenantsRetention: NewTenantsRetention(limits),
+		lineRetention:    newLineRetention(),
 	}
 }
 
 // Expired tells if a ref chunk is expired based on retention rules.
+// When only some lines of the chunk are out of the retention of the rules filtering lines,
+// it returns a filter.Func selecting them.
 func (e *expirationChecker) Expired(ref ChunkEntry, now model.Time) (bool, filter.Func) {
 	userID := unsafeGetString(ref.UserID)
 	period := e.tenantsRetention.RetentionPeriodFor(userID, ref.Labels)
 	// The 0 value should disable retention
-	if period <= 0 {
+	if period > 0 && now.Sub(ref.Through) > period {
+		return true, nil
+	}
+
+	rules := e.tenantsRetention.LineRetentionFor(userID, ref.Labels)
+	if len(rules) == 0 {
 		return false, nil
 	}
-	return now.Sub(ref.Through) > period, nil
+	if filterFunc := e.lineRetention.filterFunc(userID, rules, ref, now); filterFunc != nil {
+		return true, filterFunc
+	}
+	return false, nil
 }
 
 // DropFromIndex tells if it is okay to drop the chunk entry from index table.
@@ -74,14 +86,24 @@ func (e *expirationChecker) DropFromIndex(ref ChunkEntry, tableEndTime model.Tim
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
-	e.latestRetentionStartTime = findLatestRetentionStartTime(model.Now(), e.tenantsRetention.limits)
+	now := model.Now()
+	e.lineRetention.markPhaseStarted(now)
+	e.latestRetentionStartTime = findLatestRetentionStartTime(now, e.tenantsRetention.limits)
 	level.Info(util_log.Logger).Log("msg", fmt.Sprintf("overall smallest retention period %v, default smallest retention period %v",
 		e.latestRetentionStartTime.overall, e.latestRetentionStartTime.defaults))
 }
 
-func (e *expirationChecker) MarkPhaseFailed()   {}
-func (e *expirationChecker) MarkPhaseTimedOut() {}
-func (e *expirationChecker) MarkPhaseFinished() {}
+func (e *expirationChecker) MarkPhaseFailed() {
+	e.lineRetention.markPhaseEnded(false)
+}
+
+func (e *expirationChecker) MarkPhaseTimedOut() {
+	e.lineRetention.markPhaseTimedOut()
+}
+
+func (e *expirationChecker) MarkPhaseFinished() {
+	e.lineRetention.markPhaseEnded(true)
+}
 
 func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval, userID string) bool {
 	// when userID is empty, it means we are checking for common index table. In this case we use e.overallLatestRetentionStartTime.
@@ -140,6 +162,10 @@ func (tr *TenantsRetention) RetentionPeriodFor(userID string, lbs labels.Labels)
 	)
 Outer:
 	for _, streamRetention := range streamRetentions {
+		// rules filtering lines never apply to whole streams.
+		if streamRetention.HasLineFilter() {
+			continue
+		}
 		for _, m := range streamRetention.Matchers {
 			if !m.Matches(lbs.Get(m.Name)) {
 				continue Outer
@@ -165,6 +191,24 @@ Outer:
 	return globalRetention
 }
 
+// LineRetentionFor returns the stream retention rules filtering lines which match the given stream.
+func (tr *TenantsRetention) LineRetentionFor(userID string, lbs labels.Labels) []validation.StreamRetention {
+	var matched []validation.StreamRetention
+Outer:
+	for _, streamRetention := range tr.limits.StreamRetention(userID) {
+		if !streamRetention.HasLineFilter() {
+			continue
+		}
+		for _, m := range streamRetention.Matchers {
+			if !m.Matches(lbs.Get(m.Name)) {
+				continue Outer
+			}
+		}
+		matched = append(matched, streamRetention)
+	}
+	return matched
+}
+
 type latestRetentionStartTime struct {
 	// defaults holds latest retention start time considering only default retention config.
 	// It is used to determine if user index table may have any expired chunks when the user does not have any custom retention config set.
@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/validation"
 )
 
@@ -503,3 +504,118 @@ func TestExpirationChecker_IntervalMayHaveExpiredChunks(t *testing.T) {
 		})
 	}
 }
+
+func Test_expirationChecker_LineRetention(t *testing.T) {
+	debugLogs, err := syntax.ParseLogSelector(`{foo="bar"} | level="debug"`, true)
+	require.NoError(t, err)
+
+	limits := fakeLimits{
+		perTenant: map[string]retentionLimit{
+			"1": {
+				retentionPeriod: 30 * 24 * time.Hour,
+				streamRetention: []validation.StreamRetention{
+					{
+						Period:      model.Duration(72 * time.Hour),
+						Selector:    `{foo="bar"} | level="debug"`,
+						Matchers:    debugLogs.Matchers(),
+						LogSelector: debugLogs,
+					},
+				},
+			},
+		},
+	}
+	e := NewExpirationChecker(limits)
+	now := model.Now()
+	debug := labels.FromStrings("level", "debug")
+	info := labels.FromStrings("level", "info")
+
+	// line rules do not change the retention of whole streams.
+	require.Equal(t, 30*24*time.Hour, NewTenantsRetention(limits).RetentionPeriodFor("1", labels.FromStrings("foo", "bar")))
+
+	e.MarkPhaseStarted()
+
+	expired, filterFunc := e.Expired(newChunkEntry("1", `{foo="bar"}`, now.Add(-24*time.Hour), now.Add(-12*time.Hour)), now)
+	require.False(t, expired, "chunk within the period of the rule")
+	require.Nil(t, filterFunc)
+
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{foo="buzz"}`, now.Add(-100*time.Hour), now.Add(-90*time.Hour)), now)
+	require.False(t, expired, "stream not matching the rule")
+	require.Nil(t, filterFunc)
+
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{foo="bar"}`, now.Add(-74*time.Hour), now.Add(-70*time.Hour)), now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+	require.True(t, filterFunc(now.Add(-73*time.Hour).Time(), "msg", debug...))
+	require.False(t, filterFunc(now.Add(-73*time.Hour).Time(), "msg", info...))
+	require.False(t, filterFunc(now.Add(-71*time.Hour).Time(), "msg", debug...))
+
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{foo="bar"}`, now.Add(-800*time.Hour), now.Add(-790*time.Hour)), now)
+	require.True(t, expired)
+	require.Nil(t, filterFunc, "stream retention deletes the whole chunk")
+
+	filtered := newChunkEntry("1", `{foo="bar"}`, now.Add(-76*time.Hour), now.Add(-74*time.Hour))
+	filtered.ChunkID = []byte("filtered")
+	expired, filterFunc = e.Expired(filtered, now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+
+	e.MarkPhaseFinished()
+	e.MarkPhaseStarted()
+
+	// the chunks entirely filtered by the previous run are not filtered again.
+	expired, _ = e.Expired(filtered, now)
+	require.False(t, expired)
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{foo="bar"}`, now.Add(-74*time.Hour), now.Add(-70*time.Hour)), now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+
+	// a chunk flushed late with old timestamps is filtered.
+	late := newChunkEntry("1", `{foo="bar"}`, now.Add(-76*time.Hour), now.Add(-74*time.Hour))
+	late.ChunkID = []byte("late")
+	expired, filterFunc = e.Expired(late, now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+
+	e.MarkPhaseFinished()
+
+	// both chunks were entirely filtered by the last run.
+	expired, _ = e.Expired(late, now)
+	require.False(t, expired)
+	expired, _ = e.Expired(filtered, now)
+	require.False(t, expired)
+}
+
+func Test_expirationChecker_LineRetention_TimedOut(t *testing.T) {
+	debugLogs, err := syntax.ParseLogSelector(`{foo="bar"} |= "debug"`, true)
+	require.NoError(t, err)
+
+	e := NewExpirationChecker(fakeLimits{
+		perTenant: map[string]retentionLimit{
+			"1": {
+				streamRetention: []validation.StreamRetention{
+					{
+						Period:      model.Duration(24 * time.Hour),
+						Selector:    `{foo="bar"} |= "debug"`,
+						Matchers:    debugLogs.Matchers(),
+						LogSelector: debugLogs,
+					},
+				},
+			},
+		},
+	})
+	now := model.Now()
+	chunk := newChunkEntry("1", `{foo="bar"}`, now.Add(-50*time.Hour), now.Add(-48*time.Hour))
+
+	e.MarkPhaseStarted()
+	expired, filterFunc := e.Expired(chunk, now)
+	require.True(t, expired)
+	require.True(t, filterFunc(now.Add(-49*time.Hour).Time(), "a debug line"))
+	require.False(t, filterFunc(now.Add(-49*time.Hour).Time(), "an error line"))
+	e.MarkPhaseTimedOut()
+	e.MarkPhaseFinished()
+
+	// the run did not complete so the chunk has to be filtered again.
+	e.MarkPhaseStarted()
+	expired, _ = e.Expired(chunk, now)
+	require.True(t, expired)
+}
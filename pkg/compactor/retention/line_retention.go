@@ -0,0 +1,151 @@
+package retention
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/util/filter"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+// lineRetention applies the stream retention rules filtering log lines, for instance
+// `{app="foo"} | level="debug"`. The lines of the matching streams which pass the
+// filters are deleted once they are older than the period of the rule, by rewriting
+// the chunks holding them.
+//
+// Since a rewritten chunk still holds lines older than the period of the rule, the
+// chunks would be downloaded and filtered again by every retention run. To avoid it,
+// lineRetention keeps, for each tenant and rule, the chunks entirely filtered by the
+// last successful run, and skips them. The chunks are identified by their ID rather
+// than their time range, so the chunks flushed late with old timestamps are still
+// filtered by the next run. A chunk rewritten by a run gets a new ID, so it is
+// filtered once more by the next run before being skipped.
+// This state lives in memory, so the first run after a restart filters all the chunks
+// again.
+type lineRetention struct {
+	mtx        sync.Mutex
+	phaseStart model.Time
+	timedOut   bool
+	// filtered holds the hashes of the IDs of the chunks entirely filtered by the
+	// last successful retention run, by tenant and rule.
+	filtered map[string]map[uint64]struct{}
+	// pending holds the chunks entirely filtered by the current run.
+	pending map[string]map[uint64]struct{}
+}
+
+func newLineRetention() *lineRetention {
+	return &lineRetention{
+		filtered: map[string]map[uint64]struct{}{},
+		pending:  map[string]map[uint64]struct{}{},
+	}
+}
+
+func lineRetentionKey(userID string, rule validation.StreamRetention) string {
+	return fmt.Sprintf("%s/%s/%s", userID, rule.Period, rule.Selector)
+}
+
+// filterFunc returns the filter.Func deleting the lines of the chunk matched by the rules,
+// or nil if there is nothing to delete from it.
+func (r *lineRetention) filterFunc(userID string, rules []validation.StreamRetention, ref ChunkEntry, now model.Time) filter.Func {
+	var filters []filter.Func
+	chunkID := xxhash.Sum64(ref.ChunkID)
+	for _, rule := range rules {
+		cutoff := now.Add(-time.Duration(rule.Period))
+		// the chunk has no line old enough to be deleted.
+		if !ref.From.Before(cutoff) {
+			continue
+		}
+
+		key := lineRetentionKey(userID, rule)
+		r.mtx.Lock()
+		_, filtered := r.filtered[key][chunkID]
+		// the chunk has no line left to be filtered by the next runs once all its
+		// lines are older than the cutoff.
+		if r.phaseStart != 0 && ref.Through.Before(cutoff) {
+			if r.pending[key] == nil {
+				r.pending[key] = map[uint64]struct{}{}
+			}
+			r.pending[key][chunkID] = struct{}{}
+		}
+		r.mtx.Unlock()
+
+		// the chunk was already filtered by the previous run.
+		if filtered {
+			continue
+		}
+
+		f, err := lineRetentionFilter(rule, ref.Labels, cutoff)
+		if err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to build line retention filter", "user", userID, "selector", rule.Selector, "err", err)
+			continue
+		}
+		filters = append(filters, f)
+	}
+
+	switch len(filters) {
+	case 0:
+		return nil
+	case 1:
+		return filters[0]
+	}
+	return func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		for _, f := range filters {
+			if f(ts, s, structuredMetadata...) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func lineRetentionFilter(rule validation.StreamRetention, lbls labels.Labels, cutoff model.Time) (filter.Func, error) {
+	p, err := rule.LogSelector.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+	process := p.ForStream(lbls).ProcessString
+	cutoffTime := cutoff.Time()
+	return func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		if !ts.Before(cutoffTime) {
+			return false
+		}
+		_, _, matches := process(ts.UnixNano(), s, structuredMetadata...)
+		return matches
+	}, nil
+}
+
+func (r *lineRetention) markPhaseStarted(now model.Time) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.phaseStart = now
+	r.timedOut = false
+	r.pending = map[string]map[uint64]struct{}{}
+}
+
+func (r *lineRetention) markPhaseTimedOut() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.timedOut = true
+}
+
+func (r *lineRetention) markPhaseEnded(success bool) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	// some chunks may not have been processed, they must be filtered again by the next run.
+	if success && !r.timedOut {
+		// the chunks which were deleted or rewritten are dropped.
+		r.filtered = r.pending
+	}
+	r.phaseStart = 0
+	r.pending = map[string]map[uint64]struct{}{}
+}
@@ -75,6 +75,7 @@ type table struct {
 	indexCompactor     IndexCompactor
 	tableMarker        retention.TableMarker
 	expirationChecker  tableExpirationChecker
+	coldStorage        *coldStorage
 	periodConfig       config.PeriodConfig
 
 	baseUserIndexSet, baseCommonIndexSet storage.IndexSet
@@ -89,7 +90,7 @@ type table struct {
 func newTable(ctx context.Context, workingDirectory string, indexStorageClient storage.Client,
 	indexCompactor IndexCompactor, periodConfig config.PeriodConfig,
 	tableMarker retention.TableMarker, expirationChecker tableExpirationChecker,
-	uploadConcurrency int,
+	coldStorage *coldStorage, uploadConcurrency int,
 ) (*table, error) {
 	err := chunk_util.EnsureDirectory(workingDirectory)
 	if err != nil {
@@ -104,6 +105,7 @@ func newTable(ctx context.Context, workingDirectory string, indexStorageClient s
 		indexCompactor:     indexCompactor,
 		tableMarker:        tableMarker,
 		expirationChecker:  expirationChecker,
+		coldStorage:        coldStorage,
 		periodConfig:       periodConfig,
 		indexSets:          map[string]*indexSet{},
 		baseUserIndexSet:   storage.NewIndexSet(indexStorageClient, true),
@@ -181,6 +183,12 @@ func (t *table) compact(applyRetention bool) error {
 		}
 	}
 
+	if t.coldStorage != nil {
+		if err := t.moveToColdStorage(); err != nil {
+			return err
+		}
+	}
+
 	return t.done()
 }
 
@@ -245,6 +253,36 @@ func (t *table) applyRetention() error {
 	return nil
 }
 
+// moveToColdStorage moves the chunks of the table to the cold storage once the table is old enough.
+func (t *table) moveToColdStorage() error {
+	needsMove, err := t.coldStorage.tableNeedsMove(t.ctx, t.name)
+	if err != nil || !needsMove {
+		return err
+	}
+
+	for userID, is := range t.indexSets {
+		// same as retention, skip the common index set which got compacted away to per-user index
+		if userID == "" && is.compactedIndex == nil && is.removeSourceObjects && !is.uploadCompactedDB {
+			continue
+		}
+
+		if is.compactedIndex == nil && len(is.ListSourceFiles()) == 1 {
+			if err := t.openCompactedIndexForRetention(is); err != nil {
+				return err
+			}
+		}
+		if is.compactedIndex == nil {
+			continue
+		}
+
+		if err := t.coldStorage.moveChunks(t.ctx, is.compactedIndex, is.logger); err != nil {
+			return err
+		}
+	}
+
+	return t.coldStorage.markTableMoved(t.ctx, t.name)
+}
+
 func (t *table) openCompactedIndexForRetention(idxSet *indexSet) error {
 	sourceFiles := idxSet.ListSourceFiles()
 	if len(sourceFiles) != 1 {
@@ -940,6 +940,10 @@ func (p *mockStreamExtractor) ReferencedStructuredMetadata() bool {
 	return false
 }
 
+func (p *mockStreamExtractor) NextSample() (float64, log.LabelsResult, bool) {
+	return p.wrappedSP.NextSample()
+}
+
 func (p *mockStreamExtractor) BaseLabels() log.LabelsResult {
 	return p.wrappedSP.BaseLabels()
 }
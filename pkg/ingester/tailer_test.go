@@ -370,6 +370,72 @@ func Test_StructuredMetadata(t *testing.T) {
 	}
 }
 
+func Test_TailerLineFilters(t *testing.T) {
+	t.Parallel()
+	lbs := makeRandomLabels()
+	stream := logproto.Stream{
+		Labels: lbs.String(),
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(0, 1), Line: "request TIMEOUT after 5s"},
+			{Timestamp: time.Unix(0, 2), Line: "request timout after 5s"},
+			{Timestamp: time.Unix(0, 3), Line: "connection refused"},
+		},
+	}
+
+	for _, tc := range []struct {
+		query    string
+		expected []logproto.Entry
+	}{
+		{
+			query:    `{app="foo"} |=* "timeout"`,
+			expected: stream.Entries[:1],
+		},
+		{
+			query:    `{app="foo"} !=* "timeout"`,
+			expected: stream.Entries[1:],
+		},
+		{
+			query:    `{app="foo"} |~~ "timeout" 1`,
+			expected: stream.Entries[1:2],
+		},
+		{
+			query:    `{app="foo"} !~~ "timeout" 1`,
+			expected: []logproto.Entry{stream.Entries[0], stream.Entries[2]},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			var server fakeTailServer
+			expr, err := syntax.ParseLogSelector(tc.query, true)
+			require.NoError(t, err)
+			tail, err := newTailer("foo", expr, &server, 10)
+			require.NoError(t, err)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				tail.loop()
+				wg.Done()
+			}()
+
+			tail.send(stream, lbs)
+
+			// Wait for the stream to be received by the server.
+			require.Eventually(t, func() bool {
+				return len(server.GetResponses()) > 0
+			}, 30*time.Second, 100*time.Millisecond, "stream was not received")
+
+			var entries []logproto.Entry
+			for _, response := range server.GetResponses() {
+				entries = append(entries, response.Stream.Entries...)
+			}
+			require.ElementsMatch(t, tc.expected, entries)
+
+			tail.close()
+			wg.Wait()
+		})
+	}
+}
+
 func Benchmark_isClosed(t *testing.B) {
 	var server fakeTailServer
 	expr, err := syntax.ParseLogSelector(`{app="foo"}`, true)
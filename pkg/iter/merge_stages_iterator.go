@@ -0,0 +1,213 @@
+package iter
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// MergeStages evaluates the dedup and limit stages of a log query, see syntax.HasMergeStages.
+// Those stages need the entries of all the streams in the query direction, so they are evaluated
+// over merged iterators.
+type MergeStages struct {
+	filters []entryFilter
+
+	// streams caches the parsed stream labels.
+	streams map[string]labels.Labels
+	builder *labels.Builder
+}
+
+// entryFilter returns true if the entry of the given series should be kept.
+type entryFilter interface {
+	keep(series labels.Labels, entry *logproto.Entry) bool
+}
+
+// NewMergeStages returns the dedup and limit stages of the log selector in order.
+func NewMergeStages(expr syntax.LogSelectorExpr) *MergeStages {
+	m := &MergeStages{
+		streams: map[string]labels.Labels{},
+		builder: labels.NewBuilder(labels.EmptyLabels()),
+	}
+	expr.Walk(func(e syntax.Expr) {
+		switch s := e.(type) {
+		case *syntax.DedupExpr:
+			m.filters = append(m.filters, &dedupFilter{
+				grouping: newEntryGrouping(s.Grouping),
+				within:   s.Within.Nanoseconds(),
+				window:   math.MinInt64,
+				seen:     map[uint64]struct{}{},
+			})
+		case *syntax.LimitByExpr:
+			m.filters = append(m.filters, &limitByFilter{
+				grouping: newEntryGrouping(s.Grouping),
+				limit:    s.Limit,
+				counts:   map[uint64]int{},
+			})
+		}
+	})
+	return m
+}
+
+// Wrap returns an iterator filtering the entries of it with the stages.
+func (m *MergeStages) Wrap(it EntryIterator) EntryIterator {
+	if len(m.filters) == 0 {
+		return it
+	}
+	return &mergeStagesIterator{
+		EntryIterator: it,
+		stages:        m,
+	}
+}
+
+func (m *MergeStages) keep(streamLabels string, entry *logproto.Entry) (bool, error) {
+	series, ok := m.streams[streamLabels]
+	if !ok {
+		lbs, err := syntax.ParseLabels(streamLabels)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse series labels for dedup and limit stages: %w", err)
+		}
+		m.streams[streamLabels] = lbs
+		series = lbs
+	}
+
+	// the structured metadata and parsed labels are not part of the stream labels when labels are categorized.
+	if len(entry.StructuredMetadata) > 0 || len(entry.Parsed) > 0 {
+		m.builder.Reset(series)
+		for _, l := range entry.StructuredMetadata {
+			m.builder.Set(l.Name, l.Value)
+		}
+		for _, l := range entry.Parsed {
+			m.builder.Set(l.Name, l.Value)
+		}
+		series = m.builder.Labels()
+	}
+
+	for _, f := range m.filters {
+		if !f.keep(series, entry) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+type mergeStagesIterator struct {
+	EntryIterator
+
+	stages *MergeStages
+	err    error
+}
+
+func (it *mergeStagesIterator) Next() bool {
+	for it.EntryIterator.Next() {
+		entry := it.EntryIterator.At()
+		ok, err := it.stages.keep(it.EntryIterator.Labels(), &entry)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *mergeStagesIterator) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.EntryIterator.Err()
+}
+
+// entryGrouping hashes the labels of the group of a series.
+type entryGrouping struct {
+	grouping *syntax.Grouping
+	buf      []byte
+}
+
+func newEntryGrouping(g *syntax.Grouping) entryGrouping {
+	if g == nil {
+		return entryGrouping{}
+	}
+	// hashing the labels requires sorted names.
+	groups := make([]string, len(g.Groups))
+	copy(groups, g.Groups)
+	sort.Strings(groups)
+	return entryGrouping{grouping: &syntax.Grouping{Groups: groups, Without: g.Without}}
+}
+
+func (g *entryGrouping) hash(series labels.Labels) uint64 {
+	var h uint64
+	switch {
+	case g.grouping == nil:
+		h = series.Hash()
+	case g.grouping.Without:
+		h, g.buf = series.HashWithoutLabels(g.buf, g.grouping.Groups...)
+	default:
+		h, g.buf = series.HashForLabels(g.buf, g.grouping.Groups...)
+	}
+	return h
+}
+
+// dedupFilter keeps the first entry of each line per group within each window.
+// Windows are aligned on the epoch.
+type dedupFilter struct {
+	grouping entryGrouping
+	within   int64
+
+	window int64
+	seen   map[uint64]struct{}
+	digest *xxhash.Digest
+	buf    [8]byte
+}
+
+func (f *dedupFilter) keep(series labels.Labels, entry *logproto.Entry) bool {
+	// entries are in order so the lines seen in the previous window are not needed anymore.
+	window := entry.Timestamp.UnixNano() / f.within
+	if entry.Timestamp.UnixNano()%f.within < 0 {
+		window--
+	}
+	if window != f.window {
+		f.window = window
+		clear(f.seen)
+	}
+
+	if f.digest == nil {
+		f.digest = xxhash.New()
+	}
+	f.digest.Reset()
+	binary.LittleEndian.PutUint64(f.buf[:], f.grouping.hash(series))
+	_, _ = f.digest.Write(f.buf[:])
+	_, _ = f.digest.WriteString(entry.Line)
+	key := f.digest.Sum64()
+
+	if _, ok := f.seen[key]; ok {
+		return false
+	}
+	f.seen[key] = struct{}{}
+	return true
+}
+
+// limitByFilter keeps the first entries of each group up to the limit.
+type limitByFilter struct {
+	grouping entryGrouping
+	limit    int
+
+	counts map[uint64]int
+}
+
+func (f *limitByFilter) keep(series labels.Labels, _ *logproto.Entry) bool {
+	key := f.grouping.hash(series)
+	if f.counts[key] >= f.limit {
+		return false
+	}
+	f.counts[key]++
+	return true
+}
@@ -0,0 +1,171 @@
+package iter
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+func mustMergeStages(t *testing.T, query string) *MergeStages {
+	t.Helper()
+	expr, err := syntax.ParseLogSelector(query, true)
+	require.NoError(t, err)
+	return NewMergeStages(expr)
+}
+
+func readEntries(t *testing.T, it EntryIterator) []string {
+	t.Helper()
+	var res []string
+	for it.Next() {
+		res = append(res, it.Labels()+" "+it.At().Line)
+	}
+	require.NoError(t, it.Err())
+	require.NoError(t, it.Close())
+	return res
+}
+
+// testStreams returns the streams with their entries in the given direction.
+func testStreams(direction logproto.Direction) []logproto.Stream {
+	streams := []logproto.Stream{
+		{
+			Labels: `{app="foo", pod="a"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "timeout"},
+				{Timestamp: time.Unix(3, 0), Line: "timeout"},
+				{Timestamp: time.Unix(5, 0), Line: "done"},
+				{Timestamp: time.Unix(12, 0), Line: "timeout"},
+			},
+		},
+		{
+			Labels: `{app="foo", pod="b"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(2, 0), Line: "timeout"},
+				{Timestamp: time.Unix(4, 0), Line: "retry"},
+				{Timestamp: time.Unix(6, 0), Line: "retry"},
+			},
+		},
+	}
+	if direction == logproto.BACKWARD {
+		for _, s := range streams {
+			slices.Reverse(s.Entries)
+		}
+	}
+	return streams
+}
+
+func TestMergeStages(t *testing.T) {
+	for _, tc := range []struct {
+		query     string
+		direction logproto.Direction
+		expected  []string
+	}{
+		{
+			query:     `{app="foo"}`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} timeout`,
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} timeout`,
+			},
+		},
+		{
+			query:     `{app="foo"} | dedup within 1m`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+			},
+		},
+		{
+			query:     `{app="foo"} | dedup by () within 1m`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+			},
+		},
+		{
+			query:     `{app="foo"} | dedup by (app) within 10s`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+				`{app="foo", pod="a"} timeout`,
+			},
+		},
+		{
+			query:     `{app="foo"} | dedup without (pod) within 10s`,
+			direction: logproto.BACKWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+				`{app="foo", pod="a"} timeout`,
+			},
+		},
+		{
+			query:     `{app="foo"} | limit 1`,
+			direction: logproto.BACKWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} retry`,
+			},
+		},
+		{
+			query:     `{app="foo"} | limit 2 by (app)`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} timeout`,
+			},
+		},
+		{
+			query:     `{app="foo"} | dedup within 1m | limit 2`,
+			direction: logproto.FORWARD,
+			expected: []string{
+				`{app="foo", pod="a"} timeout`,
+				`{app="foo", pod="b"} timeout`,
+				`{app="foo", pod="b"} retry`,
+				`{app="foo", pod="a"} done`,
+			},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			it := mustMergeStages(t, tc.query).Wrap(NewStreamsIterator(testStreams(tc.direction), tc.direction))
+			require.Equal(t, tc.expected, readEntries(t, it))
+		})
+	}
+}
+
+func TestMergeStages_CategorizedLabels(t *testing.T) {
+	stages := mustMergeStages(t, `{app="foo"} | limit 1 by (level)`)
+
+	it := stages.Wrap(NewStreamsIterator([]logproto.Stream{
+		{
+			Labels: `{app="foo"}`,
+			Entries: []logproto.Entry{
+				{Timestamp: time.Unix(1, 0), Line: "a", StructuredMetadata: []logproto.LabelAdapter{{Name: "level", Value: "info"}}},
+				{Timestamp: time.Unix(2, 0), Line: "b", Parsed: []logproto.LabelAdapter{{Name: "level", Value: "info"}}},
+				{Timestamp: time.Unix(3, 0), Line: "c", Parsed: []logproto.LabelAdapter{{Name: "level", Value: "error"}}},
+			},
+		},
+	}, logproto.FORWARD))
+
+	require.Equal(t, []string{
+		`{app="foo"} a`,
+		`{app="foo"} c`,
+	}, readEntries(t, it))
+}
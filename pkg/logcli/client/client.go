@@ -38,6 +38,7 @@ const (
 	volumePath         = "/loki/api/v1/index/volume"
 	volumeRangePath    = "/loki/api/v1/index/volume_range"
 	detectedFieldsPath = "/loki/api/v1/detected_fields"
+	lintPath           = "/loki/api/v1/lint"
 	defaultAuthHeader  = "Authorization"
 
 	// HTTP header keys
@@ -62,6 +63,7 @@ type Client interface {
 	GetVolume(query *volume.Query) (*loghttp.QueryResponse, error)
 	GetVolumeRange(query *volume.Query) (*loghttp.QueryResponse, error)
 	GetDetectedFields(queryStr string, fieldLimit, lineLimit int, start, end time.Time, step time.Duration, quiet bool) (*loghttp.DetectedFieldsResponse, error)
+	LintQuery(queryStr string, start, end time.Time, quiet bool) (*loghttp.LintQueryResponse, error)
 }
 
 // Tripperware can wrap a roundtripper.
@@ -84,6 +86,7 @@ type DefaultClient struct {
 	Retries         int
 	QueryTags       string
 	NoCache         bool
+	ExplainAnalyze  bool
 	AuthHeader      string
 	ProxyURL        string
 	BackoffConfig   BackoffConfig
@@ -98,6 +101,9 @@ func (c *DefaultClient) Query(queryStr string, limit int, time time.Time, direct
 	qsb.SetInt("limit", int64(limit))
 	qsb.SetInt("time", time.UnixNano())
 	qsb.SetString("direction", direction.String())
+	if c.ExplainAnalyze {
+		qsb.SetString("explain", "analyze")
+	}
 
 	return c.doQuery(queryPath, qsb.Encode(), quiet)
 }
@@ -123,6 +129,10 @@ func (c *DefaultClient) QueryRange(queryStr string, limit int, start, end time.T
 		params.SetFloat("interval", interval.Seconds())
 	}
 
+	if c.ExplainAnalyze {
+		params.SetString("explain", "analyze")
+	}
+
 	return c.doQuery(queryRangePath, params.Encode(), quiet)
 }
 
@@ -195,6 +205,20 @@ func (c *DefaultClient) GetStats(queryStr string, start, end time.Time, quiet bo
 	return &statsResponse, nil
 }
 
+// LintQuery uses the /loki/api/v1/lint endpoint to analyze a query and estimate its cost
+func (c *DefaultClient) LintQuery(queryStr string, start, end time.Time, quiet bool) (*loghttp.LintQueryResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetInt("start", start.UnixNano())
+	params.SetInt("end", end.UnixNano())
+	params.SetString("query", queryStr)
+
+	var lintResponse loghttp.LintQueryResponse
+	if err := c.doRequest(lintPath, params.Encode(), quiet, &lintResponse); err != nil {
+		return nil, err
+	}
+	return &lintResponse, nil
+}
+
 func (c *DefaultClient) GetVolume(query *volume.Query) (*loghttp.QueryResponse, error) {
 	return c.getVolume(volumePath, query)
 }
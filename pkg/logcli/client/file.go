@@ -17,6 +17,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
 	logqllog "github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/util/log"
 	"github.com/grafana/loki/v3/pkg/util/marshal"
 	"github.com/grafana/loki/v3/pkg/util/validation"
@@ -217,6 +218,18 @@ func (f *FileClient) GetDetectedFields(
 	return nil, ErrNotSupported
 }
 
+// LintQuery analyzes the query locally, there is no index to estimate its cost from.
+func (f *FileClient) LintQuery(queryStr string, _, _ time.Time, _ bool) (*loghttp.LintQueryResponse, error) {
+	findings, err := syntax.Lint(queryStr)
+	if err != nil {
+		return &loghttp.LintQueryResponse{Status: "invalid-query", Err: err.Error()}, nil
+	}
+	return &loghttp.LintQueryResponse{
+		Status: "success",
+		Data:   &loghttp.LintQueryData{Findings: findings},
+	}, nil
+}
+
 type limiter struct {
 	n int
 }
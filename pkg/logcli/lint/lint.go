@@ -0,0 +1,79 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+
+	"github.com/grafana/loki/v3/pkg/logcli/client"
+	"github.com/grafana/loki/v3/pkg/loghttp"
+)
+
+type Query struct {
+	QueryString string
+	Start       time.Time
+	End         time.Time
+	Quiet       bool
+}
+
+// Do lints the query and prints out the findings and cost estimate
+func (q *Query) Do(c client.Client, outputMode string, w io.Writer) {
+	resp, err := c.LintQuery(q.QueryString, q.Start, q.End, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error doing request: %+v", err)
+	}
+	if resp.Err != "" {
+		log.Fatalf("Invalid query: %s", resp.Err)
+	}
+
+	switch outputMode {
+	case "raw":
+		out, err := json.Marshal(resp)
+		if err != nil {
+			log.Fatalf("Error marshalling response: %+v", err)
+		}
+		fmt.Fprintln(w, string(out))
+	default:
+		Print(w, resp.Data)
+	}
+}
+
+// Print writes the lint findings, warnings and cost estimate in a human readable form.
+func Print(w io.Writer, data *loghttp.LintQueryData) {
+	if data == nil {
+		return
+	}
+
+	if len(data.Findings) == 0 {
+		fmt.Fprintln(w, "no issues found")
+	}
+	for _, f := range data.Findings {
+		severity := color.YellowString(f.Severity)
+		if f.Severity != "warning" {
+			severity = color.BlueString(f.Severity)
+		}
+		fmt.Fprintf(w, "%d:%d: %s %s: %s\n", f.Position.Line, f.Position.Column, severity, f.Rule, f.Message)
+		if f.Suggestion != "" {
+			fmt.Fprintf(w, "\tsuggestion: %s\n", f.Suggestion)
+		}
+	}
+
+	for _, warning := range data.Warnings {
+		fmt.Fprintf(w, "%s: %s\n", color.YellowString("warning"), warning)
+	}
+
+	if e := data.Estimate; e != nil {
+		fmt.Fprintf(w, "\n%s\n", color.New(color.Bold).Sprint("estimate:"))
+		fmt.Fprintf(w, "  %s: %s\n", color.BlueString("bytes"), strings.Replace(humanize.Bytes(e.Bytes), " ", "", 1))
+		fmt.Fprintf(w, "  %s: %d\n", color.BlueString("chunks"), e.Chunks)
+		fmt.Fprintf(w, "  %s: %d\n", color.BlueString("streams"), e.Streams)
+		fmt.Fprintf(w, "  %s: %d\n", color.BlueString("entries"), e.Entries)
+		fmt.Fprintf(w, "  %s: %d\n", color.BlueString("shards"), e.Shards)
+	}
+}
@@ -8,13 +8,17 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 
 	"github.com/grafana/loki/v3/pkg/logcli/output"
 	"github.com/grafana/loki/v3/pkg/logcli/util"
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 )
 
@@ -212,6 +216,53 @@ func (r *QueryResultPrinter) PrintStats(stats stats.Result) {
 	stats.Log(kvLogger{Writer: writer})
 }
 
+// PrintExplain prints the execution plan of a query to stderr.
+func (r *QueryResultPrinter) PrintExplain(plan *explain.Node) {
+	if plan == nil {
+		return
+	}
+	fmt.Fprint(os.Stderr, FormatExplain(plan))
+}
+
+// FormatExplain renders an execution plan as a tree, one node per line
+// followed by its attributes and statistics.
+func FormatExplain(plan *explain.Node) string {
+	tree := logql.NewTree()
+	formatExplainNode(tree, plan)
+	return tree.String()
+}
+
+func formatExplainNode(parent logql.Node, n *explain.Node) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] %s", n.Type, n.Query)
+	fmt.Fprintf(&sb, "\nrange: %s - %s, time: %s", n.Start.UTC().Format(time.RFC3339), n.End.UTC().Format(time.RFC3339), time.Duration(n.ExecTime*float64(time.Second)).Round(time.Microsecond))
+
+	keys := make([]string, 0, len(n.Attributes))
+	for k := range n.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "\n%s: %s", k, n.Attributes[k])
+	}
+
+	if s := n.Stats; s != nil {
+		fmt.Fprintf(&sb, "\nbytes: %s, lines: %d, chunk refs: %d, chunks downloaded: %d",
+			strings.Replace(humanize.Bytes(uint64(s.BytesProcessed)), " ", "", 1), s.LinesProcessed, s.ChunkRefs, s.ChunksDownloaded)
+		if s.IndexChunks > 0 {
+			fmt.Fprintf(&sb, "\nindex chunks: %d, after bloom filtering: %d", s.IndexChunks, s.IndexPostFilterChunks)
+		}
+		if s.ResultCacheRequests > 0 {
+			fmt.Fprintf(&sb, "\nresult cache hits: %d/%d", s.ResultCacheHits, s.ResultCacheRequests)
+		}
+	}
+
+	node := parent.Child(sb.String())
+	for _, c := range n.Children {
+		formatExplainNode(node, c)
+	}
+}
+
 func matchLabels(on bool, l loghttp.LabelSet, names []string) loghttp.LabelSet {
 	return util.MatchLabels(on, l, names)
 }
@@ -3,10 +3,12 @@ package print
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/util/marshal"
 )
 
@@ -73,6 +75,41 @@ func Test_commonLabels(t *testing.T) {
 	}
 }
 
+func TestFormatExplain(t *testing.T) {
+	start := time.Unix(0, 0)
+	plan := &explain.Node{
+		Type:     explain.TypeQuery,
+		Query:    `{app="foo"}`,
+		Start:    start,
+		End:      start.Add(time.Hour),
+		ExecTime: 1.5,
+		Stats:    &explain.Stats{BytesProcessed: 2048, LinesProcessed: 10, ChunkRefs: 3, ChunksDownloaded: 2},
+		Children: []*explain.Node{
+			{
+				Type:       explain.TypeSplit,
+				Query:      `{app="foo"}`,
+				Start:      start,
+				End:        start.Add(time.Hour),
+				ExecTime:   0.5,
+				Attributes: map[string]string{"cache.results": "miss", "sharding": "noop"},
+				Stats:      &explain.Stats{IndexChunks: 4, IndexPostFilterChunks: 3, ResultCacheHits: 0, ResultCacheRequests: 1},
+			},
+		},
+	}
+
+	require.Equal(t, `[query] {app="foo"}
+range: 1970-01-01T00:00:00Z - 1970-01-01T01:00:00Z, time: 1.5s
+bytes: 2.0kB, lines: 10, chunk refs: 3, chunks downloaded: 2
+ └── [split] {app="foo"}
+     range: 1970-01-01T00:00:00Z - 1970-01-01T01:00:00Z, time: 500ms
+     cache.results: miss
+     sharding: noop
+     bytes: 0B, lines: 0, chunk refs: 0, chunks downloaded: 0
+     index chunks: 4, after bloom filtering: 3
+     result cache hits: 0/1
+`, FormatExplain(plan))
+}
+
 func Test_subtract(t *testing.T) {
 	type args struct {
 		a loghttp.LabelSet
@@ -495,6 +495,10 @@ func (t *testQueryClient) GetDetectedFields(
 	panic("not implemented")
 }
 
+func (t *testQueryClient) LintQuery(_ string, _, _ time.Time, _ bool) (*loghttp.LintQueryResponse, error) {
+	panic("not implemented")
+}
+
 var legacySchemaConfigContents = `schema_config:
   configs:
   - from: 2020-05-15
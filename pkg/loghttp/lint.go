@@ -0,0 +1,26 @@
+package loghttp
+
+import "github.com/grafana/loki/v3/pkg/logql/syntax"
+
+// LintQueryResponse represents the http json response to a lint query
+type LintQueryResponse struct {
+	Status string         `json:"status"`
+	Data   *LintQueryData `json:"data,omitempty"`
+	Err    string         `json:"error,omitempty"`
+}
+
+type LintQueryData struct {
+	Findings []syntax.LintFinding `json:"findings"`
+	Estimate *LintQueryEstimate   `json:"estimate,omitempty"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+// LintQueryEstimate is the amount of data the index reports for the query's
+// matcher groups over the requested time range.
+type LintQueryEstimate struct {
+	Streams uint64 `json:"streams"`
+	Chunks  uint64 `json:"chunks"`
+	Entries uint64 `json:"entries"`
+	Bytes   uint64 `json:"bytes"`
+	Shards  int    `json:"shards"`
+}
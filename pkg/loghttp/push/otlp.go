@@ -30,6 +30,7 @@ const (
 	attrServiceName     = "service.name"
 
 	OTLPSeverityNumber = "severity_number"
+	OTLPSeverityText   = "severity_text"
 )
 
 func newPushStats() *Stats {
@@ -125,7 +126,7 @@ func otlpToLokiPushRequest(ctx context.Context, ld plog.Logs, userID string, ten
 				return true
 			}
 
-			attributeAsLabels := attributeToLabels(k, v, "")
+			attributeAsLabels := AttributeToLabels(k, v, "")
 			if action == IndexLabel {
 				for _, lbl := range attributeAsLabels {
 					streamLabels[model.LabelName(lbl.Name)] = model.LabelValue(lbl.Value)
@@ -196,7 +197,7 @@ func otlpToLokiPushRequest(ctx context.Context, ld plog.Logs, userID string, ten
 					return true
 				}
 
-				attributeAsLabels := attributeToLabels(k, v, "")
+				attributeAsLabels := AttributeToLabels(k, v, "")
 				if action == StructuredMetadata {
 					scopeAttributesAsStructuredMetadata = append(scopeAttributesAsStructuredMetadata, attributeAsLabels...)
 				}
@@ -233,7 +234,7 @@ func otlpToLokiPushRequest(ctx context.Context, ld plog.Logs, userID string, ten
 			for k := 0; k < logs.Len(); k++ {
 				log := logs.At(k)
 
-				entry := otlpLogToPushEntry(log, otlpConfig)
+				entry := OTLPLogToPushEntry(log, otlpConfig, OTLPSeverityText)
 
 				// if entry.StructuredMetadata doesn't have capacity to add resource and scope attributes, make a new slice with enough capacity
 				attributesAsStructuredMetadataLen := len(resourceAttributesAsStructuredMetadata) + len(scopeAttributesAsStructuredMetadata)
@@ -277,8 +278,9 @@ func otlpToLokiPushRequest(ctx context.Context, ld plog.Logs, userID string, ten
 	return pr
 }
 
-// otlpLogToPushEntry converts an OTLP log record to a Loki push.Entry.
-func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
+// OTLPLogToPushEntry converts an OTLP log record to a Loki push.Entry, the
+// severity text being stored as the structured metadata severityTextKey.
+func OTLPLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig, severityTextKey string) push.Entry {
 	// copy log attributes and all the fields from log(except log.Body) to structured metadata
 	logAttrs := log.Attributes()
 	structuredMetadata := make(push.LabelsAdapter, 0, logAttrs.Len()+7)
@@ -288,7 +290,7 @@ func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
 			return true
 		}
 
-		attributeAsLabels := attributeToLabels(k, v, "")
+		attributeAsLabels := AttributeToLabels(k, v, "")
 		if action == StructuredMetadata {
 			structuredMetadata = append(structuredMetadata, attributeAsLabels...)
 		}
@@ -312,7 +314,7 @@ func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
 	}
 	if severityText := log.SeverityText(); severityText != "" {
 		structuredMetadata = append(structuredMetadata, push.LabelAdapter{
-			Name:  "severity_text",
+			Name:  severityTextKey,
 			Value: severityText,
 		})
 	}
@@ -350,21 +352,25 @@ func otlpLogToPushEntry(log plog.LogRecord, otlpConfig OTLPConfig) push.Entry {
 	}
 }
 
-func attributesToLabels(attrs pcommon.Map, prefix string) push.LabelsAdapter {
+// AttributesToLabels converts the attributes to labels, their names being
+// prefixed by the prefix when not empty.
+func AttributesToLabels(attrs pcommon.Map, prefix string) push.LabelsAdapter {
 	labelsAdapter := make(push.LabelsAdapter, 0, attrs.Len())
 	if attrs.Len() == 0 {
 		return labelsAdapter
 	}
 
 	attrs.Range(func(k string, v pcommon.Value) bool {
-		labelsAdapter = append(labelsAdapter, attributeToLabels(k, v, prefix)...)
+		labelsAdapter = append(labelsAdapter, AttributeToLabels(k, v, prefix)...)
 		return true
 	})
 
 	return labelsAdapter
 }
 
-func attributeToLabels(k string, v pcommon.Value, prefix string) push.LabelsAdapter {
+// AttributeToLabels converts the attribute to labels with normalized names,
+// the maps being flattened with their keys prefixed by the name of the attribute.
+func AttributeToLabels(k string, v pcommon.Value, prefix string) push.LabelsAdapter {
 	var labelsAdapter push.LabelsAdapter
 
 	keyWithPrefix := k
@@ -378,7 +384,7 @@ func attributeToLabels(k string, v pcommon.Value, prefix string) push.LabelsAdap
 		mv := v.Map()
 		labelsAdapter = make(push.LabelsAdapter, 0, mv.Len())
 		mv.Range(func(k string, v pcommon.Value) bool {
-			labelsAdapter = append(labelsAdapter, attributeToLabels(k, v, keyWithPrefix)...)
+			labelsAdapter = append(labelsAdapter, AttributeToLabels(k, v, keyWithPrefix)...)
 			return true
 		})
 	} else {
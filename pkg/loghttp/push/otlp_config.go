@@ -45,31 +45,35 @@ type OTLPConfig struct {
 	LogAttributes      []AttributesConfig       `yaml:"log_attributes,omitempty" doc:"description=Configuration for log attributes to store them as Structured Metadata or drop them altogether"`
 }
 
+// DefaultOTLPResourceAttributesAsIndexLabels are the resource attributes
+// stored as index labels by default.
+var DefaultOTLPResourceAttributesAsIndexLabels = []string{
+	"service.name",
+	"service.namespace",
+	"service.instance.id",
+	"deployment.environment",
+	"cloud.region",
+	"cloud.availability_zone",
+	"k8s.cluster.name",
+	"k8s.namespace.name",
+	"k8s.pod.name",
+	"k8s.container.name",
+	"container.name",
+	"k8s.replicaset.name",
+	"k8s.deployment.name",
+	"k8s.statefulset.name",
+	"k8s.daemonset.name",
+	"k8s.cronjob.name",
+	"k8s.job.name",
+}
+
 type GlobalOTLPConfig struct {
 	DefaultOTLPResourceAttributesAsIndexLabels []string `yaml:"default_resource_attributes_as_index_labels"`
 }
 
 // RegisterFlags registers distributor-related flags.
 func (cfg *GlobalOTLPConfig) RegisterFlags(fs *flag.FlagSet) {
-	cfg.DefaultOTLPResourceAttributesAsIndexLabels = []string{
-		"service.name",
-		"service.namespace",
-		"service.instance.id",
-		"deployment.environment",
-		"cloud.region",
-		"cloud.availability_zone",
-		"k8s.cluster.name",
-		"k8s.namespace.name",
-		"k8s.pod.name",
-		"k8s.container.name",
-		"container.name",
-		"k8s.replicaset.name",
-		"k8s.deployment.name",
-		"k8s.statefulset.name",
-		"k8s.daemonset.name",
-		"k8s.cronjob.name",
-		"k8s.job.name",
-	}
+	cfg.DefaultOTLPResourceAttributesAsIndexLabels = append([]string(nil), DefaultOTLPResourceAttributesAsIndexLabels...)
 	fs.Var((*flagext.StringSlice)(&cfg.DefaultOTLPResourceAttributesAsIndexLabels), "distributor.otlp.default_resource_attributes_as_index_labels", "List of default otlp resource attributes to be picked as index labels")
 }
 
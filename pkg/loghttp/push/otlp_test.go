@@ -604,7 +604,7 @@ func TestOTLPLogToPushEntry(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.expectedResp, otlpLogToPushEntry(tc.buildLogRecord(), DefaultOTLPConfig(defaultGlobalOTLPConfig)))
+			require.Equal(t, tc.expectedResp, OTLPLogToPushEntry(tc.buildLogRecord(), DefaultOTLPConfig(defaultGlobalOTLPConfig), OTLPSeverityText))
 		})
 	}
 }
@@ -711,7 +711,7 @@ func TestAttributesToLabels(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			require.Equal(t, tc.expectedResp, attributesToLabels(tc.buildAttrs(), ""))
+			require.Equal(t, tc.expectedResp, AttributesToLabels(tc.buildAttrs(), ""))
 		})
 	}
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index/seriesvolume"
 	"github.com/grafana/loki/v3/pkg/util"
@@ -48,6 +49,8 @@ type QueryResponse struct {
 	Status   string            `json:"status"`
 	Warnings []string          `json:"warnings,omitempty"`
 	Data     QueryResponseData `json:"data"`
+	// Explain is the execution plan of the query, only set when requested with `explain=analyze`.
+	Explain *explain.Node `json:"explain,omitempty"`
 }
 
 func (q *QueryResponse) UnmarshalJSON(data []byte) error {
@@ -72,6 +75,12 @@ func (q *QueryResponse) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			q.Data = responseData
+		case "explain":
+			var plan explain.Node
+			if err := json.Unmarshal(value, &plan); err != nil {
+				return err
+			}
+			q.Explain = &plan
 		}
 		return nil
 	})
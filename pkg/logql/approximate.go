@@ -0,0 +1,207 @@
+package logql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+)
+
+// ErrChunkStatsUnavailable is returned by a ChunkStatsQuerier when the index alone
+// cannot answer for the requested range, for instance because part of it is still
+// served by ingesters. The evaluator then falls back to reading chunks.
+var ErrChunkStatsUnavailable = errors.New("chunk statistics are not available for the requested range")
+
+// ChunkStats holds the statistics the index keeps about a single chunk.
+type ChunkStats struct {
+	From, Through model.Time
+	Entries       uint64
+	Bytes         uint64
+}
+
+// StreamChunkStats holds the statistics of all the chunks of a single stream.
+type StreamChunkStats struct {
+	Labels labels.Labels
+	Chunks []ChunkStats
+}
+
+// ChunkStatsQuerier is implemented by queriers able to serve the statistics of
+// the chunks matching a sample query straight from the index, without fetching them.
+type ChunkStatsQuerier interface {
+	SelectChunkStats(context.Context, SelectSampleParams) ([]StreamChunkStats, error)
+}
+
+// approximableFromIndex returns true if the range aggregation only counts the entries
+// or the bytes of whole streams at a resolution coarse enough for the chunk statistics
+// in the index to give a meaningful approximation.
+func approximableFromIndex(expr *syntax.RangeAggregationExpr, q Params, minStep time.Duration) bool {
+	switch expr.Operation {
+	case syntax.OpRangeTypeCount, syntax.OpRangeTypeBytes:
+	default:
+		return false
+	}
+	if expr.Grouping != nil || expr.Left.Unwrap != nil {
+		return false
+	}
+	// any pipeline stage, including line filters, requires reading the lines.
+	if _, ok := expr.Left.Left.(*syntax.MatchersExpr); !ok {
+		return false
+	}
+	if expr.Left.Interval < minStep {
+		return false
+	}
+	return GetRangeType(q) == InstantType || q.Step() >= minStep
+}
+
+// newIndexStatsEvaluator returns a StepEvaluator answering the range aggregation from
+// the chunk statistics in the index. It returns false if the expression or the
+// querier does not allow it, in which case the caller must evaluate the expression
+// from the chunks.
+func (ev *DefaultEvaluator) newIndexStatsEvaluator(ctx context.Context, expr *syntax.RangeAggregationExpr, q Params) (StepEvaluator, bool, error) {
+	if !ev.approximateFromIndex {
+		return nil, false, nil
+	}
+	querier, ok := ev.querier.(ChunkStatsQuerier)
+	if !ok || !approximableFromIndex(expr, q, ev.approximateMinStep) {
+		return nil, false, nil
+	}
+
+	streams, err := querier.SelectChunkStats(ctx, SelectSampleParams{
+		&logproto.SampleQueryRequest{
+			Start:    q.Start().Add(-expr.Left.Interval).Add(-expr.Left.Offset),
+			End:      q.End().Add(-expr.Left.Offset).Add(time.Nanosecond),
+			Selector: expr.String(),
+			Shards:   q.Shards(),
+			Plan: &plan.QueryPlan{
+				AST: expr,
+			},
+			StoreChunks: q.GetStoreChunks(),
+		},
+	})
+	if errors.Is(err, ErrChunkStatsUnavailable) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	stats.FromContext(ctx).SetApproximate()
+
+	m := chunkStatsMatrix(streams, expr, q)
+	if GetRangeType(q) == InstantType {
+		vec := make(promql.Vector, 0, len(m))
+		for _, s := range m {
+			vec = append(vec, promql.Sample{Metric: s.Metric, T: s.Floats[0].T, F: s.Floats[0].F})
+		}
+		return NewVectorStepEvaluator(q.Start(), vec), true, nil
+	}
+	return NewMatrixStepEvaluator(q.Start(), q.End(), q.Step(), m), true, nil
+}
+
+// chunkStatsMatrix computes the result of a count_over_time or bytes_over_time
+// range aggregation from chunk statistics. Since only the time bounds of a chunk
+// are known, its entries and bytes are assumed to be evenly spread between them,
+// and each range window receives the share of the chunk it overlaps.
+func chunkStatsMatrix(streams []StreamChunkStats, expr *syntax.RangeAggregationExpr, q Params) promql.Matrix {
+	var (
+		start    = q.Start().UnixMilli()
+		end      = q.End().UnixMilli()
+		step     = q.Step().Milliseconds()
+		selRange = expr.Left.Interval.Milliseconds()
+		offset   = expr.Left.Offset.Milliseconds()
+	)
+	// forces at least one step.
+	if step == 0 {
+		step = 1
+	}
+	steps := int((end-start)/step) + 1
+
+	type series struct {
+		metric labels.Labels
+		values []float64
+		seen   []bool
+	}
+	bySeries := make(map[uint64]*series, len(streams))
+	order := make([]uint64, 0, len(streams))
+
+	for _, stream := range streams {
+		hash := stream.Labels.Hash()
+		s, ok := bySeries[hash]
+		if !ok {
+			s = &series{
+				metric: stream.Labels,
+				values: make([]float64, steps),
+				seen:   make([]bool, steps),
+			}
+			bySeries[hash] = s
+			order = append(order, hash)
+		}
+
+		for _, chk := range stream.Chunks {
+			value := float64(chk.Entries)
+			if expr.Operation == syntax.OpRangeTypeBytes {
+				value = float64(chk.Bytes)
+			}
+			from, through := int64(chk.From), int64(chk.Through)
+
+			// skip the steps whose window ends before the chunk starts.
+			first := 0
+			if d := from + offset - start; d > 0 {
+				first = int((d + step - 1) / step)
+			}
+			for i := first; i < steps; i++ {
+				windowEnd := start + int64(i)*step - offset
+				windowStart := windowEnd - selRange
+				if windowStart >= through {
+					break
+				}
+				share := overlap(from, through, windowStart, windowEnd)
+				if share == 0 {
+					continue
+				}
+				s.values[i] += value * share
+				s.seen[i] = true
+			}
+		}
+	}
+
+	m := make(promql.Matrix, 0, len(order))
+	for _, hash := range order {
+		s := bySeries[hash]
+		var points []promql.FPoint
+		for i, seen := range s.seen {
+			if !seen {
+				continue
+			}
+			points = append(points, promql.FPoint{T: start + int64(i)*step, F: s.values[i]})
+		}
+		if len(points) == 0 {
+			continue
+		}
+		m = append(m, promql.Series{Metric: s.metric, Floats: points})
+	}
+	return m
+}
+
+// overlap returns the fraction of the chunk [from, through] that falls within the
+// range window (windowStart, windowEnd].
+func overlap(from, through, windowStart, windowEnd int64) float64 {
+	if from == through {
+		if from > windowStart && from <= windowEnd {
+			return 1
+		}
+		return 0
+	}
+	lo, hi := max(from, windowStart), min(through, windowEnd)
+	if hi <= lo {
+		return 0
+	}
+	return float64(hi-lo) / float64(through-from)
+}
@@ -0,0 +1,184 @@
+package logql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+type chunkStatsQuerier struct {
+	streams []StreamChunkStats
+	err     error
+	samples int
+}
+
+func (q *chunkStatsQuerier) SelectLogs(context.Context, SelectLogParams) (iter.EntryIterator, error) {
+	return iter.NoopEntryIterator, nil
+}
+
+func (q *chunkStatsQuerier) SelectSamples(context.Context, SelectSampleParams) (iter.SampleIterator, error) {
+	q.samples++
+	return iter.NoopSampleIterator, nil
+}
+
+func (q *chunkStatsQuerier) SelectChunkStats(_ context.Context, params SelectSampleParams) ([]StreamChunkStats, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	selector, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	var res []StreamChunkStats
+outer:
+	for _, s := range q.streams {
+		for _, m := range selector.Matchers() {
+			if !m.Matches(s.Labels.Get(m.Name)) {
+				continue outer
+			}
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+func TestEngine_ApproximateFromIndex(t *testing.T) {
+	start := time.Unix(0, 0)
+	hour := time.Hour.Milliseconds()
+	streams := []StreamChunkStats{
+		{
+			Labels: labels.FromStrings("app", "foo", "pod", "a"),
+			Chunks: []ChunkStats{
+				// fully within the first hour.
+				{From: model.Time(hour / 4), Through: model.Time(hour / 2), Entries: 10, Bytes: 1024},
+				// spread evenly over the second and third hours.
+				{From: model.Time(hour), Through: model.Time(3 * hour), Entries: 20, Bytes: 2048},
+			},
+		},
+		{
+			Labels: labels.FromStrings("app", "foo", "pod", "b"),
+			Chunks: []ChunkStats{
+				{From: model.Time(2 * hour), Through: model.Time(2 * hour), Entries: 5, Bytes: 512},
+			},
+		},
+		{
+			Labels: labels.FromStrings("app", "bar", "pod", "c"),
+			Chunks: []ChunkStats{
+				{From: model.Time(hour / 2), Through: model.Time(hour), Entries: 1, Bytes: 100},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		qs          string
+		step        time.Duration
+		approximate bool
+		expected    promql.Matrix
+	}{
+		{
+			name:        "sum by count_over_time",
+			qs:          `sum by (app) (count_over_time({app=~".+"}[1h]))`,
+			step:        time.Hour,
+			approximate: true,
+			expected: promql.Matrix{
+				{Metric: labels.FromStrings("app", "bar"), Floats: []promql.FPoint{{T: hour, F: 1}}},
+				{Metric: labels.FromStrings("app", "foo"), Floats: []promql.FPoint{{T: hour, F: 10}, {T: 2 * hour, F: 15}, {T: 3 * hour, F: 10}}},
+			},
+		},
+		{
+			name:        "bytes_over_time",
+			qs:          `bytes_over_time({app="foo"}[1h])`,
+			step:        time.Hour,
+			approximate: true,
+			expected: promql.Matrix{
+				{Metric: labels.FromStrings("app", "foo", "pod", "a"), Floats: []promql.FPoint{{T: hour, F: 1024}, {T: 2 * hour, F: 1024}, {T: 3 * hour, F: 1024}}},
+				{Metric: labels.FromStrings("app", "foo", "pod", "b"), Floats: []promql.FPoint{{T: 2 * hour, F: 512}}},
+			},
+		},
+		{
+			name: "step below chunk granularity",
+			qs:   `sum(count_over_time({app="foo"}[1h]))`,
+			step: time.Minute,
+		},
+		{
+			name: "line filter",
+			qs:   `sum(count_over_time({app="foo"} |= "error" [1h]))`,
+			step: time.Hour,
+		},
+		{
+			name: "rate",
+			qs:   `sum(rate({app="foo"}[1h]))`,
+			step: time.Hour,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &chunkStatsQuerier{streams: streams}
+			eng := NewEngine(EngineOpts{ApproximateFromIndex: true, ApproximateFromIndexMinStep: time.Hour}, q, NoLimits, log.NewNopLogger())
+
+			params, err := NewLiteralParams(tc.qs, start.Add(time.Hour), start.Add(3*time.Hour), tc.step, 0, logproto.FORWARD, 0, nil, nil)
+			require.NoError(t, err)
+			res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+			require.NoError(t, err)
+
+			require.Equal(t, tc.approximate, res.Statistics.Summary.Approximate)
+			if !tc.approximate {
+				require.Equal(t, 1, q.samples)
+				return
+			}
+			require.Equal(t, 0, q.samples)
+			require.Equal(t, tc.expected, res.Data)
+		})
+	}
+}
+
+func TestEngine_ApproximateFromIndexFallback(t *testing.T) {
+	q := &chunkStatsQuerier{err: ErrChunkStatsUnavailable}
+	eng := NewEngine(EngineOpts{ApproximateFromIndex: true, ApproximateFromIndexMinStep: time.Hour}, q, NoLimits, log.NewNopLogger())
+
+	params, err := NewLiteralParams(`sum(count_over_time({app="foo"}[1h]))`, time.Unix(0, 0), time.Unix(0, 0).Add(3*time.Hour), time.Hour, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+	res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+	require.NoError(t, err)
+	require.False(t, res.Statistics.Summary.Approximate)
+	require.Equal(t, 1, q.samples)
+}
+
+func TestEngine_ApproximateFromIndexDisabled(t *testing.T) {
+	q := &chunkStatsQuerier{}
+	eng := NewEngine(EngineOpts{}, q, NoLimits, log.NewNopLogger())
+
+	params, err := NewLiteralParams(`count_over_time({app="foo"}[1h])`, time.Unix(3600, 0), time.Unix(3600, 0), 0, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+	res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+	require.NoError(t, err)
+	require.False(t, res.Statistics.Summary.Approximate)
+	require.Equal(t, 1, q.samples)
+}
+
+func TestOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		from, through, windowStart, windowEnd int64
+		expected                              float64
+	}{
+		{0, 10, 0, 10, 1},
+		{0, 10, 5, 20, 0.5},
+		{0, 10, -5, 2, 0.2},
+		{0, 10, 10, 20, 0},
+		{0, 10, 20, 30, 0},
+		{5, 5, 0, 5, 1},
+		{5, 5, 5, 10, 0},
+	} {
+		require.Equal(t, tc.expected, overlap(tc.from, tc.through, tc.windowStart, tc.windowEnd))
+	}
+}
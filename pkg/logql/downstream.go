@@ -67,7 +67,7 @@ func (ng *DownstreamEngine) Query(ctx context.Context, p Params) Query {
 	return &query{
 		logger:    ng.logger,
 		params:    p,
-		evaluator: NewDownstreamEvaluator(ng.downstreamable.Downstreamer(ctx)),
+		evaluator: newDownstreamEvaluator(ng.downstreamable.Downstreamer(ctx), ng.opts),
 		limits:    ng.limits,
 	}
 }
@@ -433,9 +433,15 @@ func (errorQuerier) SelectSamples(_ context.Context, _ SelectSampleParams) (iter
 }
 
 func NewDownstreamEvaluator(downstreamer Downstreamer) *DownstreamEvaluator {
+	return newDownstreamEvaluator(downstreamer, EngineOpts{})
+}
+
+func newDownstreamEvaluator(downstreamer Downstreamer, opts EngineOpts) *DownstreamEvaluator {
+	ev := NewDefaultEvaluator(&errorQuerier{}, 0)
+	ev.maxSubquerySamples = opts.MaxSubquerySamples
 	return &DownstreamEvaluator{
 		Downstreamer:     downstreamer,
-		defaultEvaluator: NewDefaultEvaluator(&errorQuerier{}, 0),
+		defaultEvaluator: ev,
 	}
 }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logql/vector"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/util"
@@ -146,10 +147,25 @@ type EngineOpts struct {
 
 	// LogExecutingQuery will control if we log the query when Exec is called.
 	LogExecutingQuery bool `yaml:"-"`
+
+	// ApproximateFromIndex allows answering count_over_time and bytes_over_time
+	// queries without pipeline stages from the chunk statistics in the index.
+	ApproximateFromIndex bool `yaml:"approximate_from_index"`
+
+	// ApproximateFromIndexMinStep is the smallest step and range interval for which
+	// queries are approximated from the index.
+	ApproximateFromIndexMinStep time.Duration `yaml:"approximate_from_index_min_step"`
+
+	// MaxSubquerySamples is the maximum number of samples of the inner
+	// expression of a subquery held in memory.
+	MaxSubquerySamples int `yaml:"max_subquery_samples"`
 }
 
 func (opts *EngineOpts) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&opts.MaxLookBackPeriod, prefix+".engine.max-lookback-period", 30*time.Second, "The maximum amount of time to look back for log lines. Used only for instant log queries.")
+	f.BoolVar(&opts.ApproximateFromIndex, prefix+".engine.approximate-from-index", false, "Answer count_over_time and bytes_over_time queries without line filters or parsers from the chunk statistics stored in the TSDB index instead of fetching chunks. Results are approximate and flagged as such in the response statistics. Ranges still served by ingesters or affected by delete requests are always evaluated from chunks.")
+	f.DurationVar(&opts.ApproximateFromIndexMinStep, prefix+".engine.approximate-from-index-min-step", time.Hour, "The minimum step and range interval of queries approximated from the index. Should be in the order of the chunk time span.")
+	f.IntVar(&opts.MaxSubquerySamples, prefix+".engine.max-subquery-samples", 10_000_000, "The maximum number of samples of the inner expression of a subquery held in memory while evaluating it. 0 to disable.")
 	// Log executing query by default
 	opts.LogExecutingQuery = true
 }
@@ -174,9 +190,13 @@ func NewEngine(opts EngineOpts, q Querier, l Limits, logger log.Logger) *Engine
 	if logger == nil {
 		logger = log.NewNopLogger()
 	}
+	ev := NewDefaultEvaluator(q, opts.MaxLookBackPeriod)
+	ev.approximateFromIndex = opts.ApproximateFromIndex
+	ev.approximateMinStep = opts.ApproximateFromIndexMinStep
+	ev.maxSubquerySamples = opts.MaxSubquerySamples
 	return &Engine{
 		logger:           logger,
-		evaluatorFactory: NewDefaultEvaluator(q, opts.MaxLookBackPeriod),
+		evaluatorFactory: ev,
 		limits:           l,
 		opts:             opts,
 	}
@@ -298,6 +318,8 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		if err != nil {
 			return nil, err
 		}
+		// dedup and limit stages are evaluated over the merged entries of all the streams.
+		itr = iter.NewMergeStages(e).Wrap(itr)
 
 		encodingFlags := httpreq.ExtractEncodingFlagsFromCtx(ctx)
 		if encodingFlags.Has(httpreq.FlagCategorizeLabels) {
@@ -591,4 +613,6 @@ type groupedAggregation struct {
 	groupCount  int
 	heap        vectorByValueHeap
 	reverseHeap vectorByReverseValueHeap
+	values      vector.HeapByMaxValue
+	samples     promql.Vector
 }
@@ -438,6 +438,135 @@ func TestEngine_InstantQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`count_values("count", count_over_time({app=~"foo|bar|baz"}[1m]))`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("count", "12")},
+				{T: 60 * 1000, F: 2, Metric: labels.FromStrings("count", "6")},
+			},
+		},
+		{
+			`count_values by (namespace) ("count", count_over_time({app=~"foo|bar|baz"}[1m]))`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("count", "12", "namespace", "a")},
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("count", "6", "namespace", "a")},
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("count", "6", "namespace", "b")},
+			},
+		},
+		{
+			`quantile(0.75, count_over_time({app=~"foo|bar|baz"}[1m]))`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 9, Metric: labels.EmptyLabels()},
+			},
+		},
+		{
+			`group by (namespace) (count_over_time({app=~"foo|bar|baz"}[1m]))`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("namespace", "a")},
+				{T: 60 * 1000, F: 1, Metric: labels.FromStrings("namespace", "b")},
+			},
+		},
+		{
+			// the series with the lowest hash of each group is kept.
+			`limitk(1, count_over_time({app=~"foo|bar|baz"}[1m])) by (namespace)`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 6, Metric: labels.FromStrings("app", "bar", "namespace", "b")},
+				{T: 60 * 1000, F: 12, Metric: labels.FromStrings("app", "baz", "namespace", "a")},
+			},
+		},
+		{
+			// the subquery is evaluated at 240s, 270s, 300s and 330s, the last two steps having fewer lines.
+			`min_over_time(count_over_time({app="foo"}[1m])[2m:30s])`, time.Unix(330, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(180, 0), End: time.Unix(330, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 330 * 1000, F: 29, Metric: labels.FromStrings("app", "foo")},
+			},
+		},
+		{
+			`count_over_time(count_over_time({app="foo"}[1m])[2m:30s])`, time.Unix(330, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(180, 0), End: time.Unix(330, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 330 * 1000, F: 4, Metric: labels.FromStrings("app", "foo")},
+			},
+		},
+		{
+			`label_join(count_over_time({app=~"foo|bar|baz"}[1m]), "id", "-", "namespace", "app")`, time.Unix(60, 0), logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{
+					newSeries(testSize, factor(10, identity), `{app="foo", namespace="a"}`),
+					newSeries(testSize, factor(10, identity), `{app="bar", namespace="b"}`),
+					newSeries(testSize, factor(5, identity), `{app="baz", namespace="a"}`),
+				},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo|bar|baz"}[1m])`}},
+			},
+			promql.Vector{
+				{T: 60 * 1000, F: 6, Metric: labels.FromStrings("app", "bar", "id", "b-bar", "namespace", "b")},
+				{T: 60 * 1000, F: 12, Metric: labels.FromStrings("app", "baz", "id", "a-baz", "namespace", "a")},
+				{T: 60 * 1000, F: 6, Metric: labels.FromStrings("app", "foo", "id", "a-foo", "namespace", "a")},
+			},
+		},
 		{
 			`count(count_over_time({app=~"foo|bar"} |~".+bar" [1m])) without (app)`, time.Unix(60, 0), logproto.FORWARD, 100,
 			[][]logproto.Series{
@@ -1863,6 +1992,22 @@ func TestEngine_RangeQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`min_over_time(sum by (app) (count_over_time({app="foo"}[30s]))[1m:15s])`,
+			time.Unix(240, 0), time.Unix(330, 0), 30 * time.Second, 0, logproto.FORWARD, 100,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(165, 0), End: time.Unix(330, 0), Selector: `sum by (app) (count_over_time({app="foo"}[30s]))`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.FromStrings("app", "foo"),
+					Floats: []promql.FPoint{{T: 240 * 1000, F: 30}, {T: 270 * 1000, F: 30}, {T: 300 * 1000, F: 29}, {T: 330 * 1000, F: 14}},
+				},
+			},
+		},
 		{
 			`label_replace(
 				avg by (app) (
@@ -2470,6 +2615,60 @@ func TestEngine_MaxSeries(t *testing.T) {
 	}
 }
 
+func TestEngine_Explode(t *testing.T) {
+	querier := NewMockQuerier(1, []logproto.Stream{{
+		Labels: `{app="foo"}`,
+		Entries: []logproto.Entry{
+			{Timestamp: time.Unix(10, 0), Line: `{"tags":["db","cache"]}`},
+			{Timestamp: time.Unix(20, 0), Line: `{"tags":["db"]}`},
+			{Timestamp: time.Unix(30, 0), Line: `{"tags":[]}`},
+		},
+	}})
+	eng := NewEngine(EngineOpts{}, querier, NoLimits, log.NewNopLogger())
+
+	params, err := NewLiteralParams(`sum by (tags) (count_over_time({app="foo"} | explode tags [1m]))`, time.Unix(60, 0), time.Unix(60, 0), 0, 0, logproto.FORWARD, 0, nil, nil)
+	require.NoError(t, err)
+	res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+	require.NoError(t, err)
+
+	require.Equal(t, promql.Vector{
+		{T: 60 * 1000, F: 1, Metric: labels.FromStrings("tags", "cache")},
+		{T: 60 * 1000, F: 2, Metric: labels.FromStrings("tags", "db")},
+	}, res.Data)
+}
+
+func TestEngine_SubquerySamplesLimit(t *testing.T) {
+	querier := NewMockQuerier(1, []logproto.Stream{{
+		Labels:  `{app="foo"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(10, 0), Line: "foo"}},
+	}})
+	// the subquery evaluates its inner expression at 12 steps.
+	query := `max_over_time(count_over_time({app="foo"}[1h])[1h:5m])`
+	for _, tc := range []struct {
+		maxSamples int
+		err        error
+	}{
+		{maxSamples: 0},
+		{maxSamples: 12},
+		{maxSamples: 11, err: logqlmodel.ErrLimit},
+	} {
+		t.Run(fmt.Sprintf("max_samples=%d", tc.maxSamples), func(t *testing.T) {
+			eng := NewEngine(EngineOpts{MaxSubquerySamples: tc.maxSamples}, querier, NoLimits, log.NewNopLogger())
+			params, err := NewLiteralParams(query, time.Unix(3600, 0), time.Unix(3600, 0), 0, 0, logproto.FORWARD, 0, nil, nil)
+			require.NoError(t, err)
+			res, err := eng.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, promql.Vector{
+				{T: 3600 * 1000, F: 1, Metric: labels.FromStrings("app", "foo")},
+			}, res.Data)
+		})
+	}
+}
+
 func TestEngine_MaxRangeInterval(t *testing.T) {
 	eng := NewEngine(EngineOpts{}, getLocalQuerier(100000), &fakeLimits{rangeLimit: 24 * time.Hour, maxSeries: 100000}, log.NewNopLogger())
 
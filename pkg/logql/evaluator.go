@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -16,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logql/vector"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache/resultscache"
@@ -204,6 +207,27 @@ func (p ParamsWithShardsOverride) Shards() []string {
 	return p.ShardsOverride
 }
 
+// ParamsWithTimeRangeOverride overrides the time range and the step of the
+// query. It is used to evaluate subqueries at their own resolution.
+type ParamsWithTimeRangeOverride struct {
+	Params
+	StartOverride time.Time
+	EndOverride   time.Time
+	StepOverride  time.Duration
+}
+
+func (p ParamsWithTimeRangeOverride) Start() time.Time {
+	return p.StartOverride
+}
+
+func (p ParamsWithTimeRangeOverride) End() time.Time {
+	return p.EndOverride
+}
+
+func (p ParamsWithTimeRangeOverride) Step() time.Duration {
+	return p.StepOverride
+}
+
 type ParamsWithChunkOverrides struct {
 	Params
 	StoreChunksOverride *logproto.ChunkRefGroup
@@ -284,6 +308,15 @@ func EvaluatorUnsupportedType(expr syntax.Expr, ev EvaluatorFactory) error {
 type DefaultEvaluator struct {
 	maxLookBackPeriod time.Duration
 	querier           Querier
+
+	// approximateFromIndex allows answering eligible range aggregations from the
+	// chunk statistics in the index when the querier supports it.
+	approximateFromIndex bool
+	approximateMinStep   time.Duration
+
+	// maxSubquerySamples is the maximum number of inner samples a subquery
+	// holds in memory, 0 for no limit.
+	maxSubquerySamples int
 }
 
 // NewDefaultEvaluator constructs a DefaultEvaluator
@@ -329,6 +362,9 @@ func (ev *DefaultEvaluator) NewStepEvaluator(
 			// if range expression is wrapped with a vector expression
 			// we should send the vector expression for allowing reducing labels at the source.
 			nextEvFactory = SampleEvaluatorFunc(func(ctx context.Context, _ SampleEvaluatorFactory, _ syntax.SampleExpr, _ Params) (StepEvaluator, error) {
+				if se, ok, err := ev.newIndexStatsEvaluator(ctx, rangExpr, q); ok {
+					return se, err
+				}
 				it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
 					&logproto.SampleQueryRequest{
 						// extend startTs backwards by step
@@ -352,6 +388,9 @@ func (ev *DefaultEvaluator) NewStepEvaluator(
 		}
 		return newVectorAggEvaluator(ctx, nextEvFactory, e, q)
 	case *syntax.RangeAggregationExpr:
+		if se, ok, err := ev.newIndexStatsEvaluator(ctx, e, q); ok {
+			return se, err
+		}
 		it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
 			&logproto.SampleQueryRequest{
 				// extend startTs backwards by step
@@ -375,6 +414,10 @@ func (ev *DefaultEvaluator) NewStepEvaluator(
 		return newBinOpStepEvaluator(ctx, nextEvFactory, e, q)
 	case *syntax.LabelReplaceExpr:
 		return newLabelReplaceEvaluator(ctx, nextEvFactory, e, q)
+	case *syntax.LabelJoinExpr:
+		return newLabelJoinEvaluator(ctx, nextEvFactory, e, q)
+	case *syntax.SubqueryExpr:
+		return newSubqueryEvaluator(ctx, nextEvFactory, e, q, ev.maxSubquerySamples)
 	case *syntax.VectorExpr:
 		val, err := e.Value()
 		if err != nil {
@@ -404,14 +447,35 @@ func newVectorAggEvaluator(
 	return &VectorAggEvaluator{
 		nextEvaluator: nextEvaluator,
 		expr:          expr,
+		groups:        vectorAggGroups(expr),
 		buf:           make([]byte, 0, 1024),
 		lb:            labels.NewBuilder(nil),
 	}, nil
 }
 
+// vectorAggGroups returns the sorted grouping labels of the aggregation.
+// count_values groups the samples by their value label as well.
+func vectorAggGroups(expr *syntax.VectorAggregationExpr) []string {
+	if expr.Operation != syntax.OpTypeCountValues {
+		return expr.Grouping.Groups
+	}
+	groups := make([]string, 0, len(expr.Grouping.Groups)+1)
+	for _, g := range expr.Grouping.Groups {
+		if g != expr.ValueLabel {
+			groups = append(groups, g)
+		}
+	}
+	if !expr.Grouping.Without {
+		groups = append(groups, expr.ValueLabel)
+		sort.Strings(groups)
+	}
+	return groups
+}
+
 type VectorAggEvaluator struct {
 	nextEvaluator StepEvaluator
 	expr          *syntax.VectorAggregationExpr
+	groups        []string
 	buf           []byte
 	lb            *labels.Builder
 }
@@ -424,19 +488,24 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 	}
 	vec := r.SampleVector()
 	result := map[uint64]*groupedAggregation{}
-	if e.expr.Operation == syntax.OpTypeTopK || e.expr.Operation == syntax.OpTypeBottomK {
+	if e.expr.Operation == syntax.OpTypeTopK || e.expr.Operation == syntax.OpTypeBottomK || e.expr.Operation == syntax.OpTypeLimitK {
 		if e.expr.Params < 1 {
 			return next, ts, SampleVector{}
 		}
 	}
 	for _, s := range vec {
 		metric := s.Metric
+		if e.expr.Operation == syntax.OpTypeCountValues {
+			e.lb.Reset(metric)
+			e.lb.Set(e.expr.ValueLabel, strconv.FormatFloat(s.F, 'f', -1, 64))
+			metric = e.lb.Labels()
+		}
 
 		var groupingKey uint64
 		if e.expr.Grouping.Without {
-			groupingKey, e.buf = metric.HashWithoutLabels(e.buf, e.expr.Grouping.Groups...)
+			groupingKey, e.buf = metric.HashWithoutLabels(e.buf, e.groups...)
 		} else {
-			groupingKey, e.buf = metric.HashForLabels(e.buf, e.expr.Grouping.Groups...)
+			groupingKey, e.buf = metric.HashForLabels(e.buf, e.groups...)
 		}
 		group, ok := result[groupingKey]
 		// Add a new group if it doesn't exist.
@@ -445,13 +514,13 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 
 			if e.expr.Grouping.Without {
 				e.lb.Reset(metric)
-				e.lb.Del(e.expr.Grouping.Groups...)
+				e.lb.Del(e.groups...)
 				e.lb.Del(labels.MetricName)
 				m = e.lb.Labels()
 			} else {
-				m = make(labels.Labels, 0, len(e.expr.Grouping.Groups))
+				m = make(labels.Labels, 0, len(e.groups))
 				for _, l := range metric {
-					for _, n := range e.expr.Grouping.Groups {
+					for _, n := range e.groups {
 						if l.Name == n {
 							m = append(m, l)
 							break
@@ -498,6 +567,10 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 					F:      s.F,
 					Metric: s.Metric,
 				})
+			} else if e.expr.Operation == syntax.OpTypeQuantile {
+				result[groupingKey].values = vector.HeapByMaxValue{s}
+			} else if e.expr.Operation == syntax.OpTypeLimitK {
+				result[groupingKey].samples = promql.Vector{s}
 			}
 			continue
 		}
@@ -519,9 +592,17 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 				group.value = s.F
 			}
 
-		case syntax.OpTypeCount:
+		case syntax.OpTypeCount, syntax.OpTypeCountValues:
 			group.groupCount++
 
+		case syntax.OpTypeGroup:
+
+		case syntax.OpTypeQuantile:
+			group.values = append(group.values, s)
+
+		case syntax.OpTypeLimitK:
+			group.samples = append(group.samples, s)
+
 		case syntax.OpTypeStddev, syntax.OpTypeStdvar:
 			group.groupCount++
 			delta := s.F - group.mean
@@ -569,9 +650,32 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 		case syntax.OpTypeAvg:
 			aggr.value = aggr.mean
 
-		case syntax.OpTypeCount:
+		case syntax.OpTypeCount, syntax.OpTypeCountValues:
 			aggr.value = float64(aggr.groupCount)
 
+		case syntax.OpTypeGroup:
+			aggr.value = 1
+
+		case syntax.OpTypeQuantile:
+			aggr.value = Quantile(e.expr.Quantile, aggr.values)
+
+		case syntax.OpTypeLimitK:
+			// the kept series are chosen by their hash so that they don't change from one step to the next.
+			sort.Slice(aggr.samples, func(i, j int) bool {
+				return aggr.samples[i].Metric.Hash() < aggr.samples[j].Metric.Hash()
+			})
+			if len(aggr.samples) > e.expr.Params {
+				aggr.samples = aggr.samples[:e.expr.Params]
+			}
+			for _, v := range aggr.samples {
+				vec = append(vec, promql.Sample{
+					Metric: v.Metric,
+					T:      ts,
+					F:      v.F,
+				})
+			}
+			continue // Bypass default append.
+
 		case syntax.OpTypeStddev:
 			aggr.value = math.Sqrt(aggr.value / float64(aggr.groupCount))
 
@@ -1298,6 +1402,218 @@ func (e *LabelReplaceEvaluator) Error() error {
 	return e.nextEvaluator.Error()
 }
 
+func newLabelJoinEvaluator(
+	ctx context.Context,
+	evFactory SampleEvaluatorFactory,
+	expr *syntax.LabelJoinExpr,
+	q Params,
+) (*LabelJoinEvaluator, error) {
+	nextEvaluator, err := evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, q)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelJoinEvaluator{
+		nextEvaluator: nextEvaluator,
+		expr:          expr,
+		buf:           make([]byte, 0, 1024),
+	}, nil
+}
+
+type LabelJoinEvaluator struct {
+	nextEvaluator StepEvaluator
+	labelCache    map[uint64]labels.Labels
+	expr          *syntax.LabelJoinExpr
+	buf           []byte
+	values        []string
+}
+
+func (e *LabelJoinEvaluator) Next() (bool, int64, StepResult) {
+	next, ts, r := e.nextEvaluator.Next()
+	if !next {
+		return false, 0, SampleVector{}
+	}
+	vec := r.SampleVector()
+	if e.labelCache == nil {
+		e.labelCache = make(map[uint64]labels.Labels, len(vec))
+	}
+	var hash uint64
+	for i, s := range vec {
+		hash, e.buf = s.Metric.HashWithoutLabels(e.buf)
+		if labels, ok := e.labelCache[hash]; ok {
+			vec[i].Metric = labels
+			continue
+		}
+		e.values = e.values[:0]
+		for _, src := range e.expr.Src {
+			e.values = append(e.values, s.Metric.Get(src))
+		}
+		res := strings.Join(e.values, e.expr.Separator)
+
+		lb := labels.NewBuilder(s.Metric).Del(e.expr.Dst)
+		if len(res) > 0 {
+			lb.Set(e.expr.Dst, res)
+		}
+		outLbs := lb.Labels()
+		e.labelCache[hash] = outLbs
+		vec[i].Metric = outLbs
+	}
+	return next, ts, SampleVector(vec)
+}
+
+func (e *LabelJoinEvaluator) Close() error {
+	return e.nextEvaluator.Close()
+}
+func (e *LabelJoinEvaluator) Error() error {
+	return e.nextEvaluator.Error()
+}
+
+// newSubqueryEvaluator evaluates the inner expression of the subquery as a
+// range query at the subquery resolution. The inner steps are aligned to
+// multiples of the resolution, so that results do not depend on the query
+// start, and cover the ranges of all the outer steps.
+func newSubqueryEvaluator(
+	ctx context.Context,
+	evFactory SampleEvaluatorFactory,
+	expr *syntax.SubqueryExpr,
+	q Params,
+	maxSamples int,
+) (*SubqueryEvaluator, error) {
+	agg, err := subqueryAggregator(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	stepMs := q.Step().Milliseconds()
+	if stepMs == 0 {
+		stepMs = 1
+	}
+	ev := &SubqueryEvaluator{
+		expr:       expr,
+		agg:        agg,
+		maxSamples: maxSamples,
+		stepMs:     stepMs,
+		endMs:      q.End().UnixMilli(),
+		currentMs:  q.Start().UnixMilli() - stepMs,
+	}
+
+	resolution := expr.Step.Nanoseconds()
+	// the range of a step is left-open, the first inner step is thus the
+	// first multiple of the resolution after the beginning of the range.
+	start := alignDown(q.Start().Add(-expr.Offset).Add(-expr.Range).UnixNano(), resolution) + resolution
+	end := alignDown(q.End().Add(-expr.Offset).UnixNano(), resolution)
+	if end < start {
+		ev.nextEvaluator = EmptyEvaluator[SampleVector]{}
+		return ev, nil
+	}
+
+	ev.nextEvaluator, err = evFactory.NewStepEvaluator(ctx, evFactory, expr.Left, ParamsWithTimeRangeOverride{
+		Params:        q,
+		StartOverride: time.Unix(0, start),
+		EndOverride:   time.Unix(0, end),
+		StepOverride:  expr.Step,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+// alignDown returns the greatest multiple of step lower or equal to ts.
+func alignDown(ts, step int64) int64 {
+	r := ts % step
+	if r < 0 {
+		r += step
+	}
+	return ts - r
+}
+
+// SubqueryEvaluator applies a range aggregation over the samples of its
+// inner evaluator. All the inner samples are loaded on the first step, up to
+// maxSamples.
+type SubqueryEvaluator struct {
+	nextEvaluator StepEvaluator
+	expr          *syntax.SubqueryExpr
+	agg           BatchRangeVectorAggregator
+	maxSamples    int
+
+	series []*promql.Series
+	loaded bool
+	err    error
+
+	stepMs, endMs, currentMs int64
+}
+
+func (e *SubqueryEvaluator) load() {
+	e.loaded = true
+	seriesByHash := map[uint64]*promql.Series{}
+	samples := 0
+	for {
+		next, ts, r := e.nextEvaluator.Next()
+		if !next {
+			break
+		}
+		vec := r.SampleVector()
+		if samples += len(vec); e.maxSamples > 0 && samples > e.maxSamples {
+			e.series = nil
+			e.err = logqlmodel.NewSubquerySamplesLimitError(e.maxSamples)
+			return
+		}
+		for _, s := range vec {
+			hash := s.Metric.Hash()
+			series, ok := seriesByHash[hash]
+			if !ok {
+				series = &promql.Series{Metric: s.Metric}
+				seriesByHash[hash] = series
+				e.series = append(e.series, series)
+			}
+			series.Floats = append(series.Floats, promql.FPoint{T: ts, F: s.F})
+		}
+	}
+}
+
+func (e *SubqueryEvaluator) Next() (bool, int64, StepResult) {
+	if !e.loaded {
+		e.load()
+		if e.Error() != nil {
+			return false, 0, SampleVector{}
+		}
+	}
+	e.currentMs += e.stepMs
+	if e.currentMs > e.endMs {
+		return false, 0, SampleVector{}
+	}
+
+	end := e.currentMs - e.expr.Offset.Milliseconds()
+	start := end - e.expr.Range.Milliseconds()
+	vec := make(promql.Vector, 0, len(e.series))
+	for _, series := range e.series {
+		// points are sorted by timestamp, find the ones within (start, end].
+		lo := sort.Search(len(series.Floats), func(i int) bool { return series.Floats[i].T > start })
+		hi := sort.Search(len(series.Floats), func(i int) bool { return series.Floats[i].T > end })
+		if lo == hi {
+			continue
+		}
+		vec = append(vec, promql.Sample{
+			T:      e.currentMs,
+			F:      e.agg(series.Floats[lo:hi]),
+			Metric: series.Metric,
+		})
+	}
+	return true, e.currentMs, SampleVector(vec)
+}
+
+func (e *SubqueryEvaluator) Close() error {
+	return e.nextEvaluator.Close()
+}
+
+func (e *SubqueryEvaluator) Error() error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.nextEvaluator.Error()
+}
+
 // This is to replace missing timeseries during absent_over_time aggregation.
 func absentLabels(expr syntax.SampleExpr) (labels.Labels, error) {
 	m := labels.Labels{}
@@ -0,0 +1,213 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/jsonparser"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logql/log/jsonexpr"
+)
+
+var errNotAnArray = errors.New("exploded field is not an array")
+
+// ExplodeStage fans a log line out into one result per element of a JSON array.
+// The fields of an object element are extracted as labels like the json parser does,
+// while a scalar element is extracted into the label named after the exploded field.
+//
+// The array is read from the label named after the field when there is one, for instance structured metadata,
+// otherwise from the JSON log line. A line without the field or with an empty array is filtered out.
+//
+// The stage only processes a single element per call: the sample extractors process the line
+// again for each element, see StreamSampleExtractor.NextSample. The array is only parsed when the
+// first element is processed, the next elements of the line are read from the explodedArray of the stream.
+type ExplodeStage struct {
+	field string
+	path  []string
+	label string
+
+	// index is the element to extract from the line being processed, count is its number of elements
+	// and array holds its elements, all are set per call by the sampleExploder.
+	index int
+	count int
+	array *explodedArray
+
+	parser *JSONParser
+}
+
+// explodedArray holds a copy of the exploded array of a line and its elements.
+type explodedArray struct {
+	buf      []byte
+	elements []explodedElement
+}
+
+type explodedElement struct {
+	value     []byte
+	valueType jsonparser.ValueType
+}
+
+// NewExplodeStage creates a stage exploding the JSON array at the given field, e.g. `items` or `order.items`.
+func NewExplodeStage(field string) (*ExplodeStage, error) {
+	path, err := jsonexpr.Parse(field, false)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse field [%s]: %w", field, err)
+	}
+
+	label := sanitizeLabelKey(field, true)
+	if len(label) == 0 {
+		return nil, fmt.Errorf("invalid exploded field '%s'", field)
+	}
+
+	return &ExplodeStage{
+		field:  field,
+		path:   pathsToString(path),
+		label:  label,
+		array:  &explodedArray{},
+		parser: NewJSONParser(),
+	}, nil
+}
+
+func (e *ExplodeStage) Process(ts int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if e.index == 0 {
+		if err := e.parse(line, lbs); err != nil {
+			if errors.Is(err, jsonparser.KeyPathNotFoundError) {
+				return line, false
+			}
+			// only the first element carries the error to not duplicate it.
+			e.count = 1
+			addErrLabel(errJSON, err, lbs)
+			return line, true
+		}
+	}
+	e.count = len(e.array.elements)
+	if e.index >= e.count {
+		return line, false
+	}
+	element, elementType := e.array.elements[e.index].value, e.array.elements[e.index].valueType
+
+	if elementType == jsonparser.Object {
+		return e.parser.Process(ts, element, lbs)
+	}
+
+	key := e.label
+	if lbs.BaseHas(key) {
+		key = key + duplicateSuffix
+	}
+	value := readValue(element, elementType)
+	// an array read from a label, for instance structured metadata, is replaced by its element.
+	if _, category, ok := lbs.GetWithCategory(key); ok {
+		lbs.Set(category, key, value)
+		return line, true
+	}
+	if !lbs.ParserLabelHints().ShouldExtract(key) {
+		return line, true
+	}
+	lbs.Set(ParsedLabel, key, value)
+	return line, lbs.ParserLabelHints().ShouldContinueParsingLine(key, lbs)
+}
+
+// parse reads the elements of the exploded array of the line.
+func (e *ExplodeStage) parse(line []byte, lbs *LabelsBuilder) error {
+	a := e.array
+	a.elements = a.elements[:0]
+
+	array, err := e.read(line, lbs)
+	if err != nil {
+		return err
+	}
+	// the stages before this one process the line again for the next elements and may reuse its buffer,
+	// so the array is copied.
+	a.buf = append(a.buf[:0], array...)
+	_, err = jsonparser.ArrayEach(a.buf, func(value []byte, dataType jsonparser.ValueType, _ int, _ error) {
+		a.elements = append(a.elements, explodedElement{value: value, valueType: dataType})
+	})
+	if err != nil {
+		a.elements = a.elements[:0]
+		return err
+	}
+	return nil
+}
+
+// read returns the exploded JSON array.
+func (e *ExplodeStage) read(line []byte, lbs *LabelsBuilder) ([]byte, error) {
+	if value, ok := lbs.Get(e.field); ok {
+		line = unsafeGetBytes(value)
+	} else if len(e.path) > 0 {
+		value, dataType, _, err := jsonparser.Get(line, e.path...)
+		if err != nil {
+			return nil, err
+		}
+		if dataType != jsonparser.Array {
+			return nil, errNotAnArray
+		}
+		return value, nil
+	}
+
+	value, dataType, _, err := jsonparser.Get(line)
+	if err != nil {
+		return nil, err
+	}
+	if dataType != jsonparser.Array {
+		return nil, errNotAnArray
+	}
+	return value, nil
+}
+
+func (e *ExplodeStage) RequiredLabelNames() []string { return []string{} }
+
+func findExplodeStage(stages []Stage) *ExplodeStage {
+	for _, s := range stages {
+		if e, ok := s.(*ExplodeStage); ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// sampleExploder processes a line once per element of the array exploded by an ExplodeStage.
+// It keeps the position within the line, since the stage is shared by the extractors of all the streams.
+type sampleExploder struct {
+	stage   *ExplodeStage
+	process func(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
+
+	// the line being exploded, the element to extract next, the number of elements of the line and its elements.
+	ts                 int64
+	line               []byte
+	structuredMetadata []labels.Label
+	index              int
+	count              int
+	array              explodedArray
+}
+
+// first returns the sample of the first element of the line which isn't filtered out.
+func (e *sampleExploder) first(ts int64, line []byte, structuredMetadata []labels.Label) (float64, LabelsResult, bool) {
+	e.ts, e.line, e.structuredMetadata = ts, line, structuredMetadata
+	e.index, e.count = 0, 0
+	return e.extract()
+}
+
+// next returns the sample of the next element of the line which isn't filtered out.
+func (e *sampleExploder) next() (float64, LabelsResult, bool) {
+	if e.index+1 >= e.count {
+		return 0, nil, false
+	}
+	e.index++
+	return e.extract()
+}
+
+func (e *sampleExploder) extract() (float64, LabelsResult, bool) {
+	for {
+		e.stage.index, e.stage.count, e.stage.array = e.index, 0, &e.array
+		v, lbs, ok := e.process(e.ts, e.line, e.structuredMetadata...)
+		// stages before the explode stage can filter the line out before it is processed.
+		e.count = e.stage.count
+		if ok {
+			return v, lbs, true
+		}
+		if e.index+1 >= e.count {
+			return 0, nil, false
+		}
+		e.index++
+	}
+}
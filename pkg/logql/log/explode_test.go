@@ -0,0 +1,134 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+type explodedSample struct {
+	value  float64
+	labels string
+}
+
+func extractAll(sse StreamSampleExtractor, line string, structuredMetadata ...labels.Label) []explodedSample {
+	var res []explodedSample
+	for v, lbs, ok := sse.ProcessString(0, line, structuredMetadata...); ok; v, lbs, ok = sse.NextSample() {
+		res = append(res, explodedSample{value: v, labels: lbs.String()})
+	}
+	return res
+}
+
+func mustExplodeStage(t *testing.T, field string) *ExplodeStage {
+	t.Helper()
+	s, err := NewExplodeStage(field)
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewExplodeStage(t *testing.T) {
+	for _, tc := range []struct {
+		field   string
+		wantErr bool
+	}{
+		{"items", false},
+		{"order.items", false},
+		{`order["items"]`, false},
+		{"order[0].items", false},
+		{"", true},
+		{"order.", true},
+	} {
+		t.Run(tc.field, func(t *testing.T) {
+			_, err := NewExplodeStage(tc.field)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestExplodeStage_LabelSampleExtractor(t *testing.T) {
+	lbs := labels.FromStrings("app", "shop")
+	se, err := LabelExtractorWithStages(
+		"price", ConvertFloat, []string{"sku"}, false, false,
+		[]Stage{mustExplodeStage(t, "order.items")}, NoopStage,
+	)
+	require.NoError(t, err)
+	sse := se.ForStream(lbs)
+
+	require.Equal(t, []explodedSample{
+		{value: 10, labels: `{sku="a"}`},
+		{value: 2.5, labels: `{sku="b"}`},
+		{value: 4, labels: `{sku="c"}`},
+	}, extractAll(sse, `{"order":{"id":1,"items":[{"sku":"a","price":10},{"sku":"b","price":2.5},{"sku":"c","price":4}]}}`))
+
+	// elements without the unwrapped label are skipped.
+	require.Equal(t, []explodedSample{
+		{value: 4, labels: `{sku="c"}`},
+	}, extractAll(sse, `{"order":{"items":[{"sku":"a"},{"sku":"b"},{"sku":"c","price":4}]}}`))
+
+	// lines without the field or with an empty array are filtered out.
+	require.Empty(t, extractAll(sse, `{"order":{"id":1}}`))
+	require.Empty(t, extractAll(sse, `{"order":{"items":[]}}`))
+
+	// a new line starts from its first element.
+	require.Equal(t, []explodedSample{
+		{value: 1, labels: `{sku="d"}`},
+	}, extractAll(sse, `{"order":{"items":[{"sku":"d","price":1}]}}`))
+}
+
+func TestExplodeStage_LineSampleExtractor(t *testing.T) {
+	lbs := labels.FromStrings("app", "shop")
+	se, err := NewLineSampleExtractor(CountExtractor, []Stage{
+		mustExplodeStage(t, "tags"),
+		NewStringLabelFilter(labels.MustNewMatcher(labels.MatchNotEqual, "tags", "debug")),
+	}, []string{"tags"}, false, false)
+	require.NoError(t, err)
+	sse := se.ForStream(lbs)
+
+	require.Equal(t, []explodedSample{
+		{value: 1, labels: `{tags="web"}`},
+		{value: 1, labels: `{tags="api"}`},
+	}, extractAll(sse, `{"tags":["web","debug","api"]}`))
+
+	// the array is read from structured metadata when there is such a label.
+	require.Equal(t, []explodedSample{
+		{value: 1, labels: `{tags="db"}`},
+		{value: 1, labels: `{tags="cache"}`},
+	}, extractAll(sse, `not json`, labels.Label{Name: "tags", Value: `["db","cache"]`}))
+
+	// a field which isn't an array is reported once.
+	res := extractAll(sse, `{"tags":"web"}`)
+	require.Len(t, res, 1)
+	require.Contains(t, res[0].labels, errJSON)
+}
+
+func TestExplodeStage_InterleavedStreams(t *testing.T) {
+	se, err := NewLineSampleExtractor(CountExtractor, []Stage{mustExplodeStage(t, "tags")}, []string{"tags"}, false, false)
+	require.NoError(t, err)
+
+	first := se.ForStream(labels.FromStrings("app", "first"))
+	second := se.ForStream(labels.FromStrings("app", "second"))
+
+	_, lbs, ok := first.ProcessString(0, `{"tags":["a","b"]}`)
+	require.True(t, ok)
+	require.Equal(t, `{tags="a"}`, lbs.String())
+
+	_, lbs, ok = second.ProcessString(0, `{"tags":["c","d","e"]}`)
+	require.True(t, ok)
+	require.Equal(t, `{tags="c"}`, lbs.String())
+
+	// each stream keeps its own position within its line.
+	_, lbs, ok = first.NextSample()
+	require.True(t, ok)
+	require.Equal(t, `{tags="b"}`, lbs.String())
+	_, _, ok = first.NextSample()
+	require.False(t, ok)
+
+	_, lbs, ok = second.NextSample()
+	require.True(t, ok)
+	require.Equal(t, `{tags="d"}`, lbs.String())
+}
@@ -26,6 +26,10 @@ const (
 	LineMatchNotRegexp
 	LineMatchPattern
 	LineMatchNotPattern
+	LineMatchEqualCaseInsensitive
+	LineMatchNotEqualCaseInsensitive
+	LineMatchFuzzy
+	LineMatchNotFuzzy
 )
 
 func (t LineMatchType) String() string {
@@ -42,6 +46,14 @@ func (t LineMatchType) String() string {
 		return "|>"
 	case LineMatchNotPattern:
 		return "!>"
+	case LineMatchEqualCaseInsensitive:
+		return "|=*"
+	case LineMatchNotEqualCaseInsensitive:
+		return "!=*"
+	case LineMatchFuzzy:
+		return "|~~"
+	case LineMatchNotFuzzy:
+		return "!~~"
 	default:
 		return ""
 	}
@@ -437,9 +449,88 @@ func contains(line, substr []byte, caseInsensitive bool) bool {
 	if !caseInsensitive {
 		return bytes.Contains(line, substr)
 	}
+	if isASCII(substr) {
+		return containsLowerASCII(line, substr)
+	}
 	return containsLower(line, substr)
 }
 
+// containsLowerASCII is the fast path of containsLower for an ASCII substr.
+// Candidates are found with bytes.IndexByte, which is vectorized, on either
+// case of the first byte of substr and only then compared case-insensitively.
+func containsLowerASCII(line, substr []byte) bool {
+	if len(substr) == 0 {
+		return true
+	}
+	// n is the number of positions substr can start at.
+	n := len(line) - len(substr) + 1
+	if n <= 0 {
+		return false
+	}
+	lower, upper := toLowerASCII(substr[0]), substr[0]
+	if 'a' <= lower && lower <= 'z' {
+		upper = lower - ('a' - 'A')
+	}
+
+	nextLower, nextUpper := -1, -1
+	for i := 0; i < n; i++ {
+		if nextLower < i {
+			nextLower = indexByteFrom(line[:n], lower, i)
+		}
+		if nextUpper < i {
+			if upper == lower {
+				nextUpper = nextLower
+			} else {
+				nextUpper = indexByteFrom(line[:n], upper, i)
+			}
+		}
+		i = min(nextLower, nextUpper)
+		if i >= n {
+			return false
+		}
+		if equalFoldASCII(line[i+1:i+len(substr)], substr[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// indexByteFrom returns the index of the first c in b starting at from, or
+// len(b) if there is none.
+func indexByteFrom(b []byte, c byte, from int) int {
+	i := bytes.IndexByte(b[from:], c)
+	if i < 0 {
+		return len(b)
+	}
+	return from + i
+}
+
+// equalFoldASCII returns whether b equals the ASCII string s, ignoring case.
+func equalFoldASCII(b, s []byte) bool {
+	for i, c := range b {
+		if toLowerASCII(c) != toLowerASCII(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func toLowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
 func containsLower(line, substr []byte) bool {
 	if len(substr) == 0 {
 		return true
@@ -564,6 +655,12 @@ func NewFilter(match string, mt LineMatchType) (Filterer, error) {
 		return newPatternFilterer([]byte(match), true)
 	case LineMatchNotPattern:
 		return newPatternFilterer([]byte(match), false)
+	case LineMatchEqualCaseInsensitive:
+		return newContainsFilter([]byte(match), true), nil
+	case LineMatchNotEqualCaseInsensitive:
+		return NewNotFilter(newContainsFilter([]byte(match), true)), nil
+	case LineMatchFuzzy, LineMatchNotFuzzy:
+		return nil, fmt.Errorf("fuzzy line filter %s requires a distance", mt)
 	default:
 		return nil, fmt.Errorf("unknown matcher: %v", match)
 	}
@@ -219,3 +219,50 @@ func benchmarkRegex(b *testing.B, re, line string, match bool) {
 func Test_rune(t *testing.T) {
 	require.True(t, newContainsFilter([]byte("foo"), true).Filter([]byte("foo")))
 }
+
+func Test_CaseInsensitiveFilter(t *testing.T) {
+	for _, tc := range []struct {
+		match string
+		line  string
+		want  bool
+	}{
+		{"timeout", "Request TIMEOUT after 5s", true},
+		{"Timeout", "request timeout after 5s", true},
+		{"timeout", "request timeou", false},
+		{"aab", "aaab", true},
+		{"ab", "aAB", true},
+		{"a", "", false},
+		{"", "foo", true},
+		{"ß", "STRAßE", true},
+		{"straße", "STRAßE", true},
+		{"timeout", "tïmeout", false},
+		{"t", "T", true},
+		{"1.2", "version 1.2.3", true},
+		{"[error]", "[ERROR] failed", true},
+	} {
+		t.Run(tc.match+"_"+tc.line, func(t *testing.T) {
+			f, err := NewFilter(tc.match, LineMatchEqualCaseInsensitive)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, f.Filter([]byte(tc.line)))
+
+			f, err = NewFilter(tc.match, LineMatchNotEqualCaseInsensitive)
+			require.NoError(t, err)
+			require.Equal(t, !tc.want, f.Filter([]byte(tc.line)))
+		})
+	}
+}
+
+func Benchmark_CaseInsensitiveFilter(b *testing.B) {
+	line := []byte(`level=error ts=2024-05-01T10:00:00.000Z caller=client.go:123 msg="request failed" err="context deadline exceeded: request Timeout after 5s" tenant=foo`)
+	match := []byte("timeout")
+	b.Run("containsLower", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			res = containsLower(line, match)
+		}
+	})
+	b.Run("containsLowerASCII", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			res = containsLowerASCII(line, match)
+		}
+	})
+}
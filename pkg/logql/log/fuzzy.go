@@ -0,0 +1,162 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+var ErrFuzzyFilterInvalidDistance = errors.New("fuzzy: distance must be a positive integer lower than the length of the pattern")
+
+// fuzzyFilter keeps lines containing a substring within a Levenshtein
+// distance of a pattern.
+type fuzzyFilter struct {
+	pattern  []byte
+	distance int
+
+	runes []rune
+	// peq holds the bit vector of the positions of each rune in the pattern,
+	// it is only used for patterns of up to 64 runes.
+	peq      map[rune]uint64
+	peqASCII [utf8.RuneSelf]uint64
+}
+
+// NewFuzzyFilter creates a line filter keeping (or dropping for
+// LineMatchNotFuzzy) lines containing a substring which can be turned into
+// pattern with at most distance single character insertions, deletions or
+// substitutions.
+func NewFuzzyFilter(pattern string, distance int, ty LineMatchType) (MatcherFilterer, error) {
+	switch ty {
+	case LineMatchFuzzy, LineMatchNotFuzzy:
+	default:
+		return nil, fmt.Errorf("fuzzy: invalid operation %s", ty)
+	}
+
+	runes := []rune(pattern)
+	if distance < 0 || distance >= len(runes) {
+		return nil, ErrFuzzyFilterInvalidDistance
+	}
+
+	f := &fuzzyFilter{
+		pattern:  []byte(pattern),
+		distance: distance,
+		runes:    runes,
+	}
+	if len(runes) <= 64 {
+		f.peq = map[rune]uint64{}
+		for i, r := range runes {
+			if r < utf8.RuneSelf {
+				f.peqASCII[r] |= 1 << i
+				continue
+			}
+			f.peq[r] |= 1 << i
+		}
+	}
+
+	if ty == LineMatchNotFuzzy {
+		return NewNotFilter(f), nil
+	}
+	return f, nil
+}
+
+func (f *fuzzyFilter) Filter(line []byte) bool {
+	switch {
+	case f.distance == 0:
+		return bytes.Contains(line, f.pattern)
+	case f.peq != nil:
+		return f.filterBitParallel(line)
+	default:
+		return f.filterDynamic(line)
+	}
+}
+
+// filterBitParallel implements Myers' bit-vector algorithm for approximate
+// string matching, computing the edit distance column for every position of
+// the line with a few bitwise operations.
+func (f *fuzzyFilter) filterBitParallel(line []byte) bool {
+	var (
+		m     = len(f.runes)
+		last  = uint64(1) << (m - 1)
+		pv    = ^uint64(0)
+		mv    = uint64(0)
+		score = m
+	)
+	for len(line) > 0 {
+		var eq uint64
+		if c := line[0]; c < utf8.RuneSelf {
+			eq = f.peqASCII[c]
+			line = line[1:]
+		} else {
+			r, size := utf8.DecodeRune(line)
+			eq = f.peq[r]
+			line = line[size:]
+		}
+
+		xv := eq | mv
+		xh := (((eq & pv) + pv) ^ pv) | eq
+		ph := mv | ^(xh | pv)
+		mh := pv & xh
+		if ph&last != 0 {
+			score++
+		} else if mh&last != 0 {
+			score--
+		}
+		ph <<= 1
+		mh <<= 1
+		pv = mh | ^(xv | ph)
+		mv = ph & xv
+
+		if score <= f.distance {
+			return true
+		}
+	}
+	return false
+}
+
+// filterDynamic is the fallback of filterBitParallel for patterns longer
+// than 64 runes, computing the edit distance columns one cell at a time.
+func (f *fuzzyFilter) filterDynamic(line []byte) bool {
+	m := len(f.runes)
+	col := make([]int, m+1)
+	for i := range col {
+		col[i] = i
+	}
+	for len(line) > 0 {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+
+		diag := col[0]
+		for i := 1; i <= m; i++ {
+			cost := 1
+			if f.runes[i-1] == r {
+				cost = 0
+			}
+			next := min(col[i]+1, col[i-1]+1, diag+cost)
+			diag = col[i]
+			col[i] = next
+		}
+		if col[m] <= f.distance {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fuzzyFilter) ToStage() Stage {
+	return StageFunc{
+		process: func(_ int64, line []byte, _ *LabelsBuilder) ([]byte, bool) {
+			return line, f.Filter(line)
+		},
+	}
+}
+
+// Matches implements Matcher. Approximate matches can't be tested against a
+// Checker, so the filter is assumed to match.
+func (f *fuzzyFilter) Matches(_ Checker) bool {
+	return true
+}
+
+func (f *fuzzyFilter) String() string {
+	return string(f.pattern)
+}
@@ -0,0 +1,90 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FuzzyFilter(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		pattern  string
+		distance int
+		line     string
+		match    bool
+	}{
+		{"exact", "timeout", 0, "request timeout after 5s", true},
+		{"exact no match", "timeout", 0, "request tiemout after 5s", false},
+		{"transposition", "tiemout", 2, "request timeout after 5s", true},
+		{"transposition too far", "tiemout", 1, "request timeout after 5s", false},
+		{"substitution", "timeout", 1, "request timeoot after 5s", true},
+		{"insertion", "timeout", 1, "request timeoout after 5s", true},
+		{"deletion", "timeout", 1, "request timout after 5s", true},
+		{"prefix", "timeout", 1, "imeout", true},
+		{"no match", "timeout", 2, "connection refused", false},
+		{"empty line", "timeout", 1, "", false},
+		{"unicode", "schließen", 1, "Verbindung schliessen", false},
+		{"unicode substitution", "schließen", 1, "Verbindung schlieben", true},
+		{"long pattern", strings.Repeat("ab", 40), 2, "x" + strings.Repeat("ab", 39) + "x", true},
+		{"long pattern too far", strings.Repeat("ab", 40), 1, "x" + strings.Repeat("ab", 39) + "x", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := NewFuzzyFilter(tc.pattern, tc.distance, LineMatchFuzzy)
+			require.NoError(t, err)
+			require.Equal(t, tc.match, f.Filter([]byte(tc.line)))
+
+			f, err = NewFuzzyFilter(tc.pattern, tc.distance, LineMatchNotFuzzy)
+			require.NoError(t, err)
+			require.Equal(t, !tc.match, f.Filter([]byte(tc.line)))
+		})
+	}
+}
+
+// Test_FuzzyFilterBitParallel checks the bit parallel implementation against
+// the dynamic programming one.
+func Test_FuzzyFilterBitParallel(t *testing.T) {
+	lines := []string{
+		"level=error msg=\"request timeout\" duration=5s",
+		"level=info msg=\"connection refused\"",
+		"tiemout timeot tmeout",
+		"ünïcödé lïnë wïth tïmëöüt",
+		"",
+	}
+	for _, pattern := range []string{"timeout", "refused", "msg", "tïmëöüt", "error msg"} {
+		for distance := 0; distance < 3; distance++ {
+			f, err := NewFuzzyFilter(pattern, distance, LineMatchFuzzy)
+			require.NoError(t, err)
+			ff := f.(*fuzzyFilter)
+			for _, line := range lines {
+				require.Equal(t, ff.filterDynamic([]byte(line)), ff.Filter([]byte(line)), "pattern %q distance %d line %q", pattern, distance, line)
+			}
+		}
+	}
+}
+
+func Test_FuzzyFilterInvalid(t *testing.T) {
+	_, err := NewFuzzyFilter("foo", -1, LineMatchFuzzy)
+	require.ErrorIs(t, err, ErrFuzzyFilterInvalidDistance)
+	_, err = NewFuzzyFilter("foo", 3, LineMatchFuzzy)
+	require.ErrorIs(t, err, ErrFuzzyFilterInvalidDistance)
+	_, err = NewFuzzyFilter("foo", 1, LineMatchEqual)
+	require.Error(t, err)
+	_, err = NewFilter("foo", LineMatchFuzzy)
+	require.Error(t, err)
+}
+
+func Benchmark_FuzzyFilter(b *testing.B) {
+	line := []byte(`level=error ts=2024-05-01T10:00:00.000Z caller=client.go:123 msg="request failed" err="context deadline exceeded: request timeout after 5s" tenant=foo`)
+	for _, distance := range []int{1, 2} {
+		f, err := NewFuzzyFilter("tiemout", distance, LineMatchFuzzy)
+		require.NoError(b, err)
+		b.Run(fmt.Sprintf("distance_%d", distance), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				res = f.Filter(line)
+			}
+		})
+	}
+}
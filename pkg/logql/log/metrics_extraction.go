@@ -37,6 +37,9 @@ type StreamSampleExtractor interface {
 	BaseLabels() LabelsResult
 	Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
 	ProcessString(ts int64, line string, structuredMetadata ...labels.Label) (float64, LabelsResult, bool)
+	// NextSample returns the next sample of the line given to the last Process call, if any.
+	// A line is extracted into multiple samples when the pipeline explodes it, see ExplodeStage.
+	NextSample() (float64, LabelsResult, bool)
 	ReferencedStructuredMetadata() bool
 }
 
@@ -49,6 +52,7 @@ type SampleExtractorWrapper interface {
 type lineSampleExtractor struct {
 	Stage
 	LineExtractor
+	explode *ExplodeStage
 
 	baseBuilder      *BaseLabelsBuilder
 	streamExtractors map[uint64]StreamSampleExtractor
@@ -62,6 +66,7 @@ func NewLineSampleExtractor(ex LineExtractor, stages []Stage, groups []string, w
 	return &lineSampleExtractor{
 		Stage:            s,
 		LineExtractor:    ex,
+		explode:          findExplodeStage(stages),
 		baseBuilder:      NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
 		streamExtractors: make(map[uint64]StreamSampleExtractor),
 	}, nil
@@ -78,6 +83,9 @@ func (l *lineSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtrac
 		LineExtractor: l.LineExtractor,
 		builder:       l.baseBuilder.ForLabels(labels, hash),
 	}
+	if l.explode != nil {
+		res.exploder = &sampleExploder{stage: l.explode, process: res.process}
+	}
 	l.streamExtractors[hash] = res
 	return res
 }
@@ -85,7 +93,8 @@ func (l *lineSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtrac
 type streamLineSampleExtractor struct {
 	Stage
 	LineExtractor
-	builder *LabelsBuilder
+	builder  *LabelsBuilder
+	exploder *sampleExploder
 }
 
 func (l *streamLineSampleExtractor) ReferencedStructuredMetadata() bool {
@@ -93,6 +102,13 @@ func (l *streamLineSampleExtractor) ReferencedStructuredMetadata() bool {
 }
 
 func (l *streamLineSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+	if l.exploder != nil {
+		return l.exploder.first(ts, line, structuredMetadata)
+	}
+	return l.process(ts, line, structuredMetadata...)
+}
+
+func (l *streamLineSampleExtractor) process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
 	l.builder.Reset()
 	l.builder.Add(StructuredMetadataLabel, structuredMetadata...)
 
@@ -113,6 +129,13 @@ func (l *streamLineSampleExtractor) ProcessString(ts int64, line string, structu
 	return l.Process(ts, unsafeGetBytes(line), structuredMetadata...)
 }
 
+func (l *streamLineSampleExtractor) NextSample() (float64, LabelsResult, bool) {
+	if l.exploder == nil {
+		return 0, nil, false
+	}
+	return l.exploder.next()
+}
+
 func (l *streamLineSampleExtractor) BaseLabels() LabelsResult { return l.builder.currentResult }
 
 type convertionFn func(value string) (float64, error)
@@ -122,6 +145,7 @@ type labelSampleExtractor struct {
 	postFilter   Stage
 	labelName    string
 	conversionFn convertionFn
+	explode      *ExplodeStage
 
 	baseBuilder      *BaseLabelsBuilder
 	streamExtractors map[uint64]StreamSampleExtractor
@@ -159,6 +183,7 @@ func LabelExtractorWithStages(
 		conversionFn:     convFn,
 		labelName:        labelName,
 		postFilter:       postFilter,
+		explode:          findExplodeStage(preStages),
 		baseBuilder:      NewBaseLabelsBuilderWithGrouping(groups, hints, without, noLabels),
 		streamExtractors: make(map[uint64]StreamSampleExtractor),
 	}, nil
@@ -166,7 +191,8 @@ func LabelExtractorWithStages(
 
 type streamLabelSampleExtractor struct {
 	*labelSampleExtractor
-	builder *LabelsBuilder
+	builder  *LabelsBuilder
+	exploder *sampleExploder
 }
 
 func (l *labelSampleExtractor) ReferencedStructuredMetadata() bool {
@@ -183,11 +209,21 @@ func (l *labelSampleExtractor) ForStream(labels labels.Labels) StreamSampleExtra
 		labelSampleExtractor: l,
 		builder:              l.baseBuilder.ForLabels(labels, hash),
 	}
+	if l.explode != nil {
+		res.exploder = &sampleExploder{stage: l.explode, process: res.process}
+	}
 	l.streamExtractors[hash] = res
 	return res
 }
 
 func (l *streamLabelSampleExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
+	if l.exploder != nil {
+		return l.exploder.first(ts, line, structuredMetadata)
+	}
+	return l.process(ts, line, structuredMetadata...)
+}
+
+func (l *streamLabelSampleExtractor) process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
 	// Apply the pipeline first.
 	l.builder.Reset()
 	l.builder.Add(StructuredMetadataLabel, structuredMetadata...)
@@ -223,6 +259,13 @@ func (l *streamLabelSampleExtractor) ProcessString(ts int64, line string, struct
 	return l.Process(ts, unsafeGetBytes(line), structuredMetadata...)
 }
 
+func (l *streamLabelSampleExtractor) NextSample() (float64, LabelsResult, bool) {
+	if l.exploder == nil {
+		return 0, nil, false
+	}
+	return l.exploder.next()
+}
+
 func (l *streamLabelSampleExtractor) BaseLabels() LabelsResult { return l.builder.currentResult }
 
 // NewFilteringSampleExtractor creates a sample extractor where entries from
@@ -271,6 +314,10 @@ func (sp *filteringStreamExtractor) BaseLabels() LabelsResult {
 	return sp.extractor.BaseLabels()
 }
 
+func (sp *filteringStreamExtractor) NextSample() (float64, LabelsResult, bool) {
+	return sp.extractor.NextSample()
+}
+
 func (sp *filteringStreamExtractor) Process(ts int64, line []byte, structuredMetadata ...labels.Label) (float64, LabelsResult, bool) {
 	for _, filter := range sp.filters {
 		if ts < filter.start || ts > filter.end {
@@ -486,6 +486,10 @@ func (p *stubStreamExtractor) ProcessString(_ int64, _ string, _ ...labels.Label
 	return 0, nil, true
 }
 
+func (p *stubStreamExtractor) NextSample() (float64, LabelsResult, bool) {
+	return 0, nil, false
+}
+
 func (p *stubStreamExtractor) ReferencedStructuredMetadata() bool {
 	return false
 }
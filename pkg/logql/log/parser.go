@@ -2,8 +2,11 @@ package log
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -33,6 +36,9 @@ var (
 	_ Stage = &JSONParser{}
 	_ Stage = &RegexpParser{}
 	_ Stage = &LogfmtParser{}
+	_ Stage = &CSVParser{}
+	_ Stage = &KVParser{}
+	_ Stage = &XMLParser{}
 
 	trueBytes = []byte("true")
 
@@ -765,3 +771,601 @@ func (u *UnpackParser) unpack(entry []byte, lbs *LabelsBuilder) ([]byte, error)
 	}
 	return entry, nil
 }
+
+type CSVParser struct {
+	columns   []string
+	delimiter []byte
+	quote     []byte
+
+	fieldBuffer []byte
+}
+
+// NewCSVParser creates a parser that extracts the fields of a delimited log line, such as CSV or TSV,
+// into the labels named by the columns. Columns named `_` are skipped.
+// Fields can be enclosed in the quote character, in which case a doubled quote character is a literal quote.
+func NewCSVParser(columns []string, delimiter, quote string) (*CSVParser, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("at least one column must be supplied")
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return nil, fmt.Errorf("delimiter must be a single character, got %q", delimiter)
+	}
+	if utf8.RuneCountInString(quote) != 1 {
+		return nil, fmt.Errorf("quote must be a single character, got %q", quote)
+	}
+	if delimiter == quote {
+		return nil, errors.New("delimiter and quote must be different")
+	}
+
+	uniqueNames := map[string]struct{}{}
+	for _, c := range columns {
+		if c == csvSkipColumn {
+			continue
+		}
+		if !model.LabelName(c).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", c)
+		}
+		if _, ok := uniqueNames[c]; ok {
+			return nil, fmt.Errorf("duplicate extracted label name '%s'", c)
+		}
+		uniqueNames[c] = struct{}{}
+	}
+
+	return &CSVParser{
+		columns:   columns,
+		delimiter: []byte(delimiter),
+		quote:     []byte(quote),
+	}, nil
+}
+
+const csvSkipColumn = "_"
+
+func (c *CSVParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	rest := line
+	for i := 0; i < len(c.columns) && rest != nil; i++ {
+		var (
+			value []byte
+			err   error
+		)
+		value, rest, err = c.nextField(rest)
+		if err != nil {
+			addErrLabel(errCSV, err, lbs)
+			return line, true
+		}
+
+		name := c.columns[i]
+		if name == csvSkipColumn {
+			continue
+		}
+		if lbs.BaseHas(name) {
+			name = name + duplicateSuffix
+		}
+		if !parserHints.ShouldExtract(name) {
+			continue
+		}
+
+		if bytes.ContainsRune(value, utf8.RuneError) {
+			value = bytes.Map(removeInvalidUtf, value)
+		}
+
+		lbs.Set(ParsedLabel, name, string(value))
+		if !parserHints.ShouldContinueParsingLine(name, lbs) {
+			return line, false
+		}
+
+		if parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+
+	return line, true
+}
+
+// nextField returns the next field of the line and the remaining of the line after its delimiter.
+// The remaining is nil once the last field is read.
+func (c *CSVParser) nextField(line []byte) (field []byte, rest []byte, err error) {
+	if !bytes.HasPrefix(line, c.quote) {
+		idx := bytes.Index(line, c.delimiter)
+		if idx < 0 {
+			return line, nil, nil
+		}
+		return line[:idx], line[idx+len(c.delimiter):], nil
+	}
+
+	c.fieldBuffer = c.fieldBuffer[:0]
+	line = line[len(c.quote):]
+	for {
+		idx := bytes.Index(line, c.quote)
+		if idx < 0 {
+			return nil, nil, errors.New("unterminated quoted field")
+		}
+		c.fieldBuffer = append(c.fieldBuffer, line[:idx]...)
+		line = line[idx+len(c.quote):]
+
+		// a doubled quote is an escaped quote.
+		if !bytes.HasPrefix(line, c.quote) {
+			break
+		}
+		c.fieldBuffer = append(c.fieldBuffer, c.quote...)
+		line = line[len(c.quote):]
+	}
+
+	if len(line) == 0 {
+		return c.fieldBuffer, nil, nil
+	}
+	if !bytes.HasPrefix(line, c.delimiter) {
+		return nil, nil, errors.New("unexpected character after quoted field")
+	}
+	return c.fieldBuffer, line[len(c.delimiter):], nil
+}
+
+func (c *CSVParser) RequiredLabelNames() []string { return []string{} }
+
+type KVParser struct {
+	pairSeparator []byte
+	kvSeparator   []byte
+	keys          internedStringSet
+}
+
+// NewKVParser creates a parser that extracts the key value pairs of a log line separated by custom separators,
+// for example `key:value;key:value`. Values can be double quoted to contain the pair separator.
+func NewKVParser(pairSeparator, kvSeparator string) (*KVParser, error) {
+	if pairSeparator == "" || kvSeparator == "" {
+		return nil, errors.New("separators cannot be empty")
+	}
+	if pairSeparator == kvSeparator {
+		return nil, errors.New("pair and key value separators must be different")
+	}
+
+	return &KVParser{
+		pairSeparator: []byte(pairSeparator),
+		kvSeparator:   []byte(kvSeparator),
+		keys:          internedStringSet{},
+	}, nil
+}
+
+func (k *KVParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	rest := line
+	for len(rest) > 0 {
+		kvIdx := bytes.Index(rest, k.kvSeparator)
+		if kvIdx < 0 {
+			break
+		}
+		// skip the pairs without a key value separator.
+		if pairIdx := bytes.Index(rest[:kvIdx], k.pairSeparator); pairIdx >= 0 {
+			rest = rest[pairIdx+len(k.pairSeparator):]
+			continue
+		}
+
+		rawKey := rest[:kvIdx]
+		var value []byte
+		value, rest = k.nextValue(rest[kvIdx+len(k.kvSeparator):])
+
+		key, ok := k.keys.Get(rawKey, func() (string, bool) {
+			sanitized := sanitizeLabelKey(string(rawKey), true)
+			if len(sanitized) == 0 {
+				return "", false
+			}
+
+			if lbs.BaseHas(sanitized) {
+				sanitized = fmt.Sprintf("%s%s", sanitized, duplicateSuffix)
+			}
+
+			if !parserHints.ShouldExtract(sanitized) {
+				return "", false
+			}
+			return sanitized, true
+		})
+		if !ok {
+			continue
+		}
+
+		if bytes.ContainsRune(value, utf8.RuneError) {
+			value = bytes.Map(removeInvalidUtf, value)
+		}
+
+		lbs.Set(ParsedLabel, key, string(value))
+		if !parserHints.ShouldContinueParsingLine(key, lbs) {
+			return line, false
+		}
+
+		if parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+
+	return line, true
+}
+
+// nextValue returns the value at the start of the line and the remaining of the line after the next pair separator.
+func (k *KVParser) nextValue(line []byte) (value []byte, rest []byte) {
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(trimmed) > 0 && trimmed[0] == '"' {
+		if end := closingQuoteIndex(trimmed[1:]); end >= 0 {
+			value = trimmed[:end+2]
+			if unquoted, err := strconv.Unquote(string(value)); err == nil {
+				value = []byte(unquoted)
+			} else {
+				value = value[1 : len(value)-1]
+			}
+
+			rest = trimmed[end+2:]
+			if idx := bytes.Index(rest, k.pairSeparator); idx >= 0 {
+				return value, rest[idx+len(k.pairSeparator):]
+			}
+			return value, nil
+		}
+	}
+
+	idx := bytes.Index(line, k.pairSeparator)
+	if idx < 0 {
+		return bytes.TrimSpace(line), nil
+	}
+	return bytes.TrimSpace(line[:idx]), line[idx+len(k.pairSeparator):]
+}
+
+// closingQuoteIndex returns the index of the first double quote which is not escaped.
+func closingQuoteIndex(b []byte) int {
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+func (k *KVParser) RequiredLabelNames() []string { return []string{} }
+
+type XMLParser struct {
+	prefixBuffer []byte
+	// prefixLens holds the length of the prefix of each parent element.
+	prefixLens []int
+	// hasChild tells for each element being parsed if it has child elements, only leaf elements are extracted.
+	hasChild []bool
+	text     []byte
+
+	keys internedStringSet
+}
+
+// NewXMLParser creates a log stage that can parse a xml log line and add its leaf elements and attributes as labels.
+// The label names are made of the names of the elements leading to them, like the json parser does.
+func NewXMLParser() *XMLParser {
+	return &XMLParser{
+		prefixBuffer: make([]byte, 0, 1024),
+		keys:         internedStringSet{},
+	}
+}
+
+func (x *XMLParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	parserHints := lbs.ParserLabelHints()
+	if parserHints.NoLabels() {
+		return line, true
+	}
+
+	if !isValidXMLStart(line) {
+		addErrLabel(errXML, nil, lbs)
+		return line, true
+	}
+
+	// reset the state.
+	x.prefixBuffer = x.prefixBuffer[:0]
+	x.prefixLens = x.prefixLens[:0]
+	x.hasChild = x.hasChild[:0]
+
+	dec := xml.NewDecoder(bytes.NewReader(line))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			addErrLabel(errXML, err, lbs)
+			return line, true
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if len(x.hasChild) > 0 {
+				x.hasChild[len(x.hasChild)-1] = true
+			}
+
+			x.prefixLens = append(x.prefixLens, len(x.prefixBuffer))
+			if len(x.prefixBuffer) != 0 {
+				x.prefixBuffer = append(x.prefixBuffer, byte(jsonSpacer))
+			}
+			x.prefixBuffer = appendSanitized(x.prefixBuffer, unsafeGetBytes(t.Name.Local))
+
+			if !parserHints.ShouldExtractPrefix(unsafeGetString(x.prefixBuffer)) {
+				if err := dec.Skip(); err != nil {
+					addErrLabel(errXML, err, lbs)
+					return line, true
+				}
+				x.prefixBuffer = x.prefixBuffer[:x.prefixLens[len(x.prefixLens)-1]]
+				x.prefixLens = x.prefixLens[:len(x.prefixLens)-1]
+				continue
+			}
+			x.hasChild = append(x.hasChild, false)
+			x.text = x.text[:0]
+
+			for _, attr := range t.Attr {
+				if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+					continue
+				}
+				if !x.setLabel(lbs, unsafeGetBytes(attr.Name.Local), []byte(attr.Value)) {
+					return line, false
+				}
+			}
+		case xml.CharData:
+			if len(x.hasChild) > 0 && !x.hasChild[len(x.hasChild)-1] {
+				x.text = append(x.text, t...)
+			}
+		case xml.EndElement:
+			if len(x.hasChild) == 0 {
+				continue
+			}
+			if !x.hasChild[len(x.hasChild)-1] {
+				if !x.setLabel(lbs, nil, bytes.TrimSpace(x.text)) {
+					return line, false
+				}
+			}
+
+			x.hasChild = x.hasChild[:len(x.hasChild)-1]
+			x.prefixBuffer = x.prefixBuffer[:x.prefixLens[len(x.prefixLens)-1]]
+			x.prefixLens = x.prefixLens[:len(x.prefixLens)-1]
+		}
+
+		if parserHints.AllRequiredExtracted() {
+			break
+		}
+	}
+
+	return line, true
+}
+
+// setLabel sets the label named after the current prefix and the given name, if any.
+// It returns false if the line must be filtered out based on the hints.
+func (x *XMLParser) setLabel(lbs *LabelsBuilder, name, value []byte) bool {
+	parserHints := lbs.ParserLabelHints()
+
+	// snapshot the current prefix position
+	prefixLen := len(x.prefixBuffer)
+	if len(name) > 0 {
+		x.prefixBuffer = append(x.prefixBuffer, byte(jsonSpacer))
+		x.prefixBuffer = appendSanitized(x.prefixBuffer, name)
+	}
+	key, ok := x.keys.Get(x.prefixBuffer, func() (string, bool) {
+		field := string(x.prefixBuffer)
+		if lbs.BaseHas(field) {
+			field = field + duplicateSuffix
+		}
+		if !parserHints.ShouldExtract(field) {
+			return "", false
+		}
+		return field, true
+	})
+
+	// reset the prefix position
+	x.prefixBuffer = x.prefixBuffer[:prefixLen]
+	if !ok {
+		return true
+	}
+
+	if bytes.ContainsRune(value, utf8.RuneError) {
+		value = bytes.Map(removeInvalidUtf, value)
+	}
+	lbs.Set(ParsedLabel, key, string(value))
+	return parserHints.ShouldContinueParsingLine(key, lbs)
+}
+
+func isValidXMLStart(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '<'
+}
+
+func (x *XMLParser) RequiredLabelNames() []string { return []string{} }
+
+// xmlPath is a XPath-like expression selecting an element or an attribute in a xml document.
+// Paths are absolute, like `/Envelope/Body/User/Name`, or match at any depth when they start with `//`.
+// An element can be `*` to match any element and the path can end with `@name` to select an attribute.
+type xmlPath struct {
+	anyDepth  bool
+	elements  []string
+	attribute string
+}
+
+func parseXMLPath(expr string) (xmlPath, error) {
+	var p xmlPath
+	switch {
+	case strings.HasPrefix(expr, "//"):
+		p.anyDepth = true
+		expr = expr[2:]
+	case strings.HasPrefix(expr, "/"):
+		expr = expr[1:]
+	default:
+		return p, errors.New("path must start with '/'")
+	}
+
+	segments := strings.Split(expr, "/")
+	for i, s := range segments {
+		if s == "" || strings.ContainsAny(s, " \t[]") {
+			return p, fmt.Errorf("invalid path segment '%s'", s)
+		}
+		if strings.HasPrefix(s, "@") {
+			if i != len(segments)-1 || len(s) == 1 {
+				return p, fmt.Errorf("invalid attribute '%s', it must be the last segment", s)
+			}
+			p.attribute = s[1:]
+			continue
+		}
+		p.elements = append(p.elements, s)
+	}
+	if len(p.elements) == 0 {
+		return p, errors.New("path must select at least one element")
+	}
+	return p, nil
+}
+
+// matches tells if the path selects the element at the top of the stack.
+func (p xmlPath) matches(stack []string) bool {
+	if len(stack) < len(p.elements) || (!p.anyDepth && len(stack) != len(p.elements)) {
+		return false
+	}
+	stack = stack[len(stack)-len(p.elements):]
+	for i, e := range p.elements {
+		if e != "*" && e != stack[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type XMLExpressionParser struct {
+	ids   []string
+	paths []xmlPath
+	keys  internedStringSet
+
+	stack []string
+	found []bool
+	// captureDepth holds for each path the depth of the element whose text is being captured, or -1.
+	captureDepth []int
+	text         [][]byte
+}
+
+// NewXMLExpressionParser creates a log stage extracting the elements or attributes selected by XPath-like expressions.
+func NewXMLExpressionParser(expressions []LabelExtractionExpr) (*XMLExpressionParser, error) {
+	var ids []string
+	var paths []xmlPath
+	for _, exp := range expressions {
+		path, err := parseXMLPath(exp.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse expression [%s]: %w", exp.Expression, err)
+		}
+
+		if !model.LabelName(exp.Identifier).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", exp.Identifier)
+		}
+
+		ids = append(ids, exp.Identifier)
+		paths = append(paths, path)
+	}
+
+	return &XMLExpressionParser{
+		ids:          ids,
+		paths:        paths,
+		keys:         internedStringSet{},
+		found:        make([]bool, len(ids)),
+		captureDepth: make([]int, len(ids)),
+		text:         make([][]byte, len(ids)),
+	}, nil
+}
+
+func (x *XMLExpressionParser) Process(_ int64, line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if len(line) == 0 || lbs.ParserLabelHints().NoLabels() {
+		return line, true
+	}
+
+	if !isValidXMLStart(line) {
+		addErrLabel(errXML, nil, lbs)
+		return line, true
+	}
+
+	// reset the state.
+	x.stack = x.stack[:0]
+	for i := range x.ids {
+		x.found[i] = false
+		x.captureDepth[i] = -1
+		x.text[i] = x.text[i][:0]
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(line))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			addErrLabel(errXML, err, lbs)
+			return line, true
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			x.stack = append(x.stack, t.Name.Local)
+			for i, p := range x.paths {
+				if x.found[i] || x.captureDepth[i] >= 0 || !p.matches(x.stack) {
+					continue
+				}
+				if p.attribute == "" {
+					x.captureDepth[i] = len(x.stack)
+					continue
+				}
+				for _, attr := range t.Attr {
+					if attr.Name.Local == p.attribute {
+						x.found[i] = true
+						if !x.setLabel(lbs, i, attr.Value) {
+							return line, false
+						}
+						break
+					}
+				}
+			}
+		case xml.CharData:
+			for i := range x.paths {
+				if x.captureDepth[i] >= 0 {
+					x.text[i] = append(x.text[i], t...)
+				}
+			}
+		case xml.EndElement:
+			for i := range x.paths {
+				if x.captureDepth[i] != len(x.stack) {
+					continue
+				}
+				x.captureDepth[i] = -1
+				x.found[i] = true
+				if !x.setLabel(lbs, i, string(bytes.TrimSpace(x.text[i]))) {
+					return line, false
+				}
+			}
+			x.stack = x.stack[:len(x.stack)-1]
+		}
+	}
+
+	// Ensure there's a label for every value
+	for i, id := range x.ids {
+		if !x.found[i] {
+			if _, ok := lbs.Get(id); !ok {
+				lbs.Set(ParsedLabel, id, "")
+			}
+		}
+	}
+
+	return line, true
+}
+
+func (x *XMLExpressionParser) setLabel(lbs *LabelsBuilder, idx int, value string) bool {
+	identifier := x.ids[idx]
+	key, _ := x.keys.Get(unsafeGetBytes(identifier), func() (string, bool) {
+		if lbs.BaseHas(identifier) {
+			identifier = identifier + duplicateSuffix
+		}
+		return identifier, true
+	})
+
+	lbs.Set(ParsedLabel, key, value)
+	return lbs.ParserLabelHints().ShouldContinueParsingLine(key, lbs)
+}
+
+func (x *XMLExpressionParser) RequiredLabelNames() []string { return []string{} }
@@ -1439,3 +1439,310 @@ func BenchmarkJsonExpressionParser(b *testing.B) {
 		})
 	}
 }
+
+func TestCSVParser(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []string
+		delimiter string
+		quote     string
+		line      []byte
+		lbs       labels.Labels
+		want      labels.Labels
+		hints     ParserHint
+	}{
+		{
+			"csv",
+			[]string{"method", "path", "status"},
+			",", `"`,
+			[]byte(`GET,/api/v1/push,204`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "method", "GET", "path", "/api/v1/push", "status", "204"),
+			NoParserHints(),
+		},
+		{
+			"quoted fields",
+			[]string{"method", "agent", "status"},
+			",", `"`,
+			[]byte(`GET,"Mozilla/5.0 (X11, ""Linux"")",200`),
+			labels.EmptyLabels(),
+			labels.FromStrings("agent", `Mozilla/5.0 (X11, "Linux")`, "method", "GET", "status", "200"),
+			NoParserHints(),
+		},
+		{
+			"tsv with skipped columns",
+			[]string{"_", "path", "_", "status"},
+			"\t", `'`,
+			[]byte("GET\t'/a\tb'\tHTTP/1.1\t200\textra"),
+			labels.EmptyLabels(),
+			labels.FromStrings("path", "/a\tb", "status", "200"),
+			NoParserHints(),
+		},
+		{
+			"missing fields",
+			[]string{"method", "path", "status"},
+			",", `"`,
+			[]byte(`GET,`),
+			labels.EmptyLabels(),
+			labels.FromStrings("method", "GET", "path", ""),
+			NoParserHints(),
+		},
+		{
+			"duplicate label",
+			[]string{"method", "foo"},
+			",", `"`,
+			[]byte(`GET,baz`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "foo_extracted", "baz", "method", "GET"),
+			NoParserHints(),
+		},
+		{
+			"unterminated quote",
+			[]string{"method", "path"},
+			",", `"`,
+			[]byte(`GET,"/api`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"method", "GET",
+				"__error__", "CSVParserErr",
+				"__error_details__", "unterminated quoted field",
+			),
+			NoParserHints(),
+		},
+		{
+			"hints",
+			[]string{"method", "path", "status"},
+			",", `"`,
+			[]byte(`GET,/api/v1/push,204`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "status", "204"),
+			NewParserHint([]string{"status"}, nil, false, true, "", nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewCSVParser(tt.columns, tt.delimiter, tt.quote)
+			require.NoError(t, err)
+
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = p.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestNewCSVParserFailures(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		columns          []string
+		delimiter, quote string
+	}{
+		{"no columns", nil, ",", `"`},
+		{"invalid label", []string{"1foo"}, ",", `"`},
+		{"duplicate label", []string{"foo", "foo"}, ",", `"`},
+		{"long delimiter", []string{"foo"}, ",,", `"`},
+		{"same delimiter and quote", []string{"foo"}, ",", ","},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCSVParser(tt.columns, tt.delimiter, tt.quote)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestKVParser(t *testing.T) {
+	tests := []struct {
+		name                       string
+		pairSeparator, kvSeparator string
+		line                       []byte
+		lbs                        labels.Labels
+		want                       labels.Labels
+		hints                      ParserHint
+	}{
+		{
+			"kv",
+			";", ":",
+			[]byte(`level:info;msg:hello world; caller : main.go`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "caller", "main.go", "level", "info", "msg", "hello world"),
+			NoParserHints(),
+		},
+		{
+			"quoted value containing the separator",
+			"&", "=",
+			[]byte(`a=1&b="x&y \"z\""&c=3`),
+			labels.EmptyLabels(),
+			labels.FromStrings("a", "1", "b", `x&y "z"`, "c", "3"),
+			NoParserHints(),
+		},
+		{
+			"pairs without value and invalid keys",
+			";", ":",
+			[]byte(`alone;:empty;foo-bar:baz;foo:1`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "foo_bar", "baz", "foo_extracted", "1"),
+			NoParserHints(),
+		},
+		{
+			"hints",
+			";", ":",
+			[]byte(`level:info;msg:hello;caller:main.go`),
+			labels.EmptyLabels(),
+			labels.FromStrings("msg", "hello"),
+			NewParserHint([]string{"msg"}, nil, false, true, "", nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewKVParser(tt.pairSeparator, tt.kvSeparator)
+			require.NoError(t, err)
+
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = p.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+const soapEnvelope = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope">
+  <soap:Body>
+    <User id="42" role="admin">
+      <Name> John </Name>
+      <Email>john@example.com</Email>
+    </User>
+    <Status>ok</Status>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestXMLParser(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  []byte
+		lbs   labels.Labels
+		want  labels.Labels
+		hints ParserHint
+	}{
+		{
+			"soap",
+			[]byte(soapEnvelope),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"Envelope_Body_User_id", "42",
+				"Envelope_Body_User_role", "admin",
+				"Envelope_Body_User_Name", "John",
+				"Envelope_Body_User_Email", "john@example.com",
+				"Envelope_Body_Status", "ok",
+			),
+			NoParserHints(),
+		},
+		{
+			"duplicate label",
+			[]byte(`<foo>baz</foo>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "foo_extracted", "baz"),
+			NoParserHints(),
+		},
+		{
+			"not xml",
+			[]byte(`{"foo":"bar"}`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "__error__", "XMLParserErr"),
+			NoParserHints(),
+		},
+		{
+			"malformed xml",
+			[]byte(`<a><b>foo</a>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar",
+				"__error__", "XMLParserErr",
+				"__error_details__", "XML syntax error on line 1: element <b> closed by </a>",
+			),
+			NoParserHints(),
+		},
+		{
+			"hints",
+			[]byte(soapEnvelope),
+			labels.EmptyLabels(),
+			labels.FromStrings("Envelope_Body_User_Name", "John"),
+			NewParserHint([]string{"Envelope_Body_User_Name"}, nil, false, true, "", nil),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewXMLParser()
+			b := NewBaseLabelsBuilderWithGrouping(nil, tt.hints, false, false).ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = p.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestXMLExpressionParser(t *testing.T) {
+	tests := []struct {
+		name        string
+		expressions []LabelExtractionExpr
+		line        []byte
+		lbs         labels.Labels
+		want        labels.Labels
+	}{
+		{
+			"absolute paths and attributes",
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("name", "/Envelope/Body/User/Name"),
+				NewLabelExtractionExpr("id", "/Envelope/Body/User/@id"),
+				NewLabelExtractionExpr("status", "/Envelope/*/Status"),
+			},
+			[]byte(soapEnvelope),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "id", "42", "name", "John", "status", "ok"),
+		},
+		{
+			"any depth and missing path",
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("email", "//Email"),
+				NewLabelExtractionExpr("missing", "/Envelope/Header"),
+			},
+			[]byte(soapEnvelope),
+			labels.EmptyLabels(),
+			labels.FromStrings("email", "john@example.com", "missing", ""),
+		},
+		{
+			"first match and element text",
+			[]LabelExtractionExpr{
+				NewLabelExtractionExpr("item", "/items/item"),
+				NewLabelExtractionExpr("foo", "/items"),
+			},
+			[]byte(`<items><item>a</item><item>b</item></items>`),
+			labels.FromStrings("foo", "bar"),
+			labels.FromStrings("foo", "bar", "foo_extracted", "ab", "item", "a"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewXMLExpressionParser(tt.expressions)
+			require.NoError(t, err)
+
+			b := NewBaseLabelsBuilder().ForLabels(tt.lbs, tt.lbs.Hash())
+			b.Reset()
+			_, _ = p.Process(0, tt.line, b)
+			require.Equal(t, tt.want, b.LabelsResult().Labels())
+		})
+	}
+}
+
+func TestXMLExpressionParserFailures(t *testing.T) {
+	for _, expr := range []string{"Envelope", "/", "/a//b", "/a/@b/c", "/@id", "/a[1]"} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := NewXMLExpressionParser([]LabelExtractionExpr{NewLabelExtractionExpr("foo", expr)})
+			require.Error(t, err)
+		})
+	}
+}
@@ -255,6 +255,34 @@ func aggregator(r *syntax.RangeAggregationExpr) (BatchRangeVectorAggregator, err
 	}
 }
 
+// subqueryAggregator returns the aggregator of a range aggregation over a subquery.
+func subqueryAggregator(e *syntax.SubqueryExpr) (BatchRangeVectorAggregator, error) {
+	switch e.Operation {
+	case syntax.OpRangeTypeCount:
+		return countOverTime, nil
+	case syntax.OpRangeTypeSum:
+		return sumOverTime, nil
+	case syntax.OpRangeTypeAvg:
+		return avgOverTime, nil
+	case syntax.OpRangeTypeMax:
+		return maxOverTime, nil
+	case syntax.OpRangeTypeMin:
+		return minOverTime, nil
+	case syntax.OpRangeTypeStddev:
+		return stddevOverTime, nil
+	case syntax.OpRangeTypeStdvar:
+		return stdvarOverTime, nil
+	case syntax.OpRangeTypeQuantile:
+		return quantileOverTime(*e.Params), nil
+	case syntax.OpRangeTypeFirst:
+		return first, nil
+	case syntax.OpRangeTypeLast:
+		return last, nil
+	default:
+		return nil, fmt.Errorf(syntax.UnsupportedErr, e.Operation)
+	}
+}
+
 // rateLogs calculates the per-second rate of log lines or values extracted
 // from log lines
 func rateLogs(selRange time.Duration, computeValues bool) func(samples []promql.FPoint) float64 {
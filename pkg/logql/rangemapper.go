@@ -22,6 +22,19 @@ var splittableVectorOp = map[string]struct{}{
 	syntax.OpTypeTopK:     {},
 	syntax.OpTypeSort:     {},
 	syntax.OpTypeSortDesc: {},
+
+	syntax.OpTypeCountValues: {},
+	syntax.OpTypeGroup:       {},
+	syntax.OpTypeLimitK:      {},
+	syntax.OpTypeQuantile:    {},
+}
+
+// vectorAggrPushdownOp lists the splittable vector operations which can also be pushed down to the downstream queries.
+var vectorAggrPushdownOp = map[string]struct{}{
+	syntax.OpTypeSum: {},
+	syntax.OpTypeMax: {},
+	syntax.OpTypeMin: {},
+	syntax.OpTypeAvg: {},
 }
 
 var splittableRangeVectorOp = map[string]struct{}{
@@ -175,6 +188,22 @@ func (m RangeMapper) Map(expr syntax.SampleExpr, vectorAggrPushdown *syntax.Vect
 		}
 		e.Left = lhsMapped
 		return e, nil
+	case *syntax.LabelJoinExpr:
+		lhsMapped, err := m.Map(e.Left, vectorAggrPushdown, recorder)
+		if err != nil {
+			return nil, err
+		}
+		e.Left = lhsMapped
+		return e, nil
+	case *syntax.SubqueryExpr:
+		// the range aggregation of a subquery is applied on the frontend,
+		// only its inner expression can be split.
+		lhsMapped, err := m.Map(e.Left, nil, recorder)
+		if err != nil {
+			return nil, err
+		}
+		e.Left = lhsMapped
+		return e, nil
 	case *syntax.LiteralExpr:
 		return e, nil
 	case *syntax.VectorExpr:
@@ -206,12 +235,12 @@ func hasLabelExtractionStage(expr syntax.SampleExpr) bool {
 	found := false
 	expr.Walk(func(e syntax.Expr) {
 		switch concrete := e.(type) {
-		case *syntax.LogfmtParserExpr:
+		case *syntax.LogfmtParserExpr, *syntax.KVParserExpr, *syntax.ExplodeExpr:
 			found = true
 		case *syntax.LabelParserExpr:
 			// It will **not** return true for `regexp`, `unpack` and `pattern`, since these label extraction
 			// stages can control how many labels, and therefore the resulting amount of series, are extracted.
-			if concrete.Op == syntax.OpParserTypeJSON {
+			if concrete.Op == syntax.OpParserTypeJSON || concrete.Op == syntax.OpParserTypeXML {
 				found = true
 			}
 		}
@@ -369,16 +398,17 @@ func (m RangeMapper) rangeSplitAlign(
 		return expr
 	}
 
-	align := m.splitAlignTs.Sub(m.splitAlignTs.Truncate(m.splitByInterval)) // say, 12:34:00 - 12:00:00(truncated) = 34m
+	// The range ends at the query time shifted by the original offset, so
+	// that is the timestamp the subqueries have to be aligned to.
+	windowEnd := m.splitAlignTs.Add(-originalOffset)
+	align := windowEnd.Sub(windowEnd.Truncate(m.splitByInterval)) // say, 12:34:00 - 12:00:00(truncated) = 34m
 
 	if align == 0 {
 		return m.rangeSplit(expr, rangeInterval, recorder) // Don't have to align
 	}
 
 	var (
-		newRng = align
-
-		// TODO(kavi): If the originalOffset is non-zero, there may be a edge case, where subqueries generated won't be aligned correctly. Handle this edge case in separate PR.
+		newRng               = align
 		newOffset            = originalOffset
 		downstreams          *ConcatSampleExpr
 		pendingRangeInterval = rangeInterval
@@ -456,8 +486,10 @@ func (m RangeMapper) mapVectorAggregationExpr(expr *syntax.VectorAggregationExpr
 	// This does not work for `count()` and `topk()`, though.
 	// We also do not want to push down, if the inner expression is a binary operation.
 	var vectorAggrPushdown *syntax.VectorAggregationExpr
-	if _, ok := expr.Left.(*syntax.BinOpExpr); !ok && expr.Operation != syntax.OpTypeCount && expr.Operation != syntax.OpTypeTopK && expr.Operation != syntax.OpTypeSort && expr.Operation != syntax.OpTypeSortDesc {
-		vectorAggrPushdown = expr
+	if _, ok := vectorAggrPushdownOp[expr.Operation]; ok {
+		if _, ok := expr.Left.(*syntax.BinOpExpr); !ok {
+			vectorAggrPushdown = expr
+		}
 	}
 
 	// Split the vector aggregation's inner expression
@@ -467,10 +499,12 @@ func (m RangeMapper) mapVectorAggregationExpr(expr *syntax.VectorAggregationExpr
 	}
 
 	return &syntax.VectorAggregationExpr{
-		Left:      lhsMapped,
-		Grouping:  expr.Grouping,
-		Params:    expr.Params,
-		Operation: expr.Operation,
+		Left:       lhsMapped,
+		Grouping:   expr.Grouping,
+		Params:     expr.Params,
+		Operation:  expr.Operation,
+		Quantile:   expr.Quantile,
+		ValueLabel: expr.ValueLabel,
 	}, nil
 }
 
@@ -570,6 +604,10 @@ func isSplittableByRange(expr syntax.SampleExpr) bool {
 		return isSplittableByRange(e.SampleExpr) || literalLHS && isSplittableByRange(e.RHS) || literalRHS
 	case *syntax.LabelReplaceExpr:
 		return isSplittableByRange(e.Left)
+	case *syntax.LabelJoinExpr:
+		return isSplittableByRange(e.Left)
+	case *syntax.SubqueryExpr:
+		return isSplittableByRange(e.Left)
 	case *syntax.VectorExpr:
 		return false
 	default:
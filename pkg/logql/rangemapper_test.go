@@ -118,13 +118,14 @@ func Test_RangeMapperSplitAlign(t *testing.T) {
 			name: "query_time_aligned_with_split_by_with_original_offset",
 			expr: `bytes_over_time({app="foo"}[3m] offset 20m10s)`, // NOTE: original query has offset, which should be considered in all the splits subquery
 			expected: `sum without() (
-                               downstream<bytes_over_time({app="foo"}[1m] offset 22m10s), shard=<nil>>
-                               ++ downstream<bytes_over_time({app="foo"}[1m] offset 21m10s), shard=<nil>>
-                               ++ downstream<bytes_over_time({app="foo"}[1m] offset 20m10s), shard=<nil>>
+                               downstream<bytes_over_time({app="foo"}[10s] offset 23m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1m] offset 22m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1m] offset 21m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[50s] offset 20m10s), shard=<nil>>
                         )`,
 			queryTime:        time.Unix(60, 0), // 1970 00:01:00
 			splityByInterval: 1 * time.Minute,
-			expectedSplits:   3,
+			expectedSplits:   4,
 		},
 		{
 			name: "query_time_not_aligned_with_split_by",
@@ -143,15 +144,27 @@ func Test_RangeMapperSplitAlign(t *testing.T) {
 			name: "query_time_not_aligned_with_split_by_with_original_offset",
 			expr: `bytes_over_time({app="foo"}[3h] offset 1h2m20s)`, // NOTE: original query has offset, which should be considered in all the splits subquery
 			expected: `sum without() (
-                               downstream<bytes_over_time({app="foo"}[6m] offset 3h56m20s), shard=<nil>>
-                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 2h56m20s), shard=<nil>>
-                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 1h56m20s), shard=<nil>>
-                               ++ downstream<bytes_over_time({app="foo"}[54m] offset 1h2m20s), shard=<nil>>
+                               downstream<bytes_over_time({app="foo"}[8m20s] offset 3h54m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 2h54m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 1h54m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[51m40s] offset 1h2m20s), shard=<nil>>
                         )`,
 			queryTime:        time.Date(0, 0, 0, 12, 54, 0, 0, time.UTC), // 1970 12:54:00
 			splityByInterval: 1 * time.Hour,
 			expectedSplits:   4,
 		},
+		{
+			name: "query_time_aligned_with_split_by_after_timeshift",
+			expr: `timeshift(bytes_over_time({app="foo"}[3h]), 1h34m)`, // NOTE: the range ends at 11:00:00, so no alignment split is needed
+			expected: `sum without() (
+                               downstream<bytes_over_time({app="foo"}[1h] offset 3h34m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 2h34m0s), shard=<nil>>
+                               ++ downstream<bytes_over_time({app="foo"}[1h] offset 1h34m0s), shard=<nil>>
+                        )`,
+			queryTime:        time.Date(0, 0, 0, 12, 34, 0, 0, time.UTC), // 1970 12:34:00
+			splityByInterval: 1 * time.Hour,
+			expectedSplits:   3,
+		},
 	}
 
 	for _, tc := range cases {
@@ -1762,6 +1775,43 @@ func Test_SplitRangeVectorMapping(t *testing.T) {
 			)`,
 			3,
 		},
+		{
+			`group by (bar) (count_over_time({app="foo"}[3m]))`,
+			`group by (bar) (
+				sum without () (
+					   downstream<count_over_time({app="foo"}[1m] offset 2m0s), shard=<nil>>
+					++ downstream<count_over_time({app="foo"}[1m] offset 1m0s), shard=<nil>>
+					++ downstream<count_over_time({app="foo"}[1m]), shard=<nil>>
+				)
+			)`,
+			3,
+		},
+		{
+			`quantile(0.9, count_over_time({app="foo"}[3m]))`,
+			`quantile(0.9,
+				sum without () (
+					   downstream<count_over_time({app="foo"}[1m] offset 2m0s), shard=<nil>>
+					++ downstream<count_over_time({app="foo"}[1m] offset 1m0s), shard=<nil>>
+					++ downstream<count_over_time({app="foo"}[1m]), shard=<nil>>
+				)
+			)`,
+			3,
+		},
+
+		// subqueries
+		{
+			`max_over_time(sum by (bar) (count_over_time({app="foo"}[3m]))[1h:1m])`,
+			`max_over_time(
+				sum by (bar) (
+					sum without () (
+						   downstream<sum by (bar) (count_over_time({app="foo"}[1m] offset 2m0s)), shard=<nil>>
+						++ downstream<sum by (bar) (count_over_time({app="foo"}[1m] offset 1m0s)), shard=<nil>>
+						++ downstream<sum by (bar) (count_over_time({app="foo"}[1m])), shard=<nil>>
+					)
+				)[1h:1m]
+			)`,
+			3,
+		},
 
 		// regression test queries
 		{
@@ -1844,6 +1894,12 @@ func Test_SplitRangeVectorMapping_Noop(t *testing.T) {
 			`sum(avg_over_time({app="foo"} | unwrap bar[3m]))`,
 		},
 
+		// the range of a subquery is not split
+		{
+			`max_over_time(sum(count_over_time({app="foo"}[1m]))[1h:1m])`,
+			`max_over_time(sum(count_over_time({app="foo"}[1m]))[1h:1m])`,
+		},
+
 		// should be noop if range interval is lower or equal to split interval (1m)
 		{
 			`bytes_over_time({app="foo"}[1m])`,
@@ -145,6 +145,10 @@ func TestMappingStrings(t *testing.T) {
 			out: `downstream<{foo="bar"} |="foo" |~"bar" | json | (latency>=10s or (foo<5,bar="t")) | line_format "b{{.blip}}", shard=0_of_2>
 					++downstream<{foo="bar"} |="foo" |~"bar" | json | (latency>=10s or (foo<5, bar="t")) | line_format "b{{.blip}}", shard=1_of_2>`,
 		},
+		{
+			in:  `{foo="bar"} | dedup by (pod) within 1m | limit 5`,
+			out: `{foo="bar"} | dedup by (pod) within 1m | limit 5`,
+		},
 		{
 			in: `sum(rate({foo="bar"}[1m]))`,
 			out: `sum(
@@ -152,6 +156,13 @@ func TestMappingStrings(t *testing.T) {
 				++ downstream<sum(rate({foo="bar"}[1m])), shard=1_of_2>
 			)`,
 		},
+		{
+			in: `sum(rate({foo="bar"} |=* "foo" |~~ "tiemout" 1 [1m]))`,
+			out: `sum(
+				downstream<sum(rate({foo="bar"} |=* "foo" |~~ "tiemout" 1 [1m])), shard=0_of_2>
+				++ downstream<sum(rate({foo="bar"} |=* "foo" |~~ "tiemout" 1 [1m])), shard=1_of_2>
+			)`,
+		},
 		{
 			in: `max(count(rate({foo="bar"}[5m]))) / 2`,
 			out: `(max(
@@ -465,6 +476,32 @@ func TestMappingStrings(t *testing.T) {
 			in:  `count by (foo) (sum by (foo, bar) (rate({job="bar"}[1m])))`,
 			out: `countby(foo)(sumby(foo,bar)(downstream<sumby(foo,bar)(rate({job="bar"}[1m])),shard=0_of_2>++downstream<sumby(foo,bar)(rate({job="bar"}[1m])),shard=1_of_2>))`,
 		},
+		{
+			// group only keeps the labels of the groups, it is sharded even with label reduction in children
+			in:  `group by (foo) (sum by (foo, bar) (rate({job="bar"}[1m])))`,
+			out: `groupby(foo)(downstream<groupby(foo)(sumby(foo,bar)(rate({job="bar"}[1m]))),shard=0_of_2>++downstream<groupby(foo)(sumby(foo,bar)(rate({job="bar"}[1m]))),shard=1_of_2>)`,
+		},
+		{
+			// quantile, count_values and limitk need all the series, only their children are sharded
+			in:  `quantile(0.9, sum by (foo) (rate({job="bar"}[1m])))`,
+			out: `quantile(0.9,sumby(foo)(downstream<sumby(foo)(rate({job="bar"}[1m])),shard=0_of_2>++downstream<sumby(foo)(rate({job="bar"}[1m])),shard=1_of_2>))`,
+		},
+		{
+			in:  `count_values("value", sum by (foo) (rate({job="bar"}[1m])))`,
+			out: `count_values("value",sumby(foo)(downstream<sumby(foo)(rate({job="bar"}[1m])),shard=0_of_2>++downstream<sumby(foo)(rate({job="bar"}[1m])),shard=1_of_2>))`,
+		},
+		{
+			in:  `label_join(sum by (foo, bar) (rate({job="bar"}[1m])), "foobar", "-", "foo", "bar")`,
+			out: `label_join(sumby(foo,bar)(downstream<sumby(foo,bar)(rate({job="bar"}[1m])),shard=0_of_2>++downstream<sumby(foo,bar)(rate({job="bar"}[1m])),shard=1_of_2>),"foobar","-","foo","bar")`,
+		},
+		{
+			in:  `max_over_time(sum by (foo) (rate({job="bar"}[1m]))[1h:1m])`,
+			out: `max_over_time(sumby(foo)(downstream<sumby(foo)(rate({job="bar"}[1m])),shard=0_of_2>++downstream<sumby(foo)(rate({job="bar"}[1m])),shard=1_of_2>)[1h:1m])`,
+		},
+		{
+			in:  `quantile_over_time(0.9, max(rate({job="bar"}[1m]))[1h:1m] offset 5m)`,
+			out: `quantile_over_time(0.9,max(downstream<max(rate({job="bar"}[1m])),shard=0_of_2>++downstream<max(rate({job="bar"}[1m])),shard=1_of_2>)[1h:1m]offset5m0s)`,
+		},
 	} {
 		t.Run(tc.in, func(t *testing.T) {
 			ast, err := syntax.ParseExpr(tc.in)
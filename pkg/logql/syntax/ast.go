@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/grafana/loki/v3/pkg/util"
 
@@ -98,6 +99,10 @@ func ExtractLabelFiltersBeforeParser(e Expr) []*LabelFilterExpr {
 		VisitLabelParserFn:            func(v RootVisitor, e *LabelParserExpr) { foundParseStage = true },
 		VisitJSONExpressionParserFn:   func(v RootVisitor, e *JSONExpressionParser) { foundParseStage = true },
 		VisitLogfmtExpressionParserFn: func(v RootVisitor, e *LogfmtExpressionParser) { foundParseStage = true },
+		VisitCSVParserFn:              func(v RootVisitor, e *CSVParserExpr) { foundParseStage = true },
+		VisitKVParserFn:               func(v RootVisitor, e *KVParserExpr) { foundParseStage = true },
+		VisitXMLExpressionParserFn:    func(v RootVisitor, e *XMLExpressionParser) { foundParseStage = true },
+		VisitExplodeFn:                func(v RootVisitor, e *ExplodeExpr) { foundParseStage = true },
 		VisitLabelFmtFn:               func(v RootVisitor, e *LabelFmtExpr) { foundParseStage = true },
 		VisitKeepLabelFn:              func(v RootVisitor, e *KeepLabelsExpr) { foundParseStage = true },
 		VisitDropLabelsFn:             func(v RootVisitor, e *DropLabelsExpr) { foundParseStage = true },
@@ -379,7 +384,7 @@ func (e *PipelineExpr) HasFilter() bool {
 			return true
 		case *LineFilterExpr:
 			// ignore empty matchers as they match everything
-			if !((v.Ty == log.LineMatchEqual || v.Ty == log.LineMatchRegexp) && v.Match == "") {
+			if !((v.Ty == log.LineMatchEqual || v.Ty == log.LineMatchRegexp || v.Ty == log.LineMatchEqualCaseInsensitive) && v.Match == "") {
 				return true
 			}
 		default:
@@ -393,6 +398,8 @@ type LineFilter struct {
 	Ty    log.LineMatchType
 	Match string
 	Op    string
+	// Distance is the maximum edit distance of fuzzy line filters.
+	Distance int
 }
 
 type LineFilterExpr struct {
@@ -424,7 +431,24 @@ func newLineFilterExpr(ty log.LineMatchType, op, match string) *LineFilterExpr {
 	}
 }
 
+func newFuzzyLineFilterExpr(ty log.LineMatchType, match, distance string) *LineFilterExpr {
+	d, err := strconv.Atoi(distance)
+	if err != nil || d < 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid fuzzy line filter: distance must be a positive integer, got %s", distance), 0, 0))
+	}
+	if d >= utf8.RuneCountInString(match) {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid fuzzy line filter: distance %d must be lower than the length of %q", d, match), 0, 0))
+	}
+	e := newLineFilterExpr(ty, "", match)
+	e.Distance = d
+	return e
+}
+
 func newOrLineFilter(left, right *LineFilterExpr) *LineFilterExpr {
+	if left.Ty == log.LineMatchFuzzy || left.Ty == log.LineMatchNotFuzzy {
+		panic(logqlmodel.NewParseError("invalid fuzzy line filter: fuzzy line filters can't be combined with or", 0, 0))
+	}
+
 	right.Ty = left.Ty
 
 	// NOTE: Consider, we have chain of "or", != "foo" or "bar" or "baz"
@@ -438,7 +462,7 @@ func newOrLineFilter(left, right *LineFilterExpr) *LineFilterExpr {
 		tmp = tmp.Or
 	}
 
-	if left.Ty == log.LineMatchEqual || left.Ty == log.LineMatchRegexp || left.Ty == log.LineMatchPattern {
+	if left.Ty == log.LineMatchEqual || left.Ty == log.LineMatchRegexp || left.Ty == log.LineMatchPattern || left.Ty == log.LineMatchEqualCaseInsensitive {
 		left.Or = right
 		right.IsOrChild = true
 		return left
@@ -451,7 +475,7 @@ func newOrLineFilter(left, right *LineFilterExpr) *LineFilterExpr {
 func newNestedLineFilterExpr(left *LineFilterExpr, right *LineFilterExpr) *LineFilterExpr {
 	// NOTE: When parsing "or" chains in linefilter, particularly variations of NOT filters (!= or !~), we need to transform
 	// say (!= "foo" or "bar "baz") => (!="foo" != "bar" != "baz")
-	if right.Or != nil && !(right.Ty == log.LineMatchEqual || right.Ty == log.LineMatchRegexp || right.Ty == log.LineMatchPattern) {
+	if right.Or != nil && !(right.Ty == log.LineMatchEqual || right.Ty == log.LineMatchRegexp || right.Ty == log.LineMatchPattern || right.Ty == log.LineMatchEqualCaseInsensitive) {
 		right.Or.IsOrChild = false
 		tmp := right.Or
 		right.Or = nil
@@ -541,6 +565,10 @@ func (e *LineFilterExpr) String() string {
 
 	if e.Op == "" {
 		sb.WriteString(strconv.Quote(e.Match))
+		if e.Ty == log.LineMatchFuzzy || e.Ty == log.LineMatchNotFuzzy {
+			sb.WriteString(" ")
+			sb.WriteString(strconv.Itoa(e.Distance))
+		}
 	} else {
 		sb.WriteString(e.Op)
 		sb.WriteString("(")
@@ -577,7 +605,12 @@ func (e *LineFilterExpr) Filter() (log.Filterer, error) {
 				}
 				acc = append(acc, next)
 			default:
-				next, err = log.NewFilter(curr.Match, curr.Ty)
+				switch curr.Ty {
+				case log.LineMatchFuzzy, log.LineMatchNotFuzzy:
+					next, err = log.NewFuzzyFilter(curr.Match, curr.Distance, curr.Ty)
+				default:
+					next, err = log.NewFilter(curr.Match, curr.Ty)
+				}
 				if err != nil {
 					return nil, err
 				}
@@ -721,6 +754,8 @@ func (e *LabelParserExpr) Stage() (log.Stage, error) {
 		return log.NewUnpackParser(), nil
 	case OpParserTypePattern:
 		return log.NewPatternParser(e.Param)
+	case OpParserTypeXML:
+		return log.NewXMLParser(), nil
 	default:
 		return nil, fmt.Errorf("unknown parser operator: %s", e.Op)
 	}
@@ -1061,6 +1096,304 @@ func (l *LogfmtExpressionParser) String() string {
 	return sb.String()
 }
 
+// ParserOption is a named option of a parser stage, e.g. `delimiter="\t"`.
+type ParserOption struct {
+	Name  string
+	Value string
+}
+
+const (
+	defaultCSVDelimiter = ","
+	defaultCSVQuote     = `"`
+)
+
+type CSVParserExpr struct {
+	Columns   []string
+	Delimiter string
+	Quote     string
+
+	implicit
+}
+
+func newCSVParserExpr(columns []string, options []ParserOption) *CSVParserExpr {
+	e := CSVParserExpr{
+		Columns:   columns,
+		Delimiter: defaultCSVDelimiter,
+		Quote:     defaultCSVQuote,
+	}
+
+	for _, o := range options {
+		switch o.Name {
+		case OpCSVDelimiter:
+			e.Delimiter = o.Value
+		case OpCSVQuote:
+			e.Quote = o.Value
+		default:
+			panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parser option: %s", o.Name), 0, 0))
+		}
+	}
+
+	if _, err := log.NewCSVParser(e.Columns, e.Delimiter, e.Quote); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid csv parser: %s", err.Error()), 0, 0))
+	}
+
+	return &e
+}
+
+func (*CSVParserExpr) isStageExpr() {}
+
+func (e *CSVParserExpr) Shardable(_ bool) bool { return true }
+
+func (e *CSVParserExpr) Walk(f WalkFn) { f(e) }
+
+func (e *CSVParserExpr) Accept(v RootVisitor) { v.VisitCSVParser(e) }
+
+func (e *CSVParserExpr) Stage() (log.Stage, error) {
+	return log.NewCSVParser(e.Columns, e.Delimiter, e.Quote)
+}
+
+func (e *CSVParserExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s(%s)", OpPipe, OpParserTypeCSV, strings.Join(e.Columns, ", ")))
+
+	if e.Delimiter != defaultCSVDelimiter {
+		sb.WriteString(fmt.Sprintf(" %s=%s", OpCSVDelimiter, strconv.Quote(e.Delimiter)))
+	}
+
+	if e.Quote != defaultCSVQuote {
+		sb.WriteString(fmt.Sprintf(" %s=%s", OpCSVQuote, strconv.Quote(e.Quote)))
+	}
+
+	return sb.String()
+}
+
+type KVParserExpr struct {
+	PairSeparator string
+	KVSeparator   string
+
+	implicit
+}
+
+func newKVParserExpr(pairSeparator, kvSeparator string) *KVParserExpr {
+	if _, err := log.NewKVParser(pairSeparator, kvSeparator); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid kv parser: %s", err.Error()), 0, 0))
+	}
+
+	return &KVParserExpr{
+		PairSeparator: pairSeparator,
+		KVSeparator:   kvSeparator,
+	}
+}
+
+func (*KVParserExpr) isStageExpr() {}
+
+func (e *KVParserExpr) Shardable(_ bool) bool { return true }
+
+func (e *KVParserExpr) Walk(f WalkFn) { f(e) }
+
+func (e *KVParserExpr) Accept(v RootVisitor) { v.VisitKVParser(e) }
+
+func (e *KVParserExpr) Stage() (log.Stage, error) {
+	return log.NewKVParser(e.PairSeparator, e.KVSeparator)
+}
+
+func (e *KVParserExpr) String() string {
+	return fmt.Sprintf("%s %s(%s, %s)", OpPipe, OpParserTypeKV, strconv.Quote(e.PairSeparator), strconv.Quote(e.KVSeparator))
+}
+
+type XMLExpressionParser struct {
+	Expressions []log.LabelExtractionExpr
+
+	implicit
+}
+
+func newXMLExpressionParser(expressions []log.LabelExtractionExpr) *XMLExpressionParser {
+	if _, err := log.NewXMLExpressionParser(expressions); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid xml parser: %s", err.Error()), 0, 0))
+	}
+
+	return &XMLExpressionParser{
+		Expressions: expressions,
+	}
+}
+
+func (*XMLExpressionParser) isStageExpr() {}
+
+func (x *XMLExpressionParser) Shardable(_ bool) bool { return true }
+
+func (x *XMLExpressionParser) Walk(f WalkFn) { f(x) }
+
+func (x *XMLExpressionParser) Accept(v RootVisitor) { v.VisitXMLExpressionParser(x) }
+
+func (x *XMLExpressionParser) Stage() (log.Stage, error) {
+	return log.NewXMLExpressionParser(x.Expressions)
+}
+
+func (x *XMLExpressionParser) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s ", OpPipe, OpParserTypeXML))
+	for i, exp := range x.Expressions {
+		sb.WriteString(exp.Identifier)
+		sb.WriteString("=")
+		sb.WriteString(strconv.Quote(exp.Expression))
+
+		if i+1 != len(x.Expressions) {
+			sb.WriteString(",")
+		}
+	}
+	return sb.String()
+}
+
+type ExplodeExpr struct {
+	Field string
+
+	implicit
+}
+
+func newExplodeExpr(field string) *ExplodeExpr {
+	if _, err := log.NewExplodeStage(field); err != nil {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid explode stage: %s", err.Error()), 0, 0))
+	}
+
+	return &ExplodeExpr{
+		Field: field,
+	}
+}
+
+func (*ExplodeExpr) isStageExpr() {}
+
+func (e *ExplodeExpr) Shardable(_ bool) bool { return true }
+
+func (e *ExplodeExpr) Walk(f WalkFn) { f(e) }
+
+func (e *ExplodeExpr) Accept(v RootVisitor) { v.VisitExplode(e) }
+
+func (e *ExplodeExpr) Stage() (log.Stage, error) {
+	return log.NewExplodeStage(e.Field)
+}
+
+func (e *ExplodeExpr) String() string {
+	if model.LabelName(e.Field).IsValid() {
+		return fmt.Sprintf("%s %s %s", OpPipe, OpExplode, e.Field)
+	}
+	return fmt.Sprintf("%s %s %s", OpPipe, OpExplode, strconv.Quote(e.Field))
+}
+
+// DedupExpr suppresses the repeated lines of a group of labels, by default the whole series,
+// within each window of the given duration. The window is required to bound the lines to remember.
+// e.g: | dedup by (pod) within 1m
+//
+// It is evaluated over the merged entries of a log query instead of in the pipeline, see HasMergeStages.
+type DedupExpr struct {
+	Grouping *Grouping
+	Within   time.Duration
+
+	implicit
+}
+
+func newDedupExpr(grouping *Grouping, within time.Duration) *DedupExpr {
+	if within == 0 {
+		panic(logqlmodel.NewParseError("invalid dedup stage: a within window is required", 0, 0))
+	}
+	if within < 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid dedup stage: negative window %s", within), 0, 0))
+	}
+	return &DedupExpr{
+		Grouping: grouping,
+		Within:   within,
+	}
+}
+
+func (*DedupExpr) isStageExpr() {}
+
+func (e *DedupExpr) Shardable(_ bool) bool { return false }
+
+func (e *DedupExpr) Walk(f WalkFn) { f(e) }
+
+func (e *DedupExpr) Accept(v RootVisitor) { v.VisitDedup(e) }
+
+func (e *DedupExpr) Stage() (log.Stage, error) {
+	return log.NoopStage, nil
+}
+
+func (e *DedupExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpPipe)
+	sb.WriteString(" ")
+	sb.WriteString(OpDedup)
+	if e.Grouping != nil {
+		sb.WriteString(e.Grouping.String())
+	}
+	if e.Within != 0 {
+		sb.WriteString(" ")
+		sb.WriteString(OpWithin)
+		sb.WriteString(" ")
+		sb.WriteString(model.Duration(e.Within).String())
+	}
+	return sb.String()
+}
+
+// LimitByExpr caps the number of entries of a group of labels, by default the whole series.
+// e.g: | limit 5 by (pod)
+//
+// It is evaluated over the merged entries of a log query instead of in the pipeline, see HasMergeStages.
+type LimitByExpr struct {
+	Limit    int
+	Grouping *Grouping
+
+	implicit
+}
+
+func newLimitByExpr(limit string, grouping *Grouping) *LimitByExpr {
+	n, err := strconv.Atoi(limit)
+	if err != nil || n <= 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid limit stage: limit must be a positive integer, got %s", limit), 0, 0))
+	}
+	return &LimitByExpr{
+		Limit:    n,
+		Grouping: grouping,
+	}
+}
+
+func (*LimitByExpr) isStageExpr() {}
+
+func (e *LimitByExpr) Shardable(_ bool) bool { return false }
+
+func (e *LimitByExpr) Walk(f WalkFn) { f(e) }
+
+func (e *LimitByExpr) Accept(v RootVisitor) { v.VisitLimitBy(e) }
+
+func (e *LimitByExpr) Stage() (log.Stage, error) {
+	return log.NoopStage, nil
+}
+
+func (e *LimitByExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpPipe)
+	sb.WriteString(" ")
+	sb.WriteString(OpLimit)
+	sb.WriteString(" ")
+	sb.WriteString(strconv.Itoa(e.Limit))
+	if e.Grouping != nil {
+		sb.WriteString(e.Grouping.String())
+	}
+	return sb.String()
+}
+
+// HasMergeStages returns true if the expression has dedup or limit stages.
+// Those stages depend on the entries of all the streams in order up to the query limit,
+// so queries with them are neither split by time nor sharded.
+func HasMergeStages(expr Expr) bool {
+	var found bool
+	expr.Walk(func(e Expr) {
+		switch e.(type) {
+		case *DedupExpr, *LimitByExpr:
+			found = true
+		}
+	})
+	return found
+}
+
 func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
 	m, err := labels.NewMatcher(t, n, v)
 	if err != nil {
@@ -1203,6 +1536,28 @@ func newOffsetExpr(offset time.Duration) *OffsetExpr {
 	}
 }
 
+// mustNewTimeShiftExpr shifts every log range of the given sample expression
+// back in time by the given duration. Applying `offset` to a subexpression or
+// calling `timeshift()` is desugared into offsets on the underlying log ranges
+// so that splitting, sharding and evaluation keep working on a single
+// representation. Expressions without any log range, like `vector(1)`, can't
+// be shifted and are rejected.
+func mustNewTimeShiftExpr(expr SampleExpr, shift time.Duration) SampleExpr {
+	var ranges []*LogRange
+	expr.Walk(func(e Expr) {
+		if r, ok := e.(*LogRange); ok {
+			ranges = append(ranges, r)
+		}
+	})
+	if len(ranges) == 0 {
+		panic(logqlmodel.NewParseError(fmt.Sprintf("invalid offset: %s has no log range to shift", expr), 0, 0))
+	}
+	for _, r := range ranges {
+		r.Offset += shift
+	}
+	return expr
+}
+
 const (
 	// vector ops
 	OpTypeSum      = "sum"
@@ -1217,6 +1572,11 @@ const (
 	OpTypeSort     = "sort"
 	OpTypeSortDesc = "sort_desc"
 
+	OpTypeCountValues = "count_values"
+	OpTypeGroup       = "group"
+	OpTypeLimitK      = "limitk"
+	OpTypeQuantile    = "quantile"
+
 	// range vector ops
 	OpRangeTypeCount       = "count_over_time"
 	OpRangeTypeRate        = "rate"
@@ -1264,6 +1624,9 @@ const (
 	OpParserTypeRegexp  = "regexp"
 	OpParserTypeUnpack  = "unpack"
 	OpParserTypePattern = "pattern"
+	OpParserTypeCSV     = "csv"
+	OpParserTypeKV      = "kv"
+	OpParserTypeXML     = "xml"
 
 	OpFmtLine    = "line_format"
 	OpFmtLabel   = "label_format"
@@ -1285,6 +1648,8 @@ const (
 	OpConvDurationSeconds = "duration_seconds"
 
 	OpLabelReplace = "label_replace"
+	OpLabelJoin    = "label_join"
+	OpTimeshift    = "timeshift"
 
 	// function filters
 	OpFilterIP = "ip"
@@ -1292,6 +1657,14 @@ const (
 	// drop labels
 	OpDrop = "drop"
 
+	// explode
+	OpExplode = "explode"
+
+	// dedup and limit
+	OpDedup  = "dedup"
+	OpLimit  = "limit"
+	OpWithin = "within"
+
 	// keep labels
 	OpKeep = "keep"
 
@@ -1299,6 +1672,10 @@ const (
 	OpStrict    = "--strict"
 	OpKeepEmpty = "--keep-empty"
 
+	// csv parser options
+	OpCSVDelimiter = "delimiter"
+	OpCSVQuote     = "quote"
+
 	// internal expressions not represented in LogQL. These are used to
 	// evaluate expressions differently resulting in intermediate formats
 	// that are not consumable by LogQL clients but are used for sharding.
@@ -1477,6 +1854,151 @@ func (e *RangeAggregationExpr) Walk(f WalkFn) {
 
 func (e *RangeAggregationExpr) Accept(v RootVisitor) { v.VisitRangeAggregation(e) }
 
+// SubqueryExpr is a range aggregation over the samples of a metric expression
+// evaluated at a fixed resolution, such as `max_over_time(sum(rate({app="foo"}[1m]))[1h:1m])`.
+type SubqueryExpr struct {
+	Left      SampleExpr
+	Operation string
+	Range     time.Duration
+	Step      time.Duration
+	Offset    time.Duration
+
+	Params *float64
+	err    error
+	implicit
+}
+
+func mustNewSubqueryExpr(left SampleExpr, operation string, rangeStep string, offset *OffsetExpr, stringParams *string) SampleExpr {
+	rng, step, err := parseSubqueryRange(rangeStep)
+	if err != nil {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(err.Error(), 0, 0)}
+	}
+
+	var params *float64
+	if stringParams != nil {
+		if operation != OpRangeTypeQuantile {
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter %s not supported for operation %s", *stringParams, operation), 0, 0)}
+		}
+		params = new(float64)
+		*params, err = strconv.ParseFloat(*stringParams, 64)
+		if err != nil {
+			return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter for operation %s: %s", operation, err), 0, 0)}
+		}
+	} else if operation == OpRangeTypeQuantile {
+		return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+	}
+
+	switch operation {
+	case OpRangeTypeAvg, OpRangeTypeCount, OpRangeTypeFirst, OpRangeTypeLast, OpRangeTypeMax, OpRangeTypeMin,
+		OpRangeTypeQuantile, OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeSum:
+	default:
+		return &SubqueryExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid aggregation %s over subquery", operation), 0, 0)}
+	}
+
+	e := &SubqueryExpr{
+		Left:      left,
+		Operation: operation,
+		Range:     rng,
+		Step:      step,
+		Params:    params,
+	}
+	if offset != nil {
+		e.Offset = offset.Offset
+	}
+	return e
+}
+
+// parseSubqueryRange parses the `<range>:<resolution>` duration pair of a subquery.
+func parseSubqueryRange(s string) (time.Duration, time.Duration, error) {
+	rangeStr, stepStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid subquery range %q", s)
+	}
+	rng, err := model.ParseDuration(rangeStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if stepStr == "" {
+		return 0, 0, fmt.Errorf("subquery resolution required in %q", s)
+	}
+	step, err := model.ParseDuration(stepStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if rng <= 0 || step <= 0 {
+		return 0, 0, fmt.Errorf("subquery range and resolution must be greater than 0")
+	}
+	return time.Duration(rng), time.Duration(step), nil
+}
+
+func (e *SubqueryExpr) isSampleExpr() {}
+
+func (e *SubqueryExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+// MatcherGroups returns the matcher groups of the inner expression, their
+// ranges extended by the subquery range and offset.
+func (e *SubqueryExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	groups, err := e.Left.MatcherGroups()
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].Interval += e.Range
+		groups[i].Offset += e.Offset
+	}
+	return groups, nil
+}
+
+func (e *SubqueryExpr) Extractor() (SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+// Shardable returns false: the range aggregation needs the complete samples of
+// its subquery, only the subquery itself may be sharded.
+func (e *SubqueryExpr) Shardable(_ bool) bool {
+	return false
+}
+
+func (e *SubqueryExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *SubqueryExpr) Accept(v RootVisitor) { v.VisitSubquery(e) }
+
+// impls Stringer
+func (e *SubqueryExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.Operation)
+	sb.WriteString("(")
+	if e.Params != nil {
+		sb.WriteString(strconv.FormatFloat(*e.Params, 'f', -1, 64))
+		sb.WriteString(",")
+	}
+	sb.WriteString(e.Left.String())
+	sb.WriteString(fmt.Sprintf("[%v:%v]", model.Duration(e.Range), model.Duration(e.Step)))
+	if e.Offset != 0 {
+		offsetExpr := OffsetExpr{Offset: e.Offset}
+		sb.WriteString(offsetExpr.String())
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // Grouping struct represents the grouping by/without label(s) for vector aggregators and range vector aggregators.
 // The representation is as follows:
 //   - No Grouping (labels dismissed): <operation> (<expr>) => Grouping{Without: false, Groups: nil}
@@ -1525,15 +2047,19 @@ type VectorAggregationExpr struct {
 	Grouping  *Grouping `json:"grouping,omitempty"`
 	Params    int       `json:"params"`
 	Operation string    `json:"operation"`
-	err       error
+	// Quantile is the parameter of quantile and ValueLabel the label name parameter of count_values.
+	Quantile   float64 `json:"quantile,omitempty"`
+	ValueLabel string  `json:"value_label,omitempty"`
+	err        error
 	implicit
 }
 
 func mustNewVectorAggregationExpr(left SampleExpr, operation string, gr *Grouping, params *string) SampleExpr {
 	var p int
+	var q float64
 	var err error
 	switch operation {
-	case OpTypeBottomK, OpTypeTopK:
+	case OpTypeBottomK, OpTypeTopK, OpTypeLimitK:
 		if params == nil {
 			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
 		}
@@ -1545,6 +2071,21 @@ func mustNewVectorAggregationExpr(left SampleExpr, operation string, gr *Groupin
 			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter (must be greater than 0) %s(%s", operation, *params), 0, 0)}
 		}
 
+	case OpTypeQuantile:
+		if params == nil {
+			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+		}
+		q, err = strconv.ParseFloat(*params, 64)
+		if err != nil {
+			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter %s(%s,", operation, *params), 0, 0)}
+		}
+
+	case OpTypeCountValues:
+		if params == nil {
+			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0)}
+		}
+		return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid parameter (must be a label name string) %s(%s,", operation, *params), 0, 0)}
+
 	default:
 		if params != nil {
 			return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("unsupported parameter for operation %s(%s,", operation, *params), 0, 0)}
@@ -1558,6 +2099,26 @@ func mustNewVectorAggregationExpr(left SampleExpr, operation string, gr *Groupin
 		Operation: operation,
 		Grouping:  gr,
 		Params:    p,
+		Quantile:  q,
+	}
+}
+
+// mustNewCountValuesExpr creates a vector aggregation taking a label name parameter, which only count_values does.
+func mustNewCountValuesExpr(left SampleExpr, operation string, gr *Grouping, label string) SampleExpr {
+	if operation != OpTypeCountValues {
+		return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("unsupported parameter for operation %s(%q,", operation, label), 0, 0)}
+	}
+	if !model.LabelName(label).IsValid() {
+		return &VectorAggregationExpr{err: logqlmodel.NewParseError(fmt.Sprintf("invalid label name in %s(%q,", operation, label), 0, 0)}
+	}
+	if gr == nil {
+		gr = &Grouping{}
+	}
+	return &VectorAggregationExpr{
+		Left:       left,
+		Operation:  operation,
+		Grouping:   gr,
+		ValueLabel: label,
 	}
 }
 
@@ -1609,8 +2170,12 @@ func (e *VectorAggregationExpr) String() string {
 	var params []string
 	switch e.Operation {
 	// bottomK and topk can have first parameter as 0
-	case OpTypeBottomK, OpTypeTopK:
+	case OpTypeBottomK, OpTypeTopK, OpTypeLimitK:
 		params = []string{fmt.Sprintf("%d", e.Params), e.Left.String()}
+	case OpTypeQuantile:
+		params = []string{strconv.FormatFloat(e.Quantile, 'f', -1, 64), e.Left.String()}
+	case OpTypeCountValues:
+		params = []string{strconv.Quote(e.ValueLabel), e.Left.String()}
 	default:
 		if e.Params != 0 {
 			params = []string{fmt.Sprintf("%d", e.Params), e.Left.String()}
@@ -2250,6 +2815,91 @@ func (e *LabelReplaceExpr) String() string {
 	return sb.String()
 }
 
+type LabelJoinExpr struct {
+	Left      SampleExpr
+	Dst       string
+	Separator string
+	Src       []string
+	err       error
+
+	implicit
+}
+
+func mustNewLabelJoinExpr(left SampleExpr, dst, separator string, src []string) *LabelJoinExpr {
+	if !model.LabelName(dst).IsValid() {
+		return &LabelJoinExpr{
+			err: logqlmodel.NewParseError(fmt.Sprintf("invalid destination label name in label_join: %s", dst), 0, 0),
+		}
+	}
+	for _, l := range src {
+		if !model.LabelName(l).IsValid() {
+			return &LabelJoinExpr{
+				err: logqlmodel.NewParseError(fmt.Sprintf("invalid source label name in label_join: %s", l), 0, 0),
+			}
+		}
+	}
+	return &LabelJoinExpr{
+		Left:      left,
+		Dst:       dst,
+		Separator: separator,
+		Src:       src,
+	}
+}
+
+func (e *LabelJoinExpr) isSampleExpr() {}
+
+func (e *LabelJoinExpr) Selector() (LogSelectorExpr, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Selector()
+}
+
+func (e *LabelJoinExpr) MatcherGroups() ([]MatcherRange, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.MatcherGroups()
+}
+
+func (e *LabelJoinExpr) Extractor() (SampleExtractor, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.Left.Extractor()
+}
+
+func (e *LabelJoinExpr) Shardable(_ bool) bool {
+	return false
+}
+
+func (e *LabelJoinExpr) Walk(f WalkFn) {
+	f(e)
+	if e.Left == nil {
+		return
+	}
+	e.Left.Walk(f)
+}
+
+func (e *LabelJoinExpr) Accept(v RootVisitor) { v.VisitLabelJoin(e) }
+
+func (e *LabelJoinExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(OpLabelJoin)
+	sb.WriteString("(")
+	sb.WriteString(e.Left.String())
+	sb.WriteString(",")
+	sb.WriteString(strconv.Quote(e.Dst))
+	sb.WriteString(",")
+	sb.WriteString(strconv.Quote(e.Separator))
+	for _, l := range e.Src {
+		sb.WriteString(",")
+		sb.WriteString(strconv.Quote(l))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
 // shardableOps lists the operations which may be sharded, but are not
 // guaranteed to be. See the `Shardable()` implementations
 // on the respective expr types for more details.
@@ -2277,6 +2927,8 @@ var shardableOps = map[string]bool{
 	OpTypeCount: true,
 	OpTypeMax:   true,
 	OpTypeMin:   true,
+	// group only keeps the labels of its groups, which are the same whichever shard a series is in.
+	OpTypeGroup: true,
 
 	// range vector ops
 	OpRangeTypeAvg:       true,
@@ -188,6 +188,24 @@ func Test_SampleExpr_String(t *testing.T) {
 				or on ()
 				((sum by(typename,pool,commandname,colo) (sum_over_time({_namespace_="appspace", _schema_="appspace-1h", pool=~"r1testlvs", colo=~"slc|lvs|rno", env!~"(pre-production|sandbox)"} | logfmt | status!="0" | ( ( type=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" or typename=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" ) or status=~"(?i)^(Error|Exception|Fatal|ERRPAGE|ValidationError)$" ) | commandname=~"(?i).*|UNSET" | unwrap sumcount[5m])) / 60) / 60))`,
 		`{app="foo"} | logfmt code="response.code", IPAddress="host"`,
+		`{app="foo"} | csv(method, _, status) delimiter="\t" quote="'"`,
+		`sum by (status) (count_over_time({app="foo"} | csv(method, status) [5m]))`,
+		`{app="foo"} | kv(";", ":")`,
+		`{app="foo"} | xml | xml user="/Envelope/Body/User/Name", id="//User/@id"`,
+		`sum by (sku) (sum_over_time({app="foo"} | explode "order.items" | unwrap price [5m]))`,
+		`count_over_time({app="foo"} | explode tags [5m])`,
+		`{app="foo"} | json | dedup by (pod) within 1m | limit 5`,
+		`{app="foo"} | dedup without (pod) within 5m | limit 3 by (pod,container)`,
+		`count_values("value", sum by (pod) (rate({app="foo"}[5m])))`,
+		`count_values by (app) ("value", rate({app="foo"}[5m]))`,
+		`quantile(0.95, sum by (pod) (rate({app="foo"}[5m])))`,
+		`group by (pod) (rate({app="foo"}[5m]))`,
+		`limitk(2, rate({app="foo"}[5m])) by (app)`,
+		`label_join(rate({app="foo"}[5m]), "foo", ",", "app", "pod")`,
+		`label_join(rate({app="foo"}[5m]), "foo", "")`,
+		`max_over_time(sum(rate({app="api"} |= "error" [1m]))[1h:1m])`,
+		`quantile_over_time(0.9, sum by (pod) (rate({app="foo"}[1m]))[1h:30s] offset 5m)`,
+		`avg_over_time(max_over_time(rate({app="foo"}[1m])[10m:1m])[1h:5m])`,
 	} {
 		t.Run(tc, func(t *testing.T) {
 			expr, err := ParseExpr(tc)
@@ -216,6 +234,34 @@ func Test_SampleExpr_String_Fail(t *testing.T) {
 	}
 }
 
+func Test_SampleExpr_VectorParams_Fail(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		query string
+		err   string
+	}{
+		{`limitk(0, rate({app="foo"}[5m]))`, "invalid parameter (must be greater than 0)"},
+		{`quantile(rate({app="foo"}[5m]))`, "parameter required for operation quantile"},
+		{`count_values(1, rate({app="foo"}[5m]))`, "invalid parameter (must be a label name string)"},
+		{`count_values("1foo", rate({app="foo"}[5m]))`, "invalid label name"},
+		{`sum("foo", rate({app="foo"}[5m]))`, "unsupported parameter for operation sum"},
+		{`label_join(rate({app="foo"}[5m]), "foo", ",", "a-b")`, "invalid source label name in label_join"},
+		{`rate(sum(rate({app="foo"}[5m]))[1h:1m])`, "invalid aggregation rate over subquery"},
+		{`max_over_time(sum(rate({app="foo"}[5m]))[1h:])`, "subquery resolution required"},
+		{`quantile_over_time(sum(rate({app="foo"}[5m]))[1h:1m])`, "parameter required for operation quantile_over_time"},
+		{`max_over_time(rate({app="foo"}[5m:1m]))`, "syntax error: unexpected RATE, expecting NUMBER or { or ("},
+		{`rate({app="foo"}[5m:1m])`, "syntax error: unexpected SUBQUERY_RANGE"},
+		{`timeshift(vector(1), 1h)`, "invalid offset: vector(1.000000) has no log range to shift"},
+		{`(vector(1)) offset 1h`, "invalid offset: vector(1.000000) has no log range to shift"},
+		{`sum(vector(1) + vector(2)) offset 1h`, "has no log range to shift"},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseExpr(tc.query)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
 func Test_SampleExpr_Sort_Fail(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []string{
@@ -229,6 +275,50 @@ func Test_SampleExpr_Sort_Fail(t *testing.T) {
 	}
 }
 
+func Test_SampleExpr_TimeShift(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{
+			`timeshift(rate({app="foo"}[5m]), 1w)`,
+			`rate({app="foo"}[5m] offset 168h0m0s)`,
+		},
+		{
+			`timeshift(rate({app="foo"}[5m] offset 1h), 1d)`,
+			`rate({app="foo"}[5m] offset 25h0m0s)`,
+		},
+		{
+			`sum by (app) (rate({app="foo"}[5m])) offset 1h`,
+			`sum by (app)(rate({app="foo"}[5m] offset 1h0m0s))`,
+		},
+		{
+			`(sum(rate({app="foo"}[5m])) / sum(count_over_time({app="foo"}[5m]))) offset 1h`,
+			`(sum(rate({app="foo"}[5m] offset 1h0m0s)) / sum(count_over_time({app="foo"}[5m] offset 1h0m0s)))`,
+		},
+		{
+			`sum(rate({app="foo"}[5m])) - timeshift(sum(rate({app="foo"}[5m])), 1w)`,
+			`(sum(rate({app="foo"}[5m])) - sum(rate({app="foo"}[5m] offset 168h0m0s)))`,
+		},
+		{
+			`rate({app="foo"}[5m]) offset -10m`,
+			`rate({app="foo"}[5m] offset -10m0s)`,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseExpr(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, expr.String())
+
+			// the desugared form must round trip.
+			expr2, err := ParseExpr(expr.String())
+			require.NoError(t, err)
+			require.Equal(t, expr, expr2)
+		})
+	}
+}
+
 func TestMatcherGroups(t *testing.T) {
 	for i, tc := range []struct {
 		query string
@@ -655,6 +745,14 @@ func TestStringer(t *testing.T) {
 			in:  `1.6`,
 			out: `1.6`,
 		},
+		{
+			in:  `{app="foo"} |=* "Timeout" or "refused" !=* "debug"`,
+			out: `{app="foo"} |=* "Timeout" or "refused" !=* "debug"`,
+		},
+		{
+			in:  `sum(count_over_time({app="foo"} |~~ "tiemout" 1 !~~ "dbug" 1 [5m]))`,
+			out: `sum(count_over_time({app="foo"} |~~ "tiemout" 1 !~~ "dbug" 1[5m]))`,
+		},
 		{
 			in:  `1 > 1 > bool 1`,
 			out: `0`,
@@ -52,9 +52,11 @@ func (v *cloneVisitor) VisitBinOp(e *BinOpExpr) {
 
 func (v *cloneVisitor) VisitVectorAggregation(e *VectorAggregationExpr) {
 	copied := &VectorAggregationExpr{
-		Left:      MustClone[SampleExpr](e.Left),
-		Params:    e.Params,
-		Operation: e.Operation,
+		Left:       MustClone[SampleExpr](e.Left),
+		Params:     e.Params,
+		Operation:  e.Operation,
+		Quantile:   e.Quantile,
+		ValueLabel: e.ValueLabel,
 	}
 
 	if e.Grouping != nil {
@@ -82,11 +84,35 @@ func (v *cloneVisitor) VisitRangeAggregation(e *RangeAggregationExpr) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitSubquery(e *SubqueryExpr) {
+	copied := &SubqueryExpr{
+		Left:      MustClone[SampleExpr](e.Left),
+		Operation: e.Operation,
+		Range:     e.Range,
+		Step:      e.Step,
+		Offset:    e.Offset,
+	}
+
+	if e.Params != nil {
+		tmp := *e.Params
+		copied.Params = &tmp
+	}
+
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitLabelReplace(e *LabelReplaceExpr) {
 	left := MustClone[SampleExpr](e.Left)
 	v.cloned = mustNewLabelReplaceExpr(left, e.Dst, e.Replacement, e.Src, e.Regex)
 }
 
+func (v *cloneVisitor) VisitLabelJoin(e *LabelJoinExpr) {
+	left := MustClone[SampleExpr](e.Left)
+	src := make([]string, len(e.Src))
+	copy(src, e.Src)
+	v.cloned = mustNewLabelJoinExpr(left, e.Dst, e.Separator, src)
+}
+
 func (v *cloneVisitor) VisitLiteral(e *LiteralExpr) {
 	v.cloned = &LiteralExpr{Val: e.Val}
 }
@@ -159,6 +185,29 @@ func (v *cloneVisitor) VisitDropLabels(e *DropLabelsExpr) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitCSVParser(e *CSVParserExpr) {
+	copied := &CSVParserExpr{
+		Columns:   make([]string, len(e.Columns)),
+		Delimiter: e.Delimiter,
+		Quote:     e.Quote,
+	}
+	copy(copied.Columns, e.Columns)
+
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitDedup(e *DedupExpr) {
+	copied := &DedupExpr{Within: e.Within}
+	if e.Grouping != nil {
+		copied.Grouping = cloneGrouping(e.Grouping)
+	}
+	v.cloned = copied
+}
+
+func (v *cloneVisitor) VisitExplode(e *ExplodeExpr) {
+	v.cloned = &ExplodeExpr{Field: e.Field}
+}
+
 func (v *cloneVisitor) VisitJSONExpressionParser(e *JSONExpressionParser) {
 	copied := &JSONExpressionParser{
 		Expressions: make([]log.LabelExtractionExpr, len(e.Expressions)),
@@ -168,6 +217,13 @@ func (v *cloneVisitor) VisitJSONExpressionParser(e *JSONExpressionParser) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitKVParser(e *KVParserExpr) {
+	v.cloned = &KVParserExpr{
+		PairSeparator: e.PairSeparator,
+		KVSeparator:   e.KVSeparator,
+	}
+}
+
 func (v *cloneVisitor) VisitKeepLabel(e *KeepLabelsExpr) {
 	copied := &KeepLabelsExpr{
 		keepLabels: make([]log.KeepLabel, len(e.keepLabels)),
@@ -258,12 +314,21 @@ func (v *cloneVisitor) VisitLabelParser(e *LabelParserExpr) {
 	}
 }
 
+func (v *cloneVisitor) VisitLimitBy(e *LimitByExpr) {
+	copied := &LimitByExpr{Limit: e.Limit}
+	if e.Grouping != nil {
+		copied.Grouping = cloneGrouping(e.Grouping)
+	}
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitLineFilter(e *LineFilterExpr) {
 	copied := &LineFilterExpr{
 		LineFilter: LineFilter{
-			Ty:    e.Ty,
-			Match: e.Match,
-			Op:    e.Op,
+			Ty:       e.Ty,
+			Match:    e.Match,
+			Op:       e.Op,
+			Distance: e.Distance,
 		},
 		IsOrChild: e.IsOrChild,
 	}
@@ -294,6 +359,15 @@ func (v *cloneVisitor) VisitLogfmtExpressionParser(e *LogfmtExpressionParser) {
 	v.cloned = copied
 }
 
+func (v *cloneVisitor) VisitXMLExpressionParser(e *XMLExpressionParser) {
+	copied := &XMLExpressionParser{
+		Expressions: make([]log.LabelExtractionExpr, len(e.Expressions)),
+	}
+	copy(copied.Expressions, e.Expressions)
+
+	v.cloned = copied
+}
+
 func (v *cloneVisitor) VisitLogfmtParser(e *LogfmtParserExpr) {
 	v.cloned = &LogfmtParserExpr{
 		Strict:    e.Strict,
@@ -32,6 +32,9 @@ func TestClone(t *testing.T) {
 		"line filter": {
 			query: `{app="foo"} |= "bar" | json |= "500" or "200"`,
 		},
+		"case insensitive and fuzzy line filters": {
+			query: `{app="foo"} |=* "bar" or "baz" !~~ "tiemout" 1`,
+		},
 		"drop label": {
 			query: `{app="foo"} |= "bar" | json | drop latency, status_code="200"`,
 		},
@@ -61,6 +61,11 @@ import (
   LabelExtractionExpressionList []log.LabelExtractionExpr
   JSONExpressionParser          *JSONExpressionParser
   LogfmtExpressionParser        *LogfmtExpressionParser
+  XMLExpressionParser           *XMLExpressionParser
+  CSVParser                     *CSVParserExpr
+  KVParser                      *KVParserExpr
+  ParserOption                  ParserOption
+  ParserOptions                 []ParserOption
 
   UnwrapExpr              *UnwrapExpr
   DecolorizeExpr          *DecolorizeExpr
@@ -77,6 +82,7 @@ import (
 
 %type <Expr>                  expr
 %type <Filter>                filter
+%type <Filter>                fuzzyFilter
 %type <Grouping>              grouping
 %type <Labels>                labels
 %type <LogExpr>               logExpr
@@ -96,6 +102,7 @@ import (
 %type <BinOpExpr>             binOpExpr
 %type <LiteralExpr>           literalExpr
 %type <LabelReplaceExpr>      labelReplaceExpr
+%type <Labels>                stringList
 %type <BinOpModifier>         binOpModifier
 %type <BoolModifier>          boolModifier
 %type <OnOrIgnoringModifier>  onOrIgnoringModifier
@@ -126,20 +133,26 @@ import (
 %type <LabelExtractionExpressionList>    labelExtractionExpressionList
 %type <LogfmtExpressionParser>           logfmtExpressionParser
 %type <JSONExpressionParser>             jsonExpressionParser
+%type <XMLExpressionParser>              xmlExpressionParser
+%type <CSVParser>                        csvParser
+%type <KVParser>                         kvParser
+%type <ParserOption>                     parserOption
+%type <ParserOptions>                    parserOptions
 %type <UnwrapExpr>            unwrapExpr
 %type <UnitFilter>            unitFilter
 %type <IPLabelFilter>         ipLabelFilter
 %type <OffsetExpr>            offsetExpr
 
 %token <bytes> BYTES
-%token <str>      IDENTIFIER STRING NUMBER PARSER_FLAG
+%token <str>      IDENTIFIER STRING NUMBER PARSER_FLAG SUBQUERY_RANGE
 %token <duration> DURATION RANGE
-%token <val>      MATCHERS LABELS EQ RE NRE NPA OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT PIPE_PATTERN
+%token <val>      MATCHERS LABELS EQ RE NRE NPA OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT PIPE_PATTERN PIPE_EXACT_CI NEQ_CI PIPE_FUZZY NFUZZY
                   OPEN_PARENTHESIS CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE RATE_COUNTER SUM SORT SORT_DESC AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
                   BYTES_OVER_TIME BYTES_RATE BOOL JSON REGEXP LOGFMT PIPE LINE_FMT LABEL_FMT UNWRAP AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME
                   MAX_OVER_TIME STDVAR_OVER_TIME STDDEV_OVER_TIME QUANTILE_OVER_TIME BYTES_CONV DURATION_CONV DURATION_SECONDS_CONV
                   FIRST_OVER_TIME LAST_OVER_TIME ABSENT_OVER_TIME VECTOR LABEL_REPLACE UNPACK OFFSET PATTERN IP ON IGNORING GROUP_LEFT GROUP_RIGHT
-                  DECOLORIZE DROP KEEP
+                  DECOLORIZE DROP KEEP CSV KV XML EXPLODE DEDUP LIMIT WITHIN
+                  COUNT_VALUES GROUP LIMITK QUANTILE LABEL_JOIN TIMESHIFT SUBQUERY_OPEN_PARENTHESIS
 
 // Operators are listed with increasing precedence.
 %left <binOp> OR
@@ -166,6 +179,10 @@ metricExpr:
     | labelReplaceExpr                              { $$ = $1 }
     | vectorExpr                                    { $$ = $1 }
     | OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS { $$ = $2 }
+    | OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS offsetExpr                 { $$ = mustNewTimeShiftExpr($2, $4.Offset) }
+    | rangeAggregationExpr offsetExpr                                          { $$ = mustNewTimeShiftExpr($1, $2.Offset) }
+    | vectorAggregationExpr offsetExpr                                         { $$ = mustNewTimeShiftExpr($1, $2.Offset) }
+    | TIMESHIFT OPEN_PARENTHESIS metricExpr COMMA DURATION CLOSE_PARENTHESIS   { $$ = mustNewTimeShiftExpr($3, $5) }
     ;
 
 logExpr:
@@ -220,6 +237,11 @@ rangeAggregationExpr:
     | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS           { $$ = newRangeAggregationExpr($5, $1, nil, &$3) }
     | rangeOp OPEN_PARENTHESIS logRangeExpr CLOSE_PARENTHESIS grouping               { $$ = newRangeAggregationExpr($3, $1, $5, nil) }
     | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS grouping  { $$ = newRangeAggregationExpr($5, $1, $7, &$3) }
+    // Subqueries, the lexer tells their parenthesis apart from the one of log ranges.
+    | rangeOp SUBQUERY_OPEN_PARENTHESIS metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS                          { $$ = mustNewSubqueryExpr($3, $1, $4, nil, nil) }
+    | rangeOp SUBQUERY_OPEN_PARENTHESIS metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS               { $$ = mustNewSubqueryExpr($3, $1, $4, $5, nil) }
+    | rangeOp SUBQUERY_OPEN_PARENTHESIS NUMBER COMMA metricExpr SUBQUERY_RANGE CLOSE_PARENTHESIS             { $$ = mustNewSubqueryExpr($5, $1, $6, nil, &$3) }
+    | rangeOp SUBQUERY_OPEN_PARENTHESIS NUMBER COMMA metricExpr SUBQUERY_RANGE offsetExpr CLOSE_PARENTHESIS  { $$ = mustNewSubqueryExpr($5, $1, $6, $7, &$3) }
     ;
 
 vectorAggregationExpr:
@@ -231,11 +253,23 @@ vectorAggregationExpr:
     | vectorOp OPEN_PARENTHESIS NUMBER COMMA metricExpr CLOSE_PARENTHESIS                 { $$ = mustNewVectorAggregationExpr($5, $1, nil, &$3) }
     | vectorOp OPEN_PARENTHESIS NUMBER COMMA metricExpr CLOSE_PARENTHESIS grouping        { $$ = mustNewVectorAggregationExpr($5, $1, $7, &$3) }
     | vectorOp grouping OPEN_PARENTHESIS NUMBER COMMA metricExpr CLOSE_PARENTHESIS        { $$ = mustNewVectorAggregationExpr($6, $1, $2, &$4) }
+    | vectorOp OPEN_PARENTHESIS STRING COMMA metricExpr CLOSE_PARENTHESIS                 { $$ = mustNewCountValuesExpr($5, $1, nil, $3) }
+    | vectorOp OPEN_PARENTHESIS STRING COMMA metricExpr CLOSE_PARENTHESIS grouping        { $$ = mustNewCountValuesExpr($5, $1, $7, $3) }
+    | vectorOp grouping OPEN_PARENTHESIS STRING COMMA metricExpr CLOSE_PARENTHESIS        { $$ = mustNewCountValuesExpr($6, $1, $2, $4) }
     ;
 
 labelReplaceExpr:
     LABEL_REPLACE OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA STRING COMMA STRING CLOSE_PARENTHESIS
       { $$ = mustNewLabelReplaceExpr($3, $5, $7, $9, $11)}
+    | LABEL_JOIN OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING CLOSE_PARENTHESIS
+      { $$ = mustNewLabelJoinExpr($3, $5, $7, nil)}
+    | LABEL_JOIN OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA stringList CLOSE_PARENTHESIS
+      { $$ = mustNewLabelJoinExpr($3, $5, $7, $9)}
+    ;
+
+stringList:
+      STRING                  { $$ = []string{ $1 } }
+    | stringList COMMA STRING { $$ = append($1, $3) }
     ;
 
 filter:
@@ -245,6 +279,13 @@ filter:
     | NRE                              { $$ = log.LineMatchNotRegexp }
     | NEQ                              { $$ = log.LineMatchNotEqual }
     | NPA                              { $$ = log.LineMatchNotPattern }
+    | PIPE_EXACT_CI                    { $$ = log.LineMatchEqualCaseInsensitive }
+    | NEQ_CI                           { $$ = log.LineMatchNotEqualCaseInsensitive }
+    ;
+
+fuzzyFilter:
+      PIPE_FUZZY                       { $$ = log.LineMatchFuzzy }
+    | NFUZZY                           { $$ = log.LineMatchNotFuzzy }
     ;
 
 selector:
@@ -276,6 +317,17 @@ pipelineStage:
   | PIPE labelParser             { $$ = $2 }
   | PIPE jsonExpressionParser    { $$ = $2 }
   | PIPE logfmtExpressionParser  { $$ = $2 }
+  | PIPE xmlExpressionParser     { $$ = $2 }
+  | PIPE csvParser               { $$ = $2 }
+  | PIPE kvParser                { $$ = $2 }
+  | PIPE EXPLODE IDENTIFIER      { $$ = newExplodeExpr($3) }
+  | PIPE EXPLODE STRING          { $$ = newExplodeExpr($3) }
+  | PIPE DEDUP                   { $$ = newDedupExpr(nil, 0) }
+  | PIPE DEDUP grouping          { $$ = newDedupExpr($3, 0) }
+  | PIPE DEDUP WITHIN DURATION   { $$ = newDedupExpr(nil, $4) }
+  | PIPE DEDUP grouping WITHIN DURATION { $$ = newDedupExpr($3, $5) }
+  | PIPE LIMIT NUMBER            { $$ = newLimitByExpr($3, nil) }
+  | PIPE LIMIT NUMBER grouping   { $$ = newLimitByExpr($3, $4) }
   | PIPE labelFilter             { $$ = &LabelFilterExpr{LabelFilterer: $2 }}
   | PIPE lineFormatExpr          { $$ = $2 }
   | PIPE decolorizeExpr          { $$ = $2 }
@@ -298,6 +350,7 @@ lineFilter:
     filter STRING                                                   { $$ = newLineFilterExpr($1, "", $2) }
   | filter filterOp OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS       { $$ = newLineFilterExpr($1, $2, $4) }
   | filter STRING OR orFilter                                       { $$ = newOrLineFilter(newLineFilterExpr($1, "", $2), $4) }
+  | fuzzyFilter STRING NUMBER                                       { $$ = newFuzzyLineFilterExpr($1, $2, $3) }
   ;
 
 lineFilters:
@@ -321,6 +374,7 @@ labelParser:
   | REGEXP STRING       { $$ = newLabelParserExpr(OpParserTypeRegexp, $2) }
   | UNPACK              { $$ = newLabelParserExpr(OpParserTypeUnpack, "") }
   | PATTERN STRING      { $$ = newLabelParserExpr(OpParserTypePattern, $2) }
+  | XML                 { $$ = newLabelParserExpr(OpParserTypeXML, "") }
   ;
 
 jsonExpressionParser:
@@ -331,6 +385,28 @@ logfmtExpressionParser:
   | LOGFMT labelExtractionExpressionList              { $$ = newLogfmtExpressionParser($2, nil)}
   ;
 
+xmlExpressionParser:
+    XML labelExtractionExpressionList { $$ = newXMLExpressionParser($2) }
+  ;
+
+parserOption:
+    IDENTIFIER EQ STRING { $$ = ParserOption{Name: $1, Value: $3} }
+  ;
+
+parserOptions:
+    parserOption               { $$ = []ParserOption{ $1 } }
+  | parserOptions parserOption { $$ = append($1, $2) }
+  ;
+
+csvParser:
+    CSV OPEN_PARENTHESIS labels CLOSE_PARENTHESIS               { $$ = newCSVParserExpr($3, nil) }
+  | CSV OPEN_PARENTHESIS labels CLOSE_PARENTHESIS parserOptions { $$ = newCSVParserExpr($3, $5) }
+  ;
+
+kvParser:
+    KV OPEN_PARENTHESIS STRING COMMA STRING CLOSE_PARENTHESIS { $$ = newKVParserExpr($3, $5) }
+  ;
+
 lineFormatExpr: LINE_FMT STRING { $$ = newLineFmtExpr($2) };
 
 decolorizeExpr: DECOLORIZE { $$ = newDecolorizeExpr() };
@@ -545,6 +621,10 @@ vectorOp:
       | TOPK    { $$ = OpTypeTopK }
       | SORT    { $$ = OpTypeSort }
       | SORT_DESC    { $$ = OpTypeSortDesc }
+      | COUNT_VALUES { $$ = OpTypeCountValues }
+      | GROUP        { $$ = OpTypeGroup }
+      | LIMITK       { $$ = OpTypeLimitK }
+      | QUANTILE     { $$ = OpTypeQuantile }
       ;
 
 rangeOp:
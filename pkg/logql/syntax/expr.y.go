@@ -67,6 +67,11 @@ type exprSymType struct {
 	LabelExtractionExpressionList []log.LabelExtractionExpr
 	JSONExpressionParser          *JSONExpressionParser
 	LogfmtExpressionParser        *LogfmtExpressionParser
+	XMLExpressionParser           *XMLExpressionParser
+	CSVParser                     *CSVParserExpr
+	KVParser                      *KVParserExpr
+	ParserOption                  ParserOption
+	ParserOptions                 []ParserOption
 
 	UnwrapExpr     *UnwrapExpr
 	DecolorizeExpr *DecolorizeExpr
@@ -84,92 +89,111 @@ const IDENTIFIER = 57347
 const STRING = 57348
 const NUMBER = 57349
 const PARSER_FLAG = 57350
-const DURATION = 57351
-const RANGE = 57352
-const MATCHERS = 57353
-const LABELS = 57354
-const EQ = 57355
-const RE = 57356
-const NRE = 57357
-const NPA = 57358
-const OPEN_BRACE = 57359
-const CLOSE_BRACE = 57360
-const OPEN_BRACKET = 57361
-const CLOSE_BRACKET = 57362
-const COMMA = 57363
-const DOT = 57364
-const PIPE_MATCH = 57365
-const PIPE_EXACT = 57366
-const PIPE_PATTERN = 57367
-const OPEN_PARENTHESIS = 57368
-const CLOSE_PARENTHESIS = 57369
-const BY = 57370
-const WITHOUT = 57371
-const COUNT_OVER_TIME = 57372
-const RATE = 57373
-const RATE_COUNTER = 57374
-const SUM = 57375
-const SORT = 57376
-const SORT_DESC = 57377
-const AVG = 57378
-const MAX = 57379
-const MIN = 57380
-const COUNT = 57381
-const STDDEV = 57382
-const STDVAR = 57383
-const BOTTOMK = 57384
-const TOPK = 57385
-const BYTES_OVER_TIME = 57386
-const BYTES_RATE = 57387
-const BOOL = 57388
-const JSON = 57389
-const REGEXP = 57390
-const LOGFMT = 57391
-const PIPE = 57392
-const LINE_FMT = 57393
-const LABEL_FMT = 57394
-const UNWRAP = 57395
-const AVG_OVER_TIME = 57396
-const SUM_OVER_TIME = 57397
-const MIN_OVER_TIME = 57398
-const MAX_OVER_TIME = 57399
-const STDVAR_OVER_TIME = 57400
-const STDDEV_OVER_TIME = 57401
-const QUANTILE_OVER_TIME = 57402
-const BYTES_CONV = 57403
-const DURATION_CONV = 57404
-const DURATION_SECONDS_CONV = 57405
-const FIRST_OVER_TIME = 57406
-const LAST_OVER_TIME = 57407
-const ABSENT_OVER_TIME = 57408
-const VECTOR = 57409
-const LABEL_REPLACE = 57410
-const UNPACK = 57411
-const OFFSET = 57412
-const PATTERN = 57413
-const IP = 57414
-const ON = 57415
-const IGNORING = 57416
-const GROUP_LEFT = 57417
-const GROUP_RIGHT = 57418
-const DECOLORIZE = 57419
-const DROP = 57420
-const KEEP = 57421
-const OR = 57422
-const AND = 57423
-const UNLESS = 57424
-const CMP_EQ = 57425
-const NEQ = 57426
-const LT = 57427
-const LTE = 57428
-const GT = 57429
-const GTE = 57430
-const ADD = 57431
-const SUB = 57432
-const MUL = 57433
-const DIV = 57434
-const MOD = 57435
-const POW = 57436
+const SUBQUERY_RANGE = 57351
+const DURATION = 57352
+const RANGE = 57353
+const MATCHERS = 57354
+const LABELS = 57355
+const EQ = 57356
+const RE = 57357
+const NRE = 57358
+const NPA = 57359
+const OPEN_BRACE = 57360
+const CLOSE_BRACE = 57361
+const OPEN_BRACKET = 57362
+const CLOSE_BRACKET = 57363
+const COMMA = 57364
+const DOT = 57365
+const PIPE_MATCH = 57366
+const PIPE_EXACT = 57367
+const PIPE_PATTERN = 57368
+const PIPE_EXACT_CI = 57369
+const NEQ_CI = 57370
+const PIPE_FUZZY = 57371
+const NFUZZY = 57372
+const OPEN_PARENTHESIS = 57373
+const CLOSE_PARENTHESIS = 57374
+const BY = 57375
+const WITHOUT = 57376
+const COUNT_OVER_TIME = 57377
+const RATE = 57378
+const RATE_COUNTER = 57379
+const SUM = 57380
+const SORT = 57381
+const SORT_DESC = 57382
+const AVG = 57383
+const MAX = 57384
+const MIN = 57385
+const COUNT = 57386
+const STDDEV = 57387
+const STDVAR = 57388
+const BOTTOMK = 57389
+const TOPK = 57390
+const BYTES_OVER_TIME = 57391
+const BYTES_RATE = 57392
+const BOOL = 57393
+const JSON = 57394
+const REGEXP = 57395
+const LOGFMT = 57396
+const PIPE = 57397
+const LINE_FMT = 57398
+const LABEL_FMT = 57399
+const UNWRAP = 57400
+const AVG_OVER_TIME = 57401
+const SUM_OVER_TIME = 57402
+const MIN_OVER_TIME = 57403
+const MAX_OVER_TIME = 57404
+const STDVAR_OVER_TIME = 57405
+const STDDEV_OVER_TIME = 57406
+const QUANTILE_OVER_TIME = 57407
+const BYTES_CONV = 57408
+const DURATION_CONV = 57409
+const DURATION_SECONDS_CONV = 57410
+const FIRST_OVER_TIME = 57411
+const LAST_OVER_TIME = 57412
+const ABSENT_OVER_TIME = 57413
+const VECTOR = 57414
+const LABEL_REPLACE = 57415
+const UNPACK = 57416
+const OFFSET = 57417
+const PATTERN = 57418
+const IP = 57419
+const ON = 57420
+const IGNORING = 57421
+const GROUP_LEFT = 57422
+const GROUP_RIGHT = 57423
+const DECOLORIZE = 57424
+const DROP = 57425
+const KEEP = 57426
+const CSV = 57427
+const KV = 57428
+const XML = 57429
+const EXPLODE = 57430
+const DEDUP = 57431
+const LIMIT = 57432
+const WITHIN = 57433
+const COUNT_VALUES = 57434
+const GROUP = 57435
+const LIMITK = 57436
+const QUANTILE = 57437
+const LABEL_JOIN = 57438
+const TIMESHIFT = 57439
+const SUBQUERY_OPEN_PARENTHESIS = 57440
+const OR = 57441
+const AND = 57442
+const UNLESS = 57443
+const CMP_EQ = 57444
+const NEQ = 57445
+const LT = 57446
+const LTE = 57447
+const GT = 57448
+const GTE = 57449
+const ADD = 57450
+const SUB = 57451
+const MUL = 57452
+const DIV = 57453
+const MOD = 57454
+const POW = 57455
 
 var exprToknames = [...]string{
 	"$end",
@@ -180,6 +204,7 @@ var exprToknames = [...]string{
 	"STRING",
 	"NUMBER",
 	"PARSER_FLAG",
+	"SUBQUERY_RANGE",
 	"DURATION",
 	"RANGE",
 	"MATCHERS",
@@ -197,6 +222,10 @@ var exprToknames = [...]string{
 	"PIPE_MATCH",
 	"PIPE_EXACT",
 	"PIPE_PATTERN",
+	"PIPE_EXACT_CI",
+	"NEQ_CI",
+	"PIPE_FUZZY",
+	"NFUZZY",
 	"OPEN_PARENTHESIS",
 	"CLOSE_PARENTHESIS",
 	"BY",
@@ -251,6 +280,20 @@ var exprToknames = [...]string{
 	"DECOLORIZE",
 	"DROP",
 	"KEEP",
+	"CSV",
+	"KV",
+	"XML",
+	"EXPLODE",
+	"DEDUP",
+	"LIMIT",
+	"WITHIN",
+	"COUNT_VALUES",
+	"GROUP",
+	"LIMITK",
+	"QUANTILE",
+	"LABEL_JOIN",
+	"TIMESHIFT",
+	"SUBQUERY_OPEN_PARENTHESIS",
 	"OR",
 	"AND",
 	"UNLESS",
@@ -274,7 +317,7 @@ const exprEofCode = 1
 const exprErrCode = 2
 const exprInitialStackSize = 16
 
-//line expr.y:582
+//line expr.y:662
 
 //line yacctab:1
 var exprExca = [...]int8{
@@ -285,260 +328,323 @@ var exprExca = [...]int8{
 
 const exprPrivate = 57344
 
-const exprLast = 639
+const exprLast = 885
 
 var exprAct = [...]int16{
-	289, 228, 84, 4, 214, 64, 182, 126, 204, 189,
-	75, 200, 197, 63, 237, 5, 152, 187, 77, 2,
-	56, 80, 48, 49, 50, 57, 58, 61, 62, 59,
-	60, 51, 52, 53, 54, 55, 56, 283, 10, 49,
-	50, 57, 58, 61, 62, 59, 60, 51, 52, 53,
-	54, 55, 56, 57, 58, 61, 62, 59, 60, 51,
-	52, 53, 54, 55, 56, 53, 54, 55, 56, 109,
-	217, 139, 215, 115, 51, 52, 53, 54, 55, 56,
-	266, 140, 221, 16, 216, 265, 292, 156, 136, 166,
-	167, 297, 262, 161, 220, 16, 294, 261, 154, 148,
-	150, 151, 366, 281, 184, 339, 16, 67, 280, 130,
-	163, 207, 150, 151, 168, 169, 170, 171, 172, 173,
-	174, 175, 176, 177, 178, 179, 180, 181, 278, 164,
-	165, 16, 366, 277, 239, 94, 293, 194, 142, 386,
-	191, 275, 202, 206, 16, 294, 274, 142, 264, 340,
-	85, 86, 292, 141, 272, 219, 316, 16, 381, 271,
-	260, 306, 235, 185, 183, 17, 18, 356, 229, 224,
-	149, 231, 232, 110, 306, 240, 294, 17, 18, 136,
-	355, 213, 208, 211, 212, 209, 210, 374, 17, 18,
-	248, 249, 250, 269, 331, 184, 16, 306, 268, 373,
-	130, 255, 306, 354, 252, 342, 343, 344, 353, 136,
-	239, 72, 74, 17, 18, 371, 363, 339, 306, 69,
-	70, 71, 285, 384, 308, 184, 17, 18, 287, 290,
-	130, 296, 314, 299, 369, 109, 302, 115, 303, 17,
-	18, 291, 154, 288, 359, 300, 263, 267, 270, 273,
-	276, 279, 282, 349, 185, 183, 330, 294, 293, 310,
-	312, 315, 317, 318, 72, 74, 202, 206, 325, 320,
-	324, 295, 69, 70, 71, 346, 72, 74, 17, 18,
-	73, 306, 239, 239, 69, 70, 71, 307, 328, 136,
-	239, 332, 304, 334, 336, 224, 338, 109, 294, 230,
-	243, 337, 348, 333, 313, 311, 109, 239, 295, 350,
-	130, 230, 241, 72, 74, 83, 224, 85, 86, 292,
-	301, 69, 70, 71, 233, 347, 144, 13, 136, 238,
-	143, 327, 153, 73, 360, 361, 155, 326, 284, 109,
-	362, 225, 13, 247, 184, 73, 364, 365, 230, 130,
-	246, 155, 370, 245, 244, 218, 160, 159, 158, 227,
-	16, 90, 89, 82, 72, 74, 376, 380, 377, 378,
-	13, 352, 69, 70, 71, 253, 298, 305, 259, 6,
-	382, 258, 73, 21, 22, 23, 36, 45, 46, 37,
-	39, 40, 38, 41, 42, 43, 44, 24, 25, 230,
-	256, 242, 234, 226, 183, 257, 254, 26, 27, 28,
-	29, 30, 31, 32, 81, 379, 368, 33, 34, 35,
-	47, 19, 236, 227, 367, 162, 345, 79, 72, 74,
-	335, 190, 13, 73, 251, 88, 69, 70, 71, 322,
-	323, 6, 17, 18, 87, 21, 22, 23, 36, 45,
-	46, 37, 39, 40, 38, 41, 42, 43, 44, 24,
-	25, 190, 375, 230, 188, 385, 383, 372, 358, 26,
-	27, 28, 29, 30, 31, 32, 146, 357, 3, 33,
-	34, 35, 47, 19, 157, 76, 329, 319, 309, 286,
-	72, 74, 145, 321, 13, 147, 198, 73, 69, 70,
-	71, 223, 222, 6, 17, 18, 136, 21, 22, 23,
-	36, 45, 46, 37, 39, 40, 38, 41, 42, 43,
-	44, 24, 25, 221, 220, 230, 195, 130, 193, 192,
-	351, 26, 27, 28, 29, 30, 31, 32, 205, 136,
-	201, 33, 34, 35, 47, 19, 190, 81, 122, 123,
-	121, 198, 131, 133, 297, 72, 74, 127, 128, 73,
-	130, 113, 114, 69, 70, 71, 17, 18, 196, 118,
-	124, 203, 125, 120, 199, 91, 119, 117, 132, 134,
-	135, 122, 123, 121, 116, 131, 133, 186, 65, 137,
-	66, 129, 138, 111, 112, 93, 92, 11, 9, 20,
-	12, 15, 8, 124, 341, 125, 14, 7, 78, 68,
-	1, 132, 134, 135, 0, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 73, 95, 96, 97, 98, 99,
-	100, 101, 102, 103, 104, 105, 106, 107, 108,
+	89, 426, 255, 152, 100, 245, 70, 272, 4, 91,
+	220, 241, 238, 285, 227, 86, 10, 181, 95, 69,
+	225, 62, 5, 57, 58, 59, 60, 61, 62, 88,
+	2, 54, 55, 56, 63, 64, 67, 68, 65, 66,
+	57, 58, 59, 60, 61, 62, 55, 56, 63, 64,
+	67, 68, 65, 66, 57, 58, 59, 60, 61, 62,
+	63, 64, 67, 68, 65, 66, 57, 58, 59, 60,
+	61, 62, 59, 60, 61, 62, 126, 337, 258, 165,
+	297, 349, 90, 138, 199, 200, 79, 81, 177, 179,
+	180, 97, 197, 198, 76, 77, 78, 82, 83, 84,
+	85, 173, 410, 248, 179, 180, 257, 185, 187, 351,
+	101, 102, 348, 271, 193, 194, 442, 402, 79, 81,
+	183, 438, 111, 101, 102, 274, 76, 77, 78, 82,
+	83, 84, 85, 335, 352, 356, 17, 256, 196, 334,
+	438, 217, 201, 202, 203, 204, 205, 206, 207, 208,
+	209, 210, 211, 212, 213, 214, 166, 274, 98, 90,
+	90, 348, 475, 73, 243, 247, 229, 235, 218, 402,
+	232, 261, 347, 80, 465, 79, 81, 178, 90, 460,
+	263, 167, 435, 76, 77, 78, 82, 83, 84, 85,
+	441, 254, 249, 252, 253, 250, 251, 347, 332, 282,
+	472, 17, 276, 273, 331, 80, 288, 275, 168, 320,
+	471, 265, 17, 348, 274, 319, 348, 287, 409, 99,
+	463, 101, 102, 316, 299, 264, 17, 168, 287, 315,
+	462, 454, 300, 301, 302, 127, 403, 18, 19, 365,
+	329, 348, 365, 17, 376, 304, 328, 307, 365, 424,
+	365, 326, 423, 323, 17, 374, 17, 325, 422, 322,
+	421, 339, 80, 365, 268, 317, 321, 324, 327, 330,
+	333, 336, 344, 381, 342, 162, 365, 287, 357, 345,
+	126, 350, 318, 353, 359, 138, 367, 358, 343, 360,
+	361, 346, 222, 183, 395, 354, 314, 405, 406, 407,
+	453, 156, 18, 19, 373, 365, 268, 370, 372, 375,
+	377, 287, 287, 18, 19, 366, 243, 247, 452, 388,
+	379, 79, 81, 383, 387, 162, 447, 18, 19, 76,
+	77, 78, 82, 83, 84, 85, 355, 268, 371, 289,
+	391, 446, 222, 431, 18, 19, 415, 287, 399, 414,
+	401, 156, 396, 126, 398, 18, 19, 18, 19, 400,
+	72, 126, 411, 349, 397, 412, 162, 269, 79, 81,
+	221, 394, 416, 417, 286, 182, 76, 77, 78, 82,
+	83, 84, 85, 393, 162, 362, 14, 292, 279, 162,
+	171, 170, 156, 390, 389, 338, 296, 295, 294, 184,
+	432, 222, 293, 434, 126, 433, 222, 274, 80, 436,
+	156, 309, 14, 259, 443, 156, 437, 234, 233, 444,
+	445, 192, 191, 190, 107, 184, 106, 450, 105, 92,
+	175, 470, 461, 284, 283, 420, 419, 382, 305, 364,
+	457, 458, 363, 313, 456, 14, 312, 174, 310, 459,
+	176, 291, 290, 281, 280, 80, 278, 270, 6, 262,
+	466, 451, 23, 24, 25, 38, 47, 48, 39, 41,
+	42, 40, 43, 44, 45, 46, 26, 27, 223, 221,
+	311, 306, 440, 223, 221, 439, 28, 29, 30, 31,
+	32, 33, 34, 408, 79, 81, 35, 36, 37, 53,
+	20, 96, 76, 77, 78, 82, 83, 84, 85, 378,
+	341, 298, 172, 189, 188, 94, 474, 413, 277, 49,
+	50, 51, 52, 21, 13, 14, 228, 228, 455, 303,
+	226, 385, 386, 427, 260, 18, 19, 219, 6, 215,
+	216, 473, 23, 24, 25, 38, 47, 48, 39, 41,
+	42, 40, 43, 44, 45, 46, 26, 27, 195, 104,
+	103, 469, 467, 464, 449, 448, 28, 29, 30, 31,
+	32, 33, 34, 430, 429, 3, 35, 36, 37, 53,
+	20, 80, 87, 428, 392, 384, 380, 369, 239, 153,
+	368, 340, 308, 418, 17, 267, 266, 265, 264, 49,
+	50, 51, 52, 21, 13, 14, 236, 231, 230, 169,
+	246, 242, 228, 287, 96, 18, 19, 239, 6, 154,
+	425, 134, 23, 24, 25, 38, 47, 48, 39, 41,
+	42, 40, 43, 44, 45, 46, 26, 27, 133, 132,
+	130, 131, 237, 141, 244, 143, 28, 29, 30, 31,
+	32, 33, 34, 240, 142, 140, 35, 36, 37, 53,
+	20, 139, 224, 71, 163, 155, 164, 128, 129, 110,
+	109, 468, 11, 9, 186, 22, 12, 16, 8, 49,
+	50, 51, 52, 21, 13, 14, 404, 15, 7, 93,
+	75, 74, 1, 0, 0, 18, 19, 0, 6, 0,
+	0, 0, 23, 24, 25, 38, 47, 48, 39, 41,
+	42, 40, 43, 44, 45, 46, 26, 27, 0, 0,
+	0, 0, 0, 0, 0, 0, 28, 29, 30, 31,
+	32, 33, 34, 79, 81, 0, 35, 36, 37, 53,
+	20, 76, 77, 78, 82, 83, 84, 85, 0, 0,
+	0, 0, 271, 162, 0, 0, 0, 79, 81, 49,
+	50, 51, 52, 21, 13, 76, 77, 78, 82, 83,
+	84, 85, 274, 0, 0, 18, 19, 0, 0, 156,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 90, 0, 0, 0, 274, 108, 0, 162,
+	145, 146, 144, 0, 157, 159, 351, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	80, 0, 147, 0, 148, 156, 0, 0, 0, 0,
+	158, 160, 161, 150, 151, 149, 135, 136, 137, 0,
+	0, 0, 0, 0, 80, 0, 145, 146, 144, 0,
+	157, 159, 0, 112, 113, 114, 115, 116, 117, 118,
+	119, 120, 121, 122, 123, 124, 125, 0, 147, 0,
+	148, 0, 0, 0, 0, 0, 158, 160, 161, 150,
+	151, 149, 135, 136, 137,
 }
 
 var exprPact = [...]int16{
-	353, -1000, -58, -1000, -1000, 540, 353, -1000, -1000, -1000,
-	-1000, -1000, -1000, 409, 337, 289, -1000, 437, 428, 336,
-	335, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	587, -1000, -68, -1000, -1000, 305, 587, 7, 7, -1000,
+	-1000, -1000, -1000, 398, 496, 60, 188, -1000, 553, 552,
+	397, 395, 393, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 89, 89,
-	89, 89, 89, 89, 89, 89, 89, 89, 89, 89,
-	89, 89, 89, 540, -1000, 196, 534, -9, 75, -1000,
-	-1000, -1000, -1000, -1000, -1000, 303, 299, -58, 474, -1000,
-	-1000, 86, 325, 477, 332, 331, 330, -1000, -1000, 353,
-	418, 353, 56, 14, -1000, 353, 353, 353, 353, 353,
-	353, 353, 353, 353, 353, 353, 353, 353, 353, -1000,
-	-1000, -1000, -1000, -1000, -1000, 83, -1000, -1000, -1000, -1000,
-	-1000, 456, 541, 523, -1000, 522, -1000, -1000, -1000, -1000,
-	284, 520, -1000, 546, 535, 533, 98, -1000, -1000, 66,
-	-10, 329, -1000, -1000, -1000, -1000, -1000, 542, 518, 517,
-	496, 495, 314, 382, 413, 310, 297, 381, 415, 302,
-	285, 380, 273, -42, 328, 327, 324, 317, -30, -30,
-	-26, -26, -74, -74, -74, -74, -15, -15, -15, -15,
-	-15, -15, 83, 284, 284, 284, 426, 354, -1000, -1000,
-	393, 354, -1000, -1000, 174, -1000, 379, -1000, 392, 360,
-	-1000, 86, -1000, 357, -1000, 86, -1000, 88, 76, 189,
-	150, 137, 124, 99, -1000, -43, 312, 66, 483, -1000,
-	-1000, -1000, -1000, -1000, -1000, 122, 310, 249, 126, 261,
-	501, 349, 293, 122, 353, 265, 356, 260, -1000, -1000,
-	197, -1000, 482, -1000, 278, 277, 205, 129, 204, 83,
-	323, -1000, 354, 541, 481, -1000, 491, 434, 535, 533,
-	311, -1000, -1000, -1000, 305, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, 71, 71, 71, 71, 71, 71,
+	71, 71, 71, 71, 71, 71, 71, 71, 71, 305,
+	-1000, 478, 794, -20, 150, 603, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, 359, 358, -68, -1000,
+	502, -1000, 587, 428, -1000, -1000, 74, 368, 667, 507,
+	392, 391, 390, -1000, -1000, 587, 587, 551, 587, 14,
+	4, -1000, 587, 587, 587, 587, 587, 587, 587, 587,
+	587, 587, 587, 587, 587, 587, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, 534, 77, 530, 384, -1000,
+	-1000, -1000, -1000, -1000, 522, 607, 602, -1000, 601, 607,
+	387, 386, -1000, -1000, -1000, -1000, 361, 600, -1000, 612,
+	606, 605, 89, -1000, -1000, 131, -21, 382, -1000, 527,
+	7, -1000, -1000, 437, -1000, -1000, 609, 592, 591, 590,
+	589, 335, 435, 741, 394, 509, 434, 356, 432, 431,
+	427, 342, 307, 430, 429, 355, -54, 371, 367, 366,
+	365, -42, -42, -38, -38, -92, -92, -92, -92, -85,
+	-85, -85, -85, -85, -85, -1000, -1000, -11, 501, 90,
+	384, 361, 361, 361, 521, 416, -1000, -1000, 467, 416,
+	-1000, -1000, 416, 608, 586, 379, -1000, 426, -1000, 466,
+	424, -1000, 74, -1000, 421, -1000, 74, -1000, 219, 205,
+	249, 247, 236, 194, 129, -1000, -22, 364, 131, 585,
+	-1000, -1000, 500, -1000, -1000, -1000, -1000, -1000, -1000, 90,
+	394, 717, 161, 352, 748, 102, 304, 103, 587, 90,
+	587, 587, 353, 420, 417, 283, -1000, -1000, 254, -1000,
+	584, 581, -1000, 306, 272, 223, 212, 499, -1000, -1000,
+	320, 384, 270, -1000, 416, 607, 580, 241, 415, -1000,
+	583, 526, 606, 605, 363, -1000, -1000, -1000, 362, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-1000, -1000, -1000, 66, 480, -1000, 229, -1000, 167, 475,
-	46, 475, 421, 16, 284, 16, 95, 144, 416, 248,
-	298, -1000, -1000, 226, -1000, 353, 525, -1000, -1000, 350,
-	181, -1000, 176, -1000, -1000, 153, -1000, 140, -1000, -1000,
-	-1000, -1000, -1000, -1000, -1000, -1000, 471, 462, -1000, 217,
-	-1000, 122, 46, 475, 46, -1000, -1000, 83, -1000, 16,
-	-1000, 190, -1000, -1000, -1000, 82, 414, 406, 207, 122,
-	188, -1000, 461, -1000, -1000, -1000, -1000, 172, 160, -1000,
-	-1000, 46, -1000, 457, 52, 46, 38, 16, 16, 405,
-	-1000, -1000, 346, -1000, -1000, 131, 46, -1000, -1000, 16,
-	460, -1000, -1000, 202, 459, 112, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, 131, 578, -1000,
+	351, 339, -1000, 262, 159, 57, 159, 7, 361, 7,
+	106, 231, 482, 186, 70, -1000, -1000, 333, 508, -1000,
+	317, 314, -1000, 587, 587, 588, -1000, -1000, 414, 413,
+	228, -1000, 226, -1000, -1000, 220, -1000, 217, -1000, -1000,
+	-1000, 528, 577, -1000, -1000, -1000, -1000, -1000, -1000, 568,
+	567, -1000, 311, -1000, -1000, 90, 57, 159, 57, -1000,
+	384, -1000, 7, -1000, 151, -1000, -1000, -1000, 85, 474,
+	471, 158, -1000, 84, 90, 90, 309, 294, -1000, 559,
+	558, -1000, -1000, -1000, -1000, 528, -1000, 447, 286, 268,
+	199, -1000, -1000, 57, -1000, 523, 66, 57, 51, 7,
+	7, 438, -1000, 147, -1000, -1000, -1000, -1000, 410, 198,
+	-1000, 557, -1000, -1000, -1000, 142, 57, -1000, -1000, 7,
+	-1000, 556, -1000, 555, -1000, -1000, -1000, 409, 178, -1000,
+	535, -1000, 510, 130, -1000, -1000,
 }
 
 var exprPgo = [...]int16{
-	0, 610, 18, 609, 2, 14, 478, 3, 16, 7,
-	608, 607, 606, 604, 15, 602, 601, 600, 599, 84,
-	598, 38, 597, 575, 596, 595, 594, 593, 13, 5,
-	592, 591, 589, 6, 588, 107, 4, 587, 584, 577,
-	576, 574, 11, 573, 571, 8, 569, 12, 568, 9,
-	17, 562, 561, 1, 558, 557, 0,
+	0, 692, 29, 691, 690, 4, 13, 575, 8, 17,
+	3, 689, 688, 687, 686, 22, 678, 677, 676, 675,
+	106, 673, 16, 672, 671, 797, 670, 669, 668, 667,
+	19, 6, 666, 665, 664, 10, 663, 163, 2, 662,
+	661, 655, 654, 653, 11, 645, 644, 5, 643, 12,
+	642, 14, 20, 641, 640, 639, 638, 621, 1, 620,
+	7, 619, 589, 0,
 }
 
 var exprR1 = [...]int8{
-	0, 1, 2, 2, 7, 7, 7, 7, 7, 7,
-	7, 6, 6, 6, 8, 8, 8, 8, 8, 8,
-	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
-	53, 53, 53, 13, 13, 13, 11, 11, 11, 11,
-	15, 15, 15, 15, 15, 15, 22, 3, 3, 3,
-	3, 3, 3, 14, 14, 14, 10, 10, 9, 9,
-	9, 9, 28, 28, 29, 29, 29, 29, 29, 29,
-	29, 29, 29, 29, 29, 19, 36, 36, 36, 35,
-	35, 35, 34, 34, 34, 37, 37, 27, 27, 26,
-	26, 26, 26, 52, 51, 51, 38, 39, 47, 47,
-	48, 48, 48, 46, 33, 33, 33, 33, 33, 33,
-	33, 33, 33, 49, 49, 50, 50, 55, 55, 54,
-	54, 32, 32, 32, 32, 32, 32, 32, 30, 30,
-	30, 30, 30, 30, 30, 31, 31, 31, 31, 31,
-	31, 31, 42, 42, 41, 41, 40, 45, 45, 44,
-	44, 43, 20, 20, 20, 20, 20, 20, 20, 20,
-	20, 20, 20, 20, 20, 20, 20, 24, 24, 25,
-	25, 25, 25, 23, 23, 23, 23, 23, 23, 23,
-	23, 21, 21, 21, 17, 18, 16, 16, 16, 16,
-	16, 16, 16, 16, 16, 16, 16, 12, 12, 12,
-	12, 12, 12, 12, 12, 12, 12, 12, 12, 12,
-	12, 12, 56, 5, 5, 4, 4, 4, 4,
+	0, 1, 2, 2, 8, 8, 8, 8, 8, 8,
+	8, 8, 8, 8, 8, 7, 7, 7, 9, 9,
+	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
+	9, 9, 9, 9, 9, 9, 9, 9, 9, 9,
+	9, 9, 9, 9, 60, 60, 60, 14, 14, 14,
+	12, 12, 12, 12, 12, 12, 12, 12, 16, 16,
+	16, 16, 16, 16, 16, 16, 16, 23, 23, 23,
+	24, 24, 3, 3, 3, 3, 3, 3, 3, 3,
+	4, 4, 15, 15, 15, 11, 11, 10, 10, 10,
+	10, 30, 30, 31, 31, 31, 31, 31, 31, 31,
+	31, 31, 31, 31, 31, 31, 31, 31, 31, 31,
+	31, 31, 31, 31, 31, 20, 38, 38, 38, 37,
+	37, 37, 37, 36, 36, 36, 39, 39, 29, 29,
+	28, 28, 28, 28, 28, 54, 53, 53, 55, 58,
+	59, 59, 56, 56, 57, 40, 41, 49, 49, 50,
+	50, 50, 48, 35, 35, 35, 35, 35, 35, 35,
+	35, 35, 51, 51, 52, 52, 62, 62, 61, 61,
+	34, 34, 34, 34, 34, 34, 34, 32, 32, 32,
+	32, 32, 32, 32, 33, 33, 33, 33, 33, 33,
+	33, 44, 44, 43, 43, 42, 47, 47, 46, 46,
+	45, 21, 21, 21, 21, 21, 21, 21, 21, 21,
+	21, 21, 21, 21, 21, 21, 26, 26, 27, 27,
+	27, 27, 25, 25, 25, 25, 25, 25, 25, 25,
+	22, 22, 22, 18, 19, 17, 17, 17, 17, 17,
+	17, 17, 17, 17, 17, 17, 17, 17, 17, 17,
+	13, 13, 13, 13, 13, 13, 13, 13, 13, 13,
+	13, 13, 13, 13, 13, 63, 6, 6, 5, 5,
+	5, 5,
 }
 
 var exprR2 = [...]int8{
 	0, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	3, 1, 2, 3, 2, 3, 4, 5, 3, 4,
-	5, 6, 3, 4, 5, 6, 3, 4, 5, 6,
-	4, 5, 6, 7, 3, 4, 4, 5, 3, 2,
-	3, 6, 3, 1, 1, 1, 4, 6, 5, 7,
-	4, 5, 5, 6, 7, 7, 12, 1, 1, 1,
-	1, 1, 1, 3, 3, 2, 1, 3, 3, 3,
-	3, 3, 1, 2, 1, 2, 2, 2, 2, 2,
+	3, 4, 2, 2, 6, 1, 2, 3, 2, 3,
+	4, 5, 3, 4, 5, 6, 3, 4, 5, 6,
+	3, 4, 5, 6, 4, 5, 6, 7, 3, 4,
+	4, 5, 3, 2, 3, 6, 3, 1, 1, 1,
+	4, 6, 5, 7, 5, 6, 7, 8, 4, 5,
+	5, 6, 7, 7, 6, 7, 7, 12, 8, 10,
+	1, 3, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 3, 3, 2, 1, 3, 3, 3, 3,
+	3, 1, 2, 1, 2, 2, 2, 2, 2, 2,
+	2, 3, 3, 2, 3, 4, 5, 3, 4, 2,
 	2, 2, 2, 2, 2, 1, 1, 4, 3, 2,
-	5, 4, 1, 3, 2, 1, 2, 1, 2, 1,
-	2, 1, 2, 2, 3, 2, 2, 1, 3, 3,
-	1, 3, 3, 2, 1, 1, 1, 1, 3, 2,
-	3, 3, 3, 3, 1, 1, 3, 6, 6, 1,
-	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	5, 4, 3, 1, 3, 2, 1, 2, 1, 2,
+	1, 2, 1, 2, 1, 2, 3, 2, 2, 3,
+	1, 2, 4, 5, 6, 2, 1, 3, 3, 1,
+	3, 3, 2, 1, 1, 1, 1, 3, 2, 3,
+	3, 3, 3, 1, 1, 3, 6, 6, 1, 1,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 1, 1, 1, 3, 2, 1, 1, 1,
-	3, 2, 4, 4, 4, 4, 4, 4, 4, 4,
-	4, 4, 4, 4, 4, 4, 4, 0, 1, 5,
-	4, 5, 4, 1, 1, 2, 4, 5, 2, 4,
-	5, 1, 2, 2, 4, 1, 1, 1, 1, 1,
+	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 1, 1, 1, 3, 2, 1, 1, 1, 3,
+	2, 4, 4, 4, 4, 4, 4, 4, 4, 4,
+	4, 4, 4, 4, 4, 4, 0, 1, 5, 4,
+	5, 4, 1, 1, 2, 4, 5, 2, 4, 5,
+	1, 2, 2, 4, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
-	1, 1, 2, 1, 3, 4, 4, 3, 3,
+	1, 1, 1, 1, 1, 2, 1, 3, 4, 4,
+	3, 3,
 }
 
 var exprChk = [...]int16{
-	-1000, -1, -2, -6, -7, -14, 26, -11, -15, -20,
-	-21, -22, -17, 17, -12, -16, 7, 89, 90, 68,
-	-18, 30, 31, 32, 44, 45, 54, 55, 56, 57,
-	58, 59, 60, 64, 65, 66, 33, 36, 39, 37,
-	38, 40, 41, 42, 43, 34, 35, 67, 80, 81,
-	82, 89, 90, 91, 92, 93, 94, 83, 84, 87,
-	88, 85, 86, -28, -29, -34, 50, -35, -3, 23,
-	24, 25, 15, 84, 16, -7, -6, -2, -10, 18,
-	-9, 5, 26, 26, -4, 28, 29, 7, 7, 26,
-	26, -23, -24, -25, 46, -23, -23, -23, -23, -23,
-	-23, -23, -23, -23, -23, -23, -23, -23, -23, -29,
-	-35, -27, -26, -52, -51, -33, -38, -39, -46, -40,
-	-43, 49, 47, 48, 69, 71, -9, -55, -54, -31,
-	26, 51, 77, 52, 78, 79, 5, -32, -30, 80,
-	6, -19, 72, 27, 27, 18, 2, 21, 13, 84,
-	14, 15, -8, 7, -14, 26, -7, 7, 26, 26,
-	26, -7, 7, -2, 73, 74, 75, 76, -2, -2,
-	-2, -2, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -2, -33, 81, 21, 80, -37, -50, 8, -49,
-	5, -50, 6, 6, -33, 6, -48, -47, 5, -41,
-	-42, 5, -9, -44, -45, 5, -9, 13, 84, 87,
-	88, 85, 86, 83, -36, 6, -19, 80, 26, -9,
-	6, 6, 6, 6, 2, 27, 21, 10, -53, -28,
-	50, -14, -8, 27, 21, -7, 7, -5, 27, 5,
-	-5, 27, 21, 27, 26, 26, 26, 26, -33, -33,
-	-33, 8, -50, 21, 13, 27, 21, 13, 21, 21,
-	72, 9, 4, -21, 72, 9, 4, -21, 9, 4,
-	-21, 9, 4, -21, 9, 4, -21, 9, 4, -21,
-	9, 4, -21, 80, 26, -36, 6, -4, -8, -56,
-	-53, -28, 70, 10, 50, 10, -53, 53, 27, -53,
-	-28, 27, -4, -7, 27, 21, 21, 27, 27, 6,
-	-5, 27, -5, 27, 27, -5, 27, -5, -49, 6,
-	-47, 2, 5, 6, -42, -45, 26, 26, -36, 6,
-	27, 27, -53, -28, -53, 9, -56, -33, -56, 10,
-	5, -13, 61, 62, 63, 10, 27, 27, -53, 27,
-	-7, 5, 21, 27, 27, 27, 27, 6, 6, 27,
-	-4, -53, -56, 26, -56, -53, 50, 10, 10, 27,
-	-4, 27, 6, 27, 27, 5, -53, -56, -56, 10,
-	21, 27, -56, 6, 21, 6, 27,
+	-1000, -1, -2, -7, -8, -15, 31, -12, -16, -21,
+	-22, -23, -18, 97, 18, -13, -17, 7, 108, 109,
+	73, 96, -19, 35, 36, 37, 49, 50, 59, 60,
+	61, 62, 63, 64, 65, 69, 70, 71, 38, 41,
+	44, 42, 43, 45, 46, 47, 48, 39, 40, 92,
+	93, 94, 95, 72, 99, 100, 101, 108, 109, 110,
+	111, 112, 113, 102, 103, 106, 107, 104, 105, -30,
+	-31, -36, 55, -37, -3, -4, 24, 25, 26, 16,
+	103, 17, 27, 28, 29, 30, -8, -7, -2, -63,
+	75, -63, 31, -11, 19, -10, 5, 31, 98, 31,
+	-5, 33, 34, 7, 7, 31, 31, 31, -25, -26,
+	-27, 51, -25, -25, -25, -25, -25, -25, -25, -25,
+	-25, -25, -25, -25, -25, -25, -31, -37, -29, -28,
+	-54, -53, -55, -56, -57, 88, 89, 90, -35, -40,
+	-41, -48, -42, -45, 54, 52, 53, 74, 76, 87,
+	85, 86, -10, -62, -61, -33, 31, 56, 82, 57,
+	83, 84, 5, -34, -32, 99, 6, -20, 77, 6,
+	32, 32, 10, -8, 19, 2, 22, 14, 103, 15,
+	16, -9, 7, -15, 31, -8, 7, -8, 7, 6,
+	31, 31, 31, -8, -8, 7, -2, 78, 79, 80,
+	81, -2, -2, -2, -2, -2, -2, -2, -2, -2,
+	-2, -2, -2, -2, -2, 5, 6, -5, 91, 7,
+	-35, 100, 22, 99, -39, -52, 8, -51, 5, -52,
+	6, 6, -52, 31, 31, -35, 6, -50, -49, 5,
+	-43, -44, 5, -10, -46, -47, 5, -10, 14, 103,
+	106, 107, 104, 105, 102, -38, 6, -20, 99, 31,
+	7, -63, 22, -10, 6, 6, 6, 6, 2, 32,
+	22, 11, -60, -30, 55, -15, -9, 9, 22, 32,
+	22, 22, -8, 7, 6, -6, 32, 5, -6, 32,
+	22, 22, 32, 31, 31, 31, 31, 91, 10, -5,
+	-35, -35, -35, 8, -52, 22, 14, -6, 6, 32,
+	22, 14, 22, 22, 77, 10, 4, -22, 77, 10,
+	4, -22, 10, 4, -22, 10, 4, -22, 10, 4,
+	-22, 10, 4, -22, 10, 4, -22, 99, 31, -38,
+	6, 10, -5, -9, -63, -60, -30, 11, 55, 11,
+	-60, 58, 32, -60, -30, 32, 32, -63, -8, -5,
+	-8, -8, 32, 22, 22, 22, 32, 32, 6, 6,
+	-6, 32, -6, 32, 32, -6, 32, -6, 10, -51,
+	6, 32, 22, -49, 2, 5, 6, -44, -47, 31,
+	31, -38, 6, 32, 32, 32, -60, -30, -60, -63,
+	-35, -63, 11, 5, -14, 66, 67, 68, 11, 32,
+	32, -60, 32, 9, 32, 32, -8, -8, 5, 22,
+	22, 32, 32, 32, 32, -59, -58, 5, 6, 6,
+	6, 32, -5, -60, -63, 31, -63, -60, 55, 11,
+	11, 32, 32, -63, -5, -5, 32, 32, 6, 6,
+	-58, 14, 32, 32, 32, 5, -60, -63, -63, 11,
+	32, 22, 32, 22, 6, 32, -63, 6, -24, 6,
+	22, 32, 22, 6, 6, 32,
 }
 
 var exprDef = [...]int16{
-	0, -2, 1, 2, 3, 11, 0, 4, 5, 6,
-	7, 8, 9, 0, 0, 0, 191, 0, 0, 0,
-	0, 207, 208, 209, 210, 211, 212, 213, 214, 215,
-	216, 217, 218, 219, 220, 221, 196, 197, 198, 199,
-	200, 201, 202, 203, 204, 205, 206, 195, 177, 177,
-	177, 177, 177, 177, 177, 177, 177, 177, 177, 177,
-	177, 177, 177, 12, 72, 74, 0, 92, 0, 57,
-	58, 59, 60, 61, 62, 3, 2, 0, 0, 65,
-	66, 0, 0, 0, 0, 0, 0, 192, 193, 0,
-	0, 0, 183, 184, 178, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 73,
-	94, 75, 76, 77, 78, 79, 80, 81, 82, 83,
-	84, 97, 99, 0, 101, 0, 114, 115, 116, 117,
-	0, 0, 107, 0, 0, 0, 0, 129, 130, 0,
-	89, 0, 85, 10, 13, 63, 64, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 3, 191, 0, 0,
-	0, 3, 0, 162, 0, 0, 185, 188, 163, 164,
-	165, 166, 167, 168, 169, 170, 171, 172, 173, 174,
-	175, 176, 119, 0, 0, 0, 98, 105, 95, 125,
-	124, 103, 100, 102, 0, 106, 113, 110, 0, 156,
-	154, 152, 153, 161, 159, 157, 158, 0, 0, 0,
-	0, 0, 0, 0, 93, 86, 0, 0, 0, 67,
-	68, 69, 70, 71, 39, 46, 0, 14, 0, 0,
-	0, 0, 0, 50, 0, 3, 191, 0, 227, 223,
-	0, 228, 0, 194, 0, 0, 0, 0, 120, 121,
-	122, 96, 104, 0, 0, 118, 0, 0, 0, 0,
-	0, 136, 143, 150, 0, 135, 142, 149, 131, 138,
-	145, 132, 139, 146, 133, 140, 147, 134, 141, 148,
-	137, 144, 151, 0, 0, 91, 0, 48, 0, 15,
-	18, 34, 0, 22, 0, 26, 0, 0, 0, 0,
-	0, 38, 52, 3, 51, 0, 0, 225, 226, 0,
-	0, 180, 0, 182, 186, 0, 189, 0, 126, 123,
-	111, 112, 108, 109, 155, 160, 0, 0, 88, 0,
-	90, 47, 19, 35, 36, 222, 23, 42, 27, 30,
-	40, 0, 43, 44, 45, 16, 0, 0, 0, 53,
-	3, 224, 0, 179, 181, 187, 190, 0, 0, 87,
-	49, 37, 31, 0, 17, 20, 0, 24, 28, 0,
-	54, 55, 0, 127, 128, 0, 21, 25, 29, 32,
-	0, 41, 33, 0, 0, 0, 56,
+	0, -2, 1, 2, 3, 15, 0, 4, 5, 6,
+	7, 8, 9, 0, 0, 0, 0, 230, 0, 0,
+	0, 0, 0, 250, 251, 252, 253, 254, 255, 256,
+	257, 258, 259, 260, 261, 262, 263, 264, 235, 236,
+	237, 238, 239, 240, 241, 242, 243, 244, 245, 246,
+	247, 248, 249, 234, 216, 216, 216, 216, 216, 216,
+	216, 216, 216, 216, 216, 216, 216, 216, 216, 16,
+	91, 93, 0, 123, 0, 0, 72, 73, 74, 75,
+	76, 77, 78, 79, 80, 81, 3, 2, 0, 12,
+	0, 13, 0, 0, 84, 85, 0, 0, 0, 0,
+	0, 0, 0, 231, 232, 0, 0, 0, 0, 222,
+	223, 217, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 92, 125, 94, 95,
+	96, 97, 98, 99, 100, 0, 103, 0, 109, 110,
+	111, 112, 113, 114, 128, 130, 0, 132, 0, 134,
+	0, 0, 153, 154, 155, 156, 0, 0, 146, 0,
+	0, 0, 0, 168, 169, 0, 119, 0, 115, 0,
+	10, 17, 265, 3, 82, 83, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 3, 230, 3, 230, 0,
+	0, 0, 0, 3, 3, 0, 201, 0, 0, 224,
+	227, 202, 203, 204, 205, 206, 207, 208, 209, 210,
+	211, 212, 213, 214, 215, 101, 102, 104, 0, 107,
+	158, 0, 0, 0, 129, 137, 126, 164, 163, 135,
+	131, 133, 138, 0, 0, 0, 145, 152, 149, 0,
+	195, 193, 191, 192, 200, 198, 196, 197, 0, 0,
+	0, 0, 0, 0, 0, 124, 116, 0, 0, 0,
+	122, 11, 0, 86, 87, 88, 89, 90, 43, 50,
+	0, 18, 0, 0, 0, 0, 0, 0, 0, 58,
+	0, 0, 3, 230, 0, 0, 270, 266, 0, 271,
+	0, 0, 233, 0, 0, 0, 0, 0, 105, 108,
+	159, 160, 161, 127, 136, 0, 0, 0, 0, 157,
+	0, 0, 0, 0, 0, 175, 182, 189, 0, 174,
+	181, 188, 170, 177, 184, 171, 178, 185, 172, 179,
+	186, 173, 180, 187, 176, 183, 190, 0, 0, 121,
+	0, 0, 52, 0, 19, 22, 38, 26, 0, 30,
+	0, 0, 0, 0, 0, 42, 54, 0, 3, 60,
+	3, 3, 59, 0, 0, 0, 268, 269, 0, 0,
+	0, 219, 0, 221, 225, 0, 228, 0, 106, 165,
+	162, 142, 0, 150, 151, 147, 148, 194, 199, 0,
+	0, 118, 0, 120, 14, 51, 23, 39, 40, 27,
+	46, 31, 34, 44, 0, 47, 48, 49, 20, 0,
+	0, 0, 55, 0, 61, 64, 3, 3, 267, 0,
+	0, 218, 220, 226, 229, 143, 140, 0, 0, 0,
+	0, 117, 53, 41, 35, 0, 21, 24, 0, 28,
+	32, 0, 56, 0, 62, 65, 63, 66, 0, 0,
+	141, 0, 144, 166, 167, 0, 25, 29, 33, 36,
+	57, 0, 68, 0, 139, 45, 37, 0, 0, 70,
+	0, 69, 0, 0, 71, 67,
 }
 
 var exprTok1 = [...]int8{
@@ -555,7 +661,9 @@ var exprTok2 = [...]int8{
 	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
 	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
 	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
-	92, 93, 94,
+	92, 93, 94, 95, 96, 97, 98, 99, 100, 101,
+	102, 103, 104, 105, 106, 107, 108, 109, 110, 111,
+	112, 113,
 }
 
 var exprTok3 = [...]int8{
@@ -901,1368 +1009,1626 @@ exprdefault:
 
 	case 1:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:154
+//line expr.y:167
 		{
 			exprlex.(*parser).expr = exprDollar[1].Expr
 		}
 	case 2:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:157
+//line expr.y:170
 		{
 			exprVAL.Expr = exprDollar[1].LogExpr
 		}
 	case 3:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:158
+//line expr.y:171
 		{
 			exprVAL.Expr = exprDollar[1].MetricExpr
 		}
 	case 4:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:162
+//line expr.y:175
 		{
 			exprVAL.MetricExpr = exprDollar[1].RangeAggregationExpr
 		}
 	case 5:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:163
+//line expr.y:176
 		{
 			exprVAL.MetricExpr = exprDollar[1].VectorAggregationExpr
 		}
 	case 6:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:164
+//line expr.y:177
 		{
 			exprVAL.MetricExpr = exprDollar[1].BinOpExpr
 		}
 	case 7:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:165
+//line expr.y:178
 		{
 			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
 		}
 	case 8:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:166
+//line expr.y:179
 		{
 			exprVAL.MetricExpr = exprDollar[1].LabelReplaceExpr
 		}
 	case 9:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:167
+//line expr.y:180
 		{
 			exprVAL.MetricExpr = exprDollar[1].VectorExpr
 		}
 	case 10:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:168
+//line expr.y:181
 		{
 			exprVAL.MetricExpr = exprDollar[2].MetricExpr
 		}
 	case 11:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:182
+		{
+			exprVAL.MetricExpr = mustNewTimeShiftExpr(exprDollar[2].MetricExpr, exprDollar[4].OffsetExpr.Offset)
+		}
+	case 12:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:183
+		{
+			exprVAL.MetricExpr = mustNewTimeShiftExpr(exprDollar[1].RangeAggregationExpr, exprDollar[2].OffsetExpr.Offset)
+		}
+	case 13:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:184
+		{
+			exprVAL.MetricExpr = mustNewTimeShiftExpr(exprDollar[1].VectorAggregationExpr, exprDollar[2].OffsetExpr.Offset)
+		}
+	case 14:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:185
+		{
+			exprVAL.MetricExpr = mustNewTimeShiftExpr(exprDollar[3].MetricExpr, exprDollar[5].duration)
+		}
+	case 15:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:172
+//line expr.y:189
 		{
 			exprVAL.LogExpr = newMatcherExpr(exprDollar[1].Selector)
 		}
-	case 12:
+	case 16:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:173
+//line expr.y:190
 		{
 			exprVAL.LogExpr = newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr)
 		}
-	case 13:
+	case 17:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:174
+//line expr.y:191
 		{
 			exprVAL.LogExpr = exprDollar[2].LogExpr
 		}
-	case 14:
+	case 18:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:178
+//line expr.y:195
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, nil)
 		}
-	case 15:
+	case 19:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:179
+//line expr.y:196
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 16:
+	case 20:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:180
+//line expr.y:197
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, nil)
 		}
-	case 17:
+	case 21:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:181
+//line expr.y:198
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, nil, exprDollar[5].OffsetExpr)
 		}
-	case 18:
+	case 22:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:182
+//line expr.y:199
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 19:
+	case 23:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:183
+//line expr.y:200
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].duration, exprDollar[4].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 20:
+	case 24:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:184
+//line expr.y:201
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[5].UnwrapExpr, nil)
 		}
-	case 21:
+	case 25:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:185
+//line expr.y:202
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[4].duration, exprDollar[6].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 22:
+	case 26:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:186
+//line expr.y:203
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, nil)
 		}
-	case 23:
+	case 27:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:187
+//line expr.y:204
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].duration, exprDollar[2].UnwrapExpr, exprDollar[4].OffsetExpr)
 		}
-	case 24:
+	case 28:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:188
+//line expr.y:205
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 25:
+	case 29:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:189
+//line expr.y:206
 		{
 			exprVAL.LogRangeExpr = newLogRange(newMatcherExpr(exprDollar[2].Selector), exprDollar[5].duration, exprDollar[3].UnwrapExpr, exprDollar[6].OffsetExpr)
 		}
-	case 26:
+	case 30:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:190
+//line expr.y:207
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, nil)
 		}
-	case 27:
+	case 31:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:191
+//line expr.y:208
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[3].duration, nil, exprDollar[4].OffsetExpr)
 		}
-	case 28:
+	case 32:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:192
+//line expr.y:209
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, nil)
 		}
-	case 29:
+	case 33:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:193
+//line expr.y:210
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[5].duration, nil, exprDollar[6].OffsetExpr)
 		}
-	case 30:
+	case 34:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:194
+//line expr.y:211
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, nil)
 		}
-	case 31:
+	case 35:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:195
+//line expr.y:212
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[2].PipelineExpr), exprDollar[4].duration, exprDollar[3].UnwrapExpr, exprDollar[5].OffsetExpr)
 		}
-	case 32:
+	case 36:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:196
+//line expr.y:213
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 33:
+	case 37:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:197
+//line expr.y:214
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[2].Selector), exprDollar[3].PipelineExpr), exprDollar[6].duration, exprDollar[4].UnwrapExpr, exprDollar[7].OffsetExpr)
 		}
-	case 34:
+	case 38:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:198
+//line expr.y:215
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, nil, nil)
 		}
-	case 35:
+	case 39:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:199
+//line expr.y:216
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, nil, exprDollar[3].OffsetExpr)
 		}
-	case 36:
+	case 40:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:200
+//line expr.y:217
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[3].PipelineExpr), exprDollar[2].duration, exprDollar[4].UnwrapExpr, nil)
 		}
-	case 37:
+	case 41:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:201
+//line expr.y:218
 		{
 			exprVAL.LogRangeExpr = newLogRange(newPipelineExpr(newMatcherExpr(exprDollar[1].Selector), exprDollar[4].PipelineExpr), exprDollar[2].duration, exprDollar[5].UnwrapExpr, exprDollar[3].OffsetExpr)
 		}
-	case 38:
+	case 42:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:202
+//line expr.y:219
 		{
 			exprVAL.LogRangeExpr = exprDollar[2].LogRangeExpr
 		}
-	case 40:
+	case 44:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:207
+//line expr.y:224
 		{
 			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[3].str, "")
 		}
-	case 41:
+	case 45:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:208
+//line expr.y:225
 		{
 			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[5].str, exprDollar[3].ConvOp)
 		}
-	case 42:
+	case 46:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:209
+//line expr.y:226
 		{
 			exprVAL.UnwrapExpr = exprDollar[1].UnwrapExpr.addPostFilter(exprDollar[3].LabelFilter)
 		}
-	case 43:
+	case 47:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:213
+//line expr.y:230
 		{
 			exprVAL.ConvOp = OpConvBytes
 		}
-	case 44:
+	case 48:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:214
+//line expr.y:231
 		{
 			exprVAL.ConvOp = OpConvDuration
 		}
-	case 45:
+	case 49:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:215
+//line expr.y:232
 		{
 			exprVAL.ConvOp = OpConvDurationSeconds
 		}
-	case 46:
+	case 50:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:219
+//line expr.y:236
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, nil, nil)
 		}
-	case 47:
+	case 51:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:220
+//line expr.y:237
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, nil, &exprDollar[3].str)
 		}
-	case 48:
+	case 52:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:221
+//line expr.y:238
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[5].Grouping, nil)
 		}
-	case 49:
+	case 53:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:222
+//line expr.y:239
 		{
 			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
-	case 50:
-		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:227
-		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
-		}
-	case 51:
-		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:228
-		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
-		}
-	case 52:
-		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:229
-		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
-		}
-	case 53:
-		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:231
-		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
-		}
 	case 54:
-		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:232
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:241
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
+			exprVAL.RangeAggregationExpr = mustNewSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].str, nil, nil)
 		}
 	case 55:
-		exprDollar = exprS[exprpt-7 : exprpt+1]
-//line expr.y:233
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:242
 		{
-			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, &exprDollar[4].str)
+			exprVAL.RangeAggregationExpr = mustNewSubqueryExpr(exprDollar[3].MetricExpr, exprDollar[1].RangeOp, exprDollar[4].str, exprDollar[5].OffsetExpr, nil)
 		}
 	case 56:
-		exprDollar = exprS[exprpt-12 : exprpt+1]
-//line expr.y:238
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:243
 		{
-			exprVAL.LabelReplaceExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
+			exprVAL.RangeAggregationExpr = mustNewSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].str, nil, &exprDollar[3].str)
 		}
 	case 57:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:242
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:244
 		{
-			exprVAL.Filter = log.LineMatchRegexp
+			exprVAL.RangeAggregationExpr = mustNewSubqueryExpr(exprDollar[5].MetricExpr, exprDollar[1].RangeOp, exprDollar[6].str, exprDollar[7].OffsetExpr, &exprDollar[3].str)
 		}
 	case 58:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:243
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:249
 		{
-			exprVAL.Filter = log.LineMatchEqual
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
 		}
 	case 59:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:244
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:250
 		{
-			exprVAL.Filter = log.LineMatchPattern
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
 		}
 	case 60:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:245
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:251
 		{
-			exprVAL.Filter = log.LineMatchNotRegexp
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
 		}
 	case 61:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:246
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:253
 		{
-			exprVAL.Filter = log.LineMatchNotEqual
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
 		}
 	case 62:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:247
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:254
 		{
-			exprVAL.Filter = log.LineMatchNotPattern
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
 	case 63:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:251
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:255
 		{
-			exprVAL.Selector = exprDollar[2].Matchers
+			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, &exprDollar[4].str)
 		}
 	case 64:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:252
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:256
 		{
-			exprVAL.Selector = exprDollar[2].Matchers
+			exprVAL.VectorAggregationExpr = mustNewCountValuesExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, exprDollar[3].str)
 		}
 	case 65:
-		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:253
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:257
 		{
+			exprVAL.VectorAggregationExpr = mustNewCountValuesExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, exprDollar[3].str)
 		}
 	case 66:
-		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:257
+		exprDollar = exprS[exprpt-7 : exprpt+1]
+//line expr.y:258
 		{
-			exprVAL.Matchers = []*labels.Matcher{exprDollar[1].Matcher}
+			exprVAL.VectorAggregationExpr = mustNewCountValuesExpr(exprDollar[6].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, exprDollar[4].str)
 		}
 	case 67:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:258
+		exprDollar = exprS[exprpt-12 : exprpt+1]
+//line expr.y:263
 		{
-			exprVAL.Matchers = append(exprDollar[1].Matchers, exprDollar[3].Matcher)
+			exprVAL.LabelReplaceExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
 		}
 	case 68:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:262
+		exprDollar = exprS[exprpt-8 : exprpt+1]
+//line expr.y:265
 		{
-			exprVAL.Matcher = mustNewMatcher(labels.MatchEqual, exprDollar[1].str, exprDollar[3].str)
+			exprVAL.LabelReplaceExpr = mustNewLabelJoinExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, nil)
 		}
 	case 69:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:263
+		exprDollar = exprS[exprpt-10 : exprpt+1]
+//line expr.y:267
 		{
-			exprVAL.Matcher = mustNewMatcher(labels.MatchNotEqual, exprDollar[1].str, exprDollar[3].str)
+			exprVAL.LabelReplaceExpr = mustNewLabelJoinExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].Labels)
 		}
 	case 70:
-		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:264
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:271
 		{
-			exprVAL.Matcher = mustNewMatcher(labels.MatchRegexp, exprDollar[1].str, exprDollar[3].str)
+			exprVAL.Labels = []string{exprDollar[1].str}
 		}
 	case 71:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:265
+//line expr.y:272
 		{
-			exprVAL.Matcher = mustNewMatcher(labels.MatchNotRegexp, exprDollar[1].str, exprDollar[3].str)
+			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
 	case 72:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:269
+//line expr.y:276
 		{
-			exprVAL.PipelineExpr = MultiStageExpr{exprDollar[1].PipelineStage}
+			exprVAL.Filter = log.LineMatchRegexp
 		}
 	case 73:
-		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:270
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:277
 		{
-			exprVAL.PipelineExpr = append(exprDollar[1].PipelineExpr, exprDollar[2].PipelineStage)
+			exprVAL.Filter = log.LineMatchEqual
 		}
 	case 74:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:274
+//line expr.y:278
 		{
-			exprVAL.PipelineStage = exprDollar[1].LineFilters
+			exprVAL.Filter = log.LineMatchPattern
 		}
 	case 75:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:279
+		{
+			exprVAL.Filter = log.LineMatchNotRegexp
+		}
+	case 76:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:280
+		{
+			exprVAL.Filter = log.LineMatchNotEqual
+		}
+	case 77:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:281
+		{
+			exprVAL.Filter = log.LineMatchNotPattern
+		}
+	case 78:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:282
+		{
+			exprVAL.Filter = log.LineMatchEqualCaseInsensitive
+		}
+	case 79:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:283
+		{
+			exprVAL.Filter = log.LineMatchNotEqualCaseInsensitive
+		}
+	case 80:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:287
+		{
+			exprVAL.Filter = log.LineMatchFuzzy
+		}
+	case 81:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:288
+		{
+			exprVAL.Filter = log.LineMatchNotFuzzy
+		}
+	case 82:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:292
+		{
+			exprVAL.Selector = exprDollar[2].Matchers
+		}
+	case 83:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:293
+		{
+			exprVAL.Selector = exprDollar[2].Matchers
+		}
+	case 84:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:275
+//line expr.y:294
+		{
+		}
+	case 85:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:298
+		{
+			exprVAL.Matchers = []*labels.Matcher{exprDollar[1].Matcher}
+		}
+	case 86:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:299
+		{
+			exprVAL.Matchers = append(exprDollar[1].Matchers, exprDollar[3].Matcher)
+		}
+	case 87:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:303
+		{
+			exprVAL.Matcher = mustNewMatcher(labels.MatchEqual, exprDollar[1].str, exprDollar[3].str)
+		}
+	case 88:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:304
+		{
+			exprVAL.Matcher = mustNewMatcher(labels.MatchNotEqual, exprDollar[1].str, exprDollar[3].str)
+		}
+	case 89:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:305
+		{
+			exprVAL.Matcher = mustNewMatcher(labels.MatchRegexp, exprDollar[1].str, exprDollar[3].str)
+		}
+	case 90:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:306
+		{
+			exprVAL.Matcher = mustNewMatcher(labels.MatchNotRegexp, exprDollar[1].str, exprDollar[3].str)
+		}
+	case 91:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:310
+		{
+			exprVAL.PipelineExpr = MultiStageExpr{exprDollar[1].PipelineStage}
+		}
+	case 92:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:311
+		{
+			exprVAL.PipelineExpr = append(exprDollar[1].PipelineExpr, exprDollar[2].PipelineStage)
+		}
+	case 93:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:315
+		{
+			exprVAL.PipelineStage = exprDollar[1].LineFilters
+		}
+	case 94:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:316
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtParser
 		}
-	case 76:
+	case 95:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:276
+//line expr.y:317
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelParser
 		}
-	case 77:
+	case 96:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:277
+//line expr.y:318
 		{
 			exprVAL.PipelineStage = exprDollar[2].JSONExpressionParser
 		}
-	case 78:
+	case 97:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:278
+//line expr.y:319
 		{
 			exprVAL.PipelineStage = exprDollar[2].LogfmtExpressionParser
 		}
-	case 79:
+	case 98:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:279
+//line expr.y:320
+		{
+			exprVAL.PipelineStage = exprDollar[2].XMLExpressionParser
+		}
+	case 99:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:321
+		{
+			exprVAL.PipelineStage = exprDollar[2].CSVParser
+		}
+	case 100:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:322
+		{
+			exprVAL.PipelineStage = exprDollar[2].KVParser
+		}
+	case 101:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:323
+		{
+			exprVAL.PipelineStage = newExplodeExpr(exprDollar[3].str)
+		}
+	case 102:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:324
+		{
+			exprVAL.PipelineStage = newExplodeExpr(exprDollar[3].str)
+		}
+	case 103:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:325
+		{
+			exprVAL.PipelineStage = newDedupExpr(nil, 0)
+		}
+	case 104:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:326
+		{
+			exprVAL.PipelineStage = newDedupExpr(exprDollar[3].Grouping, 0)
+		}
+	case 105:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:327
+		{
+			exprVAL.PipelineStage = newDedupExpr(nil, exprDollar[4].duration)
+		}
+	case 106:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:328
+		{
+			exprVAL.PipelineStage = newDedupExpr(exprDollar[3].Grouping, exprDollar[5].duration)
+		}
+	case 107:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:329
+		{
+			exprVAL.PipelineStage = newLimitByExpr(exprDollar[3].str, nil)
+		}
+	case 108:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:330
+		{
+			exprVAL.PipelineStage = newLimitByExpr(exprDollar[3].str, exprDollar[4].Grouping)
+		}
+	case 109:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:331
 		{
 			exprVAL.PipelineStage = &LabelFilterExpr{LabelFilterer: exprDollar[2].LabelFilter}
 		}
-	case 80:
+	case 110:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:280
+//line expr.y:332
 		{
 			exprVAL.PipelineStage = exprDollar[2].LineFormatExpr
 		}
-	case 81:
+	case 111:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:281
+//line expr.y:333
 		{
 			exprVAL.PipelineStage = exprDollar[2].DecolorizeExpr
 		}
-	case 82:
+	case 112:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:282
+//line expr.y:334
 		{
 			exprVAL.PipelineStage = exprDollar[2].LabelFormatExpr
 		}
-	case 83:
+	case 113:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:283
+//line expr.y:335
 		{
 			exprVAL.PipelineStage = exprDollar[2].DropLabelsExpr
 		}
-	case 84:
+	case 114:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:284
+//line expr.y:336
 		{
 			exprVAL.PipelineStage = exprDollar[2].KeepLabelsExpr
 		}
-	case 85:
+	case 115:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:288
+//line expr.y:340
 		{
 			exprVAL.FilterOp = OpFilterIP
 		}
-	case 86:
+	case 116:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:292
+//line expr.y:344
 		{
 			exprVAL.OrFilter = newLineFilterExpr(log.LineMatchEqual, "", exprDollar[1].str)
 		}
-	case 87:
+	case 117:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:293
+//line expr.y:345
 		{
 			exprVAL.OrFilter = newLineFilterExpr(log.LineMatchEqual, exprDollar[1].FilterOp, exprDollar[3].str)
 		}
-	case 88:
+	case 118:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:294
+//line expr.y:346
 		{
 			exprVAL.OrFilter = newOrLineFilter(newLineFilterExpr(log.LineMatchEqual, "", exprDollar[1].str), exprDollar[3].OrFilter)
 		}
-	case 89:
+	case 119:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:298
+//line expr.y:350
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str)
 		}
-	case 90:
+	case 120:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:299
+//line expr.y:351
 		{
 			exprVAL.LineFilter = newLineFilterExpr(exprDollar[1].Filter, exprDollar[2].FilterOp, exprDollar[4].str)
 		}
-	case 91:
+	case 121:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:300
+//line expr.y:352
 		{
 			exprVAL.LineFilter = newOrLineFilter(newLineFilterExpr(exprDollar[1].Filter, "", exprDollar[2].str), exprDollar[4].OrFilter)
 		}
-	case 92:
+	case 122:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:353
+		{
+			exprVAL.LineFilter = newFuzzyLineFilterExpr(exprDollar[1].Filter, exprDollar[2].str, exprDollar[3].str)
+		}
+	case 123:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:304
+//line expr.y:357
 		{
 			exprVAL.LineFilters = exprDollar[1].LineFilter
 		}
-	case 93:
+	case 124:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:305
+//line expr.y:358
 		{
 			exprVAL.LineFilters = newOrLineFilter(exprDollar[1].LineFilter, exprDollar[3].OrFilter)
 		}
-	case 94:
+	case 125:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:306
+//line expr.y:359
 		{
 			exprVAL.LineFilters = newNestedLineFilterExpr(exprDollar[1].LineFilters, exprDollar[2].LineFilter)
 		}
-	case 95:
+	case 126:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:310
+//line expr.y:363
 		{
 			exprVAL.ParserFlags = []string{exprDollar[1].str}
 		}
-	case 96:
+	case 127:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:311
+//line expr.y:364
 		{
 			exprVAL.ParserFlags = append(exprDollar[1].ParserFlags, exprDollar[2].str)
 		}
-	case 97:
+	case 128:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:315
+//line expr.y:368
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(nil)
 		}
-	case 98:
+	case 129:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:316
+//line expr.y:369
 		{
 			exprVAL.LogfmtParser = newLogfmtParserExpr(exprDollar[2].ParserFlags)
 		}
-	case 99:
+	case 130:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:320
+//line expr.y:373
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeJSON, "")
 		}
-	case 100:
+	case 131:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:321
+//line expr.y:374
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeRegexp, exprDollar[2].str)
 		}
-	case 101:
+	case 132:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:322
+//line expr.y:375
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeUnpack, "")
 		}
-	case 102:
+	case 133:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:323
+//line expr.y:376
 		{
 			exprVAL.LabelParser = newLabelParserExpr(OpParserTypePattern, exprDollar[2].str)
 		}
-	case 103:
+	case 134:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:377
+		{
+			exprVAL.LabelParser = newLabelParserExpr(OpParserTypeXML, "")
+		}
+	case 135:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:327
+//line expr.y:381
 		{
 			exprVAL.JSONExpressionParser = newJSONExpressionParser(exprDollar[2].LabelExtractionExpressionList)
 		}
-	case 104:
+	case 136:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:330
+//line expr.y:384
 		{
 			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[3].LabelExtractionExpressionList, exprDollar[2].ParserFlags)
 		}
-	case 105:
+	case 137:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:331
+//line expr.y:385
 		{
 			exprVAL.LogfmtExpressionParser = newLogfmtExpressionParser(exprDollar[2].LabelExtractionExpressionList, nil)
 		}
-	case 106:
+	case 138:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:334
+//line expr.y:389
+		{
+			exprVAL.XMLExpressionParser = newXMLExpressionParser(exprDollar[2].LabelExtractionExpressionList)
+		}
+	case 139:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+//line expr.y:393
+		{
+			exprVAL.ParserOption = ParserOption{Name: exprDollar[1].str, Value: exprDollar[3].str}
+		}
+	case 140:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:397
+		{
+			exprVAL.ParserOptions = []ParserOption{exprDollar[1].ParserOption}
+		}
+	case 141:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:398
+		{
+			exprVAL.ParserOptions = append(exprDollar[1].ParserOptions, exprDollar[2].ParserOption)
+		}
+	case 142:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+//line expr.y:402
+		{
+			exprVAL.CSVParser = newCSVParserExpr(exprDollar[3].Labels, nil)
+		}
+	case 143:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+//line expr.y:403
+		{
+			exprVAL.CSVParser = newCSVParserExpr(exprDollar[3].Labels, exprDollar[5].ParserOptions)
+		}
+	case 144:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+//line expr.y:407
+		{
+			exprVAL.KVParser = newKVParserExpr(exprDollar[3].str, exprDollar[5].str)
+		}
+	case 145:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+//line expr.y:410
 		{
 			exprVAL.LineFormatExpr = newLineFmtExpr(exprDollar[2].str)
 		}
-	case 107:
+	case 146:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:336
+//line expr.y:412
 		{
 			exprVAL.DecolorizeExpr = newDecolorizeExpr()
 		}
-	case 108:
+	case 147:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:339
+//line expr.y:415
 		{
 			exprVAL.LabelFormat = log.NewRenameLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 109:
+	case 148:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:340
+//line expr.y:416
 		{
 			exprVAL.LabelFormat = log.NewTemplateLabelFmt(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 110:
+	case 149:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:344
+//line expr.y:420
 		{
 			exprVAL.LabelsFormat = []log.LabelFmt{exprDollar[1].LabelFormat}
 		}
-	case 111:
+	case 150:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:345
+//line expr.y:421
 		{
 			exprVAL.LabelsFormat = append(exprDollar[1].LabelsFormat, exprDollar[3].LabelFormat)
 		}
-	case 113:
+	case 152:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:350
+//line expr.y:426
 		{
 			exprVAL.LabelFormatExpr = newLabelFmtExpr(exprDollar[2].LabelsFormat)
 		}
-	case 114:
+	case 153:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:353
+//line expr.y:429
 		{
 			exprVAL.LabelFilter = log.NewStringLabelFilter(exprDollar[1].Matcher)
 		}
-	case 115:
+	case 154:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:354
+//line expr.y:430
 		{
 			exprVAL.LabelFilter = exprDollar[1].IPLabelFilter
 		}
-	case 116:
+	case 155:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:355
+//line expr.y:431
 		{
 			exprVAL.LabelFilter = exprDollar[1].UnitFilter
 		}
-	case 117:
+	case 156:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:356
+//line expr.y:432
 		{
 			exprVAL.LabelFilter = exprDollar[1].NumberFilter
 		}
-	case 118:
+	case 157:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:357
+//line expr.y:433
 		{
 			exprVAL.LabelFilter = exprDollar[2].LabelFilter
 		}
-	case 119:
+	case 158:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:358
+//line expr.y:434
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[2].LabelFilter)
 		}
-	case 120:
+	case 159:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:359
+//line expr.y:435
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 121:
+	case 160:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:360
+//line expr.y:436
 		{
 			exprVAL.LabelFilter = log.NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 122:
+	case 161:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:361
+//line expr.y:437
 		{
 			exprVAL.LabelFilter = log.NewOrLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
 		}
-	case 123:
+	case 162:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:365
+//line expr.y:441
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[3].str)
 		}
-	case 124:
+	case 163:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:366
+//line expr.y:442
 		{
 			exprVAL.LabelExtractionExpression = log.NewLabelExtractionExpr(exprDollar[1].str, exprDollar[1].str)
 		}
-	case 125:
+	case 164:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:369
+//line expr.y:445
 		{
 			exprVAL.LabelExtractionExpressionList = []log.LabelExtractionExpr{exprDollar[1].LabelExtractionExpression}
 		}
-	case 126:
+	case 165:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:370
+//line expr.y:446
 		{
 			exprVAL.LabelExtractionExpressionList = append(exprDollar[1].LabelExtractionExpressionList, exprDollar[3].LabelExtractionExpression)
 		}
-	case 127:
+	case 166:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:374
+//line expr.y:450
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterEqual)
 		}
-	case 128:
+	case 167:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
-//line expr.y:375
+//line expr.y:451
 		{
 			exprVAL.IPLabelFilter = log.NewIPLabelFilter(exprDollar[5].str, exprDollar[1].str, log.LabelFilterNotEqual)
 		}
-	case 129:
+	case 168:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:379
+//line expr.y:455
 		{
 			exprVAL.UnitFilter = exprDollar[1].DurationFilter
 		}
-	case 130:
+	case 169:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:380
+//line expr.y:456
 		{
 			exprVAL.UnitFilter = exprDollar[1].BytesFilter
 		}
-	case 131:
+	case 170:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:383
+//line expr.y:459
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 132:
+	case 171:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:384
+//line expr.y:460
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 133:
+	case 172:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:385
+//line expr.y:461
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 134:
+	case 173:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:386
+//line expr.y:462
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 135:
+	case 174:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:387
+//line expr.y:463
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 136:
+	case 175:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:388
+//line expr.y:464
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 137:
+	case 176:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:389
+//line expr.y:465
 		{
 			exprVAL.DurationFilter = log.NewDurationLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
 		}
-	case 138:
+	case 177:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:393
+//line expr.y:469
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 139:
+	case 178:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:394
+//line expr.y:470
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 140:
+	case 179:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:395
+//line expr.y:471
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 141:
+	case 180:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:396
+//line expr.y:472
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 142:
+	case 181:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:397
+//line expr.y:473
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 143:
+	case 182:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:398
+//line expr.y:474
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 144:
+	case 183:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:399
+//line expr.y:475
 		{
 			exprVAL.BytesFilter = log.NewBytesLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
 		}
-	case 145:
+	case 184:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:403
+//line expr.y:479
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 146:
+	case 185:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:404
+//line expr.y:480
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 147:
+	case 186:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:405
+//line expr.y:481
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 148:
+	case 187:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:406
+//line expr.y:482
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 149:
+	case 188:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:407
+//line expr.y:483
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 150:
+	case 189:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:408
+//line expr.y:484
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 151:
+	case 190:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:409
+//line expr.y:485
 		{
 			exprVAL.NumberFilter = log.NewNumericLabelFilter(log.LabelFilterEqual, exprDollar[1].str, exprDollar[3].LiteralExpr.Val)
 		}
-	case 152:
+	case 191:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:413
+//line expr.y:489
 		{
 			exprVAL.DropLabel = log.NewDropLabel(nil, exprDollar[1].str)
 		}
-	case 153:
+	case 192:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:414
+//line expr.y:490
 		{
 			exprVAL.DropLabel = log.NewDropLabel(exprDollar[1].Matcher, "")
 		}
-	case 154:
+	case 193:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:417
+//line expr.y:493
 		{
 			exprVAL.DropLabels = []log.DropLabel{exprDollar[1].DropLabel}
 		}
-	case 155:
+	case 194:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:418
+//line expr.y:494
 		{
 			exprVAL.DropLabels = append(exprDollar[1].DropLabels, exprDollar[3].DropLabel)
 		}
-	case 156:
+	case 195:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:421
+//line expr.y:497
 		{
 			exprVAL.DropLabelsExpr = newDropLabelsExpr(exprDollar[2].DropLabels)
 		}
-	case 157:
+	case 196:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:424
+//line expr.y:500
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(nil, exprDollar[1].str)
 		}
-	case 158:
+	case 197:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:425
+//line expr.y:501
 		{
 			exprVAL.KeepLabel = log.NewKeepLabel(exprDollar[1].Matcher, "")
 		}
-	case 159:
+	case 198:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:428
+//line expr.y:504
 		{
 			exprVAL.KeepLabels = []log.KeepLabel{exprDollar[1].KeepLabel}
 		}
-	case 160:
+	case 199:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:429
+//line expr.y:505
 		{
 			exprVAL.KeepLabels = append(exprDollar[1].KeepLabels, exprDollar[3].KeepLabel)
 		}
-	case 161:
+	case 200:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:432
+//line expr.y:508
 		{
 			exprVAL.KeepLabelsExpr = newKeepLabelsExpr(exprDollar[2].KeepLabels)
 		}
-	case 162:
+	case 201:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:436
+//line expr.y:512
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("or", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 163:
+	case 202:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:437
+//line expr.y:513
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("and", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 164:
+	case 203:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:438
+//line expr.y:514
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("unless", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 165:
+	case 204:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:439
+//line expr.y:515
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("+", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 166:
+	case 205:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:440
+//line expr.y:516
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("-", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 167:
+	case 206:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:441
+//line expr.y:517
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("*", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 168:
+	case 207:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:442
+//line expr.y:518
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("/", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 169:
+	case 208:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:443
+//line expr.y:519
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("%", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 170:
+	case 209:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:444
+//line expr.y:520
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("^", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 171:
+	case 210:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:445
+//line expr.y:521
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("==", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 172:
+	case 211:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:446
+//line expr.y:522
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("!=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 173:
+	case 212:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:447
+//line expr.y:523
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 174:
+	case 213:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:448
+//line expr.y:524
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 175:
+	case 214:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:449
+//line expr.y:525
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 176:
+	case 215:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:450
+//line expr.y:526
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 177:
+	case 216:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
-//line expr.y:454
+//line expr.y:530
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}}
 		}
-	case 178:
+	case 217:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:458
+//line expr.y:534
 		{
 			exprVAL.BoolModifier = &BinOpOptions{VectorMatching: &VectorMatching{Card: CardOneToOne}, ReturnBool: true}
 		}
-	case 179:
+	case 218:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:465
+//line expr.y:541
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 180:
+	case 219:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:471
+//line expr.y:547
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.On = true
 		}
-	case 181:
+	case 220:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:476
+//line expr.y:552
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 			exprVAL.OnOrIgnoringModifier.VectorMatching.MatchingLabels = exprDollar[4].Labels
 		}
-	case 182:
+	case 221:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:481
+//line expr.y:557
 		{
 			exprVAL.OnOrIgnoringModifier = exprDollar[1].BoolModifier
 		}
-	case 183:
+	case 222:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:487
+//line expr.y:563
 		{
 			exprVAL.BinOpModifier = exprDollar[1].BoolModifier
 		}
-	case 184:
+	case 223:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:488
+//line expr.y:564
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 		}
-	case 185:
+	case 224:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:490
+//line expr.y:566
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 186:
+	case 225:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:495
+//line expr.y:571
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 		}
-	case 187:
+	case 226:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:500
+//line expr.y:576
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 188:
+	case 227:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:506
+//line expr.y:582
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 189:
+	case 228:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:511
+//line expr.y:587
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 		}
-	case 190:
+	case 229:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
-//line expr.y:516
+//line expr.y:592
 		{
 			exprVAL.BinOpModifier = exprDollar[1].OnOrIgnoringModifier
 			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
 			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
 		}
-	case 191:
+	case 230:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:524
+//line expr.y:600
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[1].str, false)
 		}
-	case 192:
+	case 231:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:525
+//line expr.y:601
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, false)
 		}
-	case 193:
+	case 232:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:526
+//line expr.y:602
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, true)
 		}
-	case 194:
+	case 233:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:530
+//line expr.y:606
 		{
 			exprVAL.VectorExpr = NewVectorExpr(exprDollar[3].str)
 		}
-	case 195:
+	case 234:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:533
+//line expr.y:609
 		{
 			exprVAL.Vector = OpTypeVector
 		}
-	case 196:
+	case 235:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:537
+//line expr.y:613
 		{
 			exprVAL.VectorOp = OpTypeSum
 		}
-	case 197:
+	case 236:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:538
+//line expr.y:614
 		{
 			exprVAL.VectorOp = OpTypeAvg
 		}
-	case 198:
+	case 237:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:539
+//line expr.y:615
 		{
 			exprVAL.VectorOp = OpTypeCount
 		}
-	case 199:
+	case 238:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:540
+//line expr.y:616
 		{
 			exprVAL.VectorOp = OpTypeMax
 		}
-	case 200:
+	case 239:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:541
+//line expr.y:617
 		{
 			exprVAL.VectorOp = OpTypeMin
 		}
-	case 201:
+	case 240:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:542
+//line expr.y:618
 		{
 			exprVAL.VectorOp = OpTypeStddev
 		}
-	case 202:
+	case 241:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:543
+//line expr.y:619
 		{
 			exprVAL.VectorOp = OpTypeStdvar
 		}
-	case 203:
+	case 242:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:544
+//line expr.y:620
 		{
 			exprVAL.VectorOp = OpTypeBottomK
 		}
-	case 204:
+	case 243:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:545
+//line expr.y:621
 		{
 			exprVAL.VectorOp = OpTypeTopK
 		}
-	case 205:
+	case 244:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:546
+//line expr.y:622
 		{
 			exprVAL.VectorOp = OpTypeSort
 		}
-	case 206:
+	case 245:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:547
+//line expr.y:623
 		{
 			exprVAL.VectorOp = OpTypeSortDesc
 		}
-	case 207:
+	case 246:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:624
+		{
+			exprVAL.VectorOp = OpTypeCountValues
+		}
+	case 247:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:625
+		{
+			exprVAL.VectorOp = OpTypeGroup
+		}
+	case 248:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:626
+		{
+			exprVAL.VectorOp = OpTypeLimitK
+		}
+	case 249:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:551
+//line expr.y:627
+		{
+			exprVAL.VectorOp = OpTypeQuantile
+		}
+	case 250:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+//line expr.y:631
 		{
 			exprVAL.RangeOp = OpRangeTypeCount
 		}
-	case 208:
+	case 251:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:552
+//line expr.y:632
 		{
 			exprVAL.RangeOp = OpRangeTypeRate
 		}
-	case 209:
+	case 252:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:553
+//line expr.y:633
 		{
 			exprVAL.RangeOp = OpRangeTypeRateCounter
 		}
-	case 210:
+	case 253:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:554
+//line expr.y:634
 		{
 			exprVAL.RangeOp = OpRangeTypeBytes
 		}
-	case 211:
+	case 254:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:555
+//line expr.y:635
 		{
 			exprVAL.RangeOp = OpRangeTypeBytesRate
 		}
-	case 212:
+	case 255:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:556
+//line expr.y:636
 		{
 			exprVAL.RangeOp = OpRangeTypeAvg
 		}
-	case 213:
+	case 256:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:557
+//line expr.y:637
 		{
 			exprVAL.RangeOp = OpRangeTypeSum
 		}
-	case 214:
+	case 257:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:558
+//line expr.y:638
 		{
 			exprVAL.RangeOp = OpRangeTypeMin
 		}
-	case 215:
+	case 258:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:559
+//line expr.y:639
 		{
 			exprVAL.RangeOp = OpRangeTypeMax
 		}
-	case 216:
+	case 259:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:560
+//line expr.y:640
 		{
 			exprVAL.RangeOp = OpRangeTypeStdvar
 		}
-	case 217:
+	case 260:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:561
+//line expr.y:641
 		{
 			exprVAL.RangeOp = OpRangeTypeStddev
 		}
-	case 218:
+	case 261:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:562
+//line expr.y:642
 		{
 			exprVAL.RangeOp = OpRangeTypeQuantile
 		}
-	case 219:
+	case 262:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:563
+//line expr.y:643
 		{
 			exprVAL.RangeOp = OpRangeTypeFirst
 		}
-	case 220:
+	case 263:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:564
+//line expr.y:644
 		{
 			exprVAL.RangeOp = OpRangeTypeLast
 		}
-	case 221:
+	case 264:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:565
+//line expr.y:645
 		{
 			exprVAL.RangeOp = OpRangeTypeAbsent
 		}
-	case 222:
+	case 265:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
-//line expr.y:569
+//line expr.y:649
 		{
 			exprVAL.OffsetExpr = newOffsetExpr(exprDollar[2].duration)
 		}
-	case 223:
+	case 266:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
-//line expr.y:572
+//line expr.y:652
 		{
 			exprVAL.Labels = []string{exprDollar[1].str}
 		}
-	case 224:
+	case 267:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:573
+//line expr.y:653
 		{
 			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
-	case 225:
+	case 268:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:577
+//line expr.y:657
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: exprDollar[3].Labels}
 		}
-	case 226:
+	case 269:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
-//line expr.y:578
+//line expr.y:658
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: exprDollar[3].Labels}
 		}
-	case 227:
+	case 270:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:579
+//line expr.y:659
 		{
 			exprVAL.Grouping = &Grouping{Without: false, Groups: nil}
 		}
-	case 228:
+	case 271:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
-//line expr.y:580
+//line expr.y:660
 		{
 			exprVAL.Grouping = &Grouping{Without: true, Groups: nil}
 		}
@@ -27,6 +27,10 @@ var tokens = map[string]int{
 	"|=":           PIPE_EXACT,
 	"|~":           PIPE_MATCH,
 	"|>":           PIPE_PATTERN,
+	"|=*":          PIPE_EXACT_CI,
+	"!=*":          NEQ_CI,
+	"|~~":          PIPE_FUZZY,
+	"!~~":          NFUZZY,
 	OpPipe:         PIPE,
 	OpUnwrap:       UNWRAP,
 	"(":            OPEN_PARENTHESIS,
@@ -37,6 +41,8 @@ var tokens = map[string]int{
 	"[":            OPEN_BRACKET,
 	"]":            CLOSE_BRACKET,
 	OpLabelReplace: LABEL_REPLACE,
+	OpLabelJoin:    LABEL_JOIN,
+	OpTimeshift:    TIMESHIFT,
 	OpOffset:       OFFSET,
 	OpOn:           ON,
 	OpIgnoring:     IGNORING,
@@ -120,6 +126,13 @@ var functionTokens = map[string]int{
 	OpTypeSort:     SORT,
 	OpTypeSortDesc: SORT_DESC,
 	OpLabelReplace: LABEL_REPLACE,
+	OpLabelJoin:    LABEL_JOIN,
+	OpTimeshift:    TIMESHIFT,
+
+	OpTypeCountValues: COUNT_VALUES,
+	OpTypeGroup:       GROUP,
+	OpTypeLimitK:      LIMITK,
+	OpTypeQuantile:    QUANTILE,
 
 	// conversion Op
 	OpConvBytes:           BYTES_CONV,
@@ -128,15 +141,37 @@ var functionTokens = map[string]int{
 
 	// filterOp
 	OpFilterIP: IP,
+
+	// parsers
+	OpParserTypeCSV: CSV,
+	OpParserTypeKV:  KV,
+}
+
+// contextualTokens are keywords of pipeline stages which are only tokens in the context of the stage,
+// so they remain valid label names, e.g. `| limit 5` and `| limit > 5`. See isContextualToken.
+var contextualTokens = map[string]int{
+	OpDedup:         DEDUP,
+	OpLimit:         LIMIT,
+	OpWithin:        WITHIN,
+	OpParserTypeXML: XML,
+	OpExplode:       EXPLODE,
 }
 
 type lexer struct {
 	Scanner
 	errs    []logqlmodel.ParseError
 	builder strings.Builder
+	// prev is the previously returned token.
+	prev int
 }
 
 func (l *lexer) Lex(lval *exprSymType) int {
+	tok := l.lex(lval)
+	l.prev = tok
+	return tok
+}
+
+func (l *lexer) lex(lval *exprSymType) int {
 	r := l.Scan()
 
 	switch r {
@@ -146,7 +181,7 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		for next := l.Peek(); !(next == '\n' || next == scanner.EOF); next = l.Next() {
 		}
 
-		return l.Lex(lval)
+		return l.lex(lval)
 
 	case scanner.EOF:
 		return 0
@@ -202,6 +237,15 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		l.builder.Reset()
 		for r := l.Next(); r != scanner.EOF; r = l.Next() {
 			if r == ']' {
+				// subqueries are noted [<range>:<resolution>]
+				if strings.ContainsRune(l.builder.String(), ':') {
+					if _, _, err := parseSubqueryRange(l.builder.String()); err != nil {
+						l.Error(err.Error())
+						return 0
+					}
+					lval.str = l.builder.String()
+					return SUBQUERY_RANGE
+				}
 				i, err := model.ParseDuration(l.builder.String())
 				if err != nil {
 					l.Error(err.Error())
@@ -238,12 +282,30 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return tok
 	}
 
+	if tok, ok := contextualTokens[tokenTextLower]; ok && l.isContextualToken(tok) {
+		return tok
+	}
+
+	if l.Peek() != scanner.EOF {
+		// create a copy to look up three characters tokens
+		sc := l.Scanner
+		sc.Next()
+		if tok, ok := tokens[tokenNext+string(sc.Peek())]; ok {
+			l.Next()
+			l.Next()
+			return tok
+		}
+	}
+
 	if tok, ok := tokens[tokenNext]; ok {
 		l.Next()
 		return tok
 	}
 
 	if tok, ok := tokens[tokenTextLower]; ok {
+		if tok == OPEN_PARENTHESIS && isRangeOpToken(l.prev) && isSubquery(l.Scanner) {
+			return SUBQUERY_OPEN_PARENTHESIS
+		}
 		return tok
 	}
 
@@ -251,6 +313,65 @@ func (l *lexer) Lex(lval *exprSymType) int {
 	return IDENTIFIER
 }
 
+func isRangeOpToken(tok int) bool {
+	switch tok {
+	case COUNT_OVER_TIME, RATE, RATE_COUNTER, BYTES_OVER_TIME, BYTES_RATE, AVG_OVER_TIME, SUM_OVER_TIME, MIN_OVER_TIME,
+		MAX_OVER_TIME, STDVAR_OVER_TIME, STDDEV_OVER_TIME, QUANTILE_OVER_TIME, FIRST_OVER_TIME, LAST_OVER_TIME, ABSENT_OVER_TIME:
+		return true
+	}
+	return false
+}
+
+// isSubquery returns true if the parenthesis opening the arguments of a range
+// aggregation holds a subquery, i.e. a `[<range>:<resolution>]` range outside
+// of any nested parentheses. Telling it apart from the parenthesis of log
+// ranges keeps the syntax errors of range aggregations precise.
+func isSubquery(sc Scanner) bool {
+	// lookahead errors are reported when the tokens are lexed.
+	sc.Error = func(_ *Scanner, _ string) {}
+	depth := 0
+	for r := sc.Scan(); r != scanner.EOF; r = sc.Scan() {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return false
+			}
+			depth--
+		case '#':
+			//nolint:revive
+			for next := sc.Peek(); !(next == '\n' || next == scanner.EOF); next = sc.Next() {
+			}
+		case '[':
+			var sb strings.Builder
+			for r := sc.Next(); r != ']' && r != scanner.EOF; r = sc.Next() {
+				_, _ = sb.WriteRune(r)
+			}
+			if depth == 0 && strings.ContainsRune(sb.String(), ':') {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isContextualToken returns true if the contextual token is used as a keyword:
+// dedup, xml and explode must follow a pipe and not be compared like a label, while limit and within must be followed by a number.
+func (l *lexer) isContextualToken(tok int) bool {
+	sc := trimSpace(l.Scanner)
+	next := sc.Peek()
+	switch tok {
+	case DEDUP, XML, EXPLODE:
+		return l.prev == PIPE && !strings.ContainsRune("=!<>~", next)
+	case LIMIT:
+		return l.prev == PIPE && unicode.IsDigit(next)
+	case WITHIN:
+		return unicode.IsDigit(next)
+	}
+	return false
+}
+
 func (l *lexer) Error(msg string) {
 	l.errs = append(l.errs, logqlmodel.NewParseError(msg, l.Line, l.Column))
 }
@@ -9,16 +9,20 @@ import (
 // integer is varint encoded
 // strings are variable-length encoded
 //
-// +---------+--------------+-------------+
-// | Ty      | Match        | Op          |
-// +---------+--------------+-------------+
-// | value   | len  | value | len | value |
-// +---------+--------------+-------------+
+// +---------+--------------+-------------+----------+
+// | Ty      | Match        | Op          | Distance |
+// +---------+--------------+-------------+----------+
+// | value   | len  | value | len | value | value    |
+// +---------+--------------+-------------+----------+
+//
+// Distance is omitted when it is zero, which is also how filters encoded
+// before its introduction are decoded.
 
 func (lf LineFilter) Equal(o LineFilter) bool {
 	return lf.Ty == o.Ty &&
 		lf.Match == o.Match &&
-		lf.Op == o.Op
+		lf.Op == o.Op &&
+		lf.Distance == o.Distance
 }
 
 func (lf LineFilter) Size() int {
@@ -26,7 +30,15 @@ func (lf LineFilter) Size() int {
 		lenUint64(uint64(len(lf.Match))) +
 		len(lf.Match) +
 		lenUint64(uint64(len(lf.Op))) +
-		len(lf.Op)
+		len(lf.Op) +
+		lf.distanceSize()
+}
+
+func (lf LineFilter) distanceSize() int {
+	if lf.Distance == 0 {
+		return 0
+	}
+	return lenUint64(uint64(lf.Distance))
 }
 
 func (lf LineFilter) MarshalTo(b []byte) (int, error) {
@@ -34,6 +46,9 @@ func (lf LineFilter) MarshalTo(b []byte) (int, error) {
 	buf.PutUvarint(int(lf.Ty))
 	buf.PutUvarintStr(lf.Match)
 	buf.PutUvarintStr(lf.Op)
+	if lf.Distance != 0 {
+		buf.PutUvarint(lf.Distance)
+	}
 	return len(b), nil
 }
 
@@ -42,6 +57,9 @@ func (lf *LineFilter) Unmarshal(b []byte) error {
 	lf.Ty = log.LineMatchType(buf.Uvarint())
 	lf.Match = buf.UvarintStr()
 	lf.Op = buf.UvarintStr()
+	if buf.Len() > 0 {
+		lf.Distance = buf.Uvarint()
+	}
 	return nil
 }
 
@@ -20,6 +20,9 @@ func TestLineFilterSerialization(t *testing.T) {
 		{Ty: log.LineMatchPattern, Match: "match", Op: "OR"},
 		{Ty: log.LineMatchNotPattern, Match: "not match"},
 		{Ty: log.LineMatchNotPattern, Match: "not match", Op: "OR"},
+		{Ty: log.LineMatchEqualCaseInsensitive, Match: "Match"},
+		{Ty: log.LineMatchFuzzy, Match: "match", Distance: 2},
+		{Ty: log.LineMatchNotFuzzy, Match: "not match", Distance: 300},
 	} {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
 			b := make([]byte, orig.Size())
@@ -0,0 +1,316 @@
+package syntax
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+// Lint rules reported by Lint.
+const (
+	LintRuleRegexpLiteral           = "regexp-literal"
+	LintRuleFilterAfterParser       = "filter-after-parser"
+	LintRuleHighCardinalityGrouping = "high-cardinality-grouping"
+	LintRuleUnnarrowedSelector      = "unnarrowed-selector"
+
+	LintSeverityWarning = "warning"
+	LintSeverityInfo    = "info"
+)
+
+// highCardinalityLabelTokens are label name tokens (split on `_`, `.` and `-`)
+// that usually carry a unique value per request, user or host.
+var highCardinalityLabelTokens = map[string]struct{}{
+	"id":        {},
+	"uuid":      {},
+	"guid":      {},
+	"trace":     {},
+	"traceid":   {},
+	"span":      {},
+	"spanid":    {},
+	"request":   {},
+	"requestid": {},
+	"session":   {},
+	"ip":        {},
+	"addr":      {},
+	"email":     {},
+	"url":       {},
+	"uri":       {},
+	"timestamp": {},
+	"ts":        {},
+}
+
+// LintPosition is the location of a finding within the query string.
+// Positions are best effort: they point at the first not yet reported
+// occurrence of the offending fragment and are zero when it can't be found.
+type LintPosition struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// LintFinding is a single issue reported by Lint.
+type LintFinding struct {
+	Rule       string       `json:"rule"`
+	Severity   string       `json:"severity"`
+	Message    string       `json:"message"`
+	Suggestion string       `json:"suggestion,omitempty"`
+	Position   LintPosition `json:"position"`
+}
+
+// Lint parses the query and reports semantic anti-patterns found in its AST.
+// The query is only linted if it is valid, parse errors are returned as is.
+func Lint(query string) ([]LintFinding, error) {
+	expr, err := ParseExpr(query)
+	if err != nil {
+		return nil, err
+	}
+	return LintExpr(query, expr), nil
+}
+
+// LintExpr reports semantic anti-patterns found in expr. query is the text
+// expr was parsed from and is only used to compute positions.
+func LintExpr(query string, expr Expr) []LintFinding {
+	l := &linter{
+		query: query,
+		seen:  map[string]int{},
+	}
+	expr.Walk(func(e Expr) {
+		switch e := e.(type) {
+		case *MatchersExpr:
+			l.lintMatchers(e.Mts)
+		case *PipelineExpr:
+			l.lintPipeline(e.MultiStages)
+		case *RangeAggregationExpr:
+			l.lintGrouping(e.Operation, e.Grouping)
+		case *VectorAggregationExpr:
+			l.lintGrouping(e.Operation, e.Grouping)
+		}
+	})
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Position.Offset < l.findings[j].Position.Offset
+	})
+	return l.findings
+}
+
+type linter struct {
+	query    string
+	findings []LintFinding
+	// seen tracks where to resume searching for a fragment so that repeated
+	// fragments are attributed to successive occurrences.
+	seen map[string]int
+}
+
+func (l *linter) report(rule, severity, message, suggestion string, fragments ...string) {
+	l.findings = append(l.findings, LintFinding{
+		Rule:       rule,
+		Severity:   severity,
+		Message:    message,
+		Suggestion: suggestion,
+		Position:   l.locate(fragments...),
+	})
+}
+
+// locate returns the position of the first fragment found in the query.
+func (l *linter) locate(fragments ...string) LintPosition {
+	for _, f := range fragments {
+		if f == "" {
+			continue
+		}
+		from := l.seen[f]
+		idx := strings.Index(l.query[from:], f)
+		if idx < 0 {
+			continue
+		}
+		offset := from + idx
+		l.seen[f] = offset + len(f)
+
+		line := strings.Count(l.query[:offset], "\n") + 1
+		column := offset - strings.LastIndex(l.query[:offset], "\n")
+		return LintPosition{Offset: offset, Line: line, Column: column}
+	}
+	return LintPosition{}
+}
+
+func (l *linter) lintMatchers(matchers []*labels.Matcher) {
+	narrowed := false
+	for _, m := range matchers {
+		switch m.Type {
+		case labels.MatchEqual:
+			if m.Value != "" {
+				narrowed = true
+			}
+		case labels.MatchRegexp:
+			if lit, ok := regexpLiteral(m.Value); ok {
+				l.report(
+					LintRuleRegexpLiteral,
+					LintSeverityInfo,
+					fmt.Sprintf("regular expression matcher on label %q matches a literal value", m.Name),
+					fmt.Sprintf("%s=%s", m.Name, strconv.Quote(lit)),
+					m.Name+"=~",
+				)
+			}
+			if regexpNarrows(m.Value) {
+				narrowed = true
+			}
+		case labels.MatchNotRegexp:
+			if lit, ok := regexpLiteral(m.Value); ok {
+				l.report(
+					LintRuleRegexpLiteral,
+					LintSeverityInfo,
+					fmt.Sprintf("regular expression matcher on label %q matches a literal value", m.Name),
+					fmt.Sprintf("%s!=%s", m.Name, strconv.Quote(lit)),
+					m.Name+"!~",
+				)
+			}
+		}
+	}
+
+	if !narrowed {
+		l.report(
+			LintRuleUnnarrowedSelector,
+			LintSeverityWarning,
+			"stream selector has no matcher narrowing the selected streams, the query may scan every stream of the tenant",
+			"add an equality matcher on a label such as namespace, app or job",
+			(&MatchersExpr{Mts: matchers}).String(),
+			"{",
+		)
+	}
+}
+
+func (l *linter) lintPipeline(stages MultiStageExpr) {
+	var parser StageExpr
+	for _, stage := range stages {
+		switch s := stage.(type) {
+		case *LineFilterExpr:
+			l.lintLineFilter(s, parser)
+		case *LabelParserExpr, *LogfmtParserExpr, *JSONExpressionParser, *LogfmtExpressionParser,
+			*CSVParserExpr, *KVParserExpr, *XMLExpressionParser:
+			if parser == nil {
+				parser = s
+			}
+		case *LineFmtExpr, *DecolorizeExpr, *ExplodeExpr, *DedupExpr, *LimitByExpr:
+			// These stages change the line or which lines are kept based on
+			// the previous ones, so filters can't be moved before them.
+			parser = nil
+		}
+	}
+}
+
+func (l *linter) lintLineFilter(e *LineFilterExpr, parser StageExpr) {
+	if e == nil {
+		return
+	}
+	l.lintLineFilter(e.Left, parser)
+
+	for f := e; f != nil; f = f.Or {
+		l.lintRegexpLineFilter(f.LineFilter)
+	}
+
+	if parser != nil {
+		filter := (&LineFilterExpr{LineFilter: e.LineFilter, Or: e.Or}).String()
+		l.report(
+			LintRuleFilterAfterParser,
+			LintSeverityWarning,
+			fmt.Sprintf("line filter %s is applied after the parser %s", filter, strings.TrimSpace(parser.String())),
+			"move the line filter before the parser so lines are dropped before being parsed",
+			filter, strconv.Quote(e.Match), e.Match,
+		)
+	}
+}
+
+func (l *linter) lintRegexpLineFilter(f LineFilter) {
+	if f.Op != "" || (f.Ty != log.LineMatchRegexp && f.Ty != log.LineMatchNotRegexp) {
+		return
+	}
+	lit, ok := regexpLiteral(f.Match)
+	if !ok || lit == "" {
+		return
+	}
+	op := log.LineMatchEqual
+	if f.Ty == log.LineMatchNotRegexp {
+		op = log.LineMatchNotEqual
+	}
+	l.report(
+		LintRuleRegexpLiteral,
+		LintSeverityWarning,
+		fmt.Sprintf("regular expression line filter %s matches a literal string", strconv.Quote(f.Match)),
+		fmt.Sprintf("%s %s", op, strconv.Quote(lit)),
+		strconv.Quote(f.Match), "`"+f.Match+"`", f.Match,
+	)
+}
+
+func (l *linter) lintGrouping(operation string, g *Grouping) {
+	if g == nil || g.Without {
+		return
+	}
+	for _, name := range g.Groups {
+		if !isHighCardinalityLabel(name) {
+			continue
+		}
+		l.report(
+			LintRuleHighCardinalityGrouping,
+			LintSeverityWarning,
+			fmt.Sprintf("%s groups by %q which is likely a high cardinality label", operation, name),
+			"group by a bounded label or drop the label before aggregating",
+			name,
+		)
+	}
+}
+
+func isHighCardinalityLabel(name string) bool {
+	name = strings.ToLower(name)
+	if _, ok := highCardinalityLabelTokens[name]; ok {
+		return true
+	}
+	for _, token := range strings.FieldsFunc(name, func(r rune) bool {
+		return r == '_' || r == '.' || r == '-'
+	}) {
+		if _, ok := highCardinalityLabelTokens[token]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// regexpLiteral returns the literal string a regular expression is
+// equivalent to, if any.
+func regexpLiteral(expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	re = re.Simplify()
+	if re.Op != syntax.OpLiteral || re.Flags&syntax.FoldCase != 0 {
+		return "", false
+	}
+	return string(re.Rune), true
+}
+
+// regexpNarrows returns whether a regular expression matcher selects only a
+// subset of the values of a label, as opposed to `.*`, `.+` or anything else
+// matching every value.
+func regexpNarrows(expr string) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	re = re.Simplify()
+	if re.Op == syntax.OpPlus && len(re.Sub) == 1 &&
+		(re.Sub[0].Op == syntax.OpAnyChar || re.Sub[0].Op == syntax.OpAnyCharNotNL) {
+		return false
+	}
+	// An expression that matches the empty string also selects streams
+	// without the label.
+	anchored, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false
+	}
+	return !anchored.MatchString("")
+}
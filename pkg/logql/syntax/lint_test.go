@@ -0,0 +1,108 @@
+package syntax
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		query    string
+		expected []LintFinding
+	}{
+		{
+			name:  "clean query",
+			query: `sum by (app) (rate({namespace="prod"} |= "error" | logfmt | level="error" [5m]))`,
+		},
+		{
+			name:  "regexp line filter matching a literal",
+			query: `{app="foo"} |~ "timeout" !~ "dial.*"`,
+			expected: []LintFinding{
+				{
+					Rule:       LintRuleRegexpLiteral,
+					Severity:   LintSeverityWarning,
+					Message:    `regular expression line filter "timeout" matches a literal string`,
+					Suggestion: `|= "timeout"`,
+					Position:   LintPosition{Offset: 15, Line: 1, Column: 16},
+				},
+			},
+		},
+		{
+			name:  "regexp matcher matching a literal",
+			query: `{app=~"foo"}`,
+			expected: []LintFinding{
+				{
+					Rule:       LintRuleRegexpLiteral,
+					Severity:   LintSeverityInfo,
+					Message:    `regular expression matcher on label "app" matches a literal value`,
+					Suggestion: `app="foo"`,
+					Position:   LintPosition{Offset: 1, Line: 1, Column: 2},
+				},
+			},
+		},
+		{
+			name:  "line filter after parser",
+			query: "{app=\"foo\"}\n  | json\n  |= \"error\" or \"fatal\"",
+			expected: []LintFinding{
+				{
+					Rule:       LintRuleFilterAfterParser,
+					Severity:   LintSeverityWarning,
+					Message:    `line filter |= "error" or "fatal" is applied after the parser | json`,
+					Suggestion: "move the line filter before the parser so lines are dropped before being parsed",
+					Position:   LintPosition{Offset: 23, Line: 3, Column: 3},
+				},
+			},
+		},
+		{
+			name:  "line filter after line_format",
+			query: `{app="foo"} | json | line_format "{{.msg}}" |= "error"`,
+		},
+		{
+			name:  "high cardinality grouping",
+			query: `sum by (app, trace_id) (count_over_time({app="foo"}[5m]))`,
+			expected: []LintFinding{
+				{
+					Rule:       LintRuleHighCardinalityGrouping,
+					Severity:   LintSeverityWarning,
+					Message:    `sum groups by "trace_id" which is likely a high cardinality label`,
+					Suggestion: "group by a bounded label or drop the label before aggregating",
+					Position:   LintPosition{Offset: 13, Line: 1, Column: 14},
+				},
+			},
+		},
+		{
+			name:  "grouping without is ignored",
+			query: `sum without (trace_id) (count_over_time({app="foo"}[5m]))`,
+		},
+		{
+			name:  "unnarrowed selector",
+			query: `{app=~".+", env!="dev"}`,
+			expected: []LintFinding{
+				{
+					Rule:       LintRuleUnnarrowedSelector,
+					Severity:   LintSeverityWarning,
+					Message:    "stream selector has no matcher narrowing the selected streams, the query may scan every stream of the tenant",
+					Suggestion: "add an equality matcher on a label such as namespace, app or job",
+					Position:   LintPosition{Offset: 0, Line: 1, Column: 1},
+				},
+			},
+		},
+		{
+			name:  "regexp alternation narrows",
+			query: `{app=~"foo|bar"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := Lint(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, findings)
+		})
+	}
+}
+
+func TestLint_InvalidQuery(t *testing.T) {
+	_, err := Lint(`{app="foo"`)
+	require.Error(t, err)
+}
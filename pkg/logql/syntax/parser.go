@@ -18,6 +18,10 @@ const (
 	EmptyMatchers = "{}"
 
 	errAtleastOneEqualityMatcherRequired = "queries require at least one regexp or equality matcher that does not have an empty-compatible value. For instance, app=~\".*\" does not meet this requirement, but app=~\".+\" will"
+	errExplodeInLogQuery                 = "explode is only supported in metric queries"
+	errMultipleExplodeStages             = "only one explode stage is supported per query"
+	errMergeStagesInMetricQuery          = "dedup and limit stages are only supported in log queries"
+	errMergeStagesNotLast                = "dedup and limit stages must be at the end of the pipeline"
 )
 
 var parserPool = sync.Pool{
@@ -47,6 +51,9 @@ func init() {
 	for str, tok := range tokens {
 		exprToknames[tok-exprPrivate+1] = str
 	}
+	for str, tok := range contextualTokens {
+		exprToknames[tok-exprPrivate+1] = str
+	}
 }
 
 type parser struct {
@@ -58,6 +65,7 @@ type parser struct {
 
 func (p *parser) Parse() (Expr, error) {
 	p.lexer.errs = p.lexer.errs[:0]
+	p.lexer.prev = 0
 	p.lexer.Scanner.Error = func(_ *Scanner, msg string) {
 		p.lexer.Error(msg)
 	}
@@ -77,6 +85,10 @@ func ParseExpr(input string) (Expr, error) {
 	if err := validateExpr(expr); err != nil {
 		return nil, err
 	}
+	// a log query cannot return multiple entries per log line.
+	if e, ok := expr.(LogSelectorExpr); ok && explodeStages(e) > 0 {
+		return nil, logqlmodel.NewParseError(errExplodeInLogQuery, 0, 0)
+	}
 	return expr, nil
 }
 
@@ -205,11 +217,19 @@ func validateSampleExpr(expr SampleExpr) error {
 			}
 		}
 		return validateSampleExpr(e.Left)
+	case *SubqueryExpr:
+		if e.err != nil {
+			return e.err
+		}
+		return validateSampleExpr(e.Left)
 	default:
 		selector, err := e.Selector()
 		if err != nil {
 			return err
 		}
+		if HasMergeStages(selector) {
+			return logqlmodel.NewParseError(errMergeStagesInMetricQuery, 0, 0)
+		}
 		return validateLogSelectorExpression(selector)
 	}
 }
@@ -219,10 +239,48 @@ func validateLogSelectorExpression(expr LogSelectorExpr) error {
 	case *VectorExpr:
 		return nil
 	default:
+		if explodeStages(e) > 1 {
+			return logqlmodel.NewParseError(errMultipleExplodeStages, 0, 0)
+		}
+		if err := validateMergeStages(e); err != nil {
+			return err
+		}
 		return validateMatchers(e.Matchers())
 	}
 }
 
+// explodeStages returns the number of explode stages of the log selector.
+func explodeStages(expr LogSelectorExpr) int {
+	var count int
+	expr.Walk(func(e Expr) {
+		if _, ok := e.(*ExplodeExpr); ok {
+			count++
+		}
+	})
+	return count
+}
+
+// validateMergeStages ensures the dedup and limit stages are the last stages of the pipeline,
+// since they are evaluated over the output of the pipeline.
+func validateMergeStages(expr LogSelectorExpr) error {
+	p, ok := expr.(*PipelineExpr)
+	if !ok {
+		return nil
+	}
+	var merge bool
+	for _, s := range p.MultiStages {
+		switch s.(type) {
+		case *DedupExpr, *LimitByExpr:
+			merge = true
+		default:
+			if merge {
+				return logqlmodel.NewParseError(errMergeStagesNotLast, 0, 0)
+			}
+		}
+	}
+	return nil
+}
+
 // validateSortGrouping prevent by|without groupings on sort operations.
 // This will keep compatibility with promql and allowing sort by (foo) doesn't make much sense anyway when sort orders by value instead of labels.
 func validateSortGrouping(grouping *Grouping) error {
@@ -1420,6 +1420,139 @@ var ParseTestCases = []struct {
 			},
 		},
 	},
+	{
+		in: `{app="foo"} | csv(method, _, status) delimiter="\t" quote="'" | status >= 500`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+			MultiStages: MultiStageExpr{
+				&CSVParserExpr{Columns: []string{"method", "_", "status"}, Delimiter: "\t", Quote: "'"},
+				&LabelFilterExpr{
+					LabelFilterer: log.NewNumericLabelFilter(log.LabelFilterGreaterThanOrEqual, "status", 500),
+				},
+			},
+		},
+	},
+	{
+		in:  `{app="foo"} | csv(method, status) separator=";"`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid csv parser option: separator", 0, 0),
+	},
+	{
+		in:  `{app="foo"} | csv(method, method)`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid csv parser: duplicate extracted label name 'method'", 0, 0),
+	},
+	{
+		in: `{app="foo"} | kv(";", ":") | level="error"`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+			MultiStages: MultiStageExpr{
+				&KVParserExpr{PairSeparator: ";", KVSeparator: ":"},
+				&LabelFilterExpr{
+					LabelFilterer: log.NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "level", "error")),
+				},
+			},
+		},
+	},
+	{
+		in:  `{app="foo"} | kv(";", ";")`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid kv parser: pair and key value separators must be different", 0, 0),
+	},
+	{
+		in: `{app="foo"} | xml | xml user="/Envelope/Body/User/Name", id="/Envelope/Body/User/@id"`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+			MultiStages: MultiStageExpr{
+				newLabelParserExpr(OpParserTypeXML, ""),
+				&XMLExpressionParser{Expressions: []log.LabelExtractionExpr{
+					log.NewLabelExtractionExpr("user", "/Envelope/Body/User/Name"),
+					log.NewLabelExtractionExpr("id", "/Envelope/Body/User/@id"),
+				}},
+			},
+		},
+	},
+	{
+		in:  `{app="foo"} | xml user="Envelope"`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid xml parser: cannot parse expression [Envelope]: path must start with '/'", 0, 0),
+	},
+	{
+		in: `sum by (sku) (sum_over_time({app="foo"} | explode "order.items" | unwrap price [5m]))`,
+		exp: mustNewVectorAggregationExpr(
+			newRangeAggregationExpr(
+				newLogRange(&PipelineExpr{
+					Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+					MultiStages: MultiStageExpr{
+						&ExplodeExpr{Field: "order.items"},
+					},
+				},
+					5*time.Minute,
+					newUnwrapExpr("price", ""),
+					nil),
+				OpRangeTypeSum, nil, nil,
+			),
+			OpTypeSum,
+			&Grouping{Groups: []string{"sku"}},
+			nil,
+		),
+	},
+	{
+		in: `{app="foo"} | json | dedup by (pod) within 1m | limit 5`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+			MultiStages: MultiStageExpr{
+				newLabelParserExpr(OpParserTypeJSON, ""),
+				&DedupExpr{Grouping: &Grouping{Groups: []string{"pod"}}, Within: time.Minute},
+				&LimitByExpr{Limit: 5},
+			},
+		},
+	},
+	{
+		in: `{app="foo"} | dedup within 5m | limit 1 without (pod)`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{{Type: labels.MatchEqual, Name: "app", Value: "foo"}}),
+			MultiStages: MultiStageExpr{
+				&DedupExpr{Within: 5 * time.Minute},
+				&LimitByExpr{Limit: 1, Grouping: &Grouping{Groups: []string{"pod"}, Without: true}},
+			},
+		},
+	},
+	{
+		in:  `{app="foo"} | limit 0 by (pod)`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid limit stage: limit must be a positive integer, got 0", 0, 0),
+	},
+	{
+		in:  `{app="foo"} | dedup by (pod)`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid dedup stage: a within window is required", 0, 0),
+	},
+	{
+		in:  `{app="foo"} | dedup within 1m | json`,
+		exp: nil,
+		err: logqlmodel.NewParseError("dedup and limit stages must be at the end of the pipeline", 0, 0),
+	},
+	{
+		in:  `count_over_time({app="foo"} | limit 5 by (pod) [5m])`,
+		exp: nil,
+		err: logqlmodel.NewParseError("dedup and limit stages are only supported in log queries", 0, 0),
+	},
+	{
+		in:  `{app="foo"} | explode items`,
+		exp: nil,
+		err: logqlmodel.NewParseError("explode is only supported in metric queries", 0, 0),
+	},
+	{
+		in:  `count_over_time({app="foo"} | explode items | explode tags [5m])`,
+		exp: nil,
+		err: logqlmodel.NewParseError("only one explode stage is supported per query", 0, 0),
+	},
+	{
+		in:  `count_over_time({app="foo"} | explode "items." [5m])`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid explode stage: cannot parse field [items.]: syntax error: unexpected $end, expecting FIELD", 0, 0),
+	},
 	{
 		in: `{app="foo"} |= "bar" | pattern "<foo> bar <buzz>" | (duration > 1s or status!= 200) and method!="POST"`,
 		exp: &PipelineExpr{
@@ -3232,6 +3365,76 @@ var ParseTestCases = []struct {
 			},
 		},
 	},
+	{
+		in: `{app="foo"} |=* "Timeout" or "refused" !=* "debug"`,
+		exp: &PipelineExpr{
+			Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+			MultiStages: MultiStageExpr{
+				&LineFilterExpr{
+					Left: &LineFilterExpr{
+						LineFilter: LineFilter{
+							Ty:    log.LineMatchEqualCaseInsensitive,
+							Match: "Timeout",
+						},
+						Or: &LineFilterExpr{
+							LineFilter: LineFilter{
+								Ty:    log.LineMatchEqualCaseInsensitive,
+								Match: "refused",
+							},
+							IsOrChild: true,
+						},
+					},
+					LineFilter: LineFilter{
+						Ty:    log.LineMatchNotEqualCaseInsensitive,
+						Match: "debug",
+					},
+				},
+			},
+		},
+	},
+	{
+		in: `count_over_time({app="foo"} |~~ "tiemout" 1 !~~ "dbug" 1 [5m])`,
+		exp: &RangeAggregationExpr{
+			Operation: OpRangeTypeCount,
+			Left: &LogRange{
+				Left: &PipelineExpr{
+					Left: newMatcherExpr([]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}),
+					MultiStages: MultiStageExpr{
+						&LineFilterExpr{
+							Left: &LineFilterExpr{
+								LineFilter: LineFilter{
+									Ty:       log.LineMatchFuzzy,
+									Match:    "tiemout",
+									Distance: 1,
+								},
+							},
+							LineFilter: LineFilter{
+								Ty:       log.LineMatchNotFuzzy,
+								Match:    "dbug",
+								Distance: 1,
+							},
+						},
+					},
+				},
+				Interval: 5 * time.Minute,
+			},
+		},
+	},
+	{
+		in:  `{app="foo"} |~~ "timeout" 1.5`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid fuzzy line filter: distance must be a positive integer, got 1.5", 0, 0),
+	},
+	{
+		in:  `{app="foo"} |~~ "foo" 3`,
+		exp: nil,
+		err: logqlmodel.NewParseError(`invalid fuzzy line filter: distance 3 must be lower than the length of "foo"`, 0, 0),
+	},
+	{
+		in:  `{app="foo"} |~~ "timeout" 1 or "refused"`,
+		exp: nil,
+		err: logqlmodel.NewParseError("invalid fuzzy line filter: fuzzy line filters can't be combined with or", 0, 0),
+	},
 }
 
 func TestParse(t *testing.T) {
@@ -3583,3 +3786,24 @@ func TestParseSampleExpr_String(t *testing.T) {
 		require.Equal(t, "{cluster=\"beep\", namespace=\"boop\"} | msg=~`\\w.*`", expr.String())
 	})
 }
+
+func TestParseContextualTokensAsLabels(t *testing.T) {
+	for _, tc := range []struct {
+		in, exp string
+	}{
+		{in: `{xml="a"} | xml`, exp: `{xml="a"} | xml`},
+		{in: `{app="foo"} | xml="1"`, exp: `{app="foo"} | xml="1"`},
+		{in: `{app="foo"} | json | xml != ""`, exp: `{app="foo"} | json | xml!=""`},
+		{in: `{app="foo"} | json xml="a.b" | keep xml`, exp: `{app="foo"} | json xml="a.b" | keep xml`},
+		{in: `sum by (xml) (count_over_time({app="foo"} | xml | drop xml [5m]))`, exp: `sum by (xml)(count_over_time({app="foo"} | xml | drop xml[5m]))`},
+		{in: `{explode="a"} | logfmt | explode != ""`, exp: `{explode="a"} | logfmt | explode!=""`},
+		{in: `{app="foo"} | logfmt | drop explode`, exp: `{app="foo"} | logfmt | drop explode`},
+		{in: `sum by (explode) (count_over_time({app="foo"} | json | explode items [5m]))`, exp: `sum by (explode)(count_over_time({app="foo"} | json | explode items[5m]))`},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			expr, err := ParseExpr(tc.in)
+			require.NoError(t, err)
+			require.Equal(t, tc.exp, expr.String())
+		})
+	}
+}
@@ -95,6 +95,7 @@ func (e *LineFilterExpr) Pretty(level int) string {
 	// We re-use LineFilterExpr's String() implementation to avoid duplication.
 	// We create new LineFilterExpr without `Left`.
 	ne := newLineFilterExpr(e.Ty, e.Op, e.Match)
+	ne.Distance = e.Distance
 	s += ne.String()
 
 	return s
@@ -109,10 +110,36 @@ func (e *LogfmtParserExpr) Pretty(level int) string {
 // `| regexp`
 // `| pattern`
 // `| unpack`
+// `| xml`
 func (e *LabelParserExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | csv(method, path, status) delimiter="\t"
+func (e *CSVParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | kv(";", ":")
+func (e *KVParserExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | dedup by (pod) within 1m
+func (e *DedupExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | limit 5 by (pod)
+func (e *LimitByExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
+// e.g: | explode items
+func (e *ExplodeExpr) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 func (e *DropLabelsExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
@@ -151,6 +178,11 @@ func (e *LogfmtExpressionParser) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
 }
 
+// e.g: | xml label="/path/to/element", another="/path/to/@attribute"
+func (e *XMLExpressionParser) Pretty(level int) string {
+	return commonPrefixIndent(level, e)
+}
+
 // e.g: sum_over_time({foo="bar"} | logfmt | unwrap bytes_processed [5m])
 func (e *UnwrapExpr) Pretty(level int) string {
 	s := Indent(level)
@@ -226,6 +258,35 @@ func (e *RangeAggregationExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: max_over_time(sum(rate({foo="bar"}[1m]))[1h:1m])
+func (e *SubqueryExpr) Pretty(level int) string {
+	s := Indent(level)
+	if !NeedSplit(e) {
+		return s + e.String()
+	}
+
+	s += e.Operation // e.g: max_over_time
+
+	s += "(\n"
+
+	// print args to the function.
+	if e.Params != nil {
+		s = fmt.Sprintf("%s%s%s,", s, Indent(level+1), fmt.Sprint(*e.Params))
+		s += "\n"
+	}
+
+	s += e.Left.Pretty(level + 1)
+	s += fmt.Sprintf("[%v:%v]", model.Duration(e.Range), model.Duration(e.Step))
+	if e.Offset != 0 {
+		oe := OffsetExpr{Offset: e.Offset}
+		s += oe.Pretty(level)
+	}
+
+	s += "\n" + Indent(level) + ")"
+
+	return s
+}
+
 // e.g:
 // sum(count_over_time({foo="bar"}[5m])) by (container)
 // topk(10, count_over_time({foo="bar"}[5m])) by (container)
@@ -248,8 +309,12 @@ func (e *VectorAggregationExpr) Pretty(level int) string {
 	left := e.Left.Pretty(level + 1)
 	switch e.Operation {
 	// e.Params default value (0) can mean a legit param for topk and bottomk
-	case OpTypeBottomK, OpTypeTopK:
+	case OpTypeBottomK, OpTypeTopK, OpTypeLimitK:
 		params = []string{fmt.Sprintf("%s%d", Indent(level+1), e.Params), left}
+	case OpTypeQuantile:
+		params = []string{Indent(level+1) + strconv.FormatFloat(e.Quantile, 'f', -1, 64), left}
+	case OpTypeCountValues:
+		params = []string{Indent(level+1) + strconv.Quote(e.ValueLabel), left}
 
 	default:
 		if e.Params != 0 {
@@ -338,6 +403,41 @@ func (e *LabelReplaceExpr) Pretty(level int) string {
 	return s
 }
 
+// e.g: label_join(rate({job="api-server"}[5m]), "foo", ",", "job", "service")
+func (e *LabelJoinExpr) Pretty(level int) string {
+	s := Indent(level)
+
+	if !NeedSplit(e) {
+		return s + e.String()
+	}
+
+	s += OpLabelJoin
+
+	s += "(\n"
+
+	params := []string{
+		e.Left.Pretty(level + 1),
+		Indent(level+1) + strconv.Quote(e.Dst),
+		Indent(level+1) + strconv.Quote(e.Separator),
+	}
+	for _, l := range e.Src {
+		params = append(params, Indent(level+1)+strconv.Quote(l))
+	}
+
+	for i, v := range params {
+		s += v
+		// LogQL doesn't allow `,` at the end of last argument.
+		if i < len(params)-1 {
+			s += ","
+		}
+		s += "\n"
+	}
+
+	s += Indent(level) + ")"
+
+	return s
+}
+
 // e.g: vector(5)
 func (e *VectorExpr) Pretty(level int) string {
 	return commonPrefixIndent(level, e)
@@ -132,6 +132,26 @@ func TestFormat_VectorAggregation(t *testing.T) {
   count_over_time(
     {foo="bar", namespace="loki", instance="localhost"} [5m]
   )
+)`,
+		},
+		{
+			name: "count_values",
+			in:   `count_values("value", count_over_time({foo="bar",namespace="loki",instance="localhost"}[5m])) by (container)`,
+			exp: `count_values by (container)(
+  "value",
+  count_over_time(
+    {foo="bar", namespace="loki", instance="localhost"} [5m]
+  )
+)`,
+		},
+		{
+			name: "quantile",
+			in:   `quantile(0.99, count_over_time({foo="bar",namespace="loki",instance="localhost"}[5m]))`,
+			exp: `quantile(
+  0.99,
+  count_over_time(
+    {foo="bar", namespace="loki", instance="localhost"} [5m]
+  )
 )`,
 		},
 	}
@@ -186,6 +206,32 @@ func TestFormat_LabelReplace(t *testing.T) {
   "$1",
   "service",
   "(.*):.*"
+)`,
+		},
+		{
+			name: "label_join",
+			in:   `label_join(rate({job="api-server",service="a:c"}|= "err" [5m]), "foo", ",", "job", "service")`,
+			exp: `label_join(
+  rate(
+    {job="api-server", service="a:c"}
+      |= "err" [5m]
+  ),
+  "foo",
+  ",",
+  "job",
+  "service"
+)`,
+		},
+		{
+			name: "subquery",
+			in:   `max_over_time(sum by (job) (rate({job="api-server",service="a:c"}|= "err" [5m]))[1h:1m] offset 5m)`,
+			exp: `max_over_time(
+  sum by (job)(
+    rate(
+      {job="api-server", service="a:c"}
+        |= "err" [5m]
+    )
+  )[1h:1m] offset 5m
 )`,
 		},
 	}
@@ -46,6 +46,7 @@ const (
 	IntervalNanos       = "interval_nanos"
 	IPField             = "ip"
 	Label               = "label"
+	LabelJoin           = "label_join"
 	LabelReplace        = "label_replace"
 	LHS                 = "lhs"
 	Literal             = "literal"
@@ -59,6 +60,7 @@ const (
 	OffsetNanos         = "offset_nanos"
 	Params              = "params"
 	Pattern             = "pattern"
+	Quantile            = "quantile"
 	PostFilterers       = "post_filterers"
 	Range               = "range"
 	RangeAgg            = "range_agg"
@@ -67,12 +69,17 @@ const (
 	Replacement         = "replacement"
 	ReturnBool          = "return_bool"
 	RHS                 = "rhs"
+	Separator           = "separator"
 	Src                 = "src"
+	SrcLabels           = "src_labels"
+	StepNanos           = "step_nanos"
 	StringField         = "string"
+	Subquery            = "subquery"
 	NoopField           = "noop"
 	Type                = "type"
 	Unwrap              = "unwrap"
 	Value               = "value"
+	ValueLabel          = "value_label"
 	Vector              = "vector"
 	VectorAgg           = "vector_agg"
 	VectorMatchingField = "vector_matching"
@@ -96,6 +103,10 @@ func DecodeJSON(raw string) (Expr, error) {
 		return decodeVector(iter)
 	case LabelReplace:
 		return decodeLabelReplace(iter)
+	case LabelJoin:
+		return decodeLabelJoin(iter)
+	case Subquery:
+		return decodeSubquery(iter)
 	case LogSelector:
 		return decodeLogSelector(iter)
 	default:
@@ -159,6 +170,18 @@ func (v *JSONSerializer) VisitVectorAggregation(e *VectorAggregationExpr) {
 	v.WriteObjectField(Op)
 	v.WriteString(e.Operation)
 
+	if e.Quantile != 0 {
+		v.WriteMore()
+		v.WriteObjectField(Quantile)
+		v.WriteFloat64(e.Quantile)
+	}
+
+	if e.ValueLabel != "" {
+		v.WriteMore()
+		v.WriteObjectField(ValueLabel)
+		v.WriteString(e.ValueLabel)
+	}
+
 	if e.Grouping != nil {
 		v.WriteMore()
 		v.WriteObjectField(GroupingField)
@@ -204,6 +227,40 @@ func (v *JSONSerializer) VisitRangeAggregation(e *RangeAggregationExpr) {
 	v.Flush()
 }
 
+func (v *JSONSerializer) VisitSubquery(e *SubqueryExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Subquery)
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Op)
+	v.WriteString(e.Operation)
+
+	if e.Params != nil {
+		v.WriteMore()
+		v.WriteObjectField(Params)
+		v.WriteFloat64(*e.Params)
+	}
+
+	v.WriteMore()
+	v.WriteObjectField(IntervalNanos)
+	v.WriteInt64(int64(e.Range))
+	v.WriteMore()
+	v.WriteObjectField(StepNanos)
+	v.WriteInt64(int64(e.Step))
+	v.WriteMore()
+	v.WriteObjectField(OffsetNanos)
+	v.WriteInt64(int64(e.Offset))
+
+	v.WriteMore()
+	v.WriteObjectField(Inner)
+	e.Left.Accept(v)
+
+	v.WriteObjectEnd()
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
 func (v *JSONSerializer) VisitLogRange(e *LogRange) {
 	v.WriteObjectStart()
 
@@ -228,6 +285,39 @@ func (v *JSONSerializer) VisitLogRange(e *LogRange) {
 	v.Flush()
 }
 
+func (v *JSONSerializer) VisitLabelJoin(e *LabelJoinExpr) {
+	v.WriteObjectStart()
+
+	v.WriteObjectField(LabelJoin)
+	v.WriteObjectStart()
+
+	v.WriteObjectField(Inner)
+	e.Left.Accept(v)
+
+	v.WriteMore()
+	v.WriteObjectField(Dst)
+	v.WriteString(e.Dst)
+
+	v.WriteMore()
+	v.WriteObjectField(Separator)
+	v.WriteString(e.Separator)
+
+	v.WriteMore()
+	v.WriteObjectField(SrcLabels)
+	v.WriteArrayStart()
+	for i, l := range e.Src {
+		if i > 0 {
+			v.WriteMore()
+		}
+		v.WriteString(l)
+	}
+	v.WriteArrayEnd()
+
+	v.WriteObjectEnd()
+	v.WriteObjectEnd()
+	v.Flush()
+}
+
 func (v *JSONSerializer) VisitLabelReplace(e *LabelReplaceExpr) {
 	v.WriteObjectStart()
 
@@ -306,17 +396,23 @@ func (v *JSONSerializer) VisitPipeline(e *PipelineExpr) {
 
 // Below are StageExpr visitors that we are skipping since a pipeline is
 // serialized as a string.
+func (*JSONSerializer) VisitCSVParser(*CSVParserExpr)                       {}
 func (*JSONSerializer) VisitDecolorize(*DecolorizeExpr)                     {}
+func (*JSONSerializer) VisitDedup(*DedupExpr)                               {}
 func (*JSONSerializer) VisitDropLabels(*DropLabelsExpr)                     {}
+func (*JSONSerializer) VisitExplode(*ExplodeExpr)                           {}
 func (*JSONSerializer) VisitJSONExpressionParser(*JSONExpressionParser)     {}
+func (*JSONSerializer) VisitKVParser(*KVParserExpr)                         {}
 func (*JSONSerializer) VisitKeepLabel(*KeepLabelsExpr)                      {}
 func (*JSONSerializer) VisitLabelFilter(*LabelFilterExpr)                   {}
 func (*JSONSerializer) VisitLabelFmt(*LabelFmtExpr)                         {}
 func (*JSONSerializer) VisitLabelParser(*LabelParserExpr)                   {}
+func (*JSONSerializer) VisitLimitBy(*LimitByExpr)                           {}
 func (*JSONSerializer) VisitLineFilter(*LineFilterExpr)                     {}
 func (*JSONSerializer) VisitLineFmt(*LineFmtExpr)                           {}
 func (*JSONSerializer) VisitLogfmtExpressionParser(*LogfmtExpressionParser) {}
 func (*JSONSerializer) VisitLogfmtParser(*LogfmtParserExpr)                 {}
+func (*JSONSerializer) VisitXMLExpressionParser(*XMLExpressionParser)       {}
 
 func encodeGrouping(s *jsoniter.Stream, g *Grouping) {
 	s.WriteObjectStart()
@@ -683,10 +779,14 @@ func decodeLogSelector(iter *jsoniter.Iterator) (LogSelectorExpr, error) {
 		switch f {
 		case Raw:
 			raw := iter.ReadString()
-			expr, err := ParseExpr(raw)
+			// the log selector of a metric query may hold stages which log queries reject, such as explode.
+			expr, err := ParseExprWithoutValidation(raw)
 			if err != nil {
 				return nil, err
 			}
+			if err := validateExpr(expr); err != nil {
+				return nil, err
+			}
 
 			var ok bool
 			e, ok = expr.(LogSelectorExpr)
@@ -717,6 +817,10 @@ func decodeSample(iter *jsoniter.Iterator) (SampleExpr, error) {
 			expr, err = decodeVector(iter)
 		case LabelReplace:
 			expr, err = decodeLabelReplace(iter)
+		case LabelJoin:
+			expr, err = decodeLabelJoin(iter)
+		case Subquery:
+			expr, err = decodeSubquery(iter)
 		default:
 			return nil, fmt.Errorf("unknown sample expression type: %s", key)
 		}
@@ -827,6 +931,10 @@ func decodeVectorAgg(iter *jsoniter.Iterator) (*VectorAggregationExpr, error) {
 			expr.Operation = iter.ReadString()
 		case Params:
 			expr.Params = iter.ReadInt()
+		case Quantile:
+			expr.Quantile = iter.ReadFloat64()
+		case ValueLabel:
+			expr.ValueLabel = iter.ReadString()
 		case GroupingField:
 			expr.Grouping, err = decodeGrouping(iter)
 		case Inner:
@@ -858,6 +966,31 @@ func decodeRangeAgg(iter *jsoniter.Iterator) (*RangeAggregationExpr, error) {
 	return expr, err
 }
 
+func decodeSubquery(iter *jsoniter.Iterator) (*SubqueryExpr, error) {
+	expr := &SubqueryExpr{}
+	var err error
+
+	for f := iter.ReadObject(); f != ""; f = iter.ReadObject() {
+		switch f {
+		case Op:
+			expr.Operation = iter.ReadString()
+		case Params:
+			tmp := iter.ReadFloat64()
+			expr.Params = &tmp
+		case IntervalNanos:
+			expr.Range = time.Duration(iter.ReadInt64())
+		case StepNanos:
+			expr.Step = time.Duration(iter.ReadInt64())
+		case OffsetNanos:
+			expr.Offset = time.Duration(iter.ReadInt64())
+		case Inner:
+			expr.Left, err = decodeSample(iter)
+		}
+	}
+
+	return expr, err
+}
+
 func decodeLogRange(iter *jsoniter.Iterator) (*LogRange, error) {
 	expr := &LogRange{}
 	var err error
@@ -878,6 +1011,33 @@ func decodeLogRange(iter *jsoniter.Iterator) (*LogRange, error) {
 	return expr, err
 }
 
+func decodeLabelJoin(iter *jsoniter.Iterator) (*LabelJoinExpr, error) {
+	var err error
+	var left SampleExpr
+	var dst, separator string
+	var src []string
+
+	for f := iter.ReadObject(); f != ""; f = iter.ReadObject() {
+		switch f {
+		case Inner:
+			left, err = decodeSample(iter)
+			if err != nil {
+				return nil, err
+			}
+		case Dst:
+			dst = iter.ReadString()
+		case Separator:
+			separator = iter.ReadString()
+		case SrcLabels:
+			for iter.ReadArray() {
+				src = append(src, iter.ReadString())
+			}
+		}
+	}
+
+	return mustNewLabelJoinExpr(left, dst, separator, src), nil
+}
+
 func decodeLabelReplace(iter *jsoniter.Iterator) (*LabelReplaceExpr, error) {
 	var err error
 	var left SampleExpr
@@ -30,6 +30,9 @@ func TestJSONSerializationRoundTrip(t *testing.T) {
 		"regexp": {
 			query: `{env="prod", app=~"loki.*"} |~ ".*foo.*"`,
 		},
+		"case insensitive and fuzzy line filters": {
+			query: `{env="prod", app=~"loki.*"} |=* "foo" |~~ "tiemout" 1`,
+		},
 		"line filter": {
 			query: `{env="prod", app=~"loki.*"} |= "foo" |= "bar" or "baz" | line_format "blip{{ .foo }}blop" |= "blip"`,
 		},
@@ -42,6 +45,18 @@ func TestJSONSerializationRoundTrip(t *testing.T) {
 		"label replace": {
 			query: `label_replace(vector(0.000000),"foo","bar","","")`,
 		},
+		"label join": {
+			query: `label_join(rate({app="foo"}[5m]),"foo",",","app","pod")`,
+		},
+		"count values": {
+			query: `count_values by (app) ("value", rate({app="foo"}[5m]))`,
+		},
+		"quantile": {
+			query: `quantile(0.9, rate({app="foo"}[5m]))`,
+		},
+		"subquery": {
+			query: `quantile_over_time(0.9, sum(rate({app="foo"}[1m]))[1h:1m] offset 5m)`,
+		},
 		"filters with bytes": {
 			query: `{app="foo"} |= "bar" | json | ( status_code <500 or ( status_code>200 , size>=2.5KiB ) )`,
 		},
@@ -17,8 +17,10 @@ type SampleExprVisitor interface {
 	VisitVectorAggregation(*VectorAggregationExpr)
 	VisitRangeAggregation(*RangeAggregationExpr)
 	VisitLabelReplace(*LabelReplaceExpr)
+	VisitLabelJoin(*LabelJoinExpr)
 	VisitLiteral(*LiteralExpr)
 	VisitVector(*VectorExpr)
+	VisitSubquery(*SubqueryExpr)
 }
 
 type LogSelectorExprVisitor interface {
@@ -29,31 +31,43 @@ type LogSelectorExprVisitor interface {
 }
 
 type StageExprVisitor interface {
+	VisitCSVParser(*CSVParserExpr)
 	VisitDecolorize(*DecolorizeExpr)
+	VisitDedup(*DedupExpr)
 	VisitDropLabels(*DropLabelsExpr)
+	VisitExplode(*ExplodeExpr)
 	VisitJSONExpressionParser(*JSONExpressionParser)
+	VisitKVParser(*KVParserExpr)
 	VisitKeepLabel(*KeepLabelsExpr)
 	VisitLabelFilter(*LabelFilterExpr)
 	VisitLabelFmt(*LabelFmtExpr)
 	VisitLabelParser(*LabelParserExpr)
+	VisitLimitBy(*LimitByExpr)
 	VisitLineFilter(*LineFilterExpr)
 	VisitLineFmt(*LineFmtExpr)
 	VisitLogfmtExpressionParser(*LogfmtExpressionParser)
 	VisitLogfmtParser(*LogfmtParserExpr)
+	VisitXMLExpressionParser(*XMLExpressionParser)
 }
 
 var _ RootVisitor = &DepthFirstTraversal{}
 
 type DepthFirstTraversal struct {
 	VisitBinOpFn                  func(v RootVisitor, e *BinOpExpr)
+	VisitCSVParserFn              func(v RootVisitor, e *CSVParserExpr)
 	VisitDecolorizeFn             func(v RootVisitor, e *DecolorizeExpr)
+	VisitDedupFn                  func(v RootVisitor, e *DedupExpr)
 	VisitDropLabelsFn             func(v RootVisitor, e *DropLabelsExpr)
+	VisitExplodeFn                func(v RootVisitor, e *ExplodeExpr)
 	VisitJSONExpressionParserFn   func(v RootVisitor, e *JSONExpressionParser)
+	VisitKVParserFn               func(v RootVisitor, e *KVParserExpr)
 	VisitKeepLabelFn              func(v RootVisitor, e *KeepLabelsExpr)
 	VisitLabelFilterFn            func(v RootVisitor, e *LabelFilterExpr)
 	VisitLabelFmtFn               func(v RootVisitor, e *LabelFmtExpr)
+	VisitLabelJoinFn              func(v RootVisitor, e *LabelJoinExpr)
 	VisitLabelParserFn            func(v RootVisitor, e *LabelParserExpr)
 	VisitLabelReplaceFn           func(v RootVisitor, e *LabelReplaceExpr)
+	VisitLimitByFn                func(v RootVisitor, e *LimitByExpr)
 	VisitLineFilterFn             func(v RootVisitor, e *LineFilterExpr)
 	VisitLineFmtFn                func(v RootVisitor, e *LineFmtExpr)
 	VisitLiteralFn                func(v RootVisitor, e *LiteralExpr)
@@ -63,8 +77,10 @@ type DepthFirstTraversal struct {
 	VisitMatchersFn               func(v RootVisitor, e *MatchersExpr)
 	VisitPipelineFn               func(v RootVisitor, e *PipelineExpr)
 	VisitRangeAggregationFn       func(v RootVisitor, e *RangeAggregationExpr)
+	VisitSubqueryFn               func(v RootVisitor, e *SubqueryExpr)
 	VisitVectorFn                 func(v RootVisitor, e *VectorExpr)
 	VisitVectorAggregationFn      func(v RootVisitor, e *VectorAggregationExpr)
+	VisitXMLExpressionParserFn    func(v RootVisitor, e *XMLExpressionParser)
 }
 
 // VisitBinOp implements RootVisitor.
@@ -80,6 +96,16 @@ func (v *DepthFirstTraversal) VisitBinOp(e *BinOpExpr) {
 	}
 }
 
+// VisitCSVParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitCSVParser(e *CSVParserExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitCSVParserFn != nil {
+		v.VisitCSVParserFn(v, e)
+	}
+}
+
 // VisitDecolorize implements RootVisitor.
 func (v *DepthFirstTraversal) VisitDecolorize(e *DecolorizeExpr) {
 	if e == nil {
@@ -100,6 +126,26 @@ func (v *DepthFirstTraversal) VisitDropLabels(e *DropLabelsExpr) {
 	}
 }
 
+// VisitDedup implements RootVisitor.
+func (v *DepthFirstTraversal) VisitDedup(e *DedupExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitDedupFn != nil {
+		v.VisitDedupFn(v, e)
+	}
+}
+
+// VisitExplode implements RootVisitor.
+func (v *DepthFirstTraversal) VisitExplode(e *ExplodeExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitExplodeFn != nil {
+		v.VisitExplodeFn(v, e)
+	}
+}
+
 // VisitJSONExpressionParser implements RootVisitor.
 func (v *DepthFirstTraversal) VisitJSONExpressionParser(e *JSONExpressionParser) {
 	if e == nil {
@@ -110,6 +156,16 @@ func (v *DepthFirstTraversal) VisitJSONExpressionParser(e *JSONExpressionParser)
 	}
 }
 
+// VisitKVParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitKVParser(e *KVParserExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitKVParserFn != nil {
+		v.VisitKVParserFn(v, e)
+	}
+}
+
 // VisitKeepLabel implements RootVisitor.
 func (v *DepthFirstTraversal) VisitKeepLabel(e *KeepLabelsExpr) {
 	if e == nil {
@@ -140,6 +196,16 @@ func (v *DepthFirstTraversal) VisitLabelFmt(e *LabelFmtExpr) {
 	}
 }
 
+// VisitLabelJoin implements RootVisitor.
+func (v *DepthFirstTraversal) VisitLabelJoin(e *LabelJoinExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitLabelJoinFn != nil {
+		v.VisitLabelJoinFn(v, e)
+	}
+}
+
 // VisitLabelParser implements RootVisitor.
 func (v *DepthFirstTraversal) VisitLabelParser(e *LabelParserExpr) {
 	if e == nil {
@@ -160,6 +226,16 @@ func (v *DepthFirstTraversal) VisitLabelReplace(e *LabelReplaceExpr) {
 	}
 }
 
+// VisitLimitBy implements RootVisitor.
+func (v *DepthFirstTraversal) VisitLimitBy(e *LimitByExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitLimitByFn != nil {
+		v.VisitLimitByFn(v, e)
+	}
+}
+
 // VisitLineFilter implements RootVisitor.
 func (v *DepthFirstTraversal) VisitLineFilter(e *LineFilterExpr) {
 	if e == nil {
@@ -266,6 +342,18 @@ func (v *DepthFirstTraversal) VisitRangeAggregation(e *RangeAggregationExpr) {
 	}
 }
 
+// VisitSubquery implements RootVisitor.
+func (v *DepthFirstTraversal) VisitSubquery(e *SubqueryExpr) {
+	if e == nil {
+		return
+	}
+	if v.VisitSubqueryFn != nil {
+		v.VisitSubqueryFn(v, e)
+	} else {
+		e.Left.Accept(v)
+	}
+}
+
 // VisitVector implements RootVisitor.
 func (v *DepthFirstTraversal) VisitVector(e *VectorExpr) {
 	if e == nil {
@@ -287,3 +375,13 @@ func (v *DepthFirstTraversal) VisitVectorAggregation(e *VectorAggregationExpr) {
 		e.Left.Accept(v)
 	}
 }
+
+// VisitXMLExpressionParser implements RootVisitor.
+func (v *DepthFirstTraversal) VisitXMLExpressionParser(e *XMLExpressionParser) {
+	if e == nil {
+		return
+	}
+	if v.VisitXMLExpressionParserFn != nil {
+		v.VisitXMLExpressionParserFn(v, e)
+	}
+}
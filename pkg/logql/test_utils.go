@@ -143,7 +143,8 @@ func processSeries(in []logproto.Stream, ex log.SampleExtractor) []logproto.Seri
 	for _, stream := range in {
 		for _, e := range stream.Entries {
 			exs := ex.ForStream(mustParseLabels(stream.Labels))
-			if f, lbs, ok := exs.Process(e.Timestamp.UnixNano(), []byte(e.Line)); ok {
+			// the line is extracted into multiple samples when the pipeline explodes it.
+			for f, lbs, ok := exs.Process(e.Timestamp.UnixNano(), []byte(e.Line)); ok; f, lbs, ok = exs.NextSample() {
 				var s *logproto.Series
 				var found bool
 				s, found = resBySeries[lbs.String()]
@@ -92,6 +92,12 @@ func NewSeriesLimitError(limit int) *LimitError {
 	}
 }
 
+func NewSubquerySamplesLimitError(limit int) *LimitError {
+	return &LimitError{
+		error: fmt.Errorf("maximum of samples (%d) reached for a single subquery", limit),
+	}
+}
+
 // Is allows to use errors.Is(err,ErrLimit) on this error.
 func (e LimitError) Is(target error) bool {
 	return target == ErrLimit
@@ -0,0 +1,173 @@
+/*
+Package explain records the distributed execution plan of a query requested
+with `explain=analyze`.
+
+The plan is a tree of nodes carried in the request context. Each middleware
+taking part in the execution of the query (splitting, caching, sharding,
+downstream requests) adds a child node to the node found in its context and
+passes the child down, so the tree mirrors how the query was actually run.
+Nodes are safe for concurrent use and all methods are no-ops on a nil node,
+so call sites don't need to check whether the query is being analyzed.
+*/
+package explain
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+type ctxKeyType string
+
+const ctxKey ctxKeyType = "explain"
+
+// Parameter is the query parameter value requesting the execution plan.
+const Parameter = "analyze"
+
+// Node types.
+const (
+	TypeQuery      = "query"
+	TypeSplit      = "split"
+	TypeShardMap   = "shard_map"
+	TypeRangeMap   = "range_map"
+	TypeDownstream = "downstream"
+)
+
+// Node is a step of the execution of a query.
+type Node struct {
+	Type  string    `json:"type"`
+	Query string    `json:"query,omitempty"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// ExecTime is the wall clock time in seconds spent in this node and its children.
+	ExecTime   float64           `json:"execTime"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Stats      *Stats            `json:"stats,omitempty"`
+	Children   []*Node           `json:"children,omitempty"`
+
+	mtx     sync.Mutex
+	started time.Time
+}
+
+// Stats is the subset of the query statistics of a node shown in the plan.
+type Stats struct {
+	BytesProcessed   int64   `json:"bytesProcessed"`
+	LinesProcessed   int64   `json:"linesProcessed"`
+	EntriesReturned  int64   `json:"entriesReturned"`
+	QueueTime        float64 `json:"queueTime"`
+	ExecTime         float64 `json:"execTime"`
+	ChunkRefs        int64   `json:"chunkRefs"`
+	ChunksDownloaded int64   `json:"chunksDownloaded"`
+	// IndexChunks and IndexPostFilterChunks are the chunks matched by the
+	// index before and after bloom filtering.
+	IndexChunks           int64 `json:"indexChunks"`
+	IndexPostFilterChunks int64 `json:"indexPostFilterChunks"`
+	ResultCacheHits       int32 `json:"resultCacheHits"`
+	ResultCacheRequests   int32 `json:"resultCacheRequests"`
+}
+
+// New returns a new root node starting now.
+func New(typ, query string, start, end time.Time) *Node {
+	return &Node{
+		Type:    typ,
+		Query:   query,
+		Start:   start,
+		End:     end,
+		started: time.Now(),
+	}
+}
+
+// NewContext returns a context carrying the node.
+func NewContext(ctx context.Context, n *Node) context.Context {
+	return context.WithValue(ctx, ctxKey, n)
+}
+
+// FromContext returns the node carried by the context, or nil if the query is
+// not being analyzed.
+func FromContext(ctx context.Context) *Node {
+	n, _ := ctx.Value(ctxKey).(*Node)
+	return n
+}
+
+// Child adds a child node starting now.
+func (n *Node) Child(typ, query string, start, end time.Time) *Node {
+	if n == nil {
+		return nil
+	}
+	c := New(typ, query, start, end)
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.Children = append(n.Children, c)
+	return c
+}
+
+// Set sets an attribute of the node.
+func (n *Node) Set(key, value string) {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	if n.Attributes == nil {
+		n.Attributes = map[string]string{}
+	}
+	n.Attributes[key] = value
+}
+
+// SetStats sets the statistics of the node.
+func (n *Node) SetStats(r stats.Result) {
+	if n == nil {
+		return
+	}
+	s := &Stats{
+		BytesProcessed:        r.Summary.TotalBytesProcessed,
+		LinesProcessed:        r.Summary.TotalLinesProcessed,
+		EntriesReturned:       r.Summary.TotalEntriesReturned,
+		QueueTime:             r.Summary.QueueTime,
+		ExecTime:              r.Summary.ExecTime,
+		ChunkRefs:             r.Querier.Store.TotalChunksRef,
+		ChunksDownloaded:      r.Querier.Store.TotalChunksDownloaded,
+		IndexChunks:           r.Index.TotalChunks,
+		IndexPostFilterChunks: r.Index.PostFilterChunks,
+		ResultCacheHits:       r.Caches.Result.EntriesFound,
+		ResultCacheRequests:   r.Caches.Result.EntriesRequested,
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.Stats = s
+}
+
+// Finish records the time spent in the node and the error it failed with, if any.
+func (n *Node) Finish(err error) {
+	if n == nil {
+		return
+	}
+	if err != nil {
+		n.Set("error", err.Error())
+	}
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	n.ExecTime = time.Since(n.started).Seconds()
+}
+
+// Sort orders the children of the node and its descendants by start time.
+// Children are added concurrently, it should be called once the query is done.
+func (n *Node) Sort() {
+	if n == nil {
+		return
+	}
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	sort.SliceStable(n.Children, func(i, j int) bool {
+		return n.Children[i].Start.Before(n.Children[j].Start)
+	})
+	for _, c := range n.Children {
+		c.Sort()
+	}
+}
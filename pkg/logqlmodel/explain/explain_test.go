@@ -0,0 +1,53 @@
+package explain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+func TestNilNode(t *testing.T) {
+	var n *Node
+	require.Nil(t, FromContext(context.Background()))
+	require.Nil(t, n.Child(TypeSplit, "", time.Time{}, time.Time{}))
+	n.Set("foo", "bar")
+	n.SetStats(stats.Result{})
+	n.Finish(errors.New("failed"))
+	n.Sort()
+}
+
+func TestNode(t *testing.T) {
+	start := time.Unix(0, 0)
+	root := New(TypeQuery, `{app="foo"}`, start, start.Add(2*time.Hour))
+	ctx := NewContext(context.Background(), root)
+	require.Same(t, root, FromContext(ctx))
+
+	second := FromContext(ctx).Child(TypeSplit, `{app="foo"}`, start.Add(time.Hour), start.Add(2*time.Hour))
+	first := FromContext(ctx).Child(TypeSplit, `{app="foo"}`, start, start.Add(time.Hour))
+	first.Set("cache.results", "miss")
+	first.SetStats(stats.Result{
+		Summary: stats.Summary{TotalBytesProcessed: 10, TotalLinesProcessed: 2},
+		Caches:  stats.Caches{Result: stats.Cache{EntriesFound: 1, EntriesRequested: 2}},
+	})
+	first.Finish(nil)
+	second.Finish(errors.New("failed"))
+	root.Finish(nil)
+	root.Sort()
+
+	require.Equal(t, []*Node{first, second}, root.Children)
+	require.Equal(t, map[string]string{"cache.results": "miss"}, first.Attributes)
+	require.Equal(t, map[string]string{"error": "failed"}, second.Attributes)
+	require.Equal(t, &Stats{
+		BytesProcessed:      10,
+		LinesProcessed:      2,
+		ResultCacheHits:     1,
+		ResultCacheRequests: 2,
+	}, first.Stats)
+	require.Nil(t, second.Stats)
+	require.GreaterOrEqual(t, root.ExecTime, first.ExecTime)
+}
@@ -217,6 +217,9 @@ func (s *Store) ChunksDownloadDuration() time.Duration {
 func (s *Summary) Merge(m Summary) {
 	s.Splits += m.Splits
 	s.Shards += m.Shards
+	if m.Approximate {
+		s.Approximate = true
+	}
 }
 
 func (q *Querier) Merge(m Querier) {
@@ -494,6 +497,14 @@ func (c *Context) SetQueryReferencedStructuredMetadata() {
 	c.store.QueryReferencedStructured = true
 }
 
+// SetApproximate marks the result as approximated from index statistics.
+func (c *Context) SetApproximate() {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.result.Summary.Approximate = true
+}
+
 func (c *Context) getCacheStatsByType(t CacheType) *Cache {
 	var stats *Cache
 	switch t {
@@ -359,3 +359,17 @@ func TestCaches(t *testing.T) {
 		},
 	}, statsCtx.Caches())
 }
+
+func TestResult_MergeApproximate(t *testing.T) {
+	var res Result
+	res.Merge(Result{})
+	require.False(t, res.Summary.Approximate)
+
+	res.Merge(Result{Summary: Summary{Approximate: true}})
+	res.Merge(Result{})
+	require.True(t, res.Summary.Approximate)
+
+	statsCtx, _ := NewContext(context.Background())
+	statsCtx.SetApproximate()
+	require.True(t, statsCtx.Result(0, 0, 0).Summary.Approximate)
+}
@@ -232,6 +232,8 @@ type Summary struct {
 	TotalPostFilterLines int64 `protobuf:"varint,11,opt,name=totalPostFilterLines,proto3" json:"totalPostFilterLines"`
 	// Total bytes processed of metadata.
 	TotalStructuredMetadataBytesProcessed int64 `protobuf:"varint,12,opt,name=totalStructuredMetadataBytesProcessed,proto3" json:"totalStructuredMetadataBytesProcessed"`
+	// Whether the result was approximated from index statistics instead of reading chunks.
+	Approximate bool `protobuf:"varint,13,opt,name=approximate,proto3" json:"approximate,omitempty"`
 }
 
 func (m *Summary) Reset()      { *m = Summary{} }
@@ -350,6 +352,13 @@ func (m *Summary) GetTotalStructuredMetadataBytesProcessed() int64 {
 	return 0
 }
 
+func (m *Summary) GetApproximate() bool {
+	if m != nil {
+		return m.Approximate
+	}
+	return false
+}
+
 // Statistics from Index queries
 // TODO(owen-d): include bytes.
 // Needs some index methods added to return _sized_ chunk refs to know
@@ -873,94 +882,95 @@ func init() {
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 1378 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x58, 0xcf, 0x6f, 0xdc, 0x44,
-	0x14, 0xde, 0xcd, 0xd6, 0x9b, 0x74, 0xf2, 0xab, 0x9d, 0xa4, 0x74, 0x4b, 0x2b, 0x3b, 0x2c, 0x54,
-	0x14, 0x21, 0x65, 0x55, 0x8a, 0x84, 0x40, 0x54, 0x42, 0x4e, 0x89, 0x54, 0x29, 0x15, 0xe5, 0x2d,
-	0x08, 0x04, 0x27, 0xc7, 0x9e, 0xec, 0x5a, 0xf5, 0xda, 0x8e, 0x3d, 0x0e, 0xcd, 0x09, 0xfe, 0x04,
-	0xee, 0xdc, 0x11, 0x17, 0x4e, 0x5c, 0xe0, 0xcc, 0xa5, 0xc7, 0x1e, 0x7b, 0xb2, 0xe8, 0xe6, 0x82,
-	0x7c, 0xea, 0x1f, 0xc0, 0x01, 0xcd, 0x9b, 0x59, 0xff, 0x5a, 0x6f, 0x9a, 0xcb, 0x7a, 0xde, 0xf7,
-	0xbe, 0xef, 0xcd, 0xf8, 0x79, 0xe6, 0xbd, 0xd1, 0x92, 0x9d, 0xf0, 0xc9, 0x68, 0xe0, 0x05, 0xa3,
-	0x63, 0x6f, 0x12, 0x38, 0xcc, 0x1b, 0xc4, 0xdc, 0xe2, 0xb1, 0xfc, 0xdd, 0x0d, 0xa3, 0x80, 0x07,
-	0x54, 0x43, 0xe3, 0xcd, 0xed, 0x51, 0x30, 0x0a, 0x10, 0x19, 0x88, 0x91, 0x74, 0xf6, 0x7f, 0x5d,
-	0x22, 0x5d, 0x60, 0x71, 0xe2, 0x71, 0xfa, 0x31, 0x59, 0x8e, 0x93, 0xc9, 0xc4, 0x8a, 0x4e, 0x7b,
-	0xed, 0x9d, 0xf6, 0x9d, 0xd5, 0x0f, 0x36, 0x76, 0x65, 0x98, 0xa1, 0x44, 0xcd, 0xcd, 0x67, 0xa9,
-	0xd1, 0xca, 0x52, 0x63, 0x46, 0x83, 0xd9, 0x40, 0x48, 0x8f, 0x13, 0x16, 0xb9, 0x2c, 0xea, 0x2d,
-	0x55, 0xa4, 0x5f, 0x4a, 0xb4, 0x90, 0x2a, 0x1a, 0xcc, 0x06, 0xf4, 0x3e, 0x59, 0x71, 0xfd, 0x11,
-	0x8b, 0x39, 0x8b, 0x7a, 0x1d, 0xd4, 0x6e, 0x2a, 0xed, 0x43, 0x05, 0x9b, 0x57, 0x94, 0x38, 0x27,
-	0x42, 0x3e, 0xa2, 0x1f, 0x92, 0xae, 0x6d, 0xd9, 0x63, 0x16, 0xf7, 0x2e, 0xa1, 0x78, 0x5d, 0x89,
-	0xf7, 0x10, 0x34, 0xd7, 0x95, 0x54, 0x43, 0x12, 0x28, 0x2e, 0xbd, 0x4b, 0x34, 0xd7, 0x77, 0xd8,
-	0xd3, 0x9e, 0x86, 0xa2, 0xb5, 0x7c, 0x46, 0x87, 0x3d, 0x2d, 0x34, 0x48, 0x01, 0xf9, 0xe8, 0xff,
-	0x72, 0x89, 0x74, 0xf7, 0x72, 0xb5, 0x3d, 0x4e, 0xfc, 0x27, 0x2a, 0x4d, 0x6b, 0xe5, 0x29, 0x4b,
-	0x33, 0x0a, 0x0a, 0xc8, 0x47, 0x31, 0xe1, 0xd2, 0x79, 0x92, 0xf2, 0x84, 0xe2, 0xcd, 0x22, 0xfc,
-	0x30, 0x2a, 0x2d, 0x55, 0xcd, 0x86, 0xd2, 0x28, 0x0e, 0xa8, 0x27, 0xdd, 0x23, 0xab, 0x48, 0x93,
-	0xdf, 0x54, 0x25, 0xa5, 0x2a, 0xdd, 0x52, 0xd2, 0x32, 0x11, 0xca, 0x06, 0xdd, 0x27, 0x6b, 0x27,
-	0x81, 0x97, 0x4c, 0x98, 0x8a, 0xa2, 0x35, 0x44, 0xd9, 0x56, 0x51, 0x2a, 0x4c, 0xa8, 0x58, 0x22,
-	0x4e, 0x2c, 0xbe, 0xf2, 0x6c, 0x35, 0xdd, 0xf3, 0xe2, 0x94, 0x99, 0x50, 0xb1, 0xc4, 0x4b, 0x79,
-	0xd6, 0x21, 0xf3, 0x54, 0x98, 0xe5, 0xf3, 0x5e, 0xaa, 0x44, 0x84, 0xb2, 0x41, 0xbf, 0x27, 0x5b,
-	0xae, 0x1f, 0x73, 0xcb, 0xe7, 0x8f, 0x18, 0x8f, 0x5c, 0x5b, 0x05, 0x5b, 0x69, 0x08, 0x76, 0x53,
-	0x05, 0x6b, 0x12, 0x40, 0x13, 0xd8, 0xff, 0xab, 0x4b, 0x96, 0xd5, 0x31, 0xa1, 0x5f, 0x93, 0xeb,
-	0x87, 0xa7, 0x9c, 0xc5, 0x8f, 0xa3, 0xc0, 0x66, 0x71, 0xcc, 0x9c, 0xc7, 0x2c, 0x1a, 0x32, 0x3b,
-	0xf0, 0x1d, 0xdc, 0x30, 0x1d, 0xf3, 0x66, 0x96, 0x1a, 0x8b, 0x28, 0xb0, 0xc8, 0x21, 0xc2, 0x7a,
-	0xae, 0xdf, 0x18, 0x76, 0xa9, 0x08, 0xbb, 0x80, 0x02, 0x8b, 0x1c, 0xf4, 0x21, 0xd9, 0xe2, 0x01,
-	0xb7, 0x3c, 0xb3, 0x32, 0x2d, 0xee, 0xb9, 0x8e, 0x79, 0x5d, 0x24, 0xa1, 0xc1, 0x0d, 0x4d, 0x60,
-	0x1e, 0xea, 0xa0, 0x32, 0x15, 0xee, 0xc1, 0x72, 0xa8, 0xaa, 0x1b, 0x9a, 0x40, 0x7a, 0x87, 0xac,
-	0xb0, 0xa7, 0xcc, 0xfe, 0xca, 0x9d, 0x30, 0xdc, 0x7d, 0x6d, 0x73, 0x4d, 0x14, 0x80, 0x19, 0x06,
-	0xf9, 0x88, 0xbe, 0x4f, 0x2e, 0x1f, 0x27, 0x2c, 0x61, 0x48, 0xed, 0x22, 0x75, 0x3d, 0x4b, 0x8d,
-	0x02, 0x84, 0x62, 0x48, 0x77, 0x09, 0x89, 0x93, 0x43, 0x59, 0x7a, 0x62, 0xdc, 0x47, 0x1d, 0x73,
-	0x23, 0x4b, 0x8d, 0x12, 0x0a, 0xa5, 0x31, 0x3d, 0x20, 0xdb, 0xb8, 0xba, 0xcf, 0x7d, 0x2e, 0xb7,
-	0x23, 0x4f, 0x22, 0x9f, 0x39, 0xb8, 0x69, 0x3a, 0x66, 0x2f, 0x4b, 0x8d, 0x46, 0x3f, 0x34, 0xa2,
-	0xb4, 0x4f, 0xba, 0x71, 0xe8, 0xb9, 0x3c, 0xee, 0x5d, 0x46, 0x3d, 0x11, 0xe7, 0x57, 0x22, 0xa0,
-	0x9e, 0xc8, 0x19, 0x5b, 0x91, 0x13, 0xf7, 0x48, 0x89, 0x83, 0x08, 0xa8, 0x67, 0xbe, 0xaa, 0xc7,
-	0x41, 0xcc, 0xf7, 0x5d, 0x8f, 0xb3, 0x08, 0xb3, 0xd7, 0x5b, 0xad, 0xad, 0xaa, 0xe6, 0x87, 0x46,
-	0x94, 0xfe, 0x48, 0x6e, 0x23, 0x3e, 0xe4, 0x51, 0x62, 0xf3, 0x24, 0x62, 0xce, 0x23, 0xc6, 0x2d,
-	0xc7, 0xe2, 0x56, 0x6d, 0x4b, 0xac, 0x61, 0xf8, 0xf7, 0xb2, 0xd4, 0xb8, 0x98, 0x00, 0x2e, 0x46,
-	0xeb, 0xff, 0xd9, 0x26, 0x1a, 0x56, 0x5e, 0x7a, 0x97, 0xac, 0xa2, 0x64, 0x4f, 0xd4, 0xcc, 0x58,
-	0x9d, 0x96, 0x4d, 0x71, 0xaa, 0x4b, 0x30, 0x94, 0x0d, 0xfa, 0x19, 0xb9, 0x12, 0xe6, 0x2f, 0xa4,
-	0x74, 0xf2, 0x38, 0x6c, 0x67, 0xa9, 0x31, 0xe7, 0x83, 0x39, 0x84, 0x7e, 0x42, 0x36, 0x64, 0x5e,
-	0x1f, 0x24, 0x91, 0xc5, 0xdd, 0xc0, 0x57, 0x7b, 0x9f, 0x66, 0xa9, 0x51, 0xf3, 0x40, 0xcd, 0xee,
-	0x7f, 0x4a, 0x96, 0x55, 0x87, 0x13, 0x15, 0x3e, 0xe6, 0x41, 0xc4, 0x6a, 0x4d, 0x61, 0x28, 0xb0,
-	0xa2, 0xc2, 0x23, 0x05, 0xe4, 0xa3, 0xff, 0xfb, 0x12, 0x59, 0x79, 0x58, 0x34, 0xb2, 0x35, 0x7c,
-	0x2f, 0x60, 0xa2, 0x04, 0xc9, 0x52, 0xa1, 0x99, 0x57, 0x44, 0x65, 0x2c, 0xe3, 0x50, 0xb1, 0xe8,
-	0x3e, 0xa1, 0xa5, 0x6c, 0x3c, 0xb2, 0x38, 0x6a, 0x65, 0x02, 0xde, 0xc8, 0x52, 0xa3, 0xc1, 0x0b,
-	0x0d, 0x58, 0x3e, 0xbb, 0x89, 0x76, 0xac, 0x52, 0x50, 0xcc, 0xae, 0x70, 0xa8, 0x58, 0x22, 0x75,
-	0xc5, 0xe1, 0x1d, 0x32, 0x9f, 0xab, 0xb3, 0x8e, 0xa9, 0xab, 0x7a, 0xa0, 0x66, 0x17, 0xf9, 0xd2,
-	0x2e, 0x9c, 0xaf, 0xff, 0x2e, 0x11, 0x0d, 0xfd, 0xf9, 0xc4, 0xea, 0xa3, 0xb2, 0x23, 0xb5, 0x57,
-	0x8a, 0x89, 0x73, 0x0f, 0xd4, 0x6c, 0xfa, 0x05, 0xb9, 0x56, 0x42, 0x1e, 0x04, 0x3f, 0xf8, 0x5e,
-	0x60, 0x39, 0x79, 0xd6, 0x6e, 0x64, 0xa9, 0xd1, 0x4c, 0x80, 0x66, 0x58, 0x7c, 0x03, 0xbb, 0x82,
-	0x61, 0x29, 0xea, 0x14, 0xdf, 0x60, 0xde, 0x0b, 0x0d, 0x18, 0xb5, 0xc9, 0x0d, 0x51, 0x77, 0x4e,
-	0x81, 0x1d, 0xb1, 0x88, 0xf9, 0x36, 0x73, 0x8a, 0xa3, 0xd3, 0x5b, 0xdf, 0x69, 0xdf, 0x59, 0x31,
-	0x6f, 0x67, 0xa9, 0xf1, 0xd6, 0x42, 0xd2, 0xec, 0x7c, 0xc1, 0xe2, 0x38, 0xc5, 0xdd, 0xa5, 0x76,
-	0x33, 0x10, 0xd8, 0x82, 0xbb, 0xcb, 0xec, 0xfd, 0x80, 0x1d, 0xc5, 0xfb, 0x8c, 0xdb, 0xe3, 0xbc,
-	0x2a, 0x97, 0xdf, 0xaf, 0xe2, 0x85, 0x06, 0x8c, 0x7e, 0x4b, 0x7a, 0x76, 0x80, 0xdb, 0xdd, 0x0d,
-	0xfc, 0xbd, 0xc0, 0xe7, 0x51, 0xe0, 0x1d, 0x58, 0x9c, 0xf9, 0xf6, 0x29, 0x16, 0xee, 0x8e, 0x79,
-	0x2b, 0x4b, 0x8d, 0x85, 0x1c, 0x58, 0xe8, 0xa1, 0x0e, 0xb9, 0x15, 0xba, 0x21, 0x13, 0x2d, 0xee,
-	0x9b, 0xc8, 0x0a, 0x43, 0x16, 0xc9, 0x13, 0xce, 0x1c, 0x59, 0x18, 0x65, 0xa1, 0xdf, 0xc9, 0x52,
-	0xe3, 0x5c, 0x1e, 0x9c, 0xeb, 0xed, 0xff, 0xa1, 0x11, 0x0d, 0xf3, 0x24, 0xb6, 0xdf, 0x98, 0x59,
-	0x8e, 0x4c, 0x9a, 0x28, 0x66, 0xe5, 0x7d, 0x5f, 0xf5, 0x40, 0xcd, 0xae, 0x68, 0xe5, 0xea, 0xb4,
-	0x06, 0xad, 0x5c, 0x4f, 0xcd, 0xa6, 0x7b, 0xe4, 0xaa, 0xc3, 0xec, 0x60, 0x12, 0x46, 0x58, 0x39,
-	0xe5, 0xd4, 0x32, 0x75, 0xd7, 0xb2, 0xd4, 0x98, 0x77, 0xc2, 0x3c, 0x54, 0x0f, 0x52, 0xce, 0xd0,
-	0x5c, 0x10, 0xb9, 0x8c, 0x79, 0x88, 0xde, 0x27, 0x9b, 0xf5, 0x75, 0xc8, 0x9e, 0xb8, 0x95, 0xa5,
-	0x46, 0xdd, 0x05, 0x75, 0x40, 0xc8, 0xf1, 0x2c, 0x3d, 0x48, 0x42, 0xcf, 0xb5, 0x2d, 0x21, 0xbf,
-	0x5c, 0xc8, 0x6b, 0x2e, 0xa8, 0x03, 0x42, 0x1e, 0xd6, 0x7a, 0x1f, 0x29, 0xe4, 0x35, 0x17, 0xd4,
-	0x01, 0x1a, 0x92, 0x9d, 0x3c, 0xb1, 0x0b, 0xba, 0x93, 0xea, 0xa5, 0xef, 0x64, 0xa9, 0xf1, 0x5a,
-	0x2e, 0xbc, 0x96, 0x41, 0x4f, 0xc9, 0xdb, 0xe5, 0x1c, 0x2e, 0x9a, 0x54, 0x76, 0xd8, 0x77, 0xb3,
-	0xd4, 0xb8, 0x08, 0x1d, 0x2e, 0x42, 0xea, 0xff, 0xdd, 0x21, 0x1a, 0xde, 0x6a, 0x45, 0x8d, 0x67,
-	0xf2, 0x46, 0xb2, 0x1f, 0x24, 0x7e, 0xa5, 0xc3, 0x94, 0x71, 0xa8, 0x58, 0xa2, 0xc1, 0xb2, 0xd9,
-	0x3d, 0xe6, 0x38, 0x11, 0xbd, 0x4a, 0x56, 0x4a, 0x4d, 0x36, 0xd8, 0xba, 0x0f, 0xe6, 0x10, 0xfa,
-	0x11, 0x59, 0x57, 0x18, 0x16, 0x6f, 0x79, 0xb7, 0xd4, 0xcc, 0xab, 0x59, 0x6a, 0x54, 0x1d, 0x50,
-	0x35, 0x85, 0x10, 0x2f, 0xc3, 0xc0, 0x6c, 0xe6, 0x9e, 0xe4, 0x37, 0x49, 0x14, 0x56, 0x1c, 0x50,
-	0x35, 0xc5, 0x9d, 0x10, 0x01, 0x6c, 0x49, 0xf2, 0x78, 0xe1, 0x9d, 0x30, 0x07, 0xa1, 0x18, 0x8a,
-	0xab, 0x66, 0x24, 0xd7, 0x2a, 0xcf, 0x92, 0x26, 0xaf, 0x9a, 0x33, 0x0c, 0xf2, 0x91, 0x48, 0xa0,
-	0x53, 0x2e, 0xf1, 0xcb, 0x45, 0x93, 0x2c, 0xe3, 0x50, 0xb1, 0xc4, 0x79, 0xc3, 0x72, 0x7c, 0xc0,
-	0xfc, 0x11, 0x1f, 0x0f, 0x59, 0x74, 0x92, 0x5f, 0x20, 0xf1, 0xbc, 0xcd, 0x39, 0x61, 0x1e, 0x32,
-	0xd9, 0xf3, 0x97, 0x7a, 0xeb, 0xc5, 0x4b, 0xbd, 0xf5, 0xea, 0xa5, 0xde, 0xfe, 0x69, 0xaa, 0xb7,
-	0x7f, 0x9b, 0xea, 0xed, 0x67, 0x53, 0xbd, 0xfd, 0x7c, 0xaa, 0xb7, 0xff, 0x99, 0xea, 0xed, 0x7f,
-	0xa7, 0x7a, 0xeb, 0xd5, 0x54, 0x6f, 0xff, 0x7c, 0xa6, 0xb7, 0x9e, 0x9f, 0xe9, 0xad, 0x17, 0x67,
-	0x7a, 0xeb, 0xbb, 0xc1, 0xc8, 0xe5, 0xe3, 0xe4, 0x70, 0xd7, 0x0e, 0x26, 0x83, 0x51, 0x64, 0x1d,
-	0x59, 0xbe, 0x35, 0xf0, 0x82, 0x27, 0xee, 0xe0, 0xe4, 0xde, 0xa0, 0xe9, 0x6f, 0x83, 0xc3, 0x2e,
-	0xfe, 0x29, 0x70, 0xef, 0xff, 0x00, 0x00, 0x00, 0xff, 0xff, 0x64, 0x90, 0x88, 0xaf, 0x55, 0x10,
-	0x00, 0x00,
+	// 1402 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x58, 0xcd, 0x6f, 0xdc, 0x44,
+	0x14, 0x8f, 0xb3, 0xf1, 0x26, 0x9d, 0x7c, 0xb5, 0x93, 0x94, 0xba, 0xb4, 0x5a, 0x87, 0x85, 0x8a,
+	0x22, 0x50, 0x56, 0xa5, 0x48, 0x88, 0x8f, 0x4a, 0xc8, 0x29, 0x91, 0x2a, 0xa5, 0xa2, 0xbc, 0x80,
+	0x40, 0x70, 0x72, 0xec, 0x97, 0x8d, 0x55, 0xaf, 0xed, 0xd8, 0xe3, 0x90, 0x9c, 0xe0, 0x4f, 0xe0,
+	0xce, 0x1d, 0x71, 0xe1, 0xc4, 0x85, 0x3b, 0x97, 0x1e, 0x7b, 0xec, 0xc9, 0xa2, 0xdb, 0x0b, 0xf2,
+	0xa9, 0x27, 0x4e, 0x1c, 0xd0, 0x7c, 0xac, 0xbf, 0xd6, 0x9b, 0xe6, 0xb2, 0x9e, 0xf7, 0x7b, 0xbf,
+	0xdf, 0x9b, 0xf1, 0xf3, 0xcc, 0x7b, 0xa3, 0x25, 0x5b, 0xd1, 0xe3, 0xe1, 0xc0, 0x0f, 0x87, 0xc7,
+	0xfe, 0x28, 0x74, 0xd1, 0x1f, 0x24, 0xcc, 0x66, 0x89, 0xfc, 0xdd, 0x8e, 0xe2, 0x90, 0x85, 0x54,
+	0x17, 0xc6, 0xeb, 0x9b, 0xc3, 0x70, 0x18, 0x0a, 0x64, 0xc0, 0x47, 0xd2, 0xd9, 0xff, 0x75, 0x9e,
+	0x74, 0x01, 0x93, 0xd4, 0x67, 0xf4, 0x23, 0xb2, 0x98, 0xa4, 0xa3, 0x91, 0x1d, 0x9f, 0x19, 0xda,
+	0x96, 0x76, 0x7b, 0xf9, 0xfd, 0xb5, 0x6d, 0x19, 0x66, 0x5f, 0xa2, 0xd6, 0xfa, 0x93, 0xcc, 0x9c,
+	0xcb, 0x33, 0x73, 0x42, 0x83, 0xc9, 0x80, 0x4b, 0x8f, 0x53, 0x8c, 0x3d, 0x8c, 0x8d, 0xf9, 0x9a,
+	0xf4, 0x4b, 0x89, 0x96, 0x52, 0x45, 0x83, 0xc9, 0x80, 0xde, 0x23, 0x4b, 0x5e, 0x30, 0xc4, 0x84,
+	0x61, 0x6c, 0x74, 0x84, 0x76, 0x5d, 0x69, 0x1f, 0x28, 0xd8, 0xba, 0xac, 0xc4, 0x05, 0x11, 0x8a,
+	0x11, 0xfd, 0x80, 0x74, 0x1d, 0xdb, 0x39, 0xc2, 0xc4, 0x58, 0x10, 0xe2, 0x55, 0x25, 0xde, 0x11,
+	0xa0, 0xb5, 0xaa, 0xa4, 0xba, 0x20, 0x81, 0xe2, 0xd2, 0x3b, 0x44, 0xf7, 0x02, 0x17, 0x4f, 0x0d,
+	0x5d, 0x88, 0x56, 0x8a, 0x19, 0x5d, 0x3c, 0x2d, 0x35, 0x82, 0x02, 0xf2, 0xd1, 0xff, 0x65, 0x81,
+	0x74, 0x77, 0x0a, 0xb5, 0x73, 0x94, 0x06, 0x8f, 0x0d, 0xad, 0xa6, 0x16, 0xde, 0xca, 0x8c, 0x9c,
+	0x02, 0xf2, 0x51, 0x4e, 0x38, 0x7f, 0x9e, 0xa4, 0x3a, 0x21, 0x7f, 0xb3, 0x58, 0x7c, 0x18, 0xa3,
+	0xd3, 0xa2, 0x59, 0x53, 0x1a, 0xc5, 0x01, 0xf5, 0xa4, 0x3b, 0x64, 0x59, 0xd0, 0xe4, 0x37, 0x35,
+	0x16, 0x5a, 0xa4, 0x1b, 0x4a, 0x5a, 0x25, 0x42, 0xd5, 0xa0, 0xbb, 0x64, 0xe5, 0x24, 0xf4, 0xd3,
+	0x11, 0xaa, 0x28, 0x7a, 0x4b, 0x94, 0x4d, 0x15, 0xa5, 0xc6, 0x84, 0x9a, 0xc5, 0xe3, 0x24, 0xfc,
+	0x2b, 0x4f, 0x56, 0xd3, 0x3d, 0x2f, 0x4e, 0x95, 0x09, 0x35, 0x8b, 0xbf, 0x94, 0x6f, 0x1f, 0xa0,
+	0xaf, 0xc2, 0x2c, 0x9e, 0xf7, 0x52, 0x15, 0x22, 0x54, 0x0d, 0xfa, 0x3d, 0xd9, 0xf0, 0x82, 0x84,
+	0xd9, 0x01, 0x7b, 0x88, 0x2c, 0xf6, 0x1c, 0x15, 0x6c, 0xa9, 0x25, 0xd8, 0x0d, 0x15, 0xac, 0x4d,
+	0x00, 0x6d, 0x60, 0xff, 0xdf, 0x2e, 0x59, 0x54, 0xc7, 0x84, 0x7e, 0x4d, 0xae, 0x1d, 0x9c, 0x31,
+	0x4c, 0x1e, 0xc5, 0xa1, 0x83, 0x49, 0x82, 0xee, 0x23, 0x8c, 0xf7, 0xd1, 0x09, 0x03, 0x57, 0x6c,
+	0x98, 0x8e, 0x75, 0x23, 0xcf, 0xcc, 0x59, 0x14, 0x98, 0xe5, 0xe0, 0x61, 0x7d, 0x2f, 0x68, 0x0d,
+	0x3b, 0x5f, 0x86, 0x9d, 0x41, 0x81, 0x59, 0x0e, 0xfa, 0x80, 0x6c, 0xb0, 0x90, 0xd9, 0xbe, 0x55,
+	0x9b, 0x56, 0xec, 0xb9, 0x8e, 0x75, 0x8d, 0x27, 0xa1, 0xc5, 0x0d, 0x6d, 0x60, 0x11, 0x6a, 0xaf,
+	0x36, 0x95, 0xb1, 0xd0, 0x08, 0x55, 0x77, 0x43, 0x1b, 0x48, 0x6f, 0x93, 0x25, 0x3c, 0x45, 0xe7,
+	0x2b, 0x6f, 0x84, 0x62, 0xf7, 0x69, 0xd6, 0x0a, 0x2f, 0x00, 0x13, 0x0c, 0x8a, 0x11, 0x7d, 0x97,
+	0x5c, 0x3a, 0x4e, 0x31, 0x45, 0x41, 0xed, 0x0a, 0xea, 0x6a, 0x9e, 0x99, 0x25, 0x08, 0xe5, 0x90,
+	0x6e, 0x13, 0x92, 0xa4, 0x07, 0xb2, 0xf4, 0x24, 0x62, 0x1f, 0x75, 0xac, 0xb5, 0x3c, 0x33, 0x2b,
+	0x28, 0x54, 0xc6, 0x74, 0x8f, 0x6c, 0x8a, 0xd5, 0x7d, 0x1e, 0x30, 0xe1, 0x43, 0x96, 0xc6, 0x01,
+	0xba, 0x62, 0xd3, 0x74, 0x2c, 0x23, 0xcf, 0xcc, 0x56, 0x3f, 0xb4, 0xa2, 0xb4, 0x4f, 0xba, 0x49,
+	0xe4, 0x7b, 0x2c, 0x31, 0x2e, 0x09, 0x3d, 0xe1, 0xe7, 0x57, 0x22, 0xa0, 0x9e, 0x82, 0x73, 0x64,
+	0xc7, 0x6e, 0x62, 0x90, 0x0a, 0x47, 0x20, 0xa0, 0x9e, 0xc5, 0xaa, 0x1e, 0x85, 0x09, 0xdb, 0xf5,
+	0x7c, 0x86, 0xb1, 0xc8, 0x9e, 0xb1, 0xdc, 0x58, 0x55, 0xc3, 0x0f, 0xad, 0x28, 0xfd, 0x91, 0xdc,
+	0x12, 0xf8, 0x3e, 0x8b, 0x53, 0x87, 0xa5, 0x31, 0xba, 0x0f, 0x91, 0xd9, 0xae, 0xcd, 0xec, 0xc6,
+	0x96, 0x58, 0x11, 0xe1, 0xdf, 0xc9, 0x33, 0xf3, 0x62, 0x02, 0xb8, 0x18, 0x8d, 0x7e, 0x42, 0x96,
+	0xed, 0x28, 0x8a, 0xc3, 0x53, 0x6f, 0x64, 0x33, 0x34, 0x56, 0xb7, 0xb4, 0xdb, 0x4b, 0xd6, 0xf5,
+	0x3c, 0x33, 0xaf, 0x56, 0xe0, 0xf7, 0xc2, 0x91, 0xc7, 0x70, 0x14, 0xb1, 0x33, 0xa8, 0xb2, 0xfb,
+	0x7f, 0x6a, 0x44, 0x17, 0x65, 0x9b, 0xde, 0x21, 0xcb, 0x62, 0xbe, 0x1d, 0x5e, 0x70, 0x13, 0x75,
+	0xd4, 0xd6, 0x79, 0x49, 0xa8, 0xc0, 0x50, 0x35, 0xe8, 0x67, 0xe4, 0x72, 0x54, 0x64, 0x43, 0xe9,
+	0xe4, 0x59, 0xda, 0xcc, 0x33, 0x73, 0xca, 0x07, 0x53, 0x08, 0xfd, 0x98, 0xac, 0xc9, 0x8f, 0x72,
+	0x3f, 0x8d, 0x6d, 0xe6, 0x85, 0x81, 0x3a, 0x38, 0x34, 0xcf, 0xcc, 0x86, 0x07, 0x1a, 0x76, 0xff,
+	0x53, 0xb2, 0xa8, 0xda, 0x23, 0x6f, 0x0f, 0x09, 0x0b, 0x63, 0x6c, 0x74, 0x94, 0x7d, 0x8e, 0x95,
+	0xed, 0x41, 0x50, 0x40, 0x3e, 0xfa, 0xbf, 0xcf, 0x93, 0xa5, 0x07, 0x65, 0x17, 0x5c, 0x11, 0xef,
+	0x05, 0xc8, 0xeb, 0x97, 0xac, 0x33, 0xba, 0x75, 0x99, 0x97, 0xd5, 0x2a, 0x0e, 0x35, 0x8b, 0xee,
+	0x12, 0x5a, 0xc9, 0xc6, 0x43, 0x9b, 0x09, 0xad, 0x4c, 0xc0, 0x6b, 0x79, 0x66, 0xb6, 0x78, 0xa1,
+	0x05, 0x2b, 0x66, 0xb7, 0x84, 0x9d, 0xa8, 0x14, 0x94, 0xb3, 0x2b, 0x1c, 0x6a, 0x16, 0x4f, 0x5d,
+	0x79, 0xf2, 0xf7, 0x31, 0x60, 0xc6, 0x42, 0x99, 0xba, 0xba, 0x07, 0x1a, 0x76, 0x99, 0x2f, 0xfd,
+	0xc2, 0xf9, 0xfa, 0x6f, 0x81, 0xe8, 0xc2, 0x5f, 0x4c, 0xac, 0x3e, 0x2a, 0x1e, 0x1a, 0x5a, 0x63,
+	0xe2, 0xc2, 0x03, 0x0d, 0x9b, 0x7e, 0x41, 0xae, 0x56, 0x90, 0xfb, 0xe1, 0x0f, 0x81, 0x1f, 0xda,
+	0x6e, 0x91, 0x35, 0xb1, 0x6b, 0x5b, 0x09, 0xd0, 0x0e, 0xf3, 0x6f, 0xe0, 0xd4, 0x30, 0x51, 0xc7,
+	0x3a, 0xe5, 0x37, 0x98, 0xf6, 0x42, 0x0b, 0x46, 0x1d, 0x72, 0x9d, 0x17, 0xad, 0x33, 0xc0, 0x43,
+	0x8c, 0x31, 0x70, 0xd0, 0x2d, 0xcf, 0x9d, 0x3a, 0x52, 0xb7, 0xf2, 0xcc, 0x7c, 0x63, 0x26, 0x69,
+	0x72, 0x38, 0x61, 0x76, 0x9c, 0xf2, 0xe2, 0xd3, 0xb8, 0x56, 0x70, 0x6c, 0xc6, 0xc5, 0x67, 0xf2,
+	0x7e, 0x80, 0x87, 0xc9, 0x2e, 0x32, 0xe7, 0xa8, 0x28, 0xe9, 0xd5, 0xf7, 0xab, 0x79, 0xa1, 0x05,
+	0xa3, 0xdf, 0x12, 0xc3, 0x09, 0xc5, 0x76, 0xf7, 0xc2, 0x60, 0x27, 0x0c, 0x58, 0x1c, 0xfa, 0x7b,
+	0x36, 0xc3, 0xc0, 0x39, 0x13, 0x55, 0xbf, 0x63, 0xdd, 0xcc, 0x33, 0x73, 0x26, 0x07, 0x66, 0x7a,
+	0xa8, 0x4b, 0x6e, 0x46, 0x5e, 0x84, 0xbc, 0x3f, 0x7e, 0x13, 0xdb, 0x51, 0x84, 0xb1, 0x3c, 0xe1,
+	0xe8, 0xca, 0xaa, 0x2a, 0xbb, 0xc4, 0x56, 0x9e, 0x99, 0xe7, 0xf2, 0xe0, 0x5c, 0x6f, 0xff, 0x0f,
+	0x9d, 0xe8, 0x22, 0x4f, 0x7c, 0xfb, 0x1d, 0xa1, 0xed, 0xca, 0xa4, 0xf1, 0x4a, 0x58, 0xdd, 0xf7,
+	0x75, 0x0f, 0x34, 0xec, 0x9a, 0x56, 0xae, 0x4e, 0x6f, 0xd1, 0xca, 0xf5, 0x34, 0x6c, 0xba, 0x43,
+	0xae, 0xb8, 0xe8, 0x84, 0xa3, 0x28, 0x16, 0x65, 0x57, 0x4e, 0x2d, 0x53, 0x77, 0x35, 0xcf, 0xcc,
+	0x69, 0x27, 0x4c, 0x43, 0xcd, 0x20, 0xd5, 0x0c, 0x4d, 0x05, 0x91, 0xcb, 0x98, 0x86, 0xe8, 0x3d,
+	0xb2, 0xde, 0x5c, 0x87, 0x6c, 0xa8, 0x1b, 0x79, 0x66, 0x36, 0x5d, 0xd0, 0x04, 0xb8, 0x5c, 0x9c,
+	0xa5, 0xfb, 0x69, 0xe4, 0x7b, 0x8e, 0xcd, 0x70, 0xd2, 0x4f, 0x85, 0xbc, 0xe1, 0x82, 0x26, 0xc0,
+	0xe5, 0x51, 0xa3, 0x71, 0x92, 0x52, 0xde, 0x70, 0x41, 0x13, 0xa0, 0x11, 0xd9, 0x2a, 0x12, 0x3b,
+	0xa3, 0xb5, 0xa9, 0x46, 0xfc, 0x56, 0x9e, 0x99, 0xaf, 0xe4, 0xc2, 0x2b, 0x19, 0xf4, 0x8c, 0xbc,
+	0x59, 0xcd, 0xe1, 0xac, 0x49, 0x65, 0x7b, 0x7e, 0x3b, 0xcf, 0xcc, 0x8b, 0xd0, 0xe1, 0x22, 0xa4,
+	0xfe, 0x5f, 0x1d, 0xa2, 0x8b, 0x2b, 0x31, 0xaf, 0xf1, 0x28, 0xaf, 0x33, 0xbb, 0x61, 0x1a, 0xd4,
+	0x3a, 0x4c, 0x15, 0x87, 0x9a, 0xc5, 0x1b, 0x2c, 0x4e, 0x2e, 0x41, 0xc7, 0x29, 0x26, 0x4c, 0x55,
+	0x4a, 0x5d, 0x36, 0xd8, 0xa6, 0x0f, 0xa6, 0x10, 0xfa, 0x21, 0x59, 0x55, 0x98, 0x28, 0xde, 0xf2,
+	0x62, 0xaa, 0x5b, 0x57, 0xf2, 0xcc, 0xac, 0x3b, 0xa0, 0x6e, 0x72, 0xa1, 0xb8, 0x49, 0x03, 0x3a,
+	0xe8, 0x9d, 0x14, 0xd7, 0x50, 0x21, 0xac, 0x39, 0xa0, 0x6e, 0xf2, 0x0b, 0xa5, 0x00, 0x44, 0x4b,
+	0x92, 0xc7, 0x4b, 0x5c, 0x28, 0x0b, 0x10, 0xca, 0x21, 0xbf, 0xa7, 0xc6, 0x72, 0xad, 0xf2, 0x2c,
+	0xe9, 0xf2, 0x9e, 0x3a, 0xc1, 0xa0, 0x18, 0xf1, 0x04, 0xba, 0xd5, 0x12, 0xbf, 0x58, 0x36, 0xc9,
+	0x2a, 0x0e, 0x35, 0x8b, 0x9f, 0x37, 0x51, 0x8e, 0xf7, 0x30, 0x18, 0xb2, 0xa3, 0x7d, 0x8c, 0x4f,
+	0x8a, 0xdb, 0xa7, 0x38, 0x6f, 0x53, 0x4e, 0x98, 0x86, 0x2c, 0x7c, 0xfa, 0xbc, 0x37, 0xf7, 0xec,
+	0x79, 0x6f, 0xee, 0xe5, 0xf3, 0x9e, 0xf6, 0xd3, 0xb8, 0xa7, 0xfd, 0x36, 0xee, 0x69, 0x4f, 0xc6,
+	0x3d, 0xed, 0xe9, 0xb8, 0xa7, 0xfd, 0x3d, 0xee, 0x69, 0xff, 0x8c, 0x7b, 0x73, 0x2f, 0xc7, 0x3d,
+	0xed, 0xe7, 0x17, 0xbd, 0xb9, 0xa7, 0x2f, 0x7a, 0x73, 0xcf, 0x5e, 0xf4, 0xe6, 0xbe, 0x1b, 0x0c,
+	0x3d, 0x76, 0x94, 0x1e, 0x6c, 0x3b, 0xe1, 0x68, 0x30, 0x8c, 0xed, 0x43, 0x3b, 0xb0, 0x07, 0x7e,
+	0xf8, 0xd8, 0x1b, 0x9c, 0xdc, 0x1d, 0xb4, 0xfd, 0xe7, 0x70, 0xd0, 0x15, 0xff, 0x28, 0xdc, 0xfd,
+	0x7f, 0x00, 0x3e, 0x28, 0xd6, 0x8c, 0x92, 0x10, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -1099,6 +1109,9 @@ func (this *Summary) Equal(that interface{}) bool {
 	if this.TotalStructuredMetadataBytesProcessed != that1.TotalStructuredMetadataBytesProcessed {
 		return false
 	}
+	if this.Approximate != that1.Approximate {
+		return false
+	}
 	return true
 }
 func (this *Index) Equal(that interface{}) bool {
@@ -1364,7 +1377,7 @@ func (this *Summary) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 16)
+	s := make([]string, 0, 17)
 	s = append(s, "&stats.Summary{")
 	s = append(s, "BytesProcessedPerSecond: "+fmt.Sprintf("%#v", this.BytesProcessedPerSecond)+",\n")
 	s = append(s, "LinesProcessedPerSecond: "+fmt.Sprintf("%#v", this.LinesProcessedPerSecond)+",\n")
@@ -1378,6 +1391,7 @@ func (this *Summary) GoString() string {
 	s = append(s, "Shards: "+fmt.Sprintf("%#v", this.Shards)+",\n")
 	s = append(s, "TotalPostFilterLines: "+fmt.Sprintf("%#v", this.TotalPostFilterLines)+",\n")
 	s = append(s, "TotalStructuredMetadataBytesProcessed: "+fmt.Sprintf("%#v", this.TotalStructuredMetadataBytesProcessed)+",\n")
+	s = append(s, "Approximate: "+fmt.Sprintf("%#v", this.Approximate)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1673,6 +1687,16 @@ func (m *Summary) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Approximate {
+		i--
+		if m.Approximate {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x68
+	}
 	if m.TotalStructuredMetadataBytesProcessed != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.TotalStructuredMetadataBytesProcessed))
 		i--
@@ -2163,6 +2187,9 @@ func (m *Summary) Size() (n int) {
 	if m.TotalStructuredMetadataBytesProcessed != 0 {
 		n += 1 + sovStats(uint64(m.TotalStructuredMetadataBytesProcessed))
 	}
+	if m.Approximate {
+		n += 2
+	}
 	return n
 }
 
@@ -2373,6 +2400,7 @@ func (this *Summary) String() string {
 		`Shards:` + fmt.Sprintf("%v", this.Shards) + `,`,
 		`TotalPostFilterLines:` + fmt.Sprintf("%v", this.TotalPostFilterLines) + `,`,
 		`TotalStructuredMetadataBytesProcessed:` + fmt.Sprintf("%v", this.TotalStructuredMetadataBytesProcessed) + `,`,
+		`Approximate:` + fmt.Sprintf("%v", this.Approximate) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3249,6 +3277,26 @@ func (m *Summary) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Approximate", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Approximate = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
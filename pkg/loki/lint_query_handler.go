@@ -0,0 +1,181 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/storage/stores/index/stats"
+	"github.com/grafana/loki/v3/pkg/util"
+	"github.com/grafana/loki/v3/pkg/util/server"
+	"github.com/grafana/loki/v3/pkg/util/validation"
+)
+
+// lintQueryEstimator is the subset of the querier used to estimate the cost of a linted query.
+type lintQueryEstimator interface {
+	IndexStats(ctx context.Context, req *loghttp.RangeQuery) (*stats.Stats, error)
+	IndexShards(ctx context.Context, req *loghttp.RangeQuery, targetBytesPerShard uint64) (*logproto.ShardsResponse, error)
+}
+
+type lintQueryLimits interface {
+	TSDBMaxBytesPerShard(userID string) int
+}
+
+// frontendLintQueryEstimator estimates the cost of a linted query with the
+// index stats and shards requests of the query frontend, which are forwarded
+// to the queriers.
+type frontendLintQueryEstimator struct {
+	next queryrangebase.Handler
+}
+
+func (e frontendLintQueryEstimator) IndexStats(ctx context.Context, req *loghttp.RangeQuery) (*stats.Stats, error) {
+	from, through := util.RoundToMilliseconds(req.Start, req.End)
+	resp, err := e.next.Do(ctx, &logproto.IndexStatsRequest{
+		From:     from,
+		Through:  through,
+		Matchers: req.Query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	statsResp, ok := resp.(*queryrange.IndexStatsResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected index stats response type %T", resp)
+	}
+	return statsResp.Response, nil
+}
+
+func (e frontendLintQueryEstimator) IndexShards(ctx context.Context, req *loghttp.RangeQuery, targetBytesPerShard uint64) (*logproto.ShardsResponse, error) {
+	from, through := util.RoundToMilliseconds(req.Start, req.End)
+	resp, err := e.next.Do(ctx, &logproto.ShardsRequest{
+		From:                from,
+		Through:             through,
+		Query:               req.Query,
+		TargetBytesPerShard: targetBytesPerShard,
+	})
+	if err != nil {
+		return nil, err
+	}
+	shardsResp, ok := resp.(*queryrange.ShardsResponse)
+	if !ok {
+		return nil, fmt.Errorf("unexpected index shards response type %T", resp)
+	}
+	return shardsResp.Response, nil
+}
+
+// lintQueryHandler returns the handler of the lint endpoint. Cost estimates are
+// computed by the querier or through the query frontend, when the process runs
+// one of them.
+func (t *Loki) lintQueryHandler() http.Handler {
+	var estimator lintQueryEstimator
+	switch {
+	case t.Querier != nil:
+		estimator = t.Querier
+	case t.lintQueryEstimator != nil:
+		estimator = t.lintQueryEstimator
+	default:
+		return lintQueryHandler(nil, nil)
+	}
+	return t.HTTPAuthMiddleware.Wrap(lintQueryHandler(estimator, t.Overrides))
+}
+
+func lintQueryHandler(estimator lintQueryEstimator, limits lintQueryLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			statusCode = http.StatusOK
+			status     = "success"
+			data       *loghttp.LintQueryData
+			errStr     string
+		)
+
+		query := r.FormValue("query")
+		expr, err := syntax.ParseExpr(query)
+		if err != nil {
+			statusCode = http.StatusBadRequest
+			status = "invalid-query"
+			errStr = err.Error()
+		}
+
+		if err == nil {
+			data = &loghttp.LintQueryData{
+				Findings: syntax.LintExpr(query, expr),
+			}
+			if data.Findings == nil {
+				data.Findings = []syntax.LintFinding{}
+			}
+			if estimator != nil {
+				data.Estimate, err = estimateQueryCost(r, expr, estimator, limits)
+				if err != nil {
+					data.Warnings = append(data.Warnings, fmt.Sprintf("unable to estimate query cost: %s", err))
+				}
+			}
+		}
+
+		resp := loghttp.LintQueryResponse{
+			Status: status,
+			Data:   data,
+			Err:    errStr,
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(statusCode)
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			server.WriteError(err, w)
+		}
+	}
+}
+
+// estimateQueryCost sums the index stats and shards of every matcher group of
+// the query over the requested time range, widened by their range and offset.
+func estimateQueryCost(r *http.Request, expr syntax.Expr, estimator lintQueryEstimator, limits lintQueryLimits) (*loghttp.LintQueryEstimate, error) {
+	req, err := loghttp.ParseIndexStatsQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		return nil, err
+	}
+	targetBytesPerShard := validation.SmallestPositiveIntPerTenant(tenantIDs, limits.TSDBMaxBytesPerShard)
+
+	groups, err := syntax.MatcherGroups(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	estimate := &loghttp.LintQueryEstimate{}
+	for _, g := range groups {
+		groupReq := &loghttp.RangeQuery{
+			Start: req.Start.Add(-g.Interval - g.Offset),
+			End:   req.End.Add(-g.Offset),
+			Query: syntax.MatchersString(g.Matchers),
+		}
+
+		s, err := estimator.IndexStats(r.Context(), groupReq)
+		if err != nil {
+			return nil, err
+		}
+		estimate.Streams += s.Streams
+		estimate.Chunks += s.Chunks
+		estimate.Entries += s.Entries
+		estimate.Bytes += s.Bytes
+
+		shards, err := estimator.IndexShards(r.Context(), groupReq, uint64(targetBytesPerShard))
+		if err != nil {
+			return nil, err
+		}
+		estimate.Shards += len(shards.Shards)
+	}
+
+	return estimate, nil
+}
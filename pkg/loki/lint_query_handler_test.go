@@ -0,0 +1,143 @@
+package loki
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/storage/stores/index/stats"
+)
+
+type fakeLintQueryEstimator struct {
+	requests []*loghttp.RangeQuery
+}
+
+func (f *fakeLintQueryEstimator) IndexStats(_ context.Context, req *loghttp.RangeQuery) (*stats.Stats, error) {
+	f.requests = append(f.requests, req)
+	return &stats.Stats{Streams: 1, Chunks: 2, Entries: 3, Bytes: 4}, nil
+}
+
+func (f *fakeLintQueryEstimator) IndexShards(_ context.Context, _ *loghttp.RangeQuery, _ uint64) (*logproto.ShardsResponse, error) {
+	return &logproto.ShardsResponse{Shards: []logproto.Shard{{}, {}}}, nil
+}
+
+type fakeLintQueryLimits struct{}
+
+func (fakeLintQueryLimits) TSDBMaxBytesPerShard(_ string) int { return 1 << 20 }
+
+func Test_lintQueryHandler(t *testing.T) {
+	start := time.Unix(3600, 0)
+	end := time.Unix(7200, 0)
+	query := `sum(rate({app="foo"} |~ "error" [5m] offset 1m)) / sum(rate({app="bar"}[1m]))`
+
+	estimator := &fakeLintQueryEstimator{}
+	params := url.Values{
+		"query": []string{query},
+		"start": []string{start.Format(time.RFC3339Nano)},
+		"end":   []string{end.Format(time.RFC3339Nano)},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/lint?"+params.Encode(), nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "fake"))
+
+	w := httptest.NewRecorder()
+	lintQueryHandler(estimator, fakeLintQueryLimits{})(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var got loghttp.LintQueryResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+
+	require.Equal(t, "success", got.Status)
+	require.Len(t, got.Data.Findings, 1)
+	require.Equal(t, syntax.LintRuleRegexpLiteral, got.Data.Findings[0].Rule)
+	require.Empty(t, got.Data.Warnings)
+	require.Equal(t, &loghttp.LintQueryEstimate{Streams: 2, Chunks: 4, Entries: 6, Bytes: 8, Shards: 4}, got.Data.Estimate)
+
+	require.Len(t, estimator.requests, 2)
+	require.Equal(t, `{app="foo"}`, estimator.requests[0].Query)
+	require.True(t, start.Add(-6*time.Minute).Equal(estimator.requests[0].Start))
+	require.True(t, end.Add(-time.Minute).Equal(estimator.requests[0].End))
+	require.Equal(t, `{app="bar"}`, estimator.requests[1].Query)
+	require.True(t, start.Add(-time.Minute).Equal(estimator.requests[1].Start))
+	require.True(t, end.Equal(estimator.requests[1].End))
+}
+
+func Test_lintQueryHandler_WithoutEstimator(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		query      string
+		statusCode int
+		expected   loghttp.LintQueryResponse
+	}{
+		{
+			name:       "happy-path",
+			query:      `{foo="bar"}`,
+			statusCode: http.StatusOK,
+			expected: loghttp.LintQueryResponse{
+				Status: "success",
+				Data:   &loghttp.LintQueryData{Findings: []syntax.LintFinding{}},
+			},
+		},
+		{
+			name:       "invalid-query",
+			query:      `{foo="bar}`,
+			statusCode: http.StatusBadRequest,
+			expected: loghttp.LintQueryResponse{
+				Status: "invalid-query",
+				Err:    "parse error at line 1, col 6: literal not terminated",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/lint?"+url.Values{"query": []string{tc.query}}.Encode(), nil)
+
+			w := httptest.NewRecorder()
+			lintQueryHandler(nil, nil)(w, req)
+			require.Equal(t, tc.statusCode, w.Code)
+
+			var got loghttp.LintQueryResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+			require.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func Test_frontendLintQueryEstimator(t *testing.T) {
+	var requests []queryrangebase.Request
+	estimator := frontendLintQueryEstimator{next: queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		requests = append(requests, r)
+		switch r.(type) {
+		case *logproto.IndexStatsRequest:
+			return &queryrange.IndexStatsResponse{Response: &logproto.IndexStatsResponse{Streams: 1, Chunks: 2, Entries: 3, Bytes: 4}}, nil
+		case *logproto.ShardsRequest:
+			return &queryrange.ShardsResponse{Response: &logproto.ShardsResponse{Shards: []logproto.Shard{{}, {}}}}, nil
+		}
+		return nil, fmt.Errorf("unexpected request %T", r)
+	})}
+
+	req := &loghttp.RangeQuery{Start: time.Unix(3600, 0), End: time.Unix(7200, 0), Query: `{app="foo"}`}
+	s, err := estimator.IndexStats(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, &stats.Stats{Streams: 1, Chunks: 2, Entries: 3, Bytes: 4}, s)
+
+	shards, err := estimator.IndexShards(context.Background(), req, 1<<20)
+	require.NoError(t, err)
+	require.Len(t, shards.Shards, 2)
+
+	require.Equal(t, []queryrangebase.Request{
+		&logproto.IndexStatsRequest{From: 3600 * 1000, Through: 7200 * 1000, Matchers: `{app="foo"}`},
+		&logproto.ShardsRequest{From: 3600 * 1000, Through: 7200 * 1000, Query: `{app="foo"}`, TargetBytesPerShard: 1 << 20},
+	}, requests)
+}
@@ -253,6 +253,9 @@ func (c *Config) Validate() error {
 	if err := c.Querier.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid querier config"))
 	}
+	if err := c.Frontend.Validate(); err != nil {
+		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid frontend config"))
+	}
 	if err := c.QueryScheduler.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid query_scheduler config"))
 	}
@@ -375,6 +378,7 @@ type Loki struct {
 	MemberlistKV              *memberlist.KVInitService
 	compactor                 *compactor.Compactor
 	QueryFrontEndMiddleware   queryrangebase.Middleware
+	lintQueryEstimator        lintQueryEstimator
 	queryScheduler            *scheduler.Scheduler
 	querySchedulerRingManager *lokiring.RingManager
 	usageReport               *analytics.Reporter
@@ -526,6 +530,7 @@ func (t *Loki) Run(opts RunOpts) error {
 
 	t.Server.HTTP.Path("/debug/fgprof").Methods("GET", "POST").Handler(fgprof.Handler())
 	t.Server.HTTP.Path("/loki/api/v1/format_query").Methods("GET", "POST").HandlerFunc(formatQueryHandler())
+	t.Server.HTTP.Path("/loki/api/v1/lint").Methods("GET", "POST").Handler(t.lintQueryHandler())
 
 	// Let's listen for events from this manager, and log them.
 	logHook := func(msg, key string) func() {
@@ -56,6 +56,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v1/frontendv1pb"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v2/frontendv2pb"
+	"github.com/grafana/loki/v3/pkg/macros"
 	"github.com/grafana/loki/v3/pkg/pattern"
 	"github.com/grafana/loki/v3/pkg/querier"
 	querierrf1 "github.com/grafana/loki/v3/pkg/querier-rf1"
@@ -67,6 +68,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/scheduler"
 	"github.com/grafana/loki/v3/pkg/scheduler/schedulerpb"
 	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/bucket"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
@@ -1112,7 +1114,9 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		level.Debug(util_log.Logger).Log("msg", "no query frontend configured")
 	}
 
-	roundTripper := queryrange.NewSerializeRoundTripper(t.QueryFrontEndMiddleware.Wrap(frontendTripper), queryrange.DefaultCodec)
+	queryHandler := t.QueryFrontEndMiddleware.Wrap(frontendTripper)
+	roundTripper := queryrange.NewSerializeRoundTripper(queryHandler, queryrange.DefaultCodec)
+	t.lintQueryEstimator = frontendLintQueryEstimator{next: queryHandler}
 
 	frontendHandler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
 	if t.Cfg.Frontend.CompressResponses {
@@ -1134,6 +1138,14 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		toMerge = append(toMerge, querylimits.NewQueryLimitsMiddleware(logger))
 	}
 
+	if t.Cfg.Frontend.Macros.Enabled {
+		macrosMiddleware, err := t.initMacros()
+		if err != nil {
+			return nil, err
+		}
+		toMerge = append(toMerge, macrosMiddleware)
+	}
+
 	frontendHandler = middleware.Merge(toMerge...).Wrap(frontendHandler)
 
 	var defaultHandler http.Handler
@@ -1221,6 +1233,28 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	}), nil
 }
 
+// initMacros registers the macro management API and returns the middleware
+// expanding the macros referenced in queries.
+func (t *Loki) initMacros() (middleware.Interface, error) {
+	cfg := t.Cfg.Frontend.Macros
+	bucketClient, err := bucket.NewClient(context.Background(), cfg.Storage, "macros-storage", util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create macros store: %w", err)
+	}
+
+	store := macros.NewBucketStore(bucketClient, nil, cfg.MaxVersions)
+	registry := macros.NewRegistry(store, cfg.CacheTTL)
+	api := macros.NewAPI(store, registry, util_log.Logger)
+
+	t.Server.HTTP.Path("/loki/api/v1/macros").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.ListMacros)))
+	t.Server.HTTP.Path("/loki/api/v1/macros/{name}").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.GetMacro)))
+	t.Server.HTTP.Path("/loki/api/v1/macros/{name}").Methods("POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.SetMacro)))
+	t.Server.HTTP.Path("/loki/api/v1/macros/{name}").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.DeleteMacro)))
+	t.Server.HTTP.Path("/loki/api/v1/macros/{name}/versions").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(api.GetMacroVersions)))
+
+	return macros.Middleware(registry), nil
+}
+
 func (t *Loki) initRulerStorage() (_ services.Service, err error) {
 	// if the ruler is not configured and we're in single binary then let's just log an error and continue.
 	// unfortunately there is no way to generate a "default" config and compare default against actual
@@ -1409,6 +1443,7 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	}
 
 	objectClients := make(map[config.DayTime]client.ObjectClient)
+	coldObjectClients := make(map[config.DayTime]client.ObjectClient)
 	for _, periodConfig := range t.Cfg.SchemaConfig.Configs {
 		if !config.IsObjectStorageIndex(periodConfig.IndexType) {
 			continue
@@ -1420,6 +1455,14 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		}
 
 		objectClients[periodConfig.From] = objectClient
+
+		if periodConfig.ColdStorage.Enabled() {
+			coldClient, err := storage.NewObjectClient(periodConfig.ColdStorage.ObjectType, t.Cfg.StorageConfig, t.ClientMetrics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create cold storage object client: %w", err)
+			}
+			coldObjectClients[periodConfig.From] = coldClient
+		}
 	}
 
 	var deleteRequestStoreClient client.ObjectClient
@@ -1433,7 +1476,7 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		}
 	}
 
-	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, objectClients, deleteRequestStoreClient, t.Cfg.SchemaConfig, t.Overrides, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
+	t.compactor, err = compactor.NewCompactor(t.Cfg.CompactorConfig, objectClients, coldObjectClients, deleteRequestStoreClient, t.Cfg.SchemaConfig, t.Overrides, prometheus.DefaultRegisterer, t.Cfg.MetricsNamespace)
 	if err != nil {
 		return nil, err
 	}
@@ -1451,6 +1494,11 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetAllDeleteRequestsHandler))
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("DELETE").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.CancelDeleteRequestHandler))
 		t.Server.HTTP.Path("/loki/api/v1/cache/generation_numbers").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetCacheGenerationNumberHandler))
+		// legal holds do not depend on the deletion mode since they also preserve data from retention.
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("PUT", "POST").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.LegalHoldHandler.AddLegalHoldHandler)))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.LegalHoldHandler.GetAllLegalHoldsHandler)))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("DELETE").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.LegalHoldHandler.ReleaseLegalHoldHandler)))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold/events").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(http.HandlerFunc(t.compactor.LegalHoldHandler.GetLegalHoldEventsHandler)))
 		grpc.RegisterCompactorServer(t.Server.GRPC, t.compactor.DeleteRequestsGRPCHandler)
 	}
 
@@ -1815,7 +1863,11 @@ func (t *Loki) createRulerQueryEngine(logger log.Logger) (eng *logql.Engine, err
 		return nil, fmt.Errorf("could not create querier: %w", err)
 	}
 
-	return logql.NewEngine(t.Cfg.Querier.Engine, q, t.Overrides, logger), nil
+	// rules must not be evaluated against approximated results.
+	engineOpts := t.Cfg.Querier.Engine
+	engineOpts.ApproximateFromIndex = false
+
+	return logql.NewEngine(engineOpts, q, t.Overrides, logger), nil
 }
 
 func calculateMaxLookBack(pc config.PeriodConfig, maxLookBackConfig, minDuration time.Duration) (time.Duration, error) {
@@ -381,6 +381,8 @@ func minimalWorkingConfig(t *testing.T, dir, target string, cfgTransformers ...f
 		},
 	}
 
+	cfg.Ingester.WAL.Dir = filepath.Join(dir, "wal")
+
 	// Disable some caches otherwise we'll get errors if we don't configure them
 	cfg.QueryRange.CacheLabelResults = false
 	cfg.QueryRange.CacheSeriesResults = false
@@ -84,7 +84,7 @@ overrides:
               period: 24h
               priority: 10
 `))
-	require.Equal(t, "invalid override for tenant 29: invalid labels matchers: parse error at line 1, col 6: syntax error: unexpected IDENTIFIER, expecting STRING", err.Error())
+	require.Equal(t, `invalid override for tenant 29: invalid retention_stream rule 0 with selector "{app=foo\"}": invalid selector: parse error at line 1, col 6: syntax error: unexpected IDENTIFIER, expecting STRING`, err.Error())
 	_, err = loadRuntimeConfig(strings.NewReader(
 		`
 overrides:
@@ -94,7 +94,7 @@ overrides:
               period: 5h
               priority: 10
 `))
-	require.Equal(t, "invalid override for tenant 29: retention period must be >= 24h was 5h", err.Error())
+	require.Equal(t, `invalid override for tenant 29: invalid retention_stream rule 0 with selector "{app=\"foo\"}": invalid period: retention period must be >= 24h was 5h`, err.Error())
 }
 
 func Test_DefaultConfig(t *testing.T) {
@@ -4,10 +4,12 @@ import (
 	"flag"
 
 	"github.com/grafana/dskit/crypto/tls"
+	"github.com/pkg/errors"
 
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
 	v1 "github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v1"
 	v2 "github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v2"
+	"github.com/grafana/loki/v3/pkg/macros"
 )
 
 type Config struct {
@@ -20,6 +22,8 @@ type Config struct {
 
 	TailProxyURL string           `yaml:"tail_proxy_url"`
 	TLS          tls.ClientConfig `yaml:"tail_tls_config"`
+
+	Macros macros.Config `yaml:"macros" doc:"description=Configures the per-tenant LogQL macros expanded by the query frontend."`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -28,8 +32,17 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.FrontendV1.RegisterFlags(f)
 	cfg.FrontendV2.RegisterFlags(f)
 	cfg.TLS.RegisterFlagsWithPrefix("frontend.tail-tls-config", f)
+	cfg.Macros.RegisterFlagsWithPrefix("frontend.macros.", f)
 
 	f.BoolVar(&cfg.CompressResponses, "querier.compress-http-responses", true, "Compress HTTP responses.")
 	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Loki.")
 	f.StringVar(&cfg.TailProxyURL, "frontend.tail-proxy-url", "", "URL of querier for tail proxy.")
 }
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if err := cfg.Macros.Validate(); err != nil {
+		return errors.Wrap(err, "invalid macros config")
+	}
+	return nil
+}
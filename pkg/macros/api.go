@@ -0,0 +1,241 @@
+package macros
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/tenant"
+	"gopkg.in/yaml.v3"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// API is used to handle the HTTP requests managing the macros of the tenants.
+type API struct {
+	store    Store
+	registry *Registry
+
+	logger log.Logger
+}
+
+// NewAPI returns a new API managing the macros of store. Changed macros are
+// invalidated from the cache of registry.
+func NewAPI(store Store, registry *Registry, logger log.Logger) *API {
+	return &API{
+		store:    store,
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// ListMacros returns the latest version of all the macros of the tenant.
+func (a *API) ListMacros(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	macros, err := a.store.ListMacros(req.Context(), userID)
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to list macros", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	marshalAndSend(MacroList{Macros: macros}, w, logger)
+}
+
+// GetMacro returns a macro, in its latest version unless the version
+// parameter is set.
+func (a *API) GetMacro(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, name, err := parseRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version := 0
+	if v := req.URL.Query().Get("version"); v != "" {
+		if version, err = strconv.Atoi(v); err != nil || version < 1 {
+			http.Error(w, fmt.Sprintf("invalid version %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if version == 0 {
+		m, err := a.store.GetMacro(req.Context(), userID, name)
+		if err != nil {
+			respondStoreError(logger, w, err)
+			return
+		}
+		marshalAndSend(m, w, logger)
+		return
+	}
+
+	versions, err := a.store.GetMacroVersions(req.Context(), userID, name)
+	if err != nil {
+		respondStoreError(logger, w, err)
+		return
+	}
+	for _, m := range versions {
+		if m.Version == version {
+			marshalAndSend(m, w, logger)
+			return
+		}
+	}
+	http.Error(w, fmt.Sprintf("version %d of macro %q does not exist", version, name), http.StatusNotFound)
+}
+
+// GetMacroVersions returns the retained versions of a macro, latest first.
+func (a *API) GetMacroVersions(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, name, err := parseRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	versions, err := a.store.GetMacroVersions(req.Context(), userID, name)
+	if err != nil {
+		respondStoreError(logger, w, err)
+		return
+	}
+	marshalAndSend(MacroList{Macros: versions}, w, logger)
+}
+
+// SetMacro creates or updates a macro from a YAML payload and returns the
+// stored macro. If the payload has a version, it must be the latest version
+// of the macro, otherwise the request fails with a 409 status code.
+func (a *API) SetMacro(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, name, err := parseRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	logger = log.With(logger, "macro", name, "userID", userID)
+
+	payload, err := io.ReadAll(req.Body)
+	if err != nil {
+		level.Error(logger).Log("msg", "unable to read macro payload", "err", err.Error())
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var m Macro
+	if err := yaml.Unmarshal(payload, &m); err != nil {
+		http.Error(w, fmt.Sprintf("unable to unmarshal macro payload: %s", err), http.StatusBadRequest)
+		return
+	}
+	if m.Name == "" {
+		m.Name = name
+	}
+	if m.Name != name {
+		http.Error(w, fmt.Sprintf("macro name %q doesn't match the name of the URL %q", m.Name, name), http.StatusBadRequest)
+		return
+	}
+
+	// Referenced macros are resolved from the store, so that recently
+	// changed macros are validated against their latest version.
+	err = m.Validate(func(name string) (string, error) {
+		ref, err := a.store.GetMacro(req.Context(), userID, name)
+		if err != nil {
+			return "", err
+		}
+		return ref.Expression, nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	stored, err := a.store.SetMacro(req.Context(), userID, m)
+	if err != nil {
+		respondStoreError(logger, w, err)
+		return
+	}
+	a.registry.Invalidate(userID, name)
+
+	level.Info(logger).Log("msg", "stored macro", "version", stored.Version)
+	marshalAndSend(stored, w, logger)
+}
+
+// DeleteMacro deletes all the versions of a macro.
+func (a *API) DeleteMacro(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+	userID, name, err := parseRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := a.store.DeleteMacro(req.Context(), userID, name); err != nil {
+		respondStoreError(logger, w, err)
+		return
+	}
+	a.registry.Invalidate(userID, name)
+
+	level.Info(logger).Log("msg", "deleted macro", "macro", name, "userID", userID)
+	respondAccepted(w, logger)
+}
+
+func parseRequest(req *http.Request) (string, string, error) {
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil {
+		return "", "", err
+	}
+	name := mux.Vars(req)["name"]
+	if err := ValidateName(name); err != nil {
+		return "", "", err
+	}
+	return userID, name, nil
+}
+
+func respondStoreError(logger log.Logger, w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrMacroNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrVersionMismatch):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		level.Error(logger).Log("msg", "macro store request failed", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func marshalAndSend(output interface{}, w http.ResponseWriter, logger log.Logger) {
+	d, err := yaml.Marshal(output)
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshalling yaml macros", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(d); err != nil {
+		level.Error(logger).Log("msg", "error writing yaml response", "err", err)
+	}
+}
+
+func respondAccepted(w http.ResponseWriter, logger log.Logger) {
+	b, err := json.Marshal(map[string]string{"status": "success"})
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if n, err := w.Write(b); err != nil {
+		level.Error(logger).Log("msg", "error writing response", "bytesWritten", n, "err", err)
+	}
+}
@@ -0,0 +1,139 @@
+package macros
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestRouter(api *API) *mux.Router {
+	router := mux.NewRouter()
+	router.Path("/loki/api/v1/macros").Methods("GET").HandlerFunc(api.ListMacros)
+	router.Path("/loki/api/v1/macros/{name}").Methods("GET").HandlerFunc(api.GetMacro)
+	router.Path("/loki/api/v1/macros/{name}").Methods("POST").HandlerFunc(api.SetMacro)
+	router.Path("/loki/api/v1/macros/{name}").Methods("DELETE").HandlerFunc(api.DeleteMacro)
+	router.Path("/loki/api/v1/macros/{name}/versions").Methods("GET").HandlerFunc(api.GetMacroVersions)
+	return router
+}
+
+func doRequest(t *testing.T, handler http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func TestAPI(t *testing.T) {
+	store := newTestStore(10)
+	router := newTestRouter(NewAPI(store, NewRegistry(store, time.Minute), log.NewNopLogger()))
+
+	w := doRequest(t, router, "POST", "/loki/api/v1/macros/nginx", "expression: json | line_format \"{{.msg}}\"\ndescription: nginx access logs\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var m Macro
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &m))
+	require.Equal(t, Macro{
+		Name:        "nginx",
+		Description: "nginx access logs",
+		Expression:  `json | line_format "{{.msg}}"`,
+		Version:     1,
+		UpdatedAt:   time.Unix(0, 0).UTC(),
+	}, m)
+
+	w = doRequest(t, router, "POST", "/loki/api/v1/macros/nginx", "expression: logfmt\nversion: 1\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	for _, tc := range []struct {
+		name, body string
+		status     int
+	}{
+		{name: "nginx", body: "expression: logfmt\nversion: 1\n", status: http.StatusConflict},
+		{name: "nginx", body: "name: other\nexpression: logfmt\n", status: http.StatusBadRequest},
+		{name: "nginx", body: "expression: logfmt |\n", status: http.StatusBadRequest},
+		{name: "nginx", body: "expression: json | @unknown\n", status: http.StatusBadRequest},
+		{name: "nginx-2", body: "expression: logfmt\n", status: http.StatusBadRequest},
+	} {
+		w = doRequest(t, router, "POST", "/loki/api/v1/macros/"+tc.name, tc.body)
+		require.Equal(t, tc.status, w.Code, w.Body.String())
+	}
+
+	w = doRequest(t, router, "POST", "/loki/api/v1/macros/nginx_errors", "expression: \"@nginx | status >= 500\"\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doRequest(t, router, "GET", "/loki/api/v1/macros", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list MacroList
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Macros, 2)
+	require.Equal(t, "logfmt", list.Macros[0].Expression)
+
+	w = doRequest(t, router, "GET", "/loki/api/v1/macros/nginx?version=1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &m))
+	require.Equal(t, `json | line_format "{{.msg}}"`, m.Expression)
+
+	w = doRequest(t, router, "GET", "/loki/api/v1/macros/nginx?version=3", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doRequest(t, router, "GET", "/loki/api/v1/macros/nginx/versions", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Macros, 2)
+	require.Equal(t, 2, list.Macros[0].Version)
+
+	w = doRequest(t, router, "DELETE", "/loki/api/v1/macros/nginx", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	w = doRequest(t, router, "GET", "/loki/api/v1/macros/nginx", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	w = doRequest(t, router, "DELETE", "/loki/api/v1/macros/nginx", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMiddleware(t *testing.T) {
+	store := newTestStore(10)
+	_, err := store.SetMacro(context.Background(), "user", Macro{Name: "nginx", Expression: "json"})
+	require.NoError(t, err)
+	registry := NewRegistry(store, time.Minute)
+
+	var query string
+	handler := Middleware(registry).Wrap(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		query = req.Form.Get("query")
+	}))
+
+	w := doRequest(t, handler, "GET", "/loki/api/v1/query_range?query="+url.QueryEscape(`{app="foo"} | @nginx`), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `{app="foo"} | json`, query)
+
+	req := httptest.NewRequest("POST", "/loki/api/v1/query", strings.NewReader("query="+url.QueryEscape(`{app="foo"} | @nginx`)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `{app="foo"} | json`, query)
+
+	// Cached macros are used until they are invalidated.
+	_, err = store.SetMacro(context.Background(), "user", Macro{Name: "nginx", Expression: "logfmt"})
+	require.NoError(t, err)
+	doRequest(t, handler, "GET", "/loki/api/v1/query?query="+url.QueryEscape(`{app="foo"} | @nginx`), "")
+	require.Equal(t, `{app="foo"} | json`, query)
+	registry.Invalidate("user", "nginx")
+	doRequest(t, handler, "GET", "/loki/api/v1/query?query="+url.QueryEscape(`{app="foo"} | @nginx`), "")
+	require.Equal(t, `{app="foo"} | logfmt`, query)
+
+	w = doRequest(t, handler, "GET", "/loki/api/v1/query?query="+url.QueryEscape(`{app="foo"} | @unknown`), "")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, `unknown macro "unknown"`, w.Body.String())
+}
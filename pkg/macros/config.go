@@ -0,0 +1,39 @@
+package macros
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/grafana/loki/v3/pkg/storage/bucket"
+)
+
+// Config configures the macros of the query frontend.
+type Config struct {
+	Enabled     bool          `yaml:"enabled"`
+	Storage     bucket.Config `yaml:"storage" doc:"description=The bucket the macros are stored in, under the macros/ prefix."`
+	CacheTTL    time.Duration `yaml:"cache_ttl"`
+	MaxVersions int           `yaml:"max_versions"`
+}
+
+// RegisterFlagsWithPrefix registers flags with the given prefix.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the expansion of the macros referenced in queries as '| @name' and the macro management API.")
+	cfg.Storage.RegisterFlagsWithPrefix(prefix+"storage.", f)
+	f.DurationVar(&cfg.CacheTTL, prefix+"cache-ttl", time.Minute, "How long the macros are cached by the query frontend before being fetched again from the store.")
+	f.IntVar(&cfg.MaxVersions, prefix+"max-versions", 10, "Maximum number of versions kept for each macro. 0 to keep all the versions.")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if cfg.Enabled {
+		if err := cfg.Storage.Validate(); err != nil {
+			return errors.Wrap(err, "invalid macros storage config")
+		}
+	}
+	if cfg.MaxVersions < 0 {
+		return errors.New("max_versions must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+// Package macros implements per-tenant LogQL macros: named pipeline fragments
+// referenced in queries as `{app="foo"} | @name` and expanded by the query
+// frontend before the query is parsed.
+package macros
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+)
+
+// maxExpansionDepth is the maximum number of nested macro references.
+const maxExpansionDepth = 10
+
+var (
+	nameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+	// ErrInvalidName is returned for macro names which are not valid identifiers.
+	ErrInvalidName = errors.New("invalid macro name, it must match " + nameRegexp.String())
+	// ErrEmptyExpression is returned for macros without a pipeline.
+	ErrEmptyExpression = errors.New("macro expression can't be empty")
+)
+
+// Macro is a named LogQL pipeline fragment, e.g. `json | line_format "{{.msg}}"`.
+type Macro struct {
+	Name        string    `yaml:"name" json:"name"`
+	Description string    `yaml:"description,omitempty" json:"description,omitempty"`
+	Expression  string    `yaml:"expression" json:"expression"`
+	Version     int       `yaml:"version,omitempty" json:"version,omitempty"`
+	UpdatedAt   time.Time `yaml:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// MacroList is the format of the macro files and of the list responses of the API.
+type MacroList struct {
+	Macros []Macro `yaml:"macros" json:"macros"`
+}
+
+// LookupFunc returns the expression of the macro with the given name, or
+// ErrMacroNotFound if it doesn't exist.
+type LookupFunc func(name string) (string, error)
+
+// ValidateName returns an error if name isn't a valid macro name.
+func ValidateName(name string) error {
+	if !nameRegexp.MatchString(name) {
+		return fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	return nil
+}
+
+// Validate checks the macro has a valid name and its expression, once the
+// macros it references are expanded using lookup, is a valid log pipeline.
+func (m Macro) Validate(lookup LookupFunc) error {
+	if err := ValidateName(m.Name); err != nil {
+		return err
+	}
+	expr := normalize(m.Expression)
+	if expr == "" {
+		return ErrEmptyExpression
+	}
+
+	expanded, err := expand(expr, lookup, []string{m.Name}, true)
+	if err != nil {
+		return err
+	}
+	if _, err := syntax.ParseLogSelector(`{__macro__="`+m.Name+`"} | `+expanded, true); err != nil {
+		return fmt.Errorf("invalid macro %q: %w", m.Name, err)
+	}
+	return nil
+}
+
+// Expand replaces every macro reference `| @name` of the query with the
+// expression of the macro returned by lookup. Macros can reference other
+// macros, up to a depth of 10.
+func Expand(query string, lookup LookupFunc) (string, error) {
+	return expand(query, lookup, nil, false)
+}
+
+// expand expands the macro references of query. Macro expressions start
+// after a pipe, which is set by afterPipe.
+func expand(query string, lookup LookupFunc, path []string, afterPipe bool) (string, error) {
+	var (
+		sb strings.Builder
+		// last is the end of the part of the query already copied to sb.
+		last     int
+		lastPipe = afterPipe
+	)
+	for i := 0; i < len(query); i++ {
+		switch c := query[i]; {
+		case c == '"' || c == '`':
+			i = skipString(query, i)
+			lastPipe = false
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '|':
+			lastPipe = true
+		case c == '@' && lastPipe:
+			end := i + 1
+			for end < len(query) && isNameChar(query[end]) {
+				end++
+			}
+			name := query[i+1 : end]
+			if ValidateName(name) != nil {
+				// Not a macro reference, let the parser report the error.
+				lastPipe = false
+				continue
+			}
+
+			expr, err := resolve(name, lookup, path)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(query[last:i])
+			sb.WriteString(expr)
+			last = end
+			i = end - 1
+			lastPipe = false
+		default:
+			lastPipe = false
+		}
+	}
+	if sb.Len() == 0 {
+		return query, nil
+	}
+	sb.WriteString(query[last:])
+	return sb.String(), nil
+}
+
+// resolve returns the expression of the macro name, with its own macro
+// references expanded.
+func resolve(name string, lookup LookupFunc, path []string) (string, error) {
+	for _, p := range path {
+		if p == name {
+			return "", fmt.Errorf("macro reference cycle: %s -> %s", strings.Join(path, " -> "), name)
+		}
+	}
+	if len(path) >= maxExpansionDepth {
+		return "", fmt.Errorf("macro %q exceeds the maximum nesting depth of %d", name, maxExpansionDepth)
+	}
+
+	expr, err := lookup(name)
+	if err != nil {
+		if errors.Is(err, ErrMacroNotFound) {
+			return "", fmt.Errorf("unknown macro %q", name)
+		}
+		return "", err
+	}
+	expr, err = expand(normalize(expr), lookup, append(path, name), true)
+	if err != nil {
+		return "", err
+	}
+	// A trailing comment would swallow the rest of the query.
+	if i := strings.LastIndexByte(expr, '\n'); strings.ContainsRune(expr[i+1:], '#') {
+		expr += "\n"
+	}
+	return expr, nil
+}
+
+// normalize trims the optional leading pipe of a macro expression, so that
+// both `json` and `| json` can be used.
+func normalize(expr string) string {
+	return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(expr), "|"))
+}
+
+// skipString returns the index of the closing quote of the string starting at i.
+func skipString(s string, i int) int {
+	quote := s[i]
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return i
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,136 @@
+package macros
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func lookupMap(macros map[string]string) LookupFunc {
+	return func(name string) (string, error) {
+		expr, ok := macros[name]
+		if !ok {
+			return "", ErrMacroNotFound
+		}
+		return expr, nil
+	}
+}
+
+func TestExpand(t *testing.T) {
+	lookup := lookupMap(map[string]string{
+		"nginx":   `| json | line_format "{{.msg}}"`,
+		"errors":  `level="error"`,
+		"nested":  `@nginx | @errors`,
+		"comment": "logfmt # parse the line",
+		"cycle_a": `@cycle_b`,
+		"cycle_b": `json | @cycle_a`,
+	})
+
+	for _, tc := range []struct {
+		query    string
+		expected string
+		err      string
+	}{
+		{
+			query:    `{app="foo"}`,
+			expected: `{app="foo"}`,
+		},
+		{
+			query:    `{app="foo"} | @nginx`,
+			expected: `{app="foo"} | json | line_format "{{.msg}}"`,
+		},
+		{
+			query:    `sum(rate({app="foo"} |@nginx|@errors [5m] @ 1234))`,
+			expected: `sum(rate({app="foo"} |json | line_format "{{.msg}}"|level="error" [5m] @ 1234))`,
+		},
+		{
+			query:    `{app="foo"} | @nested`,
+			expected: `{app="foo"} | json | line_format "{{.msg}}" | level="error"`,
+		},
+		{
+			query:    `{app="foo"} |= "| @nginx" |= ` + "`| @nginx`" + ` # | @nginx`,
+			expected: `{app="foo"} |= "| @nginx" |= ` + "`| @nginx`" + ` # | @nginx`,
+		},
+		{
+			query:    `{app="foo"} |= "\"| @nginx" | @errors`,
+			expected: `{app="foo"} |= "\"| @nginx" | level="error"`,
+		},
+		{
+			query:    `{app="foo"} | @comment | @errors`,
+			expected: "{app=\"foo\"} | logfmt # parse the line\n | level=\"error\"",
+		},
+		{
+			query:    `{app="foo"} | @1nginx`,
+			expected: `{app="foo"} | @1nginx`,
+		},
+		{
+			query: `{app="foo"} | @unknown`,
+			err:   `unknown macro "unknown"`,
+		},
+		{
+			query: `{app="foo"} | @cycle_a`,
+			err:   `macro reference cycle: cycle_a -> cycle_b -> cycle_a`,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			actual, err := Expand(tc.query, lookup)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestExpandMaxDepth(t *testing.T) {
+	lookup := func(name string) (string, error) {
+		return "@" + name + "_", nil
+	}
+	_, err := Expand(`{app="foo"} | @m`, lookup)
+	require.EqualError(t, err, `macro "m__________" exceeds the maximum nesting depth of 10`)
+}
+
+func TestMacroValidate(t *testing.T) {
+	lookup := lookupMap(map[string]string{
+		"nginx": `json | line_format "{{.msg}}"`,
+	})
+
+	for _, tc := range []struct {
+		macro Macro
+		err   string
+	}{
+		{
+			macro: Macro{Name: "errors", Expression: `| logfmt | level="error"`},
+		},
+		{
+			macro: Macro{Name: "nginx_errors", Expression: `@nginx | status >= 500`},
+		},
+		{
+			macro: Macro{Name: "nginx-errors", Expression: `logfmt`},
+			err:   `invalid macro name, it must match ^[a-zA-Z_][a-zA-Z0-9_]*$: "nginx-errors"`,
+		},
+		{
+			macro: Macro{Name: "empty", Expression: ` | `},
+			err:   ErrEmptyExpression.Error(),
+		},
+		{
+			macro: Macro{Name: "invalid", Expression: `logfmt |`},
+			err:   `invalid macro "invalid": parse error at line 1, col 33: syntax error: unexpected $end`,
+		},
+		{
+			macro: Macro{Name: "self", Expression: `json | @self`},
+			err:   `macro reference cycle: self -> self`,
+		},
+	} {
+		t.Run(tc.macro.Name, func(t *testing.T) {
+			err := tc.macro.Validate(lookup)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
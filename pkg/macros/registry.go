@@ -0,0 +1,131 @@
+package macros
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/grafana/dskit/middleware"
+	"github.com/grafana/dskit/tenant"
+
+	serverutil "github.com/grafana/loki/v3/pkg/util/server"
+)
+
+type cacheKey struct {
+	userID, name string
+}
+
+type cacheEntry struct {
+	expression string
+	fetched    time.Time
+}
+
+// Registry resolves the macros of the tenants, caching them for a TTL so
+// that queries don't hit the store.
+type Registry struct {
+	store Store
+	ttl   time.Duration
+
+	mtx   sync.Mutex
+	cache map[cacheKey]cacheEntry
+
+	now func() time.Time
+}
+
+// NewRegistry returns a Registry caching the macros of store for ttl.
+func NewRegistry(store Store, ttl time.Duration) *Registry {
+	return &Registry{
+		store: store,
+		ttl:   ttl,
+		cache: map[cacheKey]cacheEntry{},
+		now:   time.Now,
+	}
+}
+
+// Lookup returns a LookupFunc resolving the macros of userID.
+func (r *Registry) Lookup(ctx context.Context, userID string) LookupFunc {
+	return func(name string) (string, error) {
+		key := cacheKey{userID: userID, name: name}
+
+		r.mtx.Lock()
+		entry, ok := r.cache[key]
+		r.mtx.Unlock()
+		if ok && r.now().Sub(entry.fetched) < r.ttl {
+			return entry.expression, nil
+		}
+
+		m, err := r.store.GetMacro(ctx, userID, name)
+		if err != nil {
+			if errors.Is(err, ErrMacroNotFound) {
+				r.Invalidate(userID, name)
+			}
+			return "", err
+		}
+
+		r.mtx.Lock()
+		r.cache[key] = cacheEntry{expression: m.Expression, fetched: r.now()}
+		r.mtx.Unlock()
+		return m.Expression, nil
+	}
+}
+
+// Invalidate removes a macro from the cache. Other instances keep using their
+// cached version until it expires.
+func (r *Registry) Invalidate(userID, name string) {
+	r.mtx.Lock()
+	delete(r.cache, cacheKey{userID: userID, name: name})
+	r.mtx.Unlock()
+}
+
+// Middleware expands the macros referenced by the query parameter of the
+// requests, so that the handlers it wraps only see plain LogQL.
+func Middleware(registry *Registry) middleware.Interface {
+	return middleware.Func(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			// Errors are left to the wrapped handler to report.
+			if err := req.ParseForm(); err != nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+			query := req.Form.Get("query")
+			if !strings.Contains(query, "@") {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			status := http.StatusBadRequest
+			expanded, err := Expand(query, func(name string) (string, error) {
+				tenants, err := tenant.TenantIDs(req.Context())
+				if err != nil {
+					return "", err
+				}
+				if len(tenants) != 1 {
+					return "", errors.New("macros can't be used in multi-tenant queries")
+				}
+				expr, err := registry.Lookup(req.Context(), tenants[0])(name)
+				if err != nil && !errors.Is(err, ErrMacroNotFound) {
+					status = http.StatusInternalServerError
+				}
+				return expr, err
+			})
+			if err != nil {
+				serverutil.WriteError(httpgrpc.Errorf(status, "%s", err.Error()), w)
+				return
+			}
+
+			req.Form.Set("query", expanded)
+			if req.PostForm.Has("query") {
+				req.PostForm.Set("query", expanded)
+			}
+			if values := req.URL.Query(); values.Has("query") {
+				values.Set("query", expanded)
+				req.URL.RawQuery = values.Encode()
+			}
+			next.ServeHTTP(w, req)
+		})
+	})
+}
@@ -0,0 +1,233 @@
+package macros
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thanos-io/objstore"
+
+	"github.com/grafana/loki/v3/pkg/storage/bucket"
+)
+
+var (
+	// ErrMacroNotFound is returned if a macro does not exist.
+	ErrMacroNotFound = errors.New("macro does not exist")
+	// ErrVersionMismatch is returned when updating a macro from a version
+	// which is not the latest one.
+	ErrVersionMismatch = errors.New("macro version is not the latest one")
+)
+
+// Store is used to store and retrieve the macros of the tenants.
+type Store interface {
+	// ListMacros returns the latest version of all the macros of a user.
+	ListMacros(ctx context.Context, userID string) ([]Macro, error)
+
+	// GetMacro returns the latest version of a macro.
+	GetMacro(ctx context.Context, userID, name string) (*Macro, error)
+
+	// GetMacroVersions returns the versions of a macro, latest first.
+	GetMacroVersions(ctx context.Context, userID, name string) ([]Macro, error)
+
+	// SetMacro stores a new version of a macro and returns it. If the version
+	// of m is set, it must be the latest version of the macro, otherwise
+	// ErrVersionMismatch is returned. The check is best-effort: it isn't atomic
+	// with the write across the processes sharing the store.
+	SetMacro(ctx context.Context, userID string, m Macro) (*Macro, error)
+
+	// DeleteMacro deletes all the versions of a macro.
+	DeleteMacro(ctx context.Context, userID, name string) error
+}
+
+// Bucket Macro Storage Schema
+// =======================
+// Object Name: "macros/<user_id>/<macro_name>"
+// Storage Format: JSON encoded storedMacro
+//
+// Macro names are valid identifiers so, unlike rule groups, they don't need
+// to be encoded to be used in object names.
+
+// The bucket prefix under which all tenants macros are stored.
+const macrosPrefix = "macros"
+
+// storedMacro holds the versions of a macro, oldest first.
+type storedMacro struct {
+	Versions []Macro `json:"versions"`
+}
+
+// BucketStore stores macros in an object storage bucket, keeping a bounded
+// number of versions of each macro. It is implemented using the Thanos
+// objstore.Bucket interface, like the ruler bucket store.
+//
+// Object storages don't provide conditional writes through this interface,
+// so updates are only serialized within a BucketStore: concurrent updates
+// of a macro through different query frontends can override each other.
+type BucketStore struct {
+	bucket      objstore.Bucket
+	cfgProvider bucket.TenantConfigProvider
+	maxVersions int
+
+	// mtx serializes the read-modify-write cycles of the macros.
+	mtx sync.Mutex
+
+	now func() time.Time
+}
+
+// NewBucketStore returns a new BucketStore. The cfgProvider can be nil.
+func NewBucketStore(bkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, maxVersions int) *BucketStore {
+	return &BucketStore{
+		bucket:      bucket.NewPrefixedBucketClient(bkt, macrosPrefix),
+		cfgProvider: cfgProvider,
+		maxVersions: maxVersions,
+		now:         time.Now,
+	}
+}
+
+func (s *BucketStore) userBucket(userID string) objstore.InstrumentedBucket {
+	return bucket.NewUserBucketClient(userID, s.bucket, s.cfgProvider)
+}
+
+func (s *BucketStore) get(ctx context.Context, userBucket objstore.BucketReader, name string) (*storedMacro, error) {
+	reader, err := userBucket.Get(ctx, name)
+	if userBucket.IsObjNotFoundErr(err) {
+		return nil, fmt.Errorf("%w: %s", ErrMacroNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get macro %s: %w", name, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macro %s: %w", name, err)
+	}
+
+	var stored storedMacro
+	if err := json.Unmarshal(buf, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal macro %s: %w", name, err)
+	}
+	if len(stored.Versions) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMacroNotFound, name)
+	}
+	return &stored, nil
+}
+
+// ListMacros implements Store.
+func (s *BucketStore) ListMacros(ctx context.Context, userID string) ([]Macro, error) {
+	userBucket := s.userBucket(userID)
+
+	var names []string
+	err := userBucket.Iter(ctx, "", func(name string) error {
+		if !strings.HasSuffix(name, objstore.DirDelim) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	macros := make([]Macro, 0, len(names))
+	for _, name := range names {
+		stored, err := s.get(ctx, userBucket, name)
+		if errors.Is(err, ErrMacroNotFound) {
+			// Deleted since it was listed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		macros = append(macros, stored.Versions[len(stored.Versions)-1])
+	}
+	sort.Slice(macros, func(i, j int) bool { return macros[i].Name < macros[j].Name })
+	return macros, nil
+}
+
+// GetMacro implements Store.
+func (s *BucketStore) GetMacro(ctx context.Context, userID, name string) (*Macro, error) {
+	stored, err := s.get(ctx, s.userBucket(userID), name)
+	if err != nil {
+		return nil, err
+	}
+	return &stored.Versions[len(stored.Versions)-1], nil
+}
+
+// GetMacroVersions implements Store.
+func (s *BucketStore) GetMacroVersions(ctx context.Context, userID, name string) ([]Macro, error) {
+	stored, err := s.get(ctx, s.userBucket(userID), name)
+	if err != nil {
+		return nil, err
+	}
+	versions := make([]Macro, 0, len(stored.Versions))
+	for i := len(stored.Versions) - 1; i >= 0; i-- {
+		versions = append(versions, stored.Versions[i])
+	}
+	return versions, nil
+}
+
+// SetMacro implements Store. Storing a macro identical to its latest version
+// doesn't create a new version.
+func (s *BucketStore) SetMacro(ctx context.Context, userID string, m Macro) (*Macro, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	userBucket := s.userBucket(userID)
+	stored, err := s.get(ctx, userBucket, m.Name)
+	switch {
+	case errors.Is(err, ErrMacroNotFound):
+		stored = &storedMacro{}
+	case err != nil:
+		return nil, err
+	}
+
+	latestVersion := 0
+	if n := len(stored.Versions); n > 0 {
+		latest := stored.Versions[n-1]
+		latestVersion = latest.Version
+		if m.Version == 0 && latest.Expression == m.Expression && latest.Description == m.Description {
+			return &latest, nil
+		}
+	}
+	if m.Version != 0 && m.Version != latestVersion {
+		return nil, fmt.Errorf("%w: macro %q is at version %d, not %d", ErrVersionMismatch, m.Name, latestVersion, m.Version)
+	}
+
+	m.Version = latestVersion + 1
+	m.UpdatedAt = s.now().UTC()
+	stored.Versions = append(stored.Versions, m)
+	if s.maxVersions > 0 && len(stored.Versions) > s.maxVersions {
+		stored.Versions = stored.Versions[len(stored.Versions)-s.maxVersions:]
+	}
+
+	buf, err := json.Marshal(stored)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal macro %s: %w", m.Name, err)
+	}
+	if err := userBucket.Upload(ctx, m.Name, bytes.NewReader(buf)); err != nil {
+		return nil, fmt.Errorf("failed to store macro %s: %w", m.Name, err)
+	}
+	return &m, nil
+}
+
+// DeleteMacro implements Store.
+func (s *BucketStore) DeleteMacro(ctx context.Context, userID, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	userBucket := s.userBucket(userID)
+	// Not all object stores fail to delete objects which don't exist.
+	if _, err := s.get(ctx, userBucket, name); err != nil {
+		return err
+	}
+	if err := userBucket.Delete(ctx, name); err != nil && !userBucket.IsObjNotFoundErr(err) {
+		return fmt.Errorf("failed to delete macro %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package macros
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+)
+
+func newTestStore(maxVersions int) *BucketStore {
+	s := NewBucketStore(objstore.NewInMemBucket(), nil, maxVersions)
+	s.now = func() time.Time { return time.Unix(0, 0) }
+	return s
+}
+
+func TestBucketStore(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	s := NewBucketStore(bkt, nil, 2)
+	s.now = func() time.Time { return time.Unix(0, 0) }
+
+	_, err := s.GetMacro(ctx, "user", "nginx")
+	require.ErrorIs(t, err, ErrMacroNotFound)
+
+	m, err := s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "json"})
+	require.NoError(t, err)
+	require.Equal(t, &Macro{Name: "nginx", Expression: "json", Version: 1, UpdatedAt: time.Unix(0, 0).UTC()}, m)
+	exists, err := bkt.Exists(ctx, "macros/user/nginx")
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Storing the same macro doesn't create a new version.
+	m, err = s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "json"})
+	require.NoError(t, err)
+	require.Equal(t, 1, m.Version)
+
+	_, err = s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "logfmt", Version: 2})
+	require.ErrorIs(t, err, ErrVersionMismatch)
+
+	m, err = s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "logfmt", Version: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Version)
+	_, err = s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "pattern `<_> <status>`"})
+	require.NoError(t, err)
+	_, err = s.SetMacro(ctx, "user", Macro{Name: "errors", Expression: `level="error"`})
+	require.NoError(t, err)
+	_, err = s.SetMacro(ctx, "other", Macro{Name: "errors", Expression: `level="error"`})
+	require.NoError(t, err)
+
+	m, err = s.GetMacro(ctx, "user", "nginx")
+	require.NoError(t, err)
+	require.Equal(t, "pattern `<_> <status>`", m.Expression)
+	require.Equal(t, 3, m.Version)
+
+	// Only the 2 latest versions are kept.
+	versions, err := s.GetMacroVersions(ctx, "user", "nginx")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	require.Equal(t, 3, versions[0].Version)
+	require.Equal(t, 2, versions[1].Version)
+	require.Equal(t, "logfmt", versions[1].Expression)
+
+	macros, err := s.ListMacros(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, macros, 2)
+	require.Equal(t, "errors", macros[0].Name)
+	require.Equal(t, "nginx", macros[1].Name)
+
+	require.NoError(t, s.DeleteMacro(ctx, "user", "nginx"))
+	require.ErrorIs(t, s.DeleteMacro(ctx, "user", "nginx"), ErrMacroNotFound)
+	_, err = s.GetMacroVersions(ctx, "user", "nginx")
+	require.ErrorIs(t, err, ErrMacroNotFound)
+
+	macros, err = s.ListMacros(ctx, "user")
+	require.NoError(t, err)
+	require.Len(t, macros, 1)
+	require.Equal(t, "errors", macros[0].Name)
+}
+
+func TestBucketStore_ConcurrentSetMacro(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(0)
+	_, err := s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: "json"})
+	require.NoError(t, err)
+
+	// Only one of the updates from the same version succeeds.
+	var (
+		wg        sync.WaitGroup
+		succeeded atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := s.SetMacro(ctx, "user", Macro{Name: "nginx", Expression: fmt.Sprintf("json | line=%d", i), Version: 1})
+			if err == nil {
+				succeeded.Inc()
+				return
+			}
+			require.ErrorIs(t, err, ErrVersionMismatch)
+		}(i)
+	}
+	wg.Wait()
+	require.Equal(t, int32(1), succeeded.Load())
+
+	m, err := s.GetMacro(ctx, "user", "nginx")
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Version)
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/axiomhq/hyperloglog"
@@ -39,6 +40,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/storage/stores/index"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index/seriesvolume"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index/stats"
+	tsdb_index "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
 	listutil "github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/httpreq"
 	"github.com/grafana/loki/v3/pkg/util/spanlogger"
@@ -255,6 +257,99 @@ func (q *SingleTenantQuerier) SelectSamples(ctx context.Context, params logql.Se
 	return iter.NewMergeSampleIterator(ctx, iters), nil
 }
 
+// SelectChunkStats implements logql.ChunkStatsQuerier. It serves the statistics kept
+// in the index for the chunks matching the request. They are reported unavailable
+// whenever part of the range is served by ingesters, is affected by delete requests
+// or is covered by an index that does not keep chunk statistics.
+func (q *SingleTenantQuerier) SelectChunkStats(ctx context.Context, params logql.SelectSampleParams) ([]logql.StreamChunkStats, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Start, params.End, err = q.validateQueryRequest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ingesterQueryInterval, storeQueryInterval := q.buildQueryIntervals(params.Start, params.End)
+	if (!q.cfg.QueryStoreOnly && ingesterQueryInterval != nil) || q.cfg.QueryIngesterOnly || storeQueryInterval == nil {
+		return nil, logql.ErrChunkStatsUnavailable
+	}
+
+	deletes, err := q.deletesForUser(ctx, params.Start, params.End)
+	if err != nil {
+		return nil, err
+	}
+	if len(deletes) > 0 {
+		return nil, logql.ErrChunkStatsUnavailable
+	}
+
+	from, through := model.TimeFromUnixNano(params.Start.UnixNano()), model.TimeFromUnixNano(params.End.UnixNano())
+	forSeries, ok := q.store.HasForSeries(from, through)
+	if !ok {
+		return nil, logql.ErrChunkStatsUnavailable
+	}
+
+	selector, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	var fpFilter tsdb_index.FingerprintFilter
+	shards, _, err := logql.ParseShards(params.Shards)
+	if err != nil {
+		return nil, err
+	}
+	if len(shards) > 1 {
+		return nil, errors.New("only one shard per chunk stats query is supported")
+	}
+	if len(shards) == 1 {
+		fpFilter = &shards[0]
+	}
+
+	var (
+		mtx     sync.Mutex
+		streams = map[model.Fingerprint]*logql.StreamChunkStats{}
+		seen    = map[model.Fingerprint]map[uint32]struct{}{}
+	)
+	err = forSeries.ForSeries(ctx, userID, fpFilter, from, through, func(lbls labels.Labels, fp model.Fingerprint, chks []tsdb_index.ChunkMeta) (stop bool) {
+		mtx.Lock()
+		defer mtx.Unlock()
+
+		stream, ok := streams[fp]
+		if !ok {
+			// the labels and chunks are reused by the index once the callback returns.
+			stream = &logql.StreamChunkStats{Labels: lbls.Copy()}
+			streams[fp] = stream
+			seen[fp] = map[uint32]struct{}{}
+		}
+		for _, chk := range chks {
+			// the same chunk can be referenced by several index files.
+			if _, ok := seen[fp][chk.Checksum]; ok {
+				continue
+			}
+			seen[fp][chk.Checksum] = struct{}{}
+			stream.Chunks = append(stream.Chunks, logql.ChunkStats{
+				From:    model.Time(chk.MinTime),
+				Through: model.Time(chk.MaxTime),
+				Entries: uint64(chk.Entries),
+				Bytes:   uint64(chk.KB) << 10,
+			})
+		}
+		return false
+	}, selector.Matchers()...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]logql.StreamChunkStats, 0, len(streams))
+	for _, stream := range streams {
+		result = append(result, *stream)
+	}
+	return result, nil
+}
+
 func (q *SingleTenantQuerier) deletesForUser(ctx context.Context, startT, endT time.Time) ([]*logproto.Delete, error) {
 	userID, err := tenant.TenantID(ctx)
 	if err != nil {
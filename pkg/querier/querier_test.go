@@ -16,6 +16,7 @@ import (
 	ring_client "github.com/grafana/dskit/ring/client"
 	"github.com/grafana/dskit/user"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -29,6 +30,8 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/storage"
+	tsdb_index "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/sharding"
 	"github.com/grafana/loki/v3/pkg/util/constants"
 	"github.com/grafana/loki/v3/pkg/validation"
 )
@@ -1361,6 +1364,120 @@ func TestQuerier_SelectSamplesWithDeletes(t *testing.T) {
 	require.Equal(t, "test", delGetter.user)
 }
 
+type forSeriesStoreMock struct {
+	*storeMock
+	forSeries sharding.ForSeries
+}
+
+func (s forSeriesStoreMock) HasForSeries(_, _ model.Time) (sharding.ForSeries, bool) {
+	return s.forSeries, s.forSeries != nil
+}
+
+func TestQuerier_SelectChunkStats(t *testing.T) {
+	lbls := labels.FromStrings("foo", "bar")
+	chunks := []tsdb_index.ChunkMeta{
+		{MinTime: 0, MaxTime: 1000, Entries: 10, KB: 2, Checksum: 1},
+		{MinTime: 1000, MaxTime: 2000, Entries: 5, KB: 1, Checksum: 2},
+	}
+	// both index files reference the first chunk.
+	forSeries := sharding.ForSeriesFunc(func(_ context.Context, _ string, _ tsdb_index.FingerprintFilter, _, _ model.Time, fn func(labels.Labels, model.Fingerprint, []tsdb_index.ChunkMeta) bool, _ ...*labels.Matcher) error {
+		fn(lbls, model.Fingerprint(lbls.Hash()), chunks[:1])
+		fn(lbls, model.Fingerprint(lbls.Hash()), chunks)
+		return nil
+	})
+
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	newRequest := func(start, end time.Time) logql.SelectSampleParams {
+		return logql.SelectSampleParams{SampleQueryRequest: &logproto.SampleQueryRequest{
+			Selector: `count_over_time({foo="bar"}[1h])`,
+			Start:    start,
+			End:      end,
+			Plan: &plan.QueryPlan{
+				AST: syntax.MustParseExpr(`count_over_time({foo="bar"}[1h])`),
+			},
+		}}
+	}
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	for _, tc := range []struct {
+		name      string
+		forSeries sharding.ForSeries
+		deletes   []deletion.DeleteRequest
+		req       logql.SelectSampleParams
+		expected  []logql.StreamChunkStats
+		err       error
+		errMsg    string
+	}{
+		{
+			name:      "store only",
+			forSeries: forSeries,
+			req:       newRequest(time.Unix(0, 0), time.Unix(3600, 0)),
+			expected: []logql.StreamChunkStats{
+				{
+					Labels: lbls,
+					Chunks: []logql.ChunkStats{
+						{From: 0, Through: 1000, Entries: 10, Bytes: 2048},
+						{From: 1000, Through: 2000, Entries: 5, Bytes: 1024},
+					},
+				},
+			},
+		},
+		{
+			name:      "within ingesters range",
+			forSeries: forSeries,
+			req:       newRequest(time.Now().Add(-time.Hour), time.Now()),
+			err:       logql.ErrChunkStatsUnavailable,
+		},
+		{
+			name:      "with deletes",
+			forSeries: forSeries,
+			deletes:   []deletion.DeleteRequest{{Query: `{foo="bar"}`, StartTime: 0, EndTime: 1000}},
+			req:       newRequest(time.Unix(0, 0), time.Unix(3600, 0)),
+			err:       logql.ErrChunkStatsUnavailable,
+		},
+		{
+			name: "index without chunk stats",
+			req:  newRequest(time.Unix(0, 0), time.Unix(3600, 0)),
+			err:  logql.ErrChunkStatsUnavailable,
+		},
+		{
+			name:      "multiple shards",
+			forSeries: forSeries,
+			req: func() logql.SelectSampleParams {
+				req := newRequest(time.Unix(0, 0), time.Unix(3600, 0))
+				req.Shards = []string{"0_of_2", "1_of_2"}
+				return req
+			}(),
+			errMsg: "only one shard per chunk stats query is supported",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := mockQuerierConfig()
+			cfg.QueryIngestersWithin = 3 * time.Hour
+
+			q, err := newQuerier(
+				cfg,
+				mockIngesterClientConfig(),
+				newIngesterClientMockFactory(newQuerierClientMock()),
+				mockReadRingWithOneActiveIngester(),
+				&mockDeleteGettter{results: tc.deletes},
+				forSeriesStoreMock{storeMock: newStoreMock(), forSeries: tc.forSeries},
+				limits)
+			require.NoError(t, err)
+
+			res, err := q.SelectChunkStats(ctx, tc.req)
+			if tc.errMsg != "" {
+				require.EqualError(t, err, tc.errMsg)
+				return
+			}
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
 func newQuerier(cfg Config, clientCfg client.Config, clientFactory ring_client.PoolFactory, ring ring.ReadRing, dg *mockDeleteGettter, store storage.Store, limits *validation.Overrides) (*SingleTenantQuerier, error) {
 	iq, err := newIngesterQuerier(clientCfg, ring, cfg.ExtraQueryDelay, clientFactory, constants.Loki, util_log.Logger)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/grafana/dskit/concurrency"
@@ -16,6 +17,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 )
@@ -106,28 +108,47 @@ type instance struct {
 }
 
 // withoutOffset returns the given query string with offsets removed and timestamp adjusted accordingly. If no offset is present in original query, it will be returned as is.
+// Queries whose subexpressions are shifted by different offsets (e.g. using `timeshift`) or which contain subqueries are also returned as is, since no single time range can replace them.
 func withoutOffset(query logql.DownstreamQuery) (string, time.Time, time.Time) {
 	expr := query.Params.GetExpression()
 
 	var (
 		newStart = query.Params.Start()
 		newEnd   = query.Params.End()
+
+		ranges      []*syntax.LogRange
+		hasSubquery bool
 	)
 	expr.Walk(func(e syntax.Expr) {
 		switch rng := e.(type) {
 		case *syntax.RangeAggregationExpr:
-			off := rng.Left.Offset
-
-			if off != 0 {
-				rng.Left.Offset = 0 // remove offset
+			ranges = append(ranges, rng.Left)
+		case *syntax.SubqueryExpr:
+			hasSubquery = true
+		}
+	})
+	// subqueries are evaluated at multiples of their resolution, shifting
+	// the query time range would change the evaluated samples.
+	if len(ranges) == 0 || hasSubquery {
+		return expr.String(), newStart, newEnd
+	}
 
-				// adjust start and end time
-				newEnd = newEnd.Add(-off)
-				newStart = newStart.Add(-off)
+	off := ranges[0].Offset
+	for _, rng := range ranges[1:] {
+		if rng.Offset != off {
+			return expr.String(), newStart, newEnd
+		}
+	}
 
-			}
+	if off != 0 {
+		for _, rng := range ranges {
+			rng.Offset = 0 // remove offset
 		}
-	})
+
+		// adjust start and end time
+		newEnd = newEnd.Add(-off)
+		newStart = newStart.Add(-off)
+	}
 	return expr.String(), newStart, newEnd
 }
 
@@ -144,7 +165,16 @@ func (in instance) Downstream(ctx context.Context, queries []logql.DownstreamQue
 		defer sp.Finish()
 		sp.LogKV("shards", fmt.Sprintf("%+v", qry.Params.Shards()), "query", req.GetQuery(), "start", req.GetStart(), "end", req.GetEnd(), "step", req.GetStep(), "handler", reflect.TypeOf(in.handler), "engine", "downstream")
 
+		node := explain.FromContext(ctx).Child(explain.TypeDownstream, req.GetQuery(), req.GetStart(), req.GetEnd())
+		if node != nil {
+			if shards := qry.Params.Shards(); len(shards) > 0 {
+				node.Set("shards", strings.Join(shards, ","))
+			}
+			ctx = explain.NewContext(ctx, node)
+		}
+
 		res, err := in.handler.Do(ctx, req)
+		explainResponse(node, res, err)
 		if err != nil {
 			return logqlmodel.Result{}, err
 		}
@@ -408,6 +408,41 @@ func TestParamsToLokiRequest(t *testing.T) {
 	}
 }
 
+func TestWithoutOffset(t *testing.T) {
+	start := time.Unix(0, 0).Add(24 * time.Hour)
+	end := start.Add(time.Hour)
+	for _, tc := range []struct {
+		query         string
+		expectedQuery string
+		expectedShift time.Duration
+	}{
+		{
+			query:         `rate({app="foo"}[5m])`,
+			expectedQuery: `rate({app="foo"}[5m])`,
+		},
+		{
+			query:         `sum(rate({app="foo"}[5m] offset 1h)) / sum(count_over_time({app="foo"}[5m] offset 1h))`,
+			expectedQuery: `(sum(rate({app="foo"}[5m])) / sum(count_over_time({app="foo"}[5m])))`,
+			expectedShift: time.Hour,
+		},
+		{
+			// different offsets can't be replaced by a single time range
+			query:         `sum(rate({app="foo"}[5m]) - timeshift(rate({app="foo"}[5m]), 1h))`,
+			expectedQuery: `sum((rate({app="foo"}[5m]) - rate({app="foo"}[5m] offset 1h0m0s)))`,
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			params, err := logql.NewLiteralParams(tc.query, start, end, time.Minute, 0, logproto.BACKWARD, 0, nil, nil)
+			require.NoError(t, err)
+
+			qs, newStart, newEnd := withoutOffset(logql.DownstreamQuery{Params: params})
+			require.Equal(t, tc.expectedQuery, qs)
+			require.Equal(t, start.Add(-tc.expectedShift), newStart)
+			require.Equal(t, end.Add(-tc.expectedShift), newEnd)
+		})
+	}
+}
+
 func TestInstanceDownstream(t *testing.T) {
 	t.Run("Downstream simple query", func(t *testing.T) {
 		ts := time.Unix(1, 0)
@@ -0,0 +1,82 @@
+package queryrange
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+const explainParam = "explain"
+
+// explainRequest returns the root node of the execution plan if the query
+// request asks for it with `explain=analyze`, nil otherwise.
+func explainRequest(r *http.Request, req queryrangebase.Request) *explain.Node {
+	if r.FormValue(explainParam) != explain.Parameter {
+		return nil
+	}
+	switch req.(type) {
+	case *LokiRequest, *LokiInstantRequest:
+		return explain.New(explain.TypeQuery, req.GetQuery(), req.GetStart(), req.GetEnd())
+	default:
+		return nil
+	}
+}
+
+// explainResponse records the outcome of a request on its plan node.
+func explainResponse(n *explain.Node, resp queryrangebase.Response, err error) {
+	if n == nil {
+		return
+	}
+	switch r := resp.(type) {
+	case *LokiResponse:
+		n.SetStats(r.Statistics)
+	case *LokiPromResponse:
+		n.SetStats(r.Statistics)
+	}
+	n.Finish(err)
+}
+
+// appendExplain adds the execution plan as the `explain` field of a JSON
+// encoded query response.
+func appendExplain(body []byte, n *explain.Node) ([]byte, error) {
+	end := bytes.LastIndexByte(body, '}')
+	if end < 0 {
+		return nil, errors.New("cannot add execution plan to a response which is not a JSON object")
+	}
+
+	n.Sort()
+	plan, err := jsonStd.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(body)+len(plan)+len(`,"explain":`))
+	out = append(out, body[:end]...)
+	out = append(out, `,"explain":`...)
+	out = append(out, plan...)
+	return append(out, body[end:]...), nil
+}
+
+func encodeExplainResponse(resp *http.Response, n *explain.Node) (*http.Response, error) {
+	if n == nil || resp.Header.Get("Content-Type") == ProtobufType {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	body, err = appendExplain(body, n)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
@@ -0,0 +1,97 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+func TestExplainAnalyze(t *testing.T) {
+	handler := queryrangebase.HandlerFunc(func(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		n := explain.FromContext(ctx).Child(explain.TypeSplit, r.GetQuery(), r.GetStart(), r.GetEnd())
+		n.Set("cache.results", "miss")
+		resp := &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: logproto.BACKWARD,
+			Limit:     100,
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+			},
+			Statistics: stats.Result{
+				Summary: stats.Summary{TotalBytesProcessed: 10, TotalLinesProcessed: 2},
+			},
+		}
+		explainResponse(n, resp, nil)
+		return resp, nil
+	})
+
+	for _, tc := range []struct {
+		name    string
+		explain string
+		plan    bool
+	}{
+		{name: "without explain", explain: "", plan: false},
+		{name: "with unknown explain mode", explain: "foo", plan: false},
+		{name: "with explain analyze", explain: explain.Parameter, plan: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			url := "/loki/api/v1/query_range?start=0&end=3600000000000&query=%7Bfoo%3D%22bar%22%7D&explain=" + tc.explain
+
+			check := func(t *testing.T, body []byte) {
+				var resp loghttp.QueryResponse
+				require.NoError(t, json.Unmarshal(body, &resp))
+				require.Equal(t, loghttp.QueryStatusSuccess, resp.Status)
+				if !tc.plan {
+					require.Nil(t, resp.Explain)
+					return
+				}
+
+				require.NotNil(t, resp.Explain)
+				require.Equal(t, explain.TypeQuery, resp.Explain.Type)
+				require.Equal(t, `{foo="bar"}`, resp.Explain.Query)
+				require.Equal(t, time.Unix(0, 0), resp.Explain.Start.Local())
+				require.Equal(t, time.Unix(3600, 0), resp.Explain.End.Local())
+				require.Equal(t, int64(10), resp.Explain.Stats.BytesProcessed)
+				require.Len(t, resp.Explain.Children, 1)
+				require.Equal(t, explain.TypeSplit, resp.Explain.Children[0].Type)
+				require.Equal(t, map[string]string{"cache.results": "miss"}, resp.Explain.Children[0].Attributes)
+				require.Equal(t, int64(2), resp.Explain.Children[0].Stats.LinesProcessed)
+			}
+
+			t.Run("http handler", func(t *testing.T) {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, url, nil)
+				req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+				NewSerializeHTTPHandler(handler, DefaultCodec).ServeHTTP(w, req)
+
+				require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+				check(t, w.Body.Bytes())
+			})
+
+			t.Run("round tripper", func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, url, nil)
+				req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+				resp, err := NewSerializeRoundTripper(handler, DefaultCodec).RoundTrip(req)
+				require.NoError(t, err)
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				check(t, body)
+			})
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/astmapper"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
@@ -167,7 +168,9 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 		return ast.next.Do(ctx, r)
 	}
 
-	conf, err := ast.confs.GetConf(int64(model.Time(r.GetStart().UnixMilli()).Add(-maxRVDuration).Add(-maxOffset)), int64(model.Time(r.GetEnd().UnixMilli()).Add(-maxOffset)))
+	minOffset := minOffsetDuration(params.GetExpression())
+
+	conf, err := ast.confs.GetConf(int64(model.Time(r.GetStart().UnixMilli()).Add(-maxRVDuration).Add(-maxOffset)), int64(model.Time(r.GetEnd().UnixMilli()).Add(-minOffset)))
 	// cannot shard with this timerange
 	if err != nil {
 		level.Warn(spLogger).Log("err", err.Error(), "msg", "skipped AST mapper for request")
@@ -233,6 +236,7 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 	// If the ast can't be mapped to a sharded equivalent,
 	// we can bypass the sharding engine and forward the request downstream.
 	if noop {
+		explain.FromContext(ctx).Set("sharding", "noop")
 		return ast.next.Do(ctx, r)
 	}
 
@@ -245,9 +249,16 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrangebase.Request) (que
 	default:
 		return nil, fmt.Errorf("expected *LokiRequest or *LokiInstantRequest, got (%T)", r)
 	}
+	shardMap := explain.FromContext(ctx).Child(explain.TypeShardMap, parsed.String(), r.GetStart(), r.GetEnd())
+	if shardMap != nil {
+		shardMap.Set("bytes_per_shard", humanize.IBytes(bytesPerShard))
+		ctx = explain.NewContext(ctx, shardMap)
+	}
+
 	query := ast.ng.Query(ctx, logql.ParamsWithExpressionOverride{Params: params, ExpressionOverride: parsed})
 
 	res, err := query.Exec(ctx)
+	shardMap.Finish(err)
 	if err != nil {
 		return nil, err
 	}
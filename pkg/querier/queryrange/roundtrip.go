@@ -812,6 +812,19 @@ func NewLabelsTripperware(
 	}), nil
 }
 
+// shouldCacheMetricQuery returns false for metric queries which opted out of caching or which are shifted forward in time.
+// A negative offset makes a result depend on data newer than its evaluation time, which the max cache freshness doesn't account for.
+func shouldCacheMetricQuery(r base.Request) bool {
+	if r.GetCachingOptions().Disabled {
+		return false
+	}
+	minOffset, err := minOffsetDurationFromQueryString(r.GetQuery())
+	if err != nil {
+		return false
+	}
+	return minOffset >= 0
+}
+
 // NewMetricTripperware creates a new frontend tripperware responsible for handling metric queries
 func NewMetricTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logger, limits Limits, schema config.SchemaConfig, merger base.Merger, iqo util.IngesterQueryOptions, c cache.Cache, cacheGenNumLoader base.CacheGenNumberLoader, retentionEnabled bool, extractor base.Extractor, metrics *Metrics, indexStatsTripperware base.Middleware, metricsNamespace string) (base.Middleware, error) {
 	cacheKey := cacheKeyLimits{limits, cfg.Transformer, iqo}
@@ -827,7 +840,7 @@ func NewMetricTripperware(cfg Config, engineOpts logql.EngineOpts, log log.Logge
 			extractor,
 			cacheGenNumLoader,
 			func(_ context.Context, r base.Request) bool {
-				return !r.GetCachingOptions().Disabled
+				return shouldCacheMetricQuery(r)
 			},
 			func(ctx context.Context, tenantIDs []string, r base.Request) int {
 				return MinWeightedParallelism(
@@ -956,7 +969,7 @@ func NewInstantMetricTripperware(
 			c,
 			cacheGenNumLoader,
 			func(_ context.Context, r base.Request) bool {
-				return !r.GetCachingOptions().Disabled
+				return shouldCacheMetricQuery(r)
 			},
 			func(ctx context.Context, tenantIDs []string, r base.Request) int {
 				return MinWeightedParallelism(
@@ -812,6 +812,28 @@ func TestVolumeTripperware(t *testing.T) {
 	})
 }
 
+func Test_shouldCacheMetricQuery(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		disabled bool
+		expected bool
+	}{
+		{query: `rate({app="foo"}[5m])`, expected: true},
+		{query: `rate({app="foo"}[5m])`, disabled: true, expected: false},
+		{query: `rate({app="foo"}[5m]) - timeshift(rate({app="foo"}[5m]), 1w)`, expected: true},
+		{query: `rate({app="foo"}[5m] offset -5m)`, expected: false},
+		{query: `sum(rate({app="foo"}[5m])) offset -1h`, expected: false},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			req := &LokiRequest{
+				Query:          tc.query,
+				CachingOptions: resultscache.CachingOptions{Disabled: tc.disabled},
+			}
+			require.Equal(t, tc.expected, shouldCacheMetricQuery(req))
+		})
+	}
+}
+
 func TestNewTripperware_Caches(t *testing.T) {
 	for _, tc := range []struct {
 		name      string
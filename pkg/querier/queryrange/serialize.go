@@ -1,11 +1,13 @@
 package queryrange
 
 import (
+	"bytes"
 	"net/http"
 
 	"github.com/opentracing/opentracing-go"
 
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/util/httpreq"
 	serverutil "github.com/grafana/loki/v3/pkg/util/server"
@@ -33,12 +35,22 @@ func (rt *serializeRoundTripper) RoundTrip(r *http.Request) (*http.Response, err
 		return nil, err
 	}
 
+	plan := explainRequest(r, request)
+	if plan != nil {
+		ctx = explain.NewContext(ctx, plan)
+	}
+
 	response, err := rt.next.Do(ctx, request)
+	explainResponse(plan, response, err)
 	if err != nil {
 		return nil, err
 	}
 
-	return rt.codec.EncodeResponse(ctx, r, response)
+	resp, err := rt.codec.EncodeResponse(ctx, r, response)
+	if err != nil {
+		return nil, err
+	}
+	return encodeExplainResponse(resp, plan)
 }
 
 type serializeHTTPHandler struct {
@@ -64,7 +76,13 @@ func (rt *serializeHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	plan := explainRequest(r, request)
+	if plan != nil {
+		ctx = explain.NewContext(ctx, plan)
+	}
+
 	response, err := rt.next.Do(ctx, request)
+	explainResponse(plan, response, err)
 	if err != nil {
 		serverutil.WriteError(err, w)
 		return
@@ -72,7 +90,22 @@ func (rt *serializeHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 
 	version := loghttp.GetVersion(r.RequestURI)
 	encodingFlags := httpreq.ExtractEncodingFlags(r)
-	if err := encodeResponseJSONTo(version, response, w, encodingFlags); err != nil {
+	if plan == nil {
+		if err := encodeResponseJSONTo(version, response, w, encodingFlags); err != nil {
+			serverutil.WriteError(err, w)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := encodeResponseJSONTo(version, response, &buf, encodingFlags); err != nil {
 		serverutil.WriteError(err, w)
+		return
+	}
+	body, err := appendExplain(buf.Bytes(), plan)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
 	}
+	_, _ = w.Write(body)
 }
@@ -3,6 +3,7 @@ package queryrange
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/grafana/dskit/httpgrpc"
@@ -20,6 +21,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/util/validation"
@@ -160,7 +162,13 @@ func (h *splitByInterval) loop(ctx context.Context, ch <-chan *lokiResult, next
 		sp, ctx := opentracing.StartSpanFromContext(ctx, "interval")
 		data.req.LogToSpan(sp)
 
+		split := explain.FromContext(ctx).Child(explain.TypeSplit, data.req.GetQuery(), data.req.GetStart(), data.req.GetEnd())
+		if split != nil {
+			ctx = explain.NewContext(ctx, split)
+		}
+
 		resp, err := next.Do(ctx, data.req)
+		explainResponse(split, resp, err)
 		sp.Finish()
 
 		select {
@@ -195,12 +203,19 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 		return h.next.Do(ctx, r)
 	}
 
+	// the dedup and limit stages would drop entries of each split once merged,
+	// leaving the merged results short of the query limit.
+	if req, ok := r.(*LokiRequest); ok && req.Plan != nil && syntax.HasMergeStages(req.Plan.AST) {
+		return h.next.Do(ctx, r)
+	}
+
 	intervals, err := h.splitter.split(time.Now().UTC(), tenantIDs, r, interval)
 	if err != nil {
 		return nil, err
 	}
 
 	h.metrics.splits.Observe(float64(len(intervals)))
+	explain.FromContext(ctx).Set("splits", strconv.Itoa(len(intervals)))
 
 	// no interval should not be processed by the frontend.
 	if len(intervals) == 0 {
@@ -271,14 +286,61 @@ func maxRangeVectorAndOffsetDuration(expr syntax.Expr) (time.Duration, time.Dura
 
 	var maxRVDuration, maxOffset time.Duration
 	expr.Walk(func(e syntax.Expr) {
-		if r, ok := e.(*syntax.LogRange); ok {
+		switch r := e.(type) {
+		case *syntax.LogRange:
 			if r.Interval > maxRVDuration {
 				maxRVDuration = r.Interval
 			}
 			if r.Offset > maxOffset {
 				maxOffset = r.Offset
 			}
+		case *syntax.SubqueryExpr:
+			// the log ranges of a subquery reach further back by the subquery range and offset.
+			innerRVDuration, innerOffset, _ := maxRangeVectorAndOffsetDuration(r.Left)
+			if r.Range+innerRVDuration > maxRVDuration {
+				maxRVDuration = r.Range + innerRVDuration
+			}
+			if r.Offset+innerOffset > maxOffset {
+				maxOffset = r.Offset + innerOffset
+			}
 		}
 	})
 	return maxRVDuration, maxOffset, nil
 }
+
+// minOffsetDurationFromQueryString parses the query and returns the smallest offset of its log ranges, or 0 for log queries.
+// The data read by a query ends at the query end minus this offset, so the schema period and shard boundaries
+// used for a request are picked from the end shifted by it, while the start is shifted by the maximum range and offset.
+// A negative minimum offset means the query reads data after its end, which also makes it unsafe to cache.
+func minOffsetDurationFromQueryString(q string) (time.Duration, error) {
+	parsed, err := syntax.ParseExpr(q)
+	if err != nil {
+		return 0, err
+	}
+	return minOffsetDuration(parsed), nil
+}
+
+// minOffsetDuration returns the minimum offset of the range vectors within a LogQL query.
+// Subexpressions can be shifted independently, so this is the offset of the window ending closest to the query end.
+func minOffsetDuration(expr syntax.Expr) time.Duration {
+	var (
+		minOffset time.Duration
+		found     bool
+	)
+	expr.Walk(func(e syntax.Expr) {
+		switch r := e.(type) {
+		case *syntax.LogRange:
+			if !found || r.Offset < minOffset {
+				minOffset = r.Offset
+				found = true
+			}
+		case *syntax.SubqueryExpr:
+			// a subquery offset shifts all the log ranges within the subquery.
+			if offset := r.Offset + minOffsetDuration(r.Left); !found || offset < minOffset {
+				minOffset = offset
+				found = true
+			}
+		}
+	})
+	return minOffset
+}
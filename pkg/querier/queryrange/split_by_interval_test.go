@@ -1548,6 +1548,48 @@ func Test_splitByInterval_Do(t *testing.T) {
 	}
 }
 
+func Test_splitByInterval_DoMergeStages(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	query := `{foo="bar"} | dedup by (foo) within 1m | limit 2 by (pod)`
+	req := &LokiRequest{
+		StartTs:   time.Unix(0, 0),
+		EndTs:     time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:     query,
+		Limit:     3,
+		Step:      1,
+		Direction: logproto.FORWARD,
+		Path:      "/api/prom/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(query),
+		},
+	}
+
+	var calls int
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		calls++
+		// the dedup and limit stages need all the entries up to the limit in a single request.
+		require.Equal(t, req, r)
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: req.Direction,
+			Limit:     req.Limit,
+			Version:   uint32(loghttp.VersionV1),
+		}, nil
+	})
+
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		WithSplitByLimits(fakeLimits{maxQueryParallelism: 1}, time.Hour),
+		DefaultCodec,
+		newDefaultSplitter(fakeLimits{}, nil),
+		nilMetrics,
+	).Wrap(next)
+
+	_, err := split.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+}
+
 func Test_series_splitByInterval_Do(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 	next := queryrangebase.HandlerFunc(func(_ context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
@@ -1948,3 +1990,44 @@ func assertSplits(t *testing.T, want, splits []queryrangebase.Request) {
 		}
 	}
 }
+
+func Test_minOffsetDuration(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected time.Duration
+	}{
+		{`{app="foo"}`, 0},
+		{`rate({app="foo"}[5m])`, 0},
+		{`rate({app="foo"}[5m] offset 1h)`, time.Hour},
+		{`sum(rate({app="foo"}[5m])) - timeshift(sum(rate({app="foo"}[5m])), 1w)`, 0},
+		{`timeshift(rate({app="foo"}[5m]) / rate({app="bar"}[5m] offset 1h), 1d)`, 24 * time.Hour},
+		{`rate({app="foo"}[5m] offset 1h) / (rate({app="bar"}[5m])) offset -10m`, -10 * time.Minute},
+		{`max_over_time(rate({app="foo"}[5m] offset 1h)[1h:1m] offset -2h)`, -time.Hour},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			minOffset, err := minOffsetDurationFromQueryString(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, minOffset)
+		})
+	}
+}
+
+func Test_maxRangeVectorAndOffsetDuration(t *testing.T) {
+	for _, tc := range []struct {
+		query          string
+		expectedRange  time.Duration
+		expectedOffset time.Duration
+	}{
+		{`{app="foo"}`, 0, 0},
+		{`rate({app="foo"}[5m] offset 1h)`, 5 * time.Minute, time.Hour},
+		{`max_over_time(sum(rate({app="foo"}[5m]))[1h:1m])`, time.Hour + 5*time.Minute, 0},
+		{`max_over_time(rate({app="foo"}[5m] offset 1h)[1h:1m] offset 1d)`, time.Hour + 5*time.Minute, 25 * time.Hour},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			maxRange, maxOffset, err := maxRangeVectorAndOffsetDurationFromQueryString(tc.query)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedRange, maxRange)
+			require.Equal(t, tc.expectedOffset, maxOffset)
+		})
+	}
+}
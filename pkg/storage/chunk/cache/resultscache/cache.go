@@ -19,6 +19,7 @@ import (
 
 	"github.com/grafana/dskit/tenant"
 
+	"github.com/grafana/loki/v3/pkg/logqlmodel/explain"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
 	util_log "github.com/grafana/loki/v3/pkg/util/log"
@@ -109,6 +110,7 @@ func (s ResultsCache) Do(ctx context.Context, r Request) (Response, error) {
 	}
 
 	if s.shouldCacheReq != nil && !s.shouldCacheReq(ctx, r) {
+		s.explain(ctx, "skipped")
 		return s.next.Do(ctx, r)
 	}
 
@@ -134,6 +136,7 @@ func (s ResultsCache) Do(ctx context.Context, r Request) (Response, error) {
 	maxCacheFreshness := validation.MaxDurationPerTenant(tenantIDs, cacheFreshnessCapture)
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
 	if r.GetStart().UnixMilli() > maxCacheTime {
+		s.explain(ctx, "skipped")
 		return s.next.Do(ctx, r)
 	}
 
@@ -141,6 +144,7 @@ func (s ResultsCache) Do(ctx context.Context, r Request) (Response, error) {
 	if ok {
 		response, extents, err = s.handleHit(ctx, r, cached, maxCacheTime)
 	} else {
+		s.explain(ctx, "miss")
 		response, extents, err = s.handleMiss(ctx, r, maxCacheTime)
 	}
 
@@ -155,6 +159,11 @@ func (s ResultsCache) Do(ctx context.Context, r Request) (Response, error) {
 	return response, err
 }
 
+// explain records the outcome of the cache lookup on the execution plan of the request, if any.
+func (s ResultsCache) explain(ctx context.Context, outcome string) {
+	explain.FromContext(ctx).Set("cache."+string(s.cache.GetCacheType()), outcome)
+}
+
 func (s ResultsCache) handleMiss(ctx context.Context, r Request, maxCacheTime int64) (Response, []Extent, error) {
 	response, err := s.next.Do(ctx, r)
 	if err != nil {
@@ -189,6 +198,12 @@ func (s ResultsCache) handleHit(ctx context.Context, r Request, extents []Extent
 		return nil, nil, err
 	}
 
+	if len(requests) == 0 {
+		s.explain(ctx, "hit")
+	} else {
+		s.explain(ctx, fmt.Sprintf("partial hit, %d missing extents", len(requests)))
+	}
+
 	queryLenFromCache := r.GetEnd().Sub(r.GetStart())
 	st := stats.FromContext(ctx)
 	if len(requests) == 0 {
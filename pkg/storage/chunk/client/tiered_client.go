@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	storage_errors "github.com/grafana/loki/v3/pkg/storage/errors"
+)
+
+// ColdStorageLimits tells whether a tenant is allowed to query the chunks moved to cold storage.
+type ColdStorageLimits interface {
+	QueryColdStorage(userID string) bool
+}
+
+// TieredClient spreads the chunks of a period between a hot and a cold storage based on their age.
+// The compactor moves the chunks to the cold storage once they are old enough, so old chunks which
+// were not moved yet are looked up in the hot storage when they are missing from the cold one.
+type TieredClient struct {
+	hot, cold Client
+	cfg       config.ColdStorageConfig
+	// limits is optional, all the tenants are allowed to query the cold storage when it is nil.
+	limits ColdStorageLimits
+	now    func() model.Time
+}
+
+func NewTieredClient(hot, cold Client, cfg config.ColdStorageConfig, limits ColdStorageLimits) *TieredClient {
+	return &TieredClient{
+		hot:    hot,
+		cold:   cold,
+		cfg:    cfg,
+		limits: limits,
+		now:    model.Now,
+	}
+}
+
+// Stop shuts down both storages.
+func (c *TieredClient) Stop() {
+	c.hot.Stop()
+	c.cold.Stop()
+}
+
+// PutChunks writes the chunks to the storage matching their age.
+func (c *TieredClient) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
+	hot, cold := c.split(chunks)
+	if len(hot) > 0 {
+		if err := c.hot.PutChunks(ctx, hot); err != nil {
+			return err
+		}
+	}
+	if len(cold) > 0 {
+		return c.cold.PutChunks(ctx, cold)
+	}
+	return nil
+}
+
+// GetChunks fetches the chunks from the storage matching their age.
+// It fails with ErrColdChunksNeedRestore without fetching anything if a tenant not allowed to query
+// the cold storage asks for old chunks.
+func (c *TieredClient) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	hot, cold := c.split(chunks)
+	if c.limits != nil {
+		for _, chk := range cold {
+			if !c.limits.QueryColdStorage(chk.UserID) {
+				return nil, storage_errors.ErrColdChunksNeedRestore
+			}
+		}
+	}
+
+	var res []chunk.Chunk
+	if len(hot) > 0 {
+		fetched, err := c.hot.GetChunks(ctx, hot)
+		if err != nil {
+			return nil, err
+		}
+		res = fetched
+	}
+
+	if len(cold) > 0 {
+		fetched, err := c.getColdChunks(ctx, cold)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fetched...)
+	}
+
+	return res, nil
+}
+
+func (c *TieredClient) getColdChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	res, err := c.cold.GetChunks(ctx, chunks)
+	if err == nil || !c.cold.IsChunkNotFoundErr(err) {
+		return res, err
+	}
+
+	// some chunks were not moved yet, look them up one by one to find the ones still in the hot storage.
+	res = make([]chunk.Chunk, 0, len(chunks))
+	for _, chk := range chunks {
+		fetched, err := c.cold.GetChunks(ctx, []chunk.Chunk{chk})
+		if err != nil && c.cold.IsChunkNotFoundErr(err) {
+			fetched, err = c.hot.GetChunks(ctx, []chunk.Chunk{chk})
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fetched...)
+	}
+	return res, nil
+}
+
+// DeleteChunk deletes the chunk from both storages since it could be in any of them.
+// It returns a not found error only if the chunk is missing from both.
+func (c *TieredClient) DeleteChunk(ctx context.Context, userID, chunkID string) error {
+	hotErr := c.hot.DeleteChunk(ctx, userID, chunkID)
+	if hotErr != nil && !c.hot.IsChunkNotFoundErr(hotErr) {
+		return hotErr
+	}
+
+	coldErr := c.cold.DeleteChunk(ctx, userID, chunkID)
+	if coldErr != nil && !c.cold.IsChunkNotFoundErr(coldErr) {
+		return coldErr
+	}
+
+	if hotErr != nil && coldErr != nil {
+		return hotErr
+	}
+	return nil
+}
+
+// MoveToCold copies a chunk from the hot to the cold storage and deletes it from the hot storage.
+// It returns false if the chunk is not in the hot storage, which happens if it was already moved.
+func (c *TieredClient) MoveToCold(ctx context.Context, userID, chunkID string) (bool, error) {
+	ref, err := chunk.ParseExternalKey(userID, chunkID)
+	if err != nil {
+		return false, err
+	}
+
+	chks, err := c.hot.GetChunks(ctx, []chunk.Chunk{ref})
+	if err != nil {
+		if c.hot.IsChunkNotFoundErr(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := c.cold.PutChunks(ctx, chks); err != nil {
+		return false, err
+	}
+
+	if err := c.hot.DeleteChunk(ctx, userID, chunkID); err != nil && !c.hot.IsChunkNotFoundErr(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *TieredClient) IsChunkNotFoundErr(err error) bool {
+	return c.hot.IsChunkNotFoundErr(err) || c.cold.IsChunkNotFoundErr(err)
+}
+
+func (c *TieredClient) IsRetryableErr(err error) bool {
+	return c.hot.IsRetryableErr(err) || c.cold.IsRetryableErr(err)
+}
+
+// split partitions the chunks between the hot and the cold storage.
+func (c *TieredClient) split(chunks []chunk.Chunk) (hot, cold []chunk.Chunk) {
+	now := c.now()
+	for _, chk := range chunks {
+		if c.cfg.IsCold(chk.Through, now) {
+			cold = append(cold, chk)
+			continue
+		}
+		hot = append(hot, chk)
+	}
+	return hot, cold
+}
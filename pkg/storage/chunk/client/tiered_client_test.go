@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	storage_errors "github.com/grafana/loki/v3/pkg/storage/errors"
+)
+
+var errChunkNotFound = errors.New("chunk not found")
+
+// mapClient is a Client storing the chunks in memory by their external key.
+type mapClient struct {
+	schema config.SchemaConfig
+	chunks map[string]chunk.Chunk
+	gets   int
+}
+
+func newMapClient(schema config.SchemaConfig) *mapClient {
+	return &mapClient{schema: schema, chunks: map[string]chunk.Chunk{}}
+}
+
+func (m *mapClient) Stop() {}
+
+func (m *mapClient) PutChunks(_ context.Context, chunks []chunk.Chunk) error {
+	for _, c := range chunks {
+		m.chunks[m.schema.ExternalKey(c.ChunkRef)] = c
+	}
+	return nil
+}
+
+func (m *mapClient) GetChunks(_ context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	m.gets++
+	res := make([]chunk.Chunk, 0, len(chunks))
+	for _, c := range chunks {
+		stored, ok := m.chunks[m.schema.ExternalKey(c.ChunkRef)]
+		if !ok {
+			return nil, errChunkNotFound
+		}
+		res = append(res, stored)
+	}
+	return res, nil
+}
+
+func (m *mapClient) DeleteChunk(_ context.Context, _, chunkID string) error {
+	if _, ok := m.chunks[chunkID]; !ok {
+		return errChunkNotFound
+	}
+	delete(m.chunks, chunkID)
+	return nil
+}
+
+func (m *mapClient) IsChunkNotFoundErr(err error) bool { return errors.Is(err, errChunkNotFound) }
+func (m *mapClient) IsRetryableErr(_ error) bool       { return false }
+
+type coldStorageLimits map[string]bool
+
+func (l coldStorageLimits) QueryColdStorage(userID string) bool { return l[userID] }
+
+func TestTieredClient(t *testing.T) {
+	now := model.Now()
+	schema := config.SchemaConfig{Configs: []config.PeriodConfig{{From: config.DayTime{Time: 0}, Schema: "v13"}}}
+	cfg := config.ColdStorageConfig{ObjectType: "cold", After: model.Duration(30 * 24 * time.Hour)}
+
+	newChunk := func(userID string, through model.Time) chunk.Chunk {
+		return chunk.Chunk{ChunkRef: logproto.ChunkRef{UserID: userID, Fingerprint: 1, From: through.Add(-time.Hour), Through: through, Checksum: 1}}
+	}
+	recent := newChunk("user", now.Add(-time.Hour))
+	old := newChunk("user", now.Add(-31*24*time.Hour))
+	notMovedYet := newChunk("user", now.Add(-32*24*time.Hour))
+	oldOfRestricted := newChunk("restricted", now.Add(-31*24*time.Hour))
+
+	hot, cold := newMapClient(schema), newMapClient(schema)
+	c := NewTieredClient(hot, cold, cfg, coldStorageLimits{"user": true})
+	c.now = func() model.Time { return now }
+
+	ctx := context.Background()
+	require.NoError(t, c.PutChunks(ctx, []chunk.Chunk{recent, old, oldOfRestricted}))
+	require.Len(t, hot.chunks, 1)
+	require.Len(t, cold.chunks, 2)
+	// simulate a chunk the compactor did not move yet.
+	require.NoError(t, hot.PutChunks(ctx, []chunk.Chunk{notMovedYet}))
+
+	chks, err := c.GetChunks(ctx, []chunk.Chunk{recent, old, notMovedYet})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []chunk.Chunk{recent, old, notMovedYet}, chks)
+
+	hotGets := hot.gets
+	_, err = c.GetChunks(ctx, []chunk.Chunk{oldOfRestricted})
+	require.ErrorIs(t, err, storage_errors.ErrColdChunksNeedRestore)
+	require.Equal(t, hotGets, hot.gets)
+
+	// the compactor moves the chunk to the cold storage.
+	moved, err := c.MoveToCold(ctx, "user", schema.ExternalKey(notMovedYet.ChunkRef))
+	require.NoError(t, err)
+	require.True(t, moved)
+	require.NotContains(t, hot.chunks, schema.ExternalKey(notMovedYet.ChunkRef))
+	require.Contains(t, cold.chunks, schema.ExternalKey(notMovedYet.ChunkRef))
+
+	moved, err = c.MoveToCold(ctx, "user", schema.ExternalKey(notMovedYet.ChunkRef))
+	require.NoError(t, err)
+	require.False(t, moved)
+
+	// chunks are deleted from whichever storage they are in.
+	require.NoError(t, c.DeleteChunk(ctx, "user", schema.ExternalKey(recent.ChunkRef)))
+	require.NoError(t, c.DeleteChunk(ctx, "user", schema.ExternalKey(old.ChunkRef)))
+	err = c.DeleteChunk(ctx, "user", schema.ExternalKey(old.ChunkRef))
+	require.True(t, c.IsChunkNotFoundErr(err))
+}
@@ -47,6 +47,7 @@ var (
 	errUpcomingBoltdbShipperNon24Hours = errors.New("boltdb-shipper with future date must always have periodic config for index set to 24h")
 	errTSDBNon24HoursIndexPeriod       = errors.New("tsdb must always have periodic config for index set to 24h")
 	errZeroLengthConfig                = errors.New("must specify at least one schema configuration")
+	errColdStorageAfterNotSet          = errors.New("cold storage must have a positive 'after' setting")
 
 	// regexp for finding the trailing index table number at the end of the table name
 	extractTableNumberRegex = regexp.MustCompile(`[0-9]+$`)
@@ -145,6 +146,7 @@ type PeriodConfig struct {
 	IndexTables IndexPeriodicTableConfig `yaml:"index" doc:"description=Configures how the index is updated and stored."`
 	ChunkTables PeriodicTableConfig      `yaml:"chunks" doc:"description=Configured how the chunks are updated and stored."`
 	RowShards   uint32                   `yaml:"row_shards" doc:"default=16|description=How many shards will be created. Only used if schema is v10 or greater."`
+	ColdStorage ColdStorageConfig        `yaml:"cold_storage,omitempty" doc:"description=Configures moving old chunks to a cheaper object store. The compactor performs the transition."`
 
 	// Integer representation of schema used for hot path calculation. Populated on unmarshaling.
 	schemaInt *int `yaml:"-"`
@@ -463,6 +465,10 @@ func (cfg PeriodConfig) validate() error {
 		return fmt.Errorf("validating chunk tables: %w", err)
 	}
 
+	if err := cfg.ColdStorage.Validate(); err != nil {
+		return fmt.Errorf("validating cold storage: %w", err)
+	}
+
 	v, err := cfg.VersionAsInt()
 	if err != nil {
 		return err
@@ -560,6 +566,31 @@ func (cfg IndexPeriodicTableConfig) MarshalYAML() (interface{}, error) {
 
 	return g, nil
 }
+
+// ColdStorageConfig configures the object store the chunks of a period are moved to once they are old enough.
+type ColdStorageConfig struct {
+	ObjectType string         `yaml:"object_store" doc:"description=Which store to move the old chunks to. Accepts the same values as object_store, use a named_store to move the chunks to another bucket or storage class. Cold storage is disabled when empty."`
+	After      model.Duration `yaml:"after" doc:"description=Age after which chunks are moved to the cold storage. A chunk is old enough once its end time is older than this duration."`
+}
+
+// Enabled returns true if the chunks of the period are moved to a cold storage.
+func (cfg ColdStorageConfig) Enabled() bool {
+	return cfg.ObjectType != ""
+}
+
+func (cfg ColdStorageConfig) Validate() error {
+	if cfg.Enabled() && cfg.After <= 0 {
+		return errColdStorageAfterNotSet
+	}
+
+	return nil
+}
+
+// IsCold returns true if a chunk ending at through is old enough to be in the cold storage.
+func (cfg ColdStorageConfig) IsCold(through, now model.Time) bool {
+	return cfg.Enabled() && through.Before(now.Add(-time.Duration(cfg.After)))
+}
+
 func ValidatePathPrefix(prefix string) error {
 	if prefix == "" {
 		return errors.New("prefix must be set")
@@ -485,6 +485,33 @@ func TestPeriodConfig_Validate(t *testing.T) {
 				ChunkTables: PeriodicTableConfig{Period: 0},
 			},
 		},
+		{
+			desc: "cold storage",
+			in: PeriodConfig{
+				Schema:    "v13",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+				ColdStorage: ColdStorageConfig{ObjectType: "archive", After: model.Duration(30 * 24 * time.Hour)},
+			},
+		},
+		{
+			desc: "error cold storage without after",
+			in: PeriodConfig{
+				Schema:    "v13",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables: PeriodicTableConfig{Period: 0},
+				ColdStorage: ColdStorageConfig{ObjectType: "archive"},
+			},
+			err: "cold storage must have a positive 'after' setting",
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			if tc.err == "" {
@@ -2,6 +2,9 @@ package errors
 
 var ErrQueryMustContainMetricName = QueryError("query must contain metric name")
 
+// ErrColdChunksNeedRestore is returned when a query needs chunks moved to cold storage while the tenant is not allowed to query them.
+var ErrColdChunksNeedRestore = QueryError("the query needs chunks which were moved to cold storage and must be restored first, reduce the time range of the query to only include recent data")
+
 // Query errors are to be treated as user errors, rather than storage errors.
 type QueryError string
 
@@ -229,27 +229,43 @@ func (s *LokiStore) chunkClientForPeriod(p config.PeriodConfig) (client.Client,
 	if objectStoreType == "" {
 		objectStoreType = p.IndexType
 	}
+	chunks, err := s.newChunkClient(objectStoreType, p.From.String(), s.cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating object client")
+	}
+
+	if p.ColdStorage.Enabled() {
+		// cold chunks get their own concurrency limit to not slow down the reads from the hot storage.
+		coldCfg := s.cfg
+		coldCfg.MaxParallelGetChunk = s.cfg.MaxParallelGetColdChunk
+
+		coldChunks, err := s.newChunkClient(p.ColdStorage.ObjectType, "cold-"+p.From.String(), coldCfg)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating cold storage object client")
+		}
+		chunks = client.NewTieredClient(chunks, coldChunks, p.ColdStorage, s.limits)
+	}
+
+	chunks = client.NewMetricsChunkClient(chunks, s.chunkClientMetrics)
+	return chunks, nil
+}
+
+func (s *LokiStore) newChunkClient(objectStoreType, name string, cfg Config) (client.Client, error) {
 	chunkClientReg := prometheus.WrapRegistererWith(
-		prometheus.Labels{"component": "chunk-store-" + p.From.String()}, s.registerer)
+		prometheus.Labels{"component": "chunk-store-" + name}, s.registerer)
 
 	var cc congestion.Controller
-	ccCfg := s.cfg.CongestionControl
+	ccCfg := cfg.CongestionControl
 
 	if ccCfg.Enabled {
 		cc = s.congestionControllerFactory(
 			ccCfg,
 			s.logger,
-			congestion.NewMetrics(fmt.Sprintf("%s-%s", objectStoreType, p.From.String()), ccCfg),
+			congestion.NewMetrics(fmt.Sprintf("%s-%s", objectStoreType, name), ccCfg),
 		)
 	}
 
-	chunks, err := NewChunkClient(objectStoreType, s.cfg, s.schemaCfg, cc, chunkClientReg, s.clientMetrics, s.logger)
-	if err != nil {
-		return nil, errors.Wrap(err, "error creating object client")
-	}
-
-	chunks = client.NewMetricsChunkClient(chunks, s.chunkClientMetrics)
-	return chunks, nil
+	return NewChunkClient(objectStoreType, cfg, s.schemaCfg, cc, chunkClientReg, s.clientMetrics, s.logger)
 }
 
 func shouldUseIndexGatewayClient(cfg indexshipper.Config) bool {
@@ -1129,6 +1129,10 @@ func (p *mockStreamExtractor) ReferencedStructuredMetadata() bool {
 	return false
 }
 
+func (p *mockStreamExtractor) NextSample() (float64, lokilog.LabelsResult, bool) {
+	return p.wrappedSP.NextSample()
+}
+
 func (p *mockStreamExtractor) BaseLabels() lokilog.LabelsResult {
 	return p.wrappedSP.BaseLabels()
 }
@@ -310,7 +310,7 @@ func (tm *tableManager) ensureQueryReadiness(ctx context.Context) error {
 	}
 
 	for _, tableName := range tables {
-		if tableName == deletion.DeleteRequestsTableName {
+		if tableName == deletion.DeleteRequestsTableName || tableName == deletion.LegalHoldsTableName {
 			continue
 		}
 
@@ -112,4 +112,12 @@ type sampleBufferedIterator struct {
 
 	cur        logproto.Sample
 	currLabels log.LabelsResult
+
+	// exploded holds the remaining samples of the current line when the pipeline explodes it.
+	exploded []explodedSample
+}
+
+type explodedSample struct {
+	value  float64
+	labels log.LabelsResult
 }
@@ -1,6 +1,8 @@
 package chunks
 
 import (
+	"github.com/cespare/xxhash/v2"
+
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/log"
@@ -58,6 +60,15 @@ func (s *sampleBufferedIterator) Labels() string {
 
 // Next implements iter.SampleIterator.
 func (s *sampleBufferedIterator) Next() bool {
+	if len(s.exploded) > 0 {
+		s.currLabels = s.exploded[0].labels
+		s.cur.Value = s.exploded[0].value
+		// each sample of an exploded line needs its own hash to not be deduplicated.
+		s.cur.Hash++
+		s.exploded = s.exploded[1:]
+		return true
+	}
+
 	for s.reader.Next() {
 		// todo: Only use length columns for bytes_over_time without filter.
 		ts, line := s.reader.At()
@@ -76,6 +87,10 @@ func (s *sampleBufferedIterator) Next() bool {
 		s.currLabels = lbs
 		s.cur.Value = val
 		s.cur.Timestamp = ts
+		s.cur.Hash = xxhash.Sum64(line)
+		for val, lbs, matches = s.pipeline.NextSample(); matches; val, lbs, matches = s.pipeline.NextSample() {
+			s.exploded = append(s.exploded, explodedSample{value: val, labels: lbs})
+		}
 		return true
 	}
 	return false
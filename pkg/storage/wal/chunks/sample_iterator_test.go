@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
@@ -34,9 +35,9 @@ func TestNewSampleIterator(t *testing.T) {
 			through:   4,
 			extractor: mustNewExtractor(t, ""),
 			expected: []logproto.Sample{
-				{Timestamp: 1, Value: 1.0, Hash: 0},
-				{Timestamp: 2, Value: 1.0, Hash: 0},
-				{Timestamp: 3, Value: 1.0, Hash: 0},
+				{Timestamp: 1, Value: 1.0, Hash: xxhash.Sum64String("1.0")},
+				{Timestamp: 2, Value: 1.0, Hash: xxhash.Sum64String("2.0")},
+				{Timestamp: 3, Value: 1.0, Hash: xxhash.Sum64String("3.0")},
 			},
 		},
 		{
@@ -51,8 +52,8 @@ func TestNewSampleIterator(t *testing.T) {
 			through:   4,
 			extractor: mustNewExtractor(t, ""),
 			expected: []logproto.Sample{
-				{Timestamp: 2, Value: 1.0, Hash: 0},
-				{Timestamp: 3, Value: 1.0, Hash: 0},
+				{Timestamp: 2, Value: 1.0, Hash: xxhash.Sum64String("2.0")},
+				{Timestamp: 3, Value: 1.0, Hash: xxhash.Sum64String("3.0")},
 			},
 		},
 		{
@@ -67,8 +68,8 @@ func TestNewSampleIterator(t *testing.T) {
 			through:   5,
 			extractor: mustNewExtractor(t, `count_over_time({foo="bar"} |= "error"[1m])`),
 			expected: []logproto.Sample{
-				{Timestamp: 1, Value: 1.0, Hash: 0},
-				{Timestamp: 3, Value: 1.0, Hash: 0},
+				{Timestamp: 1, Value: 1.0, Hash: xxhash.Sum64String("error: 1.0")},
+				{Timestamp: 3, Value: 1.0, Hash: xxhash.Sum64String("error: 3.0")},
 			},
 		},
 		{
@@ -83,8 +84,8 @@ func TestNewSampleIterator(t *testing.T) {
 			through:   5,
 			extractor: mustNewExtractor(t, `bytes_over_time({foo="bar"} |= "error"[1m])`),
 			expected: []logproto.Sample{
-				{Timestamp: 1, Value: 10, Hash: 0},
-				{Timestamp: 3, Value: 10, Hash: 0},
+				{Timestamp: 1, Value: 10, Hash: xxhash.Sum64String("error: 1.0")},
+				{Timestamp: 3, Value: 10, Hash: xxhash.Sum64String("error: 3.0")},
 			},
 		},
 		{
@@ -148,6 +149,32 @@ func TestNewSampleIterator(t *testing.T) {
 	}
 }
 
+func TestNewSampleIteratorExplode(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := WriteChunk(&buf, []*logproto.Entry{
+		{Timestamp: time.Unix(0, 1), Line: `{"items":[{"sku":"a","price":1},{"sku":"b","price":2},{"sku":"a","price":3}]}`},
+		{Timestamp: time.Unix(0, 2), Line: `{"items":[]}`},
+		{Timestamp: time.Unix(0, 3), Line: `{"items":[{"sku":"b","price":4}]}`},
+	}, EncodingSnappy)
+	require.NoError(t, err)
+
+	extractor := mustNewExtractor(t, `sum_over_time({foo="bar"} | explode items | unwrap price [1m])`)
+	iter, err := NewSampleIterator(buf.Bytes(), extractor, 0, 4)
+	require.NoError(t, err)
+	defer iter.Close()
+
+	sums := map[string]float64{}
+	hashes := map[uint64]struct{}{}
+	for iter.Next() {
+		sums[iter.Labels()] += iter.At().Value
+		hashes[iter.At().Hash] = struct{}{}
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, map[string]float64{`{sku="a"}`: 4, `{sku="b"}`: 6}, sums)
+	// each sample of an exploded line must be kept by the replicas deduplication.
+	require.Len(t, hashes, 4)
+}
+
 func TestNewSampleIteratorErrors(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/v3/pkg/macros"
+)
+
+const macrosAPIPath = "/loki/api/v1/macros"
+
+// ListMacros retrieves the latest version of all the macros
+func (r *LokiClient) ListMacros(ctx context.Context) ([]macros.Macro, error) {
+	var list macros.MacroList
+	if err := r.getYAML(ctx, macrosAPIPath, &list); err != nil {
+		return nil, err
+	}
+	return list.Macros, nil
+}
+
+// GetMacro retrieves a macro, in its latest version if version is 0
+func (r *LokiClient) GetMacro(ctx context.Context, name string, version int) (*macros.Macro, error) {
+	path := macrosAPIPath + "/" + url.PathEscape(name)
+	if version > 0 {
+		path += "?version=" + strconv.Itoa(version)
+	}
+
+	var m macros.Macro
+	if err := r.getYAML(ctx, path, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetMacroVersions retrieves the versions of a macro, latest first
+func (r *LokiClient) GetMacroVersions(ctx context.Context, name string) ([]macros.Macro, error) {
+	var list macros.MacroList
+	if err := r.getYAML(ctx, macrosAPIPath+"/"+url.PathEscape(name)+"/versions", &list); err != nil {
+		return nil, err
+	}
+	return list.Macros, nil
+}
+
+// SetMacro creates or updates a macro and returns the stored version
+func (r *LokiClient) SetMacro(ctx context.Context, m macros.Macro) (*macros.Macro, error) {
+	payload, err := yaml.Marshal(&m)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.doRequest(ctx, macrosAPIPath+"/"+url.PathEscape(m.Name), "POST", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored macros.Macro
+	if err := yaml.Unmarshal(body, &stored); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal response")
+	}
+	return &stored, nil
+}
+
+// DeleteMacro deletes all the versions of a macro
+func (r *LokiClient) DeleteMacro(ctx context.Context, name string) error {
+	res, err := r.doRequest(ctx, macrosAPIPath+"/"+url.PathEscape(name), "DELETE", nil)
+	if err != nil {
+		return err
+	}
+
+	res.Body.Close()
+
+	return nil
+}
+
+func (r *LokiClient) getYAML(ctx context.Context, path string, v interface{}) error {
+	res, err := r.doRequest(ctx, path, "GET", nil)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "unable to unmarshal response")
+	}
+	return nil
+}
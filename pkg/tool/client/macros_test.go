@@ -0,0 +1,50 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/macros"
+)
+
+func TestLokiClientMacros(t *testing.T) {
+	requestCh := make(chan *http.Request, 1)
+	bodyCh := make(chan string, 1)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requestCh <- r
+		bodyCh <- string(body)
+		_, _ = w.Write([]byte("name: nginx\nexpression: json\nversion: 2\n"))
+	}))
+	defer ts.Close()
+
+	client, err := New(Config{
+		Address: ts.URL,
+		ID:      "my-id",
+	})
+	require.NoError(t, err)
+
+	m, err := client.GetMacro(context.Background(), "nginx", 2)
+	require.NoError(t, err)
+	require.Equal(t, &macros.Macro{Name: "nginx", Expression: "json", Version: 2}, m)
+	req := <-requestCh
+	<-bodyCh
+	require.Equal(t, "GET", req.Method)
+	require.Equal(t, "/loki/api/v1/macros/nginx", req.URL.Path)
+	require.Equal(t, "version=2", req.URL.RawQuery)
+	require.Equal(t, "my-id", req.Header.Get("X-Scope-OrgID"))
+
+	m, err = client.SetMacro(context.Background(), macros.Macro{Name: "nginx", Expression: "json", Version: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Version)
+	req = <-requestCh
+	require.Equal(t, "POST", req.Method)
+	require.Equal(t, "/loki/api/v1/macros/nginx", req.URL.Path)
+	require.Equal(t, "name: nginx\nexpression: json\nversion: 1\n", <-bodyCh)
+}
@@ -0,0 +1,310 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/v3/pkg/macros"
+	"github.com/grafana/loki/v3/pkg/tool/client"
+)
+
+// MacroCommand configures and executes macro related Loki operations
+type MacroCommand struct {
+	ClientConfig client.Config
+
+	cli *client.LokiClient
+
+	// Get/Delete Macro Config
+	Name    string
+	Version int
+
+	// Load/Diff/Sync Macros Config
+	MacroFilesList []string
+}
+
+// Register macro related commands and flags with the kingpin application
+func (m *MacroCommand) Register(app *kingpin.Application) {
+	macrosCmd := app.Command("macros", "View & edit the LogQL macros stored in loki.").PreAction(m.setup)
+	macrosCmd.Flag("authToken", "Authentication token for bearer token or JWT auth, alternatively set LOKI_AUTH_TOKEN.").Default("").Envar("LOKI_AUTH_TOKEN").StringVar(&m.ClientConfig.AuthToken)
+	macrosCmd.Flag("user", "API user to use when contacting loki, alternatively set LOKI_API_USER. If empty, LOKI_TENANT_ID will be used instead.").Default("").Envar("LOKI_API_USER").StringVar(&m.ClientConfig.User)
+	macrosCmd.Flag("key", "API key to use when contacting loki, alternatively set LOKI_API_KEY.").Default("").Envar("LOKI_API_KEY").StringVar(&m.ClientConfig.Key)
+	macrosCmd.Flag("address", "Address of the loki cluster, alternatively set LOKI_ADDRESS.").Envar("LOKI_ADDRESS").Required().StringVar(&m.ClientConfig.Address)
+	macrosCmd.Flag("id", "Loki tenant id, alternatively set LOKI_TENANT_ID.").Envar("LOKI_TENANT_ID").Required().StringVar(&m.ClientConfig.ID)
+	macrosCmd.Flag("tls-ca-path", "TLS CA certificate to verify Loki API as part of mTLS, alternatively set LOKI_TLS_CA_PATH.").Default("").Envar("LOKI_TLS_CA_CERT").StringVar(&m.ClientConfig.TLS.CAPath)
+	macrosCmd.Flag("tls-cert-path", "TLS client certificate to authenticate with Loki API as part of mTLS, alternatively set LOKI_TLS_CERT_PATH.").Default("").Envar("LOKI_TLS_CLIENT_CERT").StringVar(&m.ClientConfig.TLS.CertPath)
+	macrosCmd.Flag("tls-key-path", "TLS client certificate private key to authenticate with Loki API as part of mTLS, alternatively set LOKI_TLS_KEY_PATH.").Default("").Envar("LOKI_TLS_CLIENT_KEY").StringVar(&m.ClientConfig.TLS.KeyPath)
+
+	macrosCmd.
+		Command("list", "List the latest version of the macros.").
+		Action(m.listMacros)
+	getCmd := macrosCmd.
+		Command("get", "Retrieve a macro.").
+		Action(m.getMacro)
+	historyCmd := macrosCmd.
+		Command("history", "Retrieve the versions of a macro.").
+		Action(m.macroHistory)
+	deleteCmd := macrosCmd.
+		Command("delete", "Delete a macro and all its versions.").
+		Action(m.deleteMacro)
+	loadCmd := macrosCmd.
+		Command("load", "Create or update the macros of a set of files.").
+		Action(m.loadMacros)
+	diffCmd := macrosCmd.
+		Command("diff", "Show the changes a sync of a set of files would make.").
+		Action(m.diffMacros)
+	syncCmd := macrosCmd.
+		Command("sync", "Create or update the macros of a set of files and delete the macros missing from the files.").
+		Action(m.syncMacros)
+
+	getCmd.Arg("name", "Name of the macro to retrieve.").Required().StringVar(&m.Name)
+	getCmd.Flag("version", "Version of the macro to retrieve, the latest one if not set.").IntVar(&m.Version)
+
+	historyCmd.Arg("name", "Name of the macro to retrieve.").Required().StringVar(&m.Name)
+
+	deleteCmd.Arg("name", "Name of the macro to delete.").Required().StringVar(&m.Name)
+
+	for _, c := range []*kingpin.CmdClause{loadCmd, diffCmd, syncCmd} {
+		c.Arg("macro-files", "The macro files to use.").Required().ExistingFilesVar(&m.MacroFilesList)
+	}
+}
+
+func (m *MacroCommand) setup(_ *kingpin.ParseContext) error {
+	cli, err := client.New(m.ClientConfig)
+	if err != nil {
+		return err
+	}
+	m.cli = cli
+
+	return nil
+}
+
+func (m *MacroCommand) listMacros(_ *kingpin.ParseContext) error {
+	list, err := m.cli.ListMacros(context.Background())
+	if err != nil && err != client.ErrResourceNotFound {
+		return errors.Wrap(err, "unable to list macros")
+	}
+
+	return printYAML(macros.MacroList{Macros: list})
+}
+
+func (m *MacroCommand) getMacro(_ *kingpin.ParseContext) error {
+	macro, err := m.cli.GetMacro(context.Background(), m.Name, m.Version)
+	if err != nil {
+		if err == client.ErrResourceNotFound {
+			log.Infof("this macro does not exist, check the tenant id being passed")
+			return nil
+		}
+		return errors.Wrap(err, "unable to retrieve macro")
+	}
+
+	return printYAML(macro)
+}
+
+func (m *MacroCommand) macroHistory(_ *kingpin.ParseContext) error {
+	versions, err := m.cli.GetMacroVersions(context.Background(), m.Name)
+	if err != nil {
+		if err == client.ErrResourceNotFound {
+			log.Infof("this macro does not exist, check the tenant id being passed")
+			return nil
+		}
+		return errors.Wrap(err, "unable to retrieve macro versions")
+	}
+
+	return printYAML(macros.MacroList{Macros: versions})
+}
+
+func (m *MacroCommand) deleteMacro(_ *kingpin.ParseContext) error {
+	err := m.cli.DeleteMacro(context.Background(), m.Name)
+	if err != nil && err != client.ErrResourceNotFound {
+		return errors.Wrap(err, "unable to delete macro")
+	}
+
+	return nil
+}
+
+func (m *MacroCommand) loadMacros(_ *kingpin.ParseContext) error {
+	local, err := parseMacroFiles(m.MacroFilesList)
+	if err != nil {
+		return errors.Wrap(err, "load operation unsuccessful, unable to parse macro files")
+	}
+
+	for _, macro := range local {
+		if err := m.setMacro(macro); err != nil {
+			return errors.Wrap(err, "load operation unsuccessful")
+		}
+	}
+
+	return nil
+}
+
+func (m *MacroCommand) diffMacros(_ *kingpin.ParseContext) error {
+	changes, err := m.macroChanges()
+	if err != nil {
+		return errors.Wrap(err, "diff operation unsuccessful")
+	}
+
+	if len(changes.updated) == 0 && len(changes.deleted) == 0 {
+		fmt.Println("no changes detected")
+		return nil
+	}
+	for _, macro := range changes.updated {
+		fmt.Printf("~ %s: %s\n", macro.Name, macro.Expression)
+	}
+	for _, name := range changes.deleted {
+		fmt.Printf("- %s\n", name)
+	}
+
+	return nil
+}
+
+func (m *MacroCommand) syncMacros(_ *kingpin.ParseContext) error {
+	changes, err := m.macroChanges()
+	if err != nil {
+		return errors.Wrap(err, "sync operation unsuccessful")
+	}
+
+	for _, macro := range changes.updated {
+		if err := m.setMacro(macro); err != nil {
+			return errors.Wrap(err, "sync operation unsuccessful, unable to complete executing changes")
+		}
+	}
+	for _, name := range changes.deleted {
+		log.WithFields(log.Fields{"macro": name}).Infof("deleting macro")
+		if err := m.cli.DeleteMacro(context.Background(), name); err != nil && err != client.ErrResourceNotFound {
+			return errors.Wrap(err, "sync operation unsuccessful, unable to complete executing changes")
+		}
+	}
+
+	return nil
+}
+
+func (m *MacroCommand) setMacro(macro macros.Macro) error {
+	stored, err := m.cli.SetMacro(context.Background(), macro)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"macro":   stored.Name,
+		"version": stored.Version,
+	}).Infof("stored macro")
+	return nil
+}
+
+type macroChanges struct {
+	// updated are the created or updated macros, in dependency order.
+	updated []macros.Macro
+	deleted []string
+}
+
+// macroChanges compares the macros of the files to the ones stored in Loki.
+func (m *MacroCommand) macroChanges() (macroChanges, error) {
+	local, err := parseMacroFiles(m.MacroFilesList)
+	if err != nil {
+		return macroChanges{}, errors.Wrap(err, "unable to parse macro files")
+	}
+
+	remote, err := m.cli.ListMacros(context.Background())
+	if err != nil && err != client.ErrResourceNotFound {
+		return macroChanges{}, errors.Wrap(err, "unable to contact the loki api")
+	}
+
+	return compareMacros(local, remote), nil
+}
+
+func compareMacros(local, remote []macros.Macro) macroChanges {
+	remoteByName := make(map[string]macros.Macro, len(remote))
+	for _, macro := range remote {
+		remoteByName[macro.Name] = macro
+	}
+
+	var changes macroChanges
+	for _, macro := range local {
+		current, ok := remoteByName[macro.Name]
+		delete(remoteByName, macro.Name)
+		if ok && current.Expression == macro.Expression && current.Description == macro.Description {
+			continue
+		}
+		changes.updated = append(changes.updated, macro)
+	}
+	for name := range remoteByName {
+		changes.deleted = append(changes.deleted, name)
+	}
+	sort.Strings(changes.deleted)
+
+	return changes
+}
+
+// parseMacroFiles parses the macros of the files, ordered so that macros are
+// stored after the macros they reference.
+func parseMacroFiles(files []string) ([]macros.Macro, error) {
+	byName := map[string]macros.Macro{}
+	var names []string
+	for _, file := range files {
+		buf, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		var list macros.MacroList
+		decoder := yamlv3.NewDecoder(bytes.NewReader(buf))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&list); err != nil {
+			return nil, errors.Wrapf(err, "%s", file)
+		}
+
+		for _, macro := range list.Macros {
+			if err := macros.ValidateName(macro.Name); err != nil {
+				return nil, errors.Wrapf(err, "%s", file)
+			}
+			if _, ok := byName[macro.Name]; ok {
+				return nil, fmt.Errorf("%s: macro %q is defined more than once", file, macro.Name)
+			}
+			// Versions are assigned by Loki.
+			macro.Version = 0
+			byName[macro.Name] = macro
+			names = append(names, macro.Name)
+		}
+	}
+	sort.Strings(names)
+
+	var (
+		ordered []macros.Macro
+		visited = map[string]bool{}
+		visit   func(name string)
+	)
+	visit = func(name string) {
+		macro, ok := byName[name]
+		if !ok || visited[name] {
+			return
+		}
+		visited[name] = true
+		// Only the direct references are collected, cycles are reported by Loki.
+		_, _ = macros.Expand("| "+macro.Expression, func(ref string) (string, error) {
+			visit(ref)
+			return "", nil
+		})
+		ordered = append(ordered, macro)
+	}
+	for _, name := range names {
+		visit(name)
+	}
+
+	return ordered, nil
+}
+
+func printYAML(v interface{}) error {
+	out, err := yamlv3.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Print(string(out))
+	return nil
+}
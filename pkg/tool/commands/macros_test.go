@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/macros"
+)
+
+func TestParseMacroFiles(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.yaml")
+	require.NoError(t, os.WriteFile(first, []byte(`
+macros:
+  - name: a_nginx_errors
+    expression: "@nginx | @errors"
+  - name: errors
+    expression: level="error"
+`), 0o600))
+	second := filepath.Join(dir, "second.yaml")
+	require.NoError(t, os.WriteFile(second, []byte(`
+macros:
+  - name: nginx
+    description: nginx access logs
+    expression: json | line_format "{{.msg}}"
+    version: 3
+`), 0o600))
+
+	parsed, err := parseMacroFiles([]string{first, second})
+	require.NoError(t, err)
+	assert.Equal(t, []macros.Macro{
+		{Name: "nginx", Description: "nginx access logs", Expression: `json | line_format "{{.msg}}"`},
+		{Name: "errors", Expression: `level="error"`},
+		{Name: "a_nginx_errors", Expression: "@nginx | @errors"},
+	}, parsed)
+
+	_, err = parseMacroFiles([]string{first, first})
+	require.EqualError(t, err, first+`: macro "a_nginx_errors" is defined more than once`)
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("macros:\n  - name: nginx-errors\n    expression: json\n"), 0o600))
+	_, err = parseMacroFiles([]string{invalid})
+	require.Error(t, err)
+}
+
+func TestCompareMacros(t *testing.T) {
+	changes := compareMacros(
+		[]macros.Macro{
+			{Name: "same", Expression: "json"},
+			{Name: "updated", Expression: "logfmt"},
+			{Name: "created", Expression: "json"},
+		},
+		[]macros.Macro{
+			{Name: "same", Expression: "json", Version: 2},
+			{Name: "updated", Expression: "json", Version: 1},
+			{Name: "deleted", Expression: "json", Version: 1},
+		},
+	)
+	assert.Equal(t, []macros.Macro{
+		{Name: "updated", Expression: "logfmt"},
+		{Name: "created", Expression: "json"},
+	}, changes.updated)
+	assert.Equal(t, []string{"deleted"}, changes.deleted)
+}
@@ -96,6 +96,7 @@ type Limits struct {
 
 	// Querier enforced limits.
 	MaxChunksPerQuery          int              `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
+	QueryColdStorage           bool             `yaml:"query_cold_storage" json:"query_cold_storage"`
 	MaxQuerySeries             int              `yaml:"max_query_series" json:"max_query_series"`
 	MaxQueryLookback           model.Duration   `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength             model.Duration   `yaml:"max_query_length" json:"max_query_length"`
@@ -181,7 +182,7 @@ type Limits struct {
 
 	// Global and per tenant retention
 	RetentionPeriod model.Duration    `yaml:"retention_period" json:"retention_period"`
-	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty" doc:"description=Per-stream retention to apply, if the retention is enable on the compactor side.\nExample:\n retention_stream:\n - selector: '{namespace=\"dev\"}'\n priority: 1\n period: 24h\n- selector: '{container=\"nginx\"}'\n priority: 1\n period: 744h\nSelector is a Prometheus labels matchers that will apply the 'period' retention only if the stream is matching. In case multiple stream are matching, the highest priority will be picked. If no rule is matched the 'retention_period' is used."`
+	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty" doc:"description=Per-stream retention to apply, if the retention is enable on the compactor side.\nExample:\n retention_stream:\n - selector: '{namespace=\"dev\"}'\n priority: 1\n period: 24h\n- selector: '{container=\"nginx\"}'\n priority: 1\n period: 744h\nSelector is a Prometheus labels matchers that will apply the 'period' retention only if the stream is matching. In case multiple stream are matching, the highest priority will be picked. If no rule is matched the 'retention_period' is used.\nThe selector can also filter log lines, by structured metadata or with line filters, for example '{app=\"x\"} | level=\"debug\"'. Such rules only delete the matching log lines older than their 'period', by rewriting the chunks, and do not take part in the priority resolution of the stream retention."`
 
 	// Config for overrides, convenient if it goes here.
 	PerTenantOverrideConfig string         `yaml:"per_tenant_override_config" json:"per_tenant_override_config"`
@@ -229,6 +230,16 @@ type StreamRetention struct {
 	Priority int               `yaml:"priority" json:"priority" doc:"description:The larger the value, the higher the priority."`
 	Selector string            `yaml:"selector" json:"selector" doc:"description:Stream selector expression."`
 	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
+
+	// LogSelector is populated during validation when the selector filters the log lines,
+	// for instance by structured metadata or with a line filter.
+	LogSelector syntax.LogSelectorExpr `yaml:"-" json:"-"`
+}
+
+// HasLineFilter returns true if the retention only applies to the log lines of the
+// matching streams that pass the filters of the selector rather than to whole streams.
+func (r StreamRetention) HasLineFilter() bool {
+	return r.LogSelector != nil
 }
 
 // LimitError are errors that do not comply with the limits specified.
@@ -288,6 +299,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.PerStreamRateLimitBurst, "ingester.per-stream-rate-limit-burst", "Maximum burst bytes per stream, also expressible in human readable forms (1MB, 256KB, etc). This is how far above the rate limit a stream can 'burst' before the stream is limited.")
 
 	f.IntVar(&l.MaxChunksPerQuery, "store.query-chunk-limit", 2e6, "Maximum number of chunks that can be fetched in a single query.")
+	f.BoolVar(&l.QueryColdStorage, "store.query-cold-storage", true, "Allow queries to fetch the chunks moved to the cold storage of a schema period. When disabled, queries which need such chunks fail fast with an error asking to restore them.")
 
 	_ = l.MaxQueryLength.Set("721h")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "The limit to length of chunk store queries. 0 to disable.")
@@ -452,15 +464,19 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 func (l *Limits) Validate() error {
 	if l.StreamRetention != nil {
 		for i, rule := range l.StreamRetention {
-			matchers, err := syntax.ParseMatchers(rule.Selector, true)
+			expr, err := syntax.ParseLogSelector(rule.Selector, true)
 			if err != nil {
-				return fmt.Errorf("invalid labels matchers: %w", err)
+				return fmt.Errorf("invalid retention_stream rule %d with selector %q: invalid selector: %w", i, rule.Selector, err)
 			}
 			if time.Duration(rule.Period) < 24*time.Hour {
-				return fmt.Errorf("retention period must be >= 24h was %s", rule.Period)
+				return fmt.Errorf("invalid retention_stream rule %d with selector %q: invalid period: retention period must be >= 24h was %s", i, rule.Selector, rule.Period)
 			}
 			// populate matchers during validation
-			l.StreamRetention[i].Matchers = matchers
+			l.StreamRetention[i].Matchers = expr.Matchers()
+			l.StreamRetention[i].LogSelector = nil
+			if expr.HasFilter() {
+				l.StreamRetention[i].LogSelector = expr
+			}
 		}
 	}
 
@@ -616,6 +632,11 @@ func (o *Overrides) MaxChunksPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxChunksPerQuery
 }
 
+// QueryColdStorage returns whether queries can fetch the chunks moved to cold storage.
+func (o *Overrides) QueryColdStorage(userID string) bool {
+	return o.getOverridesForUser(userID).QueryColdStorage
+}
+
 // MaxQueryLength returns the limit of the length (in time) of a query.
 func (o *Overrides) MaxQueryLength(_ context.Context, userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryLength)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
@@ -354,3 +355,39 @@ func TestLimitsValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestLimitsValidation_StreamRetention(t *testing.T) {
+	for _, tc := range []struct {
+		selector   string
+		lineFilter bool
+		err        string
+	}{
+		{selector: `{app="foo"}`},
+		{selector: `{app="foo"} | level="debug"`, lineFilter: true},
+		{selector: `{app="foo"} |= "healthcheck"`, lineFilter: true},
+		{selector: `{app="foo"} |= ""`},
+		{selector: `{app="foo"`, err: `invalid retention_stream rule 0 with selector "{app=\"foo\"": invalid selector: `},
+	} {
+		t.Run(tc.selector, func(t *testing.T) {
+			limits := Limits{
+				DeletionMode:         "disabled",
+				BloomBlockEncoding:   "none",
+				TSDBShardingStrategy: logql.PowerOfTwoVersion.String(),
+				TSDBMaxBytesPerShard: DefaultTSDBMaxBytesPerShard,
+				StreamRetention: []StreamRetention{
+					{Period: model.Duration(72 * time.Hour), Selector: tc.selector},
+				},
+			}
+			err := limits.Validate()
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			rule := limits.StreamRetention[0]
+			require.Equal(t, tc.lineFilter, rule.HasLineFilter())
+			require.Equal(t, []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "foo")}, rule.Matchers)
+		})
+	}
+}
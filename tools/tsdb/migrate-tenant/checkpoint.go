@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// checkpoint records the tables which were completely migrated so that an interrupted
+// migration can be resumed without copying those tables again.
+type checkpoint struct {
+	path string
+
+	SourceTenant string                   `json:"source_tenant"`
+	DestTenant   string                   `json:"dest_tenant"`
+	Tables       map[string]tableProgress `json:"tables"`
+}
+
+type tableProgress struct {
+	DestTable     string    `json:"dest_table"`
+	Chunks        int       `json:"chunks"`
+	SkippedChunks int       `json:"skipped_chunks"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+// loadCheckpoint reads the checkpoint at path, if any. An empty path keeps the progress in memory only.
+func loadCheckpoint(path, sourceTenant, destTenant string) (*checkpoint, error) {
+	c := &checkpoint{
+		path:         path,
+		SourceTenant: sourceTenant,
+		DestTenant:   destTenant,
+		Tables:       map[string]tableProgress{},
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, errors.Wrapf(err, "failed to decode checkpoint file %s", path)
+	}
+	if c.SourceTenant != sourceTenant || c.DestTenant != destTenant {
+		return nil, fmt.Errorf("checkpoint file %s was written for the migration of tenant %q to %q", path, c.SourceTenant, c.DestTenant)
+	}
+	if c.Tables == nil {
+		c.Tables = map[string]tableProgress{}
+	}
+
+	return c, nil
+}
+
+func (c *checkpoint) done(tableName string) bool {
+	_, ok := c.Tables[tableName]
+	return ok
+}
+
+// markDone records the table as migrated and persists the checkpoint.
+func (c *checkpoint) markDone(tableName string, progress tableProgress) error {
+	c.Tables[tableName] = progress
+	if c.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temp file and rename it to not leave a truncated checkpoint behind on crash.
+	tempPath := c.path + tempFileSuffix
+	if err := os.WriteFile(tempPath, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, c.path)
+}
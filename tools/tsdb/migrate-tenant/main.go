@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/loki"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/util/cfg"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func exit(code int) {
+	util_log.Flush()
+	os.Exit(code)
+}
+
+// Usage: go run ./tools/tsdb/migrate-tenant -source.config.file=/tmp/source.yaml -dest.config.file=/tmp/dest.yaml -source.tenant=tenant1 -dest.tenant=tenant2 -checkpoint.file=/tmp/tenant1.json
+func main() {
+	from := flag.String("from", "", "Optional start time in RFC3339 format, only index tables overlapping the time range are migrated")
+	to := flag.String("to", "", "Optional end time in RFC3339 format, only index tables overlapping the time range are migrated")
+	sf := flag.String("source.config.file", "", "Loki config file of the source cluster")
+	df := flag.String("dest.config.file", "", "Loki config file of the destination cluster")
+	sourceTenant := flag.String("source.tenant", "fake", "Source tenant identifier, default is `fake` for single tenant Loki")
+	destTenant := flag.String("dest.tenant", "", "Destination tenant identifier, defaults to the source tenant")
+	parallel := flag.Int("parallel", 8, "How many chunks to copy in parallel")
+	verify := flag.Bool("verify", true, "Read back every copied chunk from the destination store to verify its checksum")
+	checkpointFile := flag.String("checkpoint.file", "", "File recording the migrated index tables, an interrupted migration resumes from it")
+	workingDir := flag.String("working-dir", os.TempDir(), "Directory used to download and build index files")
+	flag.Parse()
+
+	if *sf == "" || *df == "" {
+		fmt.Fprintln(os.Stderr, "-source.config.file and -dest.config.file are required")
+		exit(1)
+	}
+	if *destTenant == "" {
+		*destTenant = *sourceTenant
+	}
+
+	migrateCfg := migrateConfig{
+		sourceTenant: *sourceTenant,
+		destTenant:   *destTenant,
+		from:         parseTime("from", *from),
+		through:      parseTime("to", *to),
+		parallel:     *parallel,
+		verify:       *verify,
+		workingDir:   *workingDir,
+	}
+
+	sourceCfg := loadConfig(*sf)
+	destCfg := loadConfig(*df)
+	util_log.InitLogger(&sourceCfg.Server, prometheus.DefaultRegisterer, false)
+
+	cp, err := loadCheckpoint(*checkpointFile, migrateCfg.sourceTenant, migrateCfg.destTenant)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to load checkpoint", "err", err)
+		exit(1)
+	}
+
+	clientMetrics := storage.NewClientMetrics()
+	defer clientMetrics.Unregister()
+
+	m := newMigrator(
+		migrateCfg,
+		storeConfig{schemaCfg: sourceCfg.SchemaConfig, storageCfg: sourceCfg.StorageConfig},
+		storeConfig{schemaCfg: destCfg.SchemaConfig, storageCfg: destCfg.StorageConfig},
+		cp,
+		clientMetrics,
+	)
+	defer m.stop()
+
+	start := time.Now()
+	if err := m.run(context.Background()); err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to migrate tenant", "source_tenant", migrateCfg.sourceTenant, "dest_tenant", migrateCfg.destTenant, "err", err)
+		exit(1)
+	}
+	level.Info(util_log.Logger).Log("msg", "finished migrating tenant", "source_tenant", migrateCfg.sourceTenant, "dest_tenant", migrateCfg.destTenant, "duration", time.Since(start))
+}
+
+func parseTime(name, value string) model.Time {
+	if value == "" {
+		return 0
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -%s time: %v\n", name, err)
+		exit(1)
+	}
+	return model.TimeFromUnixNano(t.UnixNano())
+}
+
+func loadConfig(file string) loki.Config {
+	var c loki.ConfigWrapper
+	args := []string{"-config.file=" + file}
+	if err := cfg.DynamicUnmarshal(&c, args, flag.NewFlagSet("config-file-loader", flag.ContinueOnError)); err != nil {
+		fmt.Fprintf(os.Stderr, "failed parsing config %s: %v\n", file, err)
+		exit(1)
+	}
+	if err := c.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed validating config %s: %v\n", file, err)
+		exit(1)
+	}
+
+	// the chunk clients are created without a congestion controller.
+	c.StorageConfig.CongestionControl.Enabled = false
+
+	return c.Config
+}
@@ -0,0 +1,532 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	shipperindex "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/index"
+	shipperstorage "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb"
+	tsdbindex "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+const (
+	gzipExtension  = ".gz"
+	tempFileSuffix = ".temp"
+)
+
+type migrateConfig struct {
+	sourceTenant string
+	destTenant   string
+	// from and through restrict the migrated index tables, zero values mean no restriction.
+	from, through model.Time
+	parallel      int
+	verify        bool
+	workingDir    string
+}
+
+// storeConfig is the subset of a Loki config needed to access the chunks and the index of a cluster.
+type storeConfig struct {
+	schemaCfg  config.SchemaConfig
+	storageCfg storage.Config
+}
+
+// periodClients are the clients to access the chunks and the index of a schema period.
+type periodClients struct {
+	chunks client.Client
+	index  shipperstorage.Client
+}
+
+// migrator copies the chunks of a tenant and rewrites its TSDB index files from one store to another,
+// bypassing the query path. It works table by table and records every migrated table in a checkpoint.
+type migrator struct {
+	cfg           migrateConfig
+	source, dest  storeConfig
+	checkpoint    *checkpoint
+	clientMetrics storage.ClientMetrics
+
+	clients map[string]periodClients
+}
+
+func newMigrator(cfg migrateConfig, source, dest storeConfig, cp *checkpoint, clientMetrics storage.ClientMetrics) *migrator {
+	if cfg.parallel <= 0 {
+		cfg.parallel = 1
+	}
+	return &migrator{
+		cfg:           cfg,
+		source:        source,
+		dest:          dest,
+		checkpoint:    cp,
+		clientMetrics: clientMetrics,
+		clients:       map[string]periodClients{},
+	}
+}
+
+func (m *migrator) stop() {
+	for _, c := range m.clients {
+		c.chunks.Stop()
+		c.index.Stop()
+	}
+}
+
+// run migrates all the TSDB index tables of the source store which have not been migrated yet.
+// The tables with un-compacted multi-tenant index files are not migrated nor recorded in the
+// checkpoint, and an error listing them is returned once the other tables are migrated.
+func (m *migrator) run(ctx context.Context) error {
+	var uncompactedTables []string
+	for i, pCfg := range m.source.schemaCfg.Configs {
+		if pCfg.IndexType != types.TSDBType {
+			level.Warn(util_log.Logger).Log("msg", "skipping schema period which does not use tsdb index", "schema_start", pCfg.From, "index_type", pCfg.IndexType)
+			continue
+		}
+
+		periodEndTime := config.DayTime{Time: math.MaxInt64}
+		if i < len(m.source.schemaCfg.Configs)-1 {
+			periodEndTime = config.DayTime{Time: m.source.schemaCfg.Configs[i+1].From.Time.Add(-time.Millisecond)}
+		}
+
+		uncompacted, err := m.migrateTables(ctx, pCfg, pCfg.GetIndexTableNumberRange(periodEndTime))
+		if err != nil {
+			return errors.Wrapf(err, "failed to migrate schema period starting at %s", pCfg.From)
+		}
+		uncompactedTables = append(uncompactedTables, uncompacted...)
+	}
+
+	if len(uncompactedTables) != 0 {
+		return fmt.Errorf("tables %s have un-compacted multi-tenant index files and were not migrated, run the compactor first and resume the migration", strings.Join(uncompactedTables, ", "))
+	}
+	return nil
+}
+
+// migrateTables migrates the tables of a schema period and returns the tables left out because
+// they have un-compacted multi-tenant index files.
+func (m *migrator) migrateTables(ctx context.Context, pCfg config.PeriodConfig, tableRange config.TableRange) ([]string, error) {
+	source, err := m.clientsFor("source", m.source, pCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames, err := source.index.ListTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var uncompactedTables []string
+	for _, tableName := range tableNames {
+		if !strings.HasPrefix(tableName, pCfg.IndexTables.Prefix) {
+			continue
+		}
+		tableInRange, err := tableRange.TableInRange(tableName)
+		if err != nil {
+			return nil, err
+		}
+		if !tableInRange {
+			continue
+		}
+
+		tableStart, tableEnd, err := tableBounds(tableName, pCfg)
+		if err != nil {
+			return nil, err
+		}
+		if (m.cfg.from != 0 && tableEnd < m.cfg.from) || (m.cfg.through != 0 && tableStart > m.cfg.through) {
+			continue
+		}
+
+		if m.checkpoint.done(tableName) {
+			level.Info(util_log.Logger).Log("msg", "skipping table which was already migrated", "table_name", tableName)
+			continue
+		}
+
+		// multi-tenant files are only left behind until the compactor processes the table
+		// and cannot be split by tenant without rewriting the index of the other tenants.
+		uncompactedFiles, _, err := source.index.ListFiles(ctx, tableName, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(uncompactedFiles) != 0 {
+			level.Error(util_log.Logger).Log("msg", "skipping table with un-compacted multi-tenant files, run the compactor first to migrate it", "table_name", tableName, "file_count", len(uncompactedFiles))
+			uncompactedTables = append(uncompactedTables, tableName)
+			continue
+		}
+
+		progress, err := m.migrateTable(ctx, tableName, tableStart, source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to migrate table %s", tableName)
+		}
+		if err := m.checkpoint.markDone(tableName, progress); err != nil {
+			return nil, errors.Wrapf(err, "failed to write checkpoint for table %s", tableName)
+		}
+
+		level.Info(util_log.Logger).Log(
+			"msg", "successfully migrated",
+			"table_name", tableName,
+			"dest_table_name", progress.DestTable,
+			"chunks", progress.Chunks,
+			"skipped_chunks", progress.SkippedChunks,
+		)
+	}
+
+	return uncompactedTables, nil
+}
+
+// series holds the chunks of a stream collected from all the index files of a table.
+type series struct {
+	labels labels.Labels
+	fp     model.Fingerprint
+	chunks tsdbindex.ChunkMetas
+}
+
+func (m *migrator) migrateTable(ctx context.Context, tableName string, tableStart model.Time, source periodClients) (tableProgress, error) {
+	destPeriod, err := m.dest.schemaCfg.SchemaForTime(tableStart)
+	if err != nil {
+		return tableProgress{}, err
+	}
+	if destPeriod.IndexType != types.TSDBType {
+		return tableProgress{}, fmt.Errorf("destination schema period starting at %s does not use tsdb index", destPeriod.From)
+	}
+	dest, err := m.clientsFor("dest", m.dest, destPeriod)
+	if err != nil {
+		return tableProgress{}, err
+	}
+	progress := tableProgress{DestTable: destPeriod.IndexTables.TableFor(tableStart)}
+
+	streams, err := m.readSourceIndex(ctx, tableName, source.index)
+	if err != nil {
+		return tableProgress{}, err
+	}
+	if len(streams) == 0 {
+		progress.CompletedAt = time.Now()
+		return progress, nil
+	}
+
+	type chunkJob struct {
+		series *series
+		idx    int
+	}
+	var jobs []chunkJob
+	for _, s := range streams {
+		for i := range s.chunks {
+			jobs = append(jobs, chunkJob{series: s, idx: i})
+		}
+	}
+
+	var (
+		mtx     sync.Mutex
+		skipped = map[*series]map[int]struct{}{}
+	)
+	err = concurrency.ForEachJob(ctx, len(jobs), m.cfg.parallel, func(ctx context.Context, i int) error {
+		job := jobs[i]
+		found, err := m.copyChunk(ctx, source.chunks, dest.chunks, job.series.fp, &job.series.chunks[job.idx])
+		if err != nil || found {
+			return err
+		}
+
+		mtx.Lock()
+		defer mtx.Unlock()
+		if skipped[job.series] == nil {
+			skipped[job.series] = map[int]struct{}{}
+		}
+		skipped[job.series][job.idx] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return tableProgress{}, err
+	}
+
+	indexFormat, err := destPeriod.TSDBFormat()
+	if err != nil {
+		return tableProgress{}, err
+	}
+
+	builder := tsdb.NewBuilder(indexFormat)
+	for _, s := range streams {
+		chks := make([]tsdbindex.ChunkMeta, 0, len(s.chunks))
+		for i, chk := range s.chunks {
+			if _, ok := skipped[s][i]; ok {
+				progress.SkippedChunks++
+				continue
+			}
+			chks = append(chks, chk)
+		}
+		if len(chks) == 0 {
+			continue
+		}
+		progress.Chunks += len(chks)
+		builder.AddSeries(s.labels, s.fp, chks)
+	}
+
+	if progress.Chunks > 0 {
+		if err := m.buildAndUpload(ctx, builder, dest.index, progress.DestTable); err != nil {
+			return tableProgress{}, err
+		}
+	}
+
+	progress.CompletedAt = time.Now()
+	return progress, nil
+}
+
+// readSourceIndex downloads all the index files of the source tenant in the table and collects their streams.
+func (m *migrator) readSourceIndex(ctx context.Context, tableName string, indexClient shipperstorage.Client) (map[string]*series, error) {
+	indexFiles, err := indexClient.ListUserFiles(ctx, tableName, m.cfg.sourceTenant, true)
+	if err != nil {
+		return nil, err
+	}
+
+	downloadDir := filepath.Join(m.cfg.workingDir, "source", tableName)
+	if err := util.EnsureDirectory(downloadDir); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := os.RemoveAll(downloadDir); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove downloaded index files", "path", downloadDir, "err", err)
+		}
+	}()
+
+	streams := map[string]*series{}
+	for _, indexFile := range indexFiles {
+		dst := filepath.Join(downloadDir, indexFile.Name)
+		decompress := shipperstorage.IsCompressedFile(indexFile.Name)
+		if decompress {
+			dst = strings.TrimSuffix(dst, gzipExtension)
+		}
+		if err := shipperstorage.DownloadFileFromStorage(
+			dst,
+			decompress,
+			true,
+			shipperstorage.LoggerWithFilename(util_log.Logger, indexFile.Name),
+			func() (io.ReadCloser, error) {
+				return indexClient.GetUserFile(ctx, tableName, m.cfg.sourceTenant, indexFile.Name)
+			},
+		); err != nil {
+			return nil, errors.Wrapf(err, "failed to download index file %s", indexFile.Name)
+		}
+
+		idx, _, err := tsdb.NewTSDBIndexFromFile(dst)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to open index file %s", indexFile.Name)
+		}
+
+		err = idx.ForSeries(ctx, "", nil, 0, math.MaxInt64, func(lbls labels.Labels, fp model.Fingerprint, chks []tsdbindex.ChunkMeta) (stop bool) {
+			key := lbls.String()
+			s, ok := streams[key]
+			if !ok {
+				s = &series{labels: lbls.Copy(), fp: fp}
+				streams[key] = s
+			}
+			s.chunks = append(s.chunks, chks...)
+			return false
+		}, labels.MustNewMatcher(labels.MatchEqual, "", ""))
+		if closeErr := idx.Close(); closeErr != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close index file", "path", dst, "err", closeErr)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read index file %s", indexFile.Name)
+		}
+	}
+
+	// the same chunk can be referenced by several un-compacted index files of the tenant.
+	for _, s := range streams {
+		s.chunks = s.chunks.Finalize()
+	}
+
+	return streams, nil
+}
+
+// copyChunk copies a chunk from the source to the destination store and updates its checksum
+// in the index if the chunk had to be re-encoded for the destination tenant.
+// It returns false without error when the chunk is missing from the source store.
+func (m *migrator) copyChunk(ctx context.Context, source, dest client.Client, fp model.Fingerprint, meta *tsdbindex.ChunkMeta) (bool, error) {
+	ref := chunk.Chunk{
+		ChunkRef: logproto.ChunkRef{
+			Fingerprint: uint64(fp),
+			UserID:      m.cfg.sourceTenant,
+			From:        meta.From(),
+			Through:     meta.Through(),
+			Checksum:    meta.Checksum,
+		},
+	}
+
+	// decoding the fetched chunk verifies its checksum.
+	chks, err := source.GetChunks(ctx, []chunk.Chunk{ref})
+	if err != nil {
+		if source.IsChunkNotFoundErr(errors.Cause(err)) {
+			level.Warn(util_log.Logger).Log("msg", "skipping chunk missing from the source store", "chunk", m.source.schemaCfg.ExternalKey(ref.ChunkRef))
+			return false, nil
+		}
+		return false, err
+	}
+	c := chks[0]
+
+	if m.cfg.destTenant != m.cfg.sourceTenant {
+		// the tenant is part of the encoded chunk, so the chunk gets a new checksum.
+		c = chunk.NewChunk(m.cfg.destTenant, fp, c.Metric, c.Data, c.From, c.Through)
+		if err := c.Encode(); err != nil {
+			return false, errors.Wrapf(err, "failed to encode chunk for tenant %s", m.cfg.destTenant)
+		}
+	}
+
+	if err := dest.PutChunks(ctx, []chunk.Chunk{c}); err != nil {
+		return false, err
+	}
+
+	if m.cfg.verify {
+		if _, err := dest.GetChunks(ctx, []chunk.Chunk{{ChunkRef: c.ChunkRef}}); err != nil {
+			return false, errors.Wrapf(err, "failed to verify chunk %s", m.dest.schemaCfg.ExternalKey(c.ChunkRef))
+		}
+	}
+
+	meta.Checksum = c.Checksum
+	return true, nil
+}
+
+func (m *migrator) buildAndUpload(ctx context.Context, builder *tsdb.Builder, indexClient shipperstorage.Client, tableName string) error {
+	buildDir := filepath.Join(m.cfg.workingDir, "dest", tableName)
+	if err := util.EnsureDirectory(buildDir); err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(buildDir); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove built index files", "path", buildDir, "err", err)
+		}
+	}()
+
+	id, err := builder.Build(ctx, buildDir, func(from, through model.Time, checksum uint32) tsdb.Identifier {
+		id := tsdb.SingleTenantTSDBIdentifier{
+			TS:       time.Now(),
+			From:     from,
+			Through:  through,
+			Checksum: checksum,
+		}
+		return tsdb.NewPrefixedIdentifier(id, buildDir, "")
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to build index")
+	}
+
+	idx, err := tsdb.NewShippableTSDBFile(id)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := idx.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close index file", "err", err)
+		}
+	}()
+
+	return errors.Wrapf(uploadFile(idx, indexClient, tableName, m.cfg.destTenant), "failed to upload index file to table %s", tableName)
+}
+
+func (m *migrator) clientsFor(name string, store storeConfig, pCfg config.PeriodConfig) (periodClients, error) {
+	key := fmt.Sprintf("%s-%s", name, pCfg.From.String())
+	if c, ok := m.clients[key]; ok {
+		return c, nil
+	}
+
+	objectType := pCfg.ObjectType
+	if objectType == "" {
+		objectType = pCfg.IndexType
+	}
+
+	objClient, err := storage.NewObjectClient(objectType, store.storageCfg, m.clientMetrics)
+	if err != nil {
+		return periodClients{}, errors.Wrapf(err, "failed to create %s object client", name)
+	}
+	chunkClient, err := storage.NewChunkClient(objectType, store.storageCfg, store.schemaCfg, nil, prometheus.NewRegistry(), m.clientMetrics, util_log.Logger)
+	if err != nil {
+		return periodClients{}, errors.Wrapf(err, "failed to create %s chunk client", name)
+	}
+
+	c := periodClients{
+		chunks: chunkClient,
+		index:  shipperstorage.NewIndexStorageClient(objClient, pCfg.IndexTables.PathPrefix),
+	}
+	m.clients[key] = c
+	return c, nil
+}
+
+// tableBounds returns the time range covered by a daily index table.
+func tableBounds(tableName string, pCfg config.PeriodConfig) (model.Time, model.Time, error) {
+	tableNum, err := config.ExtractTableNumberFromName(tableName)
+	if err != nil {
+		return 0, 0, err
+	}
+	period := int64(pCfg.IndexTables.Period / time.Millisecond)
+	start := model.Time(tableNum * period)
+	return start, start + model.Time(period) - 1, nil
+}
+
+func uploadFile(idx shipperindex.Index, indexStorageClient shipperstorage.Client, tableName, tenant string) error {
+	fileName := idx.Name()
+	level.Debug(util_log.Logger).Log("msg", fmt.Sprintf("uploading index %s", fileName))
+
+	filePath := fmt.Sprintf("%s%s", idx.Path(), tempFileSuffix)
+	f, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := f.Close(); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to close temp file", "path", filePath, "err", err)
+		}
+
+		if err := os.Remove(filePath); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to remove temp file", "path", filePath, "err", err)
+		}
+	}()
+
+	gzipPool := compression.GetWriterPool(compression.EncGZIP)
+	compressedWriter := gzipPool.GetWriter(f)
+	defer gzipPool.PutWriter(compressedWriter)
+
+	idxReader, err := idx.Reader()
+	if err != nil {
+		return err
+	}
+
+	if _, err := idxReader.Seek(0, 0); err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(compressedWriter, idxReader); err != nil {
+		return err
+	}
+
+	if err := compressedWriter.Close(); err != nil {
+		return err
+	}
+
+	// flush the file to disk and seek the file to the beginning.
+	if err := f.Sync(); err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	return indexStorageClient.PutUserFile(context.Background(), tableName, tenant, fmt.Sprintf("%s%s", fileName, gzipExtension), f)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	ingesterclient "github.com/grafana/loki/v3/pkg/ingester/client"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	shipperstorage "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+const (
+	sourceTenant = "tenant-a"
+	destTenant   = "tenant-b"
+)
+
+func testStoreConfig(dir, tablePrefix string, from model.Time) storeConfig {
+	return storeConfig{
+		schemaCfg: config.SchemaConfig{
+			Configs: []config.PeriodConfig{{
+				From:       config.DayTime{Time: from},
+				IndexType:  "tsdb",
+				ObjectType: "filesystem",
+				Schema:     "v13",
+				IndexTables: config.IndexPeriodicTableConfig{
+					PathPrefix: "index/",
+					PeriodicTableConfig: config.PeriodicTableConfig{
+						Prefix: tablePrefix,
+						Period: 24 * time.Hour,
+					}},
+			}},
+		},
+		storageCfg: storage.Config{
+			FSConfig: local.FSConfig{Directory: dir},
+		},
+	}
+}
+
+func createChunk(t *testing.T, lbs labels.Labels, from, through model.Time) chunk.Chunk {
+	t.Helper()
+	chunkEnc := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.EncSnappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 1500*1024)
+	for ts := from; !ts.After(through); ts = ts.Add(time.Minute) {
+		dup, err := chunkEnc.Append(&logproto.Entry{Timestamp: ts.Time(), Line: ts.String()})
+		require.False(t, dup)
+		require.NoError(t, err)
+	}
+	require.NoError(t, chunkEnc.Close())
+
+	c := chunk.NewChunk(sourceTenant, ingesterclient.Fingerprint(lbs), lbs, chunkenc.NewFacade(chunkEnc, 0, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+// setupSource writes a chunk per table to the source store and indexes them in a tsdb file per table.
+func setupSource(t *testing.T, m *migrator, lbs labels.Labels, tableStarts []model.Time) []chunk.Chunk {
+	t.Helper()
+	ctx := context.Background()
+	pCfg := m.source.schemaCfg.Configs[0]
+	clients, err := m.clientsFor("source", m.source, pCfg)
+	require.NoError(t, err)
+
+	var chunks []chunk.Chunk
+	for _, start := range tableStarts {
+		c := createChunk(t, lbs, start.Add(time.Hour), start.Add(2*time.Hour))
+		require.NoError(t, clients.chunks.PutChunks(ctx, []chunk.Chunk{c}))
+		chunks = append(chunks, c)
+
+		b := tsdb.NewBuilder(index.FormatV3)
+		b.AddSeries(lbs, model.Fingerprint(c.Fingerprint), []index.ChunkMeta{{
+			Checksum: c.Checksum,
+			MinTime:  int64(c.From),
+			MaxTime:  int64(c.Through),
+			KB:       1,
+			Entries:  61,
+		}})
+		buildDir := t.TempDir()
+		id, err := b.Build(ctx, buildDir, func(from, through model.Time, checksum uint32) tsdb.Identifier {
+			id := tsdb.SingleTenantTSDBIdentifier{TS: time.Now(), From: from, Through: through, Checksum: checksum}
+			return tsdb.NewPrefixedIdentifier(id, buildDir, "")
+		})
+		require.NoError(t, err)
+		idx, err := tsdb.NewShippableTSDBFile(id)
+		require.NoError(t, err)
+		require.NoError(t, uploadFile(idx, clients.index, pCfg.IndexTables.TableFor(start), sourceTenant))
+		require.NoError(t, idx.Close())
+	}
+	return chunks
+}
+
+func readDestIndex(t *testing.T, indexClient shipperstorage.Client, tableName string) map[uint64][]index.ChunkMeta {
+	t.Helper()
+	files, err := indexClient.ListUserFiles(context.Background(), tableName, destTenant, true)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	dst := filepath.Join(t.TempDir(), strings.TrimSuffix(files[0].Name, gzipExtension))
+	require.NoError(t, shipperstorage.DownloadFileFromStorage(
+		dst,
+		true,
+		true,
+		shipperstorage.LoggerWithFilename(util_log.Logger, files[0].Name),
+		func() (io.ReadCloser, error) {
+			return indexClient.GetUserFile(context.Background(), tableName, destTenant, files[0].Name)
+		},
+	))
+
+	idx, _, err := tsdb.NewTSDBIndexFromFile(dst)
+	require.NoError(t, err)
+	defer idx.Close()
+
+	res := map[uint64][]index.ChunkMeta{}
+	require.NoError(t, idx.ForSeries(context.Background(), "", nil, 0, math.MaxInt64, func(_ labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) (stop bool) {
+		res[uint64(fp)] = append(res[uint64(fp)], chks...)
+		return false
+	}, labels.MustNewMatcher(labels.MatchEqual, "", "")))
+	return res
+}
+
+func TestMigrator(t *testing.T) {
+	tempDir := t.TempDir()
+	now := model.Now()
+	periodStart := now.Add(-10 * 24 * time.Hour)
+
+	// the destination cluster uses a different table prefix to exercise the table name rewriting.
+	source := testStoreConfig(filepath.Join(tempDir, "source"), "source_", periodStart)
+	dest := testStoreConfig(filepath.Join(tempDir, "dest"), "dest_", periodStart)
+	cfg := migrateConfig{
+		sourceTenant: sourceTenant,
+		destTenant:   destTenant,
+		parallel:     2,
+		verify:       true,
+		workingDir:   filepath.Join(tempDir, "working-dir"),
+	}
+	checkpointPath := filepath.Join(tempDir, "checkpoint.json")
+	clientMetrics := storage.NewClientMetrics()
+	defer clientMetrics.Unregister()
+
+	cp, err := loadCheckpoint(checkpointPath, sourceTenant, destTenant)
+	require.NoError(t, err)
+	m := newMigrator(cfg, source, dest, cp, clientMetrics)
+	defer m.stop()
+
+	lbs := labels.FromStrings("app", "foo")
+	day := model.Time((24 * time.Hour).Milliseconds())
+	today := now - now%day
+	sourceChunks := setupSource(t, m, lbs, []model.Time{today - 2*day, today - day})
+
+	require.NoError(t, m.run(context.Background()))
+
+	destClients, err := m.clientsFor("dest", dest, dest.schemaCfg.Configs[0])
+	require.NoError(t, err)
+	for _, sourceChunk := range sourceChunks {
+		tableName := dest.schemaCfg.Configs[0].IndexTables.TableFor(sourceChunk.From)
+		metas := readDestIndex(t, destClients.index, tableName)
+		require.Len(t, metas[sourceChunk.Fingerprint], 1)
+		meta := metas[sourceChunk.Fingerprint][0]
+		require.NotEqual(t, sourceChunk.Checksum, meta.Checksum)
+
+		// the chunk referenced by the index must be stored and encoded for the destination tenant.
+		chks, err := destClients.chunks.GetChunks(context.Background(), []chunk.Chunk{{ChunkRef: logproto.ChunkRef{
+			Fingerprint: sourceChunk.Fingerprint,
+			UserID:      destTenant,
+			From:        meta.From(),
+			Through:     meta.Through(),
+			Checksum:    meta.Checksum,
+		}}})
+		require.NoError(t, err)
+		require.Equal(t, lbs, chks[0].Metric)
+		require.Equal(t, sourceChunk.Data.Entries(), chks[0].Data.Entries())
+	}
+
+	loaded, err := loadCheckpoint(checkpointPath, sourceTenant, destTenant)
+	require.NoError(t, err)
+	require.Len(t, loaded.Tables, 2)
+	for _, progress := range loaded.Tables {
+		require.Equal(t, 1, progress.Chunks)
+		require.Equal(t, 0, progress.SkippedChunks)
+		require.True(t, strings.HasPrefix(progress.DestTable, "dest_"))
+	}
+
+	_, err = loadCheckpoint(checkpointPath, sourceTenant, "other")
+	require.Error(t, err)
+
+	// resuming from the checkpoint skips the tables which were already migrated.
+	require.NoError(t, os.RemoveAll(filepath.Join(tempDir, "dest")))
+	m2 := newMigrator(cfg, source, dest, loaded, clientMetrics)
+	defer m2.stop()
+	require.NoError(t, m2.run(context.Background()))
+
+	tables, err := destClients.index.ListTables(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, tables)
+}
+
+func TestMigrator_UncompactedTable(t *testing.T) {
+	tempDir := t.TempDir()
+	now := model.Now()
+	periodStart := now.Add(-10 * 24 * time.Hour)
+
+	source := testStoreConfig(filepath.Join(tempDir, "source"), "source_", periodStart)
+	dest := testStoreConfig(filepath.Join(tempDir, "dest"), "dest_", periodStart)
+	cfg := migrateConfig{
+		sourceTenant: sourceTenant,
+		destTenant:   destTenant,
+		parallel:     1,
+		workingDir:   filepath.Join(tempDir, "working-dir"),
+	}
+	checkpointPath := filepath.Join(tempDir, "checkpoint.json")
+	clientMetrics := storage.NewClientMetrics()
+	defer clientMetrics.Unregister()
+
+	cp, err := loadCheckpoint(checkpointPath, sourceTenant, destTenant)
+	require.NoError(t, err)
+	m := newMigrator(cfg, source, dest, cp, clientMetrics)
+	defer m.stop()
+
+	day := model.Time((24 * time.Hour).Milliseconds())
+	today := now - now%day
+	setupSource(t, m, labels.FromStrings("app", "foo"), []model.Time{today - 2*day, today - day})
+
+	// a multi-tenant file not processed by the compactor yet.
+	uncompactedTable := source.schemaCfg.Configs[0].IndexTables.TableFor(today - day)
+	tableDir := filepath.Join(tempDir, "source", "index", uncompactedTable)
+	require.NoError(t, os.WriteFile(filepath.Join(tableDir, "1700000000-ingester-1-1700000000.tsdb.gz"), []byte("index"), 0o640))
+
+	err = m.run(context.Background())
+	require.EqualError(t, err, "tables "+uncompactedTable+" have un-compacted multi-tenant index files and were not migrated, run the compactor first and resume the migration")
+
+	// the other tables are migrated, the un-compacted one is left out of the checkpoint to be migrated later.
+	loaded, err := loadCheckpoint(checkpointPath, sourceTenant, destTenant)
+	require.NoError(t, err)
+	require.Len(t, loaded.Tables, 1)
+	require.True(t, loaded.done(source.schemaCfg.Configs[0].IndexTables.TableFor(today-2*day)))
+	require.False(t, loaded.done(uncompactedTable))
+}